@@ -75,6 +75,9 @@ func (h *BFDHandler) convertBfdSessionProtocolsToString(Protocols []bool) string
 	if Protocols[bfddCommonDefs.OSPF] {
 		protocols += "ospf, "
 	}
+	if Protocols[bfddCommonDefs.RIBD] {
+		protocols += "ribd, "
+	}
 	return protocols
 }
 
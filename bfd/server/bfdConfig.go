@@ -48,6 +48,7 @@ type SessionConfig struct {
 	ParamName string
 	Interface string
 	PerLink   bool
+	MultiHop  bool
 	Protocol  bfddCommonDefs.BfdSessionOwner
 	Operation bfddCommonDefs.BfdSessionOperation
 }
@@ -60,6 +61,7 @@ type SessionState struct {
 	Interface                 string
 	InterfaceSpecific         bool
 	PerLinkSession            bool
+	MultiHop                  bool
 	LocalMacAddr              net.HardwareAddr
 	RemoteMacAddr             net.HardwareAddr
 	RegisteredProtocols       []bool
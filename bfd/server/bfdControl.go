@@ -136,6 +136,7 @@ const (
 	DEFAULT_REQUIRED_MIN_ECHO_RX_INTERVAL = 0
 	DEFAULT_CONTROL_PACKET_LEN            = 24
 	DEST_PORT                             = 3784
+	DEST_PORT_MULTIHOP                    = 4784
 	SRC_PORT                              = 49152
 	DEST_PORT_LAG                         = 6784
 	SRC_PORT_LAG                          = 49153
@@ -26,6 +26,7 @@ package server
 import (
 	"encoding/json"
 	nanomsg "github.com/op/go-nanomsg"
+	"l3/bfd/bfddCommonDefs"
 	"l3/rib/ribdCommonDefs"
 )
 
@@ -57,7 +58,7 @@ func (server *BFDServer) listenForRIBdUpdates(address string) error {
 		return err
 	}
 
-	if err = server.ribdSubSocket.Subscribe(""); err != nil {
+	if err = server.ribdSubSocket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
 		server.logger.Err("Failed to subscribe to \"\" on RIBd subscribe socket, error:", err)
 		return err
 	}
@@ -95,6 +96,29 @@ func (server *BFDServer) processRibdNotification(rxBuf []byte) error {
 			server.HandleNextHopChange(msgInfo.Network, 0, false)
 		}
 		break
+	case ribdCommonDefs.NOTIFY_BFD_SESSION_CREATE, ribdCommonDefs.NOTIFY_BFD_SESSION_DELETE:
+		var msgInfo ribdCommonDefs.BfdSessionMsgInfo
+		err = json.Unmarshal(msg.MsgBuf, &msgInfo)
+		if err != nil {
+			server.logger.Err("Unable to unmarshal msg:", msg.MsgBuf)
+			return err
+		}
+		server.logger.Info("Received BFD session request from RIBd for ", msgInfo.DestIp, " msgType ", msg.MsgType)
+		sessionConfig := SessionConfig{
+			DestIp:    msgInfo.DestIp,
+			ParamName: "default",
+			Interface: msgInfo.Interface,
+			PerLink:   false,
+			MultiHop:  msgInfo.MultiHop,
+			Protocol:  bfddCommonDefs.RIBD,
+			Operation: bfddCommonDefs.CREATE,
+		}
+		if msg.MsgType == ribdCommonDefs.NOTIFY_BFD_SESSION_DELETE {
+			sessionConfig.Operation = bfddCommonDefs.DELETE
+		}
+		//processed here rather than queued on SessionConfigCh, which is served by this same loop
+		server.processSessionConfig(sessionConfig)
+		break
 	default:
 		break
 	}
@@ -55,7 +55,11 @@ func (session *BfdSession) StartSessionServer() error {
 func (session *BfdSession) StartSessionClient(server *BFDServer) error {
 	var err error
 	server.logger.Info("Starting session client for ", session.state.SessionId)
-	destAddr := net.JoinHostPort(session.state.IpAddr, strconv.Itoa(DEST_PORT))
+	destPort := DEST_PORT
+	if session.state.MultiHop {
+		destPort = DEST_PORT_MULTIHOP
+	}
+	destAddr := net.JoinHostPort(session.state.IpAddr, strconv.Itoa(destPort))
 	ServerAddr, err := net.ResolveUDPAddr("udp", destAddr)
 	if err != nil {
 		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
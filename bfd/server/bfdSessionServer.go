@@ -50,7 +50,8 @@ func (server *BFDServer) StartSessionHandler() error {
 	server.CreatedSessionCh = make(chan int32, MAX_NUM_SESSIONS)
 	server.FailedSessionClientCh = make(chan int32, MAX_NUM_SESSIONS)
 	server.tobeCreatedSessions = make(map[string]BfdSessionMgmt)
-	go server.StartBfdSesionServer()
+	go server.StartBfdSesionServer(DEST_PORT)
+	go server.StartBfdSesionServer(DEST_PORT_MULTIHOP)
 	go server.StartBfdSessionRxTx()
 	go server.StartSessionRetryHandler()
 	for {
@@ -101,11 +102,11 @@ func (server *BFDServer) DispatchReceivedBfdPacket(ipAddr string, bfdPacket *Bfd
 	return nil
 }
 
-func (server *BFDServer) StartBfdSesionServer() error {
+func (server *BFDServer) StartBfdSesionServer(port int) error {
 	var ipAddr string
 	var bfdPacket *BfdControlPacket
 	var err error
-	destAddr := net.JoinHostPort("", strconv.Itoa(DEST_PORT))
+	destAddr := net.JoinHostPort("", strconv.Itoa(port))
 	ServerAddr, err := net.ResolveUDPAddr("udp", destAddr)
 	if err != nil {
 		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
@@ -212,6 +213,7 @@ func (server *BFDServer) processSessionConfig(sessionConfig SessionConfig) error
 		Interface: sessionConfig.Interface,
 		Protocol:  sessionConfig.Protocol,
 		PerLink:   sessionConfig.PerLink,
+		MultiHop:  sessionConfig.MultiHop,
 	}
 	switch sessionConfig.Operation {
 	case bfddCommonDefs.CREATE:
@@ -293,7 +295,7 @@ func (server *BFDServer) GetTxJitter() int32 {
 	return jitter
 }
 
-func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, DestIp string, ParamName string, PerLink bool, Protocol bfddCommonDefs.BfdSessionOwner) *BfdSession {
+func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, DestIp string, ParamName string, PerLink bool, MultiHop bool, Protocol bfddCommonDefs.BfdSessionOwner) *BfdSession {
 	bfdSession := &BfdSession{}
 	sessionId := server.GetNewSessionId()
 	if sessionId == 0 {
@@ -305,6 +307,7 @@ func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, D
 	bfdSession.state.LocalAddr = LocalIp
 	bfdSession.state.Interface = Interface
 	bfdSession.state.PerLinkSession = PerLink
+	bfdSession.state.MultiHop = MultiHop
 	if PerLink {
 		bfdSession.state.LocalMacAddr, _ = server.getMacAddrFromIntfName(Interface)
 		bfdSession.state.RemoteMacAddr, _ = net.ParseMAC(bfdDedicatedMac)
@@ -354,7 +357,7 @@ func (server *BFDServer) NewPerLinkBfdSessions(IfIndex int32, LocalIp string, De
 	if exist {
 		for _, link := range lag.Links {
 			IfName, _ := server.getLinuxIntfName(IfIndex)
-			bfdSession := server.NewNormalBfdSession(IfName, LocalIp, DestIp, ParamName, true, Protocol)
+			bfdSession := server.NewNormalBfdSession(IfName, LocalIp, DestIp, ParamName, true, false, Protocol)
 			if bfdSession == nil {
 				server.logger.Info("Failed to create perlink session on ", link)
 			}
@@ -365,7 +368,7 @@ func (server *BFDServer) NewPerLinkBfdSessions(IfIndex int32, LocalIp string, De
 	return nil
 }
 
-func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interface string, Protocol bfddCommonDefs.BfdSessionOwner, PerLink bool) *BfdSession {
+func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interface string, Protocol bfddCommonDefs.BfdSessionOwner, PerLink bool, MultiHop bool) *BfdSession {
 	var IfType int
 	var interfaceSpecific bool
 	if Interface != "None" && Interface != "" {
@@ -388,7 +391,7 @@ func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interfac
 	if IfType == commonDefs.IfTypeLag && PerLink {
 		server.NewPerLinkBfdSessions(IfIndex, localIp, DestIp, ParamName, Protocol)
 	} else {
-		bfdSession := server.NewNormalBfdSession(Interface, localIp, DestIp, ParamName, false, Protocol)
+		bfdSession := server.NewNormalBfdSession(Interface, localIp, DestIp, ParamName, false, MultiHop, Protocol)
 		bfdSession.state.InterfaceSpecific = interfaceSpecific
 		return bfdSession
 	}
@@ -474,6 +477,7 @@ func (server *BFDServer) CreateBfdSession(sessionMgmt BfdSessionMgmt) (*BfdSessi
 	Interface := sessionMgmt.Interface
 	Protocol := sessionMgmt.Protocol
 	PerLink := sessionMgmt.PerLink
+	MultiHop := sessionMgmt.MultiHop
 	sessionIp := DestIp
 	if Interface != "" {
 		ipAddr := net.ParseIP(DestIp)
@@ -486,7 +490,7 @@ func (server *BFDServer) CreateBfdSession(sessionMgmt BfdSessionMgmt) (*BfdSessi
 	sessionId, found := server.FindBfdSession(sessionIp)
 	if !found {
 		server.logger.Info("CreateSession ", sessionIp, ParamName, Interface, Protocol, PerLink)
-		bfdSession = server.NewBfdSession(sessionIp, ParamName, Interface, Protocol, PerLink)
+		bfdSession = server.NewBfdSession(sessionIp, ParamName, Interface, Protocol, PerLink, MultiHop)
 		if bfdSession != nil {
 			server.logger.Info("Bfd session created ", bfdSession.state.SessionId, bfdSession.state.IpAddr)
 		} else {
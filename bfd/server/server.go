@@ -68,6 +68,7 @@ type BfdSessionMgmt struct {
 	Interface string
 	Protocol  bfddCommonDefs.BfdSessionOwner
 	PerLink   bool
+	MultiHop  bool
 	ForceDel  bool
 }
 
@@ -30,6 +30,7 @@ import (
 	"l3/bgp/packet"
 	"models/events"
 	"net"
+	"sync"
 	"time"
 	"utils/eventUtils"
 	"utils/logging"
@@ -48,6 +49,7 @@ type NeighborConf struct {
 	AfiSafiMap           map[uint32]bool
 	MaxPrefixesThreshold uint32
 	ignoreBfdFaultsTimer *time.Timer
+	historyMutex         sync.RWMutex
 }
 
 func NewNeighborConf(logger *logging.Writer, globalConf *config.GlobalConfig, peerGroup *config.PeerGroupConfig,
@@ -69,10 +71,29 @@ func NewNeighborConf(logger *logging.Writer, globalConf *config.GlobalConfig, pe
 	conf.SetRunningConf(peerGroup, &conf.RunningConf)
 	conf.SetNeighborState(&conf.RunningConf)
 	conf.setOtherStates()
-	conf.AfiSafiMap, _ = packet.GetProtocolFromConfig(&conf.Neighbor.AfiSafis, conf.Neighbor.NeighborAddress)
+	conf.setAfiSafiMap()
 	return &conf
 }
 
+/*  setAfiSafiMap sets the address families advertised in the OPEN message.
+ *  Labeled unicast and multicast add the labeled and multicast families for
+ *  each unicast family.
+ */
+func (n *NeighborConf) setAfiSafiMap() {
+	n.AfiSafiMap, _ = packet.GetProtocolFromConfig(&n.Neighbor.AfiSafis, n.Neighbor.NeighborAddress)
+	for protoFamily, _ := range n.AfiSafiMap {
+		if _, safi := packet.GetAfiSafi(protoFamily); safi != packet.SafiUnicast {
+			continue
+		}
+		if n.RunningConf.LabeledUnicast {
+			n.AfiSafiMap[packet.GetLabeledFamily(protoFamily)] = true
+		}
+		if n.RunningConf.Multicast {
+			n.AfiSafiMap[packet.GetMulticastFamily(protoFamily)] = true
+		}
+	}
+}
+
 func (n *NeighborConf) SetNeighborAddress(ip net.IP) {
 	n.Neighbor.NeighborAddress = ip
 	n.Neighbor.Config.NeighborAddress = ip
@@ -88,6 +109,9 @@ func (n *NeighborConf) ResetNeighborAddress() {
 }
 
 func (n *NeighborConf) SetNeighborState(peerConf *config.NeighborConfig) {
+	n.historyMutex.Lock()
+	defer n.historyMutex.Unlock()
+	history := n.Neighbor.State.History
 	n.Neighbor.State = config.NeighborState{
 		Disabled:                peerConf.Disabled,
 		PeerAS:                  peerConf.PeerAS,
@@ -115,6 +139,7 @@ func (n *NeighborConf) SetNeighborState(peerConf *config.NeighborConfig) {
 		TotalPrefixes:           0,
 		AdjRIBInFilter:          peerConf.AdjRIBInFilter,
 		AdjRIBOutFilter:         peerConf.AdjRIBOutFilter,
+		History:                 history,
 	}
 	n.MaxPrefixesThreshold = uint32(float64(peerConf.MaxPrefixes*uint32(peerConf.MaxPrefixesThresholdPct)) / 100)
 }
@@ -156,6 +181,7 @@ func (n *NeighborConf) UpdateNeighborConf(nConf config.NeighborConfig, bgp *conf
 	n.GetConfFromNeighbor(&n.Neighbor.Config, &n.RunningConf)
 	n.logger.Infof("UpdateNeighborConf - running conf=%+v", n.Neighbor.Config)
 	n.SetNeighborState(&n.RunningConf)
+	n.setAfiSafiMap()
 	n.logger.Infof("UpdateNeighborConf - neigh state=%+v", n.Neighbor.State)
 }
 
@@ -166,6 +192,7 @@ func (n *NeighborConf) UpdateGlobal(gConf *config.GlobalConfig) {
 	n.SetRunningConf(n.Group, &n.RunningConf)
 	n.SetNeighborState(&n.RunningConf)
 	n.setOtherStates()
+	n.setAfiSafiMap()
 }
 
 func (n *NeighborConf) UpdatePeerGroup(peerGroup *config.PeerGroupConfig) {
@@ -174,6 +201,7 @@ func (n *NeighborConf) UpdatePeerGroup(peerGroup *config.PeerGroupConfig) {
 	n.RunningConf = config.NeighborConfig{}
 	n.SetRunningConf(peerGroup, &n.RunningConf)
 	n.SetNeighborState(&n.RunningConf)
+	n.setAfiSafiMap()
 }
 
 func (n *NeighborConf) SetRunningConf(peerGroup *config.PeerGroupConfig, peerConf *config.NeighborConfig) {
@@ -289,6 +317,14 @@ func (n *NeighborConf) GetConfFromNeighbor(inConf *config.NeighborConfig, outCon
 		outConf.AdjRIBOutFilter = inConf.AdjRIBOutFilter
 	}
 
+	if inConf.LabeledUnicast != false {
+		outConf.LabeledUnicast = inConf.LabeledUnicast
+	}
+
+	if inConf.Multicast != false {
+		outConf.Multicast = inConf.Multicast
+	}
+
 	n.setDefaults(outConf)
 	outConf.PeerAddressType = inConf.PeerAddressType
 	outConf.NeighborAddress = inConf.NeighborAddress
@@ -375,11 +411,88 @@ func (n *NeighborConf) PublishEvents(stateId uint32) {
 	}
 }
 
-func (n *NeighborConf) FSMStateChange(state uint32) {
+func (n *NeighborConf) FSMStateChange(state uint32, event string) {
 	n.logger.Infof("Neighbor %s: FSMStateChange %d", n.Neighbor.NeighborAddress, state)
 	n.PublishEvents(state)
+	now := time.Now()
+	n.recordFSMTransition(config.BGPFSMState(n.Neighbor.State.SessionState), config.BGPFSMState(state), event, now)
 	n.Neighbor.State.SessionState = uint32(state)
-	n.Neighbor.State.SessionStateUpdatedTime = time.Now()
+	n.Neighbor.State.SessionStateUpdatedTime = now
+}
+
+/*  recordFSMTransition appends the transition to the bounded FSM history of the
+ *  neighbor and keeps track of the flap count and the time the session was
+ *  established.
+ */
+func (n *NeighborConf) recordFSMTransition(oldState, newState config.BGPFSMState, event string, now time.Time) {
+	if oldState == newState {
+		return
+	}
+
+	n.historyMutex.Lock()
+	defer n.historyMutex.Unlock()
+	history := &n.Neighbor.State.History
+	if len(history.Transitions) >= config.BGPFSMHistorySize {
+		history.Transitions = append(history.Transitions[:0],
+			history.Transitions[len(history.Transitions)-config.BGPFSMHistorySize+1:]...)
+	}
+	history.Transitions = append(history.Transitions, config.FSMTransition{
+		Time:      now,
+		FromState: oldState,
+		ToState:   newState,
+		Event:     event,
+	})
+
+	if newState == config.BGPFSMEstablished {
+		history.EstablishedTime = now
+	} else if oldState == config.BGPFSMEstablished {
+		history.FlapCount++
+		history.EstablishedTime = time.Time{}
+	}
+}
+
+func (n *NeighborConf) NotificationSent(code uint8, subCode uint8, data []byte) {
+	n.historyMutex.Lock()
+	defer n.historyMutex.Unlock()
+	n.Neighbor.State.History.LastNotificationSent = config.NotificationInfo{
+		Time:         time.Now(),
+		ErrorCode:    code,
+		ErrorSubcode: subCode,
+		Data:         data,
+	}
+}
+
+func (n *NeighborConf) NotificationReceived(code uint8, subCode uint8, data []byte) {
+	n.historyMutex.Lock()
+	defer n.historyMutex.Unlock()
+	n.Neighbor.State.History.LastNotificationRcvd = config.NotificationInfo{
+		Time:         time.Now(),
+		ErrorCode:    code,
+		ErrorSubcode: subCode,
+		Data:         data,
+	}
+}
+
+func (n *NeighborConf) TcpConnFailed(err error) {
+	n.historyMutex.Lock()
+	defer n.historyMutex.Unlock()
+	n.Neighbor.State.History.LastTcpError = err.Error()
+	n.Neighbor.State.History.LastTcpErrorTime = time.Now()
+}
+
+/*  GetStateSnapshot returns a copy of the neighbor state. The history is
+ *  copied under the history lock, so the caller can walk it while the FSM
+ *  keeps recording transitions.
+ */
+func (n *NeighborConf) GetStateSnapshot() *config.NeighborState {
+	n.historyMutex.RLock()
+	defer n.historyMutex.RUnlock()
+	state := n.Neighbor.State
+	history := &state.History
+	history.Transitions = append([]config.FSMTransition(nil), history.Transitions...)
+	history.LastNotificationSent.Data = append([]byte(nil), history.LastNotificationSent.Data...)
+	history.LastNotificationRcvd.Data = append([]byte(nil), history.LastNotificationRcvd.Data...)
+	return &state
 }
 
 func (n *NeighborConf) SetPeerAttrs(bgpId net.IP, asSize uint8, holdTime uint32, keepaliveTime uint32,
@@ -120,6 +120,8 @@ type BaseConfig struct {
 	MaxPrefixesRestartTimer uint8
 	AdjRIBInFilter          string
 	AdjRIBOutFilter         string
+	LabeledUnicast          bool
+	Multicast               bool
 }
 
 type NeighborConfig struct {
@@ -131,6 +133,30 @@ type NeighborConfig struct {
 	Disabled        bool
 }
 
+type FSMTransition struct {
+	Time      time.Time
+	FromState BGPFSMState
+	ToState   BGPFSMState
+	Event     string
+}
+
+type NotificationInfo struct {
+	Time         time.Time
+	ErrorCode    uint8
+	ErrorSubcode uint8
+	Data         []byte
+}
+
+type NeighborHistory struct {
+	Transitions          []FSMTransition
+	LastNotificationSent NotificationInfo
+	LastNotificationRcvd NotificationInfo
+	LastTcpError         string
+	LastTcpErrorTime     time.Time
+	FlapCount            uint32
+	EstablishedTime      time.Time
+}
+
 type NeighborState struct {
 	NeighborAddress         net.IP
 	IfIndex                 int32
@@ -165,6 +191,7 @@ type NeighborState struct {
 	AdjRIBInFilter          string
 	AdjRIBOutFilter         string
 	SessionStateUpdatedTime time.Time
+	History                 NeighborHistory
 }
 
 type TransportConfig struct {
@@ -302,4 +329,12 @@ type RouteConfig struct {
 	OutgoingInterface string
 	IsIPv6            bool
 	NullRoute         bool
+	// Labeled unicast routes go to the labeled route table in ribd, not to the
+	// unicast RIB. InLabel is the local label advertised for the route and
+	// OutLabels is the label stack received from the next hop.
+	Labeled   bool
+	InLabel   uint32
+	OutLabels []uint32
+	// Multicast SAFI routes go to the RPF table in ribd, not to the FIB.
+	Multicast bool
 }
@@ -102,6 +102,7 @@ type RouteInfo struct {
 	NetworkStatement bool
 	RouteOrigin      string
 	AddressType      ribdCommonDefs.IPType
+	RouteTag         uint32
 }
 
 type RouteCh struct {
@@ -26,6 +26,7 @@ package config
 
 const BGPConnectRetryTime uint32 = 120 // seconds
 const BGPHoldTimeDefault uint32 = 180  // 180 seconds
+const BGPFSMHistorySize int = 32       // FSM transitions kept per neighbor
 
 type BGPFSMState int
 
@@ -54,6 +54,7 @@ type RouteMgrIntf interface {
 	UpdateRoute(cfg *RouteConfig, op string)
 	ApplyPolicy(applyList []*ApplyPolicyInfo, undoList []*ApplyPolicyInfo)
 	GetRoutes() ([]*RouteInfo, []*RouteInfo)
+	RoutesEndOfRIB()
 }
 
 /*  Interface for handling policy related operations
@@ -104,6 +104,7 @@ func convertModelsToPolicyConditionConfig(cfg *objects.PolicyCondition) *utilspo
 		MatchASPathConditionInfo:      utilspolicy.PolicyMatchASPathSetCondition{cfg.ASPath, cfg.ASPathSet},
 		MatchLocalPrefConditionInfo:   cfg.LocalPref,
 		MatchMEDConditionInfo:         cfg.MED,
+		MatchRouteTagConditionInfo:    cfg.RouteTag,
 		MatchExtendedCommunityConditionInfo: utilspolicy.PolicyMatchExtendedCommunitySetCondition{
 			ExtendedCommunity: matchExtendedCommunityInfo, ExtendedCommunitySet: cfg.ExtendedCommunitySet},
 	}
@@ -163,6 +164,7 @@ func convertModelsToPolicyStmtConfig(cfg *objects.PolicyStmt) *utilspolicy.Polic
 			LocalPref:         setAction.LocalPref,
 			MED:               setAction.MED,
 			PrependASPath:     setAction.PrependASPath,
+			RouteTag:          setAction.RouteTag,
 		})
 	}
 	return &utilspolicy.PolicyStmtConfig{
@@ -81,7 +81,7 @@ func (mgr *FSRouteMgr) setupSubSocket(address string) (*nanomsg.SubSocket, error
 		return nil, err
 	}
 
-	if err = socket.Subscribe(""); err != nil {
+	if err = socket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
 		mgr.logger.Errf("Failed to subscribe to \"\" on subscribe socket %s, error:%s", address, err)
 		return nil, err
 	}
@@ -121,6 +121,7 @@ func (mgr *FSRouteMgr) populateConfigRoute(route *ribdInt.Routes) *config.RouteI
 		NetworkStatement: route.NetworkStatement,
 		RouteOrigin:      route.RouteOrigin,
 		AddressType:      ribdCommonDefs.IPType(route.IPAddrType),
+		RouteTag:         uint32(route.RouteTag),
 	}
 	return rv
 }
@@ -211,8 +212,58 @@ func (mgr *FSRouteMgr) createRibdIPv6RouteCfg(cfg *config.RouteConfig, create bo
 	return &rCfg
 }
 
+/*  createRibdRPFRouteCfg converts a multicast SAFI route to the RPF route of
+ *  ribd. The next hop is left out when the whole route is deleted.
+ */
+func (mgr *FSRouteMgr) createRibdRPFRouteCfg(cfg *config.RouteConfig, withNextHop bool) *ribdInt.RPFRoute {
+	rpfRoute := &ribdInt.RPFRoute{
+		Cost:          cfg.Cost,
+		Protocol:      cfg.Protocol,
+		NetworkMask:   cfg.NetworkMask,
+		DestinationNw: cfg.DestinationNw,
+		NextHop:       make([]*ribdInt.RouteNextHopInfo, 0),
+	}
+	if withNextHop {
+		rpfRoute.NextHop = append(rpfRoute.NextHop, &ribdInt.RouteNextHopInfo{
+			NextHopIp:     cfg.NextHopIp,
+			NextHopIntRef: cfg.OutgoingInterface,
+		})
+	}
+	return rpfRoute
+}
+
+/*  createRibdLabeledRouteCfg converts a labeled unicast route to the labeled
+ *  route of ribd. The next hop is left out when the whole route is deleted.
+ */
+func (mgr *FSRouteMgr) createRibdLabeledRouteCfg(cfg *config.RouteConfig, withNextHop bool) *ribdInt.LabeledRoute {
+	labeledRoute := &ribdInt.LabeledRoute{
+		Cost:          cfg.Cost,
+		Protocol:      cfg.Protocol,
+		NetworkMask:   cfg.NetworkMask,
+		DestinationNw: cfg.DestinationNw,
+		InLabel:       int32(cfg.InLabel),
+		NextHop:       make([]*ribdInt.LabeledNextHopInfo, 0),
+	}
+	if withNextHop {
+		outLabels := make([]int32, 0, len(cfg.OutLabels))
+		for _, label := range cfg.OutLabels {
+			outLabels = append(outLabels, int32(label))
+		}
+		labeledRoute.NextHop = append(labeledRoute.NextHop, &ribdInt.LabeledNextHopInfo{
+			NextHopIp:     cfg.NextHopIp,
+			NextHopIntRef: cfg.OutgoingInterface,
+			OutLabels:     outLabels,
+		})
+	}
+	return labeledRoute
+}
+
 func (mgr *FSRouteMgr) CreateRoute(cfg *config.RouteConfig) {
-	if cfg.IsIPv6 {
+	if cfg.Multicast {
+		mgr.ribdClient.OnewayCreateRPFRoute(mgr.createRibdRPFRouteCfg(cfg, true))
+	} else if cfg.Labeled {
+		mgr.ribdClient.OnewayCreateLabeledRoute(mgr.createRibdLabeledRouteCfg(cfg, true))
+	} else if cfg.IsIPv6 {
 		mgr.ribdClient.OnewayCreateIPv6Route(mgr.createRibdIPv6RouteCfg(cfg, true /*create*/))
 	} else {
 		mgr.ribdClient.OnewayCreateIPv4Route(mgr.createRibdIPv4RouteCfg(cfg, true /*create*/))
@@ -220,7 +271,11 @@ func (mgr *FSRouteMgr) CreateRoute(cfg *config.RouteConfig) {
 }
 
 func (mgr *FSRouteMgr) DeleteRoute(cfg *config.RouteConfig) {
-	if cfg.IsIPv6 {
+	if cfg.Multicast {
+		mgr.ribdClient.OnewayDeleteRPFRoute(mgr.createRibdRPFRouteCfg(cfg, false))
+	} else if cfg.Labeled {
+		mgr.ribdClient.OnewayDeleteLabeledRoute(mgr.createRibdLabeledRouteCfg(cfg, false))
+	} else if cfg.IsIPv6 {
 		mgr.ribdClient.OnewayDeleteIPv6Route(mgr.createRibdIPv6RouteCfg(cfg, false /*delete*/))
 	} else {
 		mgr.ribdClient.OnewayDeleteIPv4Route(mgr.createRibdIPv4RouteCfg(cfg, false /*delete*/))
@@ -252,6 +307,23 @@ func (mgr *FSRouteMgr) UpdateV6Route(cfg *config.RouteConfig, nhInfo []*ribd.Nex
 }
 
 func (mgr *FSRouteMgr) UpdateRoute(cfg *config.RouteConfig, op string) {
+	if cfg.Multicast {
+		if op == "add" {
+			mgr.ribdClient.OnewayCreateRPFRoute(mgr.createRibdRPFRouteCfg(cfg, true))
+		} else {
+			mgr.ribdClient.OnewayDeleteRPFRoute(mgr.createRibdRPFRouteCfg(cfg, true))
+		}
+		return
+	}
+	if cfg.Labeled {
+		if op == "add" {
+			mgr.ribdClient.OnewayCreateLabeledRoute(mgr.createRibdLabeledRouteCfg(cfg, true))
+		} else {
+			mgr.ribdClient.OnewayDeleteLabeledRoute(mgr.createRibdLabeledRouteCfg(cfg, true))
+		}
+		return
+	}
+
 	nextHop := ribd.NextHopInfo{
 		NextHopIp:     cfg.NextHopIp,
 		NextHopIntRef: cfg.OutgoingInterface,
@@ -350,3 +422,14 @@ func (mgr *FSRouteMgr) GetRoutes() ([]*config.RouteInfo, []*config.RouteInfo) {
 
 	return routes, (make([]*config.RouteInfo, 0))
 }
+
+/*  RoutesEndOfRIB lets ribd remove the BGP routes of the previous run of bgpd
+ *  that were not installed again.
+ */
+func (mgr *FSRouteMgr) RoutesEndOfRIB() {
+	for _, protocol := range []string{"EBGP", "IBGP"} {
+		if err := mgr.ribdClient.OnewayRoutesEndOfRIB(protocol); err != nil {
+			mgr.logger.Err("Failed to send end of RIB for", protocol, "routes to ribd, err:", err)
+		}
+	}
+}
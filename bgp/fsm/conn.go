@@ -136,6 +136,18 @@ func (o *OutTCPConn) Connect(seconds uint32, remote, local string, connCh chan n
 		}
 	}
 
+	if o.fsm.Manager.dialer != nil {
+		o.logger.Info("Neighbor:", o.fsm.pConf.NeighborAddress, "FSM", o.fsm.id,
+			"Connect called... using the configured peer dialer", "OutTCPCOnn id", o.id)
+		conn, err := o.fsm.Manager.dialer(remote, local)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		connCh <- conn
+		return
+	}
+
 	o.logger.Info("Neighbor:", o.fsm.pConf.NeighborAddress, "FSM", o.fsm.id,
 		"Connect called... calling DialTimeout with", seconds, "second timeout", "OutTCPCOnn id", o.id)
 	socket, err := netUtils.ConnectSocket("tcp", remote, local)
@@ -354,6 +366,13 @@ func (p *PeerConn) DecodeMessage(header *packet.BGPHeader, buf []byte) (*packet.
 		bgpErr := err.(packet.BGPMessageError)
 		msgErr = &bgpErr
 		msgOk = false
+	} else if header.Type == packet.BGPMsgTypeUpdate {
+		updateMsg := msg.Body.(*packet.BGPUpdate)
+		if updateMsg.ErrorAction != packet.BGPUpdateErrorActionNone {
+			p.logger.Info("Neighbor:", p.fsm.pConf.NeighborAddress, "FSM", p.fsm.id,
+				"BGP update message has malformed attributes, action:",
+				packet.BGPUpdateErrorActionToStr[updateMsg.ErrorAction], "errors:", updateMsg.AttrErrors)
+		}
 	} else if header.Type == packet.BGPMsgTypeOpen {
 		peerAS := packet.GetPeerAS(msg.Body.(*packet.BGPOpen))
 		if peerAS != p.fsm.pConf.PeerAS {
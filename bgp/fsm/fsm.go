@@ -926,6 +926,9 @@ func (fsm *FSM) StartFSM() {
 					fsm.connId, "err conn id:", outConnErrCh.id)
 				continue
 			}
+			if outConnErrCh.id == 0 && outConnErrCh.err != nil {
+				fsm.neighborConf.TcpConnFailed(outConnErrCh.err)
+			}
 			fsm.outTCPConn = nil
 			fsm.ProcessEvent(BGPEventTcpConnFails, outConnErrCh)
 			if !fsm.close {
@@ -1029,6 +1032,7 @@ func (fsm *FSM) ProcessPacket(msg *packet.BGPMessage, msgErr *packet.BGPMessageE
 			notifyMsg := msg.Body.(*packet.BGPNotification)
 			fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "Received notification message:",
 				notifyMsg.ErrorCode, notifyMsg.ErrorSubcode, notifyMsg.Data)
+			fsm.neighborConf.NotificationReceived(notifyMsg.ErrorCode, notifyMsg.ErrorSubcode, notifyMsg.Data)
 
 		case packet.BGPMsgTypeKeepAlive:
 			event = BGPEventKeepAliveMsg
@@ -1056,7 +1060,7 @@ func (fsm *FSM) ChangeState(newState BaseStateIface) {
 	} else if oldState != config.BGPFSMEstablished && fsm.State.state() == config.BGPFSMEstablished {
 		fsm.ConnEstablished()
 	}
-	fsm.Manager.fsmStateChange(fsm.id, fsm.State.state())
+	fsm.Manager.fsmStateChange(fsm.id, fsm.State.state(), fsm.event)
 }
 
 func (fsm *FSM) sendAutoStartEvent() {
@@ -1283,6 +1287,7 @@ func (fsm *FSM) SendNotificationMessage(code uint8, subCode uint8, data []byte)
 		return
 	}
 	fsm.neighborConf.Neighbor.State.Messages.Sent.Notification++
+	fsm.neighborConf.NotificationSent(code, subCode, data)
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
 		"Conn.Write succeeded. sent Notification message with", num, "bytes")
 }
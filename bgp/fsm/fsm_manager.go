@@ -64,6 +64,11 @@ type PeerFSMCommand struct {
 	Reason  int
 }
 
+/*  PeerDialer opens the transport connection to the remote peer. When it is not
+ *  set, the FSM connects to the peer over a TCP socket.
+ */
+type PeerDialer func(remote, local string) (net.Conn, error)
+
 type FSMManager struct {
 	logger         *logging.Writer
 	neighborConf   *base.NeighborConf
@@ -85,6 +90,7 @@ type FSMManager struct {
 	activeFSM      uint8
 	newConnCh      chan PeerFSMConnState
 	fsmMutex       sync.RWMutex
+	dialer         PeerDialer
 }
 
 func NewFSMManager(logger *logging.Writer, neighborConf *base.NeighborConf, bgpPktSrcCh chan *packet.BGPPktSrc,
@@ -199,6 +205,10 @@ func (mgr *FSMManager) handleBfdStatusChange(status bool) {
 	}
 }
 
+func (mgr *FSMManager) SetPeerDialer(dialer PeerDialer) {
+	mgr.dialer = dialer
+}
+
 func (mgr *FSMManager) AcceptPeerConn() {
 	mgr.acceptConn = true
 }
@@ -252,12 +262,12 @@ func (mgr *FSMManager) fsmBroken(id uint8, fsmDelete bool) {
 	}
 }
 
-func (mgr *FSMManager) fsmStateChange(id uint8, state config.BGPFSMState) {
+func (mgr *FSMManager) fsmStateChange(id uint8, state config.BGPFSMState, event BGPFSMEvent) {
 	mgr.fsmMutex.Lock()
 	defer mgr.fsmMutex.Unlock()
 
 	if mgr.activeFSM == id || mgr.activeFSM == uint8(config.ConnDirInvalid) {
-		mgr.neighborConf.FSMStateChange(uint32(state))
+		mgr.neighborConf.FSMStateChange(uint32(state), BGPEventTypeToStr[event])
 	}
 }
 
@@ -283,7 +293,7 @@ func (mgr *FSMManager) Cleanup() {
 			fsm.closeCh <- true
 			fsm = nil
 			mgr.fsmBroken(id, true)
-			mgr.fsmStateChange(id, config.BGPFSMIdle)
+			mgr.fsmStateChange(id, config.BGPFSMIdle, BGPEventManualStop)
 			mgr.fsms[id] = nil
 			delete(mgr.fsms, id)
 		}
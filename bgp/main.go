@@ -60,6 +60,8 @@ func BGPSignalHandler(sigChannel <-chan os.Signal, dbHdl *dbutils.DBUtil) {
 	case syscall.SIGHUP:
 		dbHdl.DeleteObjectWithKeyFromDb("BGPv4RouteState*")
 		dbHdl.DeleteObjectWithKeyFromDb("BGPv6RouteState*")
+		dbHdl.DeleteObjectWithKeyFromDb("BGPv4MulticastRouteState*")
+		dbHdl.DeleteObjectWithKeyFromDb("BGPv6MulticastRouteState*")
 		dbHdl.Disconnect()
 		os.Exit(0)
 	default:
@@ -64,3 +64,7 @@ func (mgr *OvsRouteMgr) ApplyPolicy(applyList []*config.ApplyPolicyInfo, undoLis
 func (mgr *OvsRouteMgr) GetRoutes() ([]*config.RouteInfo, []*config.RouteInfo) {
 	return nil, nil
 }
+
+func (mgr *OvsRouteMgr) RoutesEndOfRIB() {
+
+}
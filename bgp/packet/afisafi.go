@@ -43,13 +43,16 @@ const (
 const (
 	SafiUnicast SAFI = iota + 1
 	SafiMulticast
+	SafiLabeledUnicast SAFI = 4
 )
 
 var ProtocolFamilyMap = map[string]uint32{
-	"ipv4-unicast": GetProtocolFamily(AfiIP, SafiUnicast),
-	"ipv6-unicast": GetProtocolFamily(AfiIP6, SafiUnicast),
-	//"ipv4-multicast": GetProtocolFamily(AfiIP, SafiMulticast),
-	//"ipv6-multicast": GetProtocolFamily(AfiIP6, SafiMulticast),
+	"ipv4-unicast":         GetProtocolFamily(AfiIP, SafiUnicast),
+	"ipv6-unicast":         GetProtocolFamily(AfiIP6, SafiUnicast),
+	"ipv4-labeled-unicast": GetProtocolFamily(AfiIP, SafiLabeledUnicast),
+	"ipv6-labeled-unicast": GetProtocolFamily(AfiIP6, SafiLabeledUnicast),
+	"ipv4-multicast":       GetProtocolFamily(AfiIP, SafiMulticast),
+	"ipv6-multicast":       GetProtocolFamily(AfiIP6, SafiMulticast),
 }
 
 var AFINextHopLenMap = map[AFI]int{
@@ -102,6 +105,19 @@ func GetAfiSafi(protocolFamily uint32) (AFI, SAFI) {
 	return AFI(protocolFamily >> 8), SAFI(protocolFamily & 0xFF)
 }
 
+func IsMulticastFamily(protoFamily uint32) bool {
+	_, safi := GetAfiSafi(protoFamily)
+	return safi == SafiMulticast
+}
+
+/*  GetMulticastFamily returns the multicast family with the same AFI as the
+ *  protocol family.
+ */
+func GetMulticastFamily(protoFamily uint32) uint32 {
+	afi, _ := GetAfiSafi(protoFamily)
+	return GetProtocolFamily(afi, SafiMulticast)
+}
+
 func GetAddressLengthForFamily(protoFamily uint32) int {
 	afi, _ := GetAfiSafi(protoFamily)
 	if addrLen, ok := AFINextHopLenMap[afi]; ok {
@@ -227,9 +227,11 @@ var BGPPathAttrTypeToStructMap = map[BGPPathAttrType]BGPPathAttr{
 	BGPPathAttrTypeAtomicAggregate: &BGPPathAttrAtomicAggregate{},
 	BGPPathAttrTypeAggregator:      &BGPPathAttrAggregator{},
 	BGPPathAttrTypeOriginatorId:    &BGPPathAttrOriginatorId{},
+	BGPPathAttrTypeCommunity:       &BGPPathAttrCommunity{},
 	BGPPathAttrTypeClusterList:     &BGPPathAttrClusterList{},
 	BGPPathAttrTypeMPReachNLRI:     &BGPPathAttrMPReachNLRI{},
 	BGPPathAttrTypeMPUnreachNLRI:   &BGPPathAttrMPUnreachNLRI{},
+	BGPPathAttrTypeExtCommunity:    &BGPPathAttrExtCommunity{},
 	BGPPathAttrTypeAS4Path:         &BGPPathAttrAS4Path{},
 	BGPPathAttrTypeAS4Aggregator:   &BGPPathAttrAS4Aggregator{},
 }
@@ -249,6 +251,7 @@ var BGPPathAttrTypeFlagsMap = map[BGPPathAttrType][]BGPPathAttrFlag{
 	BGPPathAttrTypeAS4Path:         []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
 	BGPPathAttrTypeAS4Aggregator:   []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
 	BGPPathAttrTypeCommunity:       []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeExtCommunity:    []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
 }
 
 var BGPPathAttrTypeLenMap = map[BGPPathAttrType]uint16{
@@ -257,6 +260,7 @@ var BGPPathAttrTypeLenMap = map[BGPPathAttrType]uint16{
 	BGPPathAttrTypeMultiExitDisc:   4,
 	BGPPathAttrTypeLocalPref:       4,
 	BGPPathAttrTypeAtomicAggregate: 0,
+	BGPPathAttrTypeOriginatorId:    4,
 	BGPPathAttrTypeAS4Aggregator:   8,
 }
 
@@ -297,6 +301,10 @@ func (header *BGPHeader) Encode() ([]byte, error) {
 }
 
 func (header *BGPHeader) Decode(pkt []byte) error {
+	if len(pkt) < BGPMsgHeaderLen {
+		return BGPMessageError{BGPMsgHeaderError, BGPBadMessageLen, nil, "Not enough data to decode BGP header"}
+	}
+
 	header.Length = binary.BigEndian.Uint16(pkt[16:18])
 	header.Type = pkt[18]
 	return nil
@@ -341,7 +349,7 @@ func (msg *BGPCapabilityBase) Decode(pkt []byte) error {
 	msg.Len = pkt[1]
 
 	if len(pkt) < int(msg.TotalLen()) {
-		return BGPMessageError{BGPUpdateMsgError, BGPUnspecific, nil, "Not enough data to decode capability data"}
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil, "Not enough data to decode capability data"}
 	}
 	return nil
 }
@@ -383,6 +391,11 @@ func (mp *BGPCapMPExt) Decode(pkt []byte) error {
 		return err
 	}
 
+	if mp.Len != 4 {
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil,
+			fmt.Sprintf("Multiprotocol capability length %d is not 4", mp.Len)}
+	}
+
 	mp.AFI = AFI(binary.BigEndian.Uint16(pkt[2:]))
 	mp.Reserved = 0
 	mp.SAFI = SAFI(pkt[5])
@@ -426,6 +439,11 @@ func (msg *BGPCapAS4Path) Decode(pkt []byte) error {
 		return err
 	}
 
+	if msg.Len != 4 {
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil,
+			fmt.Sprintf("4 byte AS capability length %d is not 4", msg.Len)}
+	}
+
 	msg.Value = binary.BigEndian.Uint32(pkt[2:])
 	return nil
 }
@@ -506,9 +524,9 @@ func (msg *BGPCapAddPath) Decode(pkt []byte) error {
 	}
 
 	offset := uint16(2)
-	for offset < uint16(msg.Len) {
+	for offset < msg.TotalLen() {
 		addPathAFISAFI := AddPathAFISAFI{}
-		err := addPathAFISAFI.Decode(pkt[offset:])
+		err := addPathAFISAFI.Decode(pkt[offset:msg.TotalLen()])
 		if err != nil {
 			return err
 		}
@@ -562,6 +580,7 @@ func (msg *BGPCapUnknown) Decode(pkt []byte) error {
 		return err
 	}
 
+	msg.Value = make([]byte, msg.Len)
 	copy(msg.Value, pkt[2:msg.TotalLen()])
 	return nil
 }
@@ -595,7 +614,7 @@ func (msg *BGPOptParamBase) Decode(pkt []byte) error {
 	msg.Len = pkt[1]
 
 	if len(pkt) < int(msg.TotalLen()) {
-		return BGPMessageError{BGPUpdateMsgError, BGPUnspecific, nil, "Not enough data to decode Opt params data"}
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil, "Not enough data to decode Opt params data"}
 	}
 	return nil
 }
@@ -649,17 +668,22 @@ func (msg *BGPOptParamCapability) Decode(pkt []byte) error {
 	}
 
 	paramsLen := int(msg.Len)
+	end := int(msg.TotalLen())
 	msg.Value = make([]BGPCapability, 0)
-	offset := uint16(2)
+	offset := 2
 	for paramsLen > 0 {
-		capParam := msg.GetCapParam(pkt[offset:])
+		if end-offset < 2 {
+			return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil,
+				"Not enough data to decode capability type and length"}
+		}
+		capParam := msg.GetCapParam(pkt[offset:end])
 
-		err = capParam.Decode(pkt[offset:])
+		err = capParam.Decode(pkt[offset:end])
 		if err != nil {
 			return err
 		}
 		msg.Value = append(msg.Value, capParam)
-		offset += capParam.TotalLen()
+		offset += int(capParam.TotalLen())
 		paramsLen -= int(capParam.TotalLen())
 	}
 	if paramsLen < 0 {
@@ -760,6 +784,10 @@ func (msg *BGPOpen) GetOptParam(pkt []byte) (BGPOptParam, error) {
 }
 
 func (msg *BGPOpen) Decode(header *BGPHeader, pkt []byte, data interface{}) error {
+	if len(pkt) < 10 {
+		return BGPMessageError{BGPMsgHeaderError, BGPBadMessageLen, nil, "Not enough data to decode OPEN message"}
+	}
+
 	msg.Version = pkt[0]
 	msg.MyAS = uint32(binary.BigEndian.Uint16(pkt[1:3]))
 	msg.HoldTime = binary.BigEndian.Uint16(pkt[3:5])
@@ -768,18 +796,23 @@ func (msg *BGPOpen) Decode(header *BGPHeader, pkt []byte, data interface{}) erro
 
 	msg.OptParams = make([]BGPOptParam, 0)
 	paramsLen := int(msg.OptParamLen)
-	offset := uint8(10)
+	if len(pkt) < 10+paramsLen {
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil, "Not enough data to decode optional parameters"}
+	}
+
+	offset := 10
+	end := 10 + paramsLen
 	for paramsLen > 0 {
-		optParam, err := msg.GetOptParam(pkt[offset:])
+		optParam, err := msg.GetOptParam(pkt[offset:end])
 		if err != nil {
 			return err
 		}
-		err = optParam.Decode(pkt[offset:])
+		err = optParam.Decode(pkt[offset:end])
 		if err != nil {
 			return err
 		}
 		msg.OptParams = append(msg.OptParams, optParam)
-		offset += optParam.TotalLen()
+		offset += int(optParam.TotalLen())
 		paramsLen -= int(optParam.TotalLen())
 	}
 	if paramsLen < 0 {
@@ -849,6 +882,10 @@ func (msg *BGPNotification) Encode() ([]byte, error) {
 }
 
 func (msg *BGPNotification) Decode(header *BGPHeader, pkt []byte, data interface{}) error {
+	if len(pkt) < 2 {
+		return BGPMessageError{BGPMsgHeaderError, BGPBadMessageLen, nil, "Not enough data to decode NOTIFICATION message"}
+	}
+
 	msg.ErrorCode = pkt[0]
 	msg.ErrorSubcode = pkt[1]
 	if len(pkt) > 2 {
@@ -927,6 +964,10 @@ func (ip *IPPrefix) Decode(pkt []byte, afi AFI) error {
 	if afi == AfiIP6 || int(bytes) > ipLen {
 		ipLen = net.IPv6len
 	}
+	if int(bytes) > ipLen {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+			fmt.Sprintf("Prefix length %d is greater than IPv6 address length", ip.Length)}
+	}
 	ip.Prefix = make(net.IP, ipLen)
 	copy(ip.Prefix, pkt[1:bytes+1])
 	if bytes > 0 {
@@ -1233,6 +1274,13 @@ func (ps *BGPASPathSegmentBase) Decode(pkt []byte, data interface{}) error {
 	ps.Type = BGPASPathSegmentType(pkt[0])
 	ps.Length = pkt[1]
 
+	if ps.Type != BGPASPathSegmentSet && ps.Type != BGPASPathSegmentSequence {
+		return BGPMessageError{BGPUpdateMsgError, BGPMalformedASPath, nil,
+			fmt.Sprintf("Unknown AS path segment type %d", ps.Type)}
+	}
+	if ps.Length == 0 {
+		return BGPMessageError{BGPUpdateMsgError, BGPMalformedASPath, nil, "AS path segment has no ASes"}
+	}
 	return nil
 }
 
@@ -1293,7 +1341,7 @@ func (ps *BGPAS2PathSegment) Decode(pkt []byte, data interface{}) error {
 	for i := 0; i < int(ps.Length); i++ {
 		ps.AS[i] = binary.BigEndian.Uint16(pkt[(i*2)+2:])
 	}
-	ps.BGPASPathSegmentLen = uint16(ps.Length*2 + 2)
+	ps.BGPASPathSegmentLen = uint16(ps.Length)*2 + 2
 	return nil
 }
 
@@ -1434,7 +1482,7 @@ func (ps *BGPAS4PathSegment) Decode(pkt []byte, data interface{}) error {
 	for i := 0; i < int(ps.Length); i++ {
 		ps.AS[i] = binary.BigEndian.Uint32(pkt[(i*4)+2:])
 	}
-	ps.BGPASPathSegmentLen = uint16(ps.Length*4 + 2)
+	ps.BGPASPathSegmentLen = uint16(ps.Length)*4 + 2
 	return nil
 }
 
@@ -1589,7 +1637,7 @@ func (as *BGPPathAttrASPath) Decode(pkt []byte, data interface{}) error {
 
 		err = asPathSegment.Decode(pkt[ptr:], data)
 		if err != nil {
-			return nil
+			return err
 		}
 		ptr += uint32(asPathSegment.TotalLen())
 		if ptr > (uint32(as.Length) + uint32(as.BGPPathAttrLen)) {
@@ -1683,7 +1731,7 @@ func (as *BGPPathAttrAS4Path) Decode(pkt []byte, data interface{}) error {
 
 		err = asPathSegment.Decode(pkt[ptr:], data)
 		if err != nil {
-			return nil
+			return err
 		}
 		ptr += uint32(asPathSegment.TotalLen())
 		if ptr > (uint32(as.Length) + uint32(as.BGPPathAttrLen)) {
@@ -1955,6 +2003,10 @@ func (a *BGPAggregator4ByteAS) Encode(pkt []byte) error {
 }
 
 func (a *BGPAggregator4ByteAS) Decode(pkt []byte, data interface{}) error {
+	if len(pkt) < 4 {
+		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, nil, "Not enough data to decode 4 byte Aggregator"}
+	}
+
 	a.AS = binary.BigEndian.Uint32(pkt[:4])
 	return nil
 }
@@ -2008,18 +2060,17 @@ func (a *BGPPathAttrAggregator) Decode(pkt []byte, data interface{}) error {
 	}
 
 	peerAttrs := data.(BGPPeerAttrs)
-	asSize := peerAttrs.ASSize
-	if a.BGPPathAttrBase.Length < uint16(asSize+4) {
-		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, pkt[:a.BGPPathAttrBase.TotalLen()], "Bad Attribute Length"}
-	}
-
 	var aggAS BGPAggregatorAS
-	if asSize == 2 {
+	if peerAttrs.ASSize == 2 {
 		aggAS = NewBGPAggregator2ByteAS()
 	} else {
 		aggAS = NewBGPAggregator4ByteAS()
 	}
 
+	if a.BGPPathAttrBase.Length != aggAS.GetLen()+4 {
+		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, pkt[:a.BGPPathAttrBase.TotalLen()], "Bad Attribute Length"}
+	}
+
 	err = aggAS.Decode(pkt[a.BGPPathAttrLen:], data)
 	if err != nil {
 		return err
@@ -2205,6 +2256,10 @@ func (c *BGPPathAttrClusterList) Decode(pkt []byte, data interface{}) error {
 		return err
 	}
 
+	if c.Length == 0 || c.Length%4 != 0 {
+		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, pkt[:c.TotalLen()], "Bad Attribute Length"}
+	}
+
 	var i uint16
 	c.Value = make([]uint32, c.Length/4)
 	for i = 0; i < uint16(c.Length/4); i++ {
@@ -2292,6 +2347,8 @@ type BGPUpdate struct {
 	TotalPathAttrLen   uint16
 	PathAttributes     []BGPPathAttr
 	NLRI               []NLRI
+	ErrorAction        BGPUpdateErrorAction
+	AttrErrors         []BGPMessageError
 }
 
 func (msg *BGPUpdate) Clone() BGPBody {
@@ -2370,13 +2427,15 @@ func decodeNLRI(pkt []byte, ipPrefix *[]NLRI, length uint32, afi AFI, safi SAFI,
 	peerAttrs := data.(BGPPeerAttrs)
 
 	for ptr < length {
-		if peerAttrs.AddPathsRxActual {
+		if safi == SafiLabeledUnicast {
+			ip = &LabeledNLRI{AddPath: peerAttrs.AddPathsRxActual}
+		} else if peerAttrs.AddPathsRxActual {
 			ip = &ExtNLRI{}
 		} else {
 			ip = &IPPrefix{}
 		}
 
-		err := ip.Decode(pkt[ptr:], afi)
+		err := ip.Decode(pkt[ptr:length], afi)
 		if err != nil {
 			return ptr, err
 		}
@@ -2391,27 +2450,11 @@ func decodeNLRI(pkt []byte, ipPrefix *[]NLRI, length uint32, afi AFI, safi SAFI,
 	return ptr, nil
 }
 
-func checkPathAttributes(pathAttrs []BGPPathAttr) error {
-	found := make(map[BGPPathAttrType]bool)
-	for _, attr := range pathAttrs {
-		if found[attr.GetCode()] {
-			return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil,
-				fmt.Sprintf("Path Attr type %d appeared twice in the UPDATE message", attr)}
-		}
-		found[attr.GetCode()] = true
-	}
-
-	for _, attrType := range BGPPathAttrWellKnownMandatory {
-		if !found[attrType] {
-			return BGPMessageError{BGPUpdateMsgError, BGPMissingWellKnownAttr, []byte{byte(attrType)},
-				fmt.Sprintf("Path Attr type %v appeared twice in the UPDATE message", attrType)}
-		}
+func (msg *BGPUpdate) Decode(header *BGPHeader, pkt []byte, data interface{}) error {
+	if len(pkt) < BGPUpdateMsgMinLen-BGPMsgHeaderLen || int(header.Len()) != len(pkt)+BGPMsgHeaderLen {
+		return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil, "Malformed Attributes"}
 	}
 
-	return nil
-}
-
-func (msg *BGPUpdate) Decode(header *BGPHeader, pkt []byte, data interface{}) error {
 	msg.WithdrawnRoutesLen = binary.BigEndian.Uint16(pkt[0:2])
 
 	ptr := uint32(2)
@@ -2419,12 +2462,13 @@ func (msg *BGPUpdate) Decode(header *BGPHeader, pkt []byte, data interface{}) er
 	ipLen := uint32(0)
 	var err error
 
-	if uint32(msg.WithdrawnRoutesLen)+23 > header.Len() {
+	if uint32(msg.WithdrawnRoutesLen)+BGPUpdateMsgMinLen > header.Len() {
 		return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil, "Malformed Attributes"}
 	}
 
 	msg.WithdrawnRoutes = make([]NLRI, 0)
-	ipLen, err = decodeNLRI(pkt[ptr:], &msg.WithdrawnRoutes, uint32(length), AfiIP, SafiUnicast, data)
+	ipLen, err = decodeNLRI(pkt[ptr:ptr+uint32(length)], &msg.WithdrawnRoutes, uint32(length), AfiIP, SafiUnicast,
+		data)
 	if err != nil {
 		return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil, "Malformed Attributes"}
 	}
@@ -2435,31 +2479,31 @@ func (msg *BGPUpdate) Decode(header *BGPHeader, pkt []byte, data interface{}) er
 
 	length = int(msg.TotalPathAttrLen)
 
-	if length+int(msg.WithdrawnRoutesLen)+23 > int(header.Len()) {
+	if length+int(msg.WithdrawnRoutesLen)+BGPUpdateMsgMinLen > int(header.Len()) {
 		return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil, "Malformed Attributes"}
 	}
 
-	msg.PathAttributes = make([]BGPPathAttr, 0)
-	for length > 0 {
-		pa := BGPGetPathAttr(pkt[ptr:])
-		err = pa.Decode(pkt[ptr:], data)
-		if err != nil {
-			return err
-		}
-		msg.PathAttributes = append(msg.PathAttributes, pa)
-		ptr += pa.TotalLen()
-		length -= int(pa.TotalLen())
-	}
-	if length < 0 {
-		return BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil, "Malformed Attributes"}
+	msg.ErrorAction = BGPUpdateErrorActionNone
+	msg.AttrErrors = nil
+	err = msg.decodePathAttrs(pkt[ptr:ptr+uint32(length)], data)
+	if err != nil {
+		return err
 	}
+	ptr += uint32(length)
 
 	msg.NLRI = make([]NLRI, 0)
-	length = int(header.Len()) - 23 - int(msg.WithdrawnRoutesLen) - int(msg.TotalPathAttrLen)
+	length = int(header.Len()) - BGPUpdateMsgMinLen - int(msg.WithdrawnRoutesLen) - int(msg.TotalPathAttrLen)
 	ipLen, err = decodeNLRI(pkt[ptr:], &msg.NLRI, uint32(length), AfiIP, SafiUnicast, data)
 	if err != nil {
 		return err
 	}
+
+	if msg.ErrorAction < BGPUpdateErrorActionTreatAsWithdraw {
+		msg.checkPathAttributes()
+	}
+	if msg.ErrorAction == BGPUpdateErrorActionTreatAsWithdraw {
+		msg.treatAsWithdraw()
+	}
 	return nil
 }
 
@@ -2526,7 +2570,8 @@ func (msg *BGPMessage) Decode(header *BGPHeader, pkt []byte, data interface{}) e
 	}
 	err := msg.Body.Decode(header, pkt, data)
 
-	if err == nil && msg.Header.Type == BGPMsgTypeUpdate {
+	if err == nil && msg.Header.Type == BGPMsgTypeUpdate &&
+		msg.Body.(*BGPUpdate).ErrorAction != BGPUpdateErrorActionTreatAsWithdraw {
 		NormalizeASPath(msg, data)
 	}
 	return err
@@ -144,9 +144,13 @@ func TestBGPOpenPacketsIndexOutOfRange(t *testing.T) {
 
 func TestBGPUpdatePathAttrsBadFlags(t *testing.T) {
 	strPkts := make([]string, 0)
+	actions := make([]BGPUpdateErrorAction, 0)
+	// AS_PATH, NEXT_HOP and MULTI_EXIT_DISC with bad flags are treated as withdraw
 	strPkts = append(strPkts, "0000001c40010100100200060201000002584003045a01010280040400000000183c010118500101184701011846010218460101183c0102")
 	strPkts = append(strPkts, "0000001c40010100500200060201000002582003045a01010280040400000000183c010118500101184701011846010218460101183c0102")
 	strPkts = append(strPkts, "0000001c40010100500200060201000002584003045a010102A0040400000000183c010118500101184701011846010218460101183c0102")
+	actions = append(actions, BGPUpdateErrorActionTreatAsWithdraw, BGPUpdateErrorActionTreatAsWithdraw,
+		BGPUpdateErrorActionTreatAsWithdraw)
 
 	pktPathAttrs := "0000002040010100500200060201000002584003045a01010280040400000000"
 	nlri := "183c010118500101184701011846010218460101183c0102"
@@ -155,8 +159,11 @@ func TestBGPUpdatePathAttrsBadFlags(t *testing.T) {
 		pa = pa[:2] + fmt.Sprintf("%02x", BGPPathAttrTypeUnknown) + pa[4:]
 		strPkts = append(strPkts, pktPathAttrs+pa+nlri)
 	}
+	// Unrecognized well-known attributes reset the session, unrecognized optional attributes are discarded
+	actions = append(actions, BGPUpdateErrorActionSessionReset, BGPUpdateErrorActionSessionReset,
+		BGPUpdateErrorActionSessionReset, BGPUpdateErrorActionAttrDiscard)
 
-	for _, strPkt := range strPkts {
+	for idx, strPkt := range strPkts {
 		hexPkt, err := hex.DecodeString(strPkt)
 		fmt.Printf("packet = %x, len = %d\n", hexPkt, len(hexPkt))
 		if err != nil {
@@ -182,14 +189,36 @@ func TestBGPUpdatePathAttrsBadFlags(t *testing.T) {
 
 		peerAttrs := BGPPeerAttrs{
 			ASSize:           4,
-			AddPathsRxActual: true,
+			AddPathsRxActual: false,
 		}
 		bgpMessage := NewBGPMessage()
 		err = bgpMessage.Decode(bgpHeader, hexPkt, peerAttrs)
-		if err == nil {
-			t.Error("BGP update message decode called... expected failure, got NO error")
-		} else {
-			t.Log("BGP update message decode called... expected failure, error:", err)
+		if actions[idx] == BGPUpdateErrorActionSessionReset {
+			if err == nil {
+				t.Error("BGP update message decode called... expected failure, got NO error")
+			} else {
+				t.Log("BGP update message decode called... expected failure, error:", err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error("BGP update message decode failed with error:", err)
+			continue
+		}
+
+		updateMsg := bgpMessage.Body.(*BGPUpdate)
+		if updateMsg.ErrorAction != actions[idx] {
+			t.Error("BGP update message decode error action is", BGPUpdateErrorActionToStr[updateMsg.ErrorAction],
+				"expected", BGPUpdateErrorActionToStr[actions[idx]])
+		}
+		if actions[idx] == BGPUpdateErrorActionTreatAsWithdraw {
+			if len(updateMsg.NLRI) != 0 || len(updateMsg.WithdrawnRoutes) != 6 {
+				t.Error("BGP update message treated as withdraw has NLRI", updateMsg.NLRI, "withdrawn routes",
+					updateMsg.WithdrawnRoutes)
+			}
+		} else if len(updateMsg.NLRI) != 6 {
+			t.Error("BGP update message with discarded attribute has NLRI", updateMsg.NLRI)
 		}
 	}
 }
@@ -436,3 +465,104 @@ func TestBGPUpdateEncode(t *testing.T) {
 		t.Fatal("Cloned update message is not the same as the original message")
 	}
 }
+
+func decodeUpdateBody(t *testing.T, strPkt string, peerAttrs BGPPeerAttrs) (*BGPMessage, error) {
+	hexPkt, err := hex.DecodeString(strPkt)
+	if err != nil {
+		t.Fatal("Failed to decode the string to hex, string =", strPkt)
+	}
+
+	bgpHeader := NewBGPHeader()
+	bgpHeader.Length = uint16(len(hexPkt) + BGPMsgHeaderLen)
+	bgpHeader.Type = BGPMsgTypeUpdate
+	bgpMessage := NewBGPMessage()
+	err = bgpMessage.Decode(bgpHeader, hexPkt, peerAttrs)
+	return bgpMessage, err
+}
+
+func TestBGPUpdateRevisedErrorHandling(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+	utils.SetLogger(logger)
+
+	origin := "40010101"
+	asPath := "40020602011908b10a"
+	nextHop := "4003040a0a00c2"
+	med := "80040400000000"
+	nlri := "080a"
+	mpReach := "800E1E0002011020010db800000000000000000000000100" + "4020010db800000000"
+	mpUnreach := "800F07000101180a0a01"
+
+	testCases := []struct {
+		desc      string
+		pkt       string
+		action    BGPUpdateErrorAction
+		numAttrs  int
+		numNLRI   int
+		numWdrawn int
+	}{
+		{"Malformed COMMUNITY", "00000042" + origin + asPath + nextHop + med + "c00803010203" + mpReach + nlri,
+			BGPUpdateErrorActionTreatAsWithdraw, 1, 0, 1},
+		{"Malformed COMMUNITY with MP_UNREACH_NLRI for another AFI", "0000004c" + origin + asPath + nextHop + med +
+			"c00803010203" + mpUnreach + mpReach + nlri, BGPUpdateErrorActionTreatAsWithdraw, 2, 0, 1},
+		{"Malformed AGGREGATOR", "00000025" + origin + asPath + nextHop + med + "C007070000fde80a0101" + nlri,
+			BGPUpdateErrorActionAttrDiscard, 4, 1, 0},
+		{"Duplicate MULTI_EXIT_DISC", "00000022" + origin + asPath + nextHop + med + "80040400000001" + nlri,
+			BGPUpdateErrorActionAttrDiscard, 4, 1, 0},
+		{"Missing ORIGIN", "00000017" + asPath + nextHop + med + nlri,
+			BGPUpdateErrorActionTreatAsWithdraw, 0, 0, 1},
+		{"Zero length AS path segment", "0000001d" + origin + "40020802000201" + "1908b10a" + nextHop + med + nlri,
+			BGPUpdateErrorActionTreatAsWithdraw, 0, 0, 1},
+	}
+
+	peerAttrs := BGPPeerAttrs{ASSize: 4}
+	for _, tc := range testCases {
+		bgpMessage, err := decodeUpdateBody(t, tc.pkt, peerAttrs)
+		if err != nil {
+			t.Error(tc.desc, "- BGP update message decode failed with error:", err)
+			continue
+		}
+
+		updateMsg := bgpMessage.Body.(*BGPUpdate)
+		if updateMsg.ErrorAction != tc.action {
+			t.Error(tc.desc, "- error action is", BGPUpdateErrorActionToStr[updateMsg.ErrorAction], "expected",
+				BGPUpdateErrorActionToStr[tc.action])
+		}
+		if len(updateMsg.PathAttributes) != tc.numAttrs || len(updateMsg.NLRI) != tc.numNLRI ||
+			len(updateMsg.WithdrawnRoutes) != tc.numWdrawn {
+			t.Error(tc.desc, "- path attrs", updateMsg.PathAttributes, "NLRI", updateMsg.NLRI, "withdrawn routes",
+				updateMsg.WithdrawnRoutes)
+		}
+	}
+
+	// MP_REACH_NLRI routes are withdrawn with MP_UNREACH_NLRI
+	bgpMessage, _ := decodeUpdateBody(t, testCases[0].pkt, peerAttrs)
+	updateMsg := bgpMessage.Body.(*BGPUpdate)
+	if len(updateMsg.PathAttributes) == 1 {
+		mpUnreach, ok := updateMsg.PathAttributes[0].(*BGPPathAttrMPUnreachNLRI)
+		if !ok || mpUnreach.AFI != AfiIP6 || len(mpUnreach.NLRI) != 1 {
+			t.Error("Expected MP_UNREACH_NLRI with one IPv6 prefix, got", updateMsg.PathAttributes[0])
+		}
+	}
+
+	// MP_REACH_NLRI routes are withdrawn even when MP_UNREACH_NLRI is for another AFI
+	bgpMessage, _ = decodeUpdateBody(t, testCases[1].pkt, peerAttrs)
+	updateMsg = bgpMessage.Body.(*BGPUpdate)
+	if len(updateMsg.PathAttributes) == 2 {
+		mpUnreach4, ok4 := updateMsg.PathAttributes[0].(*BGPPathAttrMPUnreachNLRI)
+		mpUnreach6, ok6 := updateMsg.PathAttributes[1].(*BGPPathAttrMPUnreachNLRI)
+		if !ok4 || mpUnreach4.AFI != AfiIP || len(mpUnreach4.NLRI) != 1 {
+			t.Error("Expected MP_UNREACH_NLRI with one IPv4 prefix, got", updateMsg.PathAttributes[0])
+		}
+		if !ok6 || mpUnreach6.AFI != AfiIP6 || len(mpUnreach6.NLRI) != 1 {
+			t.Error("Expected MP_UNREACH_NLRI with one IPv6 prefix, got", updateMsg.PathAttributes[1])
+		}
+	}
+
+	// Attributes that overrun the path attributes field reset the session
+	if _, err = decodeUpdateBody(t, "00000010"+origin+"40020c02011908b10a"+nextHop[:6]+nlri, peerAttrs); err == nil {
+		t.Error("BGP update message decode called... expected failure, got NO error")
+	}
+}
@@ -61,7 +61,7 @@ func (c *BGPPathAttrCommunity) Decode(pkt []byte, data interface{}) error {
 		return err
 	}
 
-	if (c.Length % 4) != 0 {
+	if c.Length == 0 || (c.Length%4) != 0 {
 		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, pkt[:c.TotalLen()], "Bad Attribute Length"}
 	}
 
@@ -160,7 +160,7 @@ func (e *BGPPathAttrExtCommunity) Decode(pkt []byte, data interface{}) error {
 		return err
 	}
 
-	if (e.Length % 8) != 0 {
+	if e.Length == 0 || (e.Length%8) != 0 {
 		return BGPMessageError{BGPUpdateMsgError, BGPAttrLenError, pkt[:e.TotalLen()], "Bad Attribute Length"}
 	}
 
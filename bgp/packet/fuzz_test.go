@@ -0,0 +1,247 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// fuzz_test.go
+package packet
+
+import (
+	"encoding/hex"
+	"l3/bgp/utils"
+	"testing"
+	"utils/logging"
+)
+
+var fuzzUpdatePkts = []string{
+	"0000001b4001010140020602011908b10a4003040a0a00c28004040000000000000001080a",
+	"000000254001010140020602011908b10a4003040a0a00c2800404000000004005040102030440060000000001080a",
+	"000000304001010140020602011908b10a4003040a0a00c28004040000000040050401020304400600C007081908b10b0a010a1c00000001080a",
+	"000000334001010140020602011908b10a4003040a0a00c280040400000000400504010203044006008009040a010a32800A040102030400000001080a",
+	"000000474001010140020602011908b10a4003040a0a00c280040400000000800E1C000201100102030405060708091011121314151600000000020A0A80800F0A000201000000030A0BC000000001080a",
+	"0000001c40010100500200060201000002584003045a01010280040400000000183c010118500101184701011846010218460101183c0102",
+}
+
+func setFuzzLogger(f *testing.F) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		f.Fatal("Failed to start the logger")
+	}
+	utils.SetLogger(logger)
+}
+
+func getFuzzPeerAttrs(flags uint8) BGPPeerAttrs {
+	peerAttrs := BGPPeerAttrs{ASSize: 2}
+	if flags&0x1 != 0 {
+		peerAttrs.ASSize = 4
+	}
+	if flags&0x2 != 0 {
+		peerAttrs.AddPathsRxActual = true
+	}
+	return peerAttrs
+}
+
+var fuzzPathAttrPkts = []string{
+	"40010101",
+	"40020602011908b10a",
+	"4003040a0a00c2",
+	"80040400000000",
+	"40050401020304",
+	"400600",
+	"C007081908b10b0a010a1c",
+	"C00808fde80001fde80002",
+	"8009040a010a32",
+	"800A0401020304",
+	"800E1C000201100102030405060708091011121314151600000000020A0A80",
+	"800F0A000201000000030A0BC0",
+	"C0100800020000fde80001",
+	"C0110602011908b10a",
+	"C012081908b10b0a010a1c",
+}
+
+func addFuzzPathAttrSeeds(f *testing.F) {
+	for _, strPkt := range fuzzPathAttrPkts {
+		pkt, err := hex.DecodeString(strPkt)
+		if err != nil {
+			f.Fatal("Failed to decode the string to hex, string =", strPkt)
+		}
+		f.Add(uint8(1), pkt)
+	}
+}
+
+func FuzzBGPMessageDecode(f *testing.F) {
+	setFuzzLogger(f)
+	for _, strPkt := range fuzzUpdatePkts {
+		body, err := hex.DecodeString(strPkt)
+		if err != nil {
+			f.Fatal("Failed to decode the string to hex, string =", strPkt)
+		}
+		header, _ := (&BGPHeader{Length: uint16(len(body) + BGPMsgHeaderLen), Type: BGPMsgTypeUpdate}).Encode()
+		f.Add(uint8(1), append(header, body...))
+	}
+	openMsg, _ := NewBGPOpenMessage(65000, 90, "10.1.1.1", ConstructOptParams(65000,
+		map[uint32]bool{ProtocolFamilyMap["ipv4-unicast"]: true}, true, 2)).Encode()
+	f.Add(uint8(0), openMsg)
+	notifMsg, _ := NewBGPNotificationMessage(BGPCease, 0, []byte{0x1}).Encode()
+	f.Add(uint8(0), notifMsg)
+
+	f.Fuzz(func(t *testing.T, flags uint8, pkt []byte) {
+		header := NewBGPHeader()
+		if err := header.Decode(pkt); err != nil {
+			return
+		}
+
+		msg := NewBGPMessage()
+		if err := msg.Decode(header, pkt[BGPMsgHeaderLen:], getFuzzPeerAttrs(flags)); err != nil {
+			if _, ok := err.(BGPMessageError); !ok {
+				t.Fatal("BGP message decode returned an error that is not BGPMessageError, err:", err)
+			}
+			return
+		}
+
+		if msg.Body != nil {
+			msg.Clone()
+		}
+	})
+}
+
+func FuzzDecodeNLRI(f *testing.F) {
+	setFuzzLogger(f)
+	f.Add(uint8(0), uint16(AfiIP), []byte{0x18, 0x0a, 0x01, 0x01, 0x20, 0x0a, 0x01, 0x02, 0x03})
+	f.Add(uint8(2), uint16(AfiIP), []byte{0x00, 0x00, 0x00, 0x01, 0x08, 0x0a})
+	f.Add(uint8(0), uint16(AfiIP6), []byte{0x40, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x01})
+	f.Add(uint8(4), uint16(AfiIP), []byte{0x30, 0x00, 0x06, 0x41, 0x14, 0x01, 0x01})
+	f.Add(uint8(6), uint16(AfiIP), []byte{0x00, 0x00, 0x00, 0x01, 0x30, 0x80, 0x00, 0x00, 0x14, 0x01, 0x01})
+
+	f.Fuzz(func(t *testing.T, flags uint8, afi uint16, pkt []byte) {
+		safi := SafiUnicast
+		if flags&0x4 != 0 {
+			safi = SafiLabeledUnicast
+		}
+		nlriList := make([]NLRI, 0)
+		length, err := decodeNLRI(pkt, &nlriList, uint32(len(pkt)), AFI(afi), safi, getFuzzPeerAttrs(flags))
+		if err != nil {
+			return
+		}
+
+		if length != uint32(len(pkt)) {
+			t.Fatal("decodeNLRI decoded", length, "bytes, expected", len(pkt))
+		}
+		for _, nlri := range nlriList {
+			nlri.Clone()
+			nlri.GetCIDR()
+			_ = nlri.String()
+		}
+	})
+}
+
+func fuzzPathAttr(f *testing.F, code BGPPathAttrType) {
+	setFuzzLogger(f)
+	addFuzzPathAttrSeeds(f)
+
+	f.Fuzz(func(t *testing.T, flags uint8, pkt []byte) {
+		if len(pkt) < 3 {
+			return
+		}
+		pkt[1] = uint8(code)
+		length, err := getPathAttrLen(pkt)
+		if err != nil {
+			return
+		}
+
+		pa := BGPGetPathAttr(pkt)
+		if err = pa.Decode(pkt[:length], getFuzzPeerAttrs(flags)); err != nil {
+			if _, ok := err.(BGPMessageError); !ok {
+				t.Fatal("Path attr decode returned an error that is not BGPMessageError, err:", err)
+			}
+			return
+		}
+
+		if pa.TotalLen() != length {
+			t.Fatal("Path attr decode consumed", pa.TotalLen(), "bytes, expected", length)
+		}
+		pa.Clone()
+	})
+}
+
+func FuzzBGPPathAttrOrigin(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeOrigin)
+}
+
+func FuzzBGPPathAttrASPath(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeASPath)
+}
+
+func FuzzBGPPathAttrNextHop(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeNextHop)
+}
+
+func FuzzBGPPathAttrMultiExitDisc(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeMultiExitDisc)
+}
+
+func FuzzBGPPathAttrLocalPref(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeLocalPref)
+}
+
+func FuzzBGPPathAttrAtomicAggregate(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeAtomicAggregate)
+}
+
+func FuzzBGPPathAttrAggregator(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeAggregator)
+}
+
+func FuzzBGPPathAttrCommunity(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeCommunity)
+}
+
+func FuzzBGPPathAttrOriginatorId(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeOriginatorId)
+}
+
+func FuzzBGPPathAttrClusterList(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeClusterList)
+}
+
+func FuzzBGPPathAttrMPReachNLRI(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeMPReachNLRI)
+}
+
+func FuzzBGPPathAttrMPUnreachNLRI(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeMPUnreachNLRI)
+}
+
+func FuzzBGPPathAttrExtCommunity(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeExtCommunity)
+}
+
+func FuzzBGPPathAttrAS4Path(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeAS4Path)
+}
+
+func FuzzBGPPathAttrAS4Aggregator(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeAS4Aggregator)
+}
+
+func FuzzBGPPathAttrUnknown(f *testing.F) {
+	fuzzPathAttr(f, BGPPathAttrTypeUnknown)
+}
@@ -705,6 +705,8 @@ func ConstructNLRIFromPathIdAndNLRI(nlri NLRI, pathId uint32) NLRI {
 	newNLRI := nlri.Clone()
 	if extNLRI, ok := newNLRI.(*ExtNLRI); ok {
 		extNLRI.PathId = pathId
+	} else if labeledNLRI, ok := newNLRI.(*LabeledNLRI); ok {
+		labeledNLRI.PathId = pathId
 	}
 
 	return newNLRI
@@ -853,7 +855,7 @@ func RemoveClusterList(updateMsg *BGPMessage) {
 }
 
 func ConvertIPBytesToUint(bytes []byte) uint32 {
-	return uint32(bytes[0])<<24 | uint32(bytes[1]<<16) | uint32(bytes[2]<<8) | uint32(bytes[3])
+	return uint32(bytes[0])<<24 | uint32(bytes[1])<<16 | uint32(bytes[2])<<8 | uint32(bytes[3])
 }
 
 func ConstructOptParams(as uint32, afiSAfiMap map[uint32]bool, addPathsRx bool, addPathsMaxTx uint8) []BGPOptParam {
@@ -1127,6 +1129,8 @@ func NormalizeASPath(updateMsg *BGPMessage, data interface{}) {
 		} else {
 			utils.Logger.Info("NormalizeASPath calling ConvertAS2ToAS4")
 			ConvertAS2ToAS4(updateMsg)
+			asPath = getTypeFromPathAttrs(updateMsg.Body.(*BGPUpdate).PathAttributes,
+				BGPPathAttrTypeASPath).(*BGPPathAttrASPath)
 			if as4Path != nil {
 				numASes := GetNumASesByASType(updateMsg, BGPPathAttrTypeASPath)
 				numAS4es := GetNumASesByASType(updateMsg, BGPPathAttrTypeAS4Path)
@@ -0,0 +1,221 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// labeled.go
+package packet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+const (
+	MPLSLabelLen             = 3
+	MPLSLabelBoS      uint32 = 0x1
+	MPLSLabelMax      uint32 = 0xFFFFF
+	MPLSLabelImpNull  uint32 = 3
+	MPLSLabelFirstDyn uint32 = 16
+
+	// Label field sent in withdrawn labeled NLRI, RFC 8277 section 2.4
+	MPLSLabelWithdraw uint32 = 0x800000
+)
+
+/*  LabeledNLRI is an NLRI of the labeled unicast SAFI (RFC 8277). The prefix is
+ *  preceded by a stack of 3 byte label fields, the last one has the bottom of
+ *  stack bit set. When add paths is negotiated, the path id precedes the
+ *  length, like ExtNLRI.
+ */
+type LabeledNLRI struct {
+	*IPPrefix
+	PathId  uint32
+	AddPath bool
+	Labels  []uint32
+}
+
+func (n *LabeledNLRI) Clone() NLRI {
+	x := *n
+	prefix := n.IPPrefix.Clone()
+	x.IPPrefix = prefix.(*IPPrefix)
+	x.Labels = make([]uint32, len(n.Labels))
+	copy(x.Labels, n.Labels)
+	return &x
+}
+
+func (n *LabeledNLRI) Len() uint32 {
+	length := n.IPPrefix.Len() + uint32(len(n.Labels)*MPLSLabelLen)
+	if n.AddPath {
+		length += 4
+	}
+	return length
+}
+
+func (n *LabeledNLRI) Encode(afi AFI) ([]byte, error) {
+	if len(n.Labels) == 0 {
+		return nil, BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+			fmt.Sprintf("Labeled NLRI %s does not have a label", n.IPPrefix.GetCIDR())}
+	}
+
+	bits := len(n.Labels)*MPLSLabelLen*8 + int(n.Length)
+	if bits > 255 {
+		return nil, BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+			fmt.Sprintf("Labeled NLRI %s has too many labels %v", n.IPPrefix.GetCIDR(), n.Labels)}
+	}
+
+	pkt := make([]byte, 0, n.Len())
+	if n.AddPath {
+		pathId := make([]byte, 4)
+		binary.BigEndian.PutUint32(pathId, n.PathId)
+		pkt = append(pkt, pathId...)
+	}
+
+	pkt = append(pkt, uint8(bits))
+	for idx, label := range n.Labels {
+		val := label << 4
+		if label == MPLSLabelWithdraw {
+			val = label
+		} else if idx == len(n.Labels)-1 {
+			val |= MPLSLabelBoS
+		}
+		pkt = append(pkt, byte(val>>16), byte(val>>8), byte(val))
+	}
+
+	ipBytes, err := n.IPPrefix.Encode(afi)
+	if err != nil {
+		return nil, err
+	}
+	pkt = append(pkt, ipBytes[1:]...)
+	return pkt, nil
+}
+
+func (n *LabeledNLRI) Decode(pkt []byte, afi AFI) error {
+	ptr := 0
+	if n.AddPath {
+		if len(pkt) < 5 {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				"Labeled NLRI does not contain path id or prefix length"}
+		}
+		n.PathId = binary.BigEndian.Uint32(pkt[:4])
+		ptr += 4
+	}
+
+	if len(pkt) < ptr+1 {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+			"Labeled NLRI does not contain prefix length"}
+	}
+	bits := int(pkt[ptr])
+	ptr++
+
+	n.Labels = make([]uint32, 0, 1)
+	for {
+		if bits < MPLSLabelLen*8 || len(pkt) < ptr+MPLSLabelLen {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				"Labeled NLRI does not contain a complete label stack"}
+		}
+		val := uint32(pkt[ptr])<<16 | uint32(pkt[ptr+1])<<8 | uint32(pkt[ptr+2])
+		ptr += MPLSLabelLen
+		bits -= MPLSLabelLen * 8
+
+		// RFC 3107 used 0x000000 and RFC 8277 uses 0x800000 in withdraws.
+		if val == MPLSLabelWithdraw || val == 0 {
+			n.Labels = append(n.Labels, MPLSLabelWithdraw)
+			break
+		}
+		n.Labels = append(n.Labels, val>>4)
+		if val&MPLSLabelBoS != 0 {
+			break
+		}
+	}
+
+	prefix := make([]byte, len(pkt)-ptr+1)
+	prefix[0] = uint8(bits)
+	copy(prefix[1:], pkt[ptr:])
+	n.IPPrefix = &IPPrefix{}
+	return n.IPPrefix.Decode(prefix, afi)
+}
+
+func (n *LabeledNLRI) GetPathId() uint32 {
+	return n.PathId
+}
+
+func (n *LabeledNLRI) String() string {
+	return "{" + strconv.Itoa(int(n.PathId)) + " " + n.Prefix.String() + "/" + strconv.Itoa(int(n.Length)) +
+		" labels " + fmt.Sprint(n.Labels) + "}"
+}
+
+func NewLabeledNLRI(pathId uint32, addPath bool, prefix *IPPrefix, labels []uint32) *LabeledNLRI {
+	return &LabeledNLRI{
+		IPPrefix: prefix,
+		PathId:   pathId,
+		AddPath:  addPath,
+		Labels:   labels,
+	}
+}
+
+/*  ConstructNLRIForFamily returns the NLRI that advertises the prefix in the
+ *  protocol family, the label is only used by the labeled unicast families.
+ */
+func ConstructNLRIForFamily(protoFamily uint32, pathId uint32, addPath bool, prefix *IPPrefix,
+	label uint32) NLRI {
+	if !IsLabeledFamily(protoFamily) {
+		if addPath {
+			return NewExtNLRI(pathId, prefix)
+		}
+		return prefix
+	}
+	return NewLabeledNLRI(pathId, addPath, prefix, []uint32{label})
+}
+
+func IsLabeledFamily(protoFamily uint32) bool {
+	_, safi := GetAfiSafi(protoFamily)
+	return safi == SafiLabeledUnicast
+}
+
+/*  GetLabeledFamily returns the labeled unicast family with the same AFI as the
+ *  protocol family.
+ */
+func GetLabeledFamily(protoFamily uint32) uint32 {
+	afi, _ := GetAfiSafi(protoFamily)
+	return GetProtocolFamily(afi, SafiLabeledUnicast)
+}
+
+/*  ConstructMPReachNLRI builds MP_REACH_NLRI for any family. Unlike
+ *  ConstructIPv6MPReachNLRI, IPv4 families carry a 4 byte next hop.
+ */
+func ConstructMPReachNLRI(protoFamily uint32, nextHop, nextHopLinkLocal net.IP,
+	nlriList []NLRI) *BGPPathAttrMPReachNLRI {
+	afi, safi := GetAfiSafi(protoFamily)
+	if afi != AfiIP {
+		return ConstructIPv6MPReachNLRI(protoFamily, nextHop, nextHopLinkLocal, nlriList)
+	}
+
+	mpReachNLRI := NewBGPPathAttrMPReachNLRI()
+	mpReachNLRI.AFI = afi
+	mpReachNLRI.SAFI = safi
+	mpNextHop := NewMPNextHopIP()
+	mpNextHop.SetNextHop(nextHop)
+	mpReachNLRI.SetNextHop(mpNextHop)
+	mpReachNLRI.SetNLRIList(nlriList)
+	return mpReachNLRI
+}
@@ -0,0 +1,146 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// labeled_test.go
+package packet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net"
+	"testing"
+)
+
+func TestLabeledNLRIEncodeDecode(t *testing.T) {
+	nlris := []*LabeledNLRI{
+		NewLabeledNLRI(0, false, NewIPPrefix(net.ParseIP("20.1.1.0").To4(), 24), []uint32{100}),
+		NewLabeledNLRI(7, true, NewIPPrefix(net.ParseIP("20.1.1.128").To4(), 25), []uint32{16, MPLSLabelMax}),
+		NewLabeledNLRI(0, false, NewIPPrefix(net.ParseIP("2001:db8::"), 64), []uint32{MPLSLabelImpNull}),
+		NewLabeledNLRI(0, false, NewIPPrefix(net.ParseIP("0.0.0.0").To4(), 0), []uint32{MPLSLabelWithdraw}),
+	}
+	afis := []AFI{AfiIP, AfiIP, AfiIP6, AfiIP}
+
+	for idx, nlri := range nlris {
+		pkt, err := nlri.Encode(afis[idx])
+		if err != nil {
+			t.Fatal("Labeled NLRI", nlri, "encode failed with error:", err)
+		}
+		if uint32(len(pkt)) != nlri.Len() {
+			t.Fatal("Labeled NLRI", nlri, "encoded", len(pkt), "bytes, expected", nlri.Len())
+		}
+
+		decoded := &LabeledNLRI{AddPath: nlri.AddPath}
+		if err = decoded.Decode(pkt, afis[idx]); err != nil {
+			t.Fatal("Labeled NLRI", nlri, "decode failed with error:", err)
+		}
+		if decoded.GetCIDR() != nlri.GetCIDR() || decoded.PathId != nlri.PathId ||
+			len(decoded.Labels) != len(nlri.Labels) {
+			t.Fatal("Labeled NLRI decoded as", decoded, "expected", nlri)
+		}
+		for i := range nlri.Labels {
+			if decoded.Labels[i] != nlri.Labels[i] {
+				t.Fatal("Labeled NLRI decoded as", decoded, "expected", nlri)
+			}
+		}
+	}
+}
+
+func TestLabeledNLRIDecodeErrors(t *testing.T) {
+	packets := []string{
+		"",
+		"30",
+		"300006",
+		"10000641",   // prefix length shorter than the label
+		"3000064014", // label stack without bottom of stack
+		"48000641140101",
+		"50000641140101",
+	}
+
+	for _, strPkt := range packets {
+		pkt, _ := hex.DecodeString(strPkt)
+		nlri := &LabeledNLRI{}
+		if err := nlri.Decode(pkt, AfiIP); err == nil {
+			t.Fatal("Labeled NLRI decode of", strPkt, "expected failure, got NO error, nlri:", nlri)
+		}
+	}
+}
+
+func TestMPReachLabeledNLRI(t *testing.T) {
+	pkt, _ := hex.DecodeString("800E10000104040A0000020030000641140101")
+
+	mpReach := NewBGPPathAttrMPReachNLRI()
+	err := mpReach.Decode(pkt, BGPPeerAttrs{ASSize: 4})
+	if err != nil {
+		t.Fatal("MP_REACH_NLRI with labeled NLRI decode failed with error:", err)
+	}
+	if mpReach.SAFI != SafiLabeledUnicast || !mpReach.NextHop.GetNextHop().Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatal("MP_REACH_NLRI decoded with SAFI", mpReach.SAFI, "next hop", mpReach.NextHop)
+	}
+	if len(mpReach.NLRI) != 1 {
+		t.Fatal("MP_REACH_NLRI decoded", len(mpReach.NLRI), "NLRI, expected 1")
+	}
+	nlri, ok := mpReach.NLRI[0].(*LabeledNLRI)
+	if !ok || nlri.GetCIDR() != "20.1.1.0/24" || len(nlri.Labels) != 1 || nlri.Labels[0] != 100 {
+		t.Fatal("MP_REACH_NLRI decoded NLRI", mpReach.NLRI[0], "expected 20.1.1.0/24 label 100")
+	}
+
+	protoFamily := GetProtocolFamily(AfiIP, SafiLabeledUnicast)
+	prefix := NewIPPrefix(net.ParseIP("20.1.1.0"), 24)
+	mpReach = ConstructMPReachNLRI(protoFamily, net.ParseIP("10.0.0.2"), nil,
+		[]NLRI{ConstructNLRIForFamily(protoFamily, 0, false, prefix, 100)})
+	encoded, err := mpReach.Encode()
+	if err != nil {
+		t.Fatal("MP_REACH_NLRI with labeled NLRI encode failed with error:", err)
+	}
+	// The encoder always sets the extended length flag
+	expected, _ := hex.DecodeString("900E0010000104040A0000020030000641140101")
+	if !bytes.Equal(encoded, expected) {
+		t.Fatalf("MP_REACH_NLRI encoded as %x, expected %x", encoded, expected)
+	}
+}
+
+func TestMPUnreachLabeledNLRI(t *testing.T) {
+	pkt, _ := hex.DecodeString("800F0A00010430800000140101")
+
+	mpUnreach := NewBGPPathAttrMPUnreachNLRI()
+	err := mpUnreach.Decode(pkt, BGPPeerAttrs{ASSize: 4})
+	if err != nil {
+		t.Fatal("MP_UNREACH_NLRI with labeled NLRI decode failed with error:", err)
+	}
+	if len(mpUnreach.NLRI) != 1 || mpUnreach.NLRI[0].GetCIDR() != "20.1.1.0/24" {
+		t.Fatal("MP_UNREACH_NLRI decoded NLRI", mpUnreach.NLRI, "expected 20.1.1.0/24")
+	}
+
+	protoFamily := GetProtocolFamily(AfiIP, SafiLabeledUnicast)
+	prefix := NewIPPrefix(net.ParseIP("20.1.1.0").To4(), 24)
+	mpUnreach = ConstructMPUnreachNLRIFromProtoFamily(protoFamily,
+		[]NLRI{ConstructNLRIForFamily(protoFamily, 0, false, prefix, MPLSLabelWithdraw)})
+	encoded, err := mpUnreach.Encode()
+	if err != nil {
+		t.Fatal("MP_UNREACH_NLRI with labeled NLRI encode failed with error:", err)
+	}
+	expected, _ := hex.DecodeString("900F000A00010430800000140101")
+	if !bytes.Equal(encoded, expected) {
+		t.Fatalf("MP_UNREACH_NLRI encoded as %x, expected %x", encoded, expected)
+	}
+}
@@ -74,10 +74,17 @@ func (i *MPNextHopIP) Encode(pkt []byte) error {
 }
 
 func (i *MPNextHopIP) Decode(pkt []byte) error {
+	if len(pkt) < 1 {
+		return errors.New("Not enough data to decode next hop length")
+	}
+
 	i.Length = pkt[0]
 	if i.Length != 4 && i.Length != 16 && i.Length != 32 {
 		return errors.New(fmt.Sprintf("Wrong Next hop len %d", i.Length))
 	}
+	if len(pkt) < int(i.Length)+1 {
+		return errors.New(fmt.Sprintf("Not enough data to decode next hop of len %d", i.Length))
+	}
 	ipLen := net.IPv4len
 	if i.Length == 16 || i.Length == 32 {
 		ipLen = net.IPv6len
@@ -236,6 +243,10 @@ func (u *MPNextHopUnknown) Encode(pkt []byte) error {
 }
 
 func (u *MPNextHopUnknown) Decode(pkt []byte) error {
+	if len(pkt) < 1 {
+		return errors.New("Not enough data to decode next hop length")
+	}
+
 	u.Length = pkt[0]
 	u.Value = make([]byte, u.Length)
 	copy(u.Value, pkt[1:])
@@ -341,13 +352,28 @@ func (r *BGPPathAttrMPReachNLRI) Decode(pkt []byte, data interface{}) error {
 		return err
 	}
 
+	// AFI, SAFI, length of next hop and reserved byte
+	if r.BGPPathAttrBase.Length < 5 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:r.TotalLen()],
+			"Not enough data to decode MP_REACH_NLRI"}
+	}
+
 	idx := int(r.BGPPathAttrBase.BGPPathAttrLen)
 	r.AFI = AFI(binary.BigEndian.Uint16(pkt[idx : idx+2]))
 	r.SAFI = SAFI(pkt[idx+2])
 	idx += 3
 
+	nextHopLen := int(pkt[idx])
+	if idx+nextHopLen+2 > int(r.TotalLen()) {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:r.TotalLen()],
+			fmt.Sprintf("Next hop length %d overruns MP_REACH_NLRI", nextHopLen)}
+	}
+
 	nextHop := BGPGetMPNextHop(r.AFI)
-	nextHop.Decode(pkt[idx:])
+	err = nextHop.Decode(pkt[idx : idx+nextHopLen+1])
+	if err != nil {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:r.TotalLen()], err.Error()}
+	}
 	r.NextHop = nextHop
 	idx += int(nextHop.Len())
 
@@ -356,7 +382,7 @@ func (r *BGPPathAttrMPReachNLRI) Decode(pkt []byte, data interface{}) error {
 
 	r.NLRI = make([]NLRI, 0)
 	length := uint32(r.BGPPathAttrBase.Length) - 4 - uint32(r.NextHop.Len())
-	_, err = decodeNLRI(pkt[idx:], &r.NLRI, length, r.AFI, r.SAFI, data)
+	_, err = decodeNLRI(pkt[idx:r.TotalLen()], &r.NLRI, length, r.AFI, r.SAFI, data)
 	return err
 }
 
@@ -440,6 +466,11 @@ func (u *BGPPathAttrMPUnreachNLRI) Decode(pkt []byte, data interface{}) error {
 		return err
 	}
 
+	if u.BGPPathAttrBase.Length < 3 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:u.TotalLen()],
+			"Not enough data to decode MP_UNREACH_NLRI"}
+	}
+
 	idx := int(u.BGPPathAttrBase.BGPPathAttrLen)
 	u.AFI = AFI(binary.BigEndian.Uint16(pkt[idx : idx+2]))
 	u.SAFI = SAFI(pkt[idx+2])
@@ -447,7 +478,7 @@ func (u *BGPPathAttrMPUnreachNLRI) Decode(pkt []byte, data interface{}) error {
 
 	u.NLRI = make([]NLRI, 0)
 	length := uint32(u.BGPPathAttrBase.Length) - 3
-	_, err = decodeNLRI(pkt[idx:], &u.NLRI, length, u.AFI, u.SAFI, data)
+	_, err = decodeNLRI(pkt[idx:u.TotalLen()], &u.NLRI, length, u.AFI, u.SAFI, data)
 	return err
 }
 
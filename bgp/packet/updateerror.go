@@ -0,0 +1,218 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// updateerror.go
+package packet
+
+import (
+	"fmt"
+)
+
+/*  Revised error handling for UPDATE messages (RFC 7606). Errors in path
+ *  attributes that are not critical to parsing the rest of the message no
+ *  longer reset the session. Depending on the attribute, either the attribute
+ *  is discarded or all the routes in the UPDATE are treated as withdrawn.
+ */
+type BGPUpdateErrorAction uint8
+
+const (
+	BGPUpdateErrorActionNone BGPUpdateErrorAction = iota
+	BGPUpdateErrorActionAttrDiscard
+	BGPUpdateErrorActionTreatAsWithdraw
+	BGPUpdateErrorActionSessionReset
+)
+
+var BGPUpdateErrorActionToStr = map[BGPUpdateErrorAction]string{
+	BGPUpdateErrorActionNone:            "None",
+	BGPUpdateErrorActionAttrDiscard:     "Attribute discard",
+	BGPUpdateErrorActionTreatAsWithdraw: "Treat as withdraw",
+	BGPUpdateErrorActionSessionReset:    "Session reset",
+}
+
+// Action to take when a path attribute is malformed, RFC 7606 section 7 and RFC 6793 section 6
+var BGPPathAttrErrorActionMap = map[BGPPathAttrType]BGPUpdateErrorAction{
+	BGPPathAttrTypeOrigin:          BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeASPath:          BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeNextHop:         BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeMultiExitDisc:   BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeLocalPref:       BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeAtomicAggregate: BGPUpdateErrorActionAttrDiscard,
+	BGPPathAttrTypeAggregator:      BGPUpdateErrorActionAttrDiscard,
+	BGPPathAttrTypeCommunity:       BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeOriginatorId:    BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeClusterList:     BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeMPReachNLRI:     BGPUpdateErrorActionSessionReset,
+	BGPPathAttrTypeMPUnreachNLRI:   BGPUpdateErrorActionSessionReset,
+	BGPPathAttrTypeExtCommunity:    BGPUpdateErrorActionTreatAsWithdraw,
+	BGPPathAttrTypeAS4Path:         BGPUpdateErrorActionAttrDiscard,
+	BGPPathAttrTypeAS4Aggregator:   BGPUpdateErrorActionAttrDiscard,
+}
+
+func getPathAttrErrorAction(code BGPPathAttrType, flags BGPPathAttrFlag, err BGPMessageError) BGPUpdateErrorAction {
+	if err.TypeCode != BGPUpdateMsgError || err.SubTypeCode == BGPUnrecognizedWellKnownAttr {
+		return BGPUpdateErrorActionSessionReset
+	}
+
+	if action, ok := BGPPathAttrErrorActionMap[code]; ok {
+		return action
+	}
+
+	// Errors in unrecognized optional attributes can only be in the flags
+	if flags&BGPPathAttrFlagOptional != 0 {
+		return BGPUpdateErrorActionAttrDiscard
+	}
+	return BGPUpdateErrorActionSessionReset
+}
+
+/*  getPathAttrLen returns the total length of the path attribute at the start
+ *  of pkt. pkt should only contain the remaining bytes of the path attributes
+ *  field, an error is returned if the attribute overruns it.
+ */
+func getPathAttrLen(pkt []byte) (uint32, error) {
+	if len(pkt) < 3 {
+		return 0, BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil,
+			"Not enough data to decode path attribute type and length"}
+	}
+
+	length := uint32(pkt[2]) + 3
+	if BGPPathAttrFlag(pkt[0])&BGPPathAttrFlagExtendedLen != 0 {
+		if len(pkt) < 4 {
+			return 0, BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil,
+				"Not enough data to decode path attribute extended length"}
+		}
+		length = uint32(pkt[2])<<8 | uint32(pkt[3]) + 4
+	}
+
+	if length > uint32(len(pkt)) {
+		return 0, BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil,
+			fmt.Sprintf("Path attribute type %d with length %d overruns the path attributes field", pkt[1], length)}
+	}
+	return length, nil
+}
+
+func (msg *BGPUpdate) addAttrError(err BGPMessageError, action BGPUpdateErrorAction) {
+	msg.AttrErrors = append(msg.AttrErrors, err)
+	if action > msg.ErrorAction {
+		msg.ErrorAction = action
+	}
+}
+
+func (msg *BGPUpdate) decodePathAttrs(pkt []byte, data interface{}) error {
+	msg.PathAttributes = make([]BGPPathAttr, 0)
+	found := make(map[BGPPathAttrType]bool)
+	ptr := uint32(0)
+	for ptr < uint32(len(pkt)) {
+		attrLen, err := getPathAttrLen(pkt[ptr:])
+		if err != nil {
+			// The attributes that follow, MP_REACH_NLRI or MP_UNREACH_NLRI among them, can't be located
+			return err
+		}
+
+		attrPkt := pkt[ptr : ptr+attrLen]
+		ptr += attrLen
+		code := BGPPathAttrType(attrPkt[1])
+		if found[code] {
+			bgpErr := BGPMessageError{BGPUpdateMsgError, BGPMalformedAttrList, nil,
+				fmt.Sprintf("Path Attr type %d appeared twice in the UPDATE message", code)}
+			if code == BGPPathAttrTypeMPReachNLRI || code == BGPPathAttrTypeMPUnreachNLRI {
+				return bgpErr
+			}
+			msg.addAttrError(bgpErr, BGPUpdateErrorActionAttrDiscard)
+			continue
+		}
+		found[code] = true
+
+		pa := BGPGetPathAttr(attrPkt)
+		err = pa.Decode(attrPkt, data)
+		if err != nil {
+			bgpErr, ok := err.(BGPMessageError)
+			if !ok {
+				bgpErr = BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, attrPkt, err.Error()}
+			}
+			action := getPathAttrErrorAction(code, BGPPathAttrFlag(attrPkt[0]), bgpErr)
+			if action == BGPUpdateErrorActionSessionReset {
+				return bgpErr
+			}
+			msg.addAttrError(bgpErr, action)
+			continue
+		}
+		msg.PathAttributes = append(msg.PathAttributes, pa)
+	}
+	return nil
+}
+
+/*  checkPathAttributes verifies that the well-known mandatory attributes are
+ *  present when the UPDATE carries reachable routes.
+ */
+func (msg *BGPUpdate) checkPathAttributes() {
+	mpReach := getTypeFromPathAttrs(msg.PathAttributes, BGPPathAttrTypeMPReachNLRI)
+	if len(msg.NLRI) == 0 && mpReach == nil {
+		return
+	}
+
+	for _, attrType := range BGPPathAttrWellKnownMandatory {
+		if attrType == BGPPathAttrTypeNextHop && len(msg.NLRI) == 0 {
+			continue
+		}
+		if getTypeFromPathAttrs(msg.PathAttributes, attrType) == nil {
+			msg.addAttrError(BGPMessageError{BGPUpdateMsgError, BGPMissingWellKnownAttr, []byte{byte(attrType)},
+				fmt.Sprintf("Well-known mandatory path attr type %d is missing in the UPDATE message", attrType)},
+				BGPUpdateErrorActionTreatAsWithdraw)
+		}
+	}
+}
+
+/*  treatAsWithdraw withdraws all the routes advertised in the UPDATE and drops
+ *  the path attributes except MP_UNREACH_NLRI. When the MP_REACH_NLRI is for a
+ *  different AFI/SAFI than the MP_UNREACH_NLRI, its routes are withdrawn with a
+ *  second MP_UNREACH_NLRI.
+ */
+func (msg *BGPUpdate) treatAsWithdraw() {
+	msg.WithdrawnRoutes = append(msg.WithdrawnRoutes, msg.NLRI...)
+	msg.NLRI = make([]NLRI, 0)
+
+	var mpUnreach *BGPPathAttrMPUnreachNLRI
+	if pa := getTypeFromPathAttrs(msg.PathAttributes, BGPPathAttrTypeMPUnreachNLRI); pa != nil {
+		mpUnreach = pa.(*BGPPathAttrMPUnreachNLRI)
+	}
+
+	var mpReachUnreach *BGPPathAttrMPUnreachNLRI
+	if pa := getTypeFromPathAttrs(msg.PathAttributes, BGPPathAttrTypeMPReachNLRI); pa != nil {
+		mpReach := pa.(*BGPPathAttrMPReachNLRI)
+		if mpUnreach == nil {
+			mpUnreach = ConstructMPUnreachNLRI(mpReach.AFI, mpReach.SAFI, mpReach.NLRI)
+		} else if mpUnreach.AFI == mpReach.AFI && mpUnreach.SAFI == mpReach.SAFI {
+			mpUnreach.AddNLRIList(mpReach.NLRI)
+		} else {
+			mpReachUnreach = ConstructMPUnreachNLRI(mpReach.AFI, mpReach.SAFI, mpReach.NLRI)
+		}
+	}
+
+	msg.PathAttributes = make([]BGPPathAttr, 0)
+	if mpUnreach != nil {
+		msg.PathAttributes = append(msg.PathAttributes, mpUnreach)
+	}
+	if mpReachUnreach != nil {
+		msg.PathAttributes = append(msg.PathAttributes, mpReachUnreach)
+	}
+}
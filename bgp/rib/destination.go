@@ -63,6 +63,7 @@ type Destination struct {
 	BGPRouteState     config.ModelRouteIntf
 	PathInfoRouteMap  map[*bgpd.PathInfo]*Route
 	routeListIdx      int
+	LocalLabel        uint32
 }
 
 func NewDestination(rib *LocRib, nlri packet.NLRI, protoFamily uint32, gConf *config.GlobalConfig) *Destination {
@@ -88,8 +89,14 @@ func NewDestination(rib *LocRib, nlri packet.NLRI, protoFamily uint32, gConf *co
 }
 
 func (d *Destination) setBGPRouteState(protoFamily uint32, network string, cidrLen int16) {
-	afi, _ := packet.GetAfiSafi(protoFamily)
-	if afi == packet.AfiIP6 {
+	afi, safi := packet.GetAfiSafi(protoFamily)
+	if safi == packet.SafiMulticast {
+		if afi == packet.AfiIP6 {
+			d.BGPRouteState = NewIPv6MulticastRoute(network, cidrLen)
+		} else {
+			d.BGPRouteState = NewIPv4MulticastRoute(network, cidrLen)
+		}
+	} else if afi == packet.AfiIP6 {
 		d.BGPRouteState = NewIPv6Route(network, cidrLen)
 	} else {
 		d.BGPRouteState = NewIPv4Route(network, cidrLen)
@@ -117,6 +124,23 @@ func (d *Destination) GetProtocolFamily() uint32 {
 	return d.protoFamily
 }
 
+/*  GetLocalLabel returns the label advertised for the destination in a labeled
+ *  unicast family. If no label could be allocated, implicit null is advertised
+ *  so that the upstream router pops the label and forwards the IP packet.
+ */
+func (d *Destination) GetLocalLabel() uint32 {
+	if d.LocalLabel == 0 {
+		return packet.MPLSLabelImpNull
+	}
+	return d.LocalLabel
+}
+
+func (d *Destination) setPathLabels(path *Path, labels []uint32) {
+	if route, ok := d.pathRouteMap[path]; ok {
+		route.Labels = labels
+	}
+}
+
 func (d *Destination) String() string {
 	return d.NLRI.String()
 }
@@ -424,6 +448,19 @@ func (d *Destination) ConstructRouteConfig(path *Path, reachInfo *ReachabilityIn
 		OutgoingInterface: strconv.Itoa(int(reachInfo.NextHopIfIdx)),
 		IsIPv6:            isIPv6,
 		NullRoute:         nullRoute,
+		Multicast:         packet.IsMulticastFamily(d.protoFamily),
+	}
+
+	if packet.IsLabeledFamily(d.protoFamily) {
+		cfg.Labeled = true
+		cfg.InLabel = d.GetLocalLabel()
+		route, ok := d.pathRouteMap[path]
+		if !ok {
+			route = d.ecmpPaths[path]
+		}
+		if route != nil {
+			cfg.OutLabels = route.Labels
+		}
 	}
 
 	return &cfg
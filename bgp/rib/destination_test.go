@@ -68,6 +68,9 @@ func (r *RouteMgr) GetRoutes() (ri1 []*config.RouteInfo, ri2 []*config.RouteInfo
 	r.t.Log("RouteMgr:GetRoutes")
 	return ri1, ri2
 }
+func (r *RouteMgr) RoutesEndOfRIB() {
+	r.t.Log("RouteMgr:RoutesEndOfRIB")
+}
 
 func constructRibAndDest(t *testing.T, logger *logging.Writer, gConf *config.GlobalConfig) (*LocRib, *Destination) {
 	routeMgr := &RouteMgr{t}
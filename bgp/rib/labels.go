@@ -0,0 +1,102 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// labels.go
+package rib
+
+import (
+	"errors"
+	"fmt"
+	"l3/bgp/packet"
+)
+
+/*  LabelAllocator hands out the local MPLS labels bgpd advertises for labeled
+ *  unicast destinations. Released labels are reused before new ones are
+ *  taken from the range.
+ */
+type LabelAllocator struct {
+	first uint32
+	last  uint32
+	next  uint32
+	free  []uint32
+	inUse map[uint32]bool
+}
+
+func NewLabelAllocator(first, last uint32) *LabelAllocator {
+	return &LabelAllocator{
+		first: first,
+		last:  last,
+		next:  first,
+		free:  make([]uint32, 0),
+		inUse: make(map[uint32]bool),
+	}
+}
+
+func (a *LabelAllocator) Alloc() (uint32, error) {
+	var label uint32
+	if len(a.free) > 0 {
+		label = a.free[len(a.free)-1]
+		a.free = a.free[:len(a.free)-1]
+	} else if a.next <= a.last {
+		label = a.next
+		a.next++
+	} else {
+		return 0, errors.New(fmt.Sprintf("No free labels in range %d-%d", a.first, a.last))
+	}
+
+	a.inUse[label] = true
+	return label, nil
+}
+
+func (a *LabelAllocator) Release(label uint32) {
+	if !a.inUse[label] {
+		return
+	}
+
+	delete(a.inUse, label)
+	a.free = append(a.free, label)
+}
+
+func (a *LabelAllocator) InUse() int {
+	return len(a.inUse)
+}
+
+func (l *LocRib) allocLocalLabel(dest *Destination) {
+	if !packet.IsLabeledFamily(dest.protoFamily) {
+		return
+	}
+
+	label, err := l.labelAllocator.Alloc()
+	if err != nil {
+		l.logger.Err("Failed to allocate local label for", dest.NLRI.GetCIDR(), "error:", err)
+		return
+	}
+	dest.LocalLabel = label
+}
+
+func (l *LocRib) releaseLocalLabel(dest *Destination) {
+	if dest.LocalLabel != 0 {
+		l.labelAllocator.Release(dest.LocalLabel)
+		dest.LocalLabel = 0
+	}
+}
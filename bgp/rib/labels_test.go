@@ -0,0 +1,135 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// labels_test.go
+package rib
+
+import (
+	"l3/bgp/baseobjects"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	"net"
+	"testing"
+)
+
+type LabelRouteMgr struct {
+	RouteMgr
+	routes map[string]*config.RouteConfig
+}
+
+func (r *LabelRouteMgr) CreateRoute(route *config.RouteConfig) {
+	r.t.Log("LabelRouteMgr:CreateRoute:", route)
+	r.routes[route.DestinationNw] = route
+}
+
+func (r *LabelRouteMgr) UpdateRoute(cfg *config.RouteConfig, op string) {
+	r.t.Log("LabelRouteMgr:UpdateRoute:", cfg, "operation:", op)
+	if op == "remove" {
+		delete(r.routes, cfg.DestinationNw)
+	} else {
+		r.routes[cfg.DestinationNw] = cfg
+	}
+}
+
+func TestLabelAllocator(t *testing.T) {
+	allocator := NewLabelAllocator(16, 17)
+	first, err := allocator.Alloc()
+	if err != nil || first != 16 {
+		t.Fatal("LabelAllocator:Alloc returned label", first, "error", err, "expected 16")
+	}
+	second, err := allocator.Alloc()
+	if err != nil || second != 17 {
+		t.Fatal("LabelAllocator:Alloc returned label", second, "error", err, "expected 17")
+	}
+	if _, err = allocator.Alloc(); err == nil {
+		t.Fatal("LabelAllocator:Alloc expected failure when the range is exhausted, got NO error")
+	}
+
+	allocator.Release(first)
+	allocator.Release(first)
+	if allocator.InUse() != 1 {
+		t.Fatal("LabelAllocator:InUse returned", allocator.InUse(), "expected 1")
+	}
+	if label, err := allocator.Alloc(); err != nil || label != first {
+		t.Fatal("LabelAllocator:Alloc returned label", label, "error", err, "expected released label", first)
+	}
+}
+
+func TestProcessLabeledUpdate(t *testing.T) {
+	logger := getLogger(t)
+	neighbor := "192.168.0.100"
+	localAS := uint32(1234)
+	peerAS := uint32(4321)
+	gConf, pConf := getConfObjects(neighbor, localAS, peerAS)
+	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
+	routeMgr := &LabelRouteMgr{RouteMgr{t}, make(map[string]*config.RouteConfig)}
+	locRib := NewLocRib(logger, routeMgr, &DBClient{t}, gConf)
+	protoFamily := packet.GetProtocolFamily(packet.AfiIP, packet.SafiLabeledUnicast)
+
+	prefix := packet.NewIPPrefix(net.ParseIP("30.1.10.0").To4(), 24)
+	nlri := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{100})}
+	mpReach := packet.ConstructMPReachNLRI(protoFamily, net.ParseIP(neighbor), nil, nlri)
+	pathAttrs := constructPathAttrs(nil, peerAS)[:2]
+	path := NewPath(locRib, nConf, pathAttrs, mpReach, RouteTypeEGP)
+
+	updated := make(map[uint32]map[*Path][]*Destination)
+	withdrawn := make([]*Destination, 0)
+	updatedAddPaths := make([]*Destination, 0)
+	updated, withdrawn, updatedAddPaths, _ = locRib.ProcessUpdate(nConf, path, nlri, nil, protoFamily, 0, updated,
+		withdrawn, updatedAddPaths)
+	if len(updated[protoFamily]) != 1 {
+		t.Fatal("LocRib:ProcessUpdate - Labeled route not updated, updated=", updated)
+	}
+
+	dest, ok := locRib.GetDest(prefix, protoFamily, false)
+	if !ok {
+		t.Fatal("LocRib:ProcessUpdate - Destination not found for labeled route", prefix.GetCIDR())
+	}
+	if dest.LocalLabel < packet.MPLSLabelFirstDyn || dest.GetLocalLabel() != dest.LocalLabel {
+		t.Fatal("LocRib:ProcessUpdate - Local label", dest.LocalLabel, "not allocated for", prefix.GetCIDR())
+	}
+
+	cfg, ok := routeMgr.routes["30.1.10.0"]
+	if !ok {
+		t.Fatal("LocRib:ProcessUpdate - Labeled route not created in route manager")
+	}
+	if cfg.InLabel != dest.LocalLabel || len(cfg.OutLabels) != 1 || cfg.OutLabels[0] != 100 {
+		t.Fatal("LocRib:ProcessUpdate - Route created with in label", cfg.InLabel, "out labels", cfg.OutLabels,
+			"expected", dest.LocalLabel, "and [100]")
+	}
+
+	withdrawNLRI := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{packet.MPLSLabelWithdraw})}
+	updated = make(map[uint32]map[*Path][]*Destination)
+	withdrawn = make([]*Destination, 0)
+	updated, withdrawn, updatedAddPaths, _ = locRib.ProcessUpdate(nConf, path, nil, withdrawNLRI, protoFamily, 0,
+		updated, withdrawn, updatedAddPaths)
+	if len(withdrawn) != 1 {
+		t.Fatal("LocRib:ProcessUpdate - Labeled route not withdrawn, withdrawn=", withdrawn)
+	}
+	if _, ok := routeMgr.routes["30.1.10.0"]; ok {
+		t.Fatal("LocRib:ProcessUpdate - Labeled route not removed from route manager")
+	}
+	if locRib.labelAllocator.InUse() != 0 {
+		t.Fatal("LocRib:ProcessUpdate - Local label not released,", locRib.labelAllocator.InUse(), "labels in use")
+	}
+}
@@ -0,0 +1,93 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// mcast_test.go
+package rib
+
+import (
+	"l3/bgp/baseobjects"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	"net"
+	"testing"
+)
+
+func TestProcessMulticastUpdate(t *testing.T) {
+	logger := getLogger(t)
+	neighbor := "192.168.0.100"
+	localAS := uint32(1234)
+	peerAS := uint32(4321)
+	gConf, pConf := getConfObjects(neighbor, localAS, peerAS)
+	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
+	routeMgr := &LabelRouteMgr{RouteMgr{t}, make(map[string]*config.RouteConfig)}
+	locRib := NewLocRib(logger, routeMgr, &DBClient{t}, gConf)
+	unicastFamily := packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast)
+	protoFamily := packet.GetMulticastFamily(unicastFamily)
+	if !packet.IsMulticastFamily(protoFamily) || packet.IsMulticastFamily(unicastFamily) {
+		t.Fatal("IsMulticastFamily failed for protocol families", protoFamily, "and", unicastFamily)
+	}
+
+	prefix := packet.NewIPPrefix(net.ParseIP("30.1.10.0").To4(), 24)
+	nlri := []packet.NLRI{prefix}
+	mpReach := packet.ConstructMPReachNLRI(protoFamily, net.ParseIP(neighbor), nil, nlri)
+	pathAttrs := constructPathAttrs(nil, peerAS)[:2]
+	path := NewPath(locRib, nConf, pathAttrs, mpReach, RouteTypeEGP)
+
+	updated := make(map[uint32]map[*Path][]*Destination)
+	withdrawn := make([]*Destination, 0)
+	updatedAddPaths := make([]*Destination, 0)
+	updated, withdrawn, updatedAddPaths, _ = locRib.ProcessUpdate(nConf, path, nlri, nil, protoFamily, 0, updated,
+		withdrawn, updatedAddPaths)
+	if len(updated[protoFamily]) != 1 {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route not updated, updated=", updated)
+	}
+
+	dest, ok := locRib.GetDest(prefix, protoFamily, false)
+	if !ok {
+		t.Fatal("LocRib:ProcessUpdate - Destination not found for multicast route", prefix.GetCIDR())
+	}
+	if _, ok := dest.GetBGPRoute().(*IPv4MulticastRoute); !ok {
+		t.Fatal("LocRib:ProcessUpdate - Multicast destination has route state", dest.GetBGPRoute())
+	}
+	if _, ok := locRib.GetDest(prefix, unicastFamily, false); ok {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route", prefix.GetCIDR(), "found in the unicast table")
+	}
+
+	cfg, ok := routeMgr.routes["30.1.10.0"]
+	if !ok {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route not created in route manager")
+	}
+	if !cfg.Multicast {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route created in route manager as a unicast route")
+	}
+
+	updated = make(map[uint32]map[*Path][]*Destination)
+	updated, withdrawn, updatedAddPaths, _ = locRib.ProcessUpdate(nConf, path, nil, nlri, protoFamily, 0,
+		updated, withdrawn, updatedAddPaths)
+	if len(withdrawn) != 1 {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route not withdrawn, withdrawn=", withdrawn)
+	}
+	if _, ok := routeMgr.routes["30.1.10.0"]; ok {
+		t.Fatal("LocRib:ProcessUpdate - Multicast route not removed from route manager")
+	}
+}
@@ -73,6 +73,7 @@ type LocRib struct {
 	routeListDirty   map[uint32]bool
 	activeGet        map[uint32]bool
 	timer            map[uint32]*time.Timer
+	labelAllocator   *LabelAllocator
 }
 
 func NewLocRib(logger *logging.Writer, rMgr config.RouteMgrIntf, sDBMgr statedbclient.StateDBClient,
@@ -91,6 +92,7 @@ func NewLocRib(logger *logging.Writer, rMgr config.RouteMgrIntf, sDBMgr statedbc
 		activeGet:        make(map[uint32]bool),
 		routeMutex:       sync.RWMutex{},
 		timer:            make(map[uint32]*time.Timer),
+		labelAllocator:   NewLabelAllocator(packet.MPLSLabelFirstDyn, packet.MPLSLabelMax),
 	}
 
 	return rib
@@ -153,6 +155,7 @@ func (l *LocRib) GetDest(nlri packet.NLRI, protoFamily uint32, createIfNotExist
 		dest, ok = nlriDestMap[nlri.GetCIDR()]
 		if !ok && createIfNotExist {
 			dest = NewDestination(l, nlri, protoFamily, l.gConf)
+			l.allocLocalLabel(dest)
 			l.destPathMap[protoFamily][nlri.GetCIDR()] = dest
 			l.addRoutesToRouteList(dest, protoFamily)
 			if _, found := l.routesCount[protoFamily]; !found {
@@ -189,6 +192,17 @@ func (l *LocRib) GetRouteStateConfigObj(route config.ModelRouteIntf) objects.Con
 	return route.GetModelObject()
 }
 
+/*  updateRouteState writes the route state of the destination to the state DB.
+ *  The route state objects are keyed by prefix, so only the unicast routes are
+ *  written and the labeled unicast routes of the same prefixes are skipped.
+ */
+func (l *LocRib) updateRouteState(op func(objects.ConfigObj) error, dest *Destination) {
+	if packet.IsLabeledFamily(dest.protoFamily) {
+		return
+	}
+	op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+}
+
 func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, remPath *Path, addPathCount int,
 	protoFamily uint32, updated map[uint32]map[*Path][]*Destination, withdrawn []*Destination,
 	updatedAddPaths []*Destination) (map[uint32]map[*Path][]*Destination, []*Destination, []*Destination, bool) {
@@ -250,11 +264,12 @@ func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, r
 				if dest.IsEmpty() {
 					op = l.stateDBMgr.DeleteObject
 					l.removeRoutesFromRouteList(dest, protoFamily)
+					l.releaseLocalLabel(dest)
 					delete(l.destPathMap[protoFamily], nlri.GetCIDR())
 					l.routesCount[protoFamily]--
 				}
 			}
-			op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+			l.updateRouteState(op, dest)
 		} else {
 			l.logger.Info("Can't withdraw destination", nlri.GetCIDR(),
 				"Destination is part of NLRI in the UDPATE")
@@ -281,6 +296,9 @@ func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, r
 		}
 
 		dest.AddOrUpdatePath(peerIP, nlri.GetPathId(), addPath)
+		if labeledNLRI, ok := nlri.(*packet.LabeledNLRI); ok {
+			dest.setPathLabels(addPath, labeledNLRI.Labels)
+		}
 		if !addPath.IsReachable(protoFamily) {
 			if _, ok := l.unreachablePaths[nextHopStr][addPath][dest]; !ok {
 				l.unreachablePaths[nextHopStr][addPath][dest] = make([]uint32, 0)
@@ -294,7 +312,7 @@ func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, r
 		action, addPathsMod, addRoutes, updRoutes, delRoutes := dest.SelectRouteForLocRib(addPathCount)
 		updated, withdrawn, updatedAddPaths = l.updateRibOutInfo(action, addPathsMod, addRoutes, updRoutes, delRoutes,
 			dest, updated, withdrawn, updatedAddPaths)
-		op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+		l.updateRouteState(op, dest)
 	}
 
 	return updated, withdrawn, updatedAddPaths, addedAllPrefixes
@@ -321,7 +339,7 @@ func (l *LocRib) ProcessRoutesForReachableRoutes(nextHop string, reachabilityInf
 				action, addPathsMod, addRoutes, updRoutes, delRoutes := dest.SelectRouteForLocRib(addPathCount)
 				updated, withdrawn, updatedAddPaths = l.updateRibOutInfo(action, addPathsMod, addRoutes, updRoutes,
 					delRoutes, dest, updated, withdrawn, updatedAddPaths)
-				l.stateDBMgr.AddObject(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+				l.updateRouteState(l.stateDBMgr.AddObject, dest)
 			}
 		}
 	}
@@ -442,6 +460,22 @@ func (l *LocRib) ProcessFilteredRoutes(neighborConf *base.NeighborConf,
 	return updated, withdrawn, updatedAddPaths, addedAllPrefixes
 }
 
+/*  addLocalFamilies originates the local unicast routes in the labeled
+ *  unicast and multicast families too. Each labeled route gets a local label,
+ *  the multicast routes are the RPF entries of the local networks.
+ */
+func addLocalFamilies(pfNLRI map[uint32][]packet.NLRI) map[uint32][]packet.NLRI {
+	families := make(map[uint32][]packet.NLRI, len(pfNLRI)*3)
+	for protoFamily, nlri := range pfNLRI {
+		families[protoFamily] = nlri
+		if _, safi := packet.GetAfiSafi(protoFamily); safi == packet.SafiUnicast {
+			families[packet.GetLabeledFamily(protoFamily)] = nlri
+			families[packet.GetMulticastFamily(protoFamily)] = nlri
+		}
+	}
+	return families
+}
+
 func (l *LocRib) ProcessConnectedRoutes(src string, path *Path, add, remove map[uint32][]packet.NLRI,
 	addPathCount int) (map[uint32]map[*Path][]*Destination, []*Destination, []*Destination) {
 	var removePath *Path
@@ -451,6 +485,8 @@ func (l *LocRib) ProcessConnectedRoutes(src string, path *Path, add, remove map[
 	withdrawn := make([]*Destination, 0)
 	updatedAddPaths := make([]*Destination, 0)
 
+	add = addLocalFamilies(add)
+	remove = addLocalFamilies(remove)
 	for protoFamily, withdrawnNLRI := range remove {
 		updated, withdrawn, updatedAddPaths, addedAllPrefixes = l.ProcessRoutes(src, add[protoFamily], withdrawnNLRI,
 			path, removePath, addPathCount, protoFamily, updated, withdrawn, updatedAddPaths)
@@ -490,11 +526,12 @@ func (l *LocRib) RemoveUpdatesFromNeighbor(peerIP string, neighborConf *base.Nei
 			if action == RouteActionDelete && dest.IsEmpty() {
 				l.logger.Info("All routes removed for dest", dest.NLRI.GetCIDR())
 				l.removeRoutesFromRouteList(dest, protoFamily)
+				l.releaseLocalLabel(dest)
 				delete(l.destPathMap[protoFamily], destIP)
 				l.routesCount[protoFamily]--
 				op = l.stateDBMgr.DeleteObject
 			}
-			op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+			l.updateRouteState(op, dest)
 		}
 	}
 
@@ -518,11 +555,12 @@ func (l *LocRib) RemoveUpdatesFromAllNeighbors(addPathCount int) {
 				updatedAddPaths)
 			if action == RouteActionDelete && dest.IsEmpty() {
 				l.removeRoutesFromRouteList(dest, protoFamily)
+				l.releaseLocalLabel(dest)
 				delete(l.destPathMap[protoFamily], destIP)
 				l.routesCount[protoFamily]--
 				op = l.stateDBMgr.DeleteObject
 			}
-			op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+			l.updateRouteState(op, dest)
 		}
 	}
 }
@@ -591,11 +629,12 @@ func (l *LocRib) RemoveRouteFromAggregate(ip *packet.IPPrefix, aggIP *packet.IPP
 	}
 	if action == RouteActionDelete && aggDest.IsEmpty() {
 		l.removeRoutesFromRouteList(dest, protoFamily)
+		l.releaseLocalLabel(aggDest)
 		delete(l.destPathMap[protoFamily], aggIP.Prefix.String())
 		l.routesCount[protoFamily]--
 		op = l.stateDBMgr.DeleteObject
 	}
-	op(l.GetRouteStateConfigObj(dest.GetBGPRoute()))
+	l.updateRouteState(op, dest)
 
 	return updated, withdrawn, updatedAddPaths
 }
@@ -659,7 +698,7 @@ func (l *LocRib) AddRouteToAggregate(ip *packet.IPPrefix, aggIP *packet.IPPrefix
 		dest.aggPath = aggPath
 	}
 
-	op(l.GetRouteStateConfigObj(aggDest.GetBGPRoute()))
+	l.updateRouteState(op, aggDest)
 	return updated, withdrawn, updatedAddPaths
 }
 
@@ -761,6 +800,16 @@ func (l *LocRib) GetBGPv6Route(prefix string) *bgpd.BGPv6RouteState {
 	return route.(*bgpd.BGPv6RouteState)
 }
 
+func (l *LocRib) GetBGPv4MulticastRoute(prefix string) *bgpd.BGPv4MulticastRouteState {
+	route := l.GetBGPRoute(prefix, packet.GetProtocolFamily(packet.AfiIP, packet.SafiMulticast))
+	return route.(*bgpd.BGPv4MulticastRouteState)
+}
+
+func (l *LocRib) GetBGPv6MulticastRoute(prefix string) *bgpd.BGPv6MulticastRouteState {
+	route := l.GetBGPRoute(prefix, packet.GetProtocolFamily(packet.AfiIP6, packet.SafiMulticast))
+	return route.(*bgpd.BGPv6MulticastRouteState)
+}
+
 func (l *LocRib) BulkGetBGPRoutes(index int, count int, protoFamily uint32) (int, int, []interface{}) {
 	var i int
 	n := 0
@@ -813,3 +862,21 @@ func (l *LocRib) BulkGetBGPv6Routes(index int, count int) (int, int, []*bgpd.BGP
 	}
 	return i, n, thriftRoutes
 }
+
+func (l *LocRib) BulkGetBGPv4MulticastRoutes(index int, count int) (int, int, []*bgpd.BGPv4MulticastRouteState) {
+	i, n, routes := l.BulkGetBGPRoutes(index, count, packet.GetProtocolFamily(packet.AfiIP, packet.SafiMulticast))
+	thriftRoutes := make([]*bgpd.BGPv4MulticastRouteState, len(routes))
+	for idx, route := range routes {
+		thriftRoutes[idx] = route.(*bgpd.BGPv4MulticastRouteState)
+	}
+	return i, n, thriftRoutes
+}
+
+func (l *LocRib) BulkGetBGPv6MulticastRoutes(index int, count int) (int, int, []*bgpd.BGPv6MulticastRouteState) {
+	i, n, routes := l.BulkGetBGPRoutes(index, count, packet.GetProtocolFamily(packet.AfiIP6, packet.SafiMulticast))
+	thriftRoutes := make([]*bgpd.BGPv6MulticastRouteState, len(routes))
+	for idx, route := range routes {
+		thriftRoutes[idx] = route.(*bgpd.BGPv6MulticastRouteState)
+	}
+	return i, n, thriftRoutes
+}
@@ -47,6 +47,7 @@ type Route struct {
 	time             time.Time
 	action           RouteAction
 	OutPathId        uint32
+	Labels           []uint32
 	PolicyList       []string
 	PolicyHitCounter int
 }
@@ -26,6 +26,7 @@ package rpc
 
 import (
 	"bgpd"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -170,6 +171,8 @@ func (h *BGPHandler) convertModelToBGPv4PeerGroup(obj objects.BGPv4PeerGroup) (g
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			LabeledUnicast:          obj.LabeledUnicast,
+			Multicast:               obj.Multicast,
 		},
 		Name: obj.Name,
 	}
@@ -234,6 +237,8 @@ func (h *BGPHandler) convertModelToBGPv6PeerGroup(obj objects.BGPv6PeerGroup) (g
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			LabeledUnicast:          obj.LabeledUnicast,
+			Multicast:               obj.Multicast,
 		},
 		Name: obj.Name,
 	}
@@ -311,6 +316,8 @@ func (h *BGPHandler) convertModelToBGPv4Neighbor(obj objects.BGPv4Neighbor) (nei
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			LabeledUnicast:          obj.LabeledUnicast,
+			Multicast:               obj.Multicast,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -391,6 +398,8 @@ func (h *BGPHandler) convertModelToBGPv6Neighbor(obj objects.BGPv6Neighbor) (nei
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			LabeledUnicast:          obj.LabeledUnicast,
+			Multicast:               obj.Multicast,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -901,6 +910,8 @@ func (h *BGPHandler) ConvertV4NeighborFromThrift(bgpNeighbor *bgpd.BGPv4Neighbor
 			MaxPrefixesRestartTimer: uint8(bgpNeighbor.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          bgpNeighbor.AdjRIBInFilter,
 			AdjRIBOutFilter:         bgpNeighbor.AdjRIBOutFilter,
+			LabeledUnicast:          bgpNeighbor.LabeledUnicast,
+			Multicast:               bgpNeighbor.Multicast,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -1193,6 +1204,8 @@ func (h *BGPHandler) ConvertV6NeighborFromThrift(bgpNeighbor *bgpd.BGPv6Neighbor
 			MaxPrefixesRestartTimer: uint8(bgpNeighbor.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          bgpNeighbor.AdjRIBInFilter,
 			AdjRIBOutFilter:         bgpNeighbor.AdjRIBOutFilter,
+			LabeledUnicast:          bgpNeighbor.LabeledUnicast,
+			Multicast:               bgpNeighbor.Multicast,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -1385,6 +1398,84 @@ func (h *BGPHandler) GetBulkBGPv6NeighborState(index bgpd.Int, count bgpd.Int) (
 	return bgpNeighborStateBulk, nil
 }
 
+func (h *BGPHandler) convertToThriftNotificationInfo(notif *config.NotificationInfo) *bgpd.BGPNotificationInfo {
+	notifInfo := bgpd.NewBGPNotificationInfo()
+	if notif.Time.IsZero() {
+		return notifInfo
+	}
+	notifInfo.Time = notif.Time.String()
+	notifInfo.ErrorCode = int8(notif.ErrorCode)
+	notifInfo.ErrorSubcode = int8(notif.ErrorSubcode)
+	notifInfo.Data = hex.EncodeToString(notif.Data)
+	return notifInfo
+}
+
+func (h *BGPHandler) convertToThriftNeighborHistory(neighborState *config.NeighborState) *bgpd.BGPNeighborHistoryState {
+	history := &neighborState.History
+	historyResponse := bgpd.NewBGPNeighborHistoryState()
+	historyResponse.NeighborAddress = neighborState.NeighborAddress.String()
+	historyResponse.IntfRef = ""
+	if intfEntry, ok := h.server.IntfIdNameMap[int32(neighborState.IfIndex)]; ok {
+		historyResponse.IntfRef = intfEntry.Name
+	}
+	historyResponse.SessionState = int32(neighborState.SessionState)
+	historyResponse.FlapCount = int32(history.FlapCount)
+	if !history.EstablishedTime.IsZero() {
+		historyResponse.EstablishedUptime = time.Since(history.EstablishedTime).String()
+	}
+	historyResponse.LastTcpError = history.LastTcpError
+	if !history.LastTcpErrorTime.IsZero() {
+		historyResponse.LastTcpErrorTime = history.LastTcpErrorTime.String()
+	}
+	historyResponse.LastNotificationSent = h.convertToThriftNotificationInfo(&history.LastNotificationSent)
+	historyResponse.LastNotificationRcvd = h.convertToThriftNotificationInfo(&history.LastNotificationRcvd)
+
+	transitions := make([]*bgpd.BGPFSMTransition, len(history.Transitions))
+	for idx, item := range history.Transitions {
+		transition := bgpd.NewBGPFSMTransition()
+		transition.Time = item.Time.String()
+		transition.FromState = config.GetBGPStateToStr(item.FromState)
+		transition.ToState = config.GetBGPStateToStr(item.ToState)
+		transition.Event = item.Event
+		transitions[idx] = transition
+	}
+	historyResponse.Transitions = transitions
+
+	return historyResponse
+}
+
+func (h *BGPHandler) GetBGPNeighborHistoryState(neighborAddr string) (*bgpd.BGPNeighborHistoryState, error) {
+	ip := net.ParseIP(strings.TrimSpace(neighborAddr))
+	if ip == nil {
+		return bgpd.NewBGPNeighborHistoryState(), errors.New(fmt.Sprintf("Neighbor address %s is not valid",
+			neighborAddr))
+	}
+
+	bgpNeighborState := h.server.GetBGPNeighborHistory(ip.String())
+	if bgpNeighborState == nil {
+		return bgpd.NewBGPNeighborHistoryState(), errors.New(fmt.Sprintf(
+			"GetBGPNeighborHistoryState: Neighbor %s not configured", ip))
+	}
+	return h.convertToThriftNeighborHistory(bgpNeighborState), nil
+}
+
+func (h *BGPHandler) GetBulkBGPNeighborHistoryState(index bgpd.Int, count bgpd.Int) (
+	*bgpd.BGPNeighborHistoryStateGetInfo, error) {
+	nextIdx, currCount, bgpNeighbors := h.server.BulkGetBGPNeighborsHistory(int(index), int(count))
+	historyResponse := make([]*bgpd.BGPNeighborHistoryState, len(bgpNeighbors))
+	for idx, item := range bgpNeighbors {
+		historyResponse[idx] = h.convertToThriftNeighborHistory(item)
+	}
+
+	historyBulk := bgpd.NewBGPNeighborHistoryStateGetInfo()
+	historyBulk.EndIdx = bgpd.Int(nextIdx)
+	historyBulk.Count = bgpd.Int(currCount)
+	historyBulk.More = (nextIdx != 0)
+	historyBulk.BGPNeighborHistoryStateList = historyResponse
+
+	return historyBulk, nil
+}
+
 func (h *BGPHandler) UpdateBGPv6Neighbor(origN *bgpd.BGPv6Neighbor, updatedN *bgpd.BGPv6Neighbor, attrSet []bool,
 	op []*bgpd.PatchOpInfo) (bool, error) {
 	h.logger.Info("Update peer attrs:", updatedN)
@@ -1458,6 +1549,8 @@ func (h *BGPHandler) ValidateBGPv4PeerGroup(peerGroup *bgpd.BGPv4PeerGroup) (gro
 			MaxPrefixesRestartTimer: uint8(peerGroup.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          peerGroup.AdjRIBInFilter,
 			AdjRIBOutFilter:         peerGroup.AdjRIBOutFilter,
+			LabeledUnicast:          peerGroup.LabeledUnicast,
+			Multicast:               peerGroup.Multicast,
 		},
 		Name: peerGroup.Name,
 	}
@@ -1554,6 +1647,8 @@ func (h *BGPHandler) ValidateBGPv6PeerGroup(peerGroup *bgpd.BGPv6PeerGroup) (gro
 			MaxPrefixesRestartTimer: uint8(peerGroup.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          peerGroup.AdjRIBInFilter,
 			AdjRIBOutFilter:         peerGroup.AdjRIBOutFilter,
+			LabeledUnicast:          peerGroup.LabeledUnicast,
+			Multicast:               peerGroup.Multicast,
 		},
 		Name: peerGroup.Name,
 	}
@@ -1648,6 +1743,50 @@ func (h *BGPHandler) GetBulkBGPv6RouteState(index bgpd.Int, count bgpd.Int) (*bg
 	return bgpRoutesBulk, nil
 }
 
+func (h *BGPHandler) GetBGPv4MulticastRouteState(network string, cidrLen int16) (*bgpd.BGPv4MulticastRouteState, error) {
+	bgpRoute := h.server.LocRib.GetBGPv4MulticastRoute(network)
+	var err error = nil
+	if bgpRoute == nil {
+		err = errors.New(fmt.Sprintf("Multicast route not found for destination %s", network))
+	}
+	return bgpRoute, err
+}
+
+func (h *BGPHandler) GetBulkBGPv4MulticastRouteState(index bgpd.Int, count bgpd.Int) (
+	*bgpd.BGPv4MulticastRouteStateGetInfo, error) {
+	nextIdx, currCount, bgpRoutes := h.server.LocRib.BulkGetBGPv4MulticastRoutes(int(index), int(count))
+
+	bgpRoutesBulk := bgpd.NewBGPv4MulticastRouteStateGetInfo()
+	bgpRoutesBulk.EndIdx = bgpd.Int(nextIdx)
+	bgpRoutesBulk.Count = bgpd.Int(currCount)
+	bgpRoutesBulk.More = (nextIdx != 0)
+	bgpRoutesBulk.BGPv4MulticastRouteStateList = bgpRoutes
+
+	return bgpRoutesBulk, nil
+}
+
+func (h *BGPHandler) GetBGPv6MulticastRouteState(network string, cidrLen int16) (*bgpd.BGPv6MulticastRouteState, error) {
+	bgpRoute := h.server.LocRib.GetBGPv6MulticastRoute(network)
+	var err error = nil
+	if bgpRoute == nil {
+		err = errors.New(fmt.Sprintf("Multicast route not found for destination %s", network))
+	}
+	return bgpRoute, err
+}
+
+func (h *BGPHandler) GetBulkBGPv6MulticastRouteState(index bgpd.Int, count bgpd.Int) (
+	*bgpd.BGPv6MulticastRouteStateGetInfo, error) {
+	nextIdx, currCount, bgpRoutes := h.server.LocRib.BulkGetBGPv6MulticastRoutes(int(index), int(count))
+
+	bgpRoutesBulk := bgpd.NewBGPv6MulticastRouteStateGetInfo()
+	bgpRoutesBulk.EndIdx = bgpd.Int(nextIdx)
+	bgpRoutesBulk.Count = bgpd.Int(currCount)
+	bgpRoutesBulk.More = (nextIdx != 0)
+	bgpRoutesBulk.BGPv6MulticastRouteStateList = bgpRoutes
+
+	return bgpRoutesBulk, nil
+}
+
 func (h *BGPHandler) validateBGPAggregate(bgpAgg *bgpd.BGPv4Aggregate) (aggConf config.BGPAggregate, err error) {
 	if bgpAgg == nil {
 		return aggConf, err
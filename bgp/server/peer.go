@@ -35,6 +35,7 @@ import (
 	"net"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"utils/logging"
 	"utils/patriciaDB"
@@ -70,6 +71,7 @@ type Peer struct {
 	ifIdx        int32
 	ribIn        map[uint32]map[string]*bgprib.AdjRIBRoute
 	ribOut       map[uint32]map[string]*bgprib.AdjRIBRoute
+	ribInMutex   sync.RWMutex // Held while the server loop changes ribIn
 }
 
 func NewPeer(server *BGPServer, locRib *bgprib.LocRib, globalConf *config.GlobalConfig,
@@ -102,6 +104,7 @@ func NewPeer(server *BGPServer, locRib *bgprib.LocRib, globalConf *config.Global
 
 	peer.fsmManager = fsm.NewFSMManager(peer.logger, peer.NeighborConf, server.BGPPktSrcCh,
 		server.PeerFSMConnCh, server.ReachabilityCh)
+	peer.fsmManager.SetPeerDialer(server.peerDialer)
 	return &peer
 }
 
@@ -302,6 +305,7 @@ func (p *Peer) Init() {
 		p.logger.Infof("Init - Instantiating new FSM Manager for neighbor %s", p.NeighborConf.Neighbor.NeighborAddress)
 		fsmMgr = fsm.NewFSMManager(p.logger, p.NeighborConf, p.server.BGPPktSrcCh,
 			p.server.PeerFSMConnCh, p.server.ReachabilityCh)
+		fsmMgr.SetPeerDialer(p.server.peerDialer)
 	} else {
 		fsmMgr = p.fsmManager
 	}
@@ -381,7 +385,7 @@ func (p *Peer) getIfIdx() int32 {
 	return p.ifIdx
 }
 
-func (p *Peer) AcceptConn(conn *net.TCPConn) {
+func (p *Peer) AcceptConn(conn net.Conn) {
 	if p.fsmManager == nil {
 		p.logger.Errf("FSM Manager is not instantiated yet for neighbor %s",
 			p.NeighborConf.Neighbor.NeighborAddress)
@@ -415,6 +419,8 @@ func (p *Peer) getAddPathsMaxTx() int {
 }
 
 func (p *Peer) clearRibOut() {
+	defer p.ribInMutex.Unlock()
+	p.ribInMutex.Lock()
 	p.ribIn = nil
 	p.ribOut = nil
 	p.ribIn = make(map[uint32]map[string]*bgprib.AdjRIBRoute)
@@ -486,7 +492,26 @@ func (p *Peer) GetAdjRIB(adjRIBDir bgprib.AdjRIBDir) map[uint32]map[string]*bgpr
 	return nil
 }
 
+/*  GetAdjRIBInPathIds returns the path ids of the prefix in the Adj-RIB-In,
+ *  safe to call while the server loop processes updates.
+ */
+func (p *Peer) GetAdjRIBInPathIds(protoFamily uint32, prefix string) []uint32 {
+	defer p.ribInMutex.RUnlock()
+	p.ribInMutex.RLock()
+	route, ok := p.ribIn[protoFamily][prefix]
+	if !ok {
+		return nil
+	}
+	pathIds := make([]uint32, 0, len(route.PathIdRouteMap))
+	for pathId, _ := range route.PathIdRouteMap {
+		pathIds = append(pathIds, pathId)
+	}
+	return pathIds
+}
+
 func (p *Peer) processWithdraws(protoFamily uint32, nlris *[]packet.NLRI) {
+	defer p.ribInMutex.Unlock()
+	p.ribInMutex.Lock()
 	var route *bgprib.AdjRIBRoute
 	var pathIdRoute *bgprib.AdjRIBPathIdRoute
 	var ok bool
@@ -604,6 +629,8 @@ func (p *Peer) checkRIBOutFilter(nlri packet.NLRI, route *bgprib.AdjRIBPathIdRou
 }
 
 func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI, path *bgprib.Path) {
+	defer p.ribInMutex.Unlock()
+	p.ribInMutex.Lock()
 	var ok bool
 	var route *bgprib.AdjRIBRoute
 	var pathIdRoute *bgprib.AdjRIBPathIdRoute
@@ -746,6 +773,8 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 	//path := bgprib.NewPath(p.locRib, p.NeighborConf, updateMsg.PathAttributes, mpReach, bgprib.RouteTypeEGP)
 
 	mpReach, mpUnreach := packet.RemoveMPAttrs(&updateMsg.PathAttributes)
+	// An UPDATE treated as withdraw has a second MP_UNREACH_NLRI when its MP_REACH_NLRI was for another AFI/SAFI
+	_, mpReachUnreach := packet.RemoveMPAttrs(&updateMsg.PathAttributes)
 	//remPath := bgprib.NewPath(p.locRib, p.neighborConf, updateMsg.PathAttributes, mpReach, RouteTypeEGP)
 	path := bgprib.NewPath(p.locRib, p.NeighborConf, updateMsg.PathAttributes, mpReach, bgprib.RouteTypeEGP)
 
@@ -802,6 +831,17 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 		}
 	}
 
+	if mpReachUnreach != nil && len(mpReachUnreach.NLRI) > 0 {
+		mpReachUnreachProtoFamily := packet.GetProtocolFamily(mpReachUnreach.AFI, mpReachUnreach.SAFI)
+		p.processWithdraws(mpReachUnreachProtoFamily, &(mpReachUnreach.NLRI))
+		updated, withdrawn, updatedAddPaths, addedAllPrefixes = p.locRib.ProcessUpdate(p.NeighborConf, path,
+			make([]packet.NLRI, 0), mpReachUnreach.NLRI, mpReachUnreachProtoFamily, p.server.AddPathCount, updated,
+			withdrawn, updatedAddPaths)
+		if !addedAllPrefixes {
+			p.MaxPrefixesExceeded()
+		}
+	}
+
 	return updated, withdrawn, updatedAddPaths
 }
 
@@ -947,7 +987,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 		p.logger.Info("Neighbor", p.NeighborConf.Neighbor.NeighborAddress,
 			"calculateAddPathsAdvertisements - processing updates, dest", ip, "not found in rib out")
 		p.ribOut[protoFamily][ip] = bgprib.NewAdjRIBRoute(p.NeighborConf.Neighbor.NeighborAddress, protoFamily,
-			dest.NLRI)
+			p.getRibOutNLRI(dest, true))
 	}
 
 	ribOutRoute := p.ribOut[protoFamily][ip]
@@ -972,7 +1012,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 				}
 
 				pathAdded, newUpdated = p.addPathFamilyToUpdated(pathAdded, path, stmt, protoFamily, newUpdated)
-				nlri := packet.NewExtNLRI(route.OutPathId, dest.NLRI.GetIPPrefix())
+				nlri := p.constructNLRI(dest, route.OutPathId, true, false)
 				newUpdated[path][stmt][protoFamily] = append(newUpdated[path][stmt][protoFamily], nlri)
 			}
 		} else {
@@ -991,7 +1031,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 	for ribOutPathId, ribOutPath := range ribOutRoute.GetPathMap() {
 		if path, ok := pathIdMap[ribOutPathId]; !ok {
 			if p.checkRIBOutWithdraw(ribOutRoute.GetPathIdRoute(ribOutPathId)) {
-				nlri := packet.NewExtNLRI(ribOutPathId, dest.NLRI.GetIPPrefix())
+				nlri := p.constructNLRI(dest, ribOutPathId, true, true)
 				withdrawList[protoFamily] = append(withdrawList[protoFamily], nlri)
 			}
 			ribOutRoute.RemovePath(ribOutPathId)
@@ -1010,7 +1050,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 				}
 
 				pathAdded, newUpdated = p.addPathFamilyToUpdated(pathAdded, path, stmt, protoFamily, newUpdated)
-				nlri := packet.NewExtNLRI(ribOutPathId, dest.NLRI.GetIPPrefix())
+				nlri := p.constructNLRI(dest, ribOutPathId, true, false)
 				newUpdated[path][stmt][protoFamily] = append(newUpdated[path][stmt][protoFamily], nlri)
 			}
 			delete(pathIdMap, ribOutPathId)
@@ -1030,7 +1070,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 			}
 
 			pathAdded, newUpdated = p.addPathFamilyToUpdated(pathAdded, path, stmt, protoFamily, newUpdated)
-			nlri := packet.NewExtNLRI(pathId, dest.NLRI.GetIPPrefix())
+			nlri := p.constructNLRI(dest, pathId, true, false)
 			newUpdated[path][stmt][protoFamily] = append(newUpdated[path][stmt][protoFamily], nlri)
 		}
 		delete(pathIdMap, pathId)
@@ -1039,6 +1079,36 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 	return newUpdated, withdrawList, policyStmts
 }
 
+/*  constructNLRI returns the NLRI advertised to the peer for the destination.
+ *  Labeled unicast NLRI carry the local label of the destination, or the
+ *  withdraw label when the destination is withdrawn.
+ */
+func (p *Peer) constructNLRI(dest *bgprib.Destination, pathId uint32, addPath, withdraw bool) packet.NLRI {
+	label := dest.GetLocalLabel()
+	if withdraw {
+		label = packet.MPLSLabelWithdraw
+	}
+	return packet.ConstructNLRIForFamily(dest.GetProtocolFamily(), pathId, addPath, dest.NLRI.GetIPPrefix(), label)
+}
+
+/*  getRibOutNLRI returns the NLRI stored in the Adj-RIB-Out. For labeled
+ *  unicast it is the NLRI with the local label instead of the label received
+ *  from the peer that sent the route.
+ */
+func (p *Peer) getRibOutNLRI(dest *bgprib.Destination, addPath bool) packet.NLRI {
+	if packet.IsLabeledFamily(dest.GetProtocolFamily()) {
+		return p.constructNLRI(dest, 0, addPath, false)
+	}
+	return dest.NLRI
+}
+
+func (p *Peer) getWithdrawNLRI(dest *bgprib.Destination) packet.NLRI {
+	if packet.IsLabeledFamily(dest.GetProtocolFamily()) {
+		return p.constructNLRI(dest, 0, false, true)
+	}
+	return dest.NLRI
+}
+
 func (p *Peer) checkRIBOutWithdraw(route *bgprib.AdjRIBPathIdRoute) bool {
 	if p.NeighborConf.Neighbor.Config.AdjRIBOutFilter == "" {
 		p.logger.Debugf("Peer %s - withdraw %s RIB Out filter is not set", p.NeighborConf.Neighbor.NeighborAddress,
@@ -1094,10 +1164,10 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 							continue
 						}
 						if addPathsTx > 0 {
-							nlri := packet.NewExtNLRI(pathId, dest.NLRI.GetIPPrefix())
+							nlri := p.constructNLRI(dest, pathId, true, true)
 							withdrawList[protoFamily] = append(withdrawList[protoFamily], nlri)
 						} else {
-							withdrawList[protoFamily] = append(withdrawList[protoFamily], dest.NLRI)
+							withdrawList[protoFamily] = append(withdrawList[protoFamily], p.getWithdrawNLRI(dest))
 							break
 						}
 					}
@@ -1134,7 +1204,8 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 						if ribOutRoute := p.ribOut[protoFamily][ip]; ribOutRoute != nil {
 							for _, pathIdRoute := range ribOutRoute.PathIdRouteMap {
 								if p.checkRIBOutWithdraw(pathIdRoute) {
-									withdrawList[protoFamily] = append(withdrawList[protoFamily], dest.NLRI)
+									withdrawList[protoFamily] = append(withdrawList[protoFamily],
+										p.getWithdrawNLRI(dest))
 									p.ribOut[protoFamily][ip].RemoveAllPaths()
 									delete(p.ribOut[protoFamily], ip)
 									break
@@ -1144,7 +1215,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 					} else {
 						if _, ok := p.ribOut[protoFamily][ip]; !ok {
 							p.ribOut[protoFamily][ip] = bgprib.NewAdjRIBRoute(p.NeighborConf.Neighbor.NeighborAddress,
-								protoFamily, dest.NLRI)
+								protoFamily, p.getRibOutNLRI(dest, false))
 						}
 
 						ribOutRoute := p.ribOut[protoFamily][ip]
@@ -1178,7 +1249,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 									newUpdated[path][stmt][protoFamily] = make([]packet.NLRI, 0)
 								}
 								newUpdated[path][stmt][protoFamily] = append(newUpdated[path][stmt][protoFamily],
-									dest.NLRI.GetIPPrefix())
+									p.constructNLRI(dest, 0, false, false))
 							}
 						}
 					}
@@ -1237,7 +1308,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 
 			for protoFamily, nlriList := range pfNLRIMap {
 				if len(nlriList) > 0 {
-					mpReachNLRI := packet.ConstructIPv6MPReachNLRI(protoFamily, localAddress, nil, nlriList)
+					mpReachNLRI := packet.ConstructMPReachNLRI(protoFamily, localAddress, nil, nlriList)
 					pa := packet.ClonePathAttrs(path.PathAttrs)
 					pa = packet.AddMPReachNLRIToPathAttrs(pa, mpReachNLRI)
 					medUpdated := false
@@ -1327,7 +1398,7 @@ func (p *Peer) AdjRIBOutPolicyUpdated(data interface{}, updateFunc utilspolicy.P
 				var pa []packet.BGPPathAttr
 				if len(routesMap.Add) > 0 {
 					pa = packet.ClonePathAttrs(path.PathAttrs)
-					mpReachNLRI := packet.ConstructIPv6MPReachNLRI(protoFamily, localAddress, nil, routesMap.Add)
+					mpReachNLRI := packet.ConstructMPReachNLRI(protoFamily, localAddress, nil, routesMap.Add)
 					pa = packet.AddMPReachNLRIToPathAttrs(pa, mpReachNLRI)
 				}
 
@@ -52,6 +52,15 @@ import (
 	"utils/statedbclient"
 )
 
+/*  The routes of the previous run of bgpd are kept by ribd until bgpd sends
+ *  the end of RIB. It is sent once the sessions that came up after the start
+ *  went quiet, and no earlier than the end of RIB delay after the start.
+ */
+const (
+	BGPEndOfRIBDelay     = 60 * time.Second
+	BGPEndOfRIBQuietTime = 5 * time.Second
+)
+
 type GlobalUpdate struct {
 	BGPConfig *bgpd.BGPGlobal
 	OldConfig config.GlobalConfig
@@ -144,7 +153,7 @@ type BGPServer struct {
 	IntfCh           chan config.IntfStateInfo
 	IntfMapCh        chan config.IntfMapInfo
 	RoutesCh         chan *config.RouteCh
-	acceptCh         chan *net.TCPConn
+	acceptCh         chan net.Conn
 	ServerUpCh       chan bool
 	GlobalCfgDone    bool
 
@@ -170,6 +179,13 @@ type BGPServer struct {
 	bfdMgr     config.BfdMgrIntf
 	stateDBMgr statedbclient.StateDBClient
 	eventDbHdl *dbutils.DBUtil
+	peerDialer fsm.PeerDialer
+	// end of the routes installed again after the start of bgpd
+	EndOfRIBDelay     time.Duration
+	EndOfRIBQuietTime time.Duration
+	endOfRIBTimer     *time.Timer
+	endOfRIBTime      time.Time
+	endOfRIBSent      bool
 }
 
 func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyManager, iMgr config.IntfStateMgrIntf,
@@ -199,6 +215,10 @@ func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyMana
 	bgpServer.IntfMapCh = make(chan config.IntfMapInfo)
 	bgpServer.RoutesCh = make(chan *config.RouteCh)
 	bgpServer.ServerUpCh = make(chan bool)
+	// channel for accepting connections
+	bgpServer.acceptCh = make(chan net.Conn)
+	bgpServer.EndOfRIBDelay = BGPEndOfRIBDelay
+	bgpServer.EndOfRIBQuietTime = BGPEndOfRIBQuietTime
 
 	bgpServer.NeighborMutex = sync.RWMutex{}
 	bgpServer.PeerMap = make(map[string]*Peer)
@@ -332,7 +352,7 @@ func (s *BGPServer) setListener(listener *net.TCPListener, proto string) {
 	}
 }
 
-func (s *BGPServer) listenForPeers(listener *net.TCPListener, proto string, acceptCh chan *net.TCPConn) {
+func (s *BGPServer) listenForPeers(listener *net.TCPListener, proto string, acceptCh chan net.Conn) {
 	for {
 		s.logger.Info("Waiting for peer connections...")
 		tcpConn, err := listener.AcceptTCP()
@@ -2841,6 +2861,7 @@ func (s *BGPServer) listenChannelUpdates() {
 			}
 
 			s.SendAllRoutesToPeer(peer)
+			s.deferEndOfRIB()
 
 		case peerIP := <-s.PeerConnBrokenCh:
 			s.logger.Infof("Server: Peer %s FSM connection broken", peerIP)
@@ -2870,6 +2891,10 @@ func (s *BGPServer) listenChannelUpdates() {
 		case pktInfo := <-s.BGPPktSrcCh:
 			s.logger.Info("Received BGP message from peer %s", pktInfo.Src)
 			s.ProcessUpdate(pktInfo)
+			s.deferEndOfRIB()
+
+		case <-s.endOfRIBTimer.C:
+			s.processEndOfRIBTimer()
 
 		case reachabilityInfo := <-s.ReachabilityCh:
 			s.logger.Info("Server: Get reachability info for ip", reachabilityInfo.IP)
@@ -3028,9 +3053,35 @@ func (s *BGPServer) ConstructDefaultRoutes(gConf *config.GlobalConfig) {
 	}
 }
 
+/*  SetPeerDialer replaces the TCP connect used by the peer FSMs. It has to be
+ *  called before any neighbor is created.
+ */
+func (s *BGPServer) SetPeerDialer(dialer fsm.PeerDialer) {
+	s.peerDialer = dialer
+}
+
+/*  AcceptConn hands an incoming connection to the server as if it had been
+ *  accepted on the BGP listener.
+ */
+func (s *BGPServer) AcceptConn(conn net.Conn) {
+	s.acceptCh <- conn
+}
+
 func (s *BGPServer) StartServer() {
 	// Initialize Event Handler
 	s.InitBGPEvent()
+	s.startServer(true)
+}
+
+/*  StartServerWithoutListeners runs the server without the BGP TCP listeners
+ *  and the event DB. Peer connections are passed in through AcceptConn and
+ *  SetPeerDialer.
+ */
+func (s *BGPServer) StartServerWithoutListeners() {
+	s.startServer(false)
+}
+
+func (s *BGPServer) startServer(listen bool) {
 	//read the intfMgr objects before the global conf - this is the case during restart
 	s.GetIntfObjects()
 	s.ServerUpCh <- true
@@ -3047,15 +3098,14 @@ func (s *BGPServer) StartServer() {
 	s.ConstructPathsForLocalRoutes(&s.BgpConfig.Global.Config)
 	s.ConstructDefaultRoutes(&s.BgpConfig.Global.Config)
 
-	s.logger.Info("Setting up Peer connections")
-	// channel for accepting connections
-	s.acceptCh = make(chan *net.TCPConn)
-
-	s.listener, _ = s.createListener("tcp4")
-	go s.listenForPeers(s.listener, "tcp4", s.acceptCh)
+	if listen {
+		s.logger.Info("Setting up Peer connections")
+		s.listener, _ = s.createListener("tcp4")
+		go s.listenForPeers(s.listener, "tcp4", s.acceptCh)
 
-	s.listenerIPv6, _ = s.createListener("tcp6")
-	go s.listenForPeers(s.listenerIPv6, "tcp6", s.acceptCh)
+		s.listenerIPv6, _ = s.createListener("tcp6")
+		go s.listenForPeers(s.listenerIPv6, "tcp6", s.acceptCh)
+	}
 
 	s.logger.Info("Start all managers and initialize API Layer")
 	s.IntfMgr.Start()
@@ -3073,9 +3123,33 @@ func (s *BGPServer) StartServer() {
 		s.ProcessConnectedRoutes(add, remove)
 	}
 	s.GetIntfObjects()
+	s.endOfRIBTime = time.Now().Add(s.EndOfRIBDelay)
+	s.endOfRIBTimer = time.NewTimer(s.EndOfRIBDelay)
 	s.listenChannelUpdates()
 }
 
+/*  deferEndOfRIB pushes the end of RIB out while sessions come up and their
+ *  routes are received.
+ */
+func (s *BGPServer) deferEndOfRIB() {
+	if s.endOfRIBSent {
+		return
+	}
+	if deadline := time.Now().Add(s.EndOfRIBQuietTime); deadline.After(s.endOfRIBTime) {
+		s.endOfRIBTime = deadline
+	}
+}
+
+func (s *BGPServer) processEndOfRIBTimer() {
+	if wait := s.endOfRIBTime.Sub(time.Now()); wait > 0 {
+		s.endOfRIBTimer.Reset(wait)
+		return
+	}
+	s.endOfRIBSent = true
+	s.logger.Info("Sending end of RIB for the BGP routes")
+	s.routeMgr.RoutesEndOfRIB()
+}
+
 func (s *BGPServer) GetBGPGlobalState() config.GlobalState {
 	routesCount := s.LocRib.GetRoutesCount()
 	s.BgpConfig.Global.State.Totalv4Prefixes = 0
@@ -3104,6 +3178,33 @@ func (s *BGPServer) GetBGPNeighborState(neighborIP string) *config.NeighborState
 	return &peer.NeighborConf.Neighbor.State
 }
 
+/*  GetBGPNeighborHistory returns a snapshot of the neighbor state, safe to read
+ *  while the FSM records new transitions.
+ */
+func (s *BGPServer) GetBGPNeighborHistory(neighborIP string) *config.NeighborState {
+	peer, ok := s.PeerMap[neighborIP]
+	if !ok {
+		s.logger.Errf("GetBGPNeighborHistory - Neighbor not found for address:%s", neighborIP)
+		return nil
+	}
+	return peer.NeighborConf.GetStateSnapshot()
+}
+
+/*  GetAdjRIBInPathIds returns the path ids of the prefix in the Adj-RIB-In of
+ *  the neighbor, nil if the neighbor or the prefix is not found.
+ */
+func (s *BGPServer) GetAdjRIBInPathIds(neighborIP string, protoFamily uint32, prefix string) []uint32 {
+	defer s.NeighborMutex.RUnlock()
+
+	s.NeighborMutex.RLock()
+	for _, peer := range s.Neighbors {
+		if peer.NeighborConf.Neighbor.NeighborAddress.String() == neighborIP {
+			return peer.GetAdjRIBInPathIds(protoFamily, prefix)
+		}
+	}
+	return nil
+}
+
 func (s *BGPServer) bulkGetBGPNeighbors(index int, count int, addrType config.PeerAddressType) (int, int,
 	[]*config.NeighborState) {
 	defer s.NeighborMutex.RUnlock()
@@ -3140,6 +3241,32 @@ func (s *BGPServer) BulkGetBGPv6Neighbors(index int, count int) (int, int, []*co
 	return s.bulkGetBGPNeighbors(index, count, config.PeerAddressV6)
 }
 
+func (s *BGPServer) BulkGetBGPNeighborsHistory(index int, count int) (int, int, []*config.NeighborState) {
+	defer s.NeighborMutex.RUnlock()
+
+	s.NeighborMutex.RLock()
+	num := count
+	if index+count > len(s.Neighbors) {
+		num = len(s.Neighbors) - index
+	}
+	if num < 0 {
+		num = 0
+	}
+
+	result := make([]*config.NeighborState, 0, num)
+	for i := index; i < index+num; i++ {
+		result = append(result, s.Neighbors[i].NeighborConf.GetStateSnapshot())
+	}
+
+	if index+count >= len(s.Neighbors) {
+		index = 0
+		count = num
+	} else {
+		index += count
+	}
+	return index, count, result
+}
+
 func (s *BGPServer) GetBGPNetworkStatementState(ipPrefix string) *config.BGPNetworkStatement {
 	ip, _, err := net.ParseCIDR(ipPrefix)
 	if err != nil {
@@ -0,0 +1,284 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// harness.go
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"l3/bgp/config"
+	bgppolicy "l3/bgp/policy"
+	"l3/bgp/server"
+	"l3/bgp/utils"
+	"net"
+	"sync"
+	"testing"
+	"time"
+	"utils/logging"
+)
+
+const (
+	defaultTimeout = time.Duration(10) * time.Second
+	pollInterval   = time.Duration(50) * time.Millisecond
+	// bgpd signals the end of its initial routes quickly in the tests
+	endOfRIBDelay     = time.Duration(1) * time.Second
+	endOfRIBQuietTime = time.Duration(500) * time.Millisecond
+)
+
+/*  Harness runs a complete BGP server - FSM managers, peers and the LocRib -
+ *  against in-memory route, interface and BFD managers. Transport connections
+ *  are net.Pipe pairs, the far end of each one is driven by a Speaker.
+ */
+type Harness struct {
+	t        *testing.T
+	logger   *logging.Writer
+	Server   *server.BGPServer
+	RouteMgr *RouteMgr
+	IntfMgr  *IntfMgr
+	BfdMgr   *BfdMgr
+	Global   config.GlobalConfig
+	LocalIP  string
+
+	mutex    sync.Mutex
+	speakers map[string]SpeakerConfig
+	dialedCh map[string]chan *Speaker
+	refused  map[string]bool
+}
+
+/*  New starts a BGP server with the local AS and router id and waits until it
+ *  has processed the global config.
+ */
+func New(t *testing.T, as uint32, routerId string) *Harness {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+	utils.SetLogger(logger)
+
+	h := &Harness{
+		t:        t,
+		logger:   logger,
+		RouteMgr: NewRouteMgr(logger),
+		IntfMgr:  NewIntfMgr(logger),
+		BfdMgr:   NewBfdMgr(logger),
+		LocalIP:  routerId,
+		speakers: make(map[string]SpeakerConfig),
+		dialedCh: make(map[string]chan *Speaker),
+		refused:  make(map[string]bool),
+	}
+
+	policyManager := bgppolicy.NewPolicyManager(logger, &PolicyMgr{})
+	h.Server = server.NewBGPServer(logger, policyManager, h.IntfMgr, h.RouteMgr, h.BfdMgr, &StateDBClient{})
+	h.Server.SetPeerDialer(h.dial)
+	h.Server.EndOfRIBDelay = endOfRIBDelay
+	h.Server.EndOfRIBQuietTime = endOfRIBQuietTime
+	go h.Server.StartServerWithoutListeners()
+	<-h.Server.ServerUpCh
+
+	h.Global = config.GlobalConfig{}
+	h.Global.AS = as
+	h.Global.RouterId = net.ParseIP(routerId)
+	h.Server.GlobalConfigCh <- server.GlobalUpdate{NewConfig: h.Global, Op: "create"}
+	return h
+}
+
+/*  NewNeighborConfig returns a neighbor config with short timers suitable for
+ *  tests.
+ */
+func NewNeighborConfig(ip string, peerAS uint32) config.NeighborConfig {
+	nConf := config.NeighborConfig{
+		NeighborAddress: net.ParseIP(ip),
+		IfIndex:         -1,
+	}
+	nConf.PeerAS = peerAS
+	nConf.ConnectRetryTime = 5
+	nConf.HoldTime = 9
+	nConf.KeepaliveTime = 3
+	if nConf.NeighborAddress.To4() == nil {
+		nConf.PeerAddressType = config.PeerAddressV6
+	}
+	return nConf
+}
+
+/*  AddNeighbor configures the neighbor on the server. Connections bgpd opens to
+ *  the neighbor are answered by a Speaker using speakerConf.
+ */
+func (h *Harness) AddNeighbor(nConf config.NeighborConfig, speakerConf SpeakerConfig) {
+	ip := nConf.NeighborAddress.String()
+	h.mutex.Lock()
+	h.speakers[ip] = speakerConf
+	if _, ok := h.dialedCh[ip]; !ok {
+		h.dialedCh[ip] = make(chan *Speaker, 8)
+	}
+	h.mutex.Unlock()
+	h.Server.AddPeerCh <- server.PeerUpdate{NewPeer: nConf, Op: "create"}
+}
+
+func (h *Harness) RemoveNeighbor(nConf config.NeighborConfig) {
+	h.Server.RemPeerCh <- nConf
+}
+
+/*  RefuseConnections makes the connections bgpd opens to the neighbor fail, so
+ *  only incoming connections are used.
+ */
+func (h *Harness) RefuseConnections(ip string, refuse bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.refused[ip] = refuse
+}
+
+func (h *Harness) dial(remote, local string) (net.Conn, error) {
+	remoteIP, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	speakerConf, ok := h.speakers[remoteIP]
+	if !ok || h.refused[remoteIP] {
+		return nil, errors.New(fmt.Sprintf("Connection to %s refused", remote))
+	}
+
+	localConn, remoteConn := newPipeConns(h.LocalIP, remoteIP)
+	select {
+	case h.dialedCh[remoteIP] <- NewSpeaker(remoteConn, speakerConf):
+	default:
+		localConn.Close()
+		remoteConn.Close()
+		return nil, errors.New(fmt.Sprintf("Too many pending connections to %s", remote))
+	}
+	return localConn, nil
+}
+
+/*  WaitForDial returns the Speaker for the next connection bgpd opens to the
+ *  neighbor.
+ */
+func (h *Harness) WaitForDial(ip string, timeout time.Duration) (*Speaker, error) {
+	h.mutex.Lock()
+	dialedCh, ok := h.dialedCh[ip]
+	h.mutex.Unlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Neighbor %s is not added to the harness", ip))
+	}
+
+	select {
+	case speaker := <-dialedCh:
+		return speaker, nil
+
+	case <-time.After(timeout):
+		return nil, errors.New(fmt.Sprintf("bgpd did not connect to %s in %s", ip, timeout))
+	}
+}
+
+/*  Connect opens a connection from the neighbor to bgpd and returns the Speaker
+ *  driving it.
+ */
+func (h *Harness) Connect(ip string) (*Speaker, error) {
+	h.mutex.Lock()
+	speakerConf, ok := h.speakers[ip]
+	h.mutex.Unlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Neighbor %s is not added to the harness", ip))
+	}
+
+	localConn, remoteConn := newPipeConns(h.LocalIP, ip)
+	speaker := NewSpeaker(remoteConn, speakerConf)
+	h.Server.AcceptConn(localConn)
+	return speaker, nil
+}
+
+/*  GetSessionState returns the FSM state of the neighbor as reported by the
+ *  server.
+ */
+func (h *Harness) GetSessionState(ip string) config.BGPFSMState {
+	state := h.Server.GetBGPNeighborState(ip)
+	if state == nil {
+		return config.BGPFSMNone
+	}
+	return config.BGPFSMState(state.SessionState)
+}
+
+func (h *Harness) WaitForState(ip string, fsmState config.BGPFSMState, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		current := h.GetSessionState(ip)
+		if current == fsmState {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("Neighbor %s is in state %s, expected %s after %s", ip,
+				config.GetBGPStateToStr(current), config.GetBGPStateToStr(fsmState), timeout))
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+/*  EstablishSession waits for bgpd to connect to the neighbor and brings the
+ *  session up to ESTABLISHED.
+ */
+func (h *Harness) EstablishSession(ip string) (*Speaker, error) {
+	speaker, err := h.WaitForDial(ip, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = speaker.Establish(defaultTimeout); err != nil {
+		speaker.Close()
+		return nil, err
+	}
+
+	if err = h.WaitForState(ip, config.BGPFSMEstablished, defaultTimeout); err != nil {
+		speaker.Close()
+		return nil, err
+	}
+	return speaker, nil
+}
+
+/*  WaitForAdjRIBInPaths waits until the Adj-RIB-In of the neighbor has exactly
+ *  the path ids for the prefix.
+ */
+func (h *Harness) WaitForAdjRIBInPaths(ip string, protoFamily uint32, prefix string, pathIds []uint32,
+	timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	expected := make(map[uint32]bool)
+	for _, pathId := range pathIds {
+		expected[pathId] = true
+	}
+	for {
+		found := h.Server.GetAdjRIBInPathIds(ip, protoFamily, prefix)
+		matched := len(found) == len(expected)
+		for _, pathId := range found {
+			matched = matched && expected[pathId]
+		}
+		if matched {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("Neighbor %s Adj-RIB-In has path ids %v for %s, expected %v after %s",
+				ip, found, prefix, pathIds, timeout))
+		}
+		time.Sleep(pollInterval)
+	}
+}
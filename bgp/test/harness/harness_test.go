@@ -0,0 +1,502 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// harness_test.go
+package harness
+
+import (
+	"bytes"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	localAS       uint32 = 65001
+	localRouterId        = "10.0.0.1"
+	peerAS        uint32 = 65002
+	peerIP               = "10.0.0.2"
+	peer2AS       uint32 = 65003
+	peer2IP              = "10.0.0.3"
+)
+
+func constructPathAttrs(as uint32, nextHop string) []packet.BGPPathAttr {
+	pathAttrs := make([]packet.BGPPathAttr, 0)
+	pathAttrs = append(pathAttrs, packet.NewBGPPathAttrOrigin(packet.BGPPathAttrOriginIGP))
+
+	asPath := packet.NewBGPPathAttrASPath()
+	asPathSeg := packet.NewBGPAS4PathSegmentSeq()
+	asPathSeg.AppendAS(as)
+	asPath.AppendASPathSegment(asPathSeg)
+	pathAttrs = append(pathAttrs, asPath)
+
+	nh := packet.NewBGPPathAttrNextHop()
+	nh.Value = net.ParseIP(nextHop).To4()
+	pathAttrs = append(pathAttrs, nh)
+	return pathAttrs
+}
+
+func establish(t *testing.T, h *Harness, ip string, as uint32) *Speaker {
+	h.AddNeighbor(NewNeighborConfig(ip, as), SpeakerConfig{AS: as, RouterId: ip})
+	speaker, err := h.EstablishSession(ip)
+	if err != nil {
+		t.Fatal("Failed to establish session with", ip, "error:", err)
+	}
+	return speaker
+}
+
+func TestSessionEstablished(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.AddNeighbor(NewNeighborConfig(peerIP, peerAS), SpeakerConfig{AS: peerAS, RouterId: peerIP})
+
+	speaker, err := h.WaitForDial(peerIP, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer speaker.Close()
+
+	openMsg, err := speaker.Establish(defaultTimeout)
+	if err != nil {
+		t.Fatal("Session establishment failed with error:", err)
+	}
+	if openMsg.MyAS != localAS {
+		t.Error("OPEN message has AS", openMsg.MyAS, "expected", localAS)
+	}
+	if !openMsg.BGPId.Equal(net.ParseIP(localRouterId)) {
+		t.Error("OPEN message has BGP id", openMsg.BGPId, "expected", localRouterId)
+	}
+	if err = h.WaitForState(peerIP, config.BGPFSMEstablished, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotificationResetsSession(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	speaker := establish(t, h, peerIP, peerAS)
+
+	if err := speaker.SendNotification(packet.BGPCease, packet.BGPUnspecific, nil); err != nil {
+		t.Fatal("Failed to send NOTIFICATION, error:", err)
+	}
+	if err := speaker.ExpectClosed(defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if h.GetSessionState(peerIP) == config.BGPFSMEstablished {
+		t.Error("Session is still established after NOTIFICATION")
+	}
+
+	// bgpd should reconnect once the connect retry timer fires
+	speaker, err := h.EstablishSession(peerIP)
+	if err != nil {
+		t.Fatal("Session was not re-established, error:", err)
+	}
+	speaker.Close()
+}
+
+func TestSessionHistoryRecordsFlap(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	speaker := establish(t, h, peerIP, peerAS)
+
+	if err := speaker.SendNotification(packet.BGPCease, packet.BGPUnspecific, []byte{0xde, 0xad}); err != nil {
+		t.Fatal("Failed to send NOTIFICATION, error:", err)
+	}
+	if err := speaker.ExpectClosed(defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	speaker.Close()
+
+	state := h.Server.GetBGPNeighborHistory(peerIP)
+	if state == nil {
+		t.Fatal("Neighbor", peerIP, "not found")
+	}
+	deadline := time.Now().Add(defaultTimeout)
+	for state.History.FlapCount == 0 && time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+		state = h.Server.GetBGPNeighborHistory(peerIP)
+	}
+	history := state.History
+	if history.FlapCount != 1 {
+		t.Fatal("Expected flap count 1, got", history.FlapCount)
+	}
+	if !history.EstablishedTime.IsZero() {
+		t.Error("Established time is set after the session went down")
+	}
+	notif := history.LastNotificationRcvd
+	if notif.ErrorCode != packet.BGPCease || notif.ErrorSubcode != packet.BGPUnspecific ||
+		!bytes.Equal(notif.Data, []byte{0xde, 0xad}) {
+		t.Errorf("Unexpected last received notification %+v", notif)
+	}
+
+	wentUp, wentDown := false, false
+	for _, transition := range history.Transitions {
+		if transition.ToState == config.BGPFSMEstablished {
+			wentUp = true
+		} else if wentUp && transition.FromState == config.BGPFSMEstablished {
+			wentDown = true
+			if transition.Event != "NotifMsg" {
+				t.Error("Expected session to go down on NotifMsg, got", transition.Event)
+			}
+		}
+	}
+	if !wentUp || !wentDown {
+		t.Errorf("FSM history does not have the session flap, transitions: %+v", history.Transitions)
+	}
+}
+
+func TestBadPeerAS(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.AddNeighbor(NewNeighborConfig(peerIP, peerAS), SpeakerConfig{AS: peerAS + 100, RouterId: peerIP})
+
+	speaker, err := h.WaitForDial(peerIP, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer speaker.Close()
+
+	if _, err = speaker.Expect(packet.BGPMsgTypeOpen, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err = speaker.SendOpen(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := speaker.Expect(packet.BGPMsgTypeNotification, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notif := msg.Body.(*packet.BGPNotification)
+	if notif.ErrorCode != packet.BGPOpenMsgError || notif.ErrorSubcode != packet.BGPBadPeerAS {
+		t.Error("Expected OPEN message error/bad peer AS, received code", notif.ErrorCode, "subcode",
+			notif.ErrorSubcode)
+	}
+}
+
+func TestUpdateInstallsAndWithdrawsRoute(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	speaker := establish(t, h, peerIP, peerAS)
+	defer speaker.Close()
+
+	nlri := []packet.NLRI{packet.NewIPPrefix(net.ParseIP("20.1.1.0").To4(), 24)}
+	if err := speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), nlri); err != nil {
+		t.Fatal("Failed to send UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForRoute("20.1.1.0", true, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := speaker.SendUpdate(nlri, nil, nil); err != nil {
+		t.Fatal("Failed to send withdraw UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForRoute("20.1.1.0", false, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouteAdvertisedToEBGPPeer(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	speaker := establish(t, h, peerIP, peerAS)
+	defer speaker.Close()
+	speaker2 := establish(t, h, peer2IP, peer2AS)
+	defer speaker2.Close()
+
+	nlri := []packet.NLRI{packet.NewIPPrefix(net.ParseIP("20.1.2.0").To4(), 24)}
+	if err := speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), nlri); err != nil {
+		t.Fatal("Failed to send UPDATE, error:", err)
+	}
+
+	msg, err := speaker2.Expect(packet.BGPMsgTypeUpdate, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := msg.Body.(*packet.BGPUpdate)
+	if len(update.NLRI) != 1 || update.NLRI[0].GetPrefix().String() != "20.1.2.0" {
+		t.Fatal("Expected NLRI 20.1.2.0/24 in UPDATE, received", update.NLRI)
+	}
+
+	for _, pa := range update.PathAttributes {
+		if asPath, ok := pa.(*packet.BGPPathAttrASPath); ok {
+			if asPath.Value[0].GetNumASes() != 2 {
+				t.Error("Expected AS path with 2 ASes, received", asPath)
+			}
+			return
+		}
+	}
+	t.Error("AS path not found in advertised UPDATE")
+}
+
+func TestCollisionHigherRouterIdWins(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.AddNeighbor(NewNeighborConfig(peerIP, peerAS), SpeakerConfig{AS: peerAS, RouterId: peerIP})
+
+	outSpeaker, err := h.WaitForDial(peerIP, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outSpeaker.Close()
+	if _, err = outSpeaker.Expect(packet.BGPMsgTypeOpen, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err = outSpeaker.SendOpen(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The neighbor has the higher router id, so the connection it initiated
+	// survives and the one bgpd initiated is closed (RFC 4271 section 6.8)
+	inSpeaker, err := h.Connect(peerIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inSpeaker.Close()
+	if _, err = inSpeaker.Expect(packet.BGPMsgTypeOpen, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err = inSpeaker.SendOpen(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := outSpeaker.Expect(packet.BGPMsgTypeNotification, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notif := msg.Body.(*packet.BGPNotification); notif.ErrorCode != packet.BGPCease {
+		t.Error("Expected Cease NOTIFICATION on the locally initiated connection, received code", notif.ErrorCode)
+	}
+
+	if _, err = inSpeaker.Expect(packet.BGPMsgTypeKeepAlive, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err = inSpeaker.SendKeepAlive(); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.WaitForState(peerIP, config.BGPFSMEstablished, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddPathsReceived(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	nConf := NewNeighborConfig(peerIP, peerAS)
+	nConf.AddPathsRx = true
+	h.AddNeighbor(nConf, SpeakerConfig{AS: peerAS, RouterId: peerIP, AddPathsMaxTx: 2})
+	speaker, err := h.EstablishSession(peerIP)
+	if err != nil {
+		t.Fatal("Failed to establish session with", peerIP, "error:", err)
+	}
+	defer speaker.Close()
+
+	prefix := packet.NewIPPrefix(net.ParseIP("20.1.4.0").To4(), 24)
+	for _, pathId := range []uint32{1, 2} {
+		nlri := []packet.NLRI{packet.NewExtNLRI(pathId, prefix)}
+		if err = speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), nlri); err != nil {
+			t.Fatal("Failed to send UPDATE with path id", pathId, "error:", err)
+		}
+	}
+
+	protoFamily := packet.ProtocolFamilyMap["ipv4-unicast"]
+	if err = h.WaitForAdjRIBInPaths(peerIP, protoFamily, "20.1.4.0/24", []uint32{1, 2},
+		defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func establishLabeled(t *testing.T, h *Harness, ip string, as uint32) *Speaker {
+	nConf := NewNeighborConfig(ip, as)
+	nConf.LabeledUnicast = true
+	afiSafis := map[uint32]bool{
+		packet.ProtocolFamilyMap["ipv4-unicast"]:         true,
+		packet.ProtocolFamilyMap["ipv4-labeled-unicast"]: true,
+	}
+	h.AddNeighbor(nConf, SpeakerConfig{AS: as, RouterId: ip, AfiSafis: afiSafis})
+	speaker, err := h.EstablishSession(ip)
+	if err != nil {
+		t.Fatal("Failed to establish session with", ip, "error:", err)
+	}
+	return speaker
+}
+
+func getMPAttrs(update *packet.BGPUpdate) (*packet.BGPPathAttrMPReachNLRI, *packet.BGPPathAttrMPUnreachNLRI) {
+	var mpReach *packet.BGPPathAttrMPReachNLRI
+	var mpUnreach *packet.BGPPathAttrMPUnreachNLRI
+	for _, pa := range update.PathAttributes {
+		if attr, ok := pa.(*packet.BGPPathAttrMPReachNLRI); ok {
+			mpReach = attr
+		} else if attr, ok := pa.(*packet.BGPPathAttrMPUnreachNLRI); ok {
+			mpUnreach = attr
+		}
+	}
+	return mpReach, mpUnreach
+}
+
+func TestLabeledUnicastRoute(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	speaker := establishLabeled(t, h, peerIP, peerAS)
+	defer speaker.Close()
+	speaker2 := establishLabeled(t, h, peer2IP, peer2AS)
+	defer speaker2.Close()
+
+	protoFamily := packet.ProtocolFamilyMap["ipv4-labeled-unicast"]
+	prefix := packet.NewIPPrefix(net.ParseIP("20.1.3.0").To4(), 24)
+	nlri := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{1000})}
+	pathAttrs := constructPathAttrs(peerAS, peerIP)[:2]
+	pathAttrs = append(pathAttrs, packet.ConstructMPReachNLRI(protoFamily, net.ParseIP(peerIP), nil, nlri))
+	if err := speaker.SendUpdate(nil, pathAttrs, nil); err != nil {
+		t.Fatal("Failed to send labeled UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForLabeledRoute("20.1.3.0", true, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.RouteMgr.GetRoute("20.1.3.0"); ok {
+		t.Fatal("Labeled route 20.1.3.0/24 installed as a unicast route")
+	}
+	cfg, _ := h.RouteMgr.GetLabeledRoute("20.1.3.0")
+	if cfg.InLabel < packet.MPLSLabelFirstDyn || len(cfg.OutLabels) != 1 || cfg.OutLabels[0] != 1000 {
+		t.Fatal("Labeled route installed with in label", cfg.InLabel, "out labels", cfg.OutLabels)
+	}
+
+	// The route is advertised next hop self with the local label
+	msg, err := speaker2.Expect(packet.BGPMsgTypeUpdate, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpReach, _ := getMPAttrs(msg.Body.(*packet.BGPUpdate))
+	if mpReach == nil || mpReach.SAFI != packet.SafiLabeledUnicast || len(mpReach.NLRI) != 1 {
+		t.Fatal("Expected labeled MP_REACH_NLRI in UPDATE, received", msg.Body)
+	}
+	if mpReach.NextHop.Len() != uint8(net.IPv4len+1) {
+		t.Error("Expected IPv4 next hop in labeled MP_REACH_NLRI, received", mpReach.NextHop)
+	}
+	advertised, ok := mpReach.NLRI[0].(*packet.LabeledNLRI)
+	if !ok || advertised.GetCIDR() != "20.1.3.0/24" || len(advertised.Labels) != 1 ||
+		advertised.Labels[0] != cfg.InLabel {
+		t.Fatal("Expected 20.1.3.0/24 with label", cfg.InLabel, "received", mpReach.NLRI[0])
+	}
+
+	withdrawn := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{packet.MPLSLabelWithdraw})}
+	pathAttrs = []packet.BGPPathAttr{packet.ConstructMPUnreachNLRIFromProtoFamily(protoFamily, withdrawn)}
+	if err = speaker.SendUpdate(nil, pathAttrs, nil); err != nil {
+		t.Fatal("Failed to send labeled withdraw UPDATE, error:", err)
+	}
+	if err = h.RouteMgr.WaitForLabeledRoute("20.1.3.0", false, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err = speaker2.Expect(packet.BGPMsgTypeUpdate, defaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, mpUnreach := getMPAttrs(msg.Body.(*packet.BGPUpdate))
+	if mpUnreach == nil || mpUnreach.SAFI != packet.SafiLabeledUnicast || len(mpUnreach.NLRI) != 1 ||
+		mpUnreach.NLRI[0].GetCIDR() != "20.1.3.0/24" {
+		t.Fatal("Expected labeled MP_UNREACH_NLRI for 20.1.3.0/24, received", msg.Body)
+	}
+}
+
+func TestLabeledAndUnicastRouteCoexist(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	speaker := establishLabeled(t, h, peerIP, peerAS)
+	defer speaker.Close()
+
+	prefix := packet.NewIPPrefix(net.ParseIP("20.1.5.0").To4(), 24)
+	if err := speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), []packet.NLRI{prefix}); err != nil {
+		t.Fatal("Failed to send UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForRoute("20.1.5.0", true, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	protoFamily := packet.ProtocolFamilyMap["ipv4-labeled-unicast"]
+	nlri := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{2000})}
+	pathAttrs := constructPathAttrs(peerAS, peerIP)[:2]
+	pathAttrs = append(pathAttrs, packet.ConstructMPReachNLRI(protoFamily, net.ParseIP(peerIP), nil, nlri))
+	if err := speaker.SendUpdate(nil, pathAttrs, nil); err != nil {
+		t.Fatal("Failed to send labeled UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForLabeledRoute("20.1.5.0", true, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, ok := h.RouteMgr.GetRoute("20.1.5.0"); !ok || cfg.Labeled || len(cfg.OutLabels) != 0 {
+		t.Fatal("Unicast route 20.1.5.0/24 replaced by the labeled path, installed", cfg)
+	}
+
+	// Withdrawing the labeled path leaves the unicast route installed
+	withdrawn := []packet.NLRI{packet.NewLabeledNLRI(0, false, prefix, []uint32{packet.MPLSLabelWithdraw})}
+	pathAttrs = []packet.BGPPathAttr{packet.ConstructMPUnreachNLRIFromProtoFamily(protoFamily, withdrawn)}
+	if err := speaker.SendUpdate(nil, pathAttrs, nil); err != nil {
+		t.Fatal("Failed to send labeled withdraw UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForLabeledRoute("20.1.5.0", false, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.RouteMgr.GetRoute("20.1.5.0"); !ok {
+		t.Fatal("Unicast route 20.1.5.0/24 deleted by the labeled withdraw")
+	}
+
+	if err := speaker.SendUpdate([]packet.NLRI{prefix}, nil, nil); err != nil {
+		t.Fatal("Failed to send withdraw UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForRoute("20.1.5.0", false, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEndOfRIBAfterInitialRoutes(t *testing.T) {
+	h := New(t, localAS, localRouterId)
+	h.RouteMgr.SetReachable(peerIP, true)
+	speaker := establish(t, h, peerIP, peerAS)
+	defer speaker.Close()
+
+	nlri := []packet.NLRI{packet.NewIPPrefix(net.ParseIP("20.1.6.0").To4(), 24)}
+	if err := speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), nlri); err != nil {
+		t.Fatal("Failed to send UPDATE, error:", err)
+	}
+	deadline := time.Now().Add(defaultTimeout)
+	for h.RouteMgr.EndOfRIBCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("End of RIB not sent in", defaultTimeout)
+		}
+		time.Sleep(pollInterval)
+	}
+	// The route received before the end of RIB is installed already
+	if _, ok := h.RouteMgr.GetRoute("20.1.6.0"); !ok {
+		t.Fatal("End of RIB sent before route 20.1.6.0/24 was installed")
+	}
+
+	// Routes received later do not send it again
+	nlri = []packet.NLRI{packet.NewIPPrefix(net.ParseIP("20.1.7.0").To4(), 24)}
+	if err := speaker.SendUpdate(nil, constructPathAttrs(peerAS, peerIP), nlri); err != nil {
+		t.Fatal("Failed to send UPDATE, error:", err)
+	}
+	if err := h.RouteMgr.WaitForRoute("20.1.7.0", true, defaultTimeout); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * endOfRIBDelay)
+	if count := h.RouteMgr.EndOfRIBCount(); count != 1 {
+		t.Fatal("End of RIB sent", count, "times, expected once")
+	}
+}
@@ -0,0 +1,340 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// mocks.go
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"l3/bgp/config"
+	"models/objects"
+	"net"
+	"sync"
+	"time"
+	"utils/logging"
+)
+
+/*  RouteMgr is an in-memory implementation of config.RouteMgrIntf. It answers
+ *  every next hop lookup as reachable and records the routes bgpd installs.
+ *  Labeled routes are recorded apart from the unicast routes like ribd does.
+ */
+type RouteMgr struct {
+	logger        *logging.Writer
+	mutex         sync.RWMutex
+	routes        map[string]*config.RouteConfig
+	labeledRoutes map[string]*config.RouteConfig
+	unreachable   map[string]bool
+	endOfRIB      int
+}
+
+func NewRouteMgr(logger *logging.Writer) *RouteMgr {
+	return &RouteMgr{
+		logger:        logger,
+		routes:        make(map[string]*config.RouteConfig),
+		labeledRoutes: make(map[string]*config.RouteConfig),
+		unreachable:   make(map[string]bool),
+	}
+}
+
+func (r *RouteMgr) Start() {
+}
+
+func (r *RouteMgr) SetReachable(ipAddr string, reachable bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if reachable {
+		delete(r.unreachable, ipAddr)
+	} else {
+		r.unreachable[ipAddr] = true
+	}
+}
+
+func (r *RouteMgr) GetNextHopInfo(ipAddr string, ifIndex int32) (*config.NextHopInfo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if r.unreachable[ipAddr] {
+		return nil, errors.New(fmt.Sprintf("Next hop %s is not reachable", ipAddr))
+	}
+
+	nh := config.NextHopInfo{
+		IPAddr:         ipAddr,
+		Metric:         0,
+		NextHopIp:      ipAddr,
+		IsReachable:    true,
+		NextHopIfType:  0,
+		NextHopIfIndex: 1,
+	}
+	return &nh, nil
+}
+
+func (r *RouteMgr) routeTable(cfg *config.RouteConfig) map[string]*config.RouteConfig {
+	if cfg.Labeled {
+		return r.labeledRoutes
+	}
+	return r.routes
+}
+
+func (r *RouteMgr) CreateRoute(cfg *config.RouteConfig) {
+	r.logger.Info("Harness RouteMgr: CreateRoute", cfg)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.routeTable(cfg)[cfg.DestinationNw] = cfg
+}
+
+func (r *RouteMgr) DeleteRoute(cfg *config.RouteConfig) {
+	r.logger.Info("Harness RouteMgr: DeleteRoute", cfg)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.routeTable(cfg), cfg.DestinationNw)
+}
+
+func (r *RouteMgr) UpdateRoute(cfg *config.RouteConfig, op string) {
+	r.logger.Info("Harness RouteMgr: UpdateRoute", cfg, "op", op)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if op == "remove" {
+		delete(r.routeTable(cfg), cfg.DestinationNw)
+	} else {
+		r.routeTable(cfg)[cfg.DestinationNw] = cfg
+	}
+}
+
+func (r *RouteMgr) ApplyPolicy(applyList []*config.ApplyPolicyInfo, undoList []*config.ApplyPolicyInfo) {
+}
+
+func (r *RouteMgr) GetRoutes() ([]*config.RouteInfo, []*config.RouteInfo) {
+	return nil, nil
+}
+
+func (r *RouteMgr) RoutesEndOfRIB() {
+	r.logger.Info("Harness RouteMgr: RoutesEndOfRIB")
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.endOfRIB++
+}
+
+/*  EndOfRIBCount returns the number of times bgpd signalled the end of its
+ *  initial routes.
+ */
+func (r *RouteMgr) EndOfRIBCount() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.endOfRIB
+}
+
+/*  GetRoute returns the route installed for the destination network, if any.
+ */
+func (r *RouteMgr) GetRoute(destNw string) (*config.RouteConfig, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	cfg, ok := r.routes[destNw]
+	return cfg, ok
+}
+
+/*  GetLabeledRoute returns the labeled route installed for the destination
+ *  network, if any.
+ */
+func (r *RouteMgr) GetLabeledRoute(destNw string) (*config.RouteConfig, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	cfg, ok := r.labeledRoutes[destNw]
+	return cfg, ok
+}
+
+/*  WaitForRoute polls the installed routes until the destination network is
+ *  present (installed == true) or absent (installed == false).
+ */
+func (r *RouteMgr) WaitForRoute(destNw string, installed bool, timeout time.Duration) error {
+	return r.waitFor(r.GetRoute, destNw, installed, timeout)
+}
+
+/*  WaitForLabeledRoute is WaitForRoute for the labeled routes.
+ */
+func (r *RouteMgr) WaitForLabeledRoute(destNw string, installed bool, timeout time.Duration) error {
+	return r.waitFor(r.GetLabeledRoute, destNw, installed, timeout)
+}
+
+func (r *RouteMgr) waitFor(getRoute func(string) (*config.RouteConfig, bool), destNw string, installed bool,
+	timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, ok := getRoute(destNw); ok == installed {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("Route %s installed state did not change to %t in %s", destNw,
+				installed, timeout))
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+/*  IntfMgr is an in-memory implementation of config.IntfStateMgrIntf.
+ */
+type IntfMgr struct {
+	logger *logging.Writer
+}
+
+func NewIntfMgr(logger *logging.Writer) *IntfMgr {
+	return &IntfMgr{logger: logger}
+}
+
+func (i *IntfMgr) Start() {
+}
+
+func (i *IntfMgr) PortStateChange() {
+}
+
+func (i *IntfMgr) GetIPv4Intfs() []*config.IntfStateInfo {
+	return make([]*config.IntfStateInfo, 0)
+}
+
+func (i *IntfMgr) GetIPv6Intfs() []*config.IntfStateInfo {
+	return make([]*config.IntfStateInfo, 0)
+}
+
+func (i *IntfMgr) GetIPv6Neighbors() []*config.IntfStateInfo {
+	return make([]*config.IntfStateInfo, 0)
+}
+
+func (i *IntfMgr) GetPortInfo() []config.IntfMapInfo {
+	return nil
+}
+
+func (i *IntfMgr) GetVlanInfo() []config.IntfMapInfo {
+	return nil
+}
+
+func (i *IntfMgr) GetLogicalIntfInfo() []config.IntfMapInfo {
+	return nil
+}
+
+func (i *IntfMgr) GetIPv4Information(ifIndex int32) (string, error) {
+	return "", nil
+}
+
+func (i *IntfMgr) GetIPv6Information(ifIndex int32) (string, error) {
+	return "", nil
+}
+
+func (i *IntfMgr) GetIfIndex(ifIndex, ifType int) int32 {
+	return int32(ifIndex)
+}
+
+/*  BfdMgr is an in-memory implementation of config.BfdMgrIntf. It keeps track
+ *  of the sessions bgpd asked for.
+ */
+type BfdMgr struct {
+	logger   *logging.Writer
+	mutex    sync.RWMutex
+	sessions map[string]string
+}
+
+func NewBfdMgr(logger *logging.Writer) *BfdMgr {
+	return &BfdMgr{
+		logger:   logger,
+		sessions: make(map[string]string),
+	}
+}
+
+func (b *BfdMgr) Start() {
+}
+
+func (b *BfdMgr) CreateBfdSession(ipAddr string, iface string, sessionParam string) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.sessions[ipAddr] = sessionParam
+	return true, nil
+}
+
+func (b *BfdMgr) DeleteBfdSession(ipAddr string, iface string) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.sessions, ipAddr)
+	return true, nil
+}
+
+func (b *BfdMgr) HasSession(ipAddr string) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	_, ok := b.sessions[ipAddr]
+	return ok
+}
+
+/*  PolicyMgr is a no-op implementation of config.PolicyMgrIntf.
+ */
+type PolicyMgr struct {
+}
+
+func (p *PolicyMgr) Start() {
+}
+
+/*  StateDBClient discards all the state objects written by bgpd.
+ */
+type StateDBClient struct {
+}
+
+func (d *StateDBClient) Init() error {
+	return nil
+}
+
+func (d *StateDBClient) AddObject(obj objects.ConfigObj) error {
+	return nil
+}
+
+func (d *StateDBClient) DeleteObject(obj objects.ConfigObj) error {
+	return nil
+}
+
+func (d *StateDBClient) UpdateObject(obj objects.ConfigObj) error {
+	return nil
+}
+
+func (d *StateDBClient) DeleteAllObjects(obj objects.ConfigObj) error {
+	return nil
+}
+
+/*  pipeConn is one end of a net.Pipe that reports TCP addresses, so that the
+ *  server can find the peer from the connection like it does for real sockets.
+ */
+type pipeConn struct {
+	net.Conn
+	local  net.Addr
+	remote net.Addr
+}
+
+func (p *pipeConn) LocalAddr() net.Addr {
+	return p.local
+}
+
+func (p *pipeConn) RemoteAddr() net.Addr {
+	return p.remote
+}
+
+func newPipeConns(localIP, remoteIP string) (net.Conn, net.Conn) {
+	localAddr := &net.TCPAddr{IP: net.ParseIP(localIP), Port: 179}
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP(remoteIP), Port: 179}
+	localEnd, remoteEnd := net.Pipe()
+	return &pipeConn{localEnd, localAddr, remoteAddr}, &pipeConn{remoteEnd, remoteAddr, localAddr}
+}
@@ -0,0 +1,259 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// speaker.go
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"l3/bgp/packet"
+	"net"
+	"sync"
+	"time"
+)
+
+/*  SpeakerConfig describes the remote BGP speaker played by the test.
+ */
+type SpeakerConfig struct {
+	AS            uint32
+	RouterId      string
+	HoldTime      uint16
+	AfiSafis      map[uint32]bool
+	AddPathsRx    bool
+	AddPathsMaxTx uint8
+}
+
+/*  Speaker is the scripted far end of a bgpd session. Messages received from
+ *  bgpd are decoded in the background and can be waited for with Receive and
+ *  Expect.
+ */
+type Speaker struct {
+	Config    SpeakerConfig
+	conn      net.Conn
+	attrMutex sync.RWMutex
+	peerAttrs packet.BGPPeerAttrs
+	rxCh      chan *packet.BGPMessage
+	rxErr     error
+	closeOnce sync.Once
+}
+
+func NewSpeaker(conn net.Conn, cfg SpeakerConfig) *Speaker {
+	if cfg.HoldTime == 0 {
+		cfg.HoldTime = 90
+	}
+	if cfg.AfiSafis == nil {
+		cfg.AfiSafis = map[uint32]bool{packet.ProtocolFamilyMap["ipv4-unicast"]: true}
+	}
+
+	s := &Speaker{
+		Config: cfg,
+		conn:   conn,
+		peerAttrs: packet.BGPPeerAttrs{
+			ASSize:           2,
+			AddPathsRxActual: false,
+		},
+		rxCh: make(chan *packet.BGPMessage, 64),
+	}
+	go s.readMessages()
+	return s
+}
+
+func (s *Speaker) getPeerAttrs() packet.BGPPeerAttrs {
+	s.attrMutex.RLock()
+	defer s.attrMutex.RUnlock()
+	return s.peerAttrs
+}
+
+func (s *Speaker) setPeerAttrs(openMsg *packet.BGPOpen) {
+	s.attrMutex.Lock()
+	defer s.attrMutex.Unlock()
+	s.peerAttrs.ASSize = packet.GetASSize(openMsg)
+	s.peerAttrs.AddPathFamily = packet.GetAddPathFamily(openMsg)
+	if s.Config.AddPathsRx && packet.IsAddPathsTxEnabledForIPv4(s.peerAttrs.AddPathFamily) {
+		s.peerAttrs.AddPathsRxActual = true
+	}
+}
+
+func (s *Speaker) stopReading(err error) {
+	s.rxErr = err
+	close(s.rxCh)
+}
+
+func (s *Speaker) readMessages() {
+	for {
+		buf := make([]byte, packet.BGPMsgHeaderLen)
+		if _, err := io.ReadFull(s.conn, buf); err != nil {
+			s.stopReading(err)
+			return
+		}
+
+		header := packet.NewBGPHeader()
+		header.Decode(buf)
+		if header.Len() < packet.BGPMsgHeaderLen || header.Len() > packet.BGPMsgMaxLen {
+			err := errors.New(fmt.Sprintf("Received BGP message with invalid length %d", header.Len()))
+			s.stopReading(err)
+			return
+		}
+
+		body := make([]byte, header.Len()-packet.BGPMsgHeaderLen)
+		if _, err := io.ReadFull(s.conn, body); err != nil {
+			s.stopReading(err)
+			return
+		}
+
+		msg := packet.NewBGPMessage()
+		if err := msg.Decode(header, body, s.getPeerAttrs()); err != nil {
+			s.stopReading(err)
+			return
+		}
+
+		if openMsg, ok := msg.Body.(*packet.BGPOpen); ok {
+			s.setPeerAttrs(openMsg)
+		}
+		s.rxCh <- msg
+	}
+}
+
+/*  Send encodes and writes a BGP message to bgpd.
+ */
+func (s *Speaker) Send(msg *packet.BGPMessage) error {
+	pkt, err := msg.Encode()
+	if err != nil {
+		return err
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(defaultTimeout))
+	_, err = s.conn.Write(pkt)
+	return err
+}
+
+/*  SendRaw writes the bytes to bgpd as they are, for malformed message tests.
+ */
+func (s *Speaker) SendRaw(pkt []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(defaultTimeout))
+	_, err := s.conn.Write(pkt)
+	return err
+}
+
+func (s *Speaker) SendOpen() error {
+	optParams := packet.ConstructOptParams(s.Config.AS, s.Config.AfiSafis, s.Config.AddPathsRx,
+		s.Config.AddPathsMaxTx)
+	return s.Send(packet.NewBGPOpenMessage(s.Config.AS, s.Config.HoldTime, s.Config.RouterId, optParams))
+}
+
+func (s *Speaker) SendKeepAlive() error {
+	return s.Send(packet.NewBGPKeepAliveMessage())
+}
+
+func (s *Speaker) SendNotification(code uint8, subCode uint8, data []byte) error {
+	return s.Send(packet.NewBGPNotificationMessage(code, subCode, data))
+}
+
+func (s *Speaker) SendUpdate(withdrawn []packet.NLRI, pathAttrs []packet.BGPPathAttr, nlri []packet.NLRI) error {
+	return s.Send(packet.NewBGPUpdateMessage(withdrawn, pathAttrs, nlri))
+}
+
+/*  Receive returns the next message sent by bgpd.
+ */
+func (s *Speaker) Receive(timeout time.Duration) (*packet.BGPMessage, error) {
+	select {
+	case msg, ok := <-s.rxCh:
+		if !ok {
+			return nil, s.rxErr
+		}
+		return msg, nil
+
+	case <-time.After(timeout):
+		return nil, errors.New(fmt.Sprintf("No BGP message received in %s", timeout))
+	}
+}
+
+/*  Expect waits for a message of the given type. KEEPALIVEs are skipped unless
+ *  a KEEPALIVE is what is expected; any other message is an error.
+ */
+func (s *Speaker) Expect(msgType uint8, timeout time.Duration) (*packet.BGPMessage, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, err := s.Receive(deadline.Sub(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+
+		if msg.Header.Type == msgType {
+			return msg, nil
+		}
+
+		if msg.Header.Type != packet.BGPMsgTypeKeepAlive {
+			return msg, errors.New(fmt.Sprintf("Expected BGP message type %d, received %d", msgType,
+				msg.Header.Type))
+		}
+	}
+}
+
+/*  ExpectClosed waits until bgpd closes the connection.
+ */
+func (s *Speaker) ExpectClosed(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		select {
+		case _, ok := <-s.rxCh:
+			if !ok {
+				return nil
+			}
+
+		case <-time.After(deadline.Sub(time.Now())):
+			return errors.New(fmt.Sprintf("Connection was not closed in %s", timeout))
+		}
+	}
+}
+
+/*  Establish runs the OPEN/KEEPALIVE exchange for a session where bgpd sends
+ *  its OPEN first, and returns the OPEN received from bgpd.
+ */
+func (s *Speaker) Establish(timeout time.Duration) (*packet.BGPOpen, error) {
+	msg, err := s.Expect(packet.BGPMsgTypeOpen, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.SendOpen(); err != nil {
+		return nil, err
+	}
+
+	if _, err = s.Expect(packet.BGPMsgTypeKeepAlive, timeout); err != nil {
+		return nil, err
+	}
+
+	if err = s.SendKeepAlive(); err != nil {
+		return nil, err
+	}
+	return msg.Body.(*packet.BGPOpen), nil
+}
+
+func (s *Speaker) Close() {
+	s.closeOnce.Do(func() {
+		s.conn.Close()
+	})
+}
@@ -60,7 +60,7 @@ func (server *OSPFServer) listenForRIBUpdates(address string) error {
 		return err
 	}
 
-	if err = server.ribSubSocket.Subscribe(""); err != nil {
+	if err = server.ribSubSocket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
 		server.logger.Err(fmt.Sprintln("ERR: Failed to subscribe to \"\" on RIB subscribe socket, error:", err))
 		return err
 	}
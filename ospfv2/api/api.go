@@ -232,6 +232,50 @@ func DeleteOspfv2Intf(cfg *objects.Ospfv2Intf) (bool, error) {
 	return true, nil
 }
 
+func CreateOspfv2IntfAuthKey(cfg *objects.Ospfv2IntfAuthKey) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.CREATE_OSPFV2_INTF_AUTH_KEY,
+		Data: interface{}(&server.CreateOspfv2IntfAuthKeyInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.CreateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during CreateIntfAuthKey")
+}
+
+func UpdateOspfv2IntfAuthKey(oldCfg, newCfg *objects.Ospfv2IntfAuthKey, attrset []bool) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.UPDATE_OSPFV2_INTF_AUTH_KEY,
+		Data: interface{}(&server.UpdateOspfv2IntfAuthKeyInArgs{
+			OldCfg:  oldCfg,
+			NewCfg:  newCfg,
+			AttrSet: attrset,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.UpdateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during UpdateIntfAuthKey")
+}
+
+func DeleteOspfv2IntfAuthKey(cfg *objects.Ospfv2IntfAuthKey) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.DELETE_OSPFV2_INTF_AUTH_KEY,
+		Data: interface{}(&server.DeleteOspfv2IntfAuthKeyInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.DeleteConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during DeleteIntfAuthKey")
+}
+
 func GetOspfv2IntfState(ipAddr, addrLessIfIdx uint32) (*objects.Ospfv2IntfState, error) {
 	svr.ReqChan <- &server.ServerRequest{
 		Op: server.GET_OSPFV2_INTF_STATE,
@@ -264,6 +308,214 @@ func GetBulkOspfv2IntfState(fromIdx, count int) (*objects.Ospfv2IntfStateGetInfo
 	}
 }
 
+func CreateOspfv2VirtualLink(cfg *objects.Ospfv2VirtualLink) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.CREATE_OSPFV2_VIRTUAL_LINK,
+		Data: interface{}(&server.CreateOspfv2VirtualLinkInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.CreateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during CreateVirtualLink")
+}
+
+func UpdateOspfv2VirtualLink(oldCfg, newCfg *objects.Ospfv2VirtualLink, attrset []bool) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.UPDATE_OSPFV2_VIRTUAL_LINK,
+		Data: interface{}(&server.UpdateOspfv2VirtualLinkInArgs{
+			OldCfg:  oldCfg,
+			NewCfg:  newCfg,
+			AttrSet: attrset,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.UpdateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during UpdateVirtualLink")
+}
+
+func DeleteOspfv2VirtualLink(cfg *objects.Ospfv2VirtualLink) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.DELETE_OSPFV2_VIRTUAL_LINK,
+		Data: interface{}(&server.DeleteOspfv2VirtualLinkInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.DeleteConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during DeleteVirtualLink")
+}
+
+func CreateOspfv2VirtualLinkAuthKey(cfg *objects.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.CREATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY,
+		Data: interface{}(&server.CreateOspfv2VirtualLinkAuthKeyInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.CreateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during CreateVirtualLinkAuthKey")
+}
+
+func UpdateOspfv2VirtualLinkAuthKey(oldCfg, newCfg *objects.Ospfv2VirtualLinkAuthKey, attrset []bool) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.UPDATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY,
+		Data: interface{}(&server.UpdateOspfv2VirtualLinkAuthKeyInArgs{
+			OldCfg:  oldCfg,
+			NewCfg:  newCfg,
+			AttrSet: attrset,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.UpdateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during UpdateVirtualLinkAuthKey")
+}
+
+func DeleteOspfv2VirtualLinkAuthKey(cfg *objects.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.DELETE_OSPFV2_VIRTUAL_LINK_AUTH_KEY,
+		Data: interface{}(&server.DeleteOspfv2VirtualLinkAuthKeyInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.DeleteConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during DeleteVirtualLinkAuthKey")
+}
+
+func GetOspfv2VirtualLinkState(transitAreaId, nbrRouterId uint32) (*objects.Ospfv2VirtualLinkState, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.GET_OSPFV2_VIRTUAL_LINK_STATE,
+		Data: interface{}(&server.GetOspfv2VirtualLinkStateInArgs{
+			TransitAreaId: transitAreaId,
+			NbrRouterId:   nbrRouterId,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.GetOspfv2VirtualLinkStateOutArgs); ok {
+		return retObj.Obj, retObj.Err
+	} else {
+		return nil, errors.New("Error: Invalid response received from server during GetOspfv2VirtualLinkState")
+	}
+}
+
+func GetBulkOspfv2VirtualLinkState(fromIdx, count int) (*objects.Ospfv2VirtualLinkStateGetInfo, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.GET_BULK_OSPFV2_VIRTUAL_LINK_STATE,
+		Data: interface{}(&server.GetBulkInArgs{
+			FromIdx: fromIdx,
+			Count:   count,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.GetBulkOspfv2VirtualLinkStateOutArgs); ok {
+		return retObj.BulkInfo, retObj.Err
+	} else {
+		return nil, errors.New("Error: Invalid response received from server during GetBulkOspfv2VirtualLinkState")
+	}
+}
+
+func CreateOspfv2AreaRange(cfg *objects.Ospfv2AreaRange) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.CREATE_OSPFV2_AREA_RANGE,
+		Data: interface{}(&server.CreateOspfv2AreaRangeInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.CreateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during CreateAreaRange")
+}
+
+func UpdateOspfv2AreaRange(oldCfg, newCfg *objects.Ospfv2AreaRange, attrset []bool) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.UPDATE_OSPFV2_AREA_RANGE,
+		Data: interface{}(&server.UpdateOspfv2AreaRangeInArgs{
+			OldCfg:  oldCfg,
+			NewCfg:  newCfg,
+			AttrSet: attrset,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.UpdateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during UpdateAreaRange")
+}
+
+func DeleteOspfv2AreaRange(cfg *objects.Ospfv2AreaRange) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.DELETE_OSPFV2_AREA_RANGE,
+		Data: interface{}(&server.DeleteOspfv2AreaRangeInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.DeleteConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during DeleteAreaRange")
+}
+
+func CreateOspfv2PrefixList(cfg *objects.Ospfv2PrefixList) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.CREATE_OSPFV2_PREFIX_LIST,
+		Data: interface{}(&server.CreateOspfv2PrefixListInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.CreateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during CreatePrefixList")
+}
+
+func UpdateOspfv2PrefixList(oldCfg, newCfg *objects.Ospfv2PrefixList, attrset []bool) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.UPDATE_OSPFV2_PREFIX_LIST,
+		Data: interface{}(&server.UpdateOspfv2PrefixListInArgs{
+			OldCfg:  oldCfg,
+			NewCfg:  newCfg,
+			AttrSet: attrset,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.UpdateConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during UpdatePrefixList")
+}
+
+func DeleteOspfv2PrefixList(cfg *objects.Ospfv2PrefixList) (bool, error) {
+	svr.ReqChan <- &server.ServerRequest{
+		Op: server.DELETE_OSPFV2_PREFIX_LIST,
+		Data: interface{}(&server.DeleteOspfv2PrefixListInArgs{
+			Cfg: cfg,
+		}),
+	}
+	ret := <-svr.ReplyChan
+	if retObj, ok := ret.(*server.DeleteConfigOutArgs); ok {
+		return retObj.RetVal, retObj.Err
+	}
+	return false, errors.New("Error: Invalid response received from server during DeletePrefixList")
+}
+
 func GetOspfv2LsdbState(lsType uint8, lsId, areaId, advRtrId uint32) (*objects.Ospfv2LsdbState, error) {
 	svr.ReqChan <- &server.ServerRequest{
 		Op: server.GET_OSPFV2_LSDB_STATE,
@@ -23,16 +23,25 @@
 
 package objects
 
+import (
+	"time"
+)
+
 const (
 	AUTH_TYPE_NONE_STR            string = "none"
 	AUTH_TYPE_SIMPLE_PASSWORD_STR string = "simplepassword"
 	AUTH_TYPE_MD5_STR             string = "md5"
+	AUTH_TYPE_CRYPTOGRAPHIC_STR   string = "cryptographic"
 )
 
+// AuType values as carried in the OSPF header (RFC 2328 D.3). MD5 and the
+// HMAC-SHA algorithms of RFC 5709 all use the cryptographic AuType, the
+// algorithm itself comes from the interface key chain.
 const (
 	AUTH_TYPE_NONE            uint8 = 0
 	AUTH_TYPE_SIMPLE_PASSWORD uint8 = 1
 	AUTH_TYPE_MD5             uint8 = 2
+	AUTH_TYPE_CRYPTOGRAPHIC   uint8 = 2
 )
 
 const (
@@ -46,16 +55,57 @@ const (
 )
 
 const (
-	OSPFV2_AREA_UPDATE_ADMIN_STATE      = 0x1
-	OSPFV2_AREA_UPDATE_AUTH_TYPE        = 0x2
-	OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN = 0x3
+	NSSA_TRANSLATOR_ROLE_CANDIDATE_STR string = "candidate"
+	NSSA_TRANSLATOR_ROLE_ALWAYS_STR    string = "always"
+)
+
+// NSSATranslatorRole (RFC 3101 Appendix D)
+const (
+	NSSA_TRANSLATOR_ROLE_CANDIDATE uint8 = 0
+	NSSA_TRANSLATOR_ROLE_ALWAYS    uint8 = 1
+)
+
+const (
+	NSSA_TRANSLATOR_STATE_DISABLED_STR string = "disabled"
+	NSSA_TRANSLATOR_STATE_ENABLED_STR  string = "enabled"
+	NSSA_TRANSLATOR_STATE_ELECTED_STR  string = "elected"
+)
+
+// NSSATranslatorState (RFC 3101 Appendix D)
+const (
+	NSSA_TRANSLATOR_STATE_DISABLED uint8 = 0
+	NSSA_TRANSLATOR_STATE_ENABLED  uint8 = 1
+	NSSA_TRANSLATOR_STATE_ELECTED  uint8 = 2
 )
 
+const (
+	OSPFV2_AREA_UPDATE_ADMIN_STATE          = 0x1
+	OSPFV2_AREA_UPDATE_AUTH_TYPE            = 0x2
+	OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN     = 0x3
+	OSPFV2_AREA_UPDATE_NSSA                 = 0x4
+	OSPFV2_AREA_UPDATE_NO_SUMMARY           = 0x8
+	OSPFV2_AREA_UPDATE_STUB_DEFAULT_COST    = 0x10
+	OSPFV2_AREA_UPDATE_NSSA_TRANSLATOR_ROLE = 0x20
+	OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST   = 0x40
+	OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST   = 0x80
+)
+
+// An area with ImportASExtern false is a stub area, or an NSSA when Nssa
+// is also set. NoSummary makes it totally stubby / totally NSSA.
+// ImportFilterList and ExportFilterList name the Ospfv2PrefixList applied
+// by an area border router to the Type 3 summary LSAs it originates into
+// and out of the area respectively.
 type Ospfv2Area struct {
-	AreaId         uint32
-	AdminState     bool
-	AuthType       uint8
-	ImportASExtern bool
+	AreaId             uint32
+	AdminState         bool
+	AuthType           uint8
+	ImportASExtern     bool
+	Nssa               bool
+	NoSummary          bool
+	StubDefaultCost    uint32
+	NssaTranslatorRole uint8
+	ImportFilterList   string
+	ExportFilterList   string
 }
 
 type Ospfv2AreaState struct {
@@ -63,15 +113,17 @@ type Ospfv2AreaState struct {
 	//NumSpfRuns       uint32
 	//NumBdrRtr        uint32
 	//NumAsBdrRtr      uint32
-	NumOfRouterLSA     uint32
-	NumOfNetworkLSA    uint32
-	NumOfSummary3LSA   uint32
-	NumOfSummary4LSA   uint32
-	NumOfASExternalLSA uint32
-	NumOfIntfs         uint32
-	NumOfLSA           uint32
-	NumOfNbrs          uint32
-	NumOfRoutes        uint32
+	NumOfRouterLSA      uint32
+	NumOfNetworkLSA     uint32
+	NumOfSummary3LSA    uint32
+	NumOfSummary4LSA    uint32
+	NumOfASExternalLSA  uint32
+	NumOfNSSALSA        uint32
+	NumOfIntfs          uint32
+	NumOfLSA            uint32
+	NumOfNbrs           uint32
+	NumOfRoutes         uint32
+	NssaTranslatorState uint8
 }
 
 type Ospfv2AreaStateGetInfo struct {
@@ -96,6 +148,37 @@ const (
 	OSPFV2_GLOBAL_UPDATE_ADMIN_STATE         = 0x2
 	OSPFV2_GLOBAL_UPDATE_AS_BDR_RTR_STATUS   = 0x4
 	OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH = 0x8
+	OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART    = 0x10
+	OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD        = 0x20
+	OSPFV2_GLOBAL_UPDATE_GR_HELPER           = 0x40
+)
+
+// Grace period in seconds (RFC 4750 ospfRestartInterval)
+const (
+	GR_MIN_GRACE_PERIOD     uint16 = 1
+	GR_MAX_GRACE_PERIOD     uint16 = 1800
+	GR_DEFAULT_GRACE_PERIOD uint16 = 120
+)
+
+// RestartStatus (RFC 4750 ospfRestartStatus)
+const (
+	GR_STATUS_NOT_RESTARTING  uint8 = 1
+	GR_STATUS_PLANNED_RESTART uint8 = 2
+)
+
+// HelperStatus (RFC 4750 ospfNbrRestartHelperStatus)
+const (
+	GR_HELPER_STATUS_NOT_HELPING uint8 = 1
+	GR_HELPER_STATUS_HELPING     uint8 = 2
+)
+
+// Restart and helper exit reason (RFC 4750 ospfRestartExitReason)
+const (
+	GR_EXIT_REASON_NONE             uint8 = 1
+	GR_EXIT_REASON_IN_PROGRESS      uint8 = 2
+	GR_EXIT_REASON_COMPLETED        uint8 = 3
+	GR_EXIT_REASON_TIMED_OUT        uint8 = 4
+	GR_EXIT_REASON_TOPOLOGY_CHANGED uint8 = 5
 )
 
 type Ospfv2Global struct {
@@ -104,6 +187,9 @@ type Ospfv2Global struct {
 	AdminState         bool
 	ASBdrRtrStatus     bool
 	ReferenceBandwidth uint32
+	GracefulRestart    bool
+	GracePeriod        uint16
+	GrHelper           bool
 }
 
 type Ospfv2GlobalState struct {
@@ -119,6 +205,9 @@ type Ospfv2GlobalState struct {
 	NumOfSummary4LSA   uint32
 	NumOfASExternalLSA uint32
 	NumOfRoutes        uint32
+	RestartStatus      uint8
+	RestartAge         uint32
+	RestartExitReason  uint8
 }
 
 type Ospfv2GlobalStateGetInfo struct {
@@ -141,11 +230,15 @@ const (
 const (
 	INTF_TYPE_POINT2POINT_STR string = "pointtopoint"
 	INTF_TYPE_BROADCAST_STR   string = "broadcast"
+	INTF_TYPE_VIRTUAL_STR     string = "virtual"
 )
 
+// INTF_TYPE_VIRTUAL is never configured on an Ospfv2Intf, it is the type of
+// the backbone interface created for an Ospfv2VirtualLink
 const (
 	INTF_TYPE_POINT2POINT uint8 = 0
 	INTF_TYPE_BROADCAST   uint8 = 1
+	INTF_TYPE_VIRTUAL     uint8 = 2
 )
 
 const (
@@ -180,6 +273,7 @@ const (
 	OSPFV2_INTF_UPDATE_HELLO_INTERVAL    = 0x80
 	OSPFV2_INTF_UPDATE_RTR_DEAD_INTERVAL = 0x100
 	OSPFV2_INTF_UPDATE_METRIC_VALUE      = 0x200
+	OSPFV2_INTF_UPDATE_BFD_ENABLE        = 0x400
 )
 
 type Ospfv2Intf struct {
@@ -194,6 +288,48 @@ type Ospfv2Intf struct {
 	HelloInterval    uint16
 	RtrDeadInterval  uint32
 	MetricValue      uint16
+	BfdEnable        bool
+}
+
+const (
+	CRYPTO_ALGO_MD5_STR         string = "md5"
+	CRYPTO_ALGO_HMAC_SHA1_STR   string = "hmac-sha-1"
+	CRYPTO_ALGO_HMAC_SHA256_STR string = "hmac-sha-256"
+	CRYPTO_ALGO_HMAC_SHA384_STR string = "hmac-sha-384"
+	CRYPTO_ALGO_HMAC_SHA512_STR string = "hmac-sha-512"
+)
+
+const (
+	CRYPTO_ALGO_MD5         uint8 = 0
+	CRYPTO_ALGO_HMAC_SHA1   uint8 = 1
+	CRYPTO_ALGO_HMAC_SHA256 uint8 = 2
+	CRYPTO_ALGO_HMAC_SHA384 uint8 = 3
+	CRYPTO_ALGO_HMAC_SHA512 uint8 = 4
+	// Not set, only allowed on simple password keys
+	CRYPTO_ALGO_NONE uint8 = 0xff
+)
+
+const (
+	OSPFV2_INTF_AUTH_KEY_UPDATE_KEY                   = 0x1
+	OSPFV2_INTF_AUTH_KEY_UPDATE_CRYPTO_ALGORITHM      = 0x2
+	OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_START   = 0x4
+	OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_END     = 0x8
+	OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_START = 0x10
+	OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_END   = 0x20
+)
+
+// Ospfv2IntfAuthKey is one entry of an interface key chain. A zero lifetime
+// start or end leaves that side of the lifetime unbounded.
+type Ospfv2IntfAuthKey struct {
+	IpAddress           uint32
+	AddressLessIfIdx    uint32
+	KeyId               uint8
+	Key                 string
+	CryptoAlgorithm     uint8
+	SendLifetimeStart   time.Time
+	SendLifetimeEnd     time.Time
+	AcceptLifetimeStart time.Time
+	AcceptLifetimeEnd   time.Time
 }
 
 type Ospfv2IntfState struct {
@@ -224,12 +360,116 @@ type Ospfv2IntfStateGetInfo struct {
 	List   []*Ospfv2IntfState
 }
 
+const (
+	OSPFV2_VIRTUAL_LINK_UPDATE_ADMIN_STATE       = 0x1
+	OSPFV2_VIRTUAL_LINK_UPDATE_TRANSIT_DELAY     = 0x2
+	OSPFV2_VIRTUAL_LINK_UPDATE_RETRANS_INTERVAL  = 0x4
+	OSPFV2_VIRTUAL_LINK_UPDATE_HELLO_INTERVAL    = 0x8
+	OSPFV2_VIRTUAL_LINK_UPDATE_RTR_DEAD_INTERVAL = 0x10
+)
+
+// Ospfv2VirtualLink is a backbone link to the area border router NbrRouterId
+// through TransitAreaId (RFC 2328 15). It uses the AuthType of the backbone and
+// its keys are configured as Ospfv2VirtualLinkAuthKey.
+type Ospfv2VirtualLink struct {
+	TransitAreaId   uint32
+	NbrRouterId     uint32
+	AdminState      bool
+	TransitDelay    uint16
+	RetransInterval uint16
+	HelloInterval   uint16
+	RtrDeadInterval uint32
+}
+
+// Ospfv2VirtualLinkAuthKey is one entry of a virtual link key chain. Its
+// attributes follow the same order as Ospfv2IntfAuthKey, so updates use the
+// OSPFV2_INTF_AUTH_KEY_UPDATE mask.
+type Ospfv2VirtualLinkAuthKey struct {
+	TransitAreaId       uint32
+	NbrRouterId         uint32
+	KeyId               uint8
+	Key                 string
+	CryptoAlgorithm     uint8
+	SendLifetimeStart   time.Time
+	SendLifetimeEnd     time.Time
+	AcceptLifetimeStart time.Time
+	AcceptLifetimeEnd   time.Time
+}
+
+type Ospfv2VirtualLinkState struct {
+	TransitAreaId     uint32
+	NbrRouterId       uint32
+	State             uint8
+	IpAddress         uint32
+	NbrIpAddress      uint32
+	NbrState          uint8
+	Cost              uint32
+	NumOfStateChange  uint32
+	TimeOfStateChange string
+}
+
+type Ospfv2VirtualLinkStateGetInfo struct {
+	EndIdx int
+	Count  int
+	More   bool
+	List   []*Ospfv2VirtualLinkState
+}
+
+const (
+	OSPFV2_AREA_RANGE_UPDATE_ADVERTISE = 0x1
+	OSPFV2_AREA_RANGE_UPDATE_COST      = 0x2
+)
+
+// Ospfv2AreaRange condenses the intra area networks of AreaId falling in
+// IpPrefix/Netmask into a single Type 3 summary LSA (RFC 2328 12.4.3), or
+// hides them from the other areas when Advertise is false. A zero Cost
+// advertises the largest cost of the component networks.
+type Ospfv2AreaRange struct {
+	AreaId    uint32
+	IpPrefix  uint32
+	Netmask   uint32
+	Advertise bool
+	Cost      uint32
+}
+
+const (
+	PREFIX_LIST_ACTION_PERMIT_STR string = "permit"
+	PREFIX_LIST_ACTION_DENY_STR   string = "deny"
+)
+
+const (
+	PREFIX_LIST_ACTION_PERMIT uint8 = 1
+	PREFIX_LIST_ACTION_DENY   uint8 = 2
+)
+
+const (
+	OSPFV2_PREFIX_LIST_UPDATE_ACTION            = 0x1
+	OSPFV2_PREFIX_LIST_UPDATE_IP_PREFIX         = 0x2
+	OSPFV2_PREFIX_LIST_UPDATE_MASK_LENGTH_RANGE = 0x4
+)
+
+// Ospfv2PrefixList is the entry Seq of the prefix list Name. Entries are
+// evaluated in increasing Seq order, a prefix matches an entry when it is
+// covered by IpPrefix/Netmask and its length is within MinLen and MaxLen.
+// Prefixes matching no entry are denied, a prefix list without any entry
+// permits everything.
+type Ospfv2PrefixList struct {
+	Name     string
+	Seq      uint32
+	Action   uint8
+	IpPrefix uint32
+	Netmask  uint32
+	MinLen   uint8
+	MaxLen   uint8
+}
+
 const (
 	ROUTER_LSA     uint8 = 1
 	NETWORK_LSA    uint8 = 2
 	SUMMARY3_LSA   uint8 = 3
 	SUMMARY4_LSA   uint8 = 4
 	ASExternal_LSA uint8 = 5
+	NSSA_LSA       uint8 = 7
 )
 
 const (
@@ -238,6 +478,7 @@ const (
 	SUMMARY3_LSA_STR   string = "summary3"
 	SUMMARY4_LSA_STR   string = "summary4"
 	ASExternal_LSA_STR string = "asexternal"
+	NSSA_LSA_STR       string = "nssa"
 )
 
 type Ospfv2LsdbState struct {
@@ -290,6 +531,9 @@ type Ospfv2NbrState struct {
 	RtrId            uint32
 	Options          int32
 	State            uint8
+	HelperStatus     uint8
+	HelperAge        uint32
+	HelperExitReason uint8
 }
 
 type Ospfv2NbrStateGetInfo struct {
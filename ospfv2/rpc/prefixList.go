@@ -0,0 +1,86 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package rpc
+
+import (
+	"errors"
+	"l3/ospfv2/api"
+	"models/objects"
+	"ospfv2d"
+)
+
+func (rpcHdl *rpcServiceHandler) restoreOspfv2PrefixListConfFromDB() (bool, error) {
+	rpcHdl.logger.Info("Restoring Ospfv2 Prefix List Config From DB")
+	var ospfv2PrefixList objects.Ospfv2PrefixList
+
+	entryList, err := rpcHdl.dbHdl.GetAllObjFromDb(ospfv2PrefixList)
+	if err != nil {
+		return false, errors.New("Failed to retireve Ospfv2PrefixList object info from DB")
+	}
+	for idx := 0; idx < len(entryList); idx++ {
+		dbObj := entryList[idx].(objects.Ospfv2PrefixList)
+		obj := new(ospfv2d.Ospfv2PrefixList)
+		objects.Convertospfv2dOspfv2PrefixListObjToThrift(&dbObj, obj)
+		convObj, err := convertFromRPCFmtOspfv2PrefixList(obj)
+		if err != nil {
+			return false, err
+		}
+		ok, err := api.CreateOspfv2PrefixList(convObj)
+		if !ok {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+func (rpcHdl *rpcServiceHandler) CreateOspfv2PrefixList(config *ospfv2d.Ospfv2PrefixList) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2PrefixList(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.CreateOspfv2PrefixList(cfg)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) UpdateOspfv2PrefixList(oldConfig, newConfig *ospfv2d.Ospfv2PrefixList, attrset []bool, op []*ospfv2d.PatchOpInfo) (bool, error) {
+	convOldCfg, err := convertFromRPCFmtOspfv2PrefixList(oldConfig)
+	if err != nil {
+		return false, err
+	}
+	convNewCfg, err := convertFromRPCFmtOspfv2PrefixList(newConfig)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.UpdateOspfv2PrefixList(convOldCfg, convNewCfg, attrset)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) DeleteOspfv2PrefixList(config *ospfv2d.Ospfv2PrefixList) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2PrefixList(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.DeleteOspfv2PrefixList(cfg)
+	return rv, err
+}
@@ -71,10 +71,30 @@ func (rpcHdl *rpcServiceHandler) restoreConfigFromDB() (bool, error) {
 	if !ok {
 		return ok, err
 	}
+	ok, err = rpcHdl.restoreOspfv2PrefixListConfFromDB()
+	if !ok {
+		return ok, err
+	}
 	ok, err = rpcHdl.restoreOspfv2AreaConfFromDB()
 	if !ok {
 		return ok, err
 	}
+	ok, err = rpcHdl.restoreOspfv2AreaRangeConfFromDB()
+	if !ok {
+		return ok, err
+	}
 	ok, err = rpcHdl.restoreOspfv2IntfConfFromDB()
+	if !ok {
+		return ok, err
+	}
+	ok, err = rpcHdl.restoreOspfv2IntfAuthKeyConfFromDB()
+	if !ok {
+		return ok, err
+	}
+	ok, err = rpcHdl.restoreOspfv2VirtualLinkConfFromDB()
+	if !ok {
+		return ok, err
+	}
+	ok, err = rpcHdl.restoreOspfv2VirtualLinkAuthKeyConfFromDB()
 	return ok, err
 }
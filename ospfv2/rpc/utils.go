@@ -31,6 +31,7 @@ import (
 	"ospfv2d"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func convertDotNotationToUint32(str string) (uint32, error) {
@@ -78,35 +79,64 @@ func convertFromRPCFmtOspfv2Area(config *ospfv2d.Ospfv2Area) (*objects.Ospfv2Are
 		authType = objects.AUTH_TYPE_NONE
 	case objects.AUTH_TYPE_SIMPLE_PASSWORD_STR:
 		authType = objects.AUTH_TYPE_SIMPLE_PASSWORD
-	case objects.AUTH_TYPE_MD5_STR:
-		authType = objects.AUTH_TYPE_MD5
+	case objects.AUTH_TYPE_MD5_STR, objects.AUTH_TYPE_CRYPTOGRAPHIC_STR:
+		authType = objects.AUTH_TYPE_CRYPTOGRAPHIC
 	default:
 		return nil, errors.New("Invalid Auth Type")
 	}
+	var nssaTranslatorRole uint8
+	switch strings.ToLower(config.NssaTranslatorRole) {
+	case objects.NSSA_TRANSLATOR_ROLE_CANDIDATE_STR, "":
+		nssaTranslatorRole = objects.NSSA_TRANSLATOR_ROLE_CANDIDATE
+	case objects.NSSA_TRANSLATOR_ROLE_ALWAYS_STR:
+		nssaTranslatorRole = objects.NSSA_TRANSLATOR_ROLE_ALWAYS
+	default:
+		return nil, errors.New("Invalid NSSA Translator Role")
+	}
+	if config.StubDefaultCost < 0 || config.StubDefaultCost > 0xffffff {
+		return nil, errors.New("Invalid Stub Default Cost")
+	}
 	return &objects.Ospfv2Area{
-		AreaId:         areaId,
-		AdminState:     adminState,
-		AuthType:       authType,
-		ImportASExtern: config.ImportASExtern,
+		AreaId:             areaId,
+		AdminState:         adminState,
+		AuthType:           authType,
+		ImportASExtern:     config.ImportASExtern,
+		Nssa:               config.Nssa,
+		NoSummary:          config.NoSummary,
+		StubDefaultCost:    uint32(config.StubDefaultCost),
+		NssaTranslatorRole: nssaTranslatorRole,
+		ImportFilterList:   config.ImportFilterList,
+		ExportFilterList:   config.ExportFilterList,
 	}, nil
 }
 
 func convertToRPCFmtOspfv2AreaState(obj *objects.Ospfv2AreaState) *ospfv2d.Ospfv2AreaState {
 	areaId := convertUint32ToDotNotation(obj.AreaId)
+	var nssaTranslatorState string
+	switch obj.NssaTranslatorState {
+	case objects.NSSA_TRANSLATOR_STATE_DISABLED:
+		nssaTranslatorState = objects.NSSA_TRANSLATOR_STATE_DISABLED_STR
+	case objects.NSSA_TRANSLATOR_STATE_ENABLED:
+		nssaTranslatorState = objects.NSSA_TRANSLATOR_STATE_ENABLED_STR
+	case objects.NSSA_TRANSLATOR_STATE_ELECTED:
+		nssaTranslatorState = objects.NSSA_TRANSLATOR_STATE_ELECTED_STR
+	}
 	return &ospfv2d.Ospfv2AreaState{
 		AreaId: areaId,
 		//NumSpfRuns:       int32(obj.NumSpfRuns),
 		//NumBdrRtr:        int32(obj.NumBdrRtr),
 		//NumAsBdrRtr:      int32(obj.NumAsBdrRtr),
-		NumOfRouterLSA:     int32(obj.NumOfRouterLSA),
-		NumOfNetworkLSA:    int32(obj.NumOfNetworkLSA),
-		NumOfSummary3LSA:   int32(obj.NumOfSummary3LSA),
-		NumOfSummary4LSA:   int32(obj.NumOfSummary4LSA),
-		NumOfASExternalLSA: int32(obj.NumOfASExternalLSA),
-		NumOfIntfs:         int32(obj.NumOfIntfs),
-		NumOfNbrs:          int32(obj.NumOfNbrs),
-		NumOfLSA:           int32(obj.NumOfLSA),
-		NumOfRoutes:        int32(obj.NumOfRoutes),
+		NumOfRouterLSA:      int32(obj.NumOfRouterLSA),
+		NumOfNetworkLSA:     int32(obj.NumOfNetworkLSA),
+		NumOfSummary3LSA:    int32(obj.NumOfSummary3LSA),
+		NumOfSummary4LSA:    int32(obj.NumOfSummary4LSA),
+		NumOfASExternalLSA:  int32(obj.NumOfASExternalLSA),
+		NumOfNSSALSA:        int32(obj.NumOfNSSALSA),
+		NumOfIntfs:          int32(obj.NumOfIntfs),
+		NumOfNbrs:           int32(obj.NumOfNbrs),
+		NumOfLSA:            int32(obj.NumOfLSA),
+		NumOfRoutes:         int32(obj.NumOfRoutes),
+		NssaTranslatorState: nssaTranslatorState,
 	}
 }
 
@@ -134,6 +164,9 @@ func convertFromRPCFmtOspfv2Global(config *ospfv2d.Ospfv2Global) (*objects.Ospfv
 		AdminState:         adminState,
 		ASBdrRtrStatus:     config.ASBdrRtrStatus,
 		ReferenceBandwidth: uint32(config.ReferenceBandwidth),
+		GracefulRestart:    config.GracefulRestart,
+		GracePeriod:        uint16(config.GracePeriod),
+		GrHelper:           config.GrHelper,
 	}, nil
 }
 
@@ -193,6 +226,76 @@ func convertFromRPCFmtOspfv2Intf(config *ospfv2d.Ospfv2Intf) (*objects.Ospfv2Int
 		HelloInterval:    uint16(config.HelloInterval),
 		RtrDeadInterval:  uint32(config.RtrDeadInterval),
 		MetricValue:      uint16(config.MetricValue),
+		BfdEnable:        config.BfdEnable,
+	}, nil
+}
+
+func convertLifetimeToTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, str)
+}
+
+// A simple password has no use for the crypto algorithm, so it may be left
+// empty. The key is rejected later if the area uses cryptographic
+// authentication.
+func convertFromRPCFmtCryptoAlgorithm(str string) (uint8, error) {
+	switch strings.ToLower(str) {
+	case "":
+		return objects.CRYPTO_ALGO_NONE, nil
+	case objects.CRYPTO_ALGO_MD5_STR:
+		return objects.CRYPTO_ALGO_MD5, nil
+	case objects.CRYPTO_ALGO_HMAC_SHA1_STR:
+		return objects.CRYPTO_ALGO_HMAC_SHA1, nil
+	case objects.CRYPTO_ALGO_HMAC_SHA256_STR:
+		return objects.CRYPTO_ALGO_HMAC_SHA256, nil
+	case objects.CRYPTO_ALGO_HMAC_SHA384_STR:
+		return objects.CRYPTO_ALGO_HMAC_SHA384, nil
+	case objects.CRYPTO_ALGO_HMAC_SHA512_STR:
+		return objects.CRYPTO_ALGO_HMAC_SHA512, nil
+	}
+	return 0, errors.New("Invalid Crypto Algorithm")
+}
+
+func convertFromRPCFmtOspfv2IntfAuthKey(config *ospfv2d.Ospfv2IntfAuthKey) (*objects.Ospfv2IntfAuthKey, error) {
+	ipAddr, err := convertDotNotationToUint32(config.IpAddress)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid IpAddress", err))
+	}
+	if config.KeyId < 0 || config.KeyId > 255 {
+		return nil, errors.New("Invalid KeyId")
+	}
+	cryptoAlgo, err := convertFromRPCFmtCryptoAlgorithm(config.CryptoAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	sendStart, err := convertLifetimeToTime(config.SendLifetimeStart)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid SendLifetimeStart", err))
+	}
+	sendEnd, err := convertLifetimeToTime(config.SendLifetimeEnd)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid SendLifetimeEnd", err))
+	}
+	acceptStart, err := convertLifetimeToTime(config.AcceptLifetimeStart)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid AcceptLifetimeStart", err))
+	}
+	acceptEnd, err := convertLifetimeToTime(config.AcceptLifetimeEnd)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid AcceptLifetimeEnd", err))
+	}
+	return &objects.Ospfv2IntfAuthKey{
+		IpAddress:           ipAddr,
+		AddressLessIfIdx:    uint32(config.AddressLessIfIdx),
+		KeyId:               uint8(config.KeyId),
+		Key:                 config.Key,
+		CryptoAlgorithm:     cryptoAlgo,
+		SendLifetimeStart:   sendStart,
+		SendLifetimeEnd:     sendEnd,
+		AcceptLifetimeStart: acceptStart,
+		AcceptLifetimeEnd:   acceptEnd,
 	}, nil
 }
 
@@ -242,6 +345,220 @@ func convertToRPCFmtOspfv2IntfState(obj *objects.Ospfv2IntfState) *ospfv2d.Ospfv
 	}
 }
 
+func convertFromRPCFmtOspfv2VirtualLink(config *ospfv2d.Ospfv2VirtualLink) (*objects.Ospfv2VirtualLink, error) {
+	transitAreaId, err := convertDotNotationToUint32(config.TransitAreaId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid TransitAreaId", err))
+	}
+	nbrRouterId, err := convertDotNotationToUint32(config.NbrRouterId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid NbrRouterId", err))
+	}
+	var adminState bool
+	switch strings.ToLower(config.AdminState) {
+	case objects.INTF_ADMIN_STATE_UP_STR:
+		adminState = objects.INTF_ADMIN_STATE_UP
+	case objects.INTF_ADMIN_STATE_DOWN_STR:
+		adminState = objects.INTF_ADMIN_STATE_DOWN
+	default:
+		return nil, errors.New("Invalid AdminState")
+	}
+	return &objects.Ospfv2VirtualLink{
+		TransitAreaId:   transitAreaId,
+		NbrRouterId:     nbrRouterId,
+		AdminState:      adminState,
+		TransitDelay:    uint16(config.TransitDelay),
+		RetransInterval: uint16(config.RetransInterval),
+		HelloInterval:   uint16(config.HelloInterval),
+		RtrDeadInterval: uint32(config.RtrDeadInterval),
+	}, nil
+}
+
+func convertFromRPCFmtOspfv2VirtualLinkAuthKey(config *ospfv2d.Ospfv2VirtualLinkAuthKey) (*objects.Ospfv2VirtualLinkAuthKey, error) {
+	transitAreaId, err := convertDotNotationToUint32(config.TransitAreaId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid TransitAreaId", err))
+	}
+	nbrRouterId, err := convertDotNotationToUint32(config.NbrRouterId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid NbrRouterId", err))
+	}
+	if config.KeyId < 0 || config.KeyId > 255 {
+		return nil, errors.New("Invalid KeyId")
+	}
+	cryptoAlgo, err := convertFromRPCFmtCryptoAlgorithm(config.CryptoAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	sendStart, err := convertLifetimeToTime(config.SendLifetimeStart)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid SendLifetimeStart", err))
+	}
+	sendEnd, err := convertLifetimeToTime(config.SendLifetimeEnd)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid SendLifetimeEnd", err))
+	}
+	acceptStart, err := convertLifetimeToTime(config.AcceptLifetimeStart)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid AcceptLifetimeStart", err))
+	}
+	acceptEnd, err := convertLifetimeToTime(config.AcceptLifetimeEnd)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid AcceptLifetimeEnd", err))
+	}
+	return &objects.Ospfv2VirtualLinkAuthKey{
+		TransitAreaId:       transitAreaId,
+		NbrRouterId:         nbrRouterId,
+		KeyId:               uint8(config.KeyId),
+		Key:                 config.Key,
+		CryptoAlgorithm:     cryptoAlgo,
+		SendLifetimeStart:   sendStart,
+		SendLifetimeEnd:     sendEnd,
+		AcceptLifetimeStart: acceptStart,
+		AcceptLifetimeEnd:   acceptEnd,
+	}, nil
+}
+
+func convertToRPCFmtOspfv2VirtualLinkState(obj *objects.Ospfv2VirtualLinkState) *ospfv2d.Ospfv2VirtualLinkState {
+	var state string
+	switch obj.State {
+	case objects.INTF_FSM_STATE_DOWN:
+		state = strings.ToUpper(objects.INTF_FSM_STATE_DOWN_STR)
+	case objects.INTF_FSM_STATE_P2P:
+		state = strings.ToUpper(objects.INTF_FSM_STATE_P2P_STR)
+	}
+	var nbrState string
+	switch obj.NbrState {
+	case objects.NBR_STATE_ONE_WAY:
+		nbrState = strings.ToUpper(objects.NBR_STATE_ONE_WAY_STR)
+	case objects.NBR_STATE_TWO_WAY:
+		nbrState = strings.ToUpper(objects.NBR_STATE_TWO_WAY_STR)
+	case objects.NBR_STATE_INIT:
+		nbrState = strings.ToUpper(objects.NBR_STATE_INIT_STR)
+	case objects.NBR_STATE_EXSTART:
+		nbrState = strings.ToUpper(objects.NBR_STATE_EXSTART_STR)
+	case objects.NBR_STATE_EXCHANGE:
+		nbrState = strings.ToUpper(objects.NBR_STATE_EXCHANGE_STR)
+	case objects.NBR_STATE_LOADING:
+		nbrState = strings.ToUpper(objects.NBR_STATE_LOADING_STR)
+	case objects.NBR_STATE_ATTEMPT:
+		nbrState = strings.ToUpper(objects.NBR_STATE_ATTEMPT_STR)
+	case objects.NBR_STATE_DOWN:
+		nbrState = strings.ToUpper(objects.NBR_STATE_DOWN_STR)
+	case objects.NBR_STATE_FULL:
+		nbrState = strings.ToUpper(objects.NBR_STATE_FULL_STR)
+	}
+	return &ospfv2d.Ospfv2VirtualLinkState{
+		TransitAreaId:     convertUint32ToDotNotation(obj.TransitAreaId),
+		NbrRouterId:       convertUint32ToDotNotation(obj.NbrRouterId),
+		State:             state,
+		IpAddress:         convertUint32ToDotNotation(obj.IpAddress),
+		NbrIpAddress:      convertUint32ToDotNotation(obj.NbrIpAddress),
+		NbrState:          nbrState,
+		Cost:              int32(obj.Cost),
+		NumOfStateChange:  int32(obj.NumOfStateChange),
+		TimeOfStateChange: obj.TimeOfStateChange,
+	}
+}
+
+// Converts "a.b.c.d/len" to address, netmask and prefix length,
+// host bits must be zero
+func convertCIDRToUint32(str string) (uint32, uint32, uint8, error) {
+	ip, ipNet, err := net.ParseCIDR(str)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if ip.To4() == nil {
+		return 0, 0, 0, errors.New("Not an IPv4 prefix")
+	}
+	if !ip.Equal(ipNet.IP) {
+		return 0, 0, 0, errors.New("Host bits are set in prefix")
+	}
+	ipAddr, _ := convertDotNotationToUint32(ipNet.IP.String())
+	netmask, _ := convertDotNotationToUint32(net.IP(ipNet.Mask).String())
+	prefixLen, _ := ipNet.Mask.Size()
+	return ipAddr, netmask, uint8(prefixLen), nil
+}
+
+func convertFromRPCFmtOspfv2AreaRange(config *ospfv2d.Ospfv2AreaRange) (*objects.Ospfv2AreaRange, error) {
+	areaId, err := convertDotNotationToUint32(config.AreaId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid AreaId", err))
+	}
+	ipPrefix, netmask, _, err := convertCIDRToUint32(config.IpPrefix)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid IpPrefix", err))
+	}
+	if config.Cost < 0 || config.Cost > 0xffffff {
+		return nil, errors.New("Invalid Cost")
+	}
+	return &objects.Ospfv2AreaRange{
+		AreaId:    areaId,
+		IpPrefix:  ipPrefix,
+		Netmask:   netmask,
+		Advertise: config.Advertise,
+		Cost:      uint32(config.Cost),
+	}, nil
+}
+
+// MaskLengthRange is either "exact" or "min-max"
+func convertMaskLengthRange(str string, prefixLen uint8) (uint8, uint8, error) {
+	if strings.ToLower(str) == "exact" || str == "" {
+		return prefixLen, prefixLen, nil
+	}
+	lenList := strings.Split(str, "-")
+	if len(lenList) != 2 {
+		return 0, 0, errors.New("Invalid format")
+	}
+	minLen, err := strconv.Atoi(strings.TrimSpace(lenList[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	maxLen, err := strconv.Atoi(strings.TrimSpace(lenList[1]))
+	if err != nil {
+		return 0, 0, err
+	}
+	if minLen < int(prefixLen) || minLen > maxLen || maxLen > 32 {
+		return 0, 0, errors.New("Mask lengths out of range")
+	}
+	return uint8(minLen), uint8(maxLen), nil
+}
+
+func convertFromRPCFmtOspfv2PrefixList(config *ospfv2d.Ospfv2PrefixList) (*objects.Ospfv2PrefixList, error) {
+	if config.Name == "" {
+		return nil, errors.New("Invalid Name")
+	}
+	if config.Seq < 0 {
+		return nil, errors.New("Invalid Seq")
+	}
+	var action uint8
+	switch strings.ToLower(config.Action) {
+	case objects.PREFIX_LIST_ACTION_PERMIT_STR:
+		action = objects.PREFIX_LIST_ACTION_PERMIT
+	case objects.PREFIX_LIST_ACTION_DENY_STR:
+		action = objects.PREFIX_LIST_ACTION_DENY
+	default:
+		return nil, errors.New("Invalid Action")
+	}
+	ipPrefix, netmask, prefixLen, err := convertCIDRToUint32(config.IpPrefix)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid IpPrefix", err))
+	}
+	minLen, maxLen, err := convertMaskLengthRange(config.MaskLengthRange, prefixLen)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid MaskLengthRange", err))
+	}
+	return &objects.Ospfv2PrefixList{
+		Name:     config.Name,
+		Seq:      uint32(config.Seq),
+		Action:   action,
+		IpPrefix: ipPrefix,
+		Netmask:  netmask,
+		MinLen:   minLen,
+		MaxLen:   maxLen,
+	}, nil
+}
+
 func convertFromRPCFmtLSType(LSType string) (uint8, error) {
 	var lsType uint8
 
@@ -256,6 +573,8 @@ func convertFromRPCFmtLSType(LSType string) (uint8, error) {
 		lsType = objects.SUMMARY4_LSA
 	case objects.ASExternal_LSA_STR:
 		lsType = objects.ASExternal_LSA
+	case objects.NSSA_LSA_STR:
+		lsType = objects.NSSA_LSA
 	default:
 		return 0, errors.New("Invalid LSA Type")
 	}
@@ -275,6 +594,8 @@ func convertToRPCFmtOspfv2LsdbState(obj *objects.Ospfv2LsdbState) *ospfv2d.Ospfv
 		lsType = strings.ToUpper(objects.SUMMARY4_LSA_STR)
 	case objects.ASExternal_LSA:
 		lsType = strings.ToUpper(objects.ASExternal_LSA_STR)
+	case objects.NSSA_LSA:
+		lsType = strings.ToUpper(objects.NSSA_LSA_STR)
 	}
 	lsId := convertUint32ToDotNotation(obj.LSId)
 	areaId := convertUint32ToDotNotation(obj.AreaId)
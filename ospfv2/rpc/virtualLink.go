@@ -0,0 +1,121 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"l3/ospfv2/api"
+	"models/objects"
+	"ospfv2d"
+)
+
+func (rpcHdl *rpcServiceHandler) restoreOspfv2VirtualLinkConfFromDB() (bool, error) {
+	rpcHdl.logger.Info("Restoring Ospfv2 Virtual Link Config From DB")
+	var ospfv2VirtualLink objects.Ospfv2VirtualLink
+
+	vLinkList, err := rpcHdl.dbHdl.GetAllObjFromDb(ospfv2VirtualLink)
+	if err != nil {
+		return false, errors.New("Failed to retireve Ospfv2VirtualLink object info from DB")
+	}
+	for idx := 0; idx < len(vLinkList); idx++ {
+		dbObj := vLinkList[idx].(objects.Ospfv2VirtualLink)
+		obj := new(ospfv2d.Ospfv2VirtualLink)
+		objects.Convertospfv2dOspfv2VirtualLinkObjToThrift(&dbObj, obj)
+		convObj, err := convertFromRPCFmtOspfv2VirtualLink(obj)
+		if err != nil {
+			return false, err
+		}
+		ok, err := api.CreateOspfv2VirtualLink(convObj)
+		if !ok {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+func (rpcHdl *rpcServiceHandler) CreateOspfv2VirtualLink(config *ospfv2d.Ospfv2VirtualLink) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2VirtualLink(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.CreateOspfv2VirtualLink(cfg)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) UpdateOspfv2VirtualLink(oldConfig, newConfig *ospfv2d.Ospfv2VirtualLink, attrset []bool, op []*ospfv2d.PatchOpInfo) (bool, error) {
+	convOldCfg, err := convertFromRPCFmtOspfv2VirtualLink(oldConfig)
+	if err != nil {
+		return false, err
+	}
+	convNewCfg, err := convertFromRPCFmtOspfv2VirtualLink(newConfig)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.UpdateOspfv2VirtualLink(convOldCfg, convNewCfg, attrset)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) DeleteOspfv2VirtualLink(config *ospfv2d.Ospfv2VirtualLink) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2VirtualLink(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.DeleteOspfv2VirtualLink(cfg)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) GetOspfv2VirtualLinkState(TransitAreaId string, NbrRouterId string) (*ospfv2d.Ospfv2VirtualLinkState, error) {
+	var convObj *ospfv2d.Ospfv2VirtualLinkState
+	transitAreaId, err := convertDotNotationToUint32(TransitAreaId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid TransitAreaId", err))
+	}
+	nbrRouterId, err := convertDotNotationToUint32(NbrRouterId)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid NbrRouterId", err))
+	}
+	obj, err := api.GetOspfv2VirtualLinkState(transitAreaId, nbrRouterId)
+	if err == nil {
+		convObj = convertToRPCFmtOspfv2VirtualLinkState(obj)
+	}
+	return convObj, err
+}
+
+func (rpcHdl *rpcServiceHandler) GetBulkOspfv2VirtualLinkState(fromIdx, count ospfv2d.Int) (*ospfv2d.Ospfv2VirtualLinkStateGetInfo, error) {
+	var getBulkInfo ospfv2d.Ospfv2VirtualLinkStateGetInfo
+	info, err := api.GetBulkOspfv2VirtualLinkState(int(fromIdx), int(count))
+	if info == nil || err != nil {
+		return &getBulkInfo, err
+	}
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = ospfv2d.Int(info.EndIdx)
+	getBulkInfo.More = info.More
+	getBulkInfo.Count = ospfv2d.Int(len(info.List))
+	for idx := 0; idx < len(info.List); idx++ {
+		getBulkInfo.Ospfv2VirtualLinkStateList = append(getBulkInfo.Ospfv2VirtualLinkStateList,
+			convertToRPCFmtOspfv2VirtualLinkState(info.List[idx]))
+	}
+	return &getBulkInfo, err
+}
@@ -0,0 +1,86 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package rpc
+
+import (
+	"errors"
+	"l3/ospfv2/api"
+	"models/objects"
+	"ospfv2d"
+)
+
+func (rpcHdl *rpcServiceHandler) restoreOspfv2VirtualLinkAuthKeyConfFromDB() (bool, error) {
+	rpcHdl.logger.Info("Restoring Ospfv2 Virtual Link Auth Key Config From DB")
+	var ospfv2VirtualLinkAuthKey objects.Ospfv2VirtualLinkAuthKey
+
+	authKeyList, err := rpcHdl.dbHdl.GetAllObjFromDb(ospfv2VirtualLinkAuthKey)
+	if err != nil {
+		return false, errors.New("Failed to retireve Ospfv2VirtualLinkAuthKey object info from DB")
+	}
+	for idx := 0; idx < len(authKeyList); idx++ {
+		dbObj := authKeyList[idx].(objects.Ospfv2VirtualLinkAuthKey)
+		obj := new(ospfv2d.Ospfv2VirtualLinkAuthKey)
+		objects.Convertospfv2dOspfv2VirtualLinkAuthKeyObjToThrift(&dbObj, obj)
+		convObj, err := convertFromRPCFmtOspfv2VirtualLinkAuthKey(obj)
+		if err != nil {
+			return false, err
+		}
+		ok, err := api.CreateOspfv2VirtualLinkAuthKey(convObj)
+		if !ok {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+func (rpcHdl *rpcServiceHandler) CreateOspfv2VirtualLinkAuthKey(config *ospfv2d.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2VirtualLinkAuthKey(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.CreateOspfv2VirtualLinkAuthKey(cfg)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) UpdateOspfv2VirtualLinkAuthKey(oldConfig, newConfig *ospfv2d.Ospfv2VirtualLinkAuthKey, attrset []bool, op []*ospfv2d.PatchOpInfo) (bool, error) {
+	convOldCfg, err := convertFromRPCFmtOspfv2VirtualLinkAuthKey(oldConfig)
+	if err != nil {
+		return false, err
+	}
+	convNewCfg, err := convertFromRPCFmtOspfv2VirtualLinkAuthKey(newConfig)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.UpdateOspfv2VirtualLinkAuthKey(convOldCfg, convNewCfg, attrset)
+	return rv, err
+}
+
+func (rpcHdl *rpcServiceHandler) DeleteOspfv2VirtualLinkAuthKey(config *ospfv2d.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	cfg, err := convertFromRPCFmtOspfv2VirtualLinkAuthKey(config)
+	if err != nil {
+		return false, err
+	}
+	rv, err := api.DeleteOspfv2VirtualLinkAuthKey(cfg)
+	return rv, err
+}
@@ -37,6 +37,7 @@ type LsdbSliceStruct struct {
 
 type GetBulkStruct struct {
 	IntfConfSlice        []IntfConfKey
+	VirtualLinkConfSlice []VirtualLinkConfKey
 	NbrConfSlice         []NbrConfKey
 	AreaConfSlice        []uint32
 	LsdbSlice            []LsdbSliceStruct
@@ -241,10 +242,15 @@ type SPFToLsdbChStruct struct {
 	DoneSPF chan bool
 }
 
+type SPFToServerChStruct struct {
+	VirtualLinkPathChangeCh chan bool
+}
+
 type ServerToLsdbChStruct struct {
 	RefreshLsdbSliceCh    chan bool
 	RouteInfoDataUpdateCh chan RouteInfoDataUpdateMsg
 	InitAreaLsdbCh        chan uint32
+	GracefulRestartExitCh chan uint8
 }
 
 type LsdbToServerChStruct struct {
@@ -274,6 +280,7 @@ type MessagingChStruct struct {
 	LsdbToFloodChData      LsdbToFloodChStruct
 	LsdbToSPFChData        LsdbToSPFChStruct
 	SPFToLsdbChData        SPFToLsdbChStruct
+	SPFToServerChData      SPFToServerChStruct
 	ServerToLsdbChData     ServerToLsdbChStruct
 	ServerToDBClntChData   ServerToDBClntChStruct
 	LsdbToServerChData     LsdbToServerChStruct
@@ -62,6 +62,7 @@ const (
 	OSPF_PROTO_ID        uint8 = 89
 	OSPF_VERSION_2       uint8 = 2
 	OSPF_NO_OF_LSA_FIELD       = 4
+	VIRTUAL_LINK_TTL     uint8 = 64
 )
 
 const (
@@ -87,6 +88,22 @@ const (
 	AllDRouterType   DstIPType = 3
 )
 
+const (
+	// Time an NSSA translator which lost the election keeps translating
+	NSSA_TRANSLATOR_STABILITY_INTERVAL time.Duration = 40 * time.Second
+)
+
+const (
+	// Planned restart state kept in the params dir across the restart
+	GR_STATE_FILE string = "ospfv2GracefulRestart.json"
+	// Opaque type and TLV types of the grace LSA (RFC 3623 Appendix A)
+	GRACE_LSA_OPAQUE_TYPE        uint8  = 3
+	GRACE_LSA_TLV_GRACE_PERIOD   uint16 = 1
+	GRACE_LSA_TLV_RESTART_REASON uint16 = 2
+	GRACE_LSA_TLV_IP_INTF_ADDR   uint16 = 3
+	GR_REASON_SW_RESTART         uint8  = 1
+)
+
 const (
 	EOption  = 0x02
 	MCOption = 0x04
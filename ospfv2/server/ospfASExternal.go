@@ -41,9 +41,33 @@ func (server *OSPFV2Server) HandleASExternalLsa(areaId uint32) {
 		server.logger.Err("Unable to find Area Lsdb entry")
 		return
 	}
+	server.calcExternalRoutes(areaId, lsDbEnt.ASExternalLsaMap)
+}
+
+/*
+ RFC 3101 2.5
+ Type-7 LSAs are used for the routing table calculation of the NSSA in
+ the same way as AS External LSAs.
+*/
+func (server *OSPFV2Server) HandleNSSALsa(areaId uint32) {
+	isNssa, _ := server.isNssaArea(areaId)
+	if !isNssa {
+		return
+	}
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err("Unable to find Area Lsdb entry")
+		return
+	}
+	server.calcExternalRoutes(areaId, lsDbEnt.NSSALsaMap)
+}
 
-	for lsaKey, lsaEnt := range lsDbEnt.ASExternalLsaMap {
-		server.logger.Info("AS External LSAKey:", lsaKey, "lsaENt:", lsaEnt)
+func (server *OSPFV2Server) calcExternalRoutes(areaId uint32, lsaMap map[LsaKey]ASExternalLsa) {
+	for lsaKey, lsaEnt := range lsaMap {
+		server.logger.Info("External LSAKey:", lsaKey, "lsaENt:", lsaEnt)
 		if lsaEnt.Metric == LSInfinity ||
 			lsaEnt.LsaMd.LSAge == MAX_AGE {
 			server.logger.Info("Ignoring AS External LSA...")
@@ -152,6 +176,7 @@ func (server *OSPFV2Server) HandleASExternalLsa(areaId uint32) {
 				rEnt.PathType = pathType
 				rEnt.Cost = cost
 				rEnt.Type2Cost = uint16(lsaEnt.Metric)
+				rEnt.ExtRouteTag = lsaEnt.ExtRouteTag
 				//rEnt.LSOrigin = lsaKey
 				rEnt.NumOfPaths = numOfNextHops
 				rEnt.NextHops = make(map[NextHop]bool)
@@ -180,6 +205,7 @@ func (server *OSPFV2Server) HandleASExternalLsa(areaId uint32) {
 			}
 			rEnt.Cost = cost
 			rEnt.Type2Cost = uint16(lsaEnt.Metric)
+			rEnt.ExtRouteTag = lsaEnt.ExtRouteTag
 			//rEnt.LSOrigin = lsaKey
 			rEnt.NumOfPaths = numOfNextHops
 			rEnt.NextHops = make(map[NextHop]bool)
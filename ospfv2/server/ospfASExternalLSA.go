@@ -138,7 +138,7 @@ func (server *OSPFV2Server) generateASExternalLSA(routeInfo RouteInfo) {
 	asLsaEnt.LsaMd.LSAge = 0
 	asLsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
 	asLsaEnt.BitE = true
-	asLsaEnt.ExtRouteTag = 0
+	asLsaEnt.ExtRouteTag = routeInfo.ExtRouteTag
 	asLsaEnt.FwdAddr = 0
 	asLsaEnt.Metric = routeInfo.Metric
 	asLsaEnt.Netmask = routeInfo.Netmask
@@ -262,7 +262,7 @@ func (server *OSPFV2Server) GenerateAllASExternalLSA(areaId uint32) {
 		lsaEnt.LsaMd.LSSequenceNum = int(InitialSequenceNum)
 		lsaEnt.LsaMd.Options = EOption
 		lsaEnt.BitE = true
-		lsaEnt.ExtRouteTag = 0
+		lsaEnt.ExtRouteTag = route.ExtRouteTag
 		lsaEnt.FwdAddr = 0
 		lsaEnt.Metric = route.Metric
 		lsaEnt.Netmask = route.Netmask
@@ -321,7 +321,7 @@ func (server *OSPFV2Server) reGenerateASExternalLSAForGivenArea(routeInfo RouteI
 	lsaEnt.LsaMd.LSAge = 0
 	lsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
 	lsaEnt.BitE = true
-	lsaEnt.ExtRouteTag = 0
+	lsaEnt.ExtRouteTag = routeInfo.ExtRouteTag
 	lsaEnt.FwdAddr = 0
 	lsaEnt.Metric = routeInfo.Metric
 	lsaEnt.Netmask = routeInfo.Netmask
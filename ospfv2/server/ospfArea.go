@@ -29,9 +29,15 @@ import (
 )
 
 type AreaConf struct {
-	AdminState     bool
-	AuthType       uint8
-	ImportASExtern bool
+	AdminState         bool
+	AuthType           uint8
+	ImportASExtern     bool
+	Nssa               bool
+	NoSummary          bool
+	StubDefaultCost    uint32
+	NssaTranslatorRole uint8
+	ImportFilterList   string
+	ExportFilterList   string
 	//NumSpfRuns       uint32
 	//NumBdrRtr        uint32
 	//NumAsBdrRtr      uint32
@@ -50,7 +56,13 @@ func genOspfv2AreaUpdateMask(attrset []bool) uint32 {
 
 	if attrset == nil {
 		mask = objects.OSPFV2_AREA_UPDATE_AUTH_TYPE |
-			objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN
+			objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN |
+			objects.OSPFV2_AREA_UPDATE_NSSA |
+			objects.OSPFV2_AREA_UPDATE_NO_SUMMARY |
+			objects.OSPFV2_AREA_UPDATE_STUB_DEFAULT_COST |
+			objects.OSPFV2_AREA_UPDATE_NSSA_TRANSLATOR_ROLE |
+			objects.OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST |
+			objects.OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST
 	} else {
 		for idx, val := range attrset {
 			if val == true {
@@ -63,6 +75,18 @@ func genOspfv2AreaUpdateMask(attrset []bool) uint32 {
 					mask |= objects.OSPFV2_AREA_UPDATE_AUTH_TYPE
 				case 3:
 					mask |= objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN
+				case 4:
+					mask |= objects.OSPFV2_AREA_UPDATE_NSSA
+				case 5:
+					mask |= objects.OSPFV2_AREA_UPDATE_NO_SUMMARY
+				case 6:
+					mask |= objects.OSPFV2_AREA_UPDATE_STUB_DEFAULT_COST
+				case 7:
+					mask |= objects.OSPFV2_AREA_UPDATE_NSSA_TRANSLATOR_ROLE
+				case 8:
+					mask |= objects.OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST
+				case 9:
+					mask |= objects.OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST
 				}
 			}
 		}
@@ -87,6 +111,19 @@ func (server *OSPFV2Server) isAreaBDR() bool {
 
 }
 
+func validateAreaType(areaId uint32, importASExtern, nssa bool) error {
+	if nssa == false {
+		return nil
+	}
+	if areaId == 0 {
+		return errors.New("Backbone area cannot be configured as NSSA")
+	}
+	if importASExtern == true {
+		return errors.New("NSSA cannot import AS External LSAs")
+	}
+	return nil
+}
+
 func (server *OSPFV2Server) updateArea(newCfg, oldCfg *objects.Ospfv2Area, attrset []bool) (bool, error) {
 	server.logger.Info("Area configuration update")
 	oldAreaEnt, exist := server.AreaConfMap[newCfg.AreaId]
@@ -94,6 +131,42 @@ func (server *OSPFV2Server) updateArea(newCfg, oldCfg *objects.Ospfv2Area, attrs
 		server.logger.Err("Cannot update, area doesnot exist")
 		return false, errors.New("Cannot update, area doesnot exist")
 	}
+	mask := genOspfv2AreaUpdateMask(attrset)
+	filterMask := uint32(objects.OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST |
+		objects.OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST)
+	if mask&^filterMask == 0 {
+		// Filter lists only affect the summary LSAs we originate,
+		// so there is no need to restart the area
+		server.updateAreaFilterList(newCfg, mask)
+		server.sendMsgToRegenerateSummaryLsa()
+		return true, nil
+	}
+	importASExtern := oldAreaEnt.ImportASExtern
+	if mask&objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN == objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN {
+		importASExtern = newCfg.ImportASExtern
+	}
+	nssa := oldAreaEnt.Nssa
+	if mask&objects.OSPFV2_AREA_UPDATE_NSSA == objects.OSPFV2_AREA_UPDATE_NSSA {
+		nssa = newCfg.Nssa
+	}
+	err := validateAreaType(newCfg.AreaId, importASExtern, nssa)
+	if err != nil {
+		server.logger.Err("Cannot update area:", err)
+		return false, err
+	}
+	if (importASExtern == false || nssa == true) &&
+		server.isVirtualLinkTransitArea(newCfg.AreaId) {
+		server.logger.Err("Cannot update area: virtual links are configured through this area")
+		return false, errors.New("Cannot update area: virtual links are configured through this area")
+	}
+	if mask&objects.OSPFV2_AREA_UPDATE_AUTH_TYPE == objects.OSPFV2_AREA_UPDATE_AUTH_TYPE &&
+		newCfg.AuthType != oldAreaEnt.AuthType {
+		err := server.validateAreaIntfAuthType(newCfg.AreaId, newCfg.AuthType)
+		if err != nil {
+			server.logger.Err("Cannot update area:", err)
+			return false, err
+		}
+	}
 
 	if oldAreaEnt.AdminState == true &&
 		server.globalData.AdminState == true {
@@ -105,7 +178,6 @@ func (server *OSPFV2Server) updateArea(newCfg, oldCfg *objects.Ospfv2Area, attrs
 
 	oldAreaEnt, _ = server.AreaConfMap[newCfg.AreaId]
 	newAreaEnt := oldAreaEnt
-	mask := genOspfv2AreaUpdateMask(attrset)
 	if mask&objects.OSPFV2_AREA_UPDATE_ADMIN_STATE == objects.OSPFV2_AREA_UPDATE_ADMIN_STATE {
 		newAreaEnt.AdminState = newCfg.AdminState
 	}
@@ -115,8 +187,22 @@ func (server *OSPFV2Server) updateArea(newCfg, oldCfg *objects.Ospfv2Area, attrs
 	if mask&objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN == objects.OSPFV2_AREA_UPDATE_IMPORT_AS_EXTERN {
 		newAreaEnt.ImportASExtern = newCfg.ImportASExtern
 	}
+	if mask&objects.OSPFV2_AREA_UPDATE_NSSA == objects.OSPFV2_AREA_UPDATE_NSSA {
+		newAreaEnt.Nssa = newCfg.Nssa
+	}
+	if mask&objects.OSPFV2_AREA_UPDATE_NO_SUMMARY == objects.OSPFV2_AREA_UPDATE_NO_SUMMARY {
+		newAreaEnt.NoSummary = newCfg.NoSummary
+	}
+	if mask&objects.OSPFV2_AREA_UPDATE_STUB_DEFAULT_COST == objects.OSPFV2_AREA_UPDATE_STUB_DEFAULT_COST {
+		newAreaEnt.StubDefaultCost = newCfg.StubDefaultCost
+	}
+	if mask&objects.OSPFV2_AREA_UPDATE_NSSA_TRANSLATOR_ROLE == objects.OSPFV2_AREA_UPDATE_NSSA_TRANSLATOR_ROLE {
+		newAreaEnt.NssaTranslatorRole = newCfg.NssaTranslatorRole
+	}
 
 	server.AreaConfMap[newCfg.AreaId] = newAreaEnt
+	server.updateAreaFilterList(newCfg, mask)
+	server.updateAreaIntfAuthType(newCfg.AreaId)
 	server.globalData.AreaBdrRtrStatus = server.isAreaBDR()
 	if newAreaEnt.AdminState == true &&
 		server.globalData.AdminState == true {
@@ -128,6 +214,17 @@ func (server *OSPFV2Server) updateArea(newCfg, oldCfg *objects.Ospfv2Area, attrs
 	return true, nil
 }
 
+func (server *OSPFV2Server) updateAreaFilterList(newCfg *objects.Ospfv2Area, mask uint32) {
+	areaEnt, _ := server.AreaConfMap[newCfg.AreaId]
+	if mask&objects.OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST == objects.OSPFV2_AREA_UPDATE_IMPORT_FILTER_LIST {
+		areaEnt.ImportFilterList = newCfg.ImportFilterList
+	}
+	if mask&objects.OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST == objects.OSPFV2_AREA_UPDATE_EXPORT_FILTER_LIST {
+		areaEnt.ExportFilterList = newCfg.ExportFilterList
+	}
+	server.AreaConfMap[newCfg.AreaId] = areaEnt
+}
+
 func (server *OSPFV2Server) createArea(cfg *objects.Ospfv2Area) (bool, error) {
 	server.logger.Info("Area configuration create")
 	areaEnt, exist := server.AreaConfMap[cfg.AreaId]
@@ -135,13 +232,19 @@ func (server *OSPFV2Server) createArea(cfg *objects.Ospfv2Area) (bool, error) {
 		server.logger.Err("Unable to Create Area already exist")
 		return false, errors.New("Unable to create area already exist")
 	}
-	//TODO: Only AuthType none is supported
-	if cfg.AuthType != objects.AUTH_TYPE_NONE {
-		server.logger.Err("Only AuthType None is supported")
-		return false, errors.New("AuthType not supported")
+	err := validateAreaType(cfg.AreaId, cfg.ImportASExtern, cfg.Nssa)
+	if err != nil {
+		server.logger.Err("Unable to create area:", err)
+		return false, err
 	}
 	areaEnt.AuthType = cfg.AuthType
 	areaEnt.ImportASExtern = cfg.ImportASExtern
+	areaEnt.Nssa = cfg.Nssa
+	areaEnt.NoSummary = cfg.NoSummary
+	areaEnt.StubDefaultCost = cfg.StubDefaultCost
+	areaEnt.NssaTranslatorRole = cfg.NssaTranslatorRole
+	areaEnt.ImportFilterList = cfg.ImportFilterList
+	areaEnt.ExportFilterList = cfg.ExportFilterList
 	areaEnt.IntfMap = make(map[IntfConfKey]bool)
 	areaEnt.AdminState = cfg.AdminState
 	server.AreaConfMap[cfg.AreaId] = areaEnt
@@ -173,6 +276,14 @@ func (server *OSPFV2Server) deleteArea(cfg *objects.Ospfv2Area) (bool, error) {
 		server.logger.Err("Unable to delete Area as there are interface configured in this area")
 		return false, errors.New("Unable to delete Area as there are interface configured in this area")
 	}
+	if server.isVirtualLinkTransitArea(cfg.AreaId) {
+		server.logger.Err("Unable to delete Area as there are virtual links configured through this area")
+		return false, errors.New("Unable to delete Area as there are virtual links configured through this area")
+	}
+	if server.isAreaRangeConfigured(cfg.AreaId) {
+		server.logger.Err("Unable to delete Area as there are address ranges configured for this area")
+		return false, errors.New("Unable to delete Area as there are address ranges configured for this area")
+	}
 	if areaEnt.AdminState == true {
 		//This will cause Nbrs to be deleted from NbrFSM
 		//server.StopAreaIntfFSM(cfg.AreaId)
@@ -204,10 +315,12 @@ func (server *OSPFV2Server) getAreaState(areaId uint32) (*objects.Ospfv2AreaStat
 		retObj.NumOfSummary3LSA = uint32(len(lsdbEnt.Summary3LsaMap))
 		retObj.NumOfSummary4LSA = uint32(len(lsdbEnt.Summary4LsaMap))
 		retObj.NumOfASExternalLSA = uint32(len(lsdbEnt.ASExternalLsaMap))
+		retObj.NumOfNSSALSA = uint32(len(lsdbEnt.NSSALsaMap))
 	}
 	retObj.NumOfLSA = retObj.NumOfRouterLSA + retObj.NumOfNetworkLSA +
 		retObj.NumOfSummary3LSA + retObj.NumOfSummary4LSA +
-		retObj.NumOfASExternalLSA
+		retObj.NumOfASExternalLSA + retObj.NumOfNSSALSA
+	retObj.NssaTranslatorState = server.LsdbData.NssaTranslatorMap[lsdbKey].State
 	retObj.NumOfIntfs = uint32(len(areaEnt.IntfMap))
 	for intfKey, _ := range areaEnt.IntfMap {
 		intfEnt, exist := server.IntfConfMap[intfKey]
@@ -252,10 +365,12 @@ func (server *OSPFV2Server) getBulkAreaState(fromIdx, cnt int) (*objects.Ospfv2A
 			obj.NumOfSummary3LSA = uint32(len(lsdbEnt.Summary3LsaMap))
 			obj.NumOfSummary4LSA = uint32(len(lsdbEnt.Summary4LsaMap))
 			obj.NumOfASExternalLSA = uint32(len(lsdbEnt.ASExternalLsaMap))
+			obj.NumOfNSSALSA = uint32(len(lsdbEnt.NSSALsaMap))
 		}
 		obj.NumOfLSA = obj.NumOfRouterLSA + obj.NumOfNetworkLSA +
 			obj.NumOfSummary3LSA + obj.NumOfSummary4LSA +
-			obj.NumOfASExternalLSA
+			obj.NumOfASExternalLSA + obj.NumOfNSSALSA
+		obj.NssaTranslatorState = server.LsdbData.NssaTranslatorMap[lsdbKey].State
 		obj.NumOfIntfs = uint32(len(areaEnt.IntfMap))
 		for intfKey, _ := range areaEnt.IntfMap {
 			intfEnt, exist := server.IntfConfMap[intfKey]
@@ -272,6 +387,8 @@ func (server *OSPFV2Server) getBulkAreaState(fromIdx, cnt int) (*objects.Ospfv2A
 	return &retObj, nil
 }
 
+// Returns true for stub areas and NSSAs, AS External LSAs are not
+// flooded into either of them
 func (server *OSPFV2Server) isStubArea(areaId uint32) (bool, error) {
 	conf, exist := server.AreaConfMap[areaId]
 	if !exist {
@@ -284,6 +401,35 @@ func (server *OSPFV2Server) isStubArea(areaId uint32) (bool, error) {
 	return false, nil
 }
 
+func (server *OSPFV2Server) isNssaArea(areaId uint32) (bool, error) {
+	conf, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return false, errors.New("Area doesnot exist")
+	}
+
+	if conf.ImportASExtern == false &&
+		conf.Nssa == true {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Options advertised in Hello packets and Router LSAs of the given area
+func (server *OSPFV2Server) getAreaOptions(areaId uint32) (uint8, error) {
+	isStub, err := server.isStubArea(areaId)
+	if err != nil {
+		return 0, err
+	}
+	isNssa, _ := server.isNssaArea(areaId)
+	if isNssa {
+		return NPOption, nil
+	}
+	if isStub {
+		return 0, nil
+	}
+	return EOption, nil
+}
+
 func (server *OSPFV2Server) GetListOfIntfKeyInGivenArea(areaId uint32) ([]IntfConfKey, error) {
 	var intfConKeyList []IntfConfKey
 
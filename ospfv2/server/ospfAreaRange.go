@@ -0,0 +1,329 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"l3/ospfv2/objects"
+	"ribd"
+)
+
+type AreaRangeKey struct {
+	AreaId   uint32
+	IpPrefix uint32
+	Netmask  uint32
+}
+
+type AreaRangeConf struct {
+	Advertise bool
+	Cost      uint32
+}
+
+func getOspfv2AreaRangeUpdateMask(attrset []bool) uint32 {
+	var mask uint32 = 0
+
+	if attrset == nil {
+		mask = objects.OSPFV2_AREA_RANGE_UPDATE_ADVERTISE |
+			objects.OSPFV2_AREA_RANGE_UPDATE_COST
+	} else {
+		for idx, val := range attrset {
+			if true == val {
+				switch idx {
+				case 0:
+					// AreaId
+				case 1:
+					// IpPrefix
+				case 2:
+					mask |= objects.OSPFV2_AREA_RANGE_UPDATE_ADVERTISE
+				case 3:
+					mask |= objects.OSPFV2_AREA_RANGE_UPDATE_COST
+				}
+			}
+		}
+	}
+	return mask
+}
+
+func (server *OSPFV2Server) createAreaRange(cfg *objects.Ospfv2AreaRange) (bool, error) {
+	server.logger.Info("Area Range configuration create")
+	_, exist := server.AreaConfMap[cfg.AreaId]
+	if !exist {
+		server.logger.Err("Unable to create Area Range, area doesnot exist")
+		return false, errors.New("Unable to create Area Range, area doesnot exist")
+	}
+	rangeKey := AreaRangeKey{
+		AreaId:   cfg.AreaId,
+		IpPrefix: cfg.IpPrefix,
+		Netmask:  cfg.Netmask,
+	}
+	_, exist = server.AreaRangeConfMap[rangeKey]
+	if exist {
+		server.logger.Err("Area Range already exist")
+		return false, errors.New("Area Range already exist")
+	}
+	server.AreaRangeConfMap[rangeKey] = AreaRangeConf{
+		Advertise: cfg.Advertise,
+		Cost:      cfg.Cost,
+	}
+	server.sendMsgToRegenerateSummaryLsa()
+	return true, nil
+}
+
+func (server *OSPFV2Server) updateAreaRange(newCfg, oldCfg *objects.Ospfv2AreaRange, attrset []bool) (bool, error) {
+	server.logger.Info("Area Range configuration update")
+	rangeKey := AreaRangeKey{
+		AreaId:   newCfg.AreaId,
+		IpPrefix: newCfg.IpPrefix,
+		Netmask:  newCfg.Netmask,
+	}
+	rangeEnt, exist := server.AreaRangeConfMap[rangeKey]
+	if !exist {
+		server.logger.Err("Area Range doesnot exist")
+		return false, errors.New("Area Range doesnot exist")
+	}
+	mask := getOspfv2AreaRangeUpdateMask(attrset)
+	if mask&objects.OSPFV2_AREA_RANGE_UPDATE_ADVERTISE == objects.OSPFV2_AREA_RANGE_UPDATE_ADVERTISE {
+		rangeEnt.Advertise = newCfg.Advertise
+	}
+	if mask&objects.OSPFV2_AREA_RANGE_UPDATE_COST == objects.OSPFV2_AREA_RANGE_UPDATE_COST {
+		rangeEnt.Cost = newCfg.Cost
+	}
+	server.AreaRangeConfMap[rangeKey] = rangeEnt
+	server.sendMsgToRegenerateSummaryLsa()
+	return true, nil
+}
+
+func (server *OSPFV2Server) deleteAreaRange(cfg *objects.Ospfv2AreaRange) (bool, error) {
+	server.logger.Info("Area Range configuration delete")
+	rangeKey := AreaRangeKey{
+		AreaId:   cfg.AreaId,
+		IpPrefix: cfg.IpPrefix,
+		Netmask:  cfg.Netmask,
+	}
+	_, exist := server.AreaRangeConfMap[rangeKey]
+	if !exist {
+		server.logger.Err("Area Range doesnot exist")
+		return false, errors.New("Area Range doesnot exist")
+	}
+	delete(server.AreaRangeConfMap, rangeKey)
+	server.sendMsgToRegenerateSummaryLsa()
+	return true, nil
+}
+
+func (server *OSPFV2Server) isAreaRangeConfigured(areaId uint32) bool {
+	for rangeKey, _ := range server.AreaRangeConfMap {
+		if rangeKey.AreaId == areaId {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary LSAs are regenerated after every SPF run, generating
+// the router LSA of any active area triggers one
+func (server *OSPFV2Server) sendMsgToRegenerateSummaryLsa() {
+	if server.globalData.AdminState == false ||
+		server.globalData.AreaBdrRtrStatus == false {
+		return
+	}
+	for areaId, areaEnt := range server.AreaConfMap {
+		if areaEnt.AdminState == true &&
+			len(areaEnt.IntfMap) > 0 {
+			server.SendMsgToGenerateRouterLSA(areaId)
+			return
+		}
+	}
+}
+
+// Returns the most specific range of areaId containing destId/addrMask
+func (server *OSPFV2Server) getAreaRange(areaId, destId, addrMask uint32) (AreaRangeKey, AreaRangeConf, bool) {
+	var retKey AreaRangeKey
+	var retEnt AreaRangeConf
+	found := false
+	for rangeKey, rangeEnt := range server.AreaRangeConfMap {
+		if rangeKey.AreaId != areaId ||
+			destId&rangeKey.Netmask != rangeKey.IpPrefix ||
+			addrMask&rangeKey.Netmask != rangeKey.Netmask {
+			continue
+		}
+		if !found || rangeKey.Netmask > retKey.Netmask {
+			retKey = rangeKey
+			retEnt = rangeEnt
+			found = true
+		}
+	}
+	return retKey, retEnt, found
+}
+
+// RFC 2328 12.4.3: Ranges of the area the intra area route belongs
+// to, except that backbone ranges are not used when originating
+// summary LSAs into transit areas
+func (server *OSPFV2Server) getSummaryAreaRange(rEnt GlobalRoutingTblEntry, rKey RoutingTblEntryKey, areaId uint32) (AreaRangeKey, AreaRangeConf, bool) {
+	if rEnt.AreaId == 0 &&
+		server.RoutingTblData.TransitCapability[areaId] == true {
+		return AreaRangeKey{}, AreaRangeConf{}, false
+	}
+	return server.getAreaRange(rEnt.AreaId, rKey.DestId, rKey.AddrMask)
+}
+
+// Export filter of the area the route belongs to and import
+// filter of the area the summary LSA is originated into
+func (server *OSPFV2Server) isSummaryPermitted(fromAreaId, toAreaId, destId, addrMask uint32) bool {
+	fromAreaEnt, _ := server.AreaConfMap[fromAreaId]
+	if !server.isPrefixPermitted(fromAreaEnt.ExportFilterList, destId, addrMask) {
+		server.logger.Info("Summary for", convertUint32ToDotNotation(destId), "denied by export filter of area", fromAreaId)
+		return false
+	}
+	toAreaEnt, _ := server.AreaConfMap[toAreaId]
+	if !server.isPrefixPermitted(toAreaEnt.ImportFilterList, destId, addrMask) {
+		server.logger.Info("Summary for", convertUint32ToDotNotation(destId), "denied by import filter of area", toAreaId)
+		return false
+	}
+	return true
+}
+
+func (server *OSPFV2Server) GenerateAreaRangeSummary3LSA(rangeKey AreaRangeKey, metric uint32, lsDbKey LsdbKey) (LsaKey, SummaryLsa) {
+	var summaryLsa SummaryLsa
+	seq_num := int(InitialSequenceNum)
+	AdvRouter := server.globalData.RouterId
+	lsaKey := LsaKey{
+		LSType:    Summary3LSA,
+		LSId:      rangeKey.IpPrefix,
+		AdvRouter: AdvRouter,
+	}
+
+	//check if summaryLSA exist
+	if lsdbEnt, ok := server.SummaryLsDb[lsDbKey]; ok {
+		if summaryLsa, ok = lsdbEnt[lsaKey]; ok {
+			seq_num = summaryLsa.LsaMd.LSSequenceNum + 1
+		}
+	}
+
+	summaryLsa.LsaMd.Options = uint8(2)
+	summaryLsa.LsaMd.LSAge = 0
+	summaryLsa.LsaMd.LSSequenceNum = seq_num
+	summaryLsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 8)
+	summaryLsa.Netmask = rangeKey.Netmask
+	summaryLsa.Metric = metric
+
+	return lsaKey, summaryLsa
+}
+
+// Advertised ranges having at least one reachable component network
+func (server *OSPFV2Server) getActiveAreaRanges() map[AreaRangeKey]bool {
+	activeRangeMap := make(map[AreaRangeKey]bool)
+	if server.globalData.AreaBdrRtrStatus == false {
+		return activeRangeMap
+	}
+	for rKey, rEnt := range server.RoutingTblData.GlobalRoutingTbl {
+		if rKey.DestType != Network ||
+			rEnt.RoutingTblEnt.PathType != IntraArea {
+			continue
+		}
+		rangeKey, rangeEnt, exist := server.getAreaRange(rEnt.AreaId, rKey.DestId, rKey.AddrMask)
+		if exist && rangeEnt.Advertise {
+			activeRangeMap[rangeKey] = true
+		}
+	}
+	return activeRangeMap
+}
+
+// Install a discard route for every active range so that traffic to the
+// unreachable parts of a range we advertise is not looped back to us
+func (server *OSPFV2Server) updateAreaRangeDiscardRoutes() {
+	newDiscardRouteMap := make(map[RoutingTblEntryKey]bool)
+	for rangeKey, _ := range server.getActiveAreaRanges() {
+		rKey := RoutingTblEntryKey{
+			DestId:   rangeKey.IpPrefix,
+			AddrMask: rangeKey.Netmask,
+			DestType: Network,
+		}
+		// Network identical to the range is already routed by us
+		_, exist := server.RoutingTblData.GlobalRoutingTbl[rKey]
+		if exist {
+			continue
+		}
+		newDiscardRouteMap[rKey] = true
+	}
+	for rKey, _ := range server.RoutingTblData.DiscardRouteMap {
+		_, exist := newDiscardRouteMap[rKey]
+		if !exist {
+			server.deleteDiscardRoute(rKey)
+		}
+	}
+	for rKey, _ := range newDiscardRouteMap {
+		_, exist := server.RoutingTblData.DiscardRouteMap[rKey]
+		if !exist {
+			server.installDiscardRoute(rKey)
+		}
+	}
+	server.RoutingTblData.DiscardRouteMap = newDiscardRouteMap
+}
+
+func (server *OSPFV2Server) flushAreaRangeDiscardRoutes() {
+	for rKey, _ := range server.RoutingTblData.DiscardRouteMap {
+		server.deleteDiscardRoute(rKey)
+	}
+	server.RoutingTblData.DiscardRouteMap = nil
+}
+
+func (server *OSPFV2Server) getDiscardRouteCfg(rKey RoutingTblEntryKey) *ribd.IPv4Route {
+	cfg := ribd.IPv4Route{
+		DestinationNw: convertUint32ToDotNotation(rKey.DestId),
+		Protocol:      "OSPF",
+		Cost:          0,
+		NetworkMask:   convertUint32ToDotNotation(rKey.AddrMask),
+		NullRoute:     true,
+	}
+	nextHopInfo := ribd.NextHopInfo{
+		NextHopIp: "255.255.255.255",
+	}
+	cfg.NextHop = make([]*ribd.NextHopInfo, 0)
+	cfg.NextHop = append(cfg.NextHop, &nextHopInfo)
+	return &cfg
+}
+
+func (server *OSPFV2Server) installDiscardRoute(rKey RoutingTblEntryKey) {
+	if server.ribdComm.ribdClient.ClientHdl == nil {
+		server.logger.Err("Nil ribd handle. Can not install discard route.")
+		return
+	}
+	server.logger.Info("Installing discard route for area range:", rKey)
+	ret, err := server.ribdComm.ribdClient.ClientHdl.CreateIPv4Route(server.getDiscardRouteCfg(rKey))
+	if err != nil {
+		server.logger.Err("Error Installing discard route:", err, ret)
+	}
+}
+
+func (server *OSPFV2Server) deleteDiscardRoute(rKey RoutingTblEntryKey) {
+	if server.ribdComm.ribdClient.ClientHdl == nil {
+		server.logger.Err("Nil ribd handle. Can not delete discard route.")
+		return
+	}
+	server.logger.Info("Deleting discard route for area range:", rKey)
+	ret, err := server.ribdComm.ribdClient.ClientHdl.DeleteIPv4Route(server.getDiscardRouteCfg(rKey))
+	if err != nil {
+		server.logger.Err("Error Deleting discard route:", err, ret)
+	}
+}
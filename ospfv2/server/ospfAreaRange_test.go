@@ -0,0 +1,213 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/ospfv2/objects"
+	"testing"
+)
+
+// Border router between the backbone and areas 1 and 2, with ranges
+// configured in all three
+func buildTestAreaRangeServer(t *testing.T) *OSPFV2Server {
+	server := newTestServer(t)
+	for _, areaId := range []uint32{0, 1, 2} {
+		createTestArea(t, server, objects.Ospfv2Area{AreaId: areaId, AdminState: true, ImportASExtern: true})
+	}
+	ranges := []objects.Ospfv2AreaRange{
+		objects.Ospfv2AreaRange{AreaId: 1, IpPrefix: 0x0a000000, Netmask: 0xff000000, Advertise: true},
+		objects.Ospfv2AreaRange{AreaId: 1, IpPrefix: 0x0a010000, Netmask: 0xffff0000, Advertise: false},
+		objects.Ospfv2AreaRange{AreaId: 2, IpPrefix: 0x14000000, Netmask: 0xff000000, Advertise: true, Cost: 50},
+		objects.Ospfv2AreaRange{AreaId: 0, IpPrefix: 0x1e000000, Netmask: 0xff000000, Advertise: true},
+	}
+	for _, cfg := range ranges {
+		if _, err := server.createAreaRange(&cfg); err != nil {
+			t.Fatal("Failed to create area range", cfg, "err:", err)
+		}
+	}
+	server.RoutingTblData.TransitCapability = make(map[uint32]bool)
+	return server
+}
+
+func TestCreateAreaRange(t *testing.T) {
+	server := buildTestAreaRangeServer(t)
+	tests := []struct {
+		name  string
+		cfg   objects.Ospfv2AreaRange
+		valid bool
+	}{
+		{"new range", objects.Ospfv2AreaRange{AreaId: 2, IpPrefix: 0x15000000, Netmask: 0xff000000, Advertise: true}, true},
+		{"same range in another area", objects.Ospfv2AreaRange{AreaId: 2, IpPrefix: 0x0a000000, Netmask: 0xff000000}, true},
+		{"existing range", objects.Ospfv2AreaRange{AreaId: 1, IpPrefix: 0x0a000000, Netmask: 0xff000000}, false},
+		{"unknown area", objects.Ospfv2AreaRange{AreaId: 3, IpPrefix: 0x0a000000, Netmask: 0xff000000}, false},
+	}
+	for _, test := range tests {
+		if _, err := server.createAreaRange(&test.cfg); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+	cfg := objects.Ospfv2AreaRange{AreaId: 2, IpPrefix: 0x14000000, Netmask: 0xff000000, Cost: 70}
+	if _, err := server.updateAreaRange(&cfg, &cfg, []bool{false, false, false, true}); err != nil {
+		t.Fatal("Failed to update area range, err:", err)
+	}
+	if rangeEnt := server.AreaRangeConfMap[AreaRangeKey{AreaId: 2, IpPrefix: 0x14000000, Netmask: 0xff000000}]; rangeEnt.Cost != 70 || !rangeEnt.Advertise {
+		t.Error("Unexpected area range after cost update", rangeEnt)
+	}
+}
+
+func TestGetAreaRange(t *testing.T) {
+	server := buildTestAreaRangeServer(t)
+	tests := []struct {
+		name     string
+		areaId   uint32
+		destId   uint32
+		addrMask uint32
+		ipPrefix uint32
+		netmask  uint32
+		found    bool
+	}{
+		{"most specific range", 1, 0x0a010100, 0xffffff00, 0x0a010000, 0xffff0000, true},
+		{"less specific range", 1, 0x0a020100, 0xffffff00, 0x0a000000, 0xff000000, true},
+		{"range itself", 1, 0x0a000000, 0xff000000, 0x0a000000, 0xff000000, true},
+		{"network larger than the range", 1, 0x0a000000, 0xfe000000, 0, 0, false},
+		{"range of another area", 2, 0x0a010100, 0xffffff00, 0, 0, false},
+		{"no range", 1, 0x0b000000, 0xffffff00, 0, 0, false},
+	}
+	for _, test := range tests {
+		rangeKey, _, found := server.getAreaRange(test.areaId, test.destId, test.addrMask)
+		if found != test.found ||
+			(found && (rangeKey.IpPrefix != test.ipPrefix || rangeKey.Netmask != test.netmask)) {
+			t.Error(test.name, ": got range", rangeKey, found)
+		}
+	}
+}
+
+func TestGetSummaryAreaRange(t *testing.T) {
+	server := buildTestAreaRangeServer(t)
+	rKey := RoutingTblEntryKey{DestId: 0x1e010000, AddrMask: 0xffff0000, DestType: Network}
+	rEnt := GlobalRoutingTblEntry{AreaId: 0}
+	if _, _, found := server.getSummaryAreaRange(rEnt, rKey, 1); !found {
+		t.Error("Backbone range not used for a summary into area 1")
+	}
+	// RFC 2328 12.4.3: backbone ranges are not used for transit areas
+	server.RoutingTblData.TransitCapability[1] = true
+	if _, _, found := server.getSummaryAreaRange(rEnt, rKey, 1); found {
+		t.Error("Backbone range used for a summary into the transit area 1")
+	}
+	rKey.DestId = 0x14010000
+	rEnt.AreaId = 2
+	if _, rangeEnt, found := server.getSummaryAreaRange(rEnt, rKey, 1); !found || rangeEnt.Cost != 50 {
+		t.Error("Range of area 2 not used for a summary into the transit area 1")
+	}
+}
+
+func TestGetActiveAreaRanges(t *testing.T) {
+	server := buildTestAreaRangeServer(t)
+	routes := []struct {
+		destId   uint32
+		addrMask uint32
+		areaId   uint32
+		pathType PathType
+	}{
+		{0x0a020000, 0xffff0000, 1, IntraArea},
+		{0x0a010000, 0xffffff00, 1, IntraArea}, // not advertised range
+		{0x14010000, 0xffff0000, 2, InterArea},
+		{0x1e010000, 0xffff0000, 1, IntraArea}, // backbone range, area 1 route
+	}
+	for _, route := range routes {
+		rKey := RoutingTblEntryKey{DestId: route.destId, AddrMask: route.addrMask, DestType: Network}
+		server.RoutingTblData.GlobalRoutingTbl[rKey] = GlobalRoutingTblEntry{
+			AreaId:        route.areaId,
+			RoutingTblEnt: RoutingTblEntry{PathType: route.pathType},
+		}
+	}
+	activeRangeMap := server.getActiveAreaRanges()
+	if len(activeRangeMap) != 1 ||
+		!activeRangeMap[AreaRangeKey{AreaId: 1, IpPrefix: 0x0a000000, Netmask: 0xff000000}] {
+		t.Error("Unexpected active ranges", activeRangeMap)
+	}
+	// Only the backbone is left active
+	for _, areaId := range []uint32{1, 2} {
+		cfg := objects.Ospfv2Area{AreaId: areaId, AdminState: false}
+		if _, err := server.updateArea(&cfg, &cfg, []bool{false, true}); err != nil {
+			t.Fatal("Failed to disable area", areaId, "err:", err)
+		}
+	}
+	if activeRangeMap := server.getActiveAreaRanges(); len(activeRangeMap) != 0 {
+		t.Error("Active ranges on a router that is not a border router", activeRangeMap)
+	}
+}
+
+func TestIsPrefixPermitted(t *testing.T) {
+	server := buildTestAreaRangeServer(t)
+	prefixLists := []objects.Ospfv2PrefixList{
+		objects.Ospfv2PrefixList{Name: "filter", Seq: 20, Action: objects.PREFIX_LIST_ACTION_PERMIT, IpPrefix: 0x0a000000, Netmask: 0xff000000, MinLen: 8, MaxLen: 24},
+		objects.Ospfv2PrefixList{Name: "filter", Seq: 10, Action: objects.PREFIX_LIST_ACTION_DENY, IpPrefix: 0x0a010000, Netmask: 0xffff0000, MinLen: 16, MaxLen: 32},
+		objects.Ospfv2PrefixList{Name: "empty", Seq: 10, Action: objects.PREFIX_LIST_ACTION_DENY, IpPrefix: 0x0a000000, Netmask: 0xff000000, MinLen: 8, MaxLen: 32},
+	}
+	for _, cfg := range prefixLists {
+		if _, err := server.createPrefixList(&cfg); err != nil {
+			t.Fatal("Failed to create prefix list", cfg.Name, cfg.Seq, "err:", err)
+		}
+	}
+	// Deleting the last entry removes the prefix list
+	if _, err := server.deletePrefixList(&prefixLists[2]); err != nil {
+		t.Fatal("Failed to delete prefix list entry, err:", err)
+	}
+	tests := []struct {
+		name      string
+		list      string
+		ipAddr    uint32
+		netmask   uint32
+		permitted bool
+	}{
+		{"no filter", "", 0x0b000000, 0xff000000, true},
+		{"unknown filter", "unknown", 0x0b000000, 0xff000000, true},
+		{"all entries deleted", "empty", 0x0b000000, 0xff000000, true},
+		{"denied by the first entry", "filter", 0x0a010100, 0xffffff00, false},
+		{"permitted by the second entry", "filter", 0x0a020100, 0xffffff00, true},
+		{"longer than the max length", "filter", 0x0a020180, 0xffffff80, false},
+		{"not matching any entry", "filter", 0x0b000000, 0xff000000, false},
+	}
+	for _, test := range tests {
+		if permitted := server.isPrefixPermitted(test.list, test.ipAddr, test.netmask); permitted != test.permitted {
+			t.Error(test.name, ": permitted", permitted, "expected", test.permitted)
+		}
+	}
+	filterAttrset := []bool{false, false, false, false, false, false, false, false, true, true}
+	for _, cfg := range []objects.Ospfv2Area{
+		objects.Ospfv2Area{AreaId: 1, ExportFilterList: "filter"},
+		objects.Ospfv2Area{AreaId: 2, ImportFilterList: "filter"},
+	} {
+		if _, err := server.updateArea(&cfg, &cfg, filterAttrset); err != nil {
+			t.Fatal("Failed to set the filter lists of area", cfg.AreaId, "err:", err)
+		}
+	}
+	if server.isSummaryPermitted(1, 0, 0x0a010100, 0xffffff00) ||
+		server.isSummaryPermitted(0, 2, 0x0a010100, 0xffffff00) ||
+		!server.isSummaryPermitted(1, 2, 0x0a020100, 0xffffff00) ||
+		!server.isSummaryPermitted(0, 0, 0x0a010100, 0xffffff00) {
+		t.Error("Unexpected summary filtering")
+	}
+}
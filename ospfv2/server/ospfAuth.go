@@ -0,0 +1,578 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"l3/ospfv2/objects"
+	"sync"
+	"time"
+)
+
+const (
+	OSPF_SIMPLE_PASSWORD_LEN int = 8
+	OSPF_MD5_KEY_LEN         int = 16
+)
+
+// Apad from RFC 5709 section 3.3, repeated to the length of the hash output
+const OSPF_HMAC_SHA_APAD uint32 = 0x878FE1F3
+
+type AuthKeyConf struct {
+	Key                 []byte
+	CryptoAlgorithm     uint8
+	SendLifetimeStart   time.Time
+	SendLifetimeEnd     time.Time
+	AcceptLifetimeStart time.Time
+	AcceptLifetimeEnd   time.Time
+}
+
+type AuthNbrSeqNum struct {
+	SeqNum       uint32
+	LastRecvTime time.Time
+}
+
+// IntfAuthData holds the key chain and the cryptographic sequence numbers of
+// an interface. It is shared by pointer between copies of IntfConf, since it
+// is updated from the config, tx and rx routines.
+type IntfAuthData struct {
+	sync.Mutex
+	KeyChain    map[uint8]AuthKeyConf
+	TxSeqNum    uint32
+	RxSeqNumMap map[uint32]AuthNbrSeqNum //Keyed by Nbr Src IP
+}
+
+func newIntfAuthData() *IntfAuthData {
+	return &IntfAuthData{
+		KeyChain: make(map[uint8]AuthKeyConf),
+		// Rfc 2328 D.4.3: Seeding with the time keeps the sequence number
+		// non-decreasing across restarts
+		TxSeqNum:    uint32(time.Now().Unix()),
+		RxSeqNumMap: make(map[uint32]AuthNbrSeqNum),
+	}
+}
+
+func isInLifetime(start, end, now time.Time) bool {
+	if !start.IsZero() && now.Before(start) {
+		return false
+	}
+	if !end.IsZero() && !now.Before(end) {
+		return false
+	}
+	return true
+}
+
+// Rfc 2328 D.3: When several keys are valid for sending the one with the most
+// recent start time is used. When all of them have expired the last one to
+// expire is still used, rather than falling back to no authentication.
+func (authData *IntfAuthData) getSendKey(now time.Time) (uint8, AuthKeyConf, bool) {
+	var keyId, expKeyId uint8
+	var key, expKey AuthKeyConf
+	found := false
+	expFound := false
+	for id, ent := range authData.KeyChain {
+		if isInLifetime(ent.SendLifetimeStart, ent.SendLifetimeEnd, now) {
+			if !found ||
+				ent.SendLifetimeStart.After(key.SendLifetimeStart) ||
+				(ent.SendLifetimeStart.Equal(key.SendLifetimeStart) && id > keyId) {
+				keyId, key, found = id, ent, true
+			}
+		} else if !ent.SendLifetimeEnd.IsZero() && !now.Before(ent.SendLifetimeEnd) {
+			if !expFound ||
+				ent.SendLifetimeEnd.After(expKey.SendLifetimeEnd) ||
+				(ent.SendLifetimeEnd.Equal(expKey.SendLifetimeEnd) && id > expKeyId) {
+				expKeyId, expKey, expFound = id, ent, true
+			}
+		}
+	}
+	if found {
+		return keyId, key, true
+	}
+	return expKeyId, expKey, expFound
+}
+
+func (authData *IntfAuthData) getAcceptKey(keyId uint8, now time.Time) (AuthKeyConf, bool) {
+	key, exist := authData.KeyChain[keyId]
+	if !exist ||
+		!isInLifetime(key.AcceptLifetimeStart, key.AcceptLifetimeEnd, now) {
+		return key, false
+	}
+	return key, true
+}
+
+func getOspfAuthDigestLen(cryptoAlgo uint8) int {
+	switch cryptoAlgo {
+	case objects.CRYPTO_ALGO_MD5:
+		return md5.Size
+	case objects.CRYPTO_ALGO_HMAC_SHA1:
+		return sha1.Size
+	case objects.CRYPTO_ALGO_HMAC_SHA256:
+		return sha256.Size
+	case objects.CRYPTO_ALGO_HMAC_SHA384:
+		return sha512.Size384
+	case objects.CRYPTO_ALGO_HMAC_SHA512:
+		return sha512.Size
+	}
+	return 0
+}
+
+func getOspfAuthHashFunc(cryptoAlgo uint8) func() hash.Hash {
+	switch cryptoAlgo {
+	case objects.CRYPTO_ALGO_HMAC_SHA1:
+		return sha1.New
+	case objects.CRYPTO_ALGO_HMAC_SHA256:
+		return sha256.New
+	case objects.CRYPTO_ALGO_HMAC_SHA384:
+		return sha512.New384
+	case objects.CRYPTO_ALGO_HMAC_SHA512:
+		return sha512.New
+	}
+	return nil
+}
+
+// computeOspfAuthDigest computes the message digest of the ospf packet
+// (excluding the digest itself) as per Rfc 2328 D.4.3 for keyed MD5 and
+// Rfc 5709 section 3.3 for HMAC-SHA.
+func computeOspfAuthDigest(key AuthKeyConf, ospfPkt []byte) []byte {
+	if key.CryptoAlgorithm == objects.CRYPTO_ALGO_MD5 {
+		md5Key := make([]byte, OSPF_MD5_KEY_LEN)
+		copy(md5Key, key.Key)
+		h := md5.New()
+		h.Write(ospfPkt)
+		h.Write(md5Key)
+		return h.Sum(nil)
+	}
+	hashFunc := getOspfAuthHashFunc(key.CryptoAlgorithm)
+	if hashFunc == nil {
+		return nil
+	}
+	digestLen := getOspfAuthDigestLen(key.CryptoAlgorithm)
+	apad := make([]byte, digestLen)
+	for idx := 0; idx < digestLen; idx += 4 {
+		binary.BigEndian.PutUint32(apad[idx:idx+4], OSPF_HMAC_SHA_APAD)
+	}
+	return computeHmacShaDigest(hashFunc, key.Key, ospfPkt, apad)
+}
+
+// computeHmacShaDigest is HMAC over the concatenated texts, with the key
+// preparation of Rfc 5709 section 3.3: keys longer than the hash output are
+// hashed first.
+func computeHmacShaDigest(hashFunc func() hash.Hash, key []byte, texts ...[]byte) []byte {
+	hmacKey := key
+	if len(hmacKey) > hashFunc().Size() {
+		h := hashFunc()
+		h.Write(hmacKey)
+		hmacKey = h.Sum(nil)
+	}
+	mac := hmac.New(hashFunc, hmacKey)
+	for _, text := range texts {
+		mac.Write(text)
+	}
+	return mac.Sum(nil)
+}
+
+// encodeOspfAuth fills in the checksum and the authentication fields of an
+// encoded ospf packet and for cryptographic authentication appends the
+// message digest. It returns nil if the packet cannot be authenticated.
+func (server *OSPFV2Server) encodeOspfAuth(ent IntfConf, ospf []byte) []byte {
+	switch uint8(ent.AuthType) {
+	case objects.AUTH_TYPE_NONE:
+		csum := computeCheckSum(ospf)
+		binary.BigEndian.PutUint16(ospf[12:14], csum)
+	case objects.AUTH_TYPE_SIMPLE_PASSWORD:
+		if ent.AuthData == nil {
+			server.logger.Err("Auth: No key chain on interface", ent.IfName)
+			return nil
+		}
+		ent.AuthData.Lock()
+		_, key, exist := ent.AuthData.getSendKey(time.Now())
+		ent.AuthData.Unlock()
+		if !exist {
+			server.logger.Err("Auth: No valid password to send on interface", ent.IfName)
+			return nil
+		}
+		csum := computeCheckSum(ospf)
+		binary.BigEndian.PutUint16(ospf[12:14], csum)
+		copy(ospf[16:OSPF_HEADER_SIZE], key.Key)
+	case objects.AUTH_TYPE_CRYPTOGRAPHIC:
+		if ent.AuthData == nil {
+			server.logger.Err("Auth: No key chain on interface", ent.IfName)
+			return nil
+		}
+		ent.AuthData.Lock()
+		keyId, key, exist := ent.AuthData.getSendKey(time.Now())
+		ent.AuthData.TxSeqNum++
+		seqNum := ent.AuthData.TxSeqNum
+		ent.AuthData.Unlock()
+		if !exist {
+			server.logger.Err("Auth: No valid key to send on interface", ent.IfName)
+			return nil
+		}
+		// Rfc 2328 D.4.3: Checksum is not computed
+		binary.BigEndian.PutUint16(ospf[12:14], 0)
+		binary.BigEndian.PutUint16(ospf[16:18], 0)
+		ospf[18] = keyId
+		ospf[19] = uint8(getOspfAuthDigestLen(key.CryptoAlgorithm))
+		binary.BigEndian.PutUint32(ospf[20:24], seqNum)
+		digest := computeOspfAuthDigest(key, ospf)
+		ospf = append(ospf, digest...)
+	default:
+		server.logger.Err("Auth: Unsupported AuthType", ent.AuthType)
+		return nil
+	}
+	return ospf
+}
+
+// verifyOspfAuth authenticates a received ospf packet as per Rfc 2328 D.5.
+// ospfPkt holds the packet along with the trailing message digest, if any.
+func (server *OSPFV2Server) verifyOspfAuth(ent IntfConf, ospfPkt []byte, ospfHdr *OSPFHeader, srcIp uint32) error {
+	if ent.AuthType != ospfHdr.AuthType {
+		return errors.New(fmt.Sprintln("AuthType not matching", ospfHdr.AuthType))
+	}
+	pktlen := int(ospfHdr.Pktlen)
+	switch uint8(ospfHdr.AuthType) {
+	case objects.AUTH_TYPE_NONE:
+		return verifyOspfCheckSum(ospfPkt[:pktlen], ospfHdr.Chksum)
+	case objects.AUTH_TYPE_SIMPLE_PASSWORD:
+		if ent.AuthData == nil {
+			return errors.New("No key chain configured")
+		}
+		password := make([]byte, OSPF_SIMPLE_PASSWORD_LEN)
+		copy(password, ospfPkt[16:OSPF_HEADER_SIZE])
+		now := time.Now()
+		valid := false
+		ent.AuthData.Lock()
+		for keyId, _ := range ent.AuthData.KeyChain {
+			key, ok := ent.AuthData.getAcceptKey(keyId, now)
+			if !ok {
+				continue
+			}
+			keyPassword := make([]byte, OSPF_SIMPLE_PASSWORD_LEN)
+			copy(keyPassword, key.Key)
+			if bytes.Equal(password, keyPassword) {
+				valid = true
+				break
+			}
+		}
+		ent.AuthData.Unlock()
+		if !valid {
+			return errors.New("Simple password not matching")
+		}
+		return verifyOspfCheckSum(ospfPkt[:pktlen], ospfHdr.Chksum)
+	case objects.AUTH_TYPE_CRYPTOGRAPHIC:
+		if ent.AuthData == nil {
+			return errors.New("No key chain configured")
+		}
+		keyId := ospfPkt[18]
+		authLen := int(ospfPkt[19])
+		seqNum := binary.BigEndian.Uint32(ospfPkt[20:24])
+		now := time.Now()
+		ent.AuthData.Lock()
+		defer ent.AuthData.Unlock()
+		key, exist := ent.AuthData.getAcceptKey(keyId, now)
+		if !exist {
+			return errors.New(fmt.Sprintln("No valid key for KeyId", keyId))
+		}
+		if authLen != getOspfAuthDigestLen(key.CryptoAlgorithm) ||
+			len(ospfPkt) < pktlen+authLen {
+			return errors.New(fmt.Sprintln("Invalid authentication data length", authLen))
+		}
+		digest := computeOspfAuthDigest(key, ospfPkt[:pktlen])
+		if !hmac.Equal(digest, ospfPkt[pktlen:pktlen+authLen]) {
+			return errors.New(fmt.Sprintln("Message digest not matching for KeyId", keyId))
+		}
+		// The stored sequence number is forgotten once the neighbor has
+		// been silent for RouterDeadInterval, i.e. when it would have been
+		// declared down, so that a restarted neighbor is accepted again.
+		nbrSeqNum, exist := ent.AuthData.RxSeqNumMap[srcIp]
+		if exist &&
+			now.Sub(nbrSeqNum.LastRecvTime) < time.Duration(ent.RtrDeadInterval)*time.Second &&
+			seqNum < nbrSeqNum.SeqNum {
+			return errors.New(fmt.Sprintln("Cryptographic sequence number decreased", seqNum, nbrSeqNum.SeqNum))
+		}
+		ent.AuthData.RxSeqNumMap[srcIp] = AuthNbrSeqNum{
+			SeqNum:       seqNum,
+			LastRecvTime: now,
+		}
+		return nil
+	}
+	return errors.New(fmt.Sprintln("Unsupported AuthType", ospfHdr.AuthType))
+}
+
+func verifyOspfCheckSum(ospfPkt []byte, chksum uint16) error {
+	binary.BigEndian.PutUint16(ospfPkt[12:14], 0)
+	copy(ospfPkt[16:OSPF_HEADER_SIZE], []byte{0, 0, 0, 0, 0, 0, 0, 0})
+	csum := computeCheckSum(ospfPkt)
+	if csum != chksum {
+		return errors.New("Dropped because of invalid checksum")
+	}
+	return nil
+}
+
+func genOspfv2IntfAuthKeyUpdateMask(attrset []bool) uint32 {
+	var mask uint32 = 0
+
+	if attrset == nil {
+		mask = objects.OSPFV2_INTF_AUTH_KEY_UPDATE_KEY |
+			objects.OSPFV2_INTF_AUTH_KEY_UPDATE_CRYPTO_ALGORITHM |
+			objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_START |
+			objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_END |
+			objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_START |
+			objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_END
+	} else {
+		for idx, val := range attrset {
+			if true == val {
+				switch idx {
+				case 0:
+					// IpAddress
+				case 1:
+					// AddressLessIfIdx
+				case 2:
+					// KeyId
+				case 3:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_KEY
+				case 4:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_CRYPTO_ALGORITHM
+				case 5:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_START
+				case 6:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_END
+				case 7:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_START
+				case 8:
+					mask |= objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_END
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// validateIntfAuthKey checks a key against the AuthType of its interface.
+// The crypto algorithm is only used by cryptographic authentication, a simple
+// password may leave it unset.
+func validateIntfAuthKey(key AuthKeyConf, authType uint8) error {
+	if len(key.Key) == 0 {
+		return errors.New("Empty authentication key")
+	}
+	switch authType {
+	case objects.AUTH_TYPE_SIMPLE_PASSWORD:
+		if len(key.Key) > OSPF_SIMPLE_PASSWORD_LEN {
+			return errors.New("Simple password is longer than 8 bytes")
+		}
+	case objects.AUTH_TYPE_CRYPTOGRAPHIC:
+		if getOspfAuthDigestLen(key.CryptoAlgorithm) == 0 {
+			return errors.New("Invalid Crypto Algorithm")
+		}
+		if key.CryptoAlgorithm == objects.CRYPTO_ALGO_MD5 &&
+			len(key.Key) > OSPF_MD5_KEY_LEN {
+			return errors.New("MD5 key is longer than 16 bytes")
+		}
+	}
+	return nil
+}
+
+func newAuthKeyConf(cfg *objects.Ospfv2IntfAuthKey) AuthKeyConf {
+	return AuthKeyConf{
+		Key:                 []byte(cfg.Key),
+		CryptoAlgorithm:     cfg.CryptoAlgorithm,
+		SendLifetimeStart:   cfg.SendLifetimeStart,
+		SendLifetimeEnd:     cfg.SendLifetimeEnd,
+		AcceptLifetimeStart: cfg.AcceptLifetimeStart,
+		AcceptLifetimeEnd:   cfg.AcceptLifetimeEnd,
+	}
+}
+
+func (server *OSPFV2Server) createIntfAuthKey(cfg *objects.Ospfv2IntfAuthKey) (bool, error) {
+	server.logger.Info("Intf auth key configuration create")
+	intfConfKey := IntfConfKey{
+		IpAddr:  cfg.IpAddress,
+		IntfIdx: cfg.AddressLessIfIdx,
+	}
+	_, exist := server.IntfConfMap[intfConfKey]
+	if !exist {
+		server.logger.Err("Ospf Interface configuration doesnot exist")
+		return false, errors.New("Ospf Interface configuration doesnot exist")
+	}
+	return server.addAuthKey(intfConfKey, cfg.KeyId, newAuthKeyConf(cfg))
+}
+
+func (server *OSPFV2Server) updateIntfAuthKey(newCfg, oldCfg *objects.Ospfv2IntfAuthKey, attrset []bool) (bool, error) {
+	server.logger.Info("Intf auth key configuration update")
+	intfConfKey := IntfConfKey{
+		IpAddr:  newCfg.IpAddress,
+		IntfIdx: newCfg.AddressLessIfIdx,
+	}
+	_, exist := server.IntfConfMap[intfConfKey]
+	if !exist {
+		server.logger.Err("Ospf Interface configuration doesnot exist")
+		return false, errors.New("Ospf Interface configuration doesnot exist")
+	}
+	mask := genOspfv2IntfAuthKeyUpdateMask(attrset)
+	return server.modifyAuthKey(intfConfKey, newCfg.KeyId, newAuthKeyConf(newCfg), mask)
+}
+
+func (server *OSPFV2Server) deleteIntfAuthKey(cfg *objects.Ospfv2IntfAuthKey) (bool, error) {
+	server.logger.Info("Intf auth key configuration delete")
+	intfConfKey := IntfConfKey{
+		IpAddr:  cfg.IpAddress,
+		IntfIdx: cfg.AddressLessIfIdx,
+	}
+	_, exist := server.IntfConfMap[intfConfKey]
+	if !exist {
+		server.logger.Err("Ospf Interface configuration doesnot exist")
+		return false, errors.New("Ospf Interface configuration doesnot exist")
+	}
+	return server.removeAuthKey(intfConfKey, cfg.KeyId)
+}
+
+// addAuthKey, modifyAuthKey and removeAuthKey change the key chain of an
+// interface, either a real one or the virtual interface of a virtual link.
+func (server *OSPFV2Server) addAuthKey(intfConfKey IntfConfKey, keyId uint8, key AuthKeyConf) (bool, error) {
+	intfConfEnt, _ := server.IntfConfMap[intfConfKey]
+	err := validateIntfAuthKey(key, uint8(intfConfEnt.AuthType))
+	if err != nil {
+		server.logger.Err("Invalid auth key", keyId, err)
+		return false, err
+	}
+	intfConfEnt.AuthData.Lock()
+	defer intfConfEnt.AuthData.Unlock()
+	_, exist := intfConfEnt.AuthData.KeyChain[keyId]
+	if exist {
+		server.logger.Err("Auth key already exist", keyId)
+		return false, errors.New("Auth key already exist")
+	}
+	intfConfEnt.AuthData.KeyChain[keyId] = key
+	return true, nil
+}
+
+func (server *OSPFV2Server) modifyAuthKey(intfConfKey IntfConfKey, keyId uint8, newKey AuthKeyConf, mask uint32) (bool, error) {
+	intfConfEnt, _ := server.IntfConfMap[intfConfKey]
+	intfConfEnt.AuthData.Lock()
+	defer intfConfEnt.AuthData.Unlock()
+	key, exist := intfConfEnt.AuthData.KeyChain[keyId]
+	if !exist {
+		server.logger.Err("Auth key doesnot exist", keyId)
+		return false, errors.New("Auth key doesnot exist")
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_KEY == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_KEY {
+		key.Key = newKey.Key
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_CRYPTO_ALGORITHM == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_CRYPTO_ALGORITHM {
+		key.CryptoAlgorithm = newKey.CryptoAlgorithm
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_START == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_START {
+		key.SendLifetimeStart = newKey.SendLifetimeStart
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_END == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_SEND_LIFETIME_END {
+		key.SendLifetimeEnd = newKey.SendLifetimeEnd
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_START == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_START {
+		key.AcceptLifetimeStart = newKey.AcceptLifetimeStart
+	}
+	if mask&objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_END == objects.OSPFV2_INTF_AUTH_KEY_UPDATE_ACCEPT_LIFETIME_END {
+		key.AcceptLifetimeEnd = newKey.AcceptLifetimeEnd
+	}
+	err := validateIntfAuthKey(key, uint8(intfConfEnt.AuthType))
+	if err != nil {
+		server.logger.Err("Invalid auth key", keyId, err)
+		return false, err
+	}
+	intfConfEnt.AuthData.KeyChain[keyId] = key
+	return true, nil
+}
+
+func (server *OSPFV2Server) removeAuthKey(intfConfKey IntfConfKey, keyId uint8) (bool, error) {
+	intfConfEnt, _ := server.IntfConfMap[intfConfKey]
+	intfConfEnt.AuthData.Lock()
+	defer intfConfEnt.AuthData.Unlock()
+	_, exist := intfConfEnt.AuthData.KeyChain[keyId]
+	if !exist {
+		server.logger.Err("Auth key doesnot exist", keyId)
+		return false, errors.New("Auth key doesnot exist")
+	}
+	delete(intfConfEnt.AuthData.KeyChain, keyId)
+	return true, nil
+}
+
+// validateIntfKeyChain checks the key chain of an interface against the
+// AuthType it is about to inherit, keys are otherwise only checked when they
+// are configured.
+func (server *OSPFV2Server) validateIntfKeyChain(intfKey IntfConfKey, authType uint8) error {
+	intfEnt, exist := server.IntfConfMap[intfKey]
+	if !exist || intfEnt.AuthData == nil {
+		return nil
+	}
+	intfEnt.AuthData.Lock()
+	defer intfEnt.AuthData.Unlock()
+	for keyId, key := range intfEnt.AuthData.KeyChain {
+		err := validateIntfAuthKey(key, authType)
+		if err != nil {
+			return errors.New(fmt.Sprintln("Auth key", keyId, "of interface", intfKey, "is invalid:", err))
+		}
+	}
+	return nil
+}
+
+// validateAreaIntfAuthType checks the key chains of all the interfaces of an
+// area, including the virtual links of the backbone, against a new AuthType.
+func (server *OSPFV2Server) validateAreaIntfAuthType(areaId uint32, authType uint8) error {
+	areaEnt, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return nil
+	}
+	for intfKey, _ := range areaEnt.IntfMap {
+		err := server.validateIntfKeyChain(intfKey, authType)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateAreaIntfAuthType pushes the AuthType of an area down to all of its
+// interfaces. The key chains are validated by validateAreaIntfAuthType before
+// the area is changed.
+func (server *OSPFV2Server) updateAreaIntfAuthType(areaId uint32) {
+	areaEnt, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return
+	}
+	for intfKey, _ := range areaEnt.IntfMap {
+		intfEnt, exist := server.IntfConfMap[intfKey]
+		if !exist {
+			continue
+		}
+		intfEnt.AuthType = uint16(areaEnt.AuthType)
+		server.IntfConfMap[intfKey] = intfEnt
+	}
+}
@@ -0,0 +1,350 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"l3/ospfv2/objects"
+	"testing"
+	"time"
+)
+
+func buildTestOspfPkt(bodyLen int) []byte {
+	hdr := OSPFHeader{
+		Ver:      OSPF_VERSION_2,
+		PktType:  HelloType,
+		Pktlen:   uint16(OSPF_HEADER_SIZE + bodyLen),
+		RouterId: 0x01010101,
+		AreaId:   0,
+		AuthType: uint16(objects.AUTH_TYPE_CRYPTOGRAPHIC),
+	}
+	pkt := encodeOspfHdr(hdr)
+	for idx := 0; idx < bodyLen; idx++ {
+		pkt = append(pkt, byte(idx))
+	}
+	return pkt
+}
+
+func buildTestKey(keyLen int) []byte {
+	key := make([]byte, keyLen)
+	for idx := range key {
+		key[idx] = byte(0xa0 + idx)
+	}
+	return key
+}
+
+func decodeTestHex(t *testing.T, str string) []byte {
+	buf, err := hex.DecodeString(str)
+	if err != nil {
+		t.Fatal("Invalid hex string", str, err)
+	}
+	return buf
+}
+
+// Test cases 1 and 6 of Rfc 2202 (HMAC-SHA-1) and Rfc 4231 (HMAC-SHA-2). The
+// keys of test case 6 are longer than the hash block, so that HMAC hashes them
+// as Rfc 5709 does.
+func TestComputeHmacShaDigest(t *testing.T) {
+	largeKeyText := []byte("Test Using Larger Than Block-Size Key - Hash Key First")
+	tests := []struct {
+		name     string
+		hashFunc func() hash.Hash
+		key      []byte
+		text     []byte
+		digest   string
+	}{
+		{"rfc 2202 test case 1", sha1.New, bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"b617318655057264e28bc0b6fb378c8ef146be00"},
+		{"rfc 2202 test case 6", sha1.New, bytes.Repeat([]byte{0xaa}, 80), largeKeyText,
+			"aa4ae5e15272d00e95705637ce8a3b55ed402112"},
+		{"rfc 4231 test case 1 sha256", sha256.New, bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7"},
+		{"rfc 4231 test case 6 sha256", sha256.New, bytes.Repeat([]byte{0xaa}, 131), largeKeyText,
+			"60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54"},
+		{"rfc 4231 test case 1 sha384", sha512.New384, bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"afd03944d84895626b0825f4ab46907f15f9dadbe4101ec682aa034c7cebc59cfaea9ea9076ede7f4af152e8b2fa9cb6"},
+		{"rfc 4231 test case 6 sha384", sha512.New384, bytes.Repeat([]byte{0xaa}, 131), largeKeyText,
+			"4ece084485813e9088d2c63a041bc5b44f9ef1012a2b588f3cd11f05033ac4c60c2ef6ab4030fe8296248df163f44952"},
+		{"rfc 4231 test case 1 sha512", sha512.New, bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cde" +
+				"daa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854"},
+		{"rfc 4231 test case 6 sha512", sha512.New, bytes.Repeat([]byte{0xaa}, 131), largeKeyText,
+			"80b24263c7c1a3ebb71493c1dd7be8b49b46d1f41b4aeec1121b013783f8f352" +
+				"6b56d037e05f2598bd0fd2215d6a1e5295e64f73f63f0aec8b915a985d786598"},
+	}
+	for _, test := range tests {
+		if digest := computeHmacShaDigest(test.hashFunc, test.key, test.text); !bytes.Equal(digest, decodeTestHex(t, test.digest)) {
+			t.Error(test.name, ": digest", hex.EncodeToString(digest))
+		}
+	}
+}
+
+func TestComputeOspfAuthDigestHmacSha(t *testing.T) {
+	pkt := buildTestOspfPkt(20)
+	tests := []struct {
+		name     string
+		algo     uint8
+		hashFunc func() hash.Hash
+		keyLen   int
+	}{
+		{"sha1 key shorter than L", objects.CRYPTO_ALGO_HMAC_SHA1, sha1.New, 16},
+		{"sha1 key of length L", objects.CRYPTO_ALGO_HMAC_SHA1, sha1.New, sha1.Size},
+		{"sha1 key longer than L", objects.CRYPTO_ALGO_HMAC_SHA1, sha1.New, 30},
+		{"sha256 key longer than L", objects.CRYPTO_ALGO_HMAC_SHA256, sha256.New, 40},
+		{"sha384 key longer than L", objects.CRYPTO_ALGO_HMAC_SHA384, sha512.New384, 100},
+		{"sha512 key longer than L", objects.CRYPTO_ALGO_HMAC_SHA512, sha512.New, 100},
+	}
+	for _, test := range tests {
+		key := AuthKeyConf{Key: buildTestKey(test.keyLen), CryptoAlgorithm: test.algo}
+		digest := computeOspfAuthDigest(key, pkt)
+		digestLen := getOspfAuthDigestLen(test.algo)
+		if len(digest) != digestLen {
+			t.Error(test.name, ": digest length", len(digest))
+			continue
+		}
+		// Rfc 5709 section 3.3: Apad is 0x878FE1F3 repeated to L bytes
+		apad := bytes.Repeat([]byte{0x87, 0x8f, 0xe1, 0xf3}, digestLen/4)
+		if expected := computeHmacShaDigest(test.hashFunc, key.Key, pkt, apad); !bytes.Equal(digest, expected) {
+			t.Error(test.name, ": digest", digest, "expected", expected)
+		}
+		// Keys longer than L are replaced by their hash, even when HMAC
+		// would use them as they are
+		if test.keyLen > digestLen {
+			h := test.hashFunc()
+			h.Write(key.Key)
+			hashedKey := AuthKeyConf{Key: h.Sum(nil), CryptoAlgorithm: test.algo}
+			if !bytes.Equal(digest, computeOspfAuthDigest(hashedKey, pkt)) {
+				t.Error(test.name, ": key not hashed")
+			}
+		}
+	}
+}
+
+func TestComputeOspfAuthDigestMd5(t *testing.T) {
+	// Rfc 2328 D.4.3: the 16 byte key is appended to the packet. Split
+	// across the two, the last test of the Rfc 1321 test suite gives the
+	// keyed MD5 digest.
+	text := bytes.Repeat([]byte("1234567890"), 8)
+	pkt, key := text[:len(text)-OSPF_MD5_KEY_LEN], text[len(text)-OSPF_MD5_KEY_LEN:]
+	digest := computeOspfAuthDigest(AuthKeyConf{Key: key, CryptoAlgorithm: objects.CRYPTO_ALGO_MD5}, pkt)
+	if expected := decodeTestHex(t, "57edf4a22be3c955ac49da2e2107b67a"); !bytes.Equal(digest, expected) {
+		t.Error("Keyed MD5 digest", hex.EncodeToString(digest))
+	}
+	// Shorter keys are padded with zeros to 16 bytes
+	pkt = buildTestOspfPkt(20)
+	digest = computeOspfAuthDigest(AuthKeyConf{Key: []byte("ospf"), CryptoAlgorithm: objects.CRYPTO_ALGO_MD5}, pkt)
+	paddedKey := append([]byte("ospf"), make([]byte, 12)...)
+	if expected := computeOspfAuthDigest(AuthKeyConf{Key: paddedKey, CryptoAlgorithm: objects.CRYPTO_ALGO_MD5}, pkt); !bytes.Equal(digest, expected) {
+		t.Error("Short MD5 key not padded, digest", digest, "expected", expected)
+	}
+}
+
+func TestGetSendKey(t *testing.T) {
+	now := time.Now()
+	hour := time.Hour
+	tests := []struct {
+		name   string
+		keys   map[uint8]AuthKeyConf
+		keyId  uint8
+		exists bool
+	}{
+		{"no key", map[uint8]AuthKeyConf{}, 0, false},
+		{"key without lifetime", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{},
+		}, 1, true},
+		{"new key takes over", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{SendLifetimeStart: now.Add(-2 * hour), SendLifetimeEnd: now.Add(hour)},
+			2: AuthKeyConf{SendLifetimeStart: now.Add(-hour)},
+		}, 2, true},
+		{"new key not started yet", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{SendLifetimeStart: now.Add(-2 * hour), SendLifetimeEnd: now.Add(2 * hour)},
+			2: AuthKeyConf{SendLifetimeStart: now.Add(hour)},
+		}, 1, true},
+		{"old key expired", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{SendLifetimeStart: now.Add(-2 * hour), SendLifetimeEnd: now.Add(-hour)},
+			2: AuthKeyConf{SendLifetimeStart: now.Add(-3 * hour)},
+		}, 2, true},
+		{"same start time", map[uint8]AuthKeyConf{
+			3: AuthKeyConf{SendLifetimeStart: now.Add(-hour)},
+			4: AuthKeyConf{SendLifetimeStart: now.Add(-hour)},
+		}, 4, true},
+		{"all keys expired", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{SendLifetimeEnd: now.Add(-2 * hour)},
+			2: AuthKeyConf{SendLifetimeEnd: now.Add(-hour)},
+		}, 2, true},
+		{"only future keys", map[uint8]AuthKeyConf{
+			1: AuthKeyConf{SendLifetimeStart: now.Add(hour)},
+		}, 0, false},
+	}
+	for _, test := range tests {
+		authData := newIntfAuthData()
+		authData.KeyChain = test.keys
+		keyId, _, exists := authData.getSendKey(now)
+		if exists != test.exists || (exists && keyId != test.keyId) {
+			t.Error(test.name, ": got key", keyId, exists, "expected", test.keyId, test.exists)
+		}
+	}
+}
+
+func TestVerifyOspfAuthCryptographic(t *testing.T) {
+	server := newTestServer(t)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 0, ImportASExtern: true, AuthType: objects.AUTH_TYPE_CRYPTOGRAPHIC})
+	intfKey := IntfConfKey{IpAddr: 0x0a000001}
+	createTestIntf(t, server, 1, 0xffffff00, objects.Ospfv2Intf{IpAddress: intfKey.IpAddr, Type: objects.INTF_TYPE_BROADCAST, RtrDeadInterval: 40})
+	authKey := objects.Ospfv2IntfAuthKey{
+		IpAddress:       intfKey.IpAddr,
+		KeyId:           5,
+		Key:             string(buildTestKey(32)),
+		CryptoAlgorithm: objects.CRYPTO_ALGO_HMAC_SHA256,
+	}
+	if _, err := server.createIntfAuthKey(&authKey); err != nil {
+		t.Fatal("Failed to create auth key, err:", err)
+	}
+	// Packets are sent and received on the same interface, the key chain is
+	// shared but the tx and rx sequence numbers are kept apart
+	txIntf := server.IntfConfMap[intfKey]
+	rxIntf := server.IntfConfMap[intfKey]
+	srcIp := uint32(0x0a000002)
+
+	pkts := make([][]byte, 0)
+	for idx := 0; idx < 3; idx++ {
+		pkt := server.encodeOspfAuth(txIntf, buildTestOspfPkt(20))
+		if pkt == nil || pkt[18] != 5 || int(pkt[19]) != sha256.Size {
+			t.Fatal("Failed to encode the authentication of packet", idx, pkt)
+		}
+		pkts = append(pkts, pkt)
+	}
+	verify := func(pkt []byte) error {
+		ospfHdr := NewOSPFHeader()
+		decodeOspfHdr(pkt, ospfHdr)
+		return server.verifyOspfAuth(rxIntf, append([]byte{}, pkt...), ospfHdr, srcIp)
+	}
+	tampered := append([]byte{}, pkts[2]...)
+	tampered[OSPF_HEADER_SIZE] ^= 0xff
+	unknownKey := append([]byte{}, pkts[2]...)
+	unknownKey[18] = 6
+
+	tests := []struct {
+		name  string
+		pkt   []byte
+		valid bool
+	}{
+		{"first packet", pkts[0], true},
+		{"next sequence number", pkts[1], true},
+		{"same sequence number", pkts[1], true},
+		{"replayed packet", pkts[0], false},
+		{"modified packet", tampered, false},
+		{"unknown key id", unknownKey, false},
+		{"last packet", pkts[2], true},
+		{"replay of the first packet", pkts[0], false},
+	}
+	for _, test := range tests {
+		if err := verify(test.pkt); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+
+	// The sequence number of a neighbor silent for RouterDeadInterval is
+	// forgotten, it may have restarted
+	nbrSeqNum := rxIntf.AuthData.RxSeqNumMap[srcIp]
+	nbrSeqNum.LastRecvTime = time.Now().Add(-41 * time.Second)
+	rxIntf.AuthData.RxSeqNumMap[srcIp] = nbrSeqNum
+	if err := verify(pkts[0]); err != nil {
+		t.Error("Packet of a restarted neighbor rejected, err:", err)
+	}
+}
+
+func TestIntfAuthKeyAuthType(t *testing.T) {
+	server := newTestServer(t)
+	areaCfg := objects.Ospfv2Area{AreaId: 0, ImportASExtern: true, AuthType: objects.AUTH_TYPE_SIMPLE_PASSWORD}
+	createTestArea(t, server, areaCfg)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 1, ImportASExtern: true, AuthType: objects.AUTH_TYPE_SIMPLE_PASSWORD})
+	intfKey := IntfConfKey{IpAddr: 0x0a000001}
+	intfCfg := objects.Ospfv2Intf{IpAddress: intfKey.IpAddr, Type: objects.INTF_TYPE_BROADCAST}
+	createTestIntf(t, server, 1, 0xffffff00, intfCfg)
+	tests := []struct {
+		name  string
+		cfg   objects.Ospfv2IntfAuthKey
+		valid bool
+	}{
+		{"password without crypto algorithm", objects.Ospfv2IntfAuthKey{IpAddress: intfKey.IpAddr, KeyId: 1,
+			Key: "password", CryptoAlgorithm: objects.CRYPTO_ALGO_NONE}, true},
+		{"password longer than 8 bytes", objects.Ospfv2IntfAuthKey{IpAddress: intfKey.IpAddr, KeyId: 2,
+			Key: "password1", CryptoAlgorithm: objects.CRYPTO_ALGO_NONE}, false},
+		{"empty password", objects.Ospfv2IntfAuthKey{IpAddress: intfKey.IpAddr, KeyId: 3,
+			CryptoAlgorithm: objects.CRYPTO_ALGO_NONE}, false},
+		{"unknown interface", objects.Ospfv2IntfAuthKey{IpAddress: 0x0a000002, KeyId: 4,
+			Key: "password", CryptoAlgorithm: objects.CRYPTO_ALGO_NONE}, false},
+	}
+	for _, test := range tests {
+		if _, err := server.createIntfAuthKey(&test.cfg); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+
+	// The password has no crypto algorithm to use it for cryptographic
+	// authentication
+	newAreaCfg := areaCfg
+	newAreaCfg.AuthType = objects.AUTH_TYPE_CRYPTOGRAPHIC
+	areaAttrset := []bool{false, false, true}
+	if _, err := server.updateArea(&newAreaCfg, &areaCfg, areaAttrset); err == nil {
+		t.Error("Area switched to cryptographic authentication with a key without crypto algorithm")
+	}
+	if server.IntfConfMap[intfKey].AuthType != uint16(objects.AUTH_TYPE_SIMPLE_PASSWORD) {
+		t.Error("Interface AuthType changed by a rejected area update")
+	}
+	keyCfg := objects.Ospfv2IntfAuthKey{IpAddress: intfKey.IpAddr, KeyId: 1, CryptoAlgorithm: objects.CRYPTO_ALGO_HMAC_SHA1}
+	if _, err := server.updateIntfAuthKey(&keyCfg, &keyCfg, []bool{false, false, false, false, true}); err != nil {
+		t.Fatal("Failed to set the crypto algorithm, err:", err)
+	}
+	if _, err := server.updateArea(&newAreaCfg, &areaCfg, areaAttrset); err != nil {
+		t.Fatal("Failed to switch to cryptographic authentication, err:", err)
+	}
+	if server.IntfConfMap[intfKey].AuthType != uint16(objects.AUTH_TYPE_CRYPTOGRAPHIC) {
+		t.Error("Interface AuthType not updated")
+	}
+
+	// A cryptographic key would be truncated by simple password
+	keyCfg = objects.Ospfv2IntfAuthKey{IpAddress: intfKey.IpAddr, KeyId: 2, Key: "cryptographic",
+		CryptoAlgorithm: objects.CRYPTO_ALGO_HMAC_SHA1}
+	if _, err := server.createIntfAuthKey(&keyCfg); err != nil {
+		t.Fatal("Failed to create auth key, err:", err)
+	}
+	if _, err := server.updateArea(&areaCfg, &newAreaCfg, areaAttrset); err == nil {
+		t.Error("Area switched to simple password with a key longer than 8 bytes")
+	}
+	newIntfCfg := intfCfg
+	newIntfCfg.AreaId = 1
+	if _, err := server.updateIntf(&newIntfCfg, &intfCfg, []bool{false, false, false, true}); err == nil {
+		t.Error("Interface moved to a simple password area with a key longer than 8 bytes")
+	}
+	if server.IntfConfMap[intfKey].AreaId != 0 {
+		t.Error("Interface area changed by a rejected update")
+	}
+}
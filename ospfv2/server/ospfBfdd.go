@@ -0,0 +1,224 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bfdd"
+	"encoding/json"
+	"errors"
+	nanomsg "github.com/op/go-nanomsg"
+	"l3/bfd/bfddCommonDefs"
+	"strconv"
+	"time"
+	"utils/ipcutils"
+)
+
+type BfddClient struct {
+	OspfClientBase
+	ClientHdl *bfdd.BFDDServicesClient
+}
+
+type BfddCommStruct struct {
+	bfddSubSocketCh    chan []byte
+	bfddClient         BfddClient
+	bfddSubSocket      *nanomsg.SubSocket
+	bfddSubSocketErrCh chan error
+}
+
+func (server *OSPFV2Server) initBfddComm() error {
+	server.bfddComm.bfddSubSocketCh = make(chan []byte)
+	server.bfddComm.bfddSubSocketErrCh = make(chan error)
+	return nil
+}
+
+func (server *OSPFV2Server) ConnectToBfddServer(port int) {
+	var err error
+	server.logger.Info("found bfdd at port", port)
+	server.bfddComm.bfddClient.Address = "localhost:" + strconv.Itoa(port)
+	server.bfddComm.bfddClient.Transport, server.bfddComm.bfddClient.PtrProtocolFactory, err = ipcutils.CreateIPCHandles(server.bfddComm.bfddClient.Address)
+	if err != nil {
+		server.logger.Info("Failed to connect to bfdd, retrying until connection is successful")
+		count := 0
+		ticker := time.NewTicker(time.Duration(1000) * time.Millisecond)
+		for _ = range ticker.C {
+			server.bfddComm.bfddClient.Transport, server.bfddComm.bfddClient.PtrProtocolFactory, err = ipcutils.CreateIPCHandles(server.bfddComm.bfddClient.Address)
+			if err == nil {
+				ticker.Stop()
+				break
+			}
+			count++
+			if (count % 10) == 0 {
+				server.logger.Info("Still can't connect to bfdd, retrying..")
+			}
+		}
+	}
+	server.logger.Info("Ospfd is connected to bfdd")
+	server.bfddComm.bfddClient.ClientHdl = bfdd.NewBFDDServicesClientFactory(server.bfddComm.bfddClient.Transport, server.bfddComm.bfddClient.PtrProtocolFactory)
+	server.bfddComm.bfddClient.IsConnected = true
+}
+
+func (server *OSPFV2Server) StartBfddSubscriber() {
+	server.logger.Info("Listen for bfdd updates")
+	server.listenForBfddUpdates(bfddCommonDefs.PUB_SOCKET_ADDR)
+	go server.createBfddSubscriber()
+}
+
+func (server *OSPFV2Server) listenForBfddUpdates(address string) error {
+	var err error
+	if server.bfddComm.bfddSubSocket, err = nanomsg.NewSubSocket(); err != nil {
+		server.logger.Err("ERR: Failed to create BFD subscribe socket, error:", err)
+		return err
+	}
+
+	if err = server.bfddComm.bfddSubSocket.Subscribe(""); err != nil {
+		server.logger.Err("ERR: Failed to subscribe to \"\" on BFD subscribe socket, error:", err)
+		return err
+	}
+
+	if _, err = server.bfddComm.bfddSubSocket.Connect(address); err != nil {
+		server.logger.Err("ERR: Failed to connect to BFD publisher socket, address:", address, "error:", err)
+		return err
+	}
+
+	server.logger.Info("Connected to BFD publisher at address:", address)
+	if err = server.bfddComm.bfddSubSocket.SetRecvBuffer(1024 * 1024); err != nil {
+		server.logger.Err("ERR: Failed to set the buffer size for BFD publisher socket, error:", err)
+		return err
+	}
+	return nil
+}
+
+func (server *OSPFV2Server) createBfddSubscriber() {
+	if server.bfddComm.bfddSubSocket == nil {
+		return
+	}
+	for {
+		server.logger.Info("Read on Bfdd subscriber socket...")
+		bfddRxBuf, err := server.bfddComm.bfddSubSocket.Recv(0)
+		if err != nil {
+			server.logger.Err("ERR: Recv on Bfdd subscriber socket failed with error:", err)
+			server.bfddComm.bfddSubSocketErrCh <- err
+			continue
+		}
+		server.bfddComm.bfddSubSocketCh <- bfddRxBuf
+	}
+}
+
+// Only session down is of interest, the nbr is brought up
+// again by the Hello protocol
+func (server *OSPFV2Server) processBfddNotification(bfddRxBuf []byte) {
+	if server.globalData.AdminState == false {
+		return
+	}
+	var bfdMsg bfddCommonDefs.BfddNotifyMsg
+	err := json.Unmarshal(bfddRxBuf, &bfdMsg)
+	if err != nil {
+		server.logger.Err("Unable to unmarshal bfddRxBuf:", bfddRxBuf)
+		return
+	}
+	if bfdMsg.State {
+		return
+	}
+	nbrIP, err := convertDotNotationToUint32(bfdMsg.DestIp)
+	if err != nil {
+		server.logger.Debug("Ignoring BFD notification for", bfdMsg.DestIp)
+		return
+	}
+	server.logger.Info("BFD session down for nbr", bfdMsg.DestIp)
+	server.SendBfdDownMsgToNbrFSM(nbrIP)
+}
+
+// Non blocking, the dead interval is still there in case Nbr FSM is not running
+func (server *OSPFV2Server) SendBfdDownMsgToNbrFSM(nbrIP uint32) {
+	msg := NbrBfdDownMsg{
+		NbrIP: nbrIP,
+	}
+	select {
+	case server.NbrConfData.nbrBfdDownCh <- msg:
+	default:
+		server.logger.Err("Unable to send BFD down msg to Nbr FSM", nbrIP)
+	}
+}
+
+func (server *OSPFV2Server) createBfdSession(nbrIP uint32, ifName string) error {
+	if !server.bfddComm.bfddClient.IsConnected {
+		return errors.New("Not connected to bfdd")
+	}
+	bfdSession := bfdd.NewBfdSession()
+	bfdSession.IpAddr = convertUint32ToDotNotation(nbrIP)
+	bfdSession.ParamName = "default"
+	bfdSession.Interface = ifName
+	bfdSession.Owner = bfddCommonDefs.ConvertBfdSessionOwnerValToStr(bfddCommonDefs.OSPF)
+	_, err := server.bfddComm.bfddClient.ClientHdl.CreateBfdSession(bfdSession)
+	return err
+}
+
+func (server *OSPFV2Server) deleteBfdSession(nbrIP uint32, ifName string) error {
+	if !server.bfddComm.bfddClient.IsConnected {
+		return errors.New("Not connected to bfdd")
+	}
+	bfdSession := bfdd.NewBfdSession()
+	bfdSession.IpAddr = convertUint32ToDotNotation(nbrIP)
+	bfdSession.Interface = ifName
+	bfdSession.Owner = bfddCommonDefs.ConvertBfdSessionOwnerValToStr(bfddCommonDefs.OSPF)
+	_, err := server.bfddComm.bfddClient.ClientHdl.DeleteBfdSession(bfdSession)
+	return err
+}
+
+// Called from Nbr FSM once the nbr reaches 2-Way
+func (server *OSPFV2Server) createNbrBfdSession(nbrKey NbrConfKey) {
+	nbrConf, exist := server.NbrConfMap[nbrKey]
+	if !exist || nbrConf.BfdSession {
+		return
+	}
+	intfConf, exist := server.IntfConfMap[nbrConf.IntfKey]
+	if !exist || !intfConf.BfdEnable {
+		return
+	}
+	err := server.createBfdSession(nbrConf.NbrIP, intfConf.IfName)
+	if err != nil {
+		server.logger.Err("Nbr: Unable to create BFD session", nbrKey, err)
+		return
+	}
+	server.logger.Info("Nbr: Created BFD session", nbrKey)
+	nbrConf.BfdSession = true
+	server.NbrConfMap[nbrKey] = nbrConf
+}
+
+func (server *OSPFV2Server) deleteNbrBfdSession(nbrKey NbrConfKey, nbrConf NbrConf) {
+	if !nbrConf.BfdSession {
+		return
+	}
+	var ifName string
+	intfConf, exist := server.IntfConfMap[nbrConf.IntfKey]
+	if exist {
+		ifName = intfConf.IfName
+	}
+	err := server.deleteBfdSession(nbrConf.NbrIP, ifName)
+	if err != nil {
+		server.logger.Err("Nbr: Unable to delete BFD session", nbrKey, err)
+		return
+	}
+	server.logger.Info("Nbr: Deleted BFD session", nbrKey)
+}
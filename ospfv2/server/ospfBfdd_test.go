@@ -0,0 +1,166 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/json"
+	"l3/bfd/bfddCommonDefs"
+	"l3/ospfv2/objects"
+	"testing"
+	"time"
+)
+
+// Interfaces of area 0 with and without BFD
+func buildTestBfdServer(t *testing.T) *OSPFV2Server {
+	server := newTestServer(t)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 0, ImportASExtern: true})
+	createTestIntf(t, server, 1, 0xffffff00, objects.Ospfv2Intf{IpAddress: 0x0a010101, Type: objects.INTF_TYPE_BROADCAST, BfdEnable: true})
+	createTestIntf(t, server, 2, 0xffffff00, objects.Ospfv2Intf{IpAddress: 0x0a020101, Type: objects.INTF_TYPE_BROADCAST})
+	server.globalData.AdminState = true
+	return server
+}
+
+func TestProcessBfddNotification(t *testing.T) {
+	encode := func(destIp string, state bool) []byte {
+		buf, _ := json.Marshal(bfddCommonDefs.BfddNotifyMsg{DestIp: destIp, State: state})
+		return buf
+	}
+	tests := []struct {
+		name       string
+		adminState bool
+		buf        []byte
+		nbrIP      uint32
+		down       bool
+	}{
+		{"session down", true, encode("10.1.1.2", false), 0x0a010102, true},
+		{"session up", true, encode("10.1.1.2", true), 0, false},
+		{"ospf disabled", false, encode("10.1.1.2", false), 0, false},
+		{"invalid message", true, []byte("{"), 0, false},
+		{"invalid address", true, encode("10.1.1", false), 0, false},
+		{"ipv6 session down", true, encode("2001:db8::1", false), 0, false},
+	}
+	for _, test := range tests {
+		server := buildTestBfdServer(t)
+		server.globalData.AdminState = test.adminState
+		server.processBfddNotification(test.buf)
+		select {
+		case msg := <-server.NbrConfData.nbrBfdDownCh:
+			if !test.down || msg.NbrIP != test.nbrIP {
+				t.Error(test.name, ": BFD down for", msg.NbrIP, "expected", test.down, test.nbrIP)
+			}
+		default:
+			if test.down {
+				t.Error(test.name, ": no BFD down for", test.nbrIP)
+			}
+		}
+	}
+}
+
+func TestSendBfdDownMsgToNbrFSM(t *testing.T) {
+	server := buildTestBfdServer(t)
+	done := make(chan bool)
+	go func() {
+		// Nbr FSM is not reading, msgs past the channel buffer are dropped
+		for idx := 0; idx <= cap(server.NbrConfData.nbrBfdDownCh); idx++ {
+			server.SendBfdDownMsgToNbrFSM(uint32(idx + 1))
+		}
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BFD down msg blocked on Nbr FSM")
+	}
+	if msg := <-server.NbrConfData.nbrBfdDownCh; msg.NbrIP != 1 {
+		t.Error("BFD down msg for", msg.NbrIP, "expected 1")
+	}
+}
+
+func TestProcessNbrBfdDown(t *testing.T) {
+	server := buildTestBfdServer(t)
+	nbrIP := uint32(0x0a010102)
+	tests := []struct {
+		name       string
+		nbrKey     NbrConfKey
+		nbrIP      uint32
+		bfdSession bool
+		killed     bool
+	}{
+		{"nbr with BFD session", NbrConfKey{NbrIdentity: 1}, nbrIP, true, true},
+		{"nbr without BFD session", NbrConfKey{NbrIdentity: 2}, nbrIP, false, false},
+		{"other nbr", NbrConfKey{NbrIdentity: 3}, nbrIP + 1, true, false},
+	}
+	for _, test := range tests {
+		server.NbrConfMap[test.nbrKey] = NbrConf{
+			NbrIP:        test.nbrIP,
+			BfdSession:   test.bfdSession,
+			NbrDeadTimer: time.NewTimer(time.Hour),
+		}
+	}
+	// Nbr which is going away has no dead timer
+	server.NbrConfMap[NbrConfKey{NbrIdentity: 4}] = NbrConf{NbrIP: nbrIP, BfdSession: true}
+	server.ProcessNbrBfdDown(NbrBfdDownMsg{NbrIP: nbrIP})
+	for _, test := range tests {
+		killed := false
+		select {
+		case <-server.NbrConfMap[test.nbrKey].NbrDeadTimer.C:
+			killed = true
+		case <-time.After(100 * time.Millisecond):
+		}
+		if killed != test.killed {
+			t.Error(test.name, ": dead timer fired", killed, "expected", test.killed)
+		}
+	}
+}
+
+func TestCreateNbrBfdSession(t *testing.T) {
+	server := buildTestBfdServer(t)
+	bfdIntfKey := IntfConfKey{IpAddr: 0x0a010101}
+	intfKey := IntfConfKey{IpAddr: 0x0a020101}
+	tests := []struct {
+		name      string
+		intfKey   IntfConfKey
+		connected bool
+	}{
+		{"BFD disabled on the interface", intfKey, true},
+		{"not connected to bfdd", bfdIntfKey, false},
+	}
+	for _, test := range tests {
+		nbrKey := NbrConfKey{NbrIdentity: 1}
+		server.NbrConfMap[nbrKey] = NbrConf{IntfKey: test.intfKey, NbrIP: 0x0a010102}
+		server.bfddComm.bfddClient.IsConnected = test.connected
+		server.createNbrBfdSession(nbrKey)
+		if server.NbrConfMap[nbrKey].BfdSession {
+			t.Error(test.name, ": BFD session created")
+		}
+	}
+	// Unknown nbr is ignored
+	server.createNbrBfdSession(NbrConfKey{NbrIdentity: 2})
+	if _, exist := server.NbrConfMap[NbrConfKey{NbrIdentity: 2}]; exist {
+		t.Error("Nbr added by the BFD session create")
+	}
+	if err := server.deleteBfdSession(0x0a010102, "fpPort1"); err == nil {
+		t.Error("BFD session deleted without bfdd")
+	}
+}
@@ -163,3 +163,63 @@ func (server *OSPFV2Server) RouteDelFromDB(msg RouteDelMsg) {
 	}
 	return
 }
+
+// Routes installed by the previous instance, these are still in ribd
+// after a graceful restart
+func (server *OSPFV2Server) getRoutesFromDB() map[RoutingTblEntryKey]GlobalRoutingTblEntry {
+	routeMap := make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
+	if server.dbHdl == nil {
+		server.logger.Err("Db Handler is nil")
+		return routeMap
+	}
+	var dbObj objects.Ospfv2RouteState
+	objList, err := server.dbHdl.GetAllObjFromDb(dbObj)
+	if err != nil {
+		server.logger.Err("Failed to get routes from db:", err)
+		return routeMap
+	}
+	for idx := 0; idx < len(objList); idx++ {
+		obj := ospfv2d.NewOspfv2RouteState()
+		dbObj := objList[idx].(objects.Ospfv2RouteState)
+		objects.Convertospfv2dOspfv2RouteStateObjToThrift(&dbObj, obj)
+		// Only network routes are installed in ribd
+		if obj.DestType != "Network" {
+			continue
+		}
+		destId, err := convertDotNotationToUint32(obj.DestId)
+		if err != nil {
+			continue
+		}
+		addrMask, err := convertDotNotationToUint32(obj.AddrMask)
+		if err != nil {
+			continue
+		}
+		areaId, _ := convertDotNotationToUint32(obj.AreaId)
+		rKey := RoutingTblEntryKey{
+			DestId:   destId,
+			AddrMask: addrMask,
+			DestType: Network,
+		}
+		rEnt := GlobalRoutingTblEntry{
+			AreaId: areaId,
+		}
+		rEnt.RoutingTblEnt.Cost = uint16(obj.Cost)
+		rEnt.RoutingTblEnt.Type2Cost = uint16(obj.Type2Cost)
+		rEnt.RoutingTblEnt.NumOfPaths = int(obj.NumOfPaths)
+		rEnt.RoutingTblEnt.NextHops = make(map[NextHop]bool)
+		for _, nh := range obj.NextHops {
+			ifIPAddr, _ := convertDotNotationToUint32(nh.IntfIPAddr)
+			nextHopIP, _ := convertDotNotationToUint32(nh.NextHopIPAddr)
+			advRtr, _ := convertDotNotationToUint32(nh.AdvRtrId)
+			nextHop := NextHop{
+				IfIPAddr:  ifIPAddr,
+				IfIdx:     uint32(nh.IntfIdx),
+				NextHopIP: nextHopIP,
+				AdvRtr:    advRtr,
+			}
+			rEnt.RoutingTblEnt.NextHops[nextHop] = true
+		}
+		routeMap[rKey] = rEnt
+	}
+	return routeMap
+}
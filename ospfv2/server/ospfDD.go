@@ -265,9 +265,10 @@ func (server *OSPFV2Server) BuildAndSendDdBDPkt(nbrConf NbrConf, dbdData NbrDbdD
 
 	ospf := append(ospfEncHdr, dbdDataEnc...)
 	server.logger.Debug("OSPF DBD:", ospf)
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	binary.BigEndian.PutUint64(ospf[16:24], ent.AuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
+	if ospf == nil {
+		return
+	}
 
 	var DstIP net.IP
 
@@ -155,10 +155,31 @@ func (server *OSPFV2Server) sanityCheckSummaryLsa(slsa SummaryLsa, dslsa Summary
 	return discard, op
 }
 
+/* AS External LSAs are not allowed in stub areas and NSSAs,
+   NSSA LSAs are allowed only in NSSAs (RFC 2328 13, RFC 3101 3.5) */
+func (server *OSPFV2Server) isLsaTypeAllowedInArea(lsType uint8, areaId uint32) bool {
+	switch lsType {
+	case ASExternalLSA:
+		isStub, err := server.isStubArea(areaId)
+		if err != nil || isStub {
+			return false
+		}
+	case NSSALSA:
+		isNssa, err := server.isNssaArea(areaId)
+		if err != nil || !isNssa {
+			return false
+		}
+	}
+	return true
+}
+
 func (server *OSPFV2Server) sanityCheckASExternalLsa(alsa ASExternalLsa, dalsa ASExternalLsa, nbr NbrConf, intf IntfConf, exist bool, lsa_max_age bool) (discard bool, op uint8) {
 	discard = false
 	op = LsdbAdd
-	// TODO Reject this lsa if area is configured as stub area.
+	if !server.isLsaTypeAllowedInArea(ASExternalLSA, intf.AreaId) {
+		server.logger.Info(fmt.Sprintln("LSAUPD: As external LSA Discard, stub area.", " nbr ", nbr))
+		return true, LsdbNoAction
+	}
 	send_ack := server.lsAgeCheck(nbr.IntfKey, lsa_max_age, exist)
 	if send_ack {
 		op = LsdbNoAction
@@ -178,6 +199,32 @@ func (server *OSPFV2Server) sanityCheckASExternalLsa(alsa ASExternalLsa, dalsa A
 	return discard, op
 }
 
+func (server *OSPFV2Server) sanityCheckNSSALsa(alsa ASExternalLsa, dalsa ASExternalLsa, nbr NbrConf, intf IntfConf, exist bool, lsa_max_age bool) (discard bool, op uint8) {
+	discard = false
+	op = LsdbAdd
+	if !server.isLsaTypeAllowedInArea(NSSALSA, intf.AreaId) {
+		server.logger.Info(fmt.Sprintln("LSAUPD: NSSA LSA Discard, area is not NSSA.", " nbr ", nbr))
+		return true, LsdbNoAction
+	}
+	send_ack := server.lsAgeCheck(nbr.IntfKey, lsa_max_age, exist)
+	if send_ack {
+		op = LsdbNoAction
+		discard = true
+		server.logger.Info(fmt.Sprintln("LSAUPD: NSSA LSA Discard.", " nbr ", nbr))
+		return discard, op
+	} else {
+		isNew := server.validateLsaIsNew(alsa.LsaMd, dalsa.LsaMd)
+		if isNew {
+			op = FloodLsa
+			discard = false
+		} else {
+			discard = true
+			op = LsdbNoAction
+		}
+	}
+	return discard, op
+}
+
 func validateChecksum(data []byte) bool {
 	csum := computeFletcherChecksum(data[2:], FLETCHER_CHECKSUM_VALIDATE)
 	if csum != 0 {
@@ -242,6 +289,14 @@ func (server *OSPFV2Server) GetLsaByteFromLsaKey(areaId uint32, lsaKey LsaKey) [
 		lsaByte = encodeASExternalLsa(alsa, lsaKey)
 		break
 
+	case NSSALSA:
+		nslsa, valid := server.getNSSALsaFromLsdb(areaId, lsaKey)
+		if valid == LsdbEntryNotFound {
+			return nil
+		}
+		lsaByte = encodeASExternalLsa(nslsa, lsaKey)
+		break
+
 	default:
 		server.logger.Debug("Flood: Invalid lsa type . ", lsaKey)
 		return nil
@@ -274,6 +329,11 @@ func (server *OSPFV2Server) getLsaByteFromLsa(msg LsdbToFloodLSAMsg) []byte {
 			server.logger.Debug("Flood: Retrieved as external  lsa  from lsdb")
 			lsaByte = encodeASExternalLsa(lsa, msg.LsaKey)
 		}
+	case NSSALSA:
+		if lsa, ok := msg.LsaData.(ASExternalLsa); ok {
+			server.logger.Debug("Flood: Retrieved nssa lsa  from lsdb")
+			lsaByte = encodeASExternalLsa(lsa, msg.LsaKey)
+		}
 	default:
 		server.logger.Err("Flood: Invalid LSA type . Not able to decode message from lsdb ", msg.LsaKey)
 	}
@@ -307,7 +367,9 @@ func (server *OSPFV2Server) GetDestIpForFlood(intfKey IntfConfKey, nbrIP uint32,
 			destIp = net.ParseIP(AllDRouters)
 		}
 		destMac, _ = net.ParseMAC(McastMAC)
-	} else if intf.Type == objects.INTF_TYPE_POINT2POINT {
+	} else if intf.Type == objects.INTF_TYPE_POINT2POINT ||
+		intf.Type == objects.INTF_TYPE_VIRTUAL {
+		// Virtual link packets are readdressed to the nbr in SendOspfPkt
 		destIp = net.ParseIP(AllSPFRouters)
 		destMac, _ = net.ParseMAC(ALLSPFROUTERMAC)
 	}
@@ -125,6 +125,9 @@ func (server *OSPFV2Server) ProcessNbrFullFlood(nbrKey NbrConfKey) {
 		return
 	}
 	for lsaKey, _ := range selfOrigEnt {
+		if server.skipVirtualLinkFlood(intf, lsaKey.LSType) {
+			continue
+		}
 		lsa_data := server.GetLsaByteFromLsaKey(lsdbKey.AreaId, lsaKey)
 		if lsa_data != nil {
 			var lsaEncPkt []byte
@@ -339,6 +342,10 @@ func (server *OSPFV2Server) selfGenLsaFloodCheck(nbrKey NbrConfKey, key IntfConf
 	if nbrConf.State >= NbrExchange && nbrConf.IntfKey == key {
 		flood_check = true
 	}
+	intf, _ := server.IntfConfMap[key]
+	if server.skipVirtualLinkFlood(intf, lsType) {
+		flood_check = false
+	}
 	return flood_check
 }
 
@@ -353,6 +360,9 @@ func (server *OSPFV2Server) nbrFloodCheck(nbrKey NbrConfKey, key IntfConfKey, in
 			server.logger.Info(fmt.Sprintln("IF FLOOD: Nbr is DR/BDR.  Dont flood on this interface . nbr - ", nbrKey.NbrIdentity, nbrConf.NbrIP))
 			return false
 		} */
+	if server.skipVirtualLinkFlood(intf, lsType) {
+		return false
+	}
 	flood_check = server.interfaceFloodCheck(key)
 	return flood_check
 }
@@ -494,6 +504,9 @@ func (server *OSPFV2Server) floodASExternalLsa(pkt []byte) {
 			server.logger.Info(fmt.Sprintln("ASBR: Dont flood AS external as area is stub ", intf.AreaId))
 			continue
 		}
+		if server.skipVirtualLinkFlood(intf, ASExternalLSA) {
+			continue
+		}
 		nbrMdata, ok := server.NbrConfData.IntfToNbrMap[key]
 		if ok && len(nbrMdata) > 0 {
 			send_pkt := server.BuildLsaUpdPkt(key, intf, dstMac, dstIp, len(pkt), pkt)
@@ -25,6 +25,7 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"l3/ospfv2/objects"
 )
 
@@ -35,6 +36,9 @@ type GlobalStruct struct {
 	ASBdrRtrStatus     bool
 	ReferenceBandwidth uint32
 	AreaBdrRtrStatus   bool
+	GracefulRestart    bool
+	GracePeriod        uint16
+	GrHelper           bool
 	//isABR             bool
 }
 
@@ -45,7 +49,10 @@ func genOspfv2GlobalUpdateMask(attrset []bool) uint32 {
 		mask = objects.OSPFV2_GLOBAL_UPDATE_ROUTER_ID |
 			objects.OSPFV2_GLOBAL_UPDATE_ADMIN_STATE |
 			objects.OSPFV2_GLOBAL_UPDATE_AS_BDR_RTR_STATUS |
-			objects.OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH
+			objects.OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH |
+			objects.OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART |
+			objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD |
+			objects.OSPFV2_GLOBAL_UPDATE_GR_HELPER
 	} else {
 		for idx, val := range attrset {
 			if true == val {
@@ -60,6 +67,12 @@ func genOspfv2GlobalUpdateMask(attrset []bool) uint32 {
 					mask |= objects.OSPFV2_GLOBAL_UPDATE_AS_BDR_RTR_STATUS
 				case 4:
 					mask |= objects.OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH
+				case 5:
+					mask |= objects.OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART
+				case 6:
+					mask |= objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD
+				case 7:
+					mask |= objects.OSPFV2_GLOBAL_UPDATE_GR_HELPER
 				}
 			}
 		}
@@ -67,8 +80,34 @@ func genOspfv2GlobalUpdateMask(attrset []bool) uint32 {
 	return mask
 }
 
+func validateGracePeriod(gracePeriod uint16) error {
+	if gracePeriod < objects.GR_MIN_GRACE_PERIOD ||
+		gracePeriod > objects.GR_MAX_GRACE_PERIOD {
+		return errors.New(fmt.Sprintln("Invalid GracePeriod, valid range is",
+			objects.GR_MIN_GRACE_PERIOD, "-", objects.GR_MAX_GRACE_PERIOD))
+	}
+	return nil
+}
+
 func (server *OSPFV2Server) updateGlobal(newCfg, oldCfg *objects.Ospfv2Global, attrset []bool) (bool, error) {
 	server.logger.Info("Global configuration update")
+	mask := genOspfv2GlobalUpdateMask(attrset)
+	if mask&objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD == objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD &&
+		newCfg.GracefulRestart == true {
+		err := validateGracePeriod(newCfg.GracePeriod)
+		if err != nil {
+			return false, err
+		}
+	}
+	grMask := uint32(objects.OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART |
+		objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD |
+		objects.OSPFV2_GLOBAL_UPDATE_GR_HELPER)
+	if mask&^grMask == 0 {
+		// Graceful restart parameters are used only on restart or when
+		// a grace LSA is received, no need to restart the protocol
+		server.updateGlobalGracefulRestart(newCfg, mask)
+		return true, nil
+	}
 	if server.globalData.AdminState == true {
 		server.StopAllIntfFSM()
 		//Stop Rx Pkt
@@ -98,7 +137,6 @@ func (server *OSPFV2Server) updateGlobal(newCfg, oldCfg *objects.Ospfv2Global, a
 		}
 	}
 
-	mask := genOspfv2GlobalUpdateMask(attrset)
 	if mask&objects.OSPFV2_GLOBAL_UPDATE_ADMIN_STATE == objects.OSPFV2_GLOBAL_UPDATE_ADMIN_STATE {
 		server.globalData.AdminState = newCfg.AdminState
 	}
@@ -111,6 +149,7 @@ func (server *OSPFV2Server) updateGlobal(newCfg, oldCfg *objects.Ospfv2Global, a
 	if mask&objects.OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH == objects.OSPFV2_GLOBAL_UPDATE_REFERENCE_BANDWIDTH {
 		server.globalData.ReferenceBandwidth = newCfg.ReferenceBandwidth
 	}
+	server.updateGlobalGracefulRestart(newCfg, mask)
 
 	if server.globalData.AdminState == true {
 		err := server.initAsicdForRxMulticastPkt()
@@ -151,11 +190,29 @@ func (server *OSPFV2Server) createGlobal(cfg *objects.Ospfv2Global) (bool, error
 		server.logger.Err("Vrf other than default is not supported")
 		return false, errors.New("Vrf other than default is not supported")
 	}
+	if cfg.GracePeriod == 0 {
+		cfg.GracePeriod = objects.GR_DEFAULT_GRACE_PERIOD
+	}
+	if cfg.GracefulRestart == true {
+		err := validateGracePeriod(cfg.GracePeriod)
+		if err != nil {
+			server.logger.Err(err)
+			return false, err
+		}
+	}
 	server.globalData.Vrf = cfg.Vrf
 	server.globalData.AdminState = cfg.AdminState
 	server.globalData.RouterId = cfg.RouterId
 	server.globalData.ASBdrRtrStatus = cfg.ASBdrRtrStatus
 	server.globalData.ReferenceBandwidth = cfg.ReferenceBandwidth
+	server.globalData.GracefulRestart = cfg.GracefulRestart
+	server.globalData.GracePeriod = cfg.GracePeriod
+	server.globalData.GrHelper = cfg.GrHelper
+	if server.grData.Status == objects.GR_STATUS_PLANNED_RESTART &&
+		server.grData.RouterId != cfg.RouterId {
+		server.logger.Err("Router Id changed across restart, aborting graceful restart")
+		server.SendMsgToLsdbForGracefulRestartExit(objects.GR_EXIT_REASON_TOPOLOGY_CHANGED)
+	}
 	if server.globalData.AdminState == true {
 		err := server.initAsicdForRxMulticastPkt()
 		if err != nil {
@@ -192,7 +249,9 @@ func (server *OSPFV2Server) getGlobalState(vrf string) (*objects.Ospfv2GlobalSta
 	for _, intfEnt := range server.IntfConfMap {
 		numOfNbrs += len(intfEnt.NbrMap)
 	}
+	numOfNssaLsa := 0
 	for _, lsdbEnt := range server.LsdbData.AreaLsdb {
+		numOfNssaLsa += len(lsdbEnt.NSSALsaMap)
 		retObj.NumOfRouterLSA += uint32(len(lsdbEnt.RouterLsaMap))
 		retObj.NumOfNetworkLSA += uint32(len(lsdbEnt.NetworkLsaMap))
 		retObj.NumOfSummary3LSA += uint32(len(lsdbEnt.Summary3LsaMap))
@@ -201,8 +260,11 @@ func (server *OSPFV2Server) getGlobalState(vrf string) (*objects.Ospfv2GlobalSta
 	}
 	retObj.NumOfLSA = retObj.NumOfRouterLSA + retObj.NumOfNetworkLSA +
 		retObj.NumOfSummary3LSA + retObj.NumOfSummary4LSA +
-		retObj.NumOfASExternalLSA
+		retObj.NumOfASExternalLSA + uint32(numOfNssaLsa)
 	//TODO: num of routes
+	retObj.RestartStatus = server.grData.Status
+	retObj.RestartAge = server.getGracefulRestartAge()
+	retObj.RestartExitReason = server.grData.ExitReason
 	return &retObj, nil
 }
 
@@ -0,0 +1,499 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"l3/ospfv2/objects"
+	"net"
+	"os"
+	"time"
+)
+
+// Grace LSA (RFC 3623 Appendix A) is a link local opaque LSA with opaque
+// type 3. It carries the grace period, restart reason and, on broadcast
+// networks, the IP interface address TLVs.
+const (
+	GRACE_LSA_TLV_HDR_LEN = 4
+	GRACE_LSA_BODY_LEN    = 24
+	GRACE_LSA_LEN         = OSPF_LSA_HEADER_SIZE + GRACE_LSA_BODY_LEN
+)
+
+type GraceLsa struct {
+	LsaMd       LsaMetadata
+	GracePeriod uint32
+	Reason      uint8
+	IntfIpAddr  uint32
+}
+
+type GracefulRestartStruct struct {
+	Status           uint8
+	ExitReason       uint8
+	RouterId         uint32
+	GracePeriod      uint16
+	StartTime        time.Time
+	GraceTimer       *time.Timer
+	NbrMap           map[uint32]bool //Key Nbr Router Id
+	PreRestartRoutes map[RoutingTblEntryKey]GlobalRoutingTblEntry
+}
+
+// Planned restart state written before restart
+type GracefulRestartFileData struct {
+	RouterId    uint32
+	GracePeriod uint16
+	StartTime   time.Time
+	NbrList     []uint32
+}
+
+func getGraceLsaKey(routerId uint32) LsaKey {
+	return LsaKey{
+		LSType:    LocalOpaqueLSA,
+		LSId:      uint32(GRACE_LSA_OPAQUE_TYPE) << 24,
+		AdvRouter: routerId,
+	}
+}
+
+func encodeGraceLsa(lsa GraceLsa, lsaKey LsaKey) []byte {
+	graceLsa := make([]byte, GRACE_LSA_LEN)
+	lsaHdr := encodeLsaHeader(lsa.LsaMd, lsaKey)
+	copy(graceLsa[0:20], lsaHdr)
+	tlv := graceLsa[OSPF_LSA_HEADER_SIZE:]
+	binary.BigEndian.PutUint16(tlv[0:2], GRACE_LSA_TLV_GRACE_PERIOD)
+	binary.BigEndian.PutUint16(tlv[2:4], 4)
+	binary.BigEndian.PutUint32(tlv[4:8], lsa.GracePeriod)
+	// Value is padded to 4 bytes
+	binary.BigEndian.PutUint16(tlv[8:10], GRACE_LSA_TLV_RESTART_REASON)
+	binary.BigEndian.PutUint16(tlv[10:12], 1)
+	tlv[12] = lsa.Reason
+	binary.BigEndian.PutUint16(tlv[16:18], GRACE_LSA_TLV_IP_INTF_ADDR)
+	binary.BigEndian.PutUint16(tlv[18:20], 4)
+	binary.BigEndian.PutUint32(tlv[20:24], lsa.IntfIpAddr)
+	return graceLsa
+}
+
+func decodeGraceLsa(data []byte, lsa *GraceLsa, lsaKey *LsaKey) error {
+	if len(data) < OSPF_LSA_HEADER_SIZE {
+		return errors.New("Invalid grace LSA length")
+	}
+	lsa.LsaMd.LSAge = binary.BigEndian.Uint16(data[0:2])
+	lsa.LsaMd.Options = uint8(data[2])
+	lsaKey.LSType = uint8(data[3])
+	lsaKey.LSId = binary.BigEndian.Uint32(data[4:8])
+	lsaKey.AdvRouter = binary.BigEndian.Uint32(data[8:12])
+	lsa.LsaMd.LSSequenceNum = int(binary.BigEndian.Uint32(data[12:16]))
+	lsa.LsaMd.LSChecksum = binary.BigEndian.Uint16(data[16:18])
+	lsa.LsaMd.LSLen = binary.BigEndian.Uint16(data[18:20])
+	if uint8(lsaKey.LSId>>24) != GRACE_LSA_OPAQUE_TYPE {
+		return errors.New("Not a grace LSA")
+	}
+	if int(lsa.LsaMd.LSLen) > len(data) {
+		return errors.New("Invalid grace LSA length")
+	}
+	gracePeriodFound := false
+	idx := OSPF_LSA_HEADER_SIZE
+	for idx+GRACE_LSA_TLV_HDR_LEN <= int(lsa.LsaMd.LSLen) {
+		tlvType := binary.BigEndian.Uint16(data[idx : idx+2])
+		tlvLen := int(binary.BigEndian.Uint16(data[idx+2 : idx+4]))
+		val := idx + GRACE_LSA_TLV_HDR_LEN
+		if val+tlvLen > int(lsa.LsaMd.LSLen) {
+			return errors.New("Invalid grace LSA TLV length")
+		}
+		switch tlvType {
+		case GRACE_LSA_TLV_GRACE_PERIOD:
+			if tlvLen == 4 {
+				lsa.GracePeriod = binary.BigEndian.Uint32(data[val : val+4])
+				gracePeriodFound = true
+			}
+		case GRACE_LSA_TLV_RESTART_REASON:
+			if tlvLen == 1 {
+				lsa.Reason = data[val]
+			}
+		case GRACE_LSA_TLV_IP_INTF_ADDR:
+			if tlvLen == 4 {
+				lsa.IntfIpAddr = binary.BigEndian.Uint32(data[val : val+4])
+			}
+		}
+		idx = val + (tlvLen+3)/4*4
+	}
+	if !gracePeriodFound {
+		return errors.New("Grace period TLV missing in grace LSA")
+	}
+	return nil
+}
+
+func (server *OSPFV2Server) isGracefulRestartInProgress() bool {
+	return server.grData.Status == objects.GR_STATUS_PLANNED_RESTART
+}
+
+// Remaining time of the grace period in seconds
+func (server *OSPFV2Server) getGracefulRestartAge() uint32 {
+	if !server.isGracefulRestartInProgress() {
+		return 0
+	}
+	remaining := time.Duration(server.grData.GracePeriod)*time.Second - time.Since(server.grData.StartTime)
+	if remaining < 0 {
+		return 0
+	}
+	return uint32(remaining.Seconds())
+}
+
+func (server *OSPFV2Server) updateGlobalGracefulRestart(newCfg *objects.Ospfv2Global, mask uint32) {
+	if mask&objects.OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART == objects.OSPFV2_GLOBAL_UPDATE_GRACEFUL_RESTART {
+		server.globalData.GracefulRestart = newCfg.GracefulRestart
+	}
+	if mask&objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD == objects.OSPFV2_GLOBAL_UPDATE_GRACE_PERIOD {
+		server.globalData.GracePeriod = newCfg.GracePeriod
+		if server.globalData.GracePeriod == 0 {
+			server.globalData.GracePeriod = objects.GR_DEFAULT_GRACE_PERIOD
+		}
+	}
+	if mask&objects.OSPFV2_GLOBAL_UPDATE_GR_HELPER == objects.OSPFV2_GLOBAL_UPDATE_GR_HELPER {
+		server.globalData.GrHelper = newCfg.GrHelper
+	}
+}
+
+// Send grace LSA to all the neighbors on the given interface. lsAge set
+// to MAX_AGE flushes the grace LSA from the helpers.
+func (server *OSPFV2Server) sendGraceLsa(intfKey IntfConfKey, gracePeriod uint16, lsAge uint16) error {
+	intf, exist := server.IntfConfMap[intfKey]
+	if !exist {
+		return errors.New("Intf does not exist")
+	}
+	options, err := server.getAreaOptions(intf.AreaId)
+	if err != nil {
+		return err
+	}
+	lsaKey := getGraceLsaKey(server.globalData.RouterId)
+	lsa := GraceLsa{
+		LsaMd: LsaMetadata{
+			LSAge:         lsAge,
+			Options:       options,
+			LSSequenceNum: int(InitialSequenceNum),
+			LSChecksum:    0,
+			LSLen:         uint16(GRACE_LSA_LEN),
+		},
+		GracePeriod: uint32(gracePeriod),
+		Reason:      GR_REASON_SW_RESTART,
+		IntfIpAddr:  intf.IpAddr,
+	}
+	checksumOffset := uint16(14)
+	lsaEnc := encodeGraceLsa(lsa, lsaKey)
+	lsa.LsaMd.LSChecksum = computeFletcherChecksum(lsaEnc[2:], checksumOffset)
+	lsaEnc = encodeGraceLsa(lsa, lsaKey)
+
+	lsaUpdEnc := make([]byte, OSPF_NO_OF_LSA_FIELD)
+	binary.BigEndian.PutUint32(lsaUpdEnc[0:4], 1)
+	lsaUpdEnc = append(lsaUpdEnc, lsaEnc...)
+	dstIp := net.ParseIP(AllSPFRouters)
+	dstMac, _ := net.ParseMAC(ALLSPFROUTERMAC)
+	pkt := server.BuildLsaUpdPkt(intfKey, intf, dstMac, dstIp, len(lsaUpdEnc), lsaUpdEnc)
+	return server.SendOspfPkt(intfKey, pkt)
+}
+
+// Called before a planned restart. Grace LSAs are sent on all the
+// interfaces with full adjacencies and the restart state is saved so
+// that the next instance keeps the routes. Returns false if the restart
+// can not be graceful.
+func (server *OSPFV2Server) prepareGracefulRestart() bool {
+	if server.globalData.AdminState == false ||
+		server.globalData.GracefulRestart == false {
+		return false
+	}
+	nbrList := []uint32{}
+	intfMap := make(map[IntfConfKey]bool)
+	for _, nbrConf := range server.NbrConfMap {
+		if nbrConf.State != NbrFull {
+			continue
+		}
+		nbrList = append(nbrList, nbrConf.NbrRtrId)
+		intfMap[nbrConf.IntfKey] = true
+	}
+	if len(nbrList) == 0 {
+		server.logger.Info("No full adjacency, restart will not be graceful")
+		return false
+	}
+	grFileData := GracefulRestartFileData{
+		RouterId:    server.globalData.RouterId,
+		GracePeriod: server.globalData.GracePeriod,
+		StartTime:   time.Now(),
+		NbrList:     nbrList,
+	}
+	data, err := json.Marshal(grFileData)
+	if err != nil {
+		server.logger.Err("Unable to encode graceful restart state:", err)
+		return false
+	}
+	err = ioutil.WriteFile(server.paramsDir+"/"+GR_STATE_FILE, data, 0644)
+	if err != nil {
+		server.logger.Err("Unable to save graceful restart state:", err)
+		return false
+	}
+	for intfKey, _ := range intfMap {
+		err := server.sendGraceLsa(intfKey, server.globalData.GracePeriod, 0)
+		if err != nil {
+			server.logger.Err("Unable to send grace LSA on", intfKey, err)
+		}
+	}
+	server.logger.Info("Prepared for graceful restart, grace period:",
+		server.globalData.GracePeriod, "nbrs:", nbrList)
+	return true
+}
+
+// Called on startup, picks up the state saved by prepareGracefulRestart
+func (server *OSPFV2Server) initGracefulRestart() {
+	server.grData.Status = objects.GR_STATUS_NOT_RESTARTING
+	server.grData.ExitReason = objects.GR_EXIT_REASON_NONE
+	grFile := server.paramsDir + "/" + GR_STATE_FILE
+	data, err := ioutil.ReadFile(grFile)
+	if err != nil {
+		return
+	}
+	// State is valid for a single restart only
+	os.Remove(grFile)
+	var grFileData GracefulRestartFileData
+	err = json.Unmarshal(data, &grFileData)
+	if err != nil {
+		server.logger.Err("Unable to decode graceful restart state:", err)
+		return
+	}
+	gracePeriod := time.Duration(grFileData.GracePeriod) * time.Second
+	elapsed := time.Since(grFileData.StartTime)
+	if elapsed >= gracePeriod || len(grFileData.NbrList) == 0 {
+		server.logger.Info("Grace period expired, restart is not graceful")
+		return
+	}
+	server.grData.Status = objects.GR_STATUS_PLANNED_RESTART
+	server.grData.ExitReason = objects.GR_EXIT_REASON_IN_PROGRESS
+	server.grData.RouterId = grFileData.RouterId
+	server.grData.GracePeriod = grFileData.GracePeriod
+	server.grData.StartTime = grFileData.StartTime
+	server.grData.NbrMap = make(map[uint32]bool)
+	for _, nbrRtrId := range grFileData.NbrList {
+		server.grData.NbrMap[nbrRtrId] = true
+	}
+	server.grData.PreRestartRoutes = server.getRoutesFromDB()
+	server.grData.GraceTimer = time.AfterFunc(gracePeriod-elapsed, func() {
+		server.SendMsgToLsdbForGracefulRestartExit(objects.GR_EXIT_REASON_TIMED_OUT)
+	})
+	// Kept past the grace period so that ribd does not remove the routes
+	// before the exit installs them again
+	server.sendStaleRouteHold(gracePeriod - elapsed + EndOfRIBQuietTime)
+	server.logger.Info("Graceful restart in progress, pre restart nbrs:",
+		grFileData.NbrList, "routes:", len(server.grData.PreRestartRoutes))
+}
+
+// All the pre restart neighbors are full again
+func (server *OSPFV2Server) checkGracefulRestartDone() {
+	if !server.isGracefulRestartInProgress() {
+		return
+	}
+	for nbrRtrId, _ := range server.grData.NbrMap {
+		isFull := false
+		for _, nbrConf := range server.NbrConfMap {
+			if nbrConf.NbrRtrId == nbrRtrId &&
+				nbrConf.State == NbrFull {
+				isFull = true
+				break
+			}
+		}
+		if !isFull {
+			return
+		}
+	}
+	server.SendMsgToLsdbForGracefulRestartExit(objects.GR_EXIT_REASON_COMPLETED)
+}
+
+// Router LSA of a pre restart neighbor should still have a link to us,
+// otherwise the topology has changed during the restart (RFC 3623 2.2)
+func (server *OSPFV2Server) checkGracefulRestartConsistency(areaId uint32, lsa RouterLsa, lsaKey LsaKey) {
+	if !server.isGracefulRestartInProgress() {
+		return
+	}
+	_, exist := server.grData.NbrMap[lsaKey.AdvRouter]
+	if !exist || lsa.LsaMd.LSAge == MAX_AGE {
+		return
+	}
+	for _, link := range lsa.LinkDetails {
+		switch link.LinkType {
+		case P2P_LINK, VIRTUAL_LINK:
+			if link.LinkId == server.globalData.RouterId {
+				return
+			}
+		case TRANSIT_LINK:
+			for _, intf := range server.IntfConfMap {
+				if intf.AreaId == areaId &&
+					intf.Type == objects.INTF_TYPE_BROADCAST &&
+					link.LinkId&intf.Netmask == intf.IpAddr&intf.Netmask {
+					return
+				}
+			}
+		}
+	}
+	server.logger.Info("Router LSA of nbr", lsaKey.AdvRouter, "has no link to us")
+	server.SendMsgToLsdbForGracefulRestartExit(objects.GR_EXIT_REASON_TOPOLOGY_CHANGED)
+}
+
+// Flush the self originated LSAs of the previous instance which were
+// not originated again once the restart is over
+func (server *OSPFV2Server) flushStaleSelfOrigLsa() bool {
+	flag := false
+	for lsdbKey, lsdbEnt := range server.LsdbData.AreaLsdb {
+		selfOrigLsaEnt, _ := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+		for lsaKey, lsaEnt := range lsdbEnt.RouterLsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.RouterLsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		for lsaKey, lsaEnt := range lsdbEnt.NetworkLsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.NetworkLsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		for lsaKey, lsaEnt := range lsdbEnt.Summary3LsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.Summary3LsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		for lsaKey, lsaEnt := range lsdbEnt.Summary4LsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.Summary4LsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		for lsaKey, lsaEnt := range lsdbEnt.ASExternalLsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.ASExternalLsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		for lsaKey, lsaEnt := range lsdbEnt.NSSALsaMap {
+			if lsaKey.AdvRouter == server.globalData.RouterId && !selfOrigLsaEnt[lsaKey] {
+				flag = true
+				delete(lsdbEnt.NSSALsaMap, lsaKey)
+				lsaEnt.LsaMd.LSAge = MAX_AGE
+				server.CreateAndSendMsgFromLsdbToFloodLsa(lsdbKey.AreaId, lsaKey, lsaEnt)
+			}
+		}
+		server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
+	}
+	if flag {
+		server.RefreshLsdbSlice()
+	}
+	return flag
+}
+
+// Runs in the LSDB routine. LSA origination and route installation which
+// were held back during the restart are done here.
+func (server *OSPFV2Server) processGracefulRestartExit(reason uint8) {
+	if !server.isGracefulRestartInProgress() {
+		return
+	}
+	server.logger.Info("Exiting graceful restart, reason:", reason)
+	if server.grData.GraceTimer != nil {
+		server.grData.GraceTimer.Stop()
+		server.grData.GraceTimer = nil
+	}
+	server.grData.Status = objects.GR_STATUS_NOT_RESTARTING
+	server.grData.ExitReason = reason
+	server.grData.NbrMap = nil
+	preRestartRoutes := server.grData.PreRestartRoutes
+	server.grData.PreRestartRoutes = nil
+	if server.globalData.AdminState == false {
+		return
+	}
+
+	// Flush the grace LSAs so that the helpers exit helper mode
+	for intfKey, intf := range server.IntfConfMap {
+		if intf.AdminState == false ||
+			intf.OperState == false {
+			continue
+		}
+		err := server.sendGraceLsa(intfKey, 0, MAX_AGE)
+		if err != nil {
+			server.logger.Err("Unable to flush grace LSA on", intfKey, err)
+		}
+	}
+
+	// Routes still installed in ribd are compared against the new
+	// routing table, only the routes which changed are updated
+	if server.RoutingTblData.GlobalRoutingTbl != nil {
+		for rKey, rEnt := range preRestartRoutes {
+			server.RoutingTblData.GlobalRoutingTbl[rKey] = rEnt
+		}
+	}
+
+	for areaId, areaEnt := range server.AreaConfMap {
+		if areaEnt.AdminState == false {
+			continue
+		}
+		msg := GenerateRouterLSAMsg{
+			AreaId: areaId,
+		}
+		err := server.GenerateRouterLSA(msg)
+		if err != nil {
+			server.logger.Err("Unable to generate router LSA for area", areaId, err)
+		}
+		server.GenerateAllASExternalLSA(areaId)
+		server.GenerateAllNSSALSA(areaId)
+	}
+	for intfKey, intf := range server.IntfConfMap {
+		if intf.DRtrId != server.globalData.RouterId {
+			continue
+		}
+		nbrList, err := server.getFullNbrList(intfKey)
+		if err != nil {
+			continue
+		}
+		msg := UpdateSelfNetworkLSAMsg{
+			Op:      GENERATE,
+			IntfKey: intfKey,
+			NbrList: nbrList,
+		}
+		server.processUpdateSelfNetworkLSA(msg)
+	}
+	server.CalcSPFAndRoutingTbl()
+	if server.flushStaleSelfOrigLsa() {
+		server.CalcSPFAndRoutingTbl()
+	}
+	// Routes are installed again, ribd can remove the ones which are gone
+	if !server.LsdbData.EndOfRIBSent {
+		server.sendRoutesEndOfRIB()
+	}
+}
@@ -0,0 +1,272 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"l3/ospfv2/objects"
+	"os"
+	"testing"
+	"time"
+)
+
+// Restarted router, with the state saved before the restart picked up the
+// way the daemon does on startup
+func buildTestGrServer(t *testing.T, nbrList []uint32) *OSPFV2Server {
+	server := newTestServer(t)
+	paramsDir, err := ioutil.TempDir("", "ospfgr")
+	if err != nil {
+		t.Fatal("Failed to create params dir, err:", err)
+	}
+	defer os.RemoveAll(paramsDir)
+	server.paramsDir = paramsDir
+	data, _ := json.Marshal(GracefulRestartFileData{
+		RouterId:    testRouterId,
+		GracePeriod: objects.GR_DEFAULT_GRACE_PERIOD,
+		StartTime:   time.Now(),
+		NbrList:     nbrList,
+	})
+	if err := ioutil.WriteFile(paramsDir+"/"+GR_STATE_FILE, data, 0644); err != nil {
+		t.Fatal("Failed to save graceful restart state, err:", err)
+	}
+	server.initGracefulRestart()
+	if !server.isGracefulRestartInProgress() {
+		t.Fatal("Graceful restart not in progress")
+	}
+	server.grData.GraceTimer.Stop()
+	return server
+}
+
+func getTestGrExitReason(server *OSPFV2Server) (uint8, bool) {
+	select {
+	case reason := <-server.MessagingChData.ServerToLsdbChData.GracefulRestartExitCh:
+		return reason, true
+	default:
+		return 0, false
+	}
+}
+
+func TestEncodeDecodeGraceLsa(t *testing.T) {
+	lsaKey := getGraceLsaKey(testRouterId)
+	lsa := GraceLsa{
+		LsaMd: LsaMetadata{
+			LSAge:         10,
+			Options:       2,
+			LSSequenceNum: int(InitialSequenceNum),
+			LSLen:         uint16(GRACE_LSA_LEN),
+		},
+		GracePeriod: 300,
+		Reason:      GR_REASON_SW_RESTART,
+		IntfIpAddr:  0x0a000001,
+	}
+	lsaEnc := encodeGraceLsa(lsa, lsaKey)
+	if len(lsaEnc) != GRACE_LSA_LEN {
+		t.Fatal("Grace LSA length", len(lsaEnc))
+	}
+	var decLsa GraceLsa
+	var decLsaKey LsaKey
+	if err := decodeGraceLsa(lsaEnc, &decLsa, &decLsaKey); err != nil {
+		t.Fatal("Failed to decode grace LSA, err:", err)
+	}
+	if decLsa != lsa || decLsaKey != lsaKey {
+		t.Error("Decoded grace LSA", decLsa, decLsaKey, "expected", lsa, lsaKey)
+	}
+
+	notGraceLsa := append([]byte{}, lsaEnc...)
+	notGraceLsa[4] = 1
+	noGracePeriod := append([]byte{}, lsaEnc...)
+	binary.BigEndian.PutUint16(noGracePeriod[OSPF_LSA_HEADER_SIZE:], 0xff)
+	badTlvLen := append([]byte{}, lsaEnc...)
+	binary.BigEndian.PutUint16(badTlvLen[OSPF_LSA_HEADER_SIZE+18:], 8)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"shorter than the header", lsaEnc[:OSPF_LSA_HEADER_SIZE-1]},
+		{"not a grace LSA", notGraceLsa},
+		{"truncated", lsaEnc[:GRACE_LSA_LEN-4]},
+		{"grace period missing", noGracePeriod},
+		{"TLV past the LSA", badTlvLen},
+	}
+	for _, test := range tests {
+		if err := decodeGraceLsa(test.data, &decLsa, &decLsaKey); err == nil {
+			t.Error(test.name, ": decoded")
+		}
+	}
+}
+
+func TestGetGracefulRestartAge(t *testing.T) {
+	server := buildTestGrServer(t, []uint32{1})
+	tests := []struct {
+		name    string
+		status  uint8
+		elapsed time.Duration
+		age     uint32
+	}{
+		{"not restarting", objects.GR_STATUS_NOT_RESTARTING, 0, 0},
+		{"restart started", objects.GR_STATUS_PLANNED_RESTART, 0, 119},
+		{"restart in progress", objects.GR_STATUS_PLANNED_RESTART, 30 * time.Second, 89},
+		{"grace period over", objects.GR_STATUS_PLANNED_RESTART, 200 * time.Second, 0},
+	}
+	for _, test := range tests {
+		server.grData.Status = test.status
+		server.grData.StartTime = time.Now().Add(-test.elapsed)
+		// Allow for a tick of the clock
+		if age := server.getGracefulRestartAge(); age != test.age && age != test.age+1 {
+			t.Error(test.name, ": age", age, "expected", test.age)
+		}
+	}
+}
+
+func TestInitGracefulRestart(t *testing.T) {
+	paramsDir, err := ioutil.TempDir("", "ospfgr")
+	if err != nil {
+		t.Fatal("Failed to create params dir, err:", err)
+	}
+	defer os.RemoveAll(paramsDir)
+	grFile := paramsDir + "/" + GR_STATE_FILE
+	tests := []struct {
+		name       string
+		grFileData *GracefulRestartFileData
+		restarting bool
+	}{
+		{"no saved state", nil, false},
+		{"grace period expired", &GracefulRestartFileData{RouterId: testRouterId, GracePeriod: 60, StartTime: time.Now().Add(-time.Minute), NbrList: []uint32{1}}, false},
+		{"no neighbors", &GracefulRestartFileData{RouterId: testRouterId, GracePeriod: 60, StartTime: time.Now()}, false},
+		{"planned restart", &GracefulRestartFileData{RouterId: testRouterId, GracePeriod: 1800, StartTime: time.Now().Add(-time.Minute), NbrList: []uint32{1, 2}}, true},
+	}
+	for _, test := range tests {
+		server := newTestServer(t)
+		server.paramsDir = paramsDir
+		if test.grFileData != nil {
+			data, _ := json.Marshal(test.grFileData)
+			if err := ioutil.WriteFile(grFile, data, 0644); err != nil {
+				t.Fatal("Failed to save graceful restart state, err:", err)
+			}
+		}
+		server.initGracefulRestart()
+		if _, err := os.Stat(grFile); err == nil {
+			t.Error(test.name, ": graceful restart state not removed")
+			os.Remove(grFile)
+		}
+		if server.isGracefulRestartInProgress() != test.restarting {
+			t.Error(test.name, ": restarting", server.isGracefulRestartInProgress(), "expected", test.restarting)
+			continue
+		}
+		if !test.restarting {
+			continue
+		}
+		server.grData.GraceTimer.Stop()
+		if len(server.grData.NbrMap) != len(test.grFileData.NbrList) ||
+			server.grData.ExitReason != objects.GR_EXIT_REASON_IN_PROGRESS {
+			t.Error(test.name, ": unexpected restart state", server.grData)
+		}
+		// The grace period is not cut short by the default of 120 seconds
+		if age := server.getGracefulRestartAge(); age < 1700 {
+			t.Error(test.name, ": remaining grace period", age)
+		}
+	}
+}
+
+func TestCheckGracefulRestartDone(t *testing.T) {
+	server := buildTestGrServer(t, []uint32{1, 2})
+	server.NbrConfMap[NbrConfKey{NbrIdentity: 0x0a000001}] = NbrConf{NbrRtrId: 1, State: NbrFull}
+	server.NbrConfMap[NbrConfKey{NbrIdentity: 0x0a000002}] = NbrConf{NbrRtrId: 2, State: NbrExchange}
+	server.NbrConfMap[NbrConfKey{NbrIdentity: 0x0a000003}] = NbrConf{NbrRtrId: 3, State: NbrDown}
+	server.checkGracefulRestartDone()
+	if reason, exit := getTestGrExitReason(server); exit {
+		t.Error("Restart done before all the neighbors are full, reason:", reason)
+	}
+	server.NbrConfMap[NbrConfKey{NbrIdentity: 0x0a000002}] = NbrConf{NbrRtrId: 2, State: NbrFull}
+	server.checkGracefulRestartDone()
+	if reason, exit := getTestGrExitReason(server); !exit || reason != objects.GR_EXIT_REASON_COMPLETED {
+		t.Error("Restart not done with all the neighbors full, reason:", reason)
+	}
+	server.grData.Status = objects.GR_STATUS_NOT_RESTARTING
+	server.checkGracefulRestartDone()
+	if reason, exit := getTestGrExitReason(server); exit {
+		t.Error("Restart done while not restarting, reason:", reason)
+	}
+}
+
+func TestCheckGracefulRestartConsistency(t *testing.T) {
+	server := buildTestGrServer(t, []uint32{1})
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 0, ImportASExtern: true})
+	createTestIntf(t, server, 1, 0xffffff00, objects.Ospfv2Intf{IpAddress: 0x0a000005, Type: objects.INTF_TYPE_BROADCAST})
+	nbrLsaKey := LsaKey{LSType: RouterLSA, LSId: 1, AdvRouter: 1}
+	tests := []struct {
+		name    string
+		lsaKey  LsaKey
+		lsa     RouterLsa
+		changed bool
+	}{
+		{"p2p link to us", nbrLsaKey, RouterLsa{LinkDetails: []LinkDetail{
+			LinkDetail{LinkType: P2P_LINK, LinkId: testRouterId},
+		}}, false},
+		{"transit link on our network", nbrLsaKey, RouterLsa{LinkDetails: []LinkDetail{
+			LinkDetail{LinkType: TRANSIT_LINK, LinkId: 0x0a000001},
+		}}, false},
+		{"no link to us", nbrLsaKey, RouterLsa{LinkDetails: []LinkDetail{
+			LinkDetail{LinkType: P2P_LINK, LinkId: 7},
+			LinkDetail{LinkType: TRANSIT_LINK, LinkId: 0x0b000001},
+		}}, true},
+		{"flushed router lsa", nbrLsaKey, RouterLsa{LsaMd: LsaMetadata{LSAge: MAX_AGE}}, false},
+		{"not a pre restart neighbor", LsaKey{LSType: RouterLSA, LSId: 9, AdvRouter: 9}, RouterLsa{}, false},
+	}
+	for _, test := range tests {
+		server.checkGracefulRestartConsistency(0, test.lsa, test.lsaKey)
+		reason, exit := getTestGrExitReason(server)
+		if exit != test.changed || (exit && reason != objects.GR_EXIT_REASON_TOPOLOGY_CHANGED) {
+			t.Error(test.name, ": exit", exit, "reason", reason, "expected", test.changed)
+		}
+	}
+}
+
+func TestGracefulRestartGlobalConfig(t *testing.T) {
+	server := buildTestGrServer(t, []uint32{1})
+	cfg := objects.Ospfv2Global{Vrf: "default", RouterId: testRouterId, GracefulRestart: true, GracePeriod: objects.GR_MAX_GRACE_PERIOD + 1}
+	if _, err := server.createGlobal(&cfg); err == nil {
+		t.Error("Global config created with an invalid grace period")
+	}
+	// Restored config with the same router id, restart goes on
+	cfg.GracePeriod = 0
+	if _, err := server.createGlobal(&cfg); err != nil {
+		t.Fatal("Failed to create global config, err:", err)
+	}
+	if server.globalData.GracePeriod != objects.GR_DEFAULT_GRACE_PERIOD {
+		t.Error("Grace period", server.globalData.GracePeriod, "expected the default")
+	}
+	if reason, exit := getTestGrExitReason(server); exit {
+		t.Error("Restart aborted with the router id unchanged, reason:", reason)
+	}
+	cfg.RouterId = testRouterId + 1
+	if _, err := server.createGlobal(&cfg); err != nil {
+		t.Fatal("Failed to create global config, err:", err)
+	}
+	if reason, exit := getTestGrExitReason(server); !exit || reason != objects.GR_EXIT_REASON_TOPOLOGY_CHANGED {
+		t.Error("Restart not aborted on a router id change, reason:", reason)
+	}
+}
@@ -26,7 +26,6 @@ package server
 import (
 	//"fmt"
 	//    "bytes"
-	"encoding/binary"
 	"errors"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
@@ -62,21 +61,14 @@ func (server *OSPFV2Server) BuildHelloPkt(key IntfConfKey) []byte {
 		AreaId:   ent.AreaId,
 		Chksum:   0,
 		AuthType: ent.AuthType,
-		//authKey:        ent.IfAuthKey,
 	}
 
-	//Rfc 2328 4.5
-	isStub, err := server.isStubArea(ent.AreaId)
+	//Rfc 2328 4.5, Rfc 3101 2.1
+	option, err := server.getAreaOptions(ent.AreaId)
 	if err != nil {
 		server.logger.Err("Not sending Hello Packet, ", err)
 		return nil
 	}
-	var option uint8
-	if isStub {
-		option = 0
-	} else {
-		option = EOption
-	}
 	helloData := OSPFHelloData{
 		HelloInterval:   ent.HelloInterval,
 		Options:         option,
@@ -106,9 +98,10 @@ func (server *OSPFV2Server) BuildHelloPkt(key IntfConfKey) []byte {
 	ospfEncHdr := encodeOspfHdr(ospfHdr)
 	helloDataEnc := encodeOspfHelloData(helloData, nbrList)
 	ospf := append(ospfEncHdr, helloDataEnc...)
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	binary.BigEndian.PutUint64(ospf[16:24], ent.AuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
+	if ospf == nil {
+		return nil
+	}
 
 	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.Pktlen
 	srcIp := net.ParseIP(convertUint32ToDotNotation(ent.IpAddr))
@@ -187,6 +180,16 @@ func (server *OSPFV2Server) processRxHelloPkt(data []byte,
 
 	}
 
+	if areaEnt.Nssa == true {
+		if (ospfHelloData.Options & NPOption) == 0 {
+			return errors.New("NSSA Capability mismatch")
+		}
+	} else {
+		if (ospfHelloData.Options & NPOption) != 0 {
+			return errors.New("NSSA Capability mismatch")
+		}
+	}
+
 	TwoWayStatus := false
 	for _, nbr := range ospfHelloData.NbrList {
 		if nbr == server.globalData.RouterId {
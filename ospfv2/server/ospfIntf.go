@@ -31,9 +31,12 @@ import (
 	"time"
 )
 
+// VirtualNbrRtrId is set only for the virtual interfaces, which are
+// keyed by the router id of the virtual neighbor
 type IntfConfKey struct {
-	IpAddr  uint32
-	IntfIdx uint32
+	IpAddr          uint32
+	IntfIdx         uint32
+	VirtualNbrRtrId uint32
 }
 
 type IntfConf struct {
@@ -47,8 +50,9 @@ type IntfConf struct {
 	RtrDeadInterval uint32
 	Cost            uint32
 	Mtu             uint32
-	AuthType        uint16
-	AuthKey         uint64
+	AuthType        uint16 //Inherited from the area
+	AuthData        *IntfAuthData
+	BfdEnable       bool //BFD session per nbr
 
 	DRIpAddr  uint32
 	DRtrId    uint32
@@ -93,7 +97,8 @@ func getOspfv2IntfUpdateMask(attrset []bool) uint32 {
 			objects.OSPFV2_INTF_UPDATE_RETRANS_INTERVAL |
 			objects.OSPFV2_INTF_UPDATE_HELLO_INTERVAL |
 			objects.OSPFV2_INTF_UPDATE_RTR_DEAD_INTERVAL |
-			objects.OSPFV2_INTF_UPDATE_METRIC_VALUE
+			objects.OSPFV2_INTF_UPDATE_METRIC_VALUE |
+			objects.OSPFV2_INTF_UPDATE_BFD_ENABLE
 	} else {
 		for idx, val := range attrset {
 			if true == val {
@@ -120,6 +125,8 @@ func getOspfv2IntfUpdateMask(attrset []bool) uint32 {
 					mask |= objects.OSPFV2_INTF_UPDATE_RTR_DEAD_INTERVAL
 				case 10:
 					mask |= objects.OSPFV2_INTF_UPDATE_METRIC_VALUE
+				case 11:
+					mask |= objects.OSPFV2_INTF_UPDATE_BFD_ENABLE
 				}
 			}
 		}
@@ -157,7 +164,14 @@ func (server *OSPFV2Server) updateIntf(newCfg, oldCfg *objects.Ospfv2Intf, attrs
 			server.logger.Err("Area doesnot exist")
 			return false, errors.New("Area doesnot exist")
 		}
+		authType := server.AreaConfMap[newCfg.AreaId].AuthType
+		err := server.validateIntfKeyChain(intfConfKey, authType)
+		if err != nil {
+			server.logger.Err("Cannot move interface to area", newCfg.AreaId, err)
+			return false, err
+		}
 		intfConfEnt.AreaId = newCfg.AreaId
+		intfConfEnt.AuthType = uint16(authType)
 	}
 	if mask&objects.OSPFV2_INTF_UPDATE_TYPE == objects.OSPFV2_INTF_UPDATE_TYPE {
 		intfConfEnt.Type = newCfg.Type
@@ -180,6 +194,9 @@ func (server *OSPFV2Server) updateIntf(newCfg, oldCfg *objects.Ospfv2Intf, attrs
 	if mask&objects.OSPFV2_INTF_UPDATE_METRIC_VALUE == objects.OSPFV2_INTF_UPDATE_METRIC_VALUE {
 		intfConfEnt.Cost = uint32(newCfg.MetricValue)
 	}
+	if mask&objects.OSPFV2_INTF_UPDATE_BFD_ENABLE == objects.OSPFV2_INTF_UPDATE_BFD_ENABLE {
+		intfConfEnt.BfdEnable = newCfg.BfdEnable
+	}
 	areaEnt, _ = server.AreaConfMap[oldIntfConfEnt.AreaId]
 	delete(areaEnt.IntfMap, intfConfKey)
 	server.AreaConfMap[oldIntfConfEnt.AreaId] = areaEnt
@@ -249,12 +266,14 @@ func (server *OSPFV2Server) createIntf(cfg *objects.Ospfv2Intf) (bool, error) {
 	intfConfEnt.HelloInterval = cfg.HelloInterval
 	intfConfEnt.RtrDeadInterval = cfg.RtrDeadInterval
 	intfConfEnt.Cost = uint32(cfg.MetricValue)
+	intfConfEnt.BfdEnable = cfg.BfdEnable
 
 	//intfConfEnt.DRIpAddr = 0
 	//intfConfEnt.DRtrId = 0
 	//intfConfEnt.BDRIpAddr = 0
 	//intfConfEnt.BDRtrId = 0
-	intfConfEnt.AuthKey = 0
+	intfConfEnt.AuthType = uint16(areaEnt.AuthType)
+	intfConfEnt.AuthData = newIntfAuthData()
 
 	intfConfEnt.FSMState = objects.INTF_FSM_STATE_DOWN
 
@@ -346,7 +365,7 @@ func (server *OSPFV2Server) getIntfState(ipAddr, addressLessIfIdx uint32) (*obje
 		retObj.NumOfASExternalLSA = uint32(len(lsdbEnt.ASExternalLsaMap))
 		retObj.NumOfLSA = retObj.NumOfRouterLSA + retObj.NumOfNetworkLSA +
 			retObj.NumOfSummary3LSA + retObj.NumOfSummary4LSA +
-			retObj.NumOfASExternalLSA
+			retObj.NumOfASExternalLSA + uint32(len(lsdbEnt.NSSALsaMap))
 	}
 	//TODO: NumOfRoutes
 	retObj.NumOfStateChange = intfEnt.NumOfStateChange
@@ -397,7 +416,7 @@ func (server *OSPFV2Server) getBulkIntfState(fromIdx, cnt int) (*objects.Ospfv2I
 			obj.NumOfASExternalLSA = uint32(len(lsdbEnt.ASExternalLsaMap))
 			obj.NumOfLSA = obj.NumOfRouterLSA + obj.NumOfNetworkLSA +
 				obj.NumOfSummary3LSA + obj.NumOfSummary4LSA +
-				obj.NumOfASExternalLSA
+				obj.NumOfASExternalLSA + uint32(len(lsdbEnt.NSSALsaMap))
 		}
 		//TODO: NumOfRoutes
 		obj.NumOfStateChange = intfEnt.NumOfStateChange
@@ -39,7 +39,8 @@ func (server *OSPFV2Server) InitOspfIntfFSM(intfConfKey IntfConfKey) {
 	}
 	if ent.Type == objects.INTF_TYPE_BROADCAST {
 		ent.FSMState = objects.INTF_FSM_STATE_WAITING
-	} else if ent.Type == objects.INTF_TYPE_POINT2POINT {
+	} else if ent.Type == objects.INTF_TYPE_POINT2POINT ||
+		ent.Type == objects.INTF_TYPE_VIRTUAL {
 		ent.FSMState = objects.INTF_FSM_STATE_P2P
 	}
 	ent.NumOfStateChange++
@@ -164,7 +165,8 @@ func (server *OSPFV2Server) StartIntfFSM(key IntfConfKey) {
 			ent.NbrChangeCh = make(chan NbrChangeMsg)
 			server.IntfConfMap[key] = ent
 			server.StartIntfRxTxPkt(key)
-			if ent.Type == objects.INTF_TYPE_POINT2POINT {
+			if ent.Type == objects.INTF_TYPE_POINT2POINT ||
+				ent.Type == objects.INTF_TYPE_VIRTUAL {
 				go server.StartOspfP2PIntfFSM(key)
 			} else if ent.Type == objects.INTF_TYPE_BROADCAST {
 				go server.StartOspfBroadcastIntfFSM(key)
@@ -238,6 +240,10 @@ func (server *OSPFV2Server) StartOspfP2PIntfFSM(key IntfConfKey) {
 			// Only when Nbr Went Down from TwoWayStatus
 			server.logger.Info("Recev Nbr State Change message", downMsg)
 			server.processNbrDownEvent(downMsg, key, true)
+			if ent.Type == objects.INTF_TYPE_VIRTUAL {
+				server.SendMsgToGenerateRouterLSA(ent.AreaId)
+				server.sendMsgToGenerateTransitAreaRouterLSA(key)
+			}
 		case _ = <-ent.FSMCtrlCh:
 			//server.StopSendHelloPkt(key)
 			//nbrList := server.GetIntfNbrList(ent)
@@ -245,6 +251,9 @@ func (server *OSPFV2Server) StartOspfP2PIntfFSM(key IntfConfKey) {
 			server.DeinitOspfIntfFSM(key)
 			server.StopIntfRxTxPkt(key)
 			server.SendMsgToGenerateRouterLSA(ent.AreaId)
+			if ent.Type == objects.INTF_TYPE_VIRTUAL {
+				server.sendMsgToGenerateTransitAreaRouterLSA(key)
+			}
 			ent.FSMCtrlReplyCh <- false
 			return
 		}
@@ -123,9 +123,10 @@ func (server *OSPFV2Server) processLsdbAgeSelfOrigASExternalLsa(lsdbKey LsdbKey,
 	//TODO: If Age=LSRefreshTime Regenerate
 	if lsa.LsaMd.LSAge == LS_REFRESH_TIME {
 		routeInfo := RouteInfo{
-			NwAddr:  lsaKey.LSId,
-			Netmask: lsa.Netmask,
-			Metric:  lsa.Metric,
+			NwAddr:      lsaKey.LSId,
+			Netmask:     lsa.Netmask,
+			Metric:      lsa.Metric,
+			ExtRouteTag: lsa.ExtRouteTag,
 		}
 		_, exist := server.LsdbData.ExtRouteInfoMap[routeInfo]
 		if exist {
@@ -136,6 +137,23 @@ func (server *OSPFV2Server) processLsdbAgeSelfOrigASExternalLsa(lsdbKey LsdbKey,
 	return
 }
 
+func (server *OSPFV2Server) processLsdbAgeSelfOrigNSSALsa(lsdbKey LsdbKey, lsaKey LsaKey, lsa *ASExternalLsa) {
+	//Increment LSA age
+	if lsa.LsaMd.LSAge < MAX_AGE {
+		lsa.LsaMd.LSAge++
+	}
+	//If Age = multiples of CheckAge compute checksum and verify if error raise an alarm
+	if (lsa.LsaMd.LSAge % CHECK_AGE) == 0 {
+		lsaEnc := encodeASExternalLsa(*lsa, lsaKey)
+		cSum := computeFletcherChecksum(lsaEnc[2:], FLETCHER_CHECKSUM_VALIDATE)
+		if cSum != 0 {
+			server.logger.Err("Some serious problem, may be memory corruption")
+			return
+		}
+	}
+	return
+}
+
 func (server *OSPFV2Server) processLsdbAgeSelfOrigLsa(lsdbKey LsdbKey, lsaKey LsaKey, lsaEnt interface{}) {
 	switch lsaKey.LSType {
 	case RouterLSA:
@@ -173,6 +191,13 @@ func (server *OSPFV2Server) processLsdbAgeSelfOrigLsa(lsdbKey LsdbKey, lsaKey Ls
 			return
 		}
 		server.processLsdbAgeSelfOrigASExternalLsa(lsdbKey, lsaKey, lsa)
+	case NSSALSA:
+		lsa, ok := lsaEnt.(*ASExternalLsa)
+		if !ok {
+			server.logger.Err("Unable to assert lsa")
+			return
+		}
+		server.processLsdbAgeSelfOrigNSSALsa(lsdbKey, lsaKey, lsa)
 	}
 	return
 }
@@ -297,6 +322,30 @@ func (server *OSPFV2Server) processLsdbAgeNonSelfASExternalLsa(lsdbKey LsdbKey,
 	return msg, false
 }
 
+func (server *OSPFV2Server) processLsdbAgeNonSelfNSSALsa(lsdbKey LsdbKey, lsaKey LsaKey, lsa *ASExternalLsa) (LsdbToFloodLSAMsg, bool) {
+	var msg LsdbToFloodLSAMsg
+	//Increment LSA age
+	if lsa.LsaMd.LSAge < MAX_AGE {
+		lsa.LsaMd.LSAge++
+	}
+	//If Age = multiples of CheckAge compute checksum and verify if error raise an alarm
+	if (lsa.LsaMd.LSAge % CHECK_AGE) == 0 {
+		lsaEnc := encodeASExternalLsa(*lsa, lsaKey)
+		cSum := computeFletcherChecksum(lsaEnc[2:], FLETCHER_CHECKSUM_VALIDATE)
+		if cSum != 0 {
+			server.logger.Err("Some serious problem, may be memory corruption")
+			return msg, false
+		}
+	}
+	if lsa.LsaMd.LSAge == MAX_AGE {
+		msg.AreaId = lsdbKey.AreaId
+		msg.LsaKey = lsaKey
+		msg.LsaData = *lsa
+		return msg, true
+	}
+	return msg, false
+}
+
 func (server *OSPFV2Server) processLsdbAgeNonSelfLsa(lsdbKey LsdbKey, lsaKey LsaKey, lsaEnt interface{}) (LsdbToFloodLSAMsg, bool) {
 	var msg LsdbToFloodLSAMsg
 	switch lsaKey.LSType {
@@ -335,6 +384,13 @@ func (server *OSPFV2Server) processLsdbAgeNonSelfLsa(lsdbKey LsdbKey, lsaKey Lsa
 			return msg, false
 		}
 		return server.processLsdbAgeNonSelfASExternalLsa(lsdbKey, lsaKey, lsa)
+	case NSSALSA:
+		lsa, ok := lsaEnt.(*ASExternalLsa)
+		if !ok {
+			server.logger.Err("Unable to assert lsa")
+			return msg, false
+		}
+		return server.processLsdbAgeNonSelfNSSALsa(lsdbKey, lsaKey, lsa)
 	}
 	return msg, false
 }
@@ -346,6 +402,7 @@ func (server *OSPFV2Server) processLsdbAgingTicker() {
 	var needSPFCalcSummary3 bool
 	var needSPFCalcSummary4 bool
 	var needSPFCalcASExternal bool
+	var needSPFCalcNSSA bool
 	for lsdbKey, lsdbEnt := range server.LsdbData.AreaLsdb {
 		for lsaKey, lsaEnt := range lsdbEnt.RouterLsaMap {
 			selfOrigEnt, exist := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
@@ -481,14 +538,18 @@ func (server *OSPFV2Server) processLsdbAgingTicker() {
 						//TODO: If Age=LSRefreshTime Regenerate
 						if lsaEnt.LsaMd.LSAge == LS_REFRESH_TIME {
 							routeInfo := RouteInfo{
-								NwAddr:  lsaKey.LSId,
-								Netmask: lsaEnt.Netmask,
-								Metric:  lsaEnt.Metric,
+								NwAddr:      lsaKey.LSId,
+								Netmask:     lsaEnt.Netmask,
+								Metric:      lsaEnt.Metric,
+								ExtRouteTag: lsaEnt.ExtRouteTag,
 							}
 							_, exist = server.LsdbData.ExtRouteInfoMap[routeInfo]
 							if exist {
 								server.reGenerateASExternalLSAForGivenArea(routeInfo, lsdbKey.AreaId)
 								needSPFCalcASExternal = true
+							} else if server.LsdbData.TranslatedLsaMap[lsaKey] == true {
+								// Refreshed by the NSSA translator
+								needSPFCalcASExternal = true
 							}
 						}
 					}
@@ -497,13 +558,53 @@ func (server *OSPFV2Server) processLsdbAgingTicker() {
 				server.logger.Err("This should Not happen some serious problem")
 			}
 		}
+		for lsaKey, lsaEnt := range lsdbEnt.NSSALsaMap {
+			selfOrigEnt, exist := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+			if exist {
+				_, exist := selfOrigEnt[lsaKey]
+				if exist {
+					server.processLsdbAgeSelfOrigLsa(lsdbKey, lsaKey, &lsaEnt)
+				} else {
+					lsdbToFloodLSAMsg, flag := server.processLsdbAgeNonSelfLsa(lsdbKey, lsaKey, &lsaEnt)
+					if flag == true {
+						lsdbToFloodLSAMsgList = append(lsdbToFloodLSAMsgList, lsdbToFloodLSAMsg)
+					}
+				}
+				if lsaEnt.LsaMd.LSAge == MAX_AGE {
+					delete(server.LsdbData.AreaLsdb[lsdbKey].NSSALsaMap, lsaKey)
+				} else {
+					server.LsdbData.AreaLsdb[lsdbKey].NSSALsaMap[lsaKey] = lsaEnt
+					if exist {
+						if lsaEnt.LsaMd.LSAge == LS_REFRESH_TIME {
+							routeInfo := RouteInfo{
+								NwAddr:      lsaKey.LSId,
+								Netmask:     lsaEnt.Netmask,
+								Metric:      lsaEnt.Metric,
+								ExtRouteTag: lsaEnt.ExtRouteTag,
+							}
+							_, exist = server.LsdbData.ExtRouteInfoMap[routeInfo]
+							if exist {
+								server.reGenerateNSSALSAForGivenArea(routeInfo, lsdbKey.AreaId)
+								needSPFCalcNSSA = true
+							}
+						}
+					}
+				}
+			} else {
+				server.logger.Err("This should Not happen some serious problem")
+			}
+		}
+	}
+	if server.isNssaTranslatorStabilityExpired() {
+		needSPFCalcNSSA = true
 	}
 	server.SendMsgFromLsdbToFloodLsa(lsdbToFloodLSAMsgList)
 	if needSPFCalcRouter == true ||
 		needSPFCalcNetwork == true ||
 		needSPFCalcSummary3 == true ||
 		needSPFCalcSummary4 == true ||
-		needSPFCalcASExternal == true {
+		needSPFCalcASExternal == true ||
+		needSPFCalcNSSA == true {
 		server.CalcSPFAndRoutingTbl()
 	}
 }
@@ -53,6 +53,7 @@ type LinkDetail struct {
 
 type RouterLsa struct {
 	LsaMd       LsaMetadata
+	BitNt       bool         /* Nt Bit, RFC 3101 */
 	BitV        bool         /* V Bit */
 	BitE        bool         /* Bit E */
 	BitB        bool         /* Bit B */
@@ -78,7 +79,7 @@ func NewRouterLsa() *RouterLsa {
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |         LS checksum           |             length            |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-   |    0    |V|E|B|        0      |            # links            |
+   |  0  |Nt|0|V|E|B|       0      |            # links            |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |                          Link ID                              |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
@@ -106,6 +107,11 @@ func decodeRouterLsa(data []byte, lsa *RouterLsa, lsakey *LsaKey) {
 	lsa.LsaMd.LSSequenceNum = int(binary.BigEndian.Uint32(data[12:16]))
 	lsa.LsaMd.LSChecksum = binary.BigEndian.Uint16(data[16:18])
 	lsa.LsaMd.LSLen = binary.BigEndian.Uint16(data[18:20])
+	if data[20]&0x10 != 0 {
+		lsa.BitNt = true
+	} else {
+		lsa.BitNt = false
+	}
 	if data[20]&0x04 != 0 {
 		lsa.BitV = true
 	} else {
@@ -178,6 +184,9 @@ func encodeRouterLsa(lsa RouterLsa, lsakey LsaKey) []byte {
 	lsaHdr := encodeLsaHeader(lsa.LsaMd, lsakey)
 	copy(rtrLsa[0:20], lsaHdr)
 	var val uint8 = 0
+	if lsa.BitNt == true {
+		val = val | 1<<4
+	}
 	if lsa.BitV == true {
 		val = val | 1<<2
 	}
@@ -422,6 +431,12 @@ func NewASExternalLsa() *ASExternalLsa {
 	return &ASExternalLsa{}
 }
 
+/* LS Type 7 NSSA LSA (RFC 3101 Appendix C)
+   Same format as the ASExternal LSA, the P-bit is carried in the
+   NPOption bit of the LSA Options field. encodeASExternalLsa and
+   decodeASExternalLsa are used for both.
+*/
+
 func encodeASExternalLsa(lsa ASExternalLsa, lsakey LsaKey) []byte {
 	eLsa := make([]byte, lsa.LsaMd.LSLen)
 	lsaHdr := encodeLsaHeader(lsa.LsaMd, lsakey)
@@ -34,6 +34,8 @@ func (server *OSPFV2Server) InitLsdbData() {
 	server.LsdbData.AreaSelfOrigLsa = make(map[LsdbKey]SelfOrigLsa)
 	server.LsdbData.LsdbAgingTicker = nil
 	server.LsdbData.ExtRouteInfoMap = make(map[RouteInfo]bool)
+	server.LsdbData.NssaTranslatorMap = make(map[LsdbKey]NssaTranslatorData)
+	server.LsdbData.TranslatedLsaMap = make(map[LsaKey]bool)
 }
 
 func (server *OSPFV2Server) DeinitLsdb() {
@@ -47,13 +49,20 @@ func (server *OSPFV2Server) DeinitLsdb() {
 	server.LsdbData.AreaLsdb = nil
 	server.LsdbData.AreaSelfOrigLsa = nil
 	server.LsdbData.ExtRouteInfoMap = nil
+	server.LsdbData.NssaTranslatorMap = nil
+	server.LsdbData.TranslatedLsaMap = nil
 }
 
 func (server *OSPFV2Server) GetExtRouteInfo() {
 	routeInfoList := server.getBulkRoutesFromRibd()
 	for _, route := range routeInfoList {
 		server.LsdbData.ExtRouteInfoMap[*route] = true
+		if server.isGracefulRestartInProgress() {
+			// Originated on graceful restart exit
+			continue
+		}
 		server.generateASExternalLSA(*route)
+		server.generateNSSALSA(*route)
 	}
 }
 
@@ -69,6 +78,7 @@ func (server *OSPFV2Server) InitAreaLsdb(areaId uint32) {
 		lsDbEnt.Summary3LsaMap = make(map[LsaKey]SummaryLsa)
 		lsDbEnt.Summary4LsaMap = make(map[LsaKey]SummaryLsa)
 		lsDbEnt.ASExternalLsaMap = make(map[LsaKey]ASExternalLsa)
+		lsDbEnt.NSSALsaMap = make(map[LsaKey]ASExternalLsa)
 		server.LsdbData.AreaLsdb[lsdbKey] = lsDbEnt
 	}
 	selfOrigLsaEnt, exist := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
@@ -89,8 +99,10 @@ func (server *OSPFV2Server) DeinitAreaLsdb(areaId uint32) {
 		lsDbEnt.Summary3LsaMap = nil
 		lsDbEnt.Summary4LsaMap = nil
 		lsDbEnt.ASExternalLsaMap = nil
+		lsDbEnt.NSSALsaMap = nil
 		delete(server.LsdbData.AreaLsdb, lsdbKey)
 	}
+	delete(server.LsdbData.NssaTranslatorMap, lsdbKey)
 	_, exist = server.LsdbData.AreaSelfOrigLsa[lsdbKey]
 	if exist {
 		delete(server.LsdbData.AreaSelfOrigLsa, lsdbKey)
@@ -147,6 +159,8 @@ func (server *OSPFV2Server) processRecvdLSA(msg RecvdLsaMsg) error {
 		server.processRecvdSummaryLSA(msg)
 	case ASExternalLSA:
 		server.processRecvdASExternalLSA(msg)
+	case NSSALSA:
+		server.processRecvdNSSALSA(msg)
 	default:
 		server.logger.Err("Invalid LsaType:", msg)
 	}
@@ -165,6 +179,8 @@ func (server *OSPFV2Server) processRecvdSelfLSA(msg RecvdSelfLsaMsg) error {
 		server.processRecvdSelfSummaryLSA(msg)
 	case ASExternalLSA:
 		server.processRecvdSelfASExternalLSA(msg)
+	case NSSALSA:
+		server.processRecvdSelfNSSALSA(msg)
 	default:
 		server.logger.Err("Invalid LsaType:", msg)
 	}
@@ -175,12 +191,17 @@ func (server *OSPFV2Server) ProcessRouteInfoData(msg RouteInfoDataUpdateMsg) {
 	if msg.MsgType == ROUTE_INFO_ADD {
 		for _, routeInfo := range msg.RouteInfoList {
 			server.LsdbData.ExtRouteInfoMap[routeInfo] = true
+			if server.isGracefulRestartInProgress() {
+				continue
+			}
 			server.generateASExternalLSA(routeInfo)
+			server.generateNSSALSA(routeInfo)
 		}
 	} else if msg.MsgType == ROUTE_INFO_DEL {
 		for _, routeInfo := range msg.RouteInfoList {
 			delete(server.LsdbData.ExtRouteInfoMap, routeInfo)
 			server.flushASExternalLSA(routeInfo)
+			server.flushNSSALSA(routeInfo)
 		}
 	} else {
 		server.logger.Err("Invalid MsgType for RouteInfoDataUpdateMsg")
@@ -238,6 +259,14 @@ func (server *OSPFV2Server) processNbrDead(msg NbrDeadMsg) bool {
 			server.CreateAndSendMsgFromLsdbToFloodLsa(msg.AreaId, lsaKey, lsaEnt)
 		}
 	}
+	for lsaKey, lsaEnt := range lsdbEnt.NSSALsaMap {
+		if lsaKey.AdvRouter == msg.NbrRtrId {
+			flag = true
+			delete(lsdbEnt.NSSALsaMap, lsaKey)
+			lsaEnt.LsaMd.LSAge = MAX_AGE
+			server.CreateAndSendMsgFromLsdbToFloodLsa(msg.AreaId, lsaKey, lsaEnt)
+		}
+	}
 	if flag == true {
 		server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
 		return true
@@ -253,6 +282,7 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 		}
 	}
 	server.GetExtRouteInfo()
+	server.LsdbData.EndOfRIBTime = time.Now().Add(EndOfRIBDelay)
 	server.LsdbData.LsdbAgingTicker = time.NewTicker(LsaAgingTimeGranularity)
 	initDoneCh <- true
 	for {
@@ -273,9 +303,17 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 			server.InitAreaLsdb(areaId)
 			server.logger.Info("InitAreaLsdb...")
 			server.SendMsgFromLsdbToServerForInitAreaLsdbDone()
+			if server.isGracefulRestartInProgress() {
+				continue
+			}
 			server.GenerateAllASExternalLSA(areaId)
+			server.GenerateAllNSSALSA(areaId)
 		case msg := <-server.MessagingChData.IntfFSMToLsdbChData.GenerateRouterLSACh:
 			server.logger.Info("Generate self originated Router LSA", msg)
+			if server.isGracefulRestartInProgress() {
+				// No LSA origination till graceful restart exits
+				continue
+			}
 			err := server.GenerateRouterLSA(msg)
 			if err != nil {
 				continue
@@ -285,6 +323,9 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 			server.logger.Info("Successfully Calculated SPF")
 		case msg := <-server.MessagingChData.NbrFSMToLsdbChData.UpdateSelfNetworkLSACh:
 			server.logger.Info("Update self originated Network LSA", msg)
+			if server.isGracefulRestartInProgress() {
+				continue
+			}
 			err := server.processUpdateSelfNetworkLSA(msg)
 			if err != nil {
 				continue
@@ -296,6 +337,9 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 			server.CalcSPFAndRoutingTbl()
 		case msg := <-server.MessagingChData.NbrFSMToLsdbChData.RecvdSelfLsaMsgCh:
 			server.logger.Info("Recvd Self LSA", msg)
+			if server.isGracefulRestartInProgress() {
+				continue
+			}
 			server.processRecvdSelfLSA(msg)
 			server.CalcSPFAndRoutingTbl()
 		case msg := <-server.MessagingChData.NbrFSMToLsdbChData.NbrDeadMsgCh:
@@ -309,6 +353,9 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 			server.ProcessRouteInfoData(msg)
 		case <-server.LsdbData.LsdbAgingTicker.C:
 			server.processLsdbAgingTicker()
+			server.processEndOfRIB()
+		case reason := <-server.MessagingChData.ServerToLsdbChData.GracefulRestartExitCh:
+			server.processGracefulRestartExit(reason)
 		case <-server.MessagingChData.ServerToLsdbChData.RefreshLsdbSliceCh:
 			server.RefreshLsdbSlice()
 			server.SendMsgFromLsdbToServerForRefreshDone()
@@ -317,19 +364,23 @@ func (server *OSPFV2Server) ProcessLsdb(initDoneCh chan bool) {
 }
 
 func (server *OSPFV2Server) CalcSPFAndRoutingTbl() {
+	if server.isGracefulRestartInProgress() {
+		// Routes installed before the restart are kept till exit
+		return
+	}
+	server.deferEndOfRIB()
 	server.SummaryLsDb = nil
 	server.SendMsgToStartSpf()
 	spfState := <-server.MessagingChData.SPFToLsdbChData.DoneSPF
 	server.logger.Debug("SPF Calculation Return Status", spfState)
 	if server.globalData.AreaBdrRtrStatus == true {
-		server.logger.Info("Examine transit areas, Summary LSA...")
-		server.HandleTransitAreaSummaryLsa()
 		server.logger.Info("Generate Summary LSA...")
 		server.GenerateSummaryLsa()
 		server.logger.Info("========", server.SummaryLsDb, "==========")
 		//Summary LSA
 		server.installSummaryLsa()
 	}
+	server.processNssaTranslation()
 }
 
 func (server *OSPFV2Server) RefreshLsdbSlice() {
@@ -374,6 +425,13 @@ func (server *OSPFV2Server) RefreshLsdbSlice() {
 			}
 			server.GetBulkData.LsdbSlice = append(server.GetBulkData.LsdbSlice, lsdbSlice)
 		}
+		for lsaKey, _ := range lsDbEnt.NSSALsaMap {
+			lsdbSlice := LsdbSliceStruct{
+				LsdbKey: lsdbKey,
+				LsaKey:  lsaKey,
+			}
+			server.GetBulkData.LsdbSlice = append(server.GetBulkData.LsdbSlice, lsdbSlice)
+		}
 	}
 }
 
@@ -430,6 +488,13 @@ func (server *OSPFV2Server) getLsdbState(lsaType uint8, lsId, areaId, advRtrId u
 		}
 		lsaMd = lsaEnt.LsaMd
 		lsaEnc = encodeASExternalLsa(lsaEnt, lsaKey)
+	case NSSALSA:
+		lsaEnt, exist := lsdbEnt.NSSALsaMap[lsaKey]
+		if !exist {
+			return nil, errors.New("No such LSA exist")
+		}
+		lsaMd = lsaEnt.LsaMd
+		lsaEnc = encodeASExternalLsa(lsaEnt, lsaKey)
 	default:
 		return nil, errors.New("Invalid LSType")
 	}
@@ -517,6 +582,14 @@ func (server *OSPFV2Server) getBulkLsdbState(fromIdx, cnt int) (*objects.Ospfv2L
 			}
 			lsaMd = lsaEnt.LsaMd
 			lsaEnc = encodeASExternalLsa(lsaEnt, lsdbSlice.LsaKey)
+		case NSSALSA:
+			lsaEnt, exist := lsdbEnt.NSSALsaMap[lsdbSlice.LsaKey]
+			if !exist {
+				idx++
+				continue
+			}
+			lsaMd = lsaEnt.LsaMd
+			lsaEnc = encodeASExternalLsa(lsaEnt, lsdbSlice.LsaKey)
 		default:
 			idx++
 			continue
@@ -37,6 +37,9 @@ const (
 	Summary3LSA   uint8 = 3
 	Summary4LSA   uint8 = 4
 	ASExternalLSA uint8 = 5
+	NSSALSA       uint8 = 7
+	// Link local opaque LSA (RFC 5250), used only for grace LSAs
+	LocalOpaqueLSA uint8 = 9
 )
 
 type LsaKey struct {
@@ -55,6 +58,7 @@ type LSDatabase struct {
 	Summary3LsaMap   map[LsaKey]SummaryLsa
 	Summary4LsaMap   map[LsaKey]SummaryLsa
 	ASExternalLsaMap map[LsaKey]ASExternalLsa
+	NSSALsaMap       map[LsaKey]ASExternalLsa
 }
 
 type SelfOrigLsa map[LsaKey]bool
@@ -67,15 +71,37 @@ type LsdbCtrlChStruct struct {
 }
 
 type RouteInfo struct {
-	NwAddr  uint32
-	Netmask uint32
-	Metric  uint32
+	NwAddr      uint32
+	Netmask     uint32
+	Metric      uint32
+	ExtRouteTag uint32
+}
+
+type NssaTranslatorData struct {
+	State uint8
+	// Set when the router loses the translator election, translation
+	// continues until it expires (RFC 3101 3.1)
+	StabilityExpiry time.Time
 }
 
 type LsdbStruct struct {
-	AreaLsdb        map[LsdbKey]LSDatabase
-	AreaSelfOrigLsa map[LsdbKey]SelfOrigLsa
-	LsdbCtrlChData  LsdbCtrlChStruct
-	LsdbAgingTicker *time.Ticker
-	ExtRouteInfoMap map[RouteInfo]bool
+	AreaLsdb          map[LsdbKey]LSDatabase
+	AreaSelfOrigLsa   map[LsdbKey]SelfOrigLsa
+	LsdbCtrlChData    LsdbCtrlChStruct
+	LsdbAgingTicker   *time.Ticker
+	ExtRouteInfoMap   map[RouteInfo]bool
+	NssaTranslatorMap map[LsdbKey]NssaTranslatorData
+	// AS External LSAs originated by translating Type-7 LSAs
+	TranslatedLsaMap map[LsaKey]bool
+	// ribd is told once that the routes are in sync so that it
+	// sweeps the routes held stale from the previous instance
+	EndOfRIBTime time.Time
+	EndOfRIBSent bool
 }
+
+const (
+	// Routes are considered in sync once no SPF has run for
+	// EndOfRIBQuietTime, but no later than EndOfRIBDelay from start
+	EndOfRIBDelay     time.Duration = 60 * time.Second
+	EndOfRIBQuietTime time.Duration = 5 * time.Second
+)
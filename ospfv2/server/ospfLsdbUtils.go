@@ -82,3 +82,15 @@ func (server *OSPFV2Server) getASExternalLsaFromLsdb(areaId uint32, lsaKey LsaKe
 	}
 	return lsa, LsdbEntryFound
 }
+
+func (server *OSPFV2Server) getNSSALsaFromLsdb(areaId uint32, lsaKey LsaKey) (lsa ASExternalLsa, retVal bool) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, _ := server.LsdbData.AreaLsdb[lsdbKey]
+	lsa, exist := lsDbEnt.NSSALsaMap[lsaKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	return lsa, LsdbEntryFound
+}
@@ -154,10 +154,130 @@ func (server *OSPFV2Server) HandleSummaryLsa(areaId uint32) {
 	server.HandleSummaryType3Lsa(areaId)
 	server.HandleSummaryType4Lsa(areaId)
 	server.HandleASExternalLsa(areaId)
+	server.HandleNSSALsa(areaId)
 }
 
+/*
+ RFC 2328 16.3
+ Examine the summary LSAs of the transit areas to find out better
+ paths than the ones calculated for the backbone via virtual links.
+*/
 func (server *OSPFV2Server) HandleTransitAreaSummaryLsa() {
-	//TODO
+	bbAreaIdKey := AreaIdKey{
+		AreaId: 0,
+	}
+	_, exist := server.RoutingTblData.TempAreaRoutingTbl[bbAreaIdKey]
+	if !exist {
+		return
+	}
+	for areaId, transit := range server.RoutingTblData.TransitCapability {
+		if areaId == 0 || transit == false {
+			continue
+		}
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsDbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+		if !exist {
+			server.logger.Err("Unable to find Area Lsdb entry for transit area:", areaId)
+			continue
+		}
+		server.logger.Info("Examining Summary LSAs of transit area:", areaId)
+		for lsaKey, lsaEnt := range lsDbEnt.Summary3LsaMap {
+			rKey := RoutingTblEntryKey{
+				DestId:   lsaKey.LSId & lsaEnt.Netmask,
+				AddrMask: lsaEnt.Netmask,
+				DestType: Network,
+			}
+			server.examineTransitAreaSummaryLsa(areaId, lsaKey, lsaEnt, []RoutingTblEntryKey{rKey})
+		}
+		for lsaKey, lsaEnt := range lsDbEnt.Summary4LsaMap {
+			rKeyList := []RoutingTblEntryKey{
+				RoutingTblEntryKey{
+					DestId:   lsaKey.LSId,
+					AddrMask: 0,
+					DestType: ASBdrRouter,
+				},
+				RoutingTblEntryKey{
+					DestId:   lsaKey.LSId,
+					AddrMask: 0,
+					DestType: ASAreaBdrRouter,
+				},
+			}
+			server.examineTransitAreaSummaryLsa(areaId, lsaKey, lsaEnt, rKeyList)
+		}
+	}
+}
+
+func (server *OSPFV2Server) examineTransitAreaSummaryLsa(areaId uint32, lsaKey LsaKey, lsaEnt SummaryLsa, rKeyList []RoutingTblEntryKey) {
+	if lsaEnt.Metric == LSInfinity ||
+		lsaEnt.LsaMd.LSAge == MAX_AGE {
+		return
+	}
+	if lsaKey.AdvRouter == server.globalData.RouterId {
+		return
+	}
+	bbAreaIdKey := AreaIdKey{
+		AreaId: 0,
+	}
+	bbRoutingTbl := server.RoutingTblData.TempAreaRoutingTbl[bbAreaIdKey]
+	var rKey RoutingTblEntryKey
+	var rEnt RoutingTblEntry
+	exist := false
+	for _, key := range rKeyList {
+		rEnt, exist = bbRoutingTbl.RoutingTblMap[key]
+		if exist {
+			rKey = key
+			break
+		}
+	}
+	if !exist {
+		return
+	}
+	// Only backbone paths are eligible for the transit area shortcut
+	if rEnt.PathType != IntraArea &&
+		rEnt.PathType != InterArea {
+		return
+	}
+	areaIdKey := AreaIdKey{
+		AreaId: areaId,
+	}
+	tempAreaRoutingTbl := server.RoutingTblData.TempAreaRoutingTbl[areaIdKey]
+	bRKey := RoutingTblEntryKey{
+		DestId:   lsaKey.AdvRouter,
+		AddrMask: 0,
+		DestType: AreaBdrRouter,
+	}
+	bREnt, exist := tempAreaRoutingTbl.RoutingTblMap[bRKey]
+	if !exist {
+		bRKey.DestType = ASAreaBdrRouter
+		bREnt, exist = tempAreaRoutingTbl.RoutingTblMap[bRKey]
+		if !exist {
+			return
+		}
+	}
+	if bREnt.NumOfPaths == 0 {
+		return
+	}
+	cost := bREnt.Cost + uint16(lsaEnt.Metric)
+	if cost > rEnt.Cost {
+		return
+	} else if cost < rEnt.Cost {
+		server.logger.Info("Better path found through transit area:", areaId, "for:", rKey)
+		rEnt.Cost = cost
+		rEnt.NextHops = make(map[NextHop]bool)
+		rEnt.NumOfPaths = 0
+	}
+	for key, _ := range bREnt.NextHops {
+		key.AdvRtr = lsaKey.AdvRouter
+		_, exist = rEnt.NextHops[key]
+		if !exist {
+			rEnt.NextHops[key] = true
+			rEnt.NumOfPaths++
+		}
+	}
+	bbRoutingTbl.RoutingTblMap[rKey] = rEnt
+	server.RoutingTblData.TempAreaRoutingTbl[bbAreaIdKey] = bbRoutingTbl
 }
 
 func (server *OSPFV2Server) GenerateSummaryLsa() {
@@ -172,10 +292,18 @@ func (server *OSPFV2Server) GenerateSummaryLsa() {
 			AreaId: areaId,
 		}
 		isStub, _ := server.isStubArea(areaId)
+		isNssa, _ := server.isNssaArea(areaId)
+		// Totally stubby area / totally NSSA only get the default summary
+		noSummary := isStub && aEnt.NoSummary
 
 		sEnt, _ := server.SummaryLsDb[lsDbKey]
 		sEnt = make(map[LsaKey]SummaryLsa)
+		// Cost of the advertised area ranges (RFC 2328 12.4.3)
+		rangeCostMap := make(map[AreaRangeKey]uint32)
 		for rKey, rEnt := range server.RoutingTblData.GlobalRoutingTbl {
+			if noSummary {
+				break
+			}
 			if rKey.DestType == AreaBdrRouter ||
 				rEnt.RoutingTblEnt.PathType == Type1Ext ||
 				rEnt.RoutingTblEnt.PathType == Type2Ext ||
@@ -217,6 +345,9 @@ func (server *OSPFV2Server) GenerateSummaryLsa() {
 			// Dest Type Network, Inter Area Routes
 			if rKey.DestType == Network &&
 				rEnt.RoutingTblEnt.PathType == InterArea {
+				if !server.isSummaryPermitted(rEnt.AreaId, areaId, rKey.DestId, rKey.AddrMask) {
+					continue
+				}
 				// Generate Type 3 Summary LSA for the desitnation
 				// LSId = networks's address
 				// Metric = Routing Table cost
@@ -224,7 +355,20 @@ func (server *OSPFV2Server) GenerateSummaryLsa() {
 				sEnt[lsaKey] = summaryLsa
 			} else if rKey.DestType == Network &&
 				rEnt.RoutingTblEnt.PathType == IntraArea {
-				//TODO: Address Range
+				// Networks contained in an area range are
+				// advertised as a single summary LSA for the range
+				rangeKey, rangeEnt, exist := server.getSummaryAreaRange(rEnt, rKey, areaId)
+				if exist {
+					cost := uint32(rEnt.RoutingTblEnt.Cost)
+					if rangeEnt.Advertise &&
+						cost >= rangeCostMap[rangeKey] {
+						rangeCostMap[rangeKey] = cost
+					}
+					continue
+				}
+				if !server.isSummaryPermitted(rEnt.AreaId, areaId, rKey.DestId, rKey.AddrMask) {
+					continue
+				}
 				// By default LSId = network's address
 				// Metric = Routing Table cost
 				server.logger.Debug("Summary : generated summary 3 lsa ", rKey)
@@ -232,9 +376,22 @@ func (server *OSPFV2Server) GenerateSummaryLsa() {
 				sEnt[lsaKey] = summaryLsa
 			}
 		}
+		for rangeKey, cost := range rangeCostMap {
+			rangeEnt, _ := server.AreaRangeConfMap[rangeKey]
+			if rangeEnt.Cost != 0 {
+				cost = rangeEnt.Cost
+			}
+			if cost >= LSInfinity ||
+				!server.isSummaryPermitted(rangeKey.AreaId, areaId, rangeKey.IpPrefix, rangeKey.Netmask) {
+				continue
+			}
+			server.logger.Debug("Summary : generated summary 3 lsa for area range", rangeKey)
+			lsaKey, summaryLsa := server.GenerateAreaRangeSummary3LSA(rangeKey, cost, lsDbKey)
+			sEnt[lsaKey] = summaryLsa
+		}
 
 		server.SummaryLsDb[lsDbKey] = sEnt
-		if isStub {
+		if (isStub && !isNssa) || noSummary {
 			lsaKey, defsummaryLsa := server.GenerateDefaultSummary3LSA(lsDbKey)
 			sEnt[lsaKey] = defsummaryLsa
 		}
@@ -257,16 +414,10 @@ func (server *OSPFV2Server) GenerateDefaultSummary3LSA(lsDbKey LsdbKey) (LsaKey,
 	var summaryLsa SummaryLsa
 	seq_num := int(InitialSequenceNum)
 	metric := int32(20)
-	/* TODO
-	   areaConfKey := AreaConfKey{
-	           AreaId: config.AreaId(convertUint32ToIPv4(lsDbKey.AreaId)),
-	   }
-
-	   conf, exist := server.AreaConfMap[lsDbKey.AreaId]
-	   if exist {
-	           metric = conf.StubDefaultCost
-	   }
-	*/
+	conf, exist := server.AreaConfMap[lsDbKey.AreaId]
+	if exist && conf.StubDefaultCost != 0 {
+		metric = int32(conf.StubDefaultCost)
+	}
 	AdvRouter := server.globalData.RouterId
 	lsaKey := LsaKey{
 		LSType:    Summary3LSA,
@@ -0,0 +1,559 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/ospfv2/objects"
+	"time"
+)
+
+func (server *OSPFV2Server) processRecvdSelfNSSALSA(msg RecvdSelfLsaMsg) error {
+	lsa, ok := msg.LsaData.(ASExternalLsa)
+	if !ok {
+		server.logger.Err("Unable to assert given NSSA lsa")
+		return nil
+	}
+	lsdbEnt, exist := server.LsdbData.AreaLsdb[msg.LsdbKey]
+	if !exist {
+		server.logger.Err("No such Area exist", msg.LsdbKey)
+		return nil
+	}
+	lsaEnt, exist := lsdbEnt.NSSALsaMap[msg.LsaKey]
+	if !exist {
+		server.logger.Err("No such NSSA LSA exist", msg.LsaKey)
+		// Mark the recvd LSA as MAX_AGE and Flood
+		lsa.LsaMd.LSAge = MAX_AGE
+		server.CreateAndSendMsgFromLsdbToFloodLsa(msg.LsdbKey.AreaId, msg.LsaKey, lsa)
+		return nil
+	}
+	selfOrigLsaEnt, exist := server.LsdbData.AreaSelfOrigLsa[msg.LsdbKey]
+	if !exist {
+		server.logger.Err("No self originated LSA exist")
+		return nil
+	}
+	_, exist = selfOrigLsaEnt[msg.LsaKey]
+	if !exist {
+		server.logger.Err("No such self originated NSSA LSA exist", msg.LsaKey)
+		// Mark the recvd LSA as MAX_AGE and Flood
+		lsa.LsaMd.LSAge = MAX_AGE
+		server.CreateAndSendMsgFromLsdbToFloodLsa(msg.LsdbKey.AreaId, msg.LsaKey, lsa)
+		return nil
+	}
+	if lsaEnt.LsaMd.LSSequenceNum < lsa.LsaMd.LSSequenceNum {
+		lsaEnt.LsaMd.LSSequenceNum = lsa.LsaMd.LSSequenceNum + 1
+		lsaEnt.LsaMd.LSAge = 0
+		lsaEnt.LsaMd.LSChecksum = 0
+		lsaEnc := encodeASExternalLsa(lsaEnt, msg.LsaKey)
+		checksumOffset := uint16(14)
+		lsaEnt.LsaMd.LSChecksum = computeFletcherChecksum(lsaEnc[2:], checksumOffset)
+		lsdbEnt.NSSALsaMap[msg.LsaKey] = lsaEnt
+		server.LsdbData.AreaLsdb[msg.LsdbKey] = lsdbEnt
+		// Flood new Self NSSA LSA
+		server.CreateAndSendMsgFromLsdbToFloodLsa(msg.LsdbKey.AreaId, msg.LsaKey, lsaEnt)
+		return nil
+	} else {
+		// Flood existing Self NSSA LSA
+		server.CreateAndSendMsgFromLsdbToFloodLsa(msg.LsdbKey.AreaId, msg.LsaKey, lsaEnt)
+	}
+
+	return nil
+}
+
+func (server *OSPFV2Server) processRecvdNSSALSA(msg RecvdLsaMsg) error {
+	lsdbEnt, exist := server.LsdbData.AreaLsdb[msg.LsdbKey]
+	if !exist {
+		server.logger.Err("No such Area exist", msg.LsdbKey)
+		return nil
+	}
+	if msg.MsgType == LSA_ADD {
+		lsa, ok := msg.LsaData.(ASExternalLsa)
+		if !ok {
+			server.logger.Err("Unable to assert given NSSA lsa")
+			return nil
+		}
+		_, exist = lsdbEnt.NSSALsaMap[msg.LsaKey]
+		lsdbEnt.NSSALsaMap[msg.LsaKey] = lsa
+		if !exist {
+			lsdbSlice := LsdbSliceStruct{
+				LsdbKey: msg.LsdbKey,
+				LsaKey:  msg.LsaKey,
+			}
+			server.GetBulkData.LsdbSlice = append(server.GetBulkData.LsdbSlice, lsdbSlice)
+		}
+	} else if msg.MsgType == LSA_DEL {
+		delete(lsdbEnt.NSSALsaMap, msg.LsaKey)
+	}
+	server.LsdbData.AreaLsdb[msg.LsdbKey] = lsdbEnt
+	return nil
+}
+
+func (server *OSPFV2Server) getNssaAreaIdList() []uint32 {
+	var areaIdList []uint32
+	for areaId, areaEnt := range server.AreaConfMap {
+		if areaEnt.AdminState == false {
+			continue
+		}
+		isNssa, _ := server.isNssaArea(areaId)
+		if isNssa {
+			areaIdList = append(areaIdList, areaId)
+		}
+	}
+	return areaIdList
+}
+
+/*
+ RFC 3101 2.3
+ Type-7 LSAs with the P-bit set need a non zero forwarding address so
+ that the translated AS External LSA can be used from other areas. The
+ lowest interface address of the router in the NSSA is used.
+*/
+func (server *OSPFV2Server) getNssaFwdAddr(areaId uint32) uint32 {
+	areaEnt, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return 0
+	}
+	var fwdAddr uint32
+	for intfKey, _ := range areaEnt.IntfMap {
+		if intfKey.IpAddr == 0 {
+			continue
+		}
+		if fwdAddr == 0 || intfKey.IpAddr < fwdAddr {
+			fwdAddr = intfKey.IpAddr
+		}
+	}
+	return fwdAddr
+}
+
+func (server *OSPFV2Server) constructNSSALsa(routeInfo RouteInfo, areaId uint32) ASExternalLsa {
+	var lsaEnt ASExternalLsa
+	lsaEnt.LsaMd.LSAge = 0
+	lsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
+	lsaEnt.LsaMd.Options = 0
+	lsaEnt.BitE = true
+	lsaEnt.ExtRouteTag = routeInfo.ExtRouteTag
+	lsaEnt.Metric = routeInfo.Metric
+	lsaEnt.Netmask = routeInfo.Netmask
+	lsaEnt.FwdAddr = 0
+	// NSSA border routers originate the AS External LSA themselves,
+	// so their Type-7 LSAs are not to be translated (RFC 3101 2.4)
+	if server.globalData.AreaBdrRtrStatus == false {
+		lsaEnt.FwdAddr = server.getNssaFwdAddr(areaId)
+		if lsaEnt.FwdAddr != 0 {
+			lsaEnt.LsaMd.Options = NPOption
+		}
+	}
+	return lsaEnt
+}
+
+func (server *OSPFV2Server) originateNSSALSA(routeInfo RouteInfo, areaId uint32, flood bool) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err("No Lsdb Exist for:", lsdbKey)
+		return
+	}
+	lsaKey := LsaKey{
+		LSType:    NSSALSA,
+		LSId:      routeInfo.NwAddr & routeInfo.Netmask,
+		AdvRouter: server.globalData.RouterId,
+	}
+	oldLsaEnt, exist := lsdbEnt.NSSALsaMap[lsaKey]
+	lsaEnt := server.constructNSSALsa(routeInfo, areaId)
+	if exist {
+		lsaEnt.LsaMd.LSSequenceNum = oldLsaEnt.LsaMd.LSSequenceNum + 1
+	} else {
+		lsaEnt.LsaMd.LSSequenceNum = int(InitialSequenceNum)
+	}
+	lsaEnt.LsaMd.LSChecksum = 0
+	checksumOffset := uint16(14)
+	lsaEnc := encodeASExternalLsa(lsaEnt, lsaKey)
+	lsaEnt.LsaMd.LSChecksum = computeFletcherChecksum(lsaEnc[2:], checksumOffset)
+	lsdbEnt.NSSALsaMap[lsaKey] = lsaEnt
+	server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
+	selfOrigLsaEnt, _ := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+	selfOrigLsaEnt[lsaKey] = true
+	server.LsdbData.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+	if flood {
+		server.CreateAndSendMsgFromLsdbToFloodLsa(areaId, lsaKey, lsaEnt)
+	}
+	if !exist {
+		lsdbSlice := LsdbSliceStruct{
+			LsdbKey: lsdbKey,
+			LsaKey:  lsaKey,
+		}
+		server.GetBulkData.LsdbSlice = append(server.GetBulkData.LsdbSlice, lsdbSlice)
+	}
+}
+
+func (server *OSPFV2Server) generateNSSALSA(routeInfo RouteInfo) {
+	if server.globalData.ASBdrRtrStatus == false {
+		return
+	}
+	for _, areaId := range server.getNssaAreaIdList() {
+		server.originateNSSALSA(routeInfo, areaId, true)
+	}
+}
+
+func (server *OSPFV2Server) flushNSSALSA(routeInfo RouteInfo) {
+	if server.globalData.ASBdrRtrStatus == false {
+		return
+	}
+	lsaKey := LsaKey{
+		LSType:    NSSALSA,
+		LSId:      routeInfo.NwAddr & routeInfo.Netmask,
+		AdvRouter: server.globalData.RouterId,
+	}
+	for _, areaId := range server.getNssaAreaIdList() {
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+		if !exist {
+			server.logger.Err("No Lsdb Exist for:", lsdbKey)
+			continue
+		}
+		lsaEnt, exist := lsdbEnt.NSSALsaMap[lsaKey]
+		if !exist {
+			server.logger.Err("No LSA exist:", lsaKey)
+			continue
+		}
+		selfOrigLsaEnt, _ := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+		lsaEnt.LsaMd.LSAge = MAX_AGE
+		server.CreateAndSendMsgFromLsdbToFloodLsa(areaId, lsaKey, lsaEnt)
+		delete(selfOrigLsaEnt, lsaKey)
+		delete(lsdbEnt.NSSALsaMap, lsaKey)
+		server.LsdbData.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
+	}
+}
+
+func (server *OSPFV2Server) GenerateAllNSSALSA(areaId uint32) {
+	if server.globalData.ASBdrRtrStatus == false {
+		return
+	}
+	areaEnt, err := server.GetAreaConfForGivenArea(areaId)
+	if err != nil {
+		server.logger.Err("No such area exist")
+		return
+	}
+	isNssa, _ := server.isNssaArea(areaId)
+	if areaEnt.AdminState == false || !isNssa {
+		return
+	}
+	for route, _ := range server.LsdbData.ExtRouteInfoMap {
+		server.originateNSSALSA(route, areaId, false)
+	}
+}
+
+func (server *OSPFV2Server) reGenerateNSSALSAForGivenArea(routeInfo RouteInfo, areaId uint32) {
+	if server.globalData.ASBdrRtrStatus == false {
+		return
+	}
+	areaEnt, err := server.GetAreaConfForGivenArea(areaId)
+	if err != nil {
+		server.logger.Err("Error: Unable to find the areaConf for:", areaId)
+		return
+	}
+	isNssa, _ := server.isNssaArea(areaId)
+	if areaEnt.AdminState == false || !isNssa {
+		return
+	}
+	server.originateNSSALSA(routeInfo, areaId, true)
+}
+
+/*
+ Records the border routers with an intra area path in the area. The area
+ routing tables do not outlive the SPF run, the translator election runs
+ after it.
+*/
+func (server *OSPFV2Server) updateAreaBdrRtrMap(areaId uint32) {
+	areaIdKey := AreaIdKey{
+		AreaId: areaId,
+	}
+	bdrRtrMap := make(map[uint32]bool)
+	for rKey, rEnt := range server.RoutingTblData.TempAreaRoutingTbl[areaIdKey].RoutingTblMap {
+		if (rKey.DestType == AreaBdrRouter || rKey.DestType == ASAreaBdrRouter) &&
+			rEnt.PathType == IntraArea {
+			bdrRtrMap[rKey.DestId] = true
+		}
+	}
+	server.RoutingTblData.AreaBdrRtrMap[areaId] = bdrRtrMap
+}
+
+/*
+ RFC 3101 3.1
+ Translator election. A border router configured to always translate
+ wins unconditionally. Otherwise the reachable NSSA border router with
+ the highest Router ID translates, unless another border router sets
+ the Nt bit.
+*/
+func (server *OSPFV2Server) electNssaTranslator(areaId uint32) bool {
+	areaEnt, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return false
+	}
+	if areaEnt.NssaTranslatorRole == objects.NSSA_TRANSLATOR_ROLE_ALWAYS {
+		return true
+	}
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+	if !exist {
+		return false
+	}
+	rtrId := server.globalData.RouterId
+	// Only the border routers reachable within the NSSA take part, a
+	// router reachable through another area is not a candidate
+	bdrRtrMap := server.RoutingTblData.AreaBdrRtrMap[areaId]
+	for lsaKey, lsaEnt := range lsdbEnt.RouterLsaMap {
+		if lsaKey.AdvRouter == rtrId ||
+			lsaEnt.BitB == false ||
+			lsaEnt.LsaMd.LSAge == MAX_AGE ||
+			!bdrRtrMap[lsaKey.AdvRouter] {
+			continue
+		}
+		if lsaEnt.BitNt == true ||
+			lsaKey.AdvRouter > rtrId {
+			return false
+		}
+	}
+	return true
+}
+
+func (server *OSPFV2Server) updateNssaTranslatorState(areaId uint32) uint8 {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	ent, _ := server.LsdbData.NssaTranslatorMap[lsdbKey]
+	areaEnt, _ := server.AreaConfMap[areaId]
+	if server.globalData.AreaBdrRtrStatus == false {
+		ent.State = objects.NSSA_TRANSLATOR_STATE_DISABLED
+		ent.StabilityExpiry = time.Time{}
+	} else if server.electNssaTranslator(areaId) {
+		if areaEnt.NssaTranslatorRole == objects.NSSA_TRANSLATOR_ROLE_ALWAYS {
+			ent.State = objects.NSSA_TRANSLATOR_STATE_ENABLED
+		} else {
+			ent.State = objects.NSSA_TRANSLATOR_STATE_ELECTED
+		}
+		ent.StabilityExpiry = time.Time{}
+	} else if ent.State != objects.NSSA_TRANSLATOR_STATE_DISABLED {
+		// Lost the election, keep translating for the stability interval
+		if ent.StabilityExpiry.IsZero() {
+			ent.StabilityExpiry = time.Now().Add(NSSA_TRANSLATOR_STABILITY_INTERVAL)
+		} else if time.Now().After(ent.StabilityExpiry) {
+			ent.State = objects.NSSA_TRANSLATOR_STATE_DISABLED
+			ent.StabilityExpiry = time.Time{}
+		}
+	}
+	server.LsdbData.NssaTranslatorMap[lsdbKey] = ent
+	return ent.State
+}
+
+func (server *OSPFV2Server) isNssaTranslatorStabilityExpired() bool {
+	for _, ent := range server.LsdbData.NssaTranslatorMap {
+		if !ent.StabilityExpiry.IsZero() &&
+			time.Now().After(ent.StabilityExpiry) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ RFC 3101 3.2
+ Only Type-7 LSAs with the P-bit set and a non zero forwarding address,
+ which were selected for the routing table, are translated.
+*/
+func (server *OSPFV2Server) isNSSALsaTranslatable(areaId uint32, lsaKey LsaKey, lsaEnt ASExternalLsa) bool {
+	if lsaKey.AdvRouter == server.globalData.RouterId ||
+		lsaEnt.LsaMd.Options&NPOption == 0 ||
+		lsaEnt.FwdAddr == 0 ||
+		lsaEnt.Metric == LSInfinity ||
+		lsaEnt.LsaMd.LSAge == MAX_AGE {
+		return false
+	}
+	rKey := RoutingTblEntryKey{
+		DestId:   lsaKey.LSId & lsaEnt.Netmask,
+		AddrMask: lsaEnt.Netmask,
+		DestType: Network,
+	}
+	rEnt, exist := server.RoutingTblData.GlobalRoutingTbl[rKey]
+	if !exist ||
+		rEnt.AreaId != areaId ||
+		(rEnt.RoutingTblEnt.PathType != Type1Ext &&
+			rEnt.RoutingTblEnt.PathType != Type2Ext) {
+		return false
+	}
+	return true
+}
+
+func (server *OSPFV2Server) getExtAreaIdList() []uint32 {
+	var areaIdList []uint32
+	for areaId, areaEnt := range server.AreaConfMap {
+		if areaEnt.AdminState == false ||
+			areaEnt.ImportASExtern == false {
+			continue
+		}
+		areaIdList = append(areaIdList, areaId)
+	}
+	return areaIdList
+}
+
+func (server *OSPFV2Server) installTranslatedLsa(lsaKey LsaKey, lsa ASExternalLsa) {
+	checksumOffset := uint16(14)
+	for _, areaId := range server.getExtAreaIdList() {
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+		if !exist {
+			server.logger.Err("No Lsdb Exist for:", lsdbKey)
+			continue
+		}
+		lsaEnt, exist := lsdbEnt.ASExternalLsaMap[lsaKey]
+		if exist &&
+			lsaEnt.Metric == lsa.Metric &&
+			lsaEnt.BitE == lsa.BitE &&
+			lsaEnt.FwdAddr == lsa.FwdAddr &&
+			lsaEnt.ExtRouteTag == lsa.ExtRouteTag &&
+			lsaEnt.Netmask == lsa.Netmask &&
+			lsaEnt.LsaMd.LSAge < LS_REFRESH_TIME {
+			continue
+		}
+		newLsaEnt := lsa
+		if exist {
+			newLsaEnt.LsaMd.LSSequenceNum = lsaEnt.LsaMd.LSSequenceNum + 1
+		} else {
+			newLsaEnt.LsaMd.LSSequenceNum = int(InitialSequenceNum)
+		}
+		newLsaEnt.LsaMd.LSChecksum = 0
+		lsaEnc := encodeASExternalLsa(newLsaEnt, lsaKey)
+		newLsaEnt.LsaMd.LSChecksum = computeFletcherChecksum(lsaEnc[2:], checksumOffset)
+		lsdbEnt.ASExternalLsaMap[lsaKey] = newLsaEnt
+		server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
+		selfOrigLsaEnt, _ := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+		selfOrigLsaEnt[lsaKey] = true
+		server.LsdbData.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		server.CreateAndSendMsgFromLsdbToFloodLsa(areaId, lsaKey, newLsaEnt)
+		if !exist {
+			lsdbSlice := LsdbSliceStruct{
+				LsdbKey: lsdbKey,
+				LsaKey:  lsaKey,
+			}
+			server.GetBulkData.LsdbSlice = append(server.GetBulkData.LsdbSlice, lsdbSlice)
+		}
+	}
+}
+
+func (server *OSPFV2Server) flushTranslatedLsa(lsaKey LsaKey) {
+	for _, areaId := range server.getExtAreaIdList() {
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+		if !exist {
+			continue
+		}
+		lsaEnt, exist := lsdbEnt.ASExternalLsaMap[lsaKey]
+		if !exist {
+			continue
+		}
+		selfOrigLsaEnt, _ := server.LsdbData.AreaSelfOrigLsa[lsdbKey]
+		lsaEnt.LsaMd.LSAge = MAX_AGE
+		server.CreateAndSendMsgFromLsdbToFloodLsa(areaId, lsaKey, lsaEnt)
+		delete(selfOrigLsaEnt, lsaKey)
+		delete(lsdbEnt.ASExternalLsaMap, lsaKey)
+		server.LsdbData.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		server.LsdbData.AreaLsdb[lsdbKey] = lsdbEnt
+	}
+}
+
+/*
+ RFC 3101 3.2
+ An NSSA translator originates an AS External LSA for every translatable
+ Type-7 LSA of the NSSA and flushes the ones which are no longer
+ translated. Locally originated external routes take precedence.
+*/
+func (server *OSPFV2Server) processNssaTranslation() {
+	translatedLsaMap := make(map[LsaKey]ASExternalLsa)
+	for _, areaId := range server.getNssaAreaIdList() {
+		state := server.updateNssaTranslatorState(areaId)
+		if state == objects.NSSA_TRANSLATOR_STATE_DISABLED {
+			continue
+		}
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsdbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+		if !exist {
+			continue
+		}
+		for nKey, nEnt := range lsdbEnt.NSSALsaMap {
+			if !server.isNSSALsaTranslatable(areaId, nKey, nEnt) {
+				continue
+			}
+			lsaKey := LsaKey{
+				LSType:    ASExternalLSA,
+				LSId:      nKey.LSId & nEnt.Netmask,
+				AdvRouter: server.globalData.RouterId,
+			}
+			oldEnt, exist := translatedLsaMap[lsaKey]
+			if exist && oldEnt.Metric <= nEnt.Metric {
+				continue
+			}
+			var lsaEnt ASExternalLsa
+			lsaEnt.LsaMd.LSAge = 0
+			lsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
+			lsaEnt.LsaMd.Options = EOption
+			lsaEnt.BitE = nEnt.BitE
+			lsaEnt.ExtRouteTag = nEnt.ExtRouteTag
+			lsaEnt.FwdAddr = nEnt.FwdAddr
+			lsaEnt.Metric = nEnt.Metric
+			lsaEnt.Netmask = nEnt.Netmask
+			translatedLsaMap[lsaKey] = lsaEnt
+		}
+	}
+	for routeInfo, _ := range server.LsdbData.ExtRouteInfoMap {
+		lsaKey := LsaKey{
+			LSType:    ASExternalLSA,
+			LSId:      routeInfo.NwAddr & routeInfo.Netmask,
+			AdvRouter: server.globalData.RouterId,
+		}
+		delete(translatedLsaMap, lsaKey)
+	}
+	for lsaKey, _ := range server.LsdbData.TranslatedLsaMap {
+		_, exist := translatedLsaMap[lsaKey]
+		if !exist {
+			server.flushTranslatedLsa(lsaKey)
+			delete(server.LsdbData.TranslatedLsaMap, lsaKey)
+		}
+	}
+	for lsaKey, lsaEnt := range translatedLsaMap {
+		server.installTranslatedLsa(lsaKey, lsaEnt)
+		server.LsdbData.TranslatedLsaMap[lsaKey] = true
+	}
+}
@@ -0,0 +1,142 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/ospfv2/objects"
+	"testing"
+)
+
+const (
+	testNssaAreaId  uint32 = 1
+	testOtherAreaId uint32 = 2
+)
+
+// Border router between the backbone, an NSSA and a regular area
+func buildTestNssaServer(t *testing.T, role uint8) *OSPFV2Server {
+	server := newTestServer(t)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 0, ImportASExtern: true})
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: testNssaAreaId, Nssa: true, NssaTranslatorRole: role})
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: testOtherAreaId, ImportASExtern: true})
+	server.LsdbData.AreaLsdb[LsdbKey{AreaId: testNssaAreaId}] = LSDatabase{RouterLsaMap: make(map[LsaKey]RouterLsa)}
+	server.RoutingTblData.AreaBdrRtrMap = make(map[uint32]map[uint32]bool)
+	return server
+}
+
+func addTestBdrRtr(server *OSPFV2Server, rtrId uint32, lsa RouterLsa, reachableAreaId uint32) {
+	lsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      rtrId,
+		AdvRouter: rtrId,
+	}
+	server.LsdbData.AreaLsdb[LsdbKey{AreaId: testNssaAreaId}].RouterLsaMap[lsaKey] = lsa
+	bdrRtrMap, exist := server.RoutingTblData.AreaBdrRtrMap[reachableAreaId]
+	if !exist {
+		bdrRtrMap = make(map[uint32]bool)
+		server.RoutingTblData.AreaBdrRtrMap[reachableAreaId] = bdrRtrMap
+	}
+	bdrRtrMap[rtrId] = true
+}
+
+func TestElectNssaTranslator(t *testing.T) {
+	higherRtrId := testRouterId + 1
+	lowerRtrId := testRouterId - 1
+	tests := []struct {
+		name    string
+		role    uint8
+		rtrId   uint32
+		lsa     RouterLsa
+		areaId  uint32
+		elected bool
+	}{
+		{"no other border router", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, 0, RouterLsa{}, 0, true},
+		{"always translates", objects.NSSA_TRANSLATOR_ROLE_ALWAYS, higherRtrId, RouterLsa{BitB: true, BitNt: true}, testNssaAreaId, true},
+		{"higher router id", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, higherRtrId, RouterLsa{BitB: true}, testNssaAreaId, false},
+		{"lower router id", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, lowerRtrId, RouterLsa{BitB: true}, testNssaAreaId, true},
+		{"lower router id with the Nt bit", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, lowerRtrId, RouterLsa{BitB: true, BitNt: true}, testNssaAreaId, false},
+		{"not a border router", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, higherRtrId, RouterLsa{}, testNssaAreaId, true},
+		{"flushed router lsa", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, higherRtrId, RouterLsa{LsaMd: LsaMetadata{LSAge: MAX_AGE}, BitB: true}, testNssaAreaId, true},
+		{"reachable through another area only", objects.NSSA_TRANSLATOR_ROLE_CANDIDATE, higherRtrId, RouterLsa{BitB: true}, testOtherAreaId, true},
+	}
+	for _, test := range tests {
+		server := buildTestNssaServer(t, test.role)
+		if test.rtrId != 0 {
+			addTestBdrRtr(server, test.rtrId, test.lsa, test.areaId)
+		}
+		if elected := server.electNssaTranslator(testNssaAreaId); elected != test.elected {
+			t.Error(test.name, ": elected", elected, "expected", test.elected)
+		}
+	}
+}
+
+func TestUpdateAreaBdrRtrMap(t *testing.T) {
+	server := buildTestNssaServer(t, objects.NSSA_TRANSLATOR_ROLE_CANDIDATE)
+	intraAreaAbr := RoutingTblEntryKey{DestId: 0x01010101, DestType: AreaBdrRouter}
+	intraAreaAsbrAbr := RoutingTblEntryKey{DestId: 0x02020202, DestType: ASAreaBdrRouter}
+	interAreaAbr := RoutingTblEntryKey{DestId: 0x03030303, DestType: AreaBdrRouter}
+	internalRtr := RoutingTblEntryKey{DestId: 0x04040404, DestType: InternalRouter}
+	server.RoutingTblData.TempAreaRoutingTbl = map[AreaIdKey]AreaRoutingTbl{
+		AreaIdKey{AreaId: testNssaAreaId}: AreaRoutingTbl{
+			RoutingTblMap: map[RoutingTblEntryKey]RoutingTblEntry{
+				intraAreaAbr:     RoutingTblEntry{PathType: IntraArea},
+				intraAreaAsbrAbr: RoutingTblEntry{PathType: IntraArea},
+				interAreaAbr:     RoutingTblEntry{PathType: InterArea},
+				internalRtr:      RoutingTblEntry{PathType: IntraArea},
+			},
+		},
+	}
+	server.updateAreaBdrRtrMap(testNssaAreaId)
+	bdrRtrMap := server.RoutingTblData.AreaBdrRtrMap[testNssaAreaId]
+	if len(bdrRtrMap) != 2 || !bdrRtrMap[intraAreaAbr.DestId] || !bdrRtrMap[intraAreaAsbrAbr.DestId] {
+		t.Error("Unexpected border routers", bdrRtrMap)
+	}
+	server.updateAreaBdrRtrMap(testOtherAreaId)
+	if bdrRtrMap, exist := server.RoutingTblData.AreaBdrRtrMap[testOtherAreaId]; !exist || len(bdrRtrMap) != 0 {
+		t.Error("Unexpected border routers of an area without routing table", bdrRtrMap)
+	}
+}
+
+func TestCreateNssaArea(t *testing.T) {
+	server := newTestServer(t)
+	tests := []struct {
+		name  string
+		cfg   objects.Ospfv2Area
+		valid bool
+	}{
+		{"nssa", objects.Ospfv2Area{AreaId: testNssaAreaId, Nssa: true, NoSummary: true}, true},
+		{"backbone as nssa", objects.Ospfv2Area{AreaId: 0, Nssa: true}, false},
+		{"nssa importing AS external LSAs", objects.Ospfv2Area{AreaId: testOtherAreaId, Nssa: true, ImportASExtern: true}, false},
+	}
+	for _, test := range tests {
+		if _, err := server.createArea(&test.cfg); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+	if isNssa, _ := server.isNssaArea(testNssaAreaId); !isNssa {
+		t.Error("Area", testNssaAreaId, "is not an NSSA")
+	}
+	if isStub, _ := server.isStubArea(testNssaAreaId); !isStub {
+		t.Error("NSSA", testNssaAreaId, "is not stubby")
+	}
+}
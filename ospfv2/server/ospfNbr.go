@@ -27,6 +27,7 @@ import (
 	"errors"
 	"fmt"
 	"l3/ospfv2/objects"
+	"time"
 )
 
 func newDbdMsg(key NbrConfKey, dbd_data NbrDbdData) NbrDbdMsg {
@@ -200,10 +201,27 @@ func (server *OSPFV2Server) getNbrState(ipAddr, addressLessIfIdx uint32) (*objec
 	retObj.RtrId = nbr.NbrRtrId
 	retObj.State = uint8(nbr.State)
 	retObj.Options = int32(nbr.NbrOption)
+	retObj.HelperStatus, retObj.HelperAge, retObj.HelperExitReason = getNbrGrHelperState(nbr)
 
 	return &retObj, nil
 }
 
+// Helper status, remaining grace period and last exit reason (RFC 4750)
+func getNbrGrHelperState(nbr NbrConf) (uint8, uint32, uint8) {
+	exitReason := nbr.GrHelperExitReason
+	if exitReason == 0 {
+		exitReason = objects.GR_EXIT_REASON_NONE
+	}
+	if !nbr.GrHelper {
+		return objects.GR_HELPER_STATUS_NOT_HELPING, 0, exitReason
+	}
+	remaining := nbr.GrHelperExpiry.Sub(time.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return objects.GR_HELPER_STATUS_HELPING, uint32(remaining.Seconds()), exitReason
+}
+
 func (server *OSPFV2Server) getBulkNbrState(fromIdx, cnt int) (*objects.Ospfv2NbrStateGetInfo, error) {
 	var retObj objects.Ospfv2NbrStateGetInfo
 	count := 0
@@ -229,6 +247,7 @@ func (server *OSPFV2Server) getBulkNbrState(fromIdx, cnt int) (*objects.Ospfv2Nb
 		obj.Options = int32(nbrEnt.NbrOption)
 		obj.RtrId = uint32(nbrEnt.NbrRtrId)
 		obj.State = uint8(nbrEnt.State)
+		obj.HelperStatus, obj.HelperAge, obj.HelperExitReason = getNbrGrHelperState(nbrEnt)
 		retObj.List = append(retObj.List, &obj)
 		count++
 		idx++
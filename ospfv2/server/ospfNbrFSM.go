@@ -24,6 +24,8 @@
 package server
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"l3/ospfv2/objects"
 	"time"
@@ -78,6 +80,17 @@ func (server *OSPFV2Server) ProcessNbrFSM() {
 				server.ProcessNbrDeadFromIntf(msg.IntfKey)
 			}
 
+		case msg := <-server.NbrConfData.grHelperExitCh:
+			if msg.AreaWide {
+				server.exitGrHelperForArea(msg.AreaId, false, 0, msg.Reason)
+			} else {
+				server.exitGrHelper(msg.NbrKey, msg.Reason)
+			}
+
+		case msg := <-server.NbrConfData.nbrBfdDownCh:
+			server.logger.Info("Nbr : BFD session down ", msg.NbrIP)
+			server.ProcessNbrBfdDown(msg)
+
 			//NbrFsmCtrlCh
 		case _ = <-server.NbrConfData.nbrFSMCtrlCh:
 			server.logger.Debug("Nbr : FSM stopping.. ")
@@ -249,6 +262,7 @@ func (server *OSPFV2Server) ProcessNbrInit(nbrKey NbrConfKey) {
 
 func (server *OSPFV2Server) ProcessNbrTwoway(nbrKey NbrConfKey) {
 	server.ProcessNbrFsmStart(nbrKey)
+	server.createNbrBfdSession(nbrKey)
 }
 
 func (server *OSPFV2Server) ProcessNbrExstart(nbrKey NbrConfKey, nbrConf NbrConf, nbrDbPkt NbrDbdData) {
@@ -484,6 +498,15 @@ func (server *OSPFV2Server) ProcessNbrFull(nbrKey NbrConfKey) {
 
 		server.SendMsgFromNbrToLsdb(msg)
 	}
+	server.checkGracefulRestartDone()
+	if intf.Type == objects.INTF_TYPE_VIRTUAL {
+		// Virtual link and V bit are advertised only on full adjacency
+		server.SendMsgToGenerateRouterLSA(intf.AreaId)
+		vKey, exist := server.getVirtualLinkConfKey(nbrConf.IntfKey)
+		if exist {
+			server.SendMsgToGenerateRouterLSA(vKey.TransitAreaId)
+		}
+	}
 	floodMsg := NbrToFloodMsg{
 		NbrKey:  nbrKey,
 		MsgType: LSA_FLOOD_NBR_FULL,
@@ -539,6 +562,11 @@ func (server *OSPFV2Server) ProcessNbrDeadFromIntf(key IntfConfKey) {
 		}
 		nbrConf.NbrDeadTimer.Stop()
 		nbrConf.NbrDeadTimer = nil
+		if nbrConf.GrHelperTimer != nil {
+			nbrConf.GrHelperTimer.Stop()
+			nbrConf.GrHelperTimer = nil
+		}
+		server.deleteNbrBfdSession(nbr, nbrConf)
 		if len(nbrConf.NbrReqList) > 0 {
 			nbrConf.NbrReqList = nbrConf.NbrReqList[:len(nbrConf.NbrReqList)-1]
 		}
@@ -569,6 +597,7 @@ func (server *OSPFV2Server) ProcessNbrDeadFromIntf(key IntfConfKey) {
 
 			server.SendMsgFromNbrToLsdb(lsdbMsg)
 		}
+		server.exitGrHelperForArea(intf.AreaId, false, 0, objects.GR_EXIT_REASON_TOPOLOGY_CHANGED)
 	}
 	server.logger.Debug("nbr : Intf down processing done. ")
 }
@@ -578,6 +607,11 @@ func (server *OSPFV2Server) ProcessNbrDead(nbrKey NbrConfKey) {
 	var nbr_entry_dead_func func()
 	nbr_entry_dead_func = func() {
 		nbrConf, _ := server.NbrConfMap[nbrKey]
+		if nbrConf.GrHelper {
+			/* Restarting nbr is kept in router LSA till helper mode exits */
+			server.logger.Info(fmt.Sprintln("NBRSCAN: DEAD ignored, helping restarting nbr ", nbrKey))
+			return
+		}
 
 		server.logger.Info(fmt.Sprintln("NBRSCAN: DEAD ", nbrKey))
 		server.logger.Info(fmt.Sprintln("DEAD: start processing nbr dead ", nbrKey))
@@ -612,6 +646,13 @@ func (server *OSPFV2Server) ProcessNbrDead(nbrKey NbrConfKey) {
 					NbrRtrId: nbrConf.NbrRtrId,
 				}
 				server.SendMsgToLsdbFromNbrFSMForNbrDead(nbrDeadMsg)
+				if server.isGrHelperActive() {
+					server.NbrConfData.grHelperExitCh <- GrHelperExitMsg{
+						AreaId:   intfConfEnt.AreaId,
+						AreaWide: true,
+						Reason:   objects.GR_EXIT_REASON_TOPOLOGY_CHANGED,
+					}
+				}
 			}
 			//send message to lsdb if I am DR.
 			intf, valid := server.IntfConfMap[nbrConf.IntfKey]
@@ -652,6 +693,7 @@ func (server *OSPFV2Server) ProcessNbrDead(nbrKey NbrConfKey) {
 			nbrConf.NbrReqList = nil
 			nbrConf.NbrRetxList = nil
 			nbrConf.NbrDBSummaryList = nil
+			server.deleteNbrBfdSession(nbrKey, nbrConf)
 			//delete neighbor from map
 			delete(server.NbrConfMap, nbrKey)
 			server.logger.Info("Nbr: Deleted ", nbrKey)
@@ -666,6 +708,27 @@ func (server *OSPFV2Server) ProcessNbrDead(nbrKey NbrConfKey) {
 
 }
 
+// BFD reported the nbr down. Fire the dead timer right
+// away instead of waiting for RtrDeadInterval.
+func (server *OSPFV2Server) ProcessNbrBfdDown(msg NbrBfdDownMsg) {
+	for nbrKey, nbrConf := range server.NbrConfMap {
+		if nbrConf.NbrIP == msg.NbrIP && nbrConf.BfdSession {
+			server.KillNbr(nbrKey)
+		}
+	}
+}
+
+func (server *OSPFV2Server) KillNbr(nbrKey NbrConfKey) {
+	nbrConf, exist := server.NbrConfMap[nbrKey]
+	if !exist {
+		return
+	}
+	server.logger.Info("Nbr : KillNbr ", nbrKey)
+	if nbrConf.NbrDeadTimer != nil {
+		nbrConf.NbrDeadTimer.Reset(0)
+	}
+}
+
 func (server *OSPFV2Server) ProcessNbrUpdate(nbrKey NbrConfKey, nbrConf NbrConf) {
 	server.logger.Debug("Nbr : ", nbrConf)
 	if nbrConf.NbrDeadTimer != nil {
@@ -726,3 +789,142 @@ func (server *OSPFV2Server) ResetNbrData(nbr NbrConfKey, intf IntfConfKey) {
 	server.NbrConfData.IntfToNbrMap[intf] = newList
 	server.logger.Info(fmt.Sprintln("Nbr: nbrList ", newList))
 }
+
+/**** Graceful restart helper (RFC 3623 section 3) ****/
+func (server *OSPFV2Server) isGrHelperActive() bool {
+	for _, nbrConf := range server.NbrConfMap {
+		if nbrConf.GrHelper {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *OSPFV2Server) processRecvdGraceLsa(nbrKey NbrConfKey, data []byte) {
+	var lsa GraceLsa
+	var lsaKey LsaKey
+	err := decodeGraceLsa(data, &lsa, &lsaKey)
+	if err != nil {
+		server.logger.Err("Nbr: Invalid grace LSA from ", nbrKey, err)
+		return
+	}
+	if server.selfGenLsaCheck(lsaKey) || server.isGracefulRestartInProgress() {
+		return
+	}
+	nbrConf, exist := server.NbrConfMap[nbrKey]
+	if !exist {
+		return
+	}
+	/* Grace LSA may be reflooded on the segment by the DR */
+	rstNbrKey := nbrKey
+	rstNbrConf := nbrConf
+	if nbrConf.NbrRtrId != lsaKey.AdvRouter {
+		exist = false
+		for key, conf := range server.NbrConfMap {
+			if conf.IntfKey == nbrConf.IntfKey &&
+				conf.NbrRtrId == lsaKey.AdvRouter {
+				rstNbrKey = key
+				rstNbrConf = conf
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			server.logger.Info("Nbr: Grace LSA from unknown nbr ", lsaKey.AdvRouter)
+			return
+		}
+	}
+	if lsa.LsaMd.LSAge >= MAX_AGE {
+		/* Grace LSA flushed, restart is over */
+		server.exitGrHelper(rstNbrKey, objects.GR_EXIT_REASON_COMPLETED)
+		return
+	}
+	if server.globalData.GrHelper == false {
+		server.logger.Info("Nbr: Helper mode disabled, ignore grace LSA from ", lsaKey.AdvRouter)
+		return
+	}
+	if rstNbrConf.State != NbrFull && !rstNbrConf.GrHelper {
+		server.logger.Info("Nbr: Nbr not full, ignore grace LSA from ", lsaKey.AdvRouter)
+		return
+	}
+	remaining := int(lsa.GracePeriod) - int(lsa.LsaMd.LSAge)
+	if remaining <= 0 {
+		server.logger.Info("Nbr: Grace period expired, ignore grace LSA from ", lsaKey.AdvRouter)
+		return
+	}
+	gracePeriod := time.Duration(remaining) * time.Second
+	if rstNbrConf.GrHelperTimer != nil {
+		rstNbrConf.GrHelperTimer.Stop()
+	}
+	rstNbrConf.GrHelper = true
+	rstNbrConf.GrHelperExitReason = objects.GR_EXIT_REASON_IN_PROGRESS
+	rstNbrConf.GrHelperExpiry = time.Now().Add(gracePeriod)
+	rstNbrConf.GrHelperTimer = time.AfterFunc(gracePeriod, func() {
+		server.NbrConfData.grHelperExitCh <- GrHelperExitMsg{
+			NbrKey: rstNbrKey,
+			Reason: objects.GR_EXIT_REASON_TIMED_OUT,
+		}
+	})
+	server.NbrConfMap[rstNbrKey] = rstNbrConf
+	server.logger.Info("Nbr: Helper mode entered for ", rstNbrKey, " grace period ", remaining)
+}
+
+func (server *OSPFV2Server) exitGrHelper(nbrKey NbrConfKey, reason uint8) {
+	nbrConf, exist := server.NbrConfMap[nbrKey]
+	if !exist || !nbrConf.GrHelper {
+		return
+	}
+	server.logger.Info("Nbr: Helper mode exit for ", nbrKey, " reason ", reason)
+	nbrConf.GrHelper = false
+	nbrConf.GrHelperExitReason = reason
+	if nbrConf.GrHelperTimer != nil {
+		nbrConf.GrHelperTimer.Stop()
+		nbrConf.GrHelperTimer = nil
+	}
+	server.NbrConfMap[nbrKey] = nbrConf
+	if nbrConf.NbrDeadTimer != nil && !nbrConf.NbrDeadTimer.Stop() {
+		/* Dead interval expired while helping */
+		nbrConf.NbrDeadTimer.Reset(0)
+		return
+	}
+	if nbrConf.NbrDeadTimer != nil {
+		nbrConf.NbrDeadTimer.Reset(nbrConf.NbrDeadTimeDuration)
+	}
+	intf, exist := server.IntfConfMap[nbrConf.IntfKey]
+	if exist {
+		server.SendMsgToGenerateRouterLSA(intf.AreaId)
+	}
+}
+
+// Exit helper mode for all the nbrs of the area (all areas if allAreas
+// is set) except the one with rtrId
+func (server *OSPFV2Server) exitGrHelperForArea(areaId uint32, allAreas bool, rtrId uint32, reason uint8) {
+	for nbrKey, nbrConf := range server.NbrConfMap {
+		if !nbrConf.GrHelper || nbrConf.NbrRtrId == rtrId {
+			continue
+		}
+		intf, exist := server.IntfConfMap[nbrConf.IntfKey]
+		if !allAreas && exist && intf.AreaId != areaId {
+			continue
+		}
+		server.exitGrHelper(nbrKey, reason)
+	}
+}
+
+// Change in the content of an LSA which would be flooded to the
+// restarting nbr terminates helper mode
+func (server *OSPFV2Server) checkGrHelperTopologyChange(areaId uint32, lsaKey LsaKey, data []byte) {
+	if !server.isGrHelperActive() {
+		return
+	}
+	lsaEnc := server.GetLsaByteFromLsaKey(areaId, lsaKey)
+	if lsaEnc != nil &&
+		binary.BigEndian.Uint16(data[0:2]) < MAX_AGE &&
+		len(lsaEnc) == len(data) &&
+		bytes.Equal(lsaEnc[OSPF_LSA_HEADER_SIZE:], data[OSPF_LSA_HEADER_SIZE:]) {
+		/* Refresh */
+		return
+	}
+	allAreas := lsaKey.LSType == ASExternalLSA
+	server.exitGrHelperForArea(areaId, allAreas, lsaKey.AdvRouter, objects.GR_EXIT_REASON_TOPOLOGY_CHANGED)
+}
@@ -100,6 +100,11 @@ func (server *OSPFV2Server) generateDbSummaryList(nbrConfKey NbrConfKey) {
 		db_list = append(db_list, asExternal_list...)
 	}
 
+	nssa_list := server.generateDbNssaList(areaId)
+	if nssa_list != nil {
+		db_list = append(db_list, nssa_list...)
+	}
+
 	for _, lsa := range db_list {
 		rtr_id := convertUint32ToDotNotation(lsa.adv_router_id)
 		server.logger.Debug(lsa, ": ", rtr_id, " lsatype ", lsa.ls_type)
@@ -144,6 +149,41 @@ func (server *OSPFV2Server) generateDbasExternalList(self_areaId uint32) []*ospf
 	return db_list
 }
 
+/*@fn generateDbNssaList
+This function generates the NSSA LSA list if the area is NSSA
+*/
+func (server *OSPFV2Server) generateDbNssaList(self_areaId uint32) []*ospfLSAHeader {
+	isNssa, _ := server.isNssaArea(self_areaId)
+	if !isNssa {
+		return nil
+	}
+	db_list := []*ospfLSAHeader{}
+	lsdbKey := LsdbKey{
+		AreaId: self_areaId,
+	}
+
+	area_lsa, exist := server.LsdbData.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err(fmt.Sprintln("negotiation: NSSA LSA doesnt exist"))
+		return nil
+	}
+	nssa_lsdb := area_lsa.NSSALsaMap
+
+	for lsaKey, _ := range nssa_lsdb {
+		dnslsa, ret := server.getNSSALsaFromLsdb(self_areaId, lsaKey)
+		if ret == LsdbEntryNotFound {
+			continue
+		}
+		db_nssa := getLsaHeaderFromLsa(dnslsa.LsaMd.LSAge, dnslsa.LsaMd.Options,
+			NSSALSA, lsaKey.LSId, lsaKey.AdvRouter,
+			uint32(dnslsa.LsaMd.LSSequenceNum), dnslsa.LsaMd.LSChecksum,
+			dnslsa.LsaMd.LSLen)
+		/* add entry to the db summary list  */
+		db_list = append(db_list, db_nssa)
+	}
+	return db_list
+}
+
 /* @fn generateDbsummaryLsaList
 This function will attach summary LSAs if the router is ABR
 */
@@ -283,6 +323,11 @@ func (server *OSPFV2Server) lsaAddCheck(lsaheader ospfLSAHeader,
 		dalsa, ret := server.getASExternalLsaFromLsdb(areaId, *lsa_key)
 		discard, _ = server.sanityCheckASExternalLsa(*alsa, dalsa, nbr, intf, ret, lsa_max_age)
 
+	case NSSALSA:
+		nslsa := NewASExternalLsa()
+		dnslsa, ret := server.getNSSALsaFromLsdb(areaId, *lsa_key)
+		discard, _ = server.sanityCheckNSSALsa(*nslsa, dnslsa, nbr, intf, ret, lsa_max_age)
+
 	}
 	if discard {
 		server.logger.Info(fmt.Sprintln("DBD: LSA is not added in the request list. Adv router ", adv_router,
@@ -168,9 +168,10 @@ func (server *OSPFV2Server) EncodeLSAReqPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaDataEnc...)
 	server.logger.Debug("OSPF LSA REQ:", ospf)
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	binary.BigEndian.PutUint64(ospf[16:24], ent.AuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
+	if ospf == nil {
+		return nil
+	}
 
 	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.Pktlen
 	var dstIp net.IP
@@ -320,7 +321,6 @@ func (server *OSPFV2Server) BuildLsaUpdPkt(intfKey IntfConfKey, ent IntfConf,
 		AreaId:   ent.AreaId,
 		Chksum:   0,
 		AuthType: ent.AuthType,
-		//authKey:        ent.AuthKey,
 	}
 
 	ospfPktlen := OSPF_HEADER_SIZE
@@ -334,9 +334,10 @@ func (server *OSPFV2Server) BuildLsaUpdPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaUpdEnc...)
 	//server.logger.Debug(fmt.Sprintln("OSPF LSA UPD:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	binary.BigEndian.PutUint64(ospf[16:24], ent.AuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
+	if ospf == nil {
+		return nil
+	}
 	srcIp := net.ParseIP(convertUint32ToDotNotation(ent.IpAddr))
 	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.Pktlen
 	ipLayer := layers.IPv4{
@@ -439,6 +440,22 @@ func (server *OSPFV2Server) ProcessLsaUpd(msg NbrLsaUpdMsg) {
 		if lsa_header.LSAge == LSA_MAX_AGE {
 			lsa_max_age = true
 		}
+		if lsa_header.LSType == LocalOpaqueLSA {
+			/* Grace LSA is not kept in lsdb nor flooded */
+			server.processRecvdGraceLsa(msg.nbrKey, currLsa)
+			lsaAckMsg := newNbrAckTxMsg()
+			lsaAckMsg.lsa_headers_byte = append(lsaAckMsg.lsa_headers_byte, lsa_header_byte...)
+			lsaAckMsg.nbrKey = msg.nbrKey
+			server.processTxLsaAck(*lsaAckMsg)
+			index = end_index
+			continue
+		}
+		if !server.isLsaTypeAllowedInArea(lsa_header.LSType, msg.areaId) {
+			server.logger.Debug("LSAUPD: Discard. LSA type ", lsa_header.LSType,
+				" not allowed in area ", msg.areaId)
+			index = end_index
+			continue
+		}
 		/* send message to lsdb */
 		lsdb_msg := RecvdLsaMsg{}
 		lsdbKey := LsdbKey{
@@ -460,6 +477,7 @@ func (server *OSPFV2Server) ProcessLsaUpd(msg NbrLsaUpdMsg) {
 
 			drlsa, ret := server.getRouterLsaFromLsdb(msg.areaId, *lsa_key)
 			discard, _ = server.sanityCheckRouterLsa(*rlsa, drlsa, nbr, intf, ret, lsa_max_age)
+			server.checkGracefulRestartConsistency(msg.areaId, *rlsa, *lsa_key)
 			lsdb_msg.LsaData = *rlsa
 			selfGenLsaMsg.LsaData = *rlsa
 
@@ -488,6 +506,14 @@ func (server *OSPFV2Server) ProcessLsaUpd(msg NbrLsaUpdMsg) {
 			discard, _ = server.sanityCheckASExternalLsa(*alsa, dalsa, nbr, intf, ret, lsa_max_age)
 			lsdb_msg.LsaData = *alsa
 			selfGenLsaMsg.LsaData = *alsa
+
+		case NSSALSA:
+			nslsa := NewASExternalLsa()
+			decodeASExternalLsa(currLsa, nslsa, lsa_key)
+			dnslsa, ret := server.getNSSALsaFromLsdb(msg.areaId, *lsa_key)
+			discard, _ = server.sanityCheckNSSALsa(*nslsa, dnslsa, nbr, intf, ret, lsa_max_age)
+			lsdb_msg.LsaData = *nslsa
+			selfGenLsaMsg.LsaData = *nslsa
 		}
 
 		lsid := lsa_header.LinkId
@@ -495,6 +521,10 @@ func (server *OSPFV2Server) ProcessLsaUpd(msg NbrLsaUpdMsg) {
 
 		self_gen := false
 		self_gen = server.selfGenLsaCheck(*lsa_key)
+		if self_gen && server.isGracefulRestartInProgress() {
+			/* LSAs of the previous instance are kept till graceful restart exits */
+			self_gen = false
+		}
 		if self_gen {
 			//send message to lsdb for self gen LSA
 			selfGenLsaMsg = RecvdSelfLsaMsg{
@@ -508,6 +538,7 @@ func (server *OSPFV2Server) ProcessLsaUpd(msg NbrLsaUpdMsg) {
 		}
 
 		if !discard && !self_gen {
+			server.checkGrHelperTopologyChange(msg.areaId, *lsa_key, currLsa)
 			server.logger.Debug("LSAUPD: add to lsdb lsid ", lsid, " router_id ", router_id, " lstype ", lsa_header.LSType)
 			lsdb_msg.MsgType = LSA_ADD
 			lsdb_msg.LsaKey = *lsa_key
@@ -593,9 +624,10 @@ func (server *OSPFV2Server) BuildLSAAckPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaAckEnc...)
 	//server.logger.Debug(fmt.Sprintln("OSPF LSA ACK:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	binary.BigEndian.PutUint64(ospf[16:24], ent.AuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
+	if ospf == nil {
+		return nil
+	}
 
 	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.Pktlen
 	if ent.FSMState == objects.INTF_FSM_STATE_P2P {
@@ -802,6 +834,15 @@ func (server *OSPFV2Server) generateLsaUpdUnicast(req ospfLSAReq, nbrKey NbrConf
 			server.logger.Debug("LSAREQ: AS external lsa not fount. lsaid ",
 				req.link_state_id, " lstype ", lsa_key.LSType, " adv_router ", lsa_key.AdvRouter, " areaid ", areaid)
 		}
+	case NSSALSA:
+		dnslsa, ret := server.getNSSALsaFromLsdb(areaid, *lsa_key)
+		if ret == LsdbEntryFound {
+			lsa_pkt = encodeASExternalLsa(dnslsa, *lsa_key)
+			flood = true
+		} else {
+			server.logger.Debug("LSAREQ: NSSA lsa not found. lsaid ",
+				req.link_state_id, " lstype ", lsa_key.LSType, " adv_router ", lsa_key.AdvRouter, " areaid ", areaid)
+		}
 	}
 	lsid := req.link_state_id
 	router_id := req.adv_router_id
@@ -54,6 +54,13 @@ type NbrConf struct {
 	NbrDBSummaryList []*ospfLSAHeader
 	NbrRetxList      []*ospfLSAHeader
 	NbrReqListIndex  int
+	//Graceful restart helper
+	GrHelper           bool
+	GrHelperTimer      *time.Timer
+	GrHelperExpiry     time.Time
+	GrHelperExitReason uint8
+	//BFD session created for this nbr
+	BfdSession bool
 }
 
 const (
@@ -158,6 +165,22 @@ type NbrStruct struct {
 	IntfToNbrMap          map[IntfConfKey][]NbrConfKey
 	nbrFSMCtrlCh          chan bool
 	nbrFSMCtrlReplyCh     chan bool
+	grHelperExitCh        chan GrHelperExitMsg
+	nbrBfdDownCh          chan NbrBfdDownMsg
+}
+
+// Exit helper mode for the given nbr, or for all the
+// nbrs in the area on a topology change
+type GrHelperExitMsg struct {
+	NbrKey   NbrConfKey
+	AreaId   uint32
+	AreaWide bool
+	Reason   uint8
+}
+
+// BFD session to the nbr went down
+type NbrBfdDownMsg struct {
+	NbrIP uint32
 }
 
 func (server *OSPFV2Server) InitNbrStruct() {
@@ -169,17 +192,24 @@ func (server *OSPFV2Server) InitNbrStruct() {
 	server.NbrConfData.nbrLsaAckEventCh = make(chan NbrLsaAckMsg)
 	server.NbrConfData.nbrFSMCtrlCh = make(chan bool)
 	server.NbrConfData.nbrFSMCtrlReplyCh = make(chan bool)
+	server.NbrConfData.grHelperExitCh = make(chan GrHelperExitMsg)
+	// Buffered so that the server routine never waits on Nbr FSM
+	server.NbrConfData.nbrBfdDownCh = make(chan NbrBfdDownMsg, 10)
 	server.logger.Debug("Nbr: InitNbrStruct done ")
 }
 
 func (server *OSPFV2Server) DeinitNbrStruct() {
 
-	for _, nbr := range server.NbrConfMap {
+	for nbrKey, nbr := range server.NbrConfMap {
+		server.deleteNbrBfdSession(nbrKey, nbr)
 		nbr.NbrReqList = nil
 		nbr.NbrDBSummaryList = nil
 		nbr.NbrRetxList = nil
 		nbr.NbrDeadTimer = nil
 		nbr.NbrLsaRxTimer = nil
+		if nbr.GrHelperTimer != nil {
+			nbr.GrHelperTimer.Stop()
+		}
 	}
 	server.NbrConfMap = nil
 }
@@ -0,0 +1,198 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"l3/ospfv2/objects"
+	"sort"
+)
+
+type PrefixListEntry struct {
+	Action   uint8
+	IpPrefix uint32
+	Netmask  uint32
+	MinLen   uint8
+	MaxLen   uint8
+}
+
+// Entries of a prefix list keyed by sequence number
+type PrefixListConf map[uint32]PrefixListEntry
+
+func getOspfv2PrefixListUpdateMask(attrset []bool) uint32 {
+	var mask uint32 = 0
+
+	if attrset == nil {
+		mask = objects.OSPFV2_PREFIX_LIST_UPDATE_ACTION |
+			objects.OSPFV2_PREFIX_LIST_UPDATE_IP_PREFIX |
+			objects.OSPFV2_PREFIX_LIST_UPDATE_MASK_LENGTH_RANGE
+	} else {
+		for idx, val := range attrset {
+			if true == val {
+				switch idx {
+				case 0:
+					// Name
+				case 1:
+					// Seq
+				case 2:
+					mask |= objects.OSPFV2_PREFIX_LIST_UPDATE_ACTION
+				case 3:
+					mask |= objects.OSPFV2_PREFIX_LIST_UPDATE_IP_PREFIX
+				case 4:
+					mask |= objects.OSPFV2_PREFIX_LIST_UPDATE_MASK_LENGTH_RANGE
+				}
+			}
+		}
+	}
+	return mask
+}
+
+func (server *OSPFV2Server) createPrefixList(cfg *objects.Ospfv2PrefixList) (bool, error) {
+	server.logger.Info("Prefix List configuration create")
+	prefixList, exist := server.PrefixListConfMap[cfg.Name]
+	if !exist {
+		prefixList = make(PrefixListConf)
+	}
+	_, exist = prefixList[cfg.Seq]
+	if exist {
+		server.logger.Err("Prefix List entry already exist")
+		return false, errors.New("Prefix List entry already exist")
+	}
+	prefixList[cfg.Seq] = PrefixListEntry{
+		Action:   cfg.Action,
+		IpPrefix: cfg.IpPrefix,
+		Netmask:  cfg.Netmask,
+		MinLen:   cfg.MinLen,
+		MaxLen:   cfg.MaxLen,
+	}
+	server.PrefixListConfMap[cfg.Name] = prefixList
+	if server.isPrefixListInUse(cfg.Name) {
+		server.sendMsgToRegenerateSummaryLsa()
+	}
+	return true, nil
+}
+
+func (server *OSPFV2Server) updatePrefixList(newCfg, oldCfg *objects.Ospfv2PrefixList, attrset []bool) (bool, error) {
+	server.logger.Info("Prefix List configuration update")
+	prefixList, exist := server.PrefixListConfMap[newCfg.Name]
+	if !exist {
+		server.logger.Err("Prefix List doesnot exist")
+		return false, errors.New("Prefix List doesnot exist")
+	}
+	entry, exist := prefixList[newCfg.Seq]
+	if !exist {
+		server.logger.Err("Prefix List entry doesnot exist")
+		return false, errors.New("Prefix List entry doesnot exist")
+	}
+	mask := getOspfv2PrefixListUpdateMask(attrset)
+	if mask&objects.OSPFV2_PREFIX_LIST_UPDATE_ACTION == objects.OSPFV2_PREFIX_LIST_UPDATE_ACTION {
+		entry.Action = newCfg.Action
+	}
+	if mask&objects.OSPFV2_PREFIX_LIST_UPDATE_IP_PREFIX == objects.OSPFV2_PREFIX_LIST_UPDATE_IP_PREFIX {
+		entry.IpPrefix = newCfg.IpPrefix
+		entry.Netmask = newCfg.Netmask
+	}
+	if mask&objects.OSPFV2_PREFIX_LIST_UPDATE_MASK_LENGTH_RANGE == objects.OSPFV2_PREFIX_LIST_UPDATE_MASK_LENGTH_RANGE {
+		entry.MinLen = newCfg.MinLen
+		entry.MaxLen = newCfg.MaxLen
+	}
+	prefixList[newCfg.Seq] = entry
+	server.PrefixListConfMap[newCfg.Name] = prefixList
+	if server.isPrefixListInUse(newCfg.Name) {
+		server.sendMsgToRegenerateSummaryLsa()
+	}
+	return true, nil
+}
+
+func (server *OSPFV2Server) deletePrefixList(cfg *objects.Ospfv2PrefixList) (bool, error) {
+	server.logger.Info("Prefix List configuration delete")
+	prefixList, exist := server.PrefixListConfMap[cfg.Name]
+	if !exist {
+		server.logger.Err("Prefix List doesnot exist")
+		return false, errors.New("Prefix List doesnot exist")
+	}
+	_, exist = prefixList[cfg.Seq]
+	if !exist {
+		server.logger.Err("Prefix List entry doesnot exist")
+		return false, errors.New("Prefix List entry doesnot exist")
+	}
+	delete(prefixList, cfg.Seq)
+	if len(prefixList) == 0 {
+		delete(server.PrefixListConfMap, cfg.Name)
+	} else {
+		server.PrefixListConfMap[cfg.Name] = prefixList
+	}
+	if server.isPrefixListInUse(cfg.Name) {
+		server.sendMsgToRegenerateSummaryLsa()
+	}
+	return true, nil
+}
+
+func (server *OSPFV2Server) isPrefixListInUse(name string) bool {
+	for _, areaEnt := range server.AreaConfMap {
+		if areaEnt.ImportFilterList == name ||
+			areaEnt.ExportFilterList == name {
+			return true
+		}
+	}
+	return false
+}
+
+func getPrefixLen(netmask uint32) uint8 {
+	var prefixLen uint8
+	for netmask != 0 {
+		prefixLen++
+		netmask = netmask << 1
+	}
+	return prefixLen
+}
+
+// Returns true if the prefix list permits ipAddr/netmask. Prefixes not
+// matching any entry are denied, an empty or unknown prefix list
+// permits everything.
+func (server *OSPFV2Server) isPrefixPermitted(name string, ipAddr, netmask uint32) bool {
+	if name == "" {
+		return true
+	}
+	prefixList, exist := server.PrefixListConfMap[name]
+	if !exist || len(prefixList) == 0 {
+		return true
+	}
+	seqList := make([]int, 0, len(prefixList))
+	for seq, _ := range prefixList {
+		seqList = append(seqList, int(seq))
+	}
+	sort.Ints(seqList)
+	prefixLen := getPrefixLen(netmask)
+	for _, seq := range seqList {
+		entry := prefixList[uint32(seq)]
+		if ipAddr&entry.Netmask != entry.IpPrefix ||
+			prefixLen < entry.MinLen ||
+			prefixLen > entry.MaxLen {
+			continue
+		}
+		return entry.Action == objects.PREFIX_LIST_ACTION_PERMIT
+	}
+	return false
+}
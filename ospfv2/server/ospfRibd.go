@@ -93,7 +93,7 @@ func (server *OSPFV2Server) listenForRibdUpdates(address string) error {
 		return err
 	}
 
-	if err = server.ribdComm.ribdSubSocket.Subscribe(""); err != nil {
+	if err = server.ribdComm.ribdSubSocket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
 		server.logger.Err("ERR: Failed to subscribe to \"\" on RIB subscribe socket, error:", err)
 		return err
 	}
@@ -145,9 +145,10 @@ func (server *OSPFV2Server) getBulkRoutesFromRibd() []*RouteInfo {
 				netmask, _ := convertDotNotationToUint32(route.Mask)
 				metric := uint32(route.Metric)
 				routeInfo := RouteInfo{
-					NwAddr:  nwAddr,
-					Netmask: netmask,
-					Metric:  metric,
+					NwAddr:      nwAddr,
+					Netmask:     netmask,
+					Metric:      metric,
+					ExtRouteTag: uint32(route.RouteTag),
 				}
 				routeInfoList = append(routeInfoList, &routeInfo)
 			}
@@ -159,6 +160,54 @@ func (server *OSPFV2Server) getBulkRoutesFromRibd() []*RouteInfo {
 	return routeInfoList
 }
 
+// Pushes the end of RIB out while routes are still being calculated
+func (server *OSPFV2Server) deferEndOfRIB() {
+	if server.LsdbData.EndOfRIBSent {
+		return
+	}
+	quietTime := time.Now().Add(EndOfRIBQuietTime)
+	if quietTime.After(server.LsdbData.EndOfRIBTime) {
+		server.LsdbData.EndOfRIBTime = quietTime
+	}
+}
+
+// Runs in the LSDB routine, tells ribd that all the OSPF routes have
+// been installed so that the stale routes of the previous instance
+// are removed
+func (server *OSPFV2Server) processEndOfRIB() {
+	if server.LsdbData.EndOfRIBSent ||
+		server.isGracefulRestartInProgress() ||
+		time.Now().Before(server.LsdbData.EndOfRIBTime) {
+		return
+	}
+	server.sendRoutesEndOfRIB()
+}
+
+func (server *OSPFV2Server) sendRoutesEndOfRIB() {
+	if server.ribdComm.ribdClient.ClientHdl == nil {
+		server.logger.Err("Nil ribd handle. Can not send end of RIB.")
+		return
+	}
+	server.LsdbData.EndOfRIBSent = true
+	err := server.ribdComm.ribdClient.ClientHdl.OnewayRoutesEndOfRIB("OSPF")
+	if err != nil {
+		server.logger.Err("Error sending end of RIB to ribd:", err)
+	}
+}
+
+// Asks ribd to keep the routes of the previous instance for the given
+// time, its own stale hold may be shorter than the grace period
+func (server *OSPFV2Server) sendStaleRouteHold(holdTime time.Duration) {
+	if server.ribdComm.ribdClient.ClientHdl == nil {
+		server.logger.Err("Nil ribd handle. Can not send stale route hold time.")
+		return
+	}
+	err := server.ribdComm.ribdClient.ClientHdl.OnewayHoldStaleRoutes("OSPF", ribdInt.Int(holdTime/time.Second))
+	if err != nil {
+		server.logger.Err("Error sending stale route hold time to ribd:", err)
+	}
+}
+
 func (server *OSPFV2Server) processRibdNotification(ribdRxBuf []byte) {
 	if server.globalData.AdminState == false {
 		return
@@ -187,9 +236,10 @@ func (server *OSPFV2Server) processRibdRouteAddMsg(routeList *ribdCommonDefs.Rou
 	netmask, _ := convertDotNotationToUint32(route.Mask)
 	metric := uint32(route.Metric)
 	routeInfo := RouteInfo{
-		NwAddr:  nwAddr,
-		Netmask: netmask,
-		Metric:  metric,
+		NwAddr:      nwAddr,
+		Netmask:     netmask,
+		Metric:      metric,
+		ExtRouteTag: uint32(route.RouteTag),
 	}
 	routeInfoList = append(routeInfoList, routeInfo)
 	msg := RouteInfoDataUpdateMsg{
@@ -206,9 +256,10 @@ func (server *OSPFV2Server) processRibdRouteDelMsg(routeList *ribdCommonDefs.Rou
 	netmask, _ := convertDotNotationToUint32(route.Mask)
 	metric := uint32(route.Metric)
 	routeInfo := RouteInfo{
-		NwAddr:  nwAddr,
-		Netmask: netmask,
-		Metric:  metric,
+		NwAddr:      nwAddr,
+		Netmask:     netmask,
+		Metric:      metric,
+		ExtRouteTag: uint32(route.RouteTag),
 	}
 	routeInfoList = append(routeInfoList, routeInfo)
 	msg := RouteInfoDataUpdateMsg{
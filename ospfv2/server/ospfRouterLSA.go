@@ -114,6 +114,18 @@ func (server *OSPFV2Server) GetLinkDetails(areaId uint32, areaEnt AreaConf) []Li
 				}
 				linkDetail.NumOfTOS = 0
 				linkDetail.LinkMetric = uint16(intfConf.Cost)
+			case objects.INTF_TYPE_VIRTUAL:
+				// RFC 2328 12.4.1.3: Only fully adjacent virtual links
+				nbrRtrId, full := server.getVirtualLinkFullNbr(intfConf)
+				if !full {
+					continue
+				}
+				server.logger.Debug("Virtual Link")
+				linkDetail.LinkType = VIRTUAL_LINK
+				linkDetail.LinkId = nbrRtrId
+				linkDetail.LinkData = intfConf.IpAddr
+				linkDetail.NumOfTOS = 0
+				linkDetail.LinkMetric = uint16(intfConf.Cost)
 			}
 		}
 		linkDetails = append(linkDetails, linkDetail)
@@ -138,6 +150,16 @@ func (server *OSPFV2Server) GenerateRouterLSA(msg GenerateRouterLSAMsg) error {
 	if server.globalData.AreaBdrRtrStatus == true {
 		BitB = true
 	}
+	// Nt Bit: NSSA border router translating unconditionally (RFC 3101 2.3)
+	BitNt := false
+	if BitB == true &&
+		areaEnt.Nssa == true &&
+		areaEnt.NssaTranslatorRole == objects.NSSA_TRANSLATOR_ROLE_ALWAYS {
+		BitNt = true
+	}
+	// V Bit: Area is transit area for fully adjacent virtual links
+	BitV := server.isActiveVirtualLinkTransitArea(msg.AreaId)
+	options, _ := server.getAreaOptions(msg.AreaId)
 	lsaKey = LsaKey{
 		LSType:    RouterLSA,
 		LSId:      server.globalData.RouterId,
@@ -163,15 +185,16 @@ func (server *OSPFV2Server) GenerateRouterLSA(msg GenerateRouterLSAMsg) error {
 	lsaEnt.LsaMd.LSAge = 0
 	lsaEnt.LsaMd.LSChecksum = 0
 	lsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + (12 * numOfLinks))
-	lsaEnt.LsaMd.Options = EOption
+	lsaEnt.LsaMd.Options = options
 	if !exist {
 		lsaEnt.LsaMd.LSSequenceNum = int(InitialSequenceNum)
 	} else {
 		lsaEnt.LsaMd.LSSequenceNum = lsaEnt.LsaMd.LSSequenceNum + 1
 	}
+	lsaEnt.BitNt = BitNt
 	lsaEnt.BitB = BitB
 	lsaEnt.BitE = BitE
-	lsaEnt.BitV = false
+	lsaEnt.BitV = BitV
 	lsaEnt.NumOfLinks = uint16(numOfLinks)
 	lsaEnt.LinkDetails = nil
 	lsaEnt.LinkDetails = append(lsaEnt.LinkDetails, linkDetails...)
@@ -213,6 +236,16 @@ func (server *OSPFV2Server) reGenerateRouterLSA(msg GenerateRouterLSAMsg) {
 	if server.globalData.AreaBdrRtrStatus == true {
 		BitB = true
 	}
+	// Nt Bit: NSSA border router translating unconditionally (RFC 3101 2.3)
+	BitNt := false
+	if BitB == true &&
+		areaEnt.Nssa == true &&
+		areaEnt.NssaTranslatorRole == objects.NSSA_TRANSLATOR_ROLE_ALWAYS {
+		BitNt = true
+	}
+	// V Bit: Area is transit area for fully adjacent virtual links
+	BitV := server.isActiveVirtualLinkTransitArea(msg.AreaId)
+	options, _ := server.getAreaOptions(msg.AreaId)
 	lsaKey = LsaKey{
 		LSType:    RouterLSA,
 		LSId:      server.globalData.RouterId,
@@ -235,11 +268,12 @@ func (server *OSPFV2Server) reGenerateRouterLSA(msg GenerateRouterLSAMsg) {
 	lsaEnt.LsaMd.LSAge = 0
 	lsaEnt.LsaMd.LSChecksum = 0
 	lsaEnt.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + (12 * numOfLinks))
-	lsaEnt.LsaMd.Options = EOption
+	lsaEnt.LsaMd.Options = options
 	lsaEnt.LsaMd.LSSequenceNum = lsaEnt.LsaMd.LSSequenceNum + 1
+	lsaEnt.BitNt = BitNt
 	lsaEnt.BitB = BitB
 	lsaEnt.BitE = BitE
-	lsaEnt.BitV = false
+	lsaEnt.BitV = BitV
 	lsaEnt.NumOfLinks = uint16(numOfLinks)
 	lsaEnt.LinkDetails = nil
 	lsaEnt.LinkDetails = append(lsaEnt.LinkDetails, linkDetails...)
@@ -36,6 +36,10 @@ type RoutingTblStruct struct {
 	GlobalRoutingTbl     map[RoutingTblEntryKey]GlobalRoutingTblEntry
 	OldGlobalRoutingTbl  map[RoutingTblEntryKey]GlobalRoutingTblEntry
 	TempGlobalRoutingTbl map[RoutingTblEntryKey]GlobalRoutingTblEntry
+	TransitCapability    map[uint32]bool            //Key AreaId
+	AreaBdrRtrMap        map[uint32]map[uint32]bool //Key AreaId, border routers reachable within the area
+	VirtualLinkPathMap   map[VirtualLinkConfKey]VirtualLinkPath
+	DiscardRouteMap      map[RoutingTblEntryKey]bool
 }
 
 type DestType uint8
@@ -94,6 +98,7 @@ type RoutingTblEntry struct {
 	LSOrigin        LsaKey
 	NumOfPaths      int
 	NextHops        map[NextHop]bool // Next Hop
+	ExtRouteTag     uint32           // External Route Tag of AS External Path
 }
 
 type GlobalRoutingTblEntry struct {
@@ -287,6 +292,10 @@ func (server *OSPFV2Server) findP2PNextHopIP(vFirst VertexKey, vSecond VertexKey
 	} else {
 		flag = false
 	}
+	if firstLink.LinkType == VIRTUAL_LINK {
+		// Next hop of the virtual link is the one through transit area
+		return server.findVirtualLinkNextHop(vSecond.AdvRtr)
+	}
 	for _, link := range secondLsa.LinkDetails {
 		if link.LinkId == vFirst.AdvRtr &&
 			link.LinkType == P2P_LINK {
@@ -787,6 +796,7 @@ func (server *OSPFV2Server) InstallRoute(rKey RoutingTblEntryKey) {
 			Protocol:      routeType,
 			Cost:          int32(metric),
 			NetworkMask:   networkMask,
+			RouteTag:      int32(newEnt.RoutingTblEnt.ExtRouteTag),
 		}
 		nextHopInfo := ribd.NextHopInfo{
 			NextHopIp:     nextHopIp,
@@ -89,7 +89,8 @@ func (server *OSPFV2Server) processIPv4Layer(ipLayer gopacket.Layer, IpAddr uint
 
 	if IpAddr != ipHdrMd.DstIP &&
 		ALLDROUTER != ipHdrMd.DstIP &&
-		ALLSPFROUTER != ipHdrMd.DstIP {
+		ALLSPFROUTER != ipHdrMd.DstIP &&
+		!server.isVirtualLinkTransitAddr(ipHdrMd.DstIP) {
 		err := errors.New(fmt.Sprintln("Incorrect DstIP", ipPkt.DstIP, "hence dicarding the packet"))
 		return err
 	}
@@ -129,6 +130,12 @@ func (server *OSPFV2Server) processOspfHeader(ospfPkt []byte, key IntfConfKey, m
 		return err
 	}
 
+	if int(ospfHdr.Pktlen) < OSPF_HEADER_SIZE ||
+		int(ospfHdr.Pktlen) > len(ospfPkt) {
+		err := errors.New("Dropped because of invalid Ospf packet length")
+		return err
+	}
+
 	if ent.AreaId == ospfHdr.AreaId {
 		if ent.Type != objects.INTF_TYPE_POINT2POINT {
 			if (ent.IpAddr & ent.Netmask) != (ipHdrMd.SrcIP & ent.Netmask) {
@@ -138,10 +145,17 @@ func (server *OSPFV2Server) processOspfHeader(ospfPkt []byte, key IntfConfKey, m
 			}
 		}
 	} else {
-		// We don't support Virtual Link
-		err := errors.New("Dropped because Area ID is not matching and we dont support Virtual links, so this should not happend")
-		return err
-
+		// RFC 2328 8.2: Backbone packet received over transit
+		// area interface is associated with the virtual link
+		vKey, err := server.getVirtualLinkForRecvPkt(ent.AreaId, ospfHdr.AreaId, ospfHdr.RouterId)
+		if err != nil {
+			return errors.New(fmt.Sprintln("Dropped because Area ID is not matching,", err))
+		}
+		vEnt, _ := server.VirtualLinkConfMap[vKey]
+		key = vEnt.IntfKey
+		ent, _ = server.IntfConfMap[key]
+		md.VirtualLink = true
+		md.VirtualLinkKey = vKey
 	}
 
 	if ipHdrMd.DstIPType == AllDRouterType {
@@ -152,14 +166,6 @@ func (server *OSPFV2Server) processOspfHeader(ospfPkt []byte, key IntfConfKey, m
 		}
 	}
 
-	//OSPF Auth Type
-	if ent.AuthType != ospfHdr.AuthType {
-		err := errors.New("Dropped because of Router Id not matching")
-		return err
-	}
-
-	//TODO: We don't support Authentication
-
 	if ospfHdr.PktType != HelloType {
 		if ent.Type == objects.INTF_TYPE_BROADCAST {
 			nbrKey := NbrConfKey{
@@ -171,7 +177,8 @@ func (server *OSPFV2Server) processOspfHeader(ospfPkt []byte, key IntfConfKey, m
 				err := errors.New("Adjacency not established with this nbr")
 				return err
 			}
-		} else if ent.Type == objects.INTF_TYPE_POINT2POINT {
+		} else if ent.Type == objects.INTF_TYPE_POINT2POINT ||
+			ent.Type == objects.INTF_TYPE_VIRTUAL {
 			/* For future - For unnumbered P2P the identity will be
 			   router id. */
 
@@ -187,13 +194,10 @@ func (server *OSPFV2Server) processOspfHeader(ospfPkt []byte, key IntfConfKey, m
 		}
 	}
 
-	//OSPF Header CheckSum
-	binary.BigEndian.PutUint16(ospfPkt[12:14], 0)
-	copy(ospfPkt[16:OSPF_HEADER_SIZE], []byte{0, 0, 0, 0, 0, 0, 0, 0})
-	csum := computeCheckSum(ospfPkt)
-	if csum != ospfHdr.Chksum {
-		err := errors.New("Dropped because of invalid checksum")
-		return err
+	//OSPF Authentication and Header CheckSum
+	err := server.verifyOspfAuth(ent, ospfPkt, ospfHdr, ipHdrMd.SrcIP)
+	if err != nil {
+		return errors.New(fmt.Sprintln("Dropped because of authentication failure", err))
 	}
 
 	md.PktType = ospfHdr.PktType
@@ -253,7 +257,8 @@ func (server *OSPFV2Server) processOspfPkt(pkt gopacket.Packet, key IntfConfKey,
 	}
 	ospfPktData.OspfHdrMd = ospfHdrMd
 
-	ospfPktData.Data = ospfPkt[OSPF_HEADER_SIZE:]
+	// Strip the message digest trailing the packet, if any
+	ospfPktData.Data = ospfPkt[OSPF_HEADER_SIZE:ospfHdrMd.Pktlen]
 	return nil
 }
 
@@ -268,6 +273,7 @@ func (server *OSPFV2Server) ProcessOspfRecvHelloPkt(recvPktData OspfHelloPktRecv
 		case _ = <-recvPktData.OspfRecvHelloCtrlCh:
 			server.logger.Info("Stopping ProcessOspfRecvHelloPkt routine")
 			recvPktData.OspfRecvHelloCtrlReplyCh <- true
+			return
 		}
 	}
 
@@ -325,6 +331,7 @@ func (server *OSPFV2Server) ProcessOspfRecvLsaAndDbdPkt(recvPktData OspfLsaAndDb
 		case _ = <-recvPktData.OspfRecvLsaAndDbdCtrlCh:
 			server.logger.Info("Stopping ProcessOspfRecvLsaAndDbdPkt routine")
 			recvPktData.OspfRecvLsaAndDbdCtrlReplyCh <- true
+			return
 		}
 	}
 }
@@ -359,13 +366,20 @@ func (server *OSPFV2Server) ProcessOspfRecvPkt(recvPkt OspfPktRecvStruct) {
 				server.logger.Err("Error processing Ospf Pkt:", err)
 				continue
 			}
+			if ospfPktData.OspfHdrMd.VirtualLink {
+				err = server.processVirtualLinkOspfData(ospfPktData)
+				if err != nil {
+					server.logger.Err("Error processing Virtual Link Ospf Pkt:", err)
+				}
+				continue
+			}
 			server.processOspfData(ospfPktData, recvHelloPkt.OspfRecvHelloPktCh, recvLsaAndDbdPkt.OspfRecvLsaAndDbdPktCh)
 		case _ = <-recvPkt.OspfRecvCtrlCh:
 			server.logger.Info("Stopping ProcessOspfRecvPkt")
 			recvHelloPkt.OspfRecvHelloCtrlCh <- true
 			_ = <-recvHelloPkt.OspfRecvHelloCtrlReplyCh
 			recvLsaAndDbdPkt.OspfRecvLsaAndDbdCtrlCh <- true
-			_ = recvLsaAndDbdPkt.OspfRecvLsaAndDbdCtrlReplyCh
+			_ = <-recvLsaAndDbdPkt.OspfRecvLsaAndDbdCtrlReplyCh
 			recvPkt.OspfRecvCtrlReplyCh <- true
 			return
 		}
@@ -24,6 +24,10 @@ package server
 import ()
 
 func (server *OSPFV2Server) StopIntfRxTxPkt(intfKey IntfConfKey) {
+	if server.isVirtualLinkIntf(intfKey) {
+		server.StopVirtualLinkRecvPkts(intfKey)
+		return
+	}
 	server.StopOspfRecvPkts(intfKey)
 	//Nothing to stop for Tx
 	server.DeinitRxPkt(intfKey)
@@ -32,6 +36,11 @@ func (server *OSPFV2Server) StopIntfRxTxPkt(intfKey IntfConfKey) {
 }
 
 func (server *OSPFV2Server) StartIntfRxTxPkt(intfKey IntfConfKey) {
+	if server.isVirtualLinkIntf(intfKey) {
+		// Virtual link uses Rx and Tx of the transit area interface
+		server.StartVirtualLinkRecvPkts(intfKey)
+		return
+	}
 	err := server.InitRxPkt(intfKey)
 	if err != nil {
 		server.logger.Err("Error: InitRxPkt()", err)
@@ -313,15 +313,10 @@ func (server *OSPFV2Server) UpdateAreaGraphRouterLsa(lsaEnt RouterLsa, lsaKey Ls
 		server.logger.Info("SPF Graph:", server.SPFData.AreaGraph)
 		return nil
 	}
-	/*
-		if lsaEnt.BitV == true {
-			aEnt, exist := server.AreaConfMap[areaId]
-			if exist && aEnt.TransitCapability == false {
-				aEnt.TransitCapability = true
-				server.AreaConfMap[areaId] = aEnt
-			}
-		}
-	*/
+	if lsaEnt.BitV == true {
+		// Area carries atleast one fully adjacent virtual link
+		server.RoutingTblData.TransitCapability[areaId] = true
+	}
 	ent.NbrVertexKey = make([]VertexKey, 0)
 	ent.NbrVertexCost = make([]uint16, 0)
 	ent.LinkData = make(map[VertexKey]uint32)
@@ -370,8 +365,9 @@ func (server *OSPFV2Server) UpdateAreaGraphRouterLsa(lsaEnt RouterLsa, lsaKey Ls
 			sentry.LsaKey = lsaKey
 			sentry.LinkStateId = lsaKey.LSId
 			server.SPFData.AreaStubs[vKey] = sentry
-		} else if linkDetail.LinkType == P2P_LINK {
-			server.logger.Info("===It is P2PLink===")
+		} else if linkDetail.LinkType == P2P_LINK ||
+			linkDetail.LinkType == VIRTUAL_LINK {
+			server.logger.Info("===It is P2PLink or VirtualLink===")
 			vKey = VertexKey{
 				Type:   RouterVertex,
 				ID:     linkDetail.LinkId,
@@ -817,13 +813,29 @@ func (server *OSPFV2Server) SPFCalculation() {
 	server.RoutingTblData.OldGlobalRoutingTbl = server.RoutingTblData.GlobalRoutingTbl
 	server.RoutingTblData.TempAreaRoutingTbl = nil
 	server.RoutingTblData.TempAreaRoutingTbl = make(map[AreaIdKey]AreaRoutingTbl)
-	for areaId, aEnt := range server.AreaConfMap {
+	server.RoutingTblData.TransitCapability = make(map[uint32]bool)
+	server.RoutingTblData.AreaBdrRtrMap = make(map[uint32]map[uint32]bool)
+	// Backbone is calculated in the end as the virtual links
+	// depend on the intra area paths of the transit areas
+	areaList := make([]uint32, 0)
+	for areaId, _ := range server.AreaConfMap {
+		if areaId != 0 {
+			areaList = append(areaList, areaId)
+		}
+	}
+	if _, exist := server.AreaConfMap[0]; exist {
+		areaList = append(areaList, 0)
+	}
+	for _, areaId := range areaList {
+		aEnt := server.AreaConfMap[areaId]
+		if areaId == 0 {
+			server.updateVirtualLinkTransitPaths()
+		}
 
 		server.logger.Info("Area Id : ", areaId, "Area Bdr Status:", server.globalData.AreaBdrRtrStatus)
 		if len(aEnt.IntfMap) == 0 || aEnt.AdminState == false {
 			continue
 		}
-		//aEnt.TransitCapability = false
 		server.InitSPFStructs()
 		areaIdKey := AreaIdKey{
 			AreaId: areaId,
@@ -858,6 +870,7 @@ func (server *OSPFV2Server) SPFCalculation() {
 		server.UpdateRoutingTbl(vKey, areaId)
 		server.logger.Info("Handling Stub links...")
 		server.HandleStubs(vKey, areaId)
+		server.updateAreaBdrRtrMap(areaId)
 		server.HandleSummaryLsa(areaId)
 		server.SPFData.AreaGraph = nil
 		server.SPFData.AreaStubs = nil
@@ -866,12 +879,17 @@ func (server *OSPFV2Server) SPFCalculation() {
 	 //server.dumpRoutingTbl()
 	server.RoutingTblData.TempGlobalRoutingTbl = nil
 	server.RoutingTblData.TempGlobalRoutingTbl = make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
+	if server.globalData.AreaBdrRtrStatus == true {
+		server.logger.Info("Examine transit areas, Summary LSA...")
+		server.HandleTransitAreaSummaryLsa()
+	}
 	/* Summarize and Install/Delete Routes In Routing Table */
 	server.InstallRoutingTbl()
 	// Copy the Summarize Routing Table in Global Routing Table
 	server.RoutingTblData.GlobalRoutingTbl = nil
 	server.RoutingTblData.GlobalRoutingTbl = make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
 	server.RoutingTblData.GlobalRoutingTbl = server.RoutingTblData.TempGlobalRoutingTbl
+	server.updateAreaRangeDiscardRoutes()
 	server.dumpGlobalRoutingTbl()
 	for areaId, _ := range server.AreaConfMap {
 		areaIdKey := AreaIdKey{
@@ -953,6 +971,7 @@ func (server *OSPFV2Server) SPFRoutine() {
 			server.SPFCalculation()
 			server.SendMsgForSpfDone()
 		case <-server.SPFData.SPFGblCtrlCh:
+			server.flushAreaRangeDiscardRoutes()
 			server.FlushRoutingTbl()
 			server.DeinitRoutingTbl()
 			server.DeinitSPFStructs()
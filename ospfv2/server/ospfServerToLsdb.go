@@ -39,3 +39,12 @@ func (server *OSPFV2Server) SendMsgToLsdbToUpdateRouteInfo(msg RouteInfoDataUpda
 	server.logger.Info("Sending msg to Lsdb for Updating RouteInfo:", msg)
 	server.MessagingChData.ServerToLsdbChData.RouteInfoDataUpdateCh <- msg
 }
+
+func (server *OSPFV2Server) SendMsgToLsdbForGracefulRestartExit(reason uint8) {
+	server.logger.Info("Sending msg to Lsdb for Graceful Restart Exit:", reason)
+	select {
+	case server.MessagingChData.ServerToLsdbChData.GracefulRestartExitCh <- reason:
+	default:
+		// Exit is already pending
+	}
+}
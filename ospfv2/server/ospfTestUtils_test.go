@@ -0,0 +1,86 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/ospfv2/objects"
+	"testing"
+	"utils/logging"
+)
+
+const testRouterId uint32 = 0x05050505
+
+// newTestServer builds a server the way the daemon start up does, without
+// connecting to the other daemons. Ospf is left administratively down, so
+// that config is validated and stored without starting the FSMs.
+func newTestServer(t *testing.T) *OSPFV2Server {
+	logger, _ := logging.NewLogger("ospfd", "OSPFTEST", false)
+	server, err := NewOspfv2Server(InitParams{Logger: logger})
+	if err != nil {
+		t.Fatal("Failed to create ospf server, err:", err)
+	}
+	server.initMessagingChData()
+	server.initInfra()
+	server.InitNbrStruct()
+	server.InitLsdbData()
+	server.InitRoutingTbl()
+	globalCfg := objects.Ospfv2Global{
+		Vrf:      "default",
+		RouterId: testRouterId,
+	}
+	if _, err := server.createGlobal(&globalCfg); err != nil {
+		t.Fatal("Failed to create global config, err:", err)
+	}
+	return server
+}
+
+func createTestArea(t *testing.T, server *OSPFV2Server, cfg objects.Ospfv2Area) {
+	if _, err := server.createArea(&cfg); err != nil {
+		t.Fatal("Failed to create area", cfg.AreaId, "err:", err)
+	}
+}
+
+// createTestIntf adds the L3 interface learnt from asicd before creating the
+// ospf interface on it
+func createTestIntf(t *testing.T, server *OSPFV2Server, ifIdx int32, netmask uint32, cfg objects.Ospfv2Intf) {
+	server.infraData.ipToIfIdxMap[cfg.IpAddress] = ifIdx
+	server.infraData.ipPropertyMap[ifIdx] = IpProperty{
+		IfId:    uint32(ifIdx),
+		IfName:  fmt.Sprintf("fpPort%d", ifIdx),
+		IpAddr:  cfg.IpAddress,
+		NetMask: netmask,
+		Mtu:     1500,
+		State:   true,
+	}
+	if _, err := server.createIntf(&cfg); err != nil {
+		t.Fatal("Failed to create interface", cfg.IpAddress, cfg.AddressLessIfIdx, "err:", err)
+	}
+}
+
+func createTestVirtualLink(t *testing.T, server *OSPFV2Server, cfg objects.Ospfv2VirtualLink) {
+	if _, err := server.createVirtualLink(&cfg); err != nil {
+		t.Fatal("Failed to create virtual link", cfg.TransitAreaId, cfg.NbrRouterId, "err:", err)
+	}
+}
@@ -26,10 +26,17 @@ package server
 import (
 	"errors"
 	"github.com/google/gopacket/pcap"
+	"l3/ospfv2/objects"
 )
 
 func (server *OSPFV2Server) SendOspfPkt(key IntfConfKey, ospfPkt []byte) error {
+	if ospfPkt == nil {
+		return errors.New("Invalid ospf pkt")
+	}
 	entry, _ := server.IntfConfMap[key]
+	if entry.Type == objects.INTF_TYPE_VIRTUAL {
+		return server.sendVirtualLinkOspfPkt(key, ospfPkt)
+	}
 	handle := entry.txHdl.SendPcapHdl
 	if handle == nil {
 		server.logger.Err("Invalid pcap handle")
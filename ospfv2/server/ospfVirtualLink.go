@@ -0,0 +1,789 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"l3/ospfv2/objects"
+	"net"
+)
+
+type VirtualLinkConfKey struct {
+	TransitAreaId uint32
+	NbrRtrId      uint32
+}
+
+// Virtual link is run as an unnumbered point-to-point interface of the
+// backbone (RFC 2328 15). Its packets are received and transmitted over
+// the transit area interface on the path to the virtual neighbor.
+type VirtualLinkConf struct {
+	IntfKey          IntfConfKey
+	TransitIntfKey   IntfConfKey
+	NbrIpAddr        uint32
+	NextHopIP        uint32
+	RecvHelloPkt     OspfHelloPktRecvStruct
+	RecvLsaAndDbdPkt OspfLsaAndDbdPktRecvStruct
+}
+
+// Intra area path to the virtual neighbor through the transit area
+// calculated by SPF (RFC 2328 16.1 Step 4)
+type VirtualLinkPath struct {
+	OperState      bool
+	TransitIntfKey IntfConfKey
+	IpAddr         uint32
+	NbrIpAddr      uint32
+	NextHopIP      uint32
+	Cost           uint32
+}
+
+func getOspfv2VirtualLinkUpdateMask(attrset []bool) uint32 {
+	var mask uint32 = 0
+
+	if attrset == nil {
+		mask = objects.OSPFV2_VIRTUAL_LINK_UPDATE_ADMIN_STATE |
+			objects.OSPFV2_VIRTUAL_LINK_UPDATE_TRANSIT_DELAY |
+			objects.OSPFV2_VIRTUAL_LINK_UPDATE_RETRANS_INTERVAL |
+			objects.OSPFV2_VIRTUAL_LINK_UPDATE_HELLO_INTERVAL |
+			objects.OSPFV2_VIRTUAL_LINK_UPDATE_RTR_DEAD_INTERVAL
+	} else {
+		for idx, val := range attrset {
+			if true == val {
+				switch idx {
+				case 0:
+					// TransitAreaId
+				case 1:
+					// NbrRouterId
+				case 2:
+					mask |= objects.OSPFV2_VIRTUAL_LINK_UPDATE_ADMIN_STATE
+				case 3:
+					mask |= objects.OSPFV2_VIRTUAL_LINK_UPDATE_TRANSIT_DELAY
+				case 4:
+					mask |= objects.OSPFV2_VIRTUAL_LINK_UPDATE_RETRANS_INTERVAL
+				case 5:
+					mask |= objects.OSPFV2_VIRTUAL_LINK_UPDATE_HELLO_INTERVAL
+				case 6:
+					mask |= objects.OSPFV2_VIRTUAL_LINK_UPDATE_RTR_DEAD_INTERVAL
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// Virtual interfaces have their own key space, so that a router
+// id never collides with the index of an unnumbered interface
+func getVirtualLinkIntfKey(nbrRtrId uint32) IntfConfKey {
+	return IntfConfKey{
+		VirtualNbrRtrId: nbrRtrId,
+	}
+}
+
+func (server *OSPFV2Server) validateVirtualLink(cfg *objects.Ospfv2VirtualLink) error {
+	if cfg.TransitAreaId == 0 {
+		return errors.New("Backbone cannot be the transit area of a virtual link")
+	}
+	_, exist := server.AreaConfMap[cfg.TransitAreaId]
+	if !exist {
+		return errors.New("Transit area doesnot exist")
+	}
+	isStub, _ := server.isStubArea(cfg.TransitAreaId)
+	if isStub {
+		return errors.New("Virtual links cannot be configured through stub area or NSSA")
+	}
+	_, exist = server.AreaConfMap[0]
+	if !exist {
+		return errors.New("Backbone area doesnot exist")
+	}
+	if cfg.NbrRouterId == 0 ||
+		cfg.NbrRouterId == server.globalData.RouterId {
+		return errors.New("Invalid virtual neighbor router id")
+	}
+	return nil
+}
+
+func (server *OSPFV2Server) createVirtualLink(cfg *objects.Ospfv2VirtualLink) (bool, error) {
+	server.logger.Info("Virtual Link configuration create")
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: cfg.TransitAreaId,
+		NbrRtrId:      cfg.NbrRouterId,
+	}
+	_, exist := server.VirtualLinkConfMap[vKey]
+	if exist {
+		server.logger.Err("Virtual Link configuration already exist")
+		return false, errors.New("Virtual Link configuration already exist")
+	}
+	err := server.validateVirtualLink(cfg)
+	if err != nil {
+		server.logger.Err("Unable to create Virtual Link:", err)
+		return false, err
+	}
+	intfConfKey := getVirtualLinkIntfKey(cfg.NbrRouterId)
+	_, exist = server.IntfConfMap[intfConfKey]
+	if exist {
+		server.logger.Err("Virtual Link to the router already exist")
+		return false, errors.New("Virtual Link to the router already exist")
+	}
+
+	areaEnt, _ := server.AreaConfMap[0]
+	var intfConfEnt IntfConf
+	intfConfEnt.AdminState = cfg.AdminState
+	intfConfEnt.AreaId = 0
+	intfConfEnt.Type = objects.INTF_TYPE_VIRTUAL
+	intfConfEnt.TransitDelay = cfg.TransitDelay
+	intfConfEnt.RetransInterval = cfg.RetransInterval
+	intfConfEnt.HelloInterval = cfg.HelloInterval
+	intfConfEnt.RtrDeadInterval = cfg.RtrDeadInterval
+	// RFC 2328 10.8: Interface MTU is 0 on virtual links
+	intfConfEnt.Mtu = 0
+	intfConfEnt.AuthType = uint16(areaEnt.AuthType)
+	intfConfEnt.AuthData = newIntfAuthData()
+	intfConfEnt.FSMState = objects.INTF_FSM_STATE_DOWN
+	// Oper State, Cost and IpAddr are updated once the
+	// virtual neighbor is reachable through the transit area
+	intfConfEnt.OperState = false
+	intfConfEnt.LsaCount = 0
+	server.IntfConfMap[intfConfKey] = intfConfEnt
+
+	areaEnt.IntfMap[intfConfKey] = true
+	server.MessagingChData.NbrToIntfFSMChData.NbrDownMsgChMap[intfConfKey] = make(chan NbrDownMsg)
+	server.AreaConfMap[0] = areaEnt
+
+	server.VirtualLinkConfMap[vKey] = VirtualLinkConf{
+		IntfKey: intfConfKey,
+	}
+	server.GetBulkData.VirtualLinkConfSlice = append(server.GetBulkData.VirtualLinkConfSlice, vKey)
+	if server.globalData.AdminState == true {
+		// Trigger SPF to find the path through transit area
+		server.SendMsgToGenerateRouterLSA(cfg.TransitAreaId)
+	}
+	return true, nil
+}
+
+func (server *OSPFV2Server) updateVirtualLink(newCfg, oldCfg *objects.Ospfv2VirtualLink, attrset []bool) (bool, error) {
+	server.logger.Info("Virtual Link configuration update")
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: newCfg.TransitAreaId,
+		NbrRtrId:      newCfg.NbrRouterId,
+	}
+	vEnt, exist := server.VirtualLinkConfMap[vKey]
+	if !exist {
+		server.logger.Err("Virtual Link configuration doesnot exist")
+		return false, errors.New("Virtual Link configuration doesnot exist")
+	}
+	intfConfEnt, _ := server.IntfConfMap[vEnt.IntfKey]
+	areaEnt, _ := server.AreaConfMap[0]
+	if intfConfEnt.AdminState == true &&
+		server.globalData.AdminState == true &&
+		areaEnt.AdminState == true &&
+		intfConfEnt.OperState == true {
+		server.StopIntfFSM(vEnt.IntfKey)
+	}
+	intfConfEnt, _ = server.IntfConfMap[vEnt.IntfKey]
+	mask := getOspfv2VirtualLinkUpdateMask(attrset)
+	if mask&objects.OSPFV2_VIRTUAL_LINK_UPDATE_ADMIN_STATE == objects.OSPFV2_VIRTUAL_LINK_UPDATE_ADMIN_STATE {
+		intfConfEnt.AdminState = newCfg.AdminState
+	}
+	if mask&objects.OSPFV2_VIRTUAL_LINK_UPDATE_TRANSIT_DELAY == objects.OSPFV2_VIRTUAL_LINK_UPDATE_TRANSIT_DELAY {
+		intfConfEnt.TransitDelay = newCfg.TransitDelay
+	}
+	if mask&objects.OSPFV2_VIRTUAL_LINK_UPDATE_RETRANS_INTERVAL == objects.OSPFV2_VIRTUAL_LINK_UPDATE_RETRANS_INTERVAL {
+		intfConfEnt.RetransInterval = newCfg.RetransInterval
+	}
+	if mask&objects.OSPFV2_VIRTUAL_LINK_UPDATE_HELLO_INTERVAL == objects.OSPFV2_VIRTUAL_LINK_UPDATE_HELLO_INTERVAL {
+		intfConfEnt.HelloInterval = newCfg.HelloInterval
+	}
+	if mask&objects.OSPFV2_VIRTUAL_LINK_UPDATE_RTR_DEAD_INTERVAL == objects.OSPFV2_VIRTUAL_LINK_UPDATE_RTR_DEAD_INTERVAL {
+		intfConfEnt.RtrDeadInterval = newCfg.RtrDeadInterval
+	}
+	server.IntfConfMap[vEnt.IntfKey] = intfConfEnt
+	if intfConfEnt.AdminState == true &&
+		server.globalData.AdminState == true &&
+		areaEnt.AdminState == true &&
+		intfConfEnt.OperState == true {
+		server.StartIntfFSM(vEnt.IntfKey)
+		server.logger.Info("Started Virtual Link FSM successfully", vKey)
+	}
+	return true, nil
+}
+
+func (server *OSPFV2Server) deleteVirtualLink(cfg *objects.Ospfv2VirtualLink) (bool, error) {
+	server.logger.Info("Virtual Link configuration delete")
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: cfg.TransitAreaId,
+		NbrRtrId:      cfg.NbrRouterId,
+	}
+	vEnt, exist := server.VirtualLinkConfMap[vKey]
+	if !exist {
+		server.logger.Err("Virtual Link configuration doesnot exist")
+		return false, errors.New("Virtual Link configuration doesnot exist")
+	}
+	intfConfEnt, _ := server.IntfConfMap[vEnt.IntfKey]
+	areaEnt, _ := server.AreaConfMap[0]
+	if intfConfEnt.AdminState == true &&
+		server.globalData.AdminState == true &&
+		areaEnt.AdminState == true &&
+		intfConfEnt.OperState == true {
+		server.StopIntfFSM(vEnt.IntfKey)
+	}
+	delete(areaEnt.IntfMap, vEnt.IntfKey)
+	server.AreaConfMap[0] = areaEnt
+	delete(server.MessagingChData.NbrToIntfFSMChData.NbrDownMsgChMap, vEnt.IntfKey)
+	delete(server.IntfConfMap, vEnt.IntfKey)
+	delete(server.VirtualLinkConfMap, vKey)
+	return true, nil
+}
+
+func newVirtualLinkAuthKeyConf(cfg *objects.Ospfv2VirtualLinkAuthKey) AuthKeyConf {
+	return AuthKeyConf{
+		Key:                 []byte(cfg.Key),
+		CryptoAlgorithm:     cfg.CryptoAlgorithm,
+		SendLifetimeStart:   cfg.SendLifetimeStart,
+		SendLifetimeEnd:     cfg.SendLifetimeEnd,
+		AcceptLifetimeStart: cfg.AcceptLifetimeStart,
+		AcceptLifetimeEnd:   cfg.AcceptLifetimeEnd,
+	}
+}
+
+// Key chain of a virtual link is held by its virtual interface
+func (server *OSPFV2Server) getVirtualLinkAuthIntfKey(transitAreaId, nbrRtrId uint32) (IntfConfKey, error) {
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: transitAreaId,
+		NbrRtrId:      nbrRtrId,
+	}
+	vEnt, exist := server.VirtualLinkConfMap[vKey]
+	if !exist {
+		server.logger.Err("Virtual Link configuration doesnot exist")
+		return IntfConfKey{}, errors.New("Virtual Link configuration doesnot exist")
+	}
+	return vEnt.IntfKey, nil
+}
+
+func (server *OSPFV2Server) createVirtualLinkAuthKey(cfg *objects.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	server.logger.Info("Virtual Link auth key configuration create")
+	intfConfKey, err := server.getVirtualLinkAuthIntfKey(cfg.TransitAreaId, cfg.NbrRouterId)
+	if err != nil {
+		return false, err
+	}
+	return server.addAuthKey(intfConfKey, cfg.KeyId, newVirtualLinkAuthKeyConf(cfg))
+}
+
+func (server *OSPFV2Server) updateVirtualLinkAuthKey(newCfg, oldCfg *objects.Ospfv2VirtualLinkAuthKey, attrset []bool) (bool, error) {
+	server.logger.Info("Virtual Link auth key configuration update")
+	intfConfKey, err := server.getVirtualLinkAuthIntfKey(newCfg.TransitAreaId, newCfg.NbrRouterId)
+	if err != nil {
+		return false, err
+	}
+	mask := genOspfv2IntfAuthKeyUpdateMask(attrset)
+	return server.modifyAuthKey(intfConfKey, newCfg.KeyId, newVirtualLinkAuthKeyConf(newCfg), mask)
+}
+
+func (server *OSPFV2Server) deleteVirtualLinkAuthKey(cfg *objects.Ospfv2VirtualLinkAuthKey) (bool, error) {
+	server.logger.Info("Virtual Link auth key configuration delete")
+	intfConfKey, err := server.getVirtualLinkAuthIntfKey(cfg.TransitAreaId, cfg.NbrRouterId)
+	if err != nil {
+		return false, err
+	}
+	return server.removeAuthKey(intfConfKey, cfg.KeyId)
+}
+
+func (server *OSPFV2Server) fillVirtualLinkState(vKey VirtualLinkConfKey, vEnt VirtualLinkConf, obj *objects.Ospfv2VirtualLinkState) {
+	intfEnt, _ := server.IntfConfMap[vEnt.IntfKey]
+	obj.TransitAreaId = vKey.TransitAreaId
+	obj.NbrRouterId = vKey.NbrRtrId
+	obj.State = intfEnt.FSMState
+	obj.IpAddress = intfEnt.IpAddr
+	obj.NbrIpAddress = vEnt.NbrIpAddr
+	obj.NbrState = objects.NBR_STATE_DOWN
+	for nbrKey, _ := range intfEnt.NbrMap {
+		nbrEnt, exist := server.NbrConfMap[nbrKey]
+		if exist {
+			obj.NbrState = uint8(nbrEnt.State)
+		}
+	}
+	obj.Cost = intfEnt.Cost
+	obj.NumOfStateChange = intfEnt.NumOfStateChange
+	obj.TimeOfStateChange = intfEnt.TimeOfStateChange
+}
+
+func (server *OSPFV2Server) getVirtualLinkState(transitAreaId, nbrRtrId uint32) (*objects.Ospfv2VirtualLinkState, error) {
+	var retObj objects.Ospfv2VirtualLinkState
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: transitAreaId,
+		NbrRtrId:      nbrRtrId,
+	}
+	vEnt, exist := server.VirtualLinkConfMap[vKey]
+	if !exist {
+		server.logger.Err("Get Virtual Link State: Virtual Link does not exist", vKey)
+		return nil, errors.New("Virtual Link does not exist")
+	}
+	server.fillVirtualLinkState(vKey, vEnt, &retObj)
+	return &retObj, nil
+}
+
+func (server *OSPFV2Server) getBulkVirtualLinkState(fromIdx, cnt int) (*objects.Ospfv2VirtualLinkStateGetInfo, error) {
+	var retObj objects.Ospfv2VirtualLinkStateGetInfo
+	count := 0
+	idx := fromIdx
+	sliceLen := len(server.GetBulkData.VirtualLinkConfSlice)
+	if fromIdx >= sliceLen {
+		return nil, errors.New("Invalid Range")
+	}
+	for count < cnt {
+		if idx == sliceLen {
+			break
+		}
+		vKey := server.GetBulkData.VirtualLinkConfSlice[idx]
+		vEnt, exist := server.VirtualLinkConfMap[vKey]
+		if !exist {
+			idx++
+			continue
+		}
+		var obj objects.Ospfv2VirtualLinkState
+		server.fillVirtualLinkState(vKey, vEnt, &obj)
+		retObj.List = append(retObj.List, &obj)
+		count++
+		idx++
+	}
+
+	retObj.EndIdx = idx
+	if retObj.EndIdx == sliceLen {
+		retObj.More = false
+		retObj.Count = 0
+	} else {
+		retObj.More = true
+		retObj.Count = sliceLen - retObj.EndIdx + 1
+	}
+	return &retObj, nil
+}
+
+func (server *OSPFV2Server) RefreshVirtualLinkConfSlice() {
+	if len(server.GetBulkData.VirtualLinkConfSlice) == 0 {
+		return
+	}
+	server.GetBulkData.VirtualLinkConfSlice = nil
+	for vKey, _ := range server.VirtualLinkConfMap {
+		server.GetBulkData.VirtualLinkConfSlice = append(server.GetBulkData.VirtualLinkConfSlice, vKey)
+	}
+}
+
+func (server *OSPFV2Server) isVirtualLinkIntf(intfKey IntfConfKey) bool {
+	intfEnt, exist := server.IntfConfMap[intfKey]
+	if !exist {
+		return false
+	}
+	return intfEnt.Type == objects.INTF_TYPE_VIRTUAL
+}
+
+func (server *OSPFV2Server) getVirtualLinkConfKey(intfKey IntfConfKey) (VirtualLinkConfKey, bool) {
+	for vKey, vEnt := range server.VirtualLinkConfMap {
+		if vEnt.IntfKey == intfKey {
+			return vKey, true
+		}
+	}
+	return VirtualLinkConfKey{}, false
+}
+
+func (server *OSPFV2Server) isVirtualLinkTransitArea(areaId uint32) bool {
+	for vKey, _ := range server.VirtualLinkConfMap {
+		if vKey.TransitAreaId == areaId {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if any of the virtual links through
+// the given area is fully adjacent
+func (server *OSPFV2Server) isActiveVirtualLinkTransitArea(areaId uint32) bool {
+	for vKey, vEnt := range server.VirtualLinkConfMap {
+		if vKey.TransitAreaId != areaId {
+			continue
+		}
+		intfEnt, exist := server.IntfConfMap[vEnt.IntfKey]
+		if !exist {
+			continue
+		}
+		_, full := server.getVirtualLinkFullNbr(intfEnt)
+		if full {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *OSPFV2Server) getVirtualLinkFullNbr(intfEnt IntfConf) (uint32, bool) {
+	for nbrKey, nbr := range intfEnt.NbrMap {
+		nbrEnt, exist := server.NbrConfMap[nbrKey]
+		if exist && nbrEnt.State == NbrFull {
+			return nbr.RtrId, true
+		}
+	}
+	return 0, false
+}
+
+// Virtual neighbors address packets to any of our
+// interface addresses in the transit area
+func (server *OSPFV2Server) isVirtualLinkTransitAddr(ipAddr uint32) bool {
+	for vKey, _ := range server.VirtualLinkConfMap {
+		areaEnt, exist := server.AreaConfMap[vKey.TransitAreaId]
+		if !exist {
+			continue
+		}
+		for intfKey, _ := range areaEnt.IntfMap {
+			intfEnt, exist := server.IntfConfMap[intfKey]
+			if exist && intfEnt.IpAddr == ipAddr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (server *OSPFV2Server) getVirtualLinkForRecvPkt(transitAreaId, areaId, rtrId uint32) (VirtualLinkConfKey, error) {
+	vKey := VirtualLinkConfKey{
+		TransitAreaId: transitAreaId,
+		NbrRtrId:      rtrId,
+	}
+	if areaId != 0 {
+		return vKey, errors.New("Packet doesnot belong to the backbone")
+	}
+	vEnt, exist := server.VirtualLinkConfMap[vKey]
+	if !exist {
+		return vKey, errors.New(fmt.Sprintln("No virtual link configured to", convertUint32ToDotNotation(rtrId)))
+	}
+	intfEnt, exist := server.IntfConfMap[vEnt.IntfKey]
+	if !exist || intfEnt.FSMState == objects.INTF_FSM_STATE_DOWN {
+		return vKey, errors.New(fmt.Sprintln("Virtual link to", convertUint32ToDotNotation(rtrId), "is down"))
+	}
+	return vKey, nil
+}
+
+func (server *OSPFV2Server) sendMsgToGenerateTransitAreaRouterLSA(intfKey IntfConfKey) {
+	vKey, exist := server.getVirtualLinkConfKey(intfKey)
+	if exist {
+		server.SendMsgToGenerateRouterLSA(vKey.TransitAreaId)
+	}
+}
+
+// RFC 2328 13.3: AS External LSAs are not flooded over virtual links
+func (server *OSPFV2Server) skipVirtualLinkFlood(intfEnt IntfConf, lsType uint8) bool {
+	return intfEnt.Type == objects.INTF_TYPE_VIRTUAL &&
+		lsType == ASExternalLSA
+}
+
+func (server *OSPFV2Server) processVirtualLinkOspfData(recvPktData *OspfPktStruct) error {
+	vEnt, exist := server.VirtualLinkConfMap[recvPktData.OspfHdrMd.VirtualLinkKey]
+	if !exist {
+		return errors.New("Virtual link no more valid")
+	}
+	if vEnt.RecvHelloPkt.OspfRecvHelloPktCh == nil ||
+		vEnt.RecvLsaAndDbdPkt.OspfRecvLsaAndDbdPktCh == nil {
+		return errors.New("Virtual link is not receiving packets")
+	}
+	return server.processOspfData(recvPktData, vEnt.RecvHelloPkt.OspfRecvHelloPktCh,
+		vEnt.RecvLsaAndDbdPkt.OspfRecvLsaAndDbdPktCh)
+}
+
+func (server *OSPFV2Server) StartVirtualLinkRecvPkts(intfKey IntfConfKey) {
+	vKey, exist := server.getVirtualLinkConfKey(intfKey)
+	if !exist {
+		server.logger.Err("Virtual link does not exist for", intfKey)
+		return
+	}
+	vEnt, _ := server.VirtualLinkConfMap[vKey]
+	vEnt.RecvHelloPkt = OspfHelloPktRecvStruct{
+		OspfRecvHelloPktCh:       make(chan *OspfPktStruct, 1000),
+		OspfRecvHelloCtrlCh:      make(chan bool),
+		OspfRecvHelloCtrlReplyCh: make(chan bool),
+		IntfConfKey:              intfKey,
+	}
+	vEnt.RecvLsaAndDbdPkt = OspfLsaAndDbdPktRecvStruct{
+		OspfRecvLsaAndDbdPktCh:       make(chan *OspfPktStruct, 1000),
+		OspfRecvLsaAndDbdCtrlCh:      make(chan bool),
+		OspfRecvLsaAndDbdCtrlReplyCh: make(chan bool),
+		IntfConfKey:                  intfKey,
+	}
+	server.VirtualLinkConfMap[vKey] = vEnt
+	go server.ProcessOspfRecvHelloPkt(vEnt.RecvHelloPkt)
+	go server.ProcessOspfRecvLsaAndDbdPkt(vEnt.RecvLsaAndDbdPkt)
+	server.logger.Info("Started Virtual Link Recv Pkt routines", vKey)
+}
+
+func (server *OSPFV2Server) StopVirtualLinkRecvPkts(intfKey IntfConfKey) {
+	vKey, exist := server.getVirtualLinkConfKey(intfKey)
+	if !exist {
+		server.logger.Err("Virtual link does not exist for", intfKey)
+		return
+	}
+	vEnt, _ := server.VirtualLinkConfMap[vKey]
+	vEnt.RecvHelloPkt.OspfRecvHelloCtrlCh <- true
+	<-vEnt.RecvHelloPkt.OspfRecvHelloCtrlReplyCh
+	vEnt.RecvLsaAndDbdPkt.OspfRecvLsaAndDbdCtrlCh <- true
+	<-vEnt.RecvLsaAndDbdPkt.OspfRecvLsaAndDbdCtrlReplyCh
+	server.logger.Info("Stopped Virtual Link Recv Pkt routines", vKey)
+}
+
+func (server *OSPFV2Server) getVirtualLinkNextHopMac(vEnt VirtualLinkConf) (net.HardwareAddr, error) {
+	for _, nbrEnt := range server.NbrConfMap {
+		if nbrEnt.IntfKey == vEnt.TransitIntfKey &&
+			nbrEnt.NbrIP == vEnt.NextHopIP {
+			return nbrEnt.NbrMac, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("No neighbor found for next hop", convertUint32ToDotNotation(vEnt.NextHopIP)))
+}
+
+// Readdress the packet built for the virtual interface to the virtual
+// neighbor and send it out of the transit area interface
+func (server *OSPFV2Server) sendVirtualLinkOspfPkt(intfKey IntfConfKey, ospfPkt []byte) error {
+	vKey, exist := server.getVirtualLinkConfKey(intfKey)
+	if !exist {
+		return errors.New("Virtual link does not exist")
+	}
+	vEnt, _ := server.VirtualLinkConfMap[vKey]
+	transitEnt, exist := server.IntfConfMap[vEnt.TransitIntfKey]
+	if !exist || transitEnt.txHdl.SendPcapHdl == nil {
+		return errors.New("Transit interface of virtual link is not up")
+	}
+	dstMac, err := server.getVirtualLinkNextHopMac(vEnt)
+	if err != nil {
+		return err
+	}
+	pkt := gopacket.NewPacket(ospfPkt, layers.LayerTypeEthernet, gopacket.Default)
+	ethLayer := pkt.Layer(layers.LayerTypeEthernet)
+	ipLayer := pkt.Layer(layers.LayerTypeIPv4)
+	if ethLayer == nil || ipLayer == nil {
+		return errors.New("Invalid ospf pkt")
+	}
+	eth := ethLayer.(*layers.Ethernet)
+	ip := ipLayer.(*layers.IPv4)
+	eth.SrcMAC = transitEnt.IfMacAddr
+	eth.DstMAC = dstMac
+	ip.DstIP = net.ParseIP(convertUint32ToDotNotation(vEnt.NbrIpAddr))
+	ip.TTL = VIRTUAL_LINK_TTL
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}
+	err = gopacket.SerializeLayers(buffer, options, eth, ip, gopacket.Payload(ip.LayerPayload()))
+	if err != nil {
+		return err
+	}
+	transitEnt.txHdl.SendMutex.Lock()
+	err = transitEnt.txHdl.SendPcapHdl.WritePacketData(buffer.Bytes())
+	transitEnt.txHdl.SendMutex.Unlock()
+	return err
+}
+
+// Called by SPF before the backbone calculation
+func (server *OSPFV2Server) updateVirtualLinkTransitPaths() {
+	pathMap := make(map[VirtualLinkConfKey]VirtualLinkPath)
+	for vKey, _ := range server.VirtualLinkConfMap {
+		path, err := server.calcVirtualLinkTransitPath(vKey)
+		if err != nil {
+			server.logger.Info("Virtual Link", vKey, "is not operational:", err)
+		}
+		pathMap[vKey] = path
+	}
+	changed := len(pathMap) != len(server.RoutingTblData.VirtualLinkPathMap)
+	for vKey, path := range pathMap {
+		oldPath, exist := server.RoutingTblData.VirtualLinkPathMap[vKey]
+		if !exist || oldPath != path {
+			changed = true
+		}
+	}
+	// Map is replaced and never modified as it is read by the server routine
+	server.RoutingTblData.VirtualLinkPathMap = pathMap
+	if changed {
+		select {
+		case server.MessagingChData.SPFToServerChData.VirtualLinkPathChangeCh <- true:
+		default:
+			// Server routine has not yet processed the previous change
+		}
+	}
+}
+
+func (server *OSPFV2Server) calcVirtualLinkTransitPath(vKey VirtualLinkConfKey) (VirtualLinkPath, error) {
+	var path VirtualLinkPath
+	areaIdKey := AreaIdKey{
+		AreaId: vKey.TransitAreaId,
+	}
+	tempAreaRoutingTbl, exist := server.RoutingTblData.TempAreaRoutingTbl[areaIdKey]
+	if !exist || tempAreaRoutingTbl.RoutingTblMap == nil {
+		return path, errors.New("No routing table for transit area")
+	}
+	rKey := RoutingTblEntryKey{
+		DestId:   vKey.NbrRtrId,
+		AddrMask: 0,
+		DestType: AreaBdrRouter,
+	}
+	rEnt, exist := tempAreaRoutingTbl.RoutingTblMap[rKey]
+	if !exist {
+		rKey.DestType = ASAreaBdrRouter
+		rEnt, exist = tempAreaRoutingTbl.RoutingTblMap[rKey]
+		if !exist {
+			return path, errors.New("Virtual neighbor is not reachable through transit area")
+		}
+	}
+	if rEnt.PathType != IntraArea || len(rEnt.NextHops) == 0 {
+		return path, errors.New("No intra area path to virtual neighbor")
+	}
+	var nextHop NextHop
+	first := true
+	for key, _ := range rEnt.NextHops {
+		if first || key.NextHopIP < nextHop.NextHopIP {
+			nextHop = key
+			first = false
+		}
+	}
+	nbrIpAddr, err := server.findVirtualNbrIpAddr(vKey)
+	if err != nil {
+		return path, err
+	}
+	transitIntfKey, err := server.findTransitIntfKey(vKey.TransitAreaId, nextHop.IfIPAddr)
+	if err != nil {
+		return path, err
+	}
+	path.OperState = true
+	path.TransitIntfKey = transitIntfKey
+	path.IpAddr = nextHop.IfIPAddr
+	path.NbrIpAddr = nbrIpAddr
+	path.NextHopIP = nextHop.NextHopIP
+	if path.NextHopIP == 0 {
+		path.NextHopIP = nbrIpAddr
+	}
+	path.Cost = uint32(rEnt.Cost)
+	return path, nil
+}
+
+// RFC 2328 16.1 Step 4: Virtual neighbor's IP address is taken
+// from its router LSA in the transit area
+func (server *OSPFV2Server) findVirtualNbrIpAddr(vKey VirtualLinkConfKey) (uint32, error) {
+	lsdbKey := LsdbKey{
+		AreaId: vKey.TransitAreaId,
+	}
+	lsDbEnt, exist := server.LsdbData.AreaLsdb[lsdbKey]
+	if !exist {
+		return 0, errors.New("No LS Database found for transit area")
+	}
+	lsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      vKey.NbrRtrId,
+		AdvRouter: vKey.NbrRtrId,
+	}
+	lsaEnt, exist := lsDbEnt.RouterLsaMap[lsaKey]
+	if !exist || lsaEnt.LsaMd.LSAge == MAX_AGE {
+		return 0, errors.New("No Router LSA of virtual neighbor in transit area")
+	}
+	// Lowest address is used so that the choice is
+	// stable across the neighbor's LSA instances
+	var nbrIpAddr uint32
+	for _, link := range lsaEnt.LinkDetails {
+		if link.LinkType != TRANSIT_LINK &&
+			link.LinkType != P2P_LINK {
+			continue
+		}
+		if nbrIpAddr == 0 || link.LinkData < nbrIpAddr {
+			nbrIpAddr = link.LinkData
+		}
+	}
+	if nbrIpAddr == 0 {
+		return 0, errors.New("No interface address of virtual neighbor in transit area")
+	}
+	return nbrIpAddr, nil
+}
+
+func (server *OSPFV2Server) findTransitIntfKey(areaId uint32, ipAddr uint32) (IntfConfKey, error) {
+	areaEnt, exist := server.AreaConfMap[areaId]
+	if !exist {
+		return IntfConfKey{}, errors.New("Transit area doesnot exist")
+	}
+	for intfKey, _ := range areaEnt.IntfMap {
+		intfEnt, exist := server.IntfConfMap[intfKey]
+		if exist && intfEnt.IpAddr == ipAddr {
+			return intfKey, nil
+		}
+	}
+	return IntfConfKey{}, errors.New(fmt.Sprintln("No transit area interface with address", convertUint32ToDotNotation(ipAddr)))
+}
+
+// Next hop of the backbone paths over the virtual link to nbrRtrId
+func (server *OSPFV2Server) findVirtualLinkNextHop(nbrRtrId uint32) (ifIPAddr uint32, nextHopIP uint32, err error) {
+	for vKey, path := range server.RoutingTblData.VirtualLinkPathMap {
+		if vKey.NbrRtrId == nbrRtrId && path.OperState == true {
+			return path.IpAddr, path.NextHopIP, nil
+		}
+	}
+	return 0, 0, errors.New("No path through transit area for the virtual link")
+}
+
+// Apply the transit area paths calculated by SPF to the virtual interfaces
+func (server *OSPFV2Server) processVirtualLinkPathChange() {
+	pathMap := server.RoutingTblData.VirtualLinkPathMap
+	for vKey, vEnt := range server.VirtualLinkConfMap {
+		path, _ := pathMap[vKey]
+		intfEnt, exist := server.IntfConfMap[vEnt.IntfKey]
+		if !exist {
+			continue
+		}
+		restart := intfEnt.OperState != path.OperState ||
+			intfEnt.IpAddr != path.IpAddr ||
+			vEnt.NbrIpAddr != path.NbrIpAddr
+		costChange := intfEnt.Cost != path.Cost
+		if !restart && !costChange &&
+			vEnt.TransitIntfKey == path.TransitIntfKey &&
+			vEnt.NextHopIP == path.NextHopIP {
+			continue
+		}
+		server.logger.Info("Virtual Link", vKey, "path changed to", path)
+		if restart {
+			server.StopIntfFSM(vEnt.IntfKey)
+		}
+		intfEnt, _ = server.IntfConfMap[vEnt.IntfKey]
+		transitEnt, _ := server.IntfConfMap[path.TransitIntfKey]
+		intfEnt.OperState = path.OperState
+		intfEnt.IpAddr = path.IpAddr
+		intfEnt.Cost = path.Cost
+		intfEnt.IfName = transitEnt.IfName
+		intfEnt.IfMacAddr = transitEnt.IfMacAddr
+		intfEnt.IfType = transitEnt.IfType
+		server.IntfConfMap[vEnt.IntfKey] = intfEnt
+		vEnt, _ = server.VirtualLinkConfMap[vKey]
+		vEnt.TransitIntfKey = path.TransitIntfKey
+		vEnt.NbrIpAddr = path.NbrIpAddr
+		vEnt.NextHopIP = path.NextHopIP
+		server.VirtualLinkConfMap[vKey] = vEnt
+		if restart {
+			server.StartIntfFSM(vEnt.IntfKey)
+		} else if costChange &&
+			intfEnt.FSMState != objects.INTF_FSM_STATE_DOWN {
+			server.SendMsgToGenerateRouterLSA(intfEnt.AreaId)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/ospfv2/objects"
+	"testing"
+)
+
+const (
+	testTransitAreaId uint32 = 1
+	testStubAreaId    uint32 = 2
+	testVirtualNbrId  uint32 = 0x02020202
+)
+
+var (
+	testTransitIntfKey = IntfConfKey{IpAddr: 0x0a000101}
+	testVirtualIntfKey = getVirtualLinkIntfKey(testVirtualNbrId)
+)
+
+// Virtual link to testVirtualNbrId through area 1, with the router LSA of the
+// virtual neighbor in the transit area LSDB
+func buildTestVirtualLinkServer(t *testing.T, authType uint8) *OSPFV2Server {
+	server := newTestServer(t)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: 0, ImportASExtern: true, AuthType: authType})
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: testTransitAreaId, ImportASExtern: true})
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: testStubAreaId})
+	createTestIntf(t, server, 10, 0xffffff00, objects.Ospfv2Intf{
+		IpAddress: testTransitIntfKey.IpAddr,
+		AreaId:    testTransitAreaId,
+		Type:      objects.INTF_TYPE_BROADCAST,
+	})
+	createTestVirtualLink(t, server, objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId, NbrRouterId: testVirtualNbrId})
+	server.LsdbData.AreaLsdb[LsdbKey{AreaId: testTransitAreaId}] = LSDatabase{
+		RouterLsaMap: map[LsaKey]RouterLsa{
+			LsaKey{LSType: RouterLSA, LSId: testVirtualNbrId, AdvRouter: testVirtualNbrId}: RouterLsa{
+				BitB: true,
+				LinkDetails: []LinkDetail{
+					LinkDetail{LinkId: 0x0b000000, LinkData: 0xffffff00, LinkType: STUB_LINK},
+					LinkDetail{LinkId: 0x0a000202, LinkData: 0x0a000202, LinkType: TRANSIT_LINK},
+					LinkDetail{LinkId: 0x03030303, LinkData: 0x0a000302, LinkType: P2P_LINK},
+				},
+			},
+		},
+	}
+	return server
+}
+
+func TestValidateVirtualLink(t *testing.T) {
+	server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_NONE)
+	tests := []struct {
+		name  string
+		cfg   objects.Ospfv2VirtualLink
+		valid bool
+	}{
+		{"valid", objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId, NbrRouterId: testVirtualNbrId}, true},
+		{"backbone transit area", objects.Ospfv2VirtualLink{TransitAreaId: 0, NbrRouterId: testVirtualNbrId}, false},
+		{"unknown transit area", objects.Ospfv2VirtualLink{TransitAreaId: 5, NbrRouterId: testVirtualNbrId}, false},
+		{"stub transit area", objects.Ospfv2VirtualLink{TransitAreaId: testStubAreaId, NbrRouterId: testVirtualNbrId}, false},
+		{"no neighbor", objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId}, false},
+		{"own router id", objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId, NbrRouterId: testRouterId}, false},
+	}
+	for _, test := range tests {
+		if err := server.validateVirtualLink(&test.cfg); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+	cfg := objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId, NbrRouterId: testVirtualNbrId}
+	if _, err := server.createVirtualLink(&cfg); err == nil {
+		t.Error("Virtual link created twice")
+	}
+	server = newTestServer(t)
+	createTestArea(t, server, objects.Ospfv2Area{AreaId: testTransitAreaId, ImportASExtern: true})
+	if _, err := server.createVirtualLink(&cfg); err == nil {
+		t.Error("Virtual link created without a backbone area")
+	}
+}
+
+func TestCalcVirtualLinkTransitPath(t *testing.T) {
+	vKey := VirtualLinkConfKey{TransitAreaId: testTransitAreaId, NbrRtrId: testVirtualNbrId}
+	nbrKey := RoutingTblEntryKey{DestId: testVirtualNbrId, DestType: AreaBdrRouter}
+	nextHops := map[NextHop]bool{
+		NextHop{IfIPAddr: testTransitIntfKey.IpAddr, NextHopIP: 0x0a000105}: true,
+		NextHop{IfIPAddr: testTransitIntfKey.IpAddr, NextHopIP: 0x0a000103}: true,
+	}
+	tests := []struct {
+		name      string
+		rKey      RoutingTblEntryKey
+		rEnt      RoutingTblEntry
+		operState bool
+		nextHopIP uint32
+	}{
+		{"intra area path", nbrKey, RoutingTblEntry{PathType: IntraArea, Cost: 20, NextHops: nextHops}, true, 0x0a000103},
+		{"neighbor is also an ASBR", RoutingTblEntryKey{DestId: testVirtualNbrId, DestType: ASAreaBdrRouter},
+			RoutingTblEntry{PathType: IntraArea, Cost: 20, NextHops: nextHops}, true, 0x0a000103},
+		{"directly connected neighbor", nbrKey, RoutingTblEntry{PathType: IntraArea, Cost: 10,
+			NextHops: map[NextHop]bool{NextHop{IfIPAddr: testTransitIntfKey.IpAddr}: true}}, true, 0x0a000202},
+		{"inter area path", nbrKey, RoutingTblEntry{PathType: InterArea, Cost: 20, NextHops: nextHops}, false, 0},
+		{"no next hop", nbrKey, RoutingTblEntry{PathType: IntraArea, Cost: 20}, false, 0},
+		{"neighbor not reachable", RoutingTblEntryKey{DestId: 0x09090909, DestType: AreaBdrRouter},
+			RoutingTblEntry{PathType: IntraArea, Cost: 20, NextHops: nextHops}, false, 0},
+		{"next hop not on a transit area interface", nbrKey, RoutingTblEntry{PathType: IntraArea, Cost: 20,
+			NextHops: map[NextHop]bool{NextHop{IfIPAddr: 0x0c000101, NextHopIP: 0x0c000102}: true}}, false, 0},
+	}
+	for _, test := range tests {
+		server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_NONE)
+		server.RoutingTblData.TempAreaRoutingTbl = map[AreaIdKey]AreaRoutingTbl{
+			AreaIdKey{AreaId: testTransitAreaId}: AreaRoutingTbl{
+				RoutingTblMap: map[RoutingTblEntryKey]RoutingTblEntry{test.rKey: test.rEnt},
+			},
+		}
+		path, err := server.calcVirtualLinkTransitPath(vKey)
+		if path.OperState != test.operState || (err == nil) != test.operState {
+			t.Error(test.name, ": got path", path, "err", err)
+			continue
+		}
+		if !test.operState {
+			continue
+		}
+		// Lowest transit address of the neighbor's router LSA
+		if path.TransitIntfKey != testTransitIntfKey || path.IpAddr != testTransitIntfKey.IpAddr ||
+			path.NbrIpAddr != 0x0a000202 || path.NextHopIP != test.nextHopIP || path.Cost != uint32(test.rEnt.Cost) {
+			t.Error(test.name, ": unexpected path", path)
+		}
+	}
+	server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_NONE)
+	if _, err := server.calcVirtualLinkTransitPath(vKey); err == nil {
+		t.Error("Virtual link path found without a transit area routing table")
+	}
+}
+
+func TestGetVirtualLinkForRecvPkt(t *testing.T) {
+	server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_NONE)
+	// Brought up by the Intf FSM once the transit path is found
+	intfEnt := server.IntfConfMap[testVirtualIntfKey]
+	intfEnt.FSMState = objects.INTF_FSM_STATE_P2P
+	server.IntfConfMap[testVirtualIntfKey] = intfEnt
+	tests := []struct {
+		name          string
+		transitAreaId uint32
+		areaId        uint32
+		rtrId         uint32
+		valid         bool
+	}{
+		{"backbone packet from the virtual neighbor", testTransitAreaId, 0, testVirtualNbrId, true},
+		{"packet of the transit area", testTransitAreaId, testTransitAreaId, testVirtualNbrId, false},
+		{"other router", testTransitAreaId, 0, 0x03030303, false},
+		{"other transit area", testStubAreaId, 0, testVirtualNbrId, false},
+	}
+	for _, test := range tests {
+		vKey, err := server.getVirtualLinkForRecvPkt(test.transitAreaId, test.areaId, test.rtrId)
+		if (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+		if vKey.TransitAreaId != test.transitAreaId || vKey.NbrRtrId != test.rtrId {
+			t.Error(test.name, ": unexpected virtual link", vKey)
+		}
+	}
+	intfEnt.FSMState = objects.INTF_FSM_STATE_DOWN
+	server.IntfConfMap[testVirtualIntfKey] = intfEnt
+	if _, err := server.getVirtualLinkForRecvPkt(testTransitAreaId, 0, testVirtualNbrId); err == nil {
+		t.Error("Packet accepted on a virtual link that is down")
+	}
+	if !server.skipVirtualLinkFlood(intfEnt, ASExternalLSA) || server.skipVirtualLinkFlood(intfEnt, Summary3LSA) ||
+		server.skipVirtualLinkFlood(server.IntfConfMap[testTransitIntfKey], ASExternalLSA) {
+		t.Error("Unexpected flooding over the virtual link")
+	}
+}
+
+func TestVirtualLinkIntfKey(t *testing.T) {
+	server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_NONE)
+	// Unnumbered interface whose ifIndex equals the router id of a
+	// virtual neighbor
+	nbrRtrId := uint32(20)
+	unnumberedIntfKey := IntfConfKey{IntfIdx: nbrRtrId}
+	createTestIntf(t, server, 20, 0, objects.Ospfv2Intf{
+		AddressLessIfIdx: nbrRtrId,
+		AreaId:           testTransitAreaId,
+		Type:             objects.INTF_TYPE_POINT2POINT,
+	})
+	cfg := objects.Ospfv2VirtualLink{TransitAreaId: testTransitAreaId, NbrRouterId: nbrRtrId}
+	createTestVirtualLink(t, server, cfg)
+	virtualIntfKey := getVirtualLinkIntfKey(nbrRtrId)
+	if server.IntfConfMap[virtualIntfKey].Type != objects.INTF_TYPE_VIRTUAL ||
+		server.IntfConfMap[unnumberedIntfKey].Type != objects.INTF_TYPE_POINT2POINT {
+		t.Error("Virtual interface collides with the unnumbered interface")
+	}
+	if !server.isVirtualLinkIntf(virtualIntfKey) || server.isVirtualLinkIntf(unnumberedIntfKey) {
+		t.Error("Unexpected virtual link interfaces")
+	}
+	if _, err := server.deleteVirtualLink(&cfg); err != nil {
+		t.Fatal("Failed to delete virtual link, err:", err)
+	}
+	if _, exist := server.IntfConfMap[unnumberedIntfKey]; !exist {
+		t.Error("Unnumbered interface deleted with the virtual link")
+	}
+}
+
+func TestVirtualLinkAuthKey(t *testing.T) {
+	server := buildTestVirtualLinkServer(t, objects.AUTH_TYPE_CRYPTOGRAPHIC)
+	cfg := objects.Ospfv2VirtualLinkAuthKey{
+		TransitAreaId:   testTransitAreaId,
+		NbrRouterId:     testVirtualNbrId,
+		KeyId:           1,
+		Key:             "virtual-link-key",
+		CryptoAlgorithm: objects.CRYPTO_ALGO_HMAC_SHA256,
+	}
+	tests := []struct {
+		name  string
+		cfg   objects.Ospfv2VirtualLinkAuthKey
+		valid bool
+	}{
+		{"valid", cfg, true},
+		{"same key id", cfg, false},
+		{"unknown virtual link", objects.Ospfv2VirtualLinkAuthKey{TransitAreaId: testTransitAreaId, NbrRouterId: 0x03030303,
+			KeyId: 1, Key: cfg.Key, CryptoAlgorithm: cfg.CryptoAlgorithm}, false},
+		{"MD5 key longer than 16 bytes", objects.Ospfv2VirtualLinkAuthKey{TransitAreaId: testTransitAreaId, NbrRouterId: testVirtualNbrId,
+			KeyId: 2, Key: "virtual-link-md5-key", CryptoAlgorithm: objects.CRYPTO_ALGO_MD5}, false},
+	}
+	for _, test := range tests {
+		if _, err := server.createVirtualLinkAuthKey(&test.cfg); (err == nil) != test.valid {
+			t.Error(test.name, ": expected valid", test.valid, "got err", err)
+		}
+	}
+	// Configured through the virtual link, not the 0.0.0.0 interface
+	// named by the router id
+	intfAuthKey := objects.Ospfv2IntfAuthKey{AddressLessIfIdx: testVirtualNbrId, KeyId: 2, Key: cfg.Key, CryptoAlgorithm: cfg.CryptoAlgorithm}
+	if _, err := server.createIntfAuthKey(&intfAuthKey); err == nil {
+		t.Error("Virtual link key configured as an interface key")
+	}
+
+	intfEnt := server.IntfConfMap[testVirtualIntfKey]
+	if pkt := server.encodeOspfAuth(intfEnt, buildTestOspfPkt(20)); pkt == nil || pkt[18] != cfg.KeyId {
+		t.Error("Virtual link packet not authenticated", pkt)
+	}
+	newCfg := cfg
+	newCfg.Key = "new-virtual-link-key"
+	if _, err := server.updateVirtualLinkAuthKey(&newCfg, &cfg, []bool{false, false, false, true}); err != nil {
+		t.Error("Failed to update virtual link key, err:", err)
+	}
+	if key := intfEnt.AuthData.KeyChain[cfg.KeyId]; string(key.Key) != newCfg.Key ||
+		key.CryptoAlgorithm != cfg.CryptoAlgorithm {
+		t.Error("Unexpected virtual link key", key)
+	}
+	if _, err := server.deleteVirtualLinkAuthKey(&cfg); err != nil {
+		t.Error("Failed to delete virtual link key, err:", err)
+	}
+	if _, err := server.deleteVirtualLinkAuthKey(&cfg); err == nil {
+		t.Error("Virtual link key deleted twice")
+	}
+}
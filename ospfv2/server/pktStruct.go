@@ -92,6 +92,9 @@ type OspfHdrMetadata struct {
 	Backbone bool
 	RouterId uint32
 	AreaId   uint32
+	// Set when the packet is received over a virtual link
+	VirtualLink    bool
+	VirtualLinkKey VirtualLinkConfKey
 }
 
 func NewOspfHdrMetadata() *OspfHdrMetadata {
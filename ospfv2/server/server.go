@@ -67,14 +67,18 @@ type OSPFV2Server struct {
 
 	ribdComm  RibdCommStruct
 	asicdComm AsicdCommStruct
+	bfddComm  BfddCommStruct
 
 	infraData InfraStruct
 
-	globalData      GlobalStruct
-	IntfConfMap     map[IntfConfKey]IntfConf
-	NbrConfMap      map[NbrConfKey]NbrConf
-	AreaConfMap     map[uint32]AreaConf //Key AreaId
-	MessagingChData MessagingChStruct
+	globalData         GlobalStruct
+	IntfConfMap        map[IntfConfKey]IntfConf
+	VirtualLinkConfMap map[VirtualLinkConfKey]VirtualLinkConf
+	AreaRangeConfMap   map[AreaRangeKey]AreaRangeConf
+	PrefixListConfMap  map[string]PrefixListConf
+	NbrConfMap         map[NbrConfKey]NbrConf
+	AreaConfMap        map[uint32]AreaConf //Key AreaId
+	MessagingChData    MessagingChStruct
 
 	NbrConfData    NbrStruct
 	LsdbData       LsdbStruct
@@ -82,6 +86,7 @@ type OSPFV2Server struct {
 	SPFData        SPFStruct
 	RoutingTblData RoutingTblStruct
 	SummaryLsDb    map[LsdbKey]SummaryLsaMap
+	grData         GracefulRestartStruct
 
 	GetBulkData GetBulkStruct
 }
@@ -97,6 +102,9 @@ func NewOspfv2Server(initParams InitParams) (*OSPFV2Server, error) {
 	server.ReplyChan = make(chan interface{})
 	server.InitCompleteCh = make(chan bool)
 	server.IntfConfMap = make(map[IntfConfKey]IntfConf)
+	server.VirtualLinkConfMap = make(map[VirtualLinkConfKey]VirtualLinkConf)
+	server.AreaRangeConfMap = make(map[AreaRangeKey]AreaRangeConf)
+	server.PrefixListConfMap = make(map[string]PrefixListConf)
 	server.AreaConfMap = make(map[uint32]AreaConf)
 	return &server, nil
 }
@@ -122,6 +130,9 @@ func (server *OSPFV2Server) ConnectToServers() {
 			server.ConnectToRibdServer(client.Port)
 		} else if client.Name == "asicd" {
 			server.ConnectToAsicdServer(client.Port)
+		} else if client.Name == "bfdd" {
+			// BFD is optional, dont hold up the init for it
+			go server.ConnectToBfddServer(client.Port)
 		}
 	}
 }
@@ -129,6 +140,7 @@ func (server *OSPFV2Server) ConnectToServers() {
 func (server *OSPFV2Server) StartSubscribers() {
 	server.StartAsicdSubscriber()
 	server.StartRibdSubscriber()
+	server.StartBfddSubscriber()
 }
 
 func (server *OSPFV2Server) initMessagingChData() {
@@ -145,9 +157,13 @@ func (server *OSPFV2Server) initMessagingChData() {
 	server.MessagingChData.NbrFSMToFloodChData.LsaFloodCh = make(chan NbrToFloodMsg)
 	server.MessagingChData.LsdbToSPFChData.StartSPF = make(chan bool)
 	server.MessagingChData.SPFToLsdbChData.DoneSPF = make(chan bool)
+	// Buffered so that SPF never waits on the server routine
+	server.MessagingChData.SPFToServerChData.VirtualLinkPathChangeCh = make(chan bool, 1)
 	server.MessagingChData.ServerToLsdbChData.RefreshLsdbSliceCh = make(chan bool)
 	server.MessagingChData.ServerToLsdbChData.RouteInfoDataUpdateCh = make(chan RouteInfoDataUpdateMsg)
 	server.MessagingChData.ServerToLsdbChData.InitAreaLsdbCh = make(chan uint32)
+	// Buffered so that the exit can be signalled from any routine
+	server.MessagingChData.ServerToLsdbChData.GracefulRestartExitCh = make(chan uint8, 1)
 	server.MessagingChData.LsdbToServerChData.InitAreaLsdbDoneCh = make(chan bool)
 	server.MessagingChData.LsdbToServerChData.RefreshLsdbSliceDoneCh = make(chan bool)
 	server.MessagingChData.RouteTblToDBClntChData.RouteAddMsgCh = make(chan RouteAddMsg, 100)
@@ -162,8 +178,11 @@ func (server *OSPFV2Server) SigHandler(sigChan <-chan os.Signal) {
 	switch signal {
 	case syscall.SIGHUP:
 		server.logger.Debug("Received SIGHUP signal")
-		server.SendFlushRouteMsgToDBClnt()
-		<-server.MessagingChData.DBClntToServerChData.FlushRouteFromDBDoneCh
+		// Routes are kept in ribd and DB across a graceful restart
+		if !server.prepareGracefulRestart() {
+			server.SendFlushRouteMsgToDBClnt()
+			<-server.MessagingChData.DBClntToServerChData.FlushRouteFromDBDoneCh
+		}
 		debug.PrintStack()
 		var memStat runtime.MemStats
 		runtime.ReadMemStats(&memStat)
@@ -182,6 +201,7 @@ func (server *OSPFV2Server) initServer() error {
 	server.initMessagingChData()
 	server.initAsicdComm()
 	server.initRibdComm()
+	server.initBfddComm()
 	server.ConnectToServers()
 	server.StartSubscribers()
 	server.initInfra()
@@ -193,6 +213,7 @@ func (server *OSPFV2Server) initServer() error {
 		server.logger.Err("DB Handle is nil")
 		return errors.New("DB Handle is nil")
 	}
+	server.initGracefulRestart()
 	go server.StartDBClient()
 	return nil
 }
@@ -290,6 +311,108 @@ func (server *OSPFV2Server) handleRPCRequest(req *ServerRequest) {
 			retObj.BulkInfo, retObj.Err = server.getBulkIntfState(val.FromIdx, val.Count)
 		}
 		server.ReplyChan <- interface{}(&retObj)
+	case CREATE_OSPFV2_INTF_AUTH_KEY:
+		var retObj CreateConfigOutArgs
+		if val, ok := req.Data.(*CreateOspfv2IntfAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.createIntfAuthKey(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case UPDATE_OSPFV2_INTF_AUTH_KEY:
+		var retObj UpdateConfigOutArgs
+		if val, ok := req.Data.(*UpdateOspfv2IntfAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.updateIntfAuthKey(val.NewCfg, val.OldCfg, val.AttrSet)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case DELETE_OSPFV2_INTF_AUTH_KEY:
+		var retObj DeleteConfigOutArgs
+		if val, ok := req.Data.(*DeleteOspfv2IntfAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.deleteIntfAuthKey(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case CREATE_OSPFV2_VIRTUAL_LINK:
+		var retObj CreateConfigOutArgs
+		if val, ok := req.Data.(*CreateOspfv2VirtualLinkInArgs); ok {
+			retObj.RetVal, retObj.Err = server.createVirtualLink(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case UPDATE_OSPFV2_VIRTUAL_LINK:
+		var retObj UpdateConfigOutArgs
+		if val, ok := req.Data.(*UpdateOspfv2VirtualLinkInArgs); ok {
+			retObj.RetVal, retObj.Err = server.updateVirtualLink(val.NewCfg, val.OldCfg, val.AttrSet)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case DELETE_OSPFV2_VIRTUAL_LINK:
+		var retObj DeleteConfigOutArgs
+		if val, ok := req.Data.(*DeleteOspfv2VirtualLinkInArgs); ok {
+			retObj.RetVal, retObj.Err = server.deleteVirtualLink(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case GET_OSPFV2_VIRTUAL_LINK_STATE:
+		var retObj GetOspfv2VirtualLinkStateOutArgs
+		if val, ok := req.Data.(*GetOspfv2VirtualLinkStateInArgs); ok {
+			retObj.Obj, retObj.Err = server.getVirtualLinkState(val.TransitAreaId, val.NbrRouterId)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case GET_BULK_OSPFV2_VIRTUAL_LINK_STATE:
+		var retObj GetBulkOspfv2VirtualLinkStateOutArgs
+		if val, ok := req.Data.(*GetBulkInArgs); ok {
+			retObj.BulkInfo, retObj.Err = server.getBulkVirtualLinkState(val.FromIdx, val.Count)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case CREATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY:
+		var retObj CreateConfigOutArgs
+		if val, ok := req.Data.(*CreateOspfv2VirtualLinkAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.createVirtualLinkAuthKey(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case UPDATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY:
+		var retObj UpdateConfigOutArgs
+		if val, ok := req.Data.(*UpdateOspfv2VirtualLinkAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.updateVirtualLinkAuthKey(val.NewCfg, val.OldCfg, val.AttrSet)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case DELETE_OSPFV2_VIRTUAL_LINK_AUTH_KEY:
+		var retObj DeleteConfigOutArgs
+		if val, ok := req.Data.(*DeleteOspfv2VirtualLinkAuthKeyInArgs); ok {
+			retObj.RetVal, retObj.Err = server.deleteVirtualLinkAuthKey(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case CREATE_OSPFV2_AREA_RANGE:
+		var retObj CreateConfigOutArgs
+		if val, ok := req.Data.(*CreateOspfv2AreaRangeInArgs); ok {
+			retObj.RetVal, retObj.Err = server.createAreaRange(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case UPDATE_OSPFV2_AREA_RANGE:
+		var retObj UpdateConfigOutArgs
+		if val, ok := req.Data.(*UpdateOspfv2AreaRangeInArgs); ok {
+			retObj.RetVal, retObj.Err = server.updateAreaRange(val.NewCfg, val.OldCfg, val.AttrSet)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case DELETE_OSPFV2_AREA_RANGE:
+		var retObj DeleteConfigOutArgs
+		if val, ok := req.Data.(*DeleteOspfv2AreaRangeInArgs); ok {
+			retObj.RetVal, retObj.Err = server.deleteAreaRange(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case CREATE_OSPFV2_PREFIX_LIST:
+		var retObj CreateConfigOutArgs
+		if val, ok := req.Data.(*CreateOspfv2PrefixListInArgs); ok {
+			retObj.RetVal, retObj.Err = server.createPrefixList(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case UPDATE_OSPFV2_PREFIX_LIST:
+		var retObj UpdateConfigOutArgs
+		if val, ok := req.Data.(*UpdateOspfv2PrefixListInArgs); ok {
+			retObj.RetVal, retObj.Err = server.updatePrefixList(val.NewCfg, val.OldCfg, val.AttrSet)
+		}
+		server.ReplyChan <- interface{}(&retObj)
+	case DELETE_OSPFV2_PREFIX_LIST:
+		var retObj DeleteConfigOutArgs
+		if val, ok := req.Data.(*DeleteOspfv2PrefixListInArgs); ok {
+			retObj.RetVal, retObj.Err = server.deletePrefixList(val.Cfg)
+		}
+		server.ReplyChan <- interface{}(&retObj)
 	case GET_OSPFV2_NBR_STATE:
 		var retObj GetOspfv2NbrStateOutArgs
 		if val, ok := req.Data.(*GetOspfv2NbrStateInArgs); ok {
@@ -344,9 +467,20 @@ func (server *OSPFV2Server) StartOspfv2Server() {
 			server.logger.Debug("Done Process Rib Rx Buf", ribRxBuf)
 		case <-server.ribdComm.ribdSubSocketErrCh:
 			server.logger.Err("Invalid Message from Ribd")
+		case bfdRxBuf := <-server.bfddComm.bfddSubSocketCh:
+			server.logger.Debug("Process Bfd Rx Buf", bfdRxBuf)
+			server.processBfddNotification(bfdRxBuf)
+			server.logger.Debug("Done Process Bfd Rx Buf", bfdRxBuf)
+		case <-server.bfddComm.bfddSubSocketErrCh:
+			server.logger.Err("Invalid Message from Bfdd")
+		case <-server.MessagingChData.SPFToServerChData.VirtualLinkPathChangeCh:
+			server.logger.Debug("Process Virtual Link path change")
+			server.processVirtualLinkPathChange()
 		case <-server.GetBulkData.SliceRefreshCh:
 			server.logger.Debug("Refresh IntfConf Slice")
 			server.RefreshIntfConfSlice()
+			server.logger.Debug("Refresh VirtualLinkConf Slice")
+			server.RefreshVirtualLinkConfSlice()
 			server.logger.Debug("Refresh NbrConf Slice")
 			server.RefreshNbrConfSlice()
 			server.logger.Debug("Refresh AreaConf Slice")
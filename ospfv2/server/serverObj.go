@@ -45,6 +45,23 @@ const (
 	DELETE_OSPFV2_INTF
 	GET_OSPFV2_INTF_STATE
 	GET_BULK_OSPFV2_INTF_STATE
+	CREATE_OSPFV2_INTF_AUTH_KEY
+	UPDATE_OSPFV2_INTF_AUTH_KEY
+	DELETE_OSPFV2_INTF_AUTH_KEY
+	CREATE_OSPFV2_VIRTUAL_LINK
+	UPDATE_OSPFV2_VIRTUAL_LINK
+	DELETE_OSPFV2_VIRTUAL_LINK
+	GET_OSPFV2_VIRTUAL_LINK_STATE
+	GET_BULK_OSPFV2_VIRTUAL_LINK_STATE
+	CREATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY
+	UPDATE_OSPFV2_VIRTUAL_LINK_AUTH_KEY
+	DELETE_OSPFV2_VIRTUAL_LINK_AUTH_KEY
+	CREATE_OSPFV2_AREA_RANGE
+	UPDATE_OSPFV2_AREA_RANGE
+	DELETE_OSPFV2_AREA_RANGE
+	CREATE_OSPFV2_PREFIX_LIST
+	UPDATE_OSPFV2_PREFIX_LIST
+	DELETE_OSPFV2_PREFIX_LIST
 	GET_OSPFV2_NBR_STATE
 	GET_BULK_OSPFV2_NBR_STATE
 	GET_OSPFV2_LSDB_STATE
@@ -146,6 +163,91 @@ type DeleteOspfv2IntfInArgs struct {
 	Cfg *objects.Ospfv2Intf
 }
 
+type CreateOspfv2IntfAuthKeyInArgs struct {
+	Cfg *objects.Ospfv2IntfAuthKey
+}
+
+type UpdateOspfv2IntfAuthKeyInArgs struct {
+	OldCfg  *objects.Ospfv2IntfAuthKey
+	NewCfg  *objects.Ospfv2IntfAuthKey
+	AttrSet []bool
+}
+
+type DeleteOspfv2IntfAuthKeyInArgs struct {
+	Cfg *objects.Ospfv2IntfAuthKey
+}
+
+type CreateOspfv2VirtualLinkInArgs struct {
+	Cfg *objects.Ospfv2VirtualLink
+}
+
+type UpdateOspfv2VirtualLinkInArgs struct {
+	OldCfg  *objects.Ospfv2VirtualLink
+	NewCfg  *objects.Ospfv2VirtualLink
+	AttrSet []bool
+}
+
+type DeleteOspfv2VirtualLinkInArgs struct {
+	Cfg *objects.Ospfv2VirtualLink
+}
+
+type CreateOspfv2VirtualLinkAuthKeyInArgs struct {
+	Cfg *objects.Ospfv2VirtualLinkAuthKey
+}
+
+type UpdateOspfv2VirtualLinkAuthKeyInArgs struct {
+	OldCfg  *objects.Ospfv2VirtualLinkAuthKey
+	NewCfg  *objects.Ospfv2VirtualLinkAuthKey
+	AttrSet []bool
+}
+
+type DeleteOspfv2VirtualLinkAuthKeyInArgs struct {
+	Cfg *objects.Ospfv2VirtualLinkAuthKey
+}
+
+type CreateOspfv2AreaRangeInArgs struct {
+	Cfg *objects.Ospfv2AreaRange
+}
+
+type UpdateOspfv2AreaRangeInArgs struct {
+	OldCfg  *objects.Ospfv2AreaRange
+	NewCfg  *objects.Ospfv2AreaRange
+	AttrSet []bool
+}
+
+type DeleteOspfv2AreaRangeInArgs struct {
+	Cfg *objects.Ospfv2AreaRange
+}
+
+type CreateOspfv2PrefixListInArgs struct {
+	Cfg *objects.Ospfv2PrefixList
+}
+
+type UpdateOspfv2PrefixListInArgs struct {
+	OldCfg  *objects.Ospfv2PrefixList
+	NewCfg  *objects.Ospfv2PrefixList
+	AttrSet []bool
+}
+
+type DeleteOspfv2PrefixListInArgs struct {
+	Cfg *objects.Ospfv2PrefixList
+}
+
+type GetOspfv2VirtualLinkStateInArgs struct {
+	TransitAreaId uint32
+	NbrRouterId   uint32
+}
+
+type GetOspfv2VirtualLinkStateOutArgs struct {
+	Obj *objects.Ospfv2VirtualLinkState
+	Err error
+}
+
+type GetBulkOspfv2VirtualLinkStateOutArgs struct {
+	BulkInfo *objects.Ospfv2VirtualLinkStateGetInfo
+	Err      error
+}
+
 type GetOspfv2IntfStateInArgs struct {
 	IpAddr           uint32
 	AddressLessIfIdx uint32
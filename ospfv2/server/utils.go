@@ -37,6 +37,9 @@ func convertDotNotationToUint32(str string) (uint32, error) {
 		return 0, errors.New("Invalid string format")
 	}
 	ipBytes := ip.To4()
+	if ipBytes == nil {
+		return 0, errors.New("Not an IPv4 address")
+	}
 	val = val + uint32(ipBytes[0])
 	val = (val << 8) + uint32(ipBytes[1])
 	val = (val << 8) + uint32(ipBytes[2])
@@ -24,6 +24,7 @@
 package ribdCommonDefs
 
 import (
+	"encoding/json"
 	"ribdInt"
 	"utils/commonDefs"
 )
@@ -79,6 +80,26 @@ const (
 	DelPolicyDefinition
 	UpdatePolicyDefinition
 	ApplyPolicy
+	AddRPF
+	DelRPF
+	AddLabeled
+	DelLabeled
+	FlushStaleRoutes
+	MarkStaleRoutes
+	SweepStaleRoutes
+	HoldStaleRoutes
+	NextHopDown
+	NextHopUp
+	AddVrf
+	DelVrf
+	UpdateVrf
+	AddVrfRouteLeak
+	DelVrfRouteLeak
+	BfdSessionStateChange
+	AddPbrPolicy
+	DelPbrPolicy
+	UpdatePbrPolicy
+	FIBAudit
 )
 const (
 	CONNECTED                                    = 0
@@ -87,9 +108,13 @@ const (
 	EBGP                                         = 8
 	IBGP                                         = 9
 	BGP                                          = 17
+	KERNEL                                       = 3
+	RIP                                          = 120
+	DefaultVrf                                   = "default"
 	PUB_SOCKET_ADDR                              = "ipc:///tmp/ribd.ipc"
 	PUB_SOCKET_BGPD_ADDR                         = "ipc:///tmp/ribd_bgpd.ipc"
 	PUB_SOCKET_OSPFD_ADDR                        = "ipc:///tmp/ribd_ospfd.ipc"
+	PUB_SOCKET_RIPD_ADDR                         = "ipc:///tmp/ribd_ripd.ipc"
 	PUB_SOCKET_BFDD_ADDR                         = "ipc:///tmp/ribd_bfdd.ipc"
 	PUB_SOCKET_VXLAND_ADDR                       = "ipc:///tmp/ribd_vxland.ipc"
 	PUB_SOCKET_POLICY_ADDR                       = "ipc:///tmp/ribd_policyd.ipc"
@@ -118,26 +143,48 @@ const (
 	NOTIFY_POLICY_ASPATH_SET_CREATED             = 23
 	NOTIFY_POLICY_ASPATH_SET_DELETED             = 24
 	NOTIFY_POLICY_ASPATH_SET_UPDATED             = 25
+	NOTIFY_BFD_SESSION_CREATE                    = 26
+	NOTIFY_BFD_SESSION_DELETE                    = 27
 	DEFAULT_NOTIFICATION_SIZE                    = 128
 	RoutePolicyStateChangetoValid                = 1
 	RoutePolicyStateChangetoInValid              = 2
 	RoutePolicyStateChangeNoChange               = 3
 )
 
+/*
+   Vrf is marshalled first so that a subscriber can receive the notifications
+   of a single VRF by subscribing to VrfNotifyMsgFilter(vrf)
+*/
 type RibdNotifyMsg struct {
+	Vrf     string
 	MsgType uint16
 	MsgBuf  []byte
 }
 
+func VrfNotifyMsgFilter(vrf string) string {
+	vrfBytes, _ := json.Marshal(vrf)
+	return `{"Vrf":` + string(vrfBytes) + `,`
+}
+
 type RoutelistInfo struct {
 	RouteInfo ribdInt.Routes
 }
 type RouteReachabilityStatusMsgInfo struct {
+	Vrf         string
 	Network     string
 	IsReachable bool
 	NextHopIntf ribdInt.NextHopInfo
 }
 
+/*
+   BFD session ribd asks bfdd to create or delete for a static route next hop
+*/
+type BfdSessionMsgInfo struct {
+	DestIp    string
+	Interface string
+	MultiHop  bool
+}
+
 func GetNextHopIfTypeStr(nextHopIfType ribdInt.Int) (nextHopIfTypeStr string, err error) {
 	nextHopIfTypeStr = ""
 	switch nextHopIfType {
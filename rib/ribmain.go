@@ -29,6 +29,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	//"github.com/davecheney/profile"
 	"l3/rib/asicdMgr"
@@ -98,6 +99,12 @@ func main() {
 		}
 	*/
 	paramsDir := flag.String("params", "./params", "Params directory")
+	fibPlugin := flag.String("fib", server.FIBPluginAsicd, "Routes are installed through asicd or netlink")
+	fibTable := flag.Int("fibtable", syscall.RT_TABLE_MAIN, "Linux routing table used by the netlink fib")
+	staleHold := flag.Int("stalehold", int(server.DefaultStaleRouteHoldTime/time.Second), "Seconds the routes of a restarting protocol daemon are retained")
+	fibAudit := flag.Int("fibaudit", int(server.DefaultFIBAuditInterval/time.Second), "Seconds between the audits of the FIB against the RIB, 0 disables them")
+	fibAuditRepair := flag.Bool("fibauditrepair", false, "The periodic FIB audit repairs the differences it finds")
+	kernelTables := flag.String("kerneltables", "", "Comma separated Linux routing tables whose routes are imported as KERNEL routes")
 	flag.Parse()
 	fileName := *paramsDir
 	if fileName[len(fileName)-1] != '/' {
@@ -122,29 +129,79 @@ func main() {
 		logger.Println("routeServer nil")
 		return
 	}
+	routeServer.StaleRouteHoldTime = time.Duration(*staleHold) * time.Second
+	routeServer.FIBAudit = server.NewFIBAudit(time.Duration(*fibAudit)*time.Second, *fibAuditRepair)
 
+	/*
+	   With the netlink fib the kernel resolves the next hops and the
+	   interfaces are read from the kernel links, so ribd runs without arpd
+	   and asicd when they are not reachable
+	*/
+	netlinkFIB := *fibPlugin == server.FIBPluginNetlink
 	//arpdNHdl := arpdMgr.NewNotificationHdl(routeServer, logger)
 	arpdClntInitParams, err := clntIntfs.NewBaseClntInitParams("arpd", logger, nil, fileName)
 	if err != nil {
 		logger.Err("RIBD: Error Initializing base clnt for arpd")
-		panic(err)
+	} else {
+		routeServer.ArpdClntPlugin, err = arpdClntIntfs.NewArpdClntInit(arpdClntInitParams)
+		if err != nil {
+			logger.Err("RIBD: Error Initializing new Arpd clnt")
+		}
 	}
-	routeServer.ArpdClntPlugin, err = arpdClntIntfs.NewArpdClntInit(arpdClntInitParams)
 	if err != nil {
-		logger.Err("RIBD: Error Initializing new Arpd clnt")
-		panic(err)
+		if !netlinkFIB {
+			panic(err)
+		}
+		logger.Warning("RIBD: Running the netlink fib without arpd")
+		routeServer.ArpdClntPlugin = nil
 	}
 
 	asicdNHdl := asicdMgr.NewNotificationHdl(routeServer, logger)
 	asicdClntInitParams, err := clntIntfs.NewBaseClntInitParams("asicd", logger, asicdNHdl, fileName)
 	if err != nil {
 		logger.Err("RIBD: Error Initializing base clnt for asicd")
-		panic(err)
+	} else {
+		routeServer.AsicdPlugin, err = asicdClntIntfs.NewAsicdClntInit(asicdClntInitParams)
+		if err != nil {
+			logger.Err("RIBD: Error Initializing new Asicd Clnt")
+		}
 	}
-	routeServer.AsicdPlugin, err = asicdClntIntfs.NewAsicdClntInit(asicdClntInitParams)
 	if err != nil {
-		logger.Err("RIBD: Error Initializing new Asicd Clnt")
-		panic(err)
+		if !netlinkFIB {
+			panic(err)
+		}
+		logger.Warning("RIBD: Running the netlink fib without asicd")
+		routeServer.AsicdPlugin = nil
+	}
+
+	if netlinkFIB {
+		routeServer.NetlinkPlugin, err = server.NewNetlinkPlugin(*fibTable)
+		if err != nil {
+			logger.Err("RIBD: Error Initializing netlink fib for table ", *fibTable)
+			panic(err)
+		}
+		routeServer.FIBPlugin = routeServer.NetlinkPlugin
+		routeServer.PBRPlugin = routeServer.NetlinkPlugin
+		err = routeServer.NetlinkPlugin.ReadStaleRoutes()
+		if err != nil {
+			logger.Err("RIBD: Error reading kernel routes of table ", *fibTable, " err:", err)
+		}
+	} else if *fibPlugin != server.FIBPluginAsicd {
+		logger.Err("RIBD: Unknown fib ", *fibPlugin)
+		return
+	}
+
+	if *kernelTables != "" {
+		tables, err := server.ParseKernelRouteTables(*kernelTables)
+		if err != nil {
+			logger.Err("RIBD: Error parsing kernel route tables ", *kernelTables, " err:", err)
+			return
+		}
+		routeServer.KernelRouteImporter, err = server.NewKernelRouteImporter(routeServer, tables)
+		if err != nil {
+			logger.Err("RIBD: Error Initializing kernel route import of tables ", tables)
+			panic(err)
+		}
 	}
 
 	go routeServer.StartServer(*paramsDir)
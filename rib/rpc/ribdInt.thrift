@@ -51,7 +51,8 @@ struct Routes {
 	17: bool NetworkStatement,
 	18: string RouteOrigin,
 	19: int Weight,
-	20: int IPAddrType
+	20: int IPAddrType,
+	21: string Vrf
 }
 struct RoutesGetInfo {
 	1: int StartIdx,
@@ -100,6 +101,8 @@ struct IPv4RouteConfig {
 	4 : i32 Cost
 	5 : bool NullRoute
 	6 : list<RouteNextHopInfo> NextHop
+	7 : i32 RouteTag
+	8 : string Vrf
 }
 struct IPv4Route {
 	1 : string DestinationNw
@@ -136,6 +139,9 @@ struct IPv4RouteState {
 	6 : list<RouteNextHopInfo> NextHopList
 	7 : list<string> PolicyList
 	8 : NextBestRouteInfo NextBestRoute
+	9 : bool IsStale
+	10 : string Vrf
+	11 : string SourceVrf
 }
 struct IPv6RouteState {
 	1 : string DestinationNw
@@ -146,6 +152,164 @@ struct IPv6RouteState {
 	6 : list<RouteNextHopInfo> NextHopList
 	7 : list<string> PolicyList
 	8 : NextBestRouteInfo NextBestRoute
+	9 : bool IsStale
+	10 : string Vrf
+	11 : string SourceVrf
+}
+struct RPFRoute {
+	1 : string DestinationNw
+	2 : string NetworkMask
+	3 : string Protocol
+	4 : i32 Cost
+	5 : list<RouteNextHopInfo> NextHop
+}
+struct RPFRouteState {
+	1 : string DestinationNw
+	2 : string Protocol
+	3 : string RouteCreatedTime
+	4 : string RouteUpdatedTime
+	5 : list<RouteNextHopInfo> NextHopList
+}
+struct RPFRouteStateGetInfo {
+	1: int StartIdx,
+	2: int EndIdx,
+	3: int Count,
+	4: bool More,
+	5: list<RPFRouteState> RPFRouteStateList,
+}
+struct LabeledNextHopInfo {
+	1 : string NextHopIp
+	2 : string NextHopIntRef
+	3 : list<i32> OutLabels
+}
+struct LabeledRoute {
+	1 : string DestinationNw
+	2 : string NetworkMask
+	3 : string Protocol
+	4 : i32 Cost
+	5 : i32 InLabel
+	6 : list<LabeledNextHopInfo> NextHop
+}
+struct LabeledRouteState {
+	1 : string DestinationNw
+	2 : string Protocol
+	3 : i32 InLabel
+	4 : string RouteCreatedTime
+	5 : string RouteUpdatedTime
+	6 : list<LabeledNextHopInfo> NextHopList
+}
+struct LabeledRouteStateGetInfo {
+	1: int StartIdx,
+	2: int EndIdx,
+	3: int Count,
+	4: bool More,
+	5: list<LabeledRouteState> LabeledRouteStateList,
+}
+struct Vrf {
+	1 : string VrfName
+	2 : list<string> IntfList
+}
+struct VrfState {
+	1 : string VrfName
+	2 : list<string> IntfList
+	3 : i32 V4RouteCount
+	4 : i32 V6RouteCount
+}
+struct VrfRouteLeak {
+	1 : string SrcVrf
+	2 : string DstVrf
+	3 : string Policy
+}
+struct RouteCandidateState {
+	1 : string Protocol
+	2 : i32 AdminDistance
+	3 : i32 Metric
+	4 : string NextHopIp
+	5 : string NextHopIntRef
+	6 : string ResolvedNextHopIp
+	7 : string ResolvedNextHopIntRef
+	8 : bool IsReachable
+	9 : bool IsStale
+	10 : bool Selected
+	11 : bool PolicyRejected
+	12 : string Reason
+	13 : string RouteCreatedTime
+	14 : bool InFIB
+	15 : string FIBProgrammedTime
+}
+struct RouteSelectionExplainState {
+	1 : string Vrf
+	2 : string Destination
+	3 : string MatchedPrefix
+	4 : string SelectedProtocol
+	5 : list<string> PolicyList
+	6 : i32 PolicyHitCounter
+	7 : list<RouteCandidateState> Candidates
+}
+struct FIBQueueState {
+	1 : i32 QueueDepth
+	2 : i32 MaxQueueDepth
+	3 : i32 HighWatermark
+	4 : i32 ChannelDepth
+	5 : i64 Batches
+	6 : i64 RoutesProgrammed
+	7 : i64 RoutesCoalesced
+	8 : i32 LastBatchSize
+	9 : i64 LastLatencyUsec
+	10 : i64 AvgLatencyUsec
+	11 : i64 MaxLatencyUsec
+}
+struct FIBAuditEntryState {
+	1 : string Vrf
+	2 : string DestinationNw
+	3 : string Status
+	4 : list<string> RIBNextHops
+	5 : list<string> FIBNextHops
+	6 : bool Repaired
+}
+struct FIBAuditState {
+	1 : i32 IntervalSec
+	2 : bool Repair
+	3 : i64 Audits
+	4 : string LastAuditTime
+	5 : i64 DurationUsec
+	6 : bool LastAuditRepair
+	7 : i32 RIBRoutes
+	8 : i32 FIBRoutes
+	9 : i32 Missing
+	10 : i32 Extra
+	11 : i32 Mismatched
+	12 : i32 Repaired
+	13 : string Error
+	14 : list<FIBAuditEntryState> Entries
+}
+struct PbrRule {
+	1 : i32 Seq
+	2 : string SrcPrefix
+	3 : string DstPrefix
+	4 : string Protocol
+	5 : i32 SrcPort
+	6 : i32 DstPort
+	7 : i32 Dscp
+	8 : string NextHopIp
+	9 : string Vrf
+}
+struct PbrPolicy {
+	1 : string Name
+	2 : list<string> IntfList
+	3 : list<PbrRule> Rules
+}
+struct PbrRuleState {
+	1 : i32 Seq
+	2 : string NextHopIp
+	3 : string Vrf
+	4 : string ResolvedNextHopIp
+	5 : bool Installed
+}
+struct PbrPolicyState {
+	1 : string Name
+	2 : list<string> IntfList
+	3 : list<PbrRuleState> Rules
 }
 struct ApplyPolicyInfo {
 	1: string Source     
@@ -160,6 +324,8 @@ service RIBDINTServices
     //void printV4Routes();
 	RoutesGetInfo getBulkRoutesForProtocol(1: string srcProtocol, 2: int fromIndex ,3: int rcount)
     void TrackReachabilityStatus(1: string ipAddr, 2: string protocol, 3:string op) //op:"add"/"del"
+    NextHopInfo getVrfRouteReachabilityInfo(1: string vrf, 2: string destNet, 3: int ifIndex);
+    void TrackVrfReachabilityStatus(1: string vrf, 2: string ipAddr, 3: string protocol, 4: string op)
 	//RoutesGetInfo getBulkRoutes(1: int fromIndex, 2: int count);
 	IPv4RouteState getv4Route(1: string destNetIp);
 	IPv6RouteState getv6Route(1: string destNetIp);
@@ -168,6 +334,30 @@ service RIBDINTServices
 	string Getv4RouteCreatedTime(1:int number);
 	string Getv6RouteCreatedTime(1:int number);
 	oneway void OnewayCreateBulkIPv4Route(1: list<IPv4RouteConfig> config);
+	oneway void OnewayCreateRPFRoute(1: RPFRoute config);
+	oneway void OnewayDeleteRPFRoute(1: RPFRoute config);
+	oneway void OnewayRoutesEndOfRIB(1: string protocol);
+	oneway void OnewayHoldStaleRoutes(1: string protocol, 2: int holdTime);
+	NextHopInfo getRPFRouteReachabilityInfo(1: string srcIp);
+	RPFRouteStateGetInfo getBulkRPFRouteState(1: int fromIndex, 2: int rcount);
+	oneway void OnewayCreateLabeledRoute(1: LabeledRoute config);
+	oneway void OnewayDeleteLabeledRoute(1: LabeledRoute config);
+	LabeledRouteStateGetInfo getBulkLabeledRouteState(1: int fromIndex, 2: int rcount);
+	bool CreateVrf(1: Vrf config);
+	bool DeleteVrf(1: Vrf config);
+	bool UpdateVrf(1: Vrf origconfig, 2: Vrf newconfig);
+	VrfState getVrfState(1: string vrfName);
+	bool CreateVrfRouteLeak(1: VrfRouteLeak config);
+	bool DeleteVrfRouteLeak(1: VrfRouteLeak config);
+	IPv4RouteState getVrfv4Route(1: string vrf, 2: string destNetIp);
+	RouteSelectionExplainState getRouteSelectionExplain(1: string vrf, 2: string destNet);
+	FIBQueueState getFIBQueueState();
+	bool StartFIBAudit(1: bool repair);
+	FIBAuditState getFIBAuditState();
+	bool CreatePbrPolicy(1: PbrPolicy config);
+	bool DeletePbrPolicy(1: PbrPolicy config);
+	bool UpdatePbrPolicy(1: PbrPolicy origconfig, 2: PbrPolicy newconfig);
+	PbrPolicyState getPbrPolicyState(1: string name);
 	bool CreatePolicyAction(1: PolicyAction config);
 	bool UpdatePolicyAction(1: PolicyAction origconfig, 2: PolicyAction newconfig, 3: list<bool> attrset, 4: list<PatchOpInfo> op);
 	bool DeletePolicyAction(1: PolicyAction config);
@@ -31,6 +31,7 @@ import (
 	"models/objects"
 	"ribd"
 	"ribdInt"
+	"time"
 )
 
 /* Create route API
@@ -40,7 +41,9 @@ func (m RIBDServicesHandler) CreateIPv4Route(cfg *ribd.IPv4Route) (val bool, err
 	logger.Info("Received create route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask)
 	/* Validate Route config parameters for "add" operation
 	 */
+	m.server.RIB.RLock()
 	err = m.server.RouteConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
 	if err != nil {
 		logger.Err("validation check failed with error ", err)
 		return false, err
@@ -69,7 +72,9 @@ func (m RIBDServicesHandler) CreateIPv6Route(cfg *ribd.IPv6Route) (val bool, err
 	logger.Info("Received create route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask)
 	/* Validate Route config parameters for "add" operation
 	 */
+	m.server.RIB.RLock()
 	err = m.server.IPv6RouteConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
 	if err != nil {
 		logger.Err("validation check failed with error ", err)
 		return false, err
@@ -126,7 +131,9 @@ func (m RIBDServicesHandler) DeleteIPv4Route(cfg *ribd.IPv4Route) (val bool, err
 	/*
 	   Validate route config parameters for "del" operation
 	*/
+	m.server.RIB.RLock()
 	err = m.server.RouteConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
 	if err != nil {
 		logger.Err("validation check failed with error ", err)
 		return false, err
@@ -155,7 +162,9 @@ func (m RIBDServicesHandler) DeleteIPv6Route(cfg *ribd.IPv6Route) (val bool, err
 	/*
 	   Validate route config parameters for "del" operation
 	*/
+	m.server.RIB.RLock()
 	err = m.server.IPv6RouteConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
 	if err != nil {
 		logger.Err("validation check failed with error ", err)
 		return false, err
@@ -185,13 +194,17 @@ func (m RIBDServicesHandler) UpdateIPv4Route(origconfig *ribd.IPv4Route, newconf
 	   validate route config parameters for update operation
 	*/
 	if op == nil || len(op) == 0 {
+		m.server.RIB.RLock()
 		err = m.server.RouteConfigValidationCheckForUpdate(origconfig, newconfig, attrset)
+		m.server.RIB.RUnlock()
 		if err != nil {
 			logger.Err("validation check failed with error ", err)
 			return false, err
 		}
 	} else {
+		m.server.RIB.RLock()
 		err = m.server.RouteConfigValidationCheckForPatchUpdate(origconfig, newconfig, op)
+		m.server.RIB.RUnlock()
 		if err != nil {
 			logger.Err("validation check failed with error ", err)
 			return false, err
@@ -227,14 +240,18 @@ func (m RIBDServicesHandler) UpdateIPv6Route(origconfig *ribd.IPv6Route, newconf
 	*/
 	if op == nil || len(op) == 0 {
 		logger.Debug("UpdateIPv6Route:At the beginning origconfig.destinationnw:", origconfig.DestinationNw, " newconfig.DesinationNw:", newconfig.DestinationNw)
+		m.server.RIB.RLock()
 		err = m.server.IPv6RouteConfigValidationCheckForUpdate(origconfig, newconfig, attrset)
+		m.server.RIB.RUnlock()
 		if err != nil {
 			logger.Err("validation check failed with error ", err)
 			return false, err
 		}
 		logger.Debug("UpdateIPv6Route:At the end origconfig.destinationnw:", origconfig.DestinationNw, " newconfig.DesinationNw:", newconfig.DestinationNw)
 	} else {
+		m.server.RIB.RLock()
 		err = m.server.IPv6RouteConfigValidationCheckForPatchUpdate(origconfig, newconfig, op)
+		m.server.RIB.RUnlock()
 		if err != nil {
 			logger.Err("validation check failed with error ", err)
 			return false, err
@@ -265,7 +282,9 @@ func (m RIBDServicesHandler) OnewayUpdateIPv6Route(origconfig *ribd.IPv6Route, n
    Applications call this function to fetch all the routes that need to be redistributed into them.
 */
 func (m RIBDServicesHandler) GetBulkRoutesForProtocol(srcProtocol string, fromIndex ribdInt.Int, rcount ribdInt.Int) (routes *ribdInt.RoutesGetInfo, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.GetBulkRoutesForProtocol(srcProtocol, fromIndex, rcount)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 
@@ -273,7 +292,11 @@ func (m RIBDServicesHandler) GetBulkRoutesForProtocol(srcProtocol string, fromIn
    Api to track a route's reachability status
 */
 func (m RIBDServicesHandler) TrackReachabilityStatus(ipAddr string, protocol string, op string) (err error) {
-	m.server.TrackReachabilityCh <- server.TrackReachabilityInfo{ipAddr, protocol, op}
+	return m.TrackVrfReachabilityStatus(server.DefaultVrf, ipAddr, protocol, op)
+}
+
+func (m RIBDServicesHandler) TrackVrfReachabilityStatus(vrf string, ipAddr string, protocol string, op string) (err error) {
+	m.server.TrackReachabilityCh <- server.TrackReachabilityInfo{vrf, ipAddr, protocol, op}
 	return nil
 }
 
@@ -385,21 +408,29 @@ func (m RIBDServicesHandler) GetBulkIPv6RouteState(fromIndex ribd.Int, rcount ri
 	return routes, err
 }
 func (m RIBDServicesHandler) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count ribd.Int) (stats *ribd.RouteStatsPerProtocolStateGetInfo, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.GetBulkRouteStatsPerProtocolState(fromIndex, count)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 func (m RIBDServicesHandler) GetRouteStatsPerProtocolState(Protocol string) (stats *ribd.RouteStatsPerProtocolState, err error) {
 	stats = ribd.NewRouteStatsPerProtocolState()
+	m.server.RIB.RLock()
 	stats, err = m.server.GetRouteStatsPerProtocolState(Protocol)
+	m.server.RIB.RUnlock()
 	return stats, err
 }
 func (m RIBDServicesHandler) GetBulkRouteStatsPerInterfaceState(fromIndex ribd.Int, count ribd.Int) (stats *ribd.RouteStatsPerInterfaceStateGetInfo, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.GetBulkRouteStatsPerInterfaceState(fromIndex, count)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 func (m RIBDServicesHandler) GetRouteStatsPerInterfaceState(Intfref string) (stats *ribd.RouteStatsPerInterfaceState, err error) {
 	stats = ribd.NewRouteStatsPerInterfaceState()
+	m.server.RIB.RLock()
 	stats, err = m.server.GetRouteStatsPerInterfaceState(Intfref)
+	m.server.RIB.RUnlock()
 	return stats, err
 }
 
@@ -412,7 +443,9 @@ func (m RIBDServicesHandler) GetBulkRouteStatState(fromIndex ribd.Int, count rib
 	var ret_stats ribd.RouteStatStateGetInfo
 	stats = &ret_stats
 	tempstats[0] = &ribd.RouteStatState{}
-	tempstats[0].PerProtocolRouteCountList = m.server.GetPerProtocolRouteCountList()
+	m.server.RIB.RLock()
+	tempstats[0].PerProtocolRouteCountList = m.server.GetPerProtocolRouteCountList(server.DefaultVrf)
+	m.server.RIB.RUnlock()
 	for _, v := range tempstats[0].PerProtocolRouteCountList {
 		tempstats[0].TotalRouteCount = tempstats[0].TotalRouteCount + v.RouteCount
 		tempstats[0].ECMPRouteCount = tempstats[0].ECMPRouteCount + v.EcmpCount
@@ -432,7 +465,9 @@ func (m RIBDServicesHandler) GetRouteStatState(vrf string) (*ribd.RouteStatState
 	stat := ribd.NewRouteStatState()
 	v4Count, _ := m.GetTotalv4RouteCount()
 	v6Count, _ := m.GetTotalv6RouteCount()
-	stat.PerProtocolRouteCountList = m.server.GetPerProtocolRouteCountList()
+	m.server.RIB.RLock()
+	stat.PerProtocolRouteCountList = m.server.GetPerProtocolRouteCountList(vrf)
+	m.server.RIB.RUnlock()
 	for _, v := range stat.PerProtocolRouteCountList {
 		stat.TotalRouteCount = stat.TotalRouteCount + v.RouteCount
 		stat.ECMPRouteCount = stat.ECMPRouteCount + v.EcmpCount
@@ -454,40 +489,381 @@ func (m RIBDServicesHandler) GetBulkRIBEventState(fromIndex ribd.Int, rcount rib
 }
 
 func (m RIBDServicesHandler) GetBulkRouteDistanceState(fromIndex ribd.Int, rcount ribd.Int) (routeDistanceStates *ribd.RouteDistanceStateGetInfo, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.GetBulkRouteDistanceState(fromIndex, rcount)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 func (m RIBDServicesHandler) GetRouteDistanceState(protocol string) (*ribd.RouteDistanceState, error) {
 	logger.Info("Get state for RouteDistanceState")
 	state := ribd.NewRouteDistanceState()
+	m.server.RIB.RLock()
 	state, err := m.server.GetRouteDistanceState(protocol)
+	m.server.RIB.RUnlock()
 	return state, err
 }
 func (m RIBDServicesHandler) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.Getv4Route(destNetIp)
+	m.server.RIB.RUnlock()
+	return ret, err
+}
+func (m RIBDServicesHandler) GetVrfv4Route(vrf string, destNetIp string) (route *ribdInt.IPv4RouteState, err error) {
+	m.server.RIB.RLock()
+	ret, err := m.server.GetVrfv4Route(vrf, destNetIp)
+	m.server.RIB.RUnlock()
+	return ret, err
+}
+
+/*
+   Explains why the routes of the destination of an address or prefix were or were not selected
+*/
+func (m RIBDServicesHandler) GetRouteSelectionExplain(vrf string, destNet string) (explain *ribdInt.RouteSelectionExplainState, err error) {
+	m.server.RIB.RLock()
+	ret, err := m.server.GetRouteSelectionExplain(vrf, destNet)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 func (m RIBDServicesHandler) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState, err error) {
+	m.server.RIB.RLock()
 	ret, err := m.server.Getv6Route(destNetIp)
+	m.server.RIB.RUnlock()
 	return ret, err
 }
 func (m RIBDServicesHandler) GetRouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	m.server.RIB.RLock()
 	nh, err := m.server.GetRouteReachabilityInfo(destNet, ifIndex)
+	m.server.RIB.RUnlock()
+	return nh, err
+}
+func (m RIBDServicesHandler) GetVrfRouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	m.server.RIB.RLock()
+	nh, err := m.server.GetVrfRouteReachabilityInfo(vrf, destNet, ifIndex)
+	m.server.RIB.RUnlock()
 	return nh, err
 }
 func (m RIBDServicesHandler) GetTotalv4RouteCount() (number ribdInt.Int, err error) {
+	m.server.RIB.RLock()
 	num, err := m.server.GetTotalv4RouteCount()
+	m.server.RIB.RUnlock()
 	return ribdInt.Int(num), err
 }
 func (m RIBDServicesHandler) GetTotalv6RouteCount() (number ribdInt.Int, err error) {
+	m.server.RIB.RLock()
 	num, err := m.server.GetTotalv6RouteCount()
+	m.server.RIB.RUnlock()
 	return ribdInt.Int(num), err
 }
 func (m RIBDServicesHandler) Getv4RouteCreatedTime(number ribdInt.Int) (time string, err error) {
+	m.server.RIB.RLock()
 	time, err = m.server.Getv4RouteCreatedTime(int(number))
+	m.server.RIB.RUnlock()
 	return time, err
 }
 func (m RIBDServicesHandler) Getv6RouteCreatedTime(number ribdInt.Int) (time string, err error) {
+	m.server.RIB.RLock()
 	time, err = m.server.Getv6RouteCreatedTime(int(number))
+	m.server.RIB.RUnlock()
 	return time, err
 }
+
+/*
+   RPF routes are kept apart from the unicast routes and are not installed in the FIB
+*/
+func (m RIBDServicesHandler) OnewayCreateRPFRoute(cfg *ribdInt.RPFRoute) (err error) {
+	logger.Info("OnewayCreateRPFRoute - Received create RPF route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask)
+	m.server.RIB.RLock()
+	err = m.server.RPFRouteConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.AddRPF,
+	}
+	return nil
+}
+func (m RIBDServicesHandler) OnewayDeleteRPFRoute(cfg *ribdInt.RPFRoute) (err error) {
+	logger.Info("OnewayDeleteRPFRoute - Received delete RPF route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask)
+	m.server.RIB.RLock()
+	err = m.server.RPFRouteConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.DelRPF,
+	}
+	return nil
+}
+/*
+   Labeled unicast routes are kept apart from the unicast routes, a labeled path
+   never replaces or withdraws the unicast route of the same prefix
+*/
+func (m RIBDServicesHandler) OnewayCreateLabeledRoute(cfg *ribdInt.LabeledRoute) (err error) {
+	logger.Info("OnewayCreateLabeledRoute - Received create labeled route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask, " in label ", cfg.InLabel)
+	m.server.RIB.RLock()
+	err = m.server.LabeledRouteConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.AddLabeled,
+	}
+	return nil
+}
+func (m RIBDServicesHandler) OnewayDeleteLabeledRoute(cfg *ribdInt.LabeledRoute) (err error) {
+	logger.Info("OnewayDeleteLabeledRoute - Received delete labeled route request for ip", cfg.DestinationNw, " mask ", cfg.NetworkMask)
+	m.server.RIB.RLock()
+	err = m.server.LabeledRouteConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.DelLabeled,
+	}
+	return nil
+}
+func (m RIBDServicesHandler) OnewayRoutesEndOfRIB(protocol string) (err error) {
+	logger.Info("OnewayRoutesEndOfRIB - Received end of RIB from protocol ", protocol)
+	if _, ok := server.RouteProtocolTypeMapDB[protocol]; !ok {
+		logger.Err("Invalid protocol ", protocol, " in end of RIB")
+		return errors.New("Invalid protocol")
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: protocol,
+		Op:               defs.SweepStaleRoutes,
+	}
+	return nil
+}
+func (m RIBDServicesHandler) OnewayHoldStaleRoutes(protocol string, holdTime ribdInt.Int) (err error) {
+	logger.Info("OnewayHoldStaleRoutes - Received stale route hold time ", holdTime, " from protocol ", protocol)
+	if _, ok := server.RouteProtocolTypeMapDB[protocol]; !ok {
+		logger.Err("Invalid protocol ", protocol, " in stale route hold")
+		return errors.New("Invalid protocol")
+	}
+	if holdTime <= 0 {
+		logger.Err("Invalid stale route hold time ", holdTime)
+		return errors.New("Invalid hold time")
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: protocol,
+		AdditionalParams: time.Duration(holdTime) * time.Second,
+		Op:               defs.HoldStaleRoutes,
+	}
+	return nil
+}
+func (m RIBDServicesHandler) GetRPFRouteReachabilityInfo(srcIp string) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	m.server.RIB.RLock()
+	nh, err := m.server.GetRPFRouteReachabilityInfo(srcIp)
+	m.server.RIB.RUnlock()
+	return nh, err
+}
+func (m RIBDServicesHandler) GetBulkRPFRouteState(fromIndex ribdInt.Int, rcount ribdInt.Int) (routes *ribdInt.RPFRouteStateGetInfo, err error) {
+	m.server.RIB.RLock()
+	ret, err := m.server.GetBulkRPFRouteState(fromIndex, rcount)
+	m.server.RIB.RUnlock()
+	return ret, err
+}
+func (m RIBDServicesHandler) GetBulkLabeledRouteState(fromIndex ribdInt.Int, rcount ribdInt.Int) (routes *ribdInt.LabeledRouteStateGetInfo, err error) {
+	m.server.RIB.RLock()
+	ret, err := m.server.GetBulkLabeledRouteState(fromIndex, rcount)
+	m.server.RIB.RUnlock()
+	return ret, err
+}
+
+/*
+   Each VRF has its own RIB, the routes of an interface go to the RIB of the VRF
+   the interface is bound to
+*/
+func (m RIBDServicesHandler) CreateVrf(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("CreateVrf - Received create request for vrf ", cfg.VrfName, " interfaces ", cfg.IntfList)
+	m.server.RIB.RLock()
+	err = m.server.VrfConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.AddVrf,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) DeleteVrf(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("DeleteVrf - Received delete request for vrf ", cfg.VrfName)
+	m.server.RIB.RLock()
+	err = m.server.VrfConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.DelVrf,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) UpdateVrf(origconfig *ribdInt.Vrf, newconfig *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("UpdateVrf - Received update request for vrf ", origconfig.VrfName, " interfaces ", newconfig.IntfList)
+	if origconfig.VrfName != newconfig.VrfName {
+		logger.Err("Cannot change the name of vrf ", origconfig.VrfName)
+		return false, errors.New("Cannot change the VRF name")
+	}
+	m.server.RIB.RLock()
+	err = m.server.VrfConfigValidationCheck(newconfig, "update")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: origconfig,
+		NewConfigObject:  newconfig,
+		Op:               defs.UpdateVrf,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) GetVrfState(vrfName string) (*ribdInt.VrfState, error) {
+	m.server.RIB.RLock()
+	defer m.server.RIB.RUnlock()
+	return m.server.GetVrfState(vrfName)
+}
+
+/*
+   Depth of the queue of route updates to the FIB and the time the updates
+   take to reach the FIB
+*/
+func (m RIBDServicesHandler) GetFIBQueueState() (*ribdInt.FIBQueueState, error) {
+	return m.server.GetFIBQueueState()
+}
+
+/*
+   Audits the routes programmed in the FIB against the selected routes, the
+   differences are repaired when repair is set
+*/
+func (m RIBDServicesHandler) StartFIBAudit(repair bool) (val bool, err error) {
+	logger.Info("StartFIBAudit - Received FIB audit request, repair ", repair)
+	if !m.server.AcceptConfig {
+		return false, errors.New("RIBD not ready to audit the FIB")
+	}
+	m.server.StartFIBAudit(repair)
+	return true, nil
+}
+func (m RIBDServicesHandler) GetFIBAuditState() (*ribdInt.FIBAuditState, error) {
+	return m.server.GetFIBAuditState()
+}
+
+/*
+   Packets received on the interfaces of a PBR policy that match one of its
+   rules are forwarded to the next hop or looked up in the VRF of the rule
+*/
+func (m RIBDServicesHandler) CreatePbrPolicy(cfg *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("CreatePbrPolicy - Received create request for pbr policy ", cfg.Name, " interfaces ", cfg.IntfList)
+	m.server.RIB.RLock()
+	err = m.server.PbrPolicyConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.AddPbrPolicy,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) DeletePbrPolicy(cfg *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("DeletePbrPolicy - Received delete request for pbr policy ", cfg.Name)
+	m.server.RIB.RLock()
+	err = m.server.PbrPolicyConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.DelPbrPolicy,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) UpdatePbrPolicy(origconfig *ribdInt.PbrPolicy, newconfig *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("UpdatePbrPolicy - Received update request for pbr policy ", origconfig.Name, " interfaces ", newconfig.IntfList)
+	if origconfig.Name != newconfig.Name {
+		logger.Err("Cannot change the name of pbr policy ", origconfig.Name)
+		return false, errors.New("Cannot change the PBR policy name")
+	}
+	m.server.RIB.RLock()
+	err = m.server.PbrPolicyConfigValidationCheck(newconfig, "update")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: origconfig,
+		NewConfigObject:  newconfig,
+		Op:               defs.UpdatePbrPolicy,
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) GetPbrPolicyState(name string) (*ribdInt.PbrPolicyState, error) {
+	m.server.RIB.RLock()
+	defer m.server.RIB.RUnlock()
+	return m.server.GetPbrPolicyState(name)
+}
+
+/*
+   Routes of the source VRF accepted by the policy are leaked into the destination VRF
+*/
+func (m RIBDServicesHandler) CreateVrfRouteLeak(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("CreateVrfRouteLeak - Received route leak request from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " policy ", cfg.Policy)
+	m.server.RIB.RLock()
+	err = m.server.VrfRouteLeakConfigValidationCheck(cfg, "add")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.PolicyConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.AddVrfRouteLeak,
+	}
+	err = <-m.server.PolicyConfDone
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+func (m RIBDServicesHandler) DeleteVrfRouteLeak(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("DeleteVrfRouteLeak - Received delete route leak request from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf)
+	m.server.RIB.RLock()
+	err = m.server.VrfRouteLeakConfigValidationCheck(cfg, "del")
+	m.server.RIB.RUnlock()
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.PolicyConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               defs.DelVrfRouteLeak,
+	}
+	err = <-m.server.PolicyConfDone
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
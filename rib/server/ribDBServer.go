@@ -67,7 +67,7 @@ func (m RIBDServer) WriteIPv4RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		//	logger.Info("nextHop ", sel, " weight = ", routeInfoList[sel].weight, " ip ", routeInfoList[sel].nextHopIp, " intref ", routeInfoList[sel].nextHopIfIndex)
 		nextHopInfo[i].NextHopIp = routeInfoList[sel].nextHopIp.String()
 		nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(routeInfoList[sel].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(routeInfoList[sel].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(routeInfoList[sel].nextHopIfIndex))
 		if ok {
 			//	logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
 			nextHopInfo[i].NextHopIntRef = intfEntry.name
@@ -85,6 +85,8 @@ func (m RIBDServer) WriteIPv4RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		}
 		i++
 	}
+	obj.IsStale = isRouteStale(routeInfoList)
+	obj.RouteTag = int32(routeInfoList[0].tag)
 	obj.RouteCreatedTime = entry.routeCreatedTime
 	obj.RouteUpdatedTime = entry.routeUpdatedTime
 	obj.PolicyList = make([]string, 0)
@@ -114,7 +116,7 @@ func (m RIBDServer) WriteIPv4RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		}
 	}
 	obj.NextBestRoute = &ribd.NextBestRouteInfo{}
-	obj.NextBestRoute.Protocol = SelectNextBestRoute(routeList, routeList.selectedRouteProtocol)
+	obj.NextBestRoute.Protocol = RouteServiceHandler.RIB.SelectNextBestRoute(routeList, routeList.selectedRouteProtocol)
 	nextbestrouteInfoList := routeList.routeInfoProtocolMap[obj.NextBestRoute.Protocol]
 	//logger.Info("len of routeInfoList - ", len(routeInfoList), "selected route protocol = ", routeList.selectedRouteProtocol, " route Protocol: ", entry.protocol, " route nwAddr: ", entry.networkAddr)
 	nextBestRouteNextHopInfo := make([]ribd.NextHopInfo, len(nextbestrouteInfoList))
@@ -123,7 +125,7 @@ func (m RIBDServer) WriteIPv4RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		//logger.Info("nextHop ", sel, " weight = ", routeInfoList[sel].weight, " ip ", routeInfoList[sel].nextHopIp, " intref ", routeInfoList[sel].nextHopIfIndex)
 		nextBestRouteNextHopInfo[i1].NextHopIp = nextbestrouteInfoList[sel1].nextHopIp.String()
 		nextBestRouteNextHopInfo[i1].NextHopIntRef = strconv.Itoa(int(nextbestrouteInfoList[sel1].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(nextbestrouteInfoList[sel1].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(nextbestrouteInfoList[sel1].nextHopIfIndex))
 		if ok {
 			//logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
 			nextBestRouteNextHopInfo[i1].NextHopIntRef = intfEntry.name
@@ -163,7 +165,7 @@ func (m RIBDServer) WriteIPv6RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 			nextHopInfo[i].NextHopIp = "::"
 		}
 		nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(routeInfoList[sel].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(routeInfoList[sel].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(routeInfoList[sel].nextHopIfIndex))
 		if ok {
 			nextHopInfo[i].NextHopIntRef = intfEntry.name
 		}
@@ -180,6 +182,8 @@ func (m RIBDServer) WriteIPv6RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		}
 		i++
 	}
+	obj.IsStale = isRouteStale(routeInfoList)
+	obj.RouteTag = int32(routeInfoList[0].tag)
 	obj.RouteCreatedTime = entry.routeCreatedTime
 	obj.RouteUpdatedTime = entry.routeUpdatedTime
 	obj.PolicyList = make([]string, 0)
@@ -209,7 +213,7 @@ func (m RIBDServer) WriteIPv6RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		}
 	}
 	obj.NextBestRoute = &ribd.NextBestRouteInfo{}
-	obj.NextBestRoute.Protocol = SelectNextBestRoute(routeList, routeList.selectedRouteProtocol)
+	obj.NextBestRoute.Protocol = RouteServiceHandler.RIB.SelectNextBestRoute(routeList, routeList.selectedRouteProtocol)
 	nextbestrouteInfoList := routeList.routeInfoProtocolMap[obj.NextBestRoute.Protocol]
 	nextBestRouteNextHopInfo := make([]ribd.NextHopInfo, len(nextbestrouteInfoList))
 	i1 := 0
@@ -217,7 +221,7 @@ func (m RIBDServer) WriteIPv6RouteStateEntryToDB(dbInfo RouteDBInfo) error {
 		//logger.Info("WriteIPv6RouteStateEntryToDB:nextHop ", sel, " weight = ", routeInfoList[sel].weight, " ip ", routeInfoList[sel].nextHopIp, " intref ", routeInfoList[sel].nextHopIfIndex)
 		nextBestRouteNextHopInfo[i1].NextHopIp = nextbestrouteInfoList[sel1].nextHopIp.String()
 		nextBestRouteNextHopInfo[i1].NextHopIntRef = strconv.Itoa(int(nextbestrouteInfoList[sel1].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(nextbestrouteInfoList[sel1].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(nextbestrouteInfoList[sel1].nextHopIfIndex))
 		if ok {
 			//logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
 			nextBestRouteNextHopInfo[i1].NextHopIntRef = intfEntry.name
@@ -431,6 +435,10 @@ func (ribdServiceHandler *RIBDServer) StartDBServer() {
 	for {
 		select {
 		case info := <-ribdServiceHandler.DBRouteCh:
+			if dbInfo, ok := info.OrigConfigObject.(RouteDBInfo); ok && getVrfName(dbInfo.entry.vrf) != DefaultVrf {
+				//route state objects are keyed by the destination network, routes of the other VRFs are only kept in the RIB
+				continue
+			}
 			if info.Op == defs.Add {
 				dbInfo := info.OrigConfigObject.(RouteDBInfo)
 				logger.Debug("DBServer add for route:", dbInfo.entry)
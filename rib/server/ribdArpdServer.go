@@ -36,7 +36,7 @@ func (ribdServiceHandler *RIBDServer) arpdResolveRoute(routeInfoRecord RouteInfo
 			return
 		}
 	*/
-	if routeInfoRecord.ipType == defs.IPv6 {
+	if routeInfoRecord.ipType == defs.IPv6 || ribdServiceHandler.ArpdClntPlugin == nil {
 		return
 	}
 	ribdServiceHandler.ArpdClntPlugin.ResolveArpIPv4(routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, int32(routeInfoRecord.nextHopIfIndex))
@@ -47,7 +47,7 @@ func (ribdServiceHandler *RIBDServer) arpdRemoveRoute(routeInfoRecord RouteInfoR
 			return
 		}
 	*/
-	if routeInfoRecord.ipType == defs.IPv6 {
+	if routeInfoRecord.ipType == defs.IPv6 || ribdServiceHandler.ArpdClntPlugin == nil {
 		return
 	}
 	ribdServiceHandler.ArpdClntPlugin.DeleteResolveArpIPv4(routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
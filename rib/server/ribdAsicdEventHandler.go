@@ -109,44 +109,20 @@ func (ribdServiceHandler *RIBDServer) processAsicdNotification(msg clntIntfs.Not
 
 func (ribdServiceHandler *RIBDServer) ProcessLogicalIntfCreateEvent(logicalIntfNotifyMsg asicdClntDefs.LogicalIntfNotifyMsg) {
 	ifId := logicalIntfNotifyMsg.IfIndex
-	if IntfIdNameMap == nil {
-		IntfIdNameMap = make(map[int32]IntfEntry)
-	}
-	intfEntry := IntfEntry{name: logicalIntfNotifyMsg.LogicalIntfName}
 	ribdServiceHandler.Logger.Info("ProcessLogicalIntfCreateEvent:Updating IntfIdMap at index ", ifId, " with name ", logicalIntfNotifyMsg.LogicalIntfName)
-	IntfIdNameMap[int32(ifId)] = intfEntry
-	if IfNameToIfIndex == nil {
-		IfNameToIfIndex = make(map[string]int32)
-	}
-	IfNameToIfIndex[logicalIntfNotifyMsg.LogicalIntfName] = ifId
+	ribdServiceHandler.RIB.SetIntfEntry(int32(ifId), logicalIntfNotifyMsg.LogicalIntfName)
 
 }
 func (ribdServiceHandler *RIBDServer) ProcessLagIntfCreateEvent(lagIntfNotifyMsg asicdClntDefs.LagNotifyMsg) {
 	ifId := lagIntfNotifyMsg.IfIndex
-	if IntfIdNameMap == nil {
-		IntfIdNameMap = make(map[int32]IntfEntry)
-	}
-	intfEntry := IntfEntry{name: lagIntfNotifyMsg.LagName}
 	ribdServiceHandler.Logger.Info("ProcessLagIntfCreateEvent:Updating IntfIdMap at index ", ifId, " with name ", lagIntfNotifyMsg.LagName)
-	IntfIdNameMap[int32(ifId)] = intfEntry
-	if IfNameToIfIndex == nil {
-		IfNameToIfIndex = make(map[string]int32)
-	}
-	IfNameToIfIndex[lagIntfNotifyMsg.LagName] = ifId
+	ribdServiceHandler.RIB.SetIntfEntry(int32(ifId), lagIntfNotifyMsg.LagName)
 
 }
 func (ribdServiceHandler *RIBDServer) ProcessVlanCreateEvent(vlanNotifyMsg asicdClntDefs.VlanNotifyMsg) {
 	ifId := vlanNotifyMsg.VlanIfIndex //asicdClntIntfs.GetIfIndexFromIntfIdAndIntfType(int(vlanNotifyMsg.VlanId), commonDefs.IfTypeVlan)
 	ribdServiceHandler.Logger.Info("vlanId ", vlanNotifyMsg.VlanId, " ifId:", ifId)
-	if IntfIdNameMap == nil {
-		IntfIdNameMap = make(map[int32]IntfEntry)
-	}
-	intfEntry := IntfEntry{name: vlanNotifyMsg.VlanName}
-	IntfIdNameMap[int32(ifId)] = intfEntry
-	if IfNameToIfIndex == nil {
-		IfNameToIfIndex = make(map[string]int32)
-	}
-	IfNameToIfIndex[vlanNotifyMsg.VlanName] = ifId
+	ribdServiceHandler.RIB.SetIntfEntry(int32(ifId), vlanNotifyMsg.VlanName)
 }
 func (ribdServiceHandler *RIBDServer) ProcessIPv4IntfCreateEvent(msg asicdClntDefs.IPv4IntfNotifyMsg) {
 
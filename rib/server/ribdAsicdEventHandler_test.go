@@ -115,31 +115,31 @@ func TestInitRtEventHdlrTestServer(t *testing.T) {
 func TestProcessLogicalIntfCreateEvent(t *testing.T) {
 	fmt.Println("**** Test LogicalIntfCreate event ****")
 	fmt.Println("IntfIdNameMap before:")
-	fmt.Println(IntfIdNameMap)
+	fmt.Println(server.RIB.intfIdNameMap)
 	fmt.Println("IfNameToIfIndex before:")
-	fmt.Println(IfNameToIfIndex)
+	fmt.Println(server.RIB.ifNameToIfIndex)
 	for _, lo := range logicalIntfList {
 		server.ProcessLogicalIntfCreateEvent(lo)
 	}
 	fmt.Println("IntfIdNameMap after:")
-	fmt.Println(IntfIdNameMap)
+	fmt.Println(server.RIB.intfIdNameMap)
 	fmt.Println("IfNameToIfIndex after:")
-	fmt.Println(IfNameToIfIndex)
+	fmt.Println(server.RIB.ifNameToIfIndex)
 	fmt.Println("***************************************")
 }
 func TestVlanCreateEvent(t *testing.T) {
 	fmt.Println("**** TestVlanCreateEvent event ****")
 	fmt.Println("IntfIdNameMap before:")
-	fmt.Println(IntfIdNameMap)
+	fmt.Println(server.RIB.intfIdNameMap)
 	fmt.Println("IfNameToIfIndex before:")
-	fmt.Println(IfNameToIfIndex)
+	fmt.Println(server.RIB.ifNameToIfIndex)
 	for _, vlan := range vlanList {
 		server.ProcessVlanCreateEvent(vlan)
 	}
 	fmt.Println("IntfIdNameMap after:")
-	fmt.Println(IntfIdNameMap)
+	fmt.Println(server.RIB.intfIdNameMap)
 	fmt.Println("IfNameToIfIndex after:")
-	fmt.Println(IfNameToIfIndex)
+	fmt.Println(server.RIB.ifNameToIfIndex)
 	fmt.Println("***************************************")
 }
 func TestIPv4IntfCreateEvent(t *testing.T) {
@@ -28,146 +28,468 @@ import (
 	//"fmt"
 	defs "l3/rib/ribdCommonDefs"
 	"models/objects"
+	"net"
+	"strings"
+	"time"
 	"utils/clntUtils/clntDefs/asicdClntDefs"
 )
 
 var asicdBulkCount = 30000
-var asicdv4RouteCount = 0
-var asicdv4Routes []*asicdClntDefs.IPv4Route
-var asicdv4Route []asicdClntDefs.IPv4Route
-var asicdv6Route []asicdClntDefs.IPv6Route
-
-type Linklocaldata struct{}
-
-var V6linklocalIPMap = make(map[string]Linklocaldata)
-
-func (server *RIBDServer) addAsicdRouteBulk(routeInfoRecord RouteInfoRecord, bulkEnd bool) {
-	logger.Info("addAsicdRouteBulk, bulkEnd:", bulkEnd)
-	/*	if asicdclnt.IsConnected == false {
-		return
-	}*/
-	ipType := ""
-	if routeInfoRecord.ipType == defs.IPv4 {
-		ipType = "IPv4"
-	} else if routeInfoRecord.ipType == defs.IPv6 {
-		ipType = "IPv6"
-	}
-	logger.Info("addAsicdRoute, weight = ", routeInfoRecord.weight+1, " ipType:", ipType)
-	if routeInfoRecord.ipType == defs.IPv4 {
-		if asicdv4RouteCount == 0 {
-			asicdv4Routes = make([]*asicdClntDefs.IPv4Route, 0)
-		}
-		asicdv4Routes = append(asicdv4Routes,
-			&asicdClntDefs.IPv4Route{
-				routeInfoRecord.destNetIp.String(),
-				routeInfoRecord.networkMask.String(),
-				[]*asicdClntDefs.IPv4NextHop{
-					&asicdClntDefs.IPv4NextHop{
-						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
-						Weight:    int32(routeInfoRecord.weight + 1),
-					},
-				},
-			})
-		asicdv4RouteCount++
-		if asicdv4RouteCount == asicdBulkCount || bulkEnd {
-			server.AsicdPlugin.OnewayCreateIPv4Route(asicdv4Routes)
-			asicdv4Routes = nil
-			asicdv4RouteCount = 0
-		}
-	} else if routeInfoRecord.ipType == defs.IPv6 {
-	}
-}
-func (server *RIBDServer) addAsicdRoute(routeInfoRecord RouteInfoRecord) {
-	/*	if asicdclnt.IsConnected == false {
-		return
-	}*/
-	if routeInfoRecord.ipType == defs.IPv4 {
-		server.AsicdPlugin.OnewayCreateIPv4Route([]*asicdClntDefs.IPv4Route{
-			&asicdClntDefs.IPv4Route{
-				routeInfoRecord.destNetIp.String(),
-				routeInfoRecord.networkMask.String(),
-				[]*asicdClntDefs.IPv4NextHop{
-					&asicdClntDefs.IPv4NextHop{
-						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
-						Weight:    int32(routeInfoRecord.weight + 1),
-					},
-				},
-			},
+
+/*
+   asicd versions with next hop group objects implement this interface. The
+   group is an ECMP object in the hardware, identified by the ribd group id,
+   and the routes point at it, so a next hop group update is a single update
+   of the ECMP object.
+*/
+type AsicdNextHopGroupClntIntf interface {
+	OnewayCreateNextHopGroup(groupId int32, nextHopIps []string, weights []int32)
+	OnewayUpdateNextHopGroup(groupId int32, nextHopIps []string, weights []int32)
+	OnewayDeleteNextHopGroup(groupId int32)
+	OnewaySetRouteNextHopGroup(destinationNw string, networkMask string, groupId int32)
+	OnewayDeleteRouteNextHopGroup(destinationNw string, networkMask string)
+}
+
+/*
+   asicd versions with PBR implement this interface. A rule is identified by
+   its policy, sequence number and interface. Unset prefixes are empty, unset
+   protocol and ports are 0 and an unset DSCP is -1. The packets a rule
+   matches are forwarded to its next hop, or looked up in its VRF when it has
+   no next hop. With other asicd versions, PBR policies are rejected.
+*/
+type AsicdPbrClntIntf interface {
+	OnewayCreatePbrRule(policy string, seq int32, ifIndex int32, srcPrefix string, dstPrefix string, protocol int32, srcPort int32, dstPort int32, dscp int32, nextHopIp string, vrf string)
+	OnewayDeletePbrRule(policy string, seq int32, ifIndex int32)
+}
+
+/*
+   With asicd versions without next hop group objects, asicd builds its ECMP
+   groups from the next hops of the routes and a next hop group update is
+   sent to asicd as a single bulk update of the destinations using the group.
+   The asicd route objects do not carry a VRF, so only the routes of the
+   default VRF are installed in the hardware.
+   Route updates are batched: consecutive creates or deletes of the same
+   address family are sent in one call when the batch is flushed, when the
+   kind of update changes or when asicdBulkCount routes are pending.
+*/
+type AsicdFIBPlugin struct {
+	server      *RIBDServer
+	batchIpType defs.IPType
+	batchDelete bool
+	v4Routes    []*asicdClntDefs.IPv4Route
+	v6Routes    []*asicdClntDefs.IPv6Route
+	//groups programmed in asicd as next hop group objects
+	groups map[int]bool
+}
+
+func isV6LinkLocalRoute(routeInfoRecord RouteInfoRecord) bool {
+	return routeInfoRecord.ipType == defs.IPv6 && routeInfoRecord.destNetIp.IsLinkLocalUnicast()
+}
+
+func isAsicdVrfRoute(routeInfoRecord RouteInfoRecord) bool {
+	return getVrfName(routeInfoRecord.vrf) == DefaultVrf
+}
+
+func buildAsicdIPv4Route(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) *asicdClntDefs.IPv4Route {
+	nextHops := make([]*asicdClntDefs.IPv4NextHop, 0, len(members))
+	for _, member := range members {
+		nextHops = append(nextHops, &asicdClntDefs.IPv4NextHop{
+			NextHopIp: member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+			Weight:    int32(member.routeInfoRecord.weight + 1),
 		})
-	} else if routeInfoRecord.ipType == defs.IPv6 {
-		add := true
-		if routeInfoRecord.destNetIp.IsLinkLocalUnicast() {
-			if len(V6linklocalIPMap) > 0 {
-				add = false
-			}
-			empty := Linklocaldata{}
-			V6linklocalIPMap[routeInfoRecord.destNetIp.String()] = empty
-		}
-		if add {
-			server.AsicdPlugin.OnewayCreateIPv6Route([]*asicdClntDefs.IPv6Route{
-				&asicdClntDefs.IPv6Route{
-					routeInfoRecord.destNetIp.String(),
-					routeInfoRecord.networkMask.String(),
-					[]*asicdClntDefs.IPv6NextHop{
-						&asicdClntDefs.IPv6NextHop{
-							NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
-							Weight:    int32(routeInfoRecord.weight + 1),
-						},
-					},
-				},
-			})
-		}
-	}
-}
-func (server *RIBDServer) delAsicdRoute(routeInfoRecord RouteInfoRecord) {
-	/*if asicdclnt.IsConnected == false {
-		return
-	}*/
-	logger.Info("delAsicdRoute with ipType ", routeInfoRecord.ipType)
-	if routeInfoRecord.ipType == defs.IPv4 {
-		server.AsicdPlugin.OnewayDeleteIPv4Route([]*asicdClntDefs.IPv4Route{
-			&asicdClntDefs.IPv4Route{
-				routeInfoRecord.destNetIp.String(),
-				routeInfoRecord.networkMask.String(),
-				[]*asicdClntDefs.IPv4NextHop{
-					&asicdClntDefs.IPv4NextHop{
-						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
-						Weight:    int32(routeInfoRecord.weight + 1),
-						//NextHopIfType: int32(routeInfoRecord.resolvedNextHopIpIntf.NextHopIfType),
-					},
-				},
-			},
+	}
+	return &asicdClntDefs.IPv4Route{
+		routeInfoRecord.destNetIp.String(),
+		routeInfoRecord.networkMask.String(),
+		nextHops,
+	}
+}
+
+func buildAsicdIPv6Route(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) *asicdClntDefs.IPv6Route {
+	nextHops := make([]*asicdClntDefs.IPv6NextHop, 0, len(members))
+	for _, member := range members {
+		nextHops = append(nextHops, &asicdClntDefs.IPv6NextHop{
+			NextHopIp: member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+			Weight:    int32(member.routeInfoRecord.weight + 1),
 		})
-	} else if routeInfoRecord.ipType == defs.IPv6 {
-		del := true
-		//fmt.Println("RIBD delAsicdRoute: delete asicd address:", routeInfoRecord.destNetIp.String())
-		if routeInfoRecord.destNetIp.IsLinkLocalUnicast() {
-			//fmt.Println("RIBD delAsicdRoute:link local unicast ipv6:", routeInfoRecord.destNetIp.String())
-			delete(V6linklocalIPMap, routeInfoRecord.destNetIp.String())
-			//fmt.Println("RIBD delAsicdRoute: deleted map entry for ", routeInfoRecord.destNetIp.String(), " len of v6linklocalmap:", len(V6linklocalIPMap))
-			if len(V6linklocalIPMap) > 0 {
-				//fmt.Println("RIBD delAsicdRoute:link local routes still configured, so do not delete this route: ", routeInfoRecord.destNetIp.String(), "from ASICd")
-				del = false
+	}
+	return &asicdClntDefs.IPv6Route{
+		routeInfoRecord.destNetIp.String(),
+		routeInfoRecord.networkMask.String(),
+		nextHops,
+	}
+}
+
+func (plugin *AsicdFIBPlugin) nextHopGroupClnt() (AsicdNextHopGroupClntIntf, bool) {
+	clnt, ok := plugin.server.AsicdPlugin.(AsicdNextHopGroupClntIntf)
+	if ok && plugin.groups == nil {
+		plugin.groups = make(map[int]bool)
+	}
+	return clnt, ok
+}
+
+func buildAsicdNextHopGroup(members []NextHopGroupMember) (nextHopIps []string, weights []int32) {
+	nextHopIps = make([]string, 0, len(members))
+	weights = make([]int32, 0, len(members))
+	for _, member := range members {
+		nextHopIps = append(nextHopIps, member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
+		weights = append(weights, int32(member.routeInfoRecord.weight+1))
+	}
+	return nextHopIps, weights
+}
+
+/*
+   Routes of the group that asicd installs
+*/
+func getAsicdGroupRoutes(group *NextHopGroup) []RouteInfoRecord {
+	routes := make([]RouteInfoRecord, 0, len(group.routes))
+	for _, routeInfoRecord := range group.routes {
+		if isAsicdVrfRoute(routeInfoRecord) && !isV6LinkLocalRoute(routeInfoRecord) {
+			routes = append(routes, routeInfoRecord)
+		}
+	}
+	return routes
+}
+
+func (plugin *AsicdFIBPlugin) batchSize() int {
+	return len(plugin.v4Routes) + len(plugin.v6Routes)
+}
+
+/*
+   Starts a new batch when the pending routes are not of the same kind, so
+   that asicd gets the updates in the order they were made
+*/
+func (plugin *AsicdFIBPlugin) startBatch(ipType defs.IPType, isDelete bool) {
+	if plugin.batchSize() > 0 && (plugin.batchIpType != ipType || plugin.batchDelete != isDelete) {
+		plugin.Flush()
+	}
+	plugin.batchIpType = ipType
+	plugin.batchDelete = isDelete
+}
+
+func (plugin *AsicdFIBPlugin) queueRoutes(ipType defs.IPType, isDelete bool, routes []RouteInfoRecord, members []NextHopGroupMember) {
+	if len(routes) == 0 || len(members) == 0 {
+		return
+	}
+	plugin.startBatch(ipType, isDelete)
+	for _, routeInfoRecord := range routes {
+		if ipType == defs.IPv4 {
+			plugin.v4Routes = append(plugin.v4Routes, buildAsicdIPv4Route(routeInfoRecord, members))
+		} else if ipType == defs.IPv6 {
+			plugin.v6Routes = append(plugin.v6Routes, buildAsicdIPv6Route(routeInfoRecord, members))
+		}
+		if plugin.batchSize() >= asicdBulkCount {
+			plugin.Flush()
+		}
+	}
+}
+
+func (plugin *AsicdFIBPlugin) createRoutes(ipType defs.IPType, routes []RouteInfoRecord, members []NextHopGroupMember) {
+	plugin.queueRoutes(ipType, false, routes, members)
+}
+
+func (plugin *AsicdFIBPlugin) deleteRoutes(ipType defs.IPType, routes []RouteInfoRecord, members []NextHopGroupMember) {
+	plugin.queueRoutes(ipType, true, routes, members)
+}
+
+/*
+   Sends the pending routes to asicd
+*/
+func (plugin *AsicdFIBPlugin) Flush() {
+	if plugin.batchSize() == 0 {
+		return
+	}
+	logger.Debug("Flush: ", plugin.batchSize(), " routes, delete:", plugin.batchDelete)
+	if len(plugin.v4Routes) > 0 {
+		if plugin.batchDelete {
+			plugin.server.AsicdPlugin.OnewayDeleteIPv4Route(plugin.v4Routes)
+		} else {
+			plugin.server.AsicdPlugin.OnewayCreateIPv4Route(plugin.v4Routes)
+		}
+	}
+	if len(plugin.v6Routes) > 0 {
+		if plugin.batchDelete {
+			plugin.server.AsicdPlugin.OnewayDeleteIPv6Route(plugin.v6Routes)
+		} else {
+			plugin.server.AsicdPlugin.OnewayCreateIPv6Route(plugin.v6Routes)
+		}
+	}
+	plugin.v4Routes = nil
+	plugin.v6Routes = nil
+}
+
+/*
+   A group without usable next hops is not programmed, its routes are
+   removed so that the traffic falls back to a less specific route
+*/
+func (plugin *AsicdFIBPlugin) CreateNextHopGroup(group *NextHopGroup) {
+	logger.Debug("CreateNextHopGroup: group ", group.groupId, " next hops ", group.key)
+	clnt, ok := plugin.nextHopGroupClnt()
+	if !ok {
+		return
+	}
+	members := group.ActiveMembers()
+	if len(members) == 0 {
+		return
+	}
+	nextHopIps, weights := buildAsicdNextHopGroup(members)
+	clnt.OnewayCreateNextHopGroup(int32(group.groupId), nextHopIps, weights)
+	plugin.groups[group.groupId] = true
+}
+
+func (plugin *AsicdFIBPlugin) DeleteNextHopGroup(group *NextHopGroup) {
+	logger.Debug("DeleteNextHopGroup: group ", group.groupId)
+	clnt, ok := plugin.nextHopGroupClnt()
+	if !ok || !plugin.groups[group.groupId] {
+		return
+	}
+	clnt.OnewayDeleteNextHopGroup(int32(group.groupId))
+	delete(plugin.groups, group.groupId)
+}
+
+/*
+   The routes of the group are only visited when the group loses or gets
+   back all its usable next hops
+*/
+func (plugin *AsicdFIBPlugin) updateNextHopGroupObject(clnt AsicdNextHopGroupClntIntf, group *NextHopGroup) {
+	members := group.ActiveMembers()
+	installed := plugin.groups[group.groupId]
+	logger.Info("UpdateNextHopGroup: group ", group.groupId, " active next hops ", len(members), " installed ", installed)
+	if len(members) > 0 && installed {
+		nextHopIps, weights := buildAsicdNextHopGroup(members)
+		clnt.OnewayUpdateNextHopGroup(int32(group.groupId), nextHopIps, weights)
+		return
+	}
+	if len(members) > 0 {
+		plugin.CreateNextHopGroup(group)
+		for _, routeInfoRecord := range getAsicdGroupRoutes(group) {
+			clnt.OnewaySetRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String(), int32(group.groupId))
+		}
+		return
+	}
+	if installed {
+		for _, routeInfoRecord := range getAsicdGroupRoutes(group) {
+			clnt.OnewayDeleteRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String())
+		}
+		plugin.DeleteNextHopGroup(group)
+	}
+}
+
+func (plugin *AsicdFIBPlugin) UpdateNextHopGroup(group *NextHopGroup, oldMembers []NextHopGroupMember) {
+	if clnt, ok := plugin.nextHopGroupClnt(); ok {
+		plugin.updateNextHopGroupObject(clnt, group)
+		return
+	}
+	members := group.ActiveMembers()
+	routes := getAsicdGroupRoutes(group)
+	logger.Info("UpdateNextHopGroup: group ", group.groupId, " active next hops ", len(members), " routes ", len(routes))
+	plugin.deleteRoutes(group.ipType, routes, diffNextHopGroupMembers(oldMembers, members))
+	plugin.createRoutes(group.ipType, routes, diffNextHopGroupMembers(members, oldMembers))
+}
+
+func (plugin *AsicdFIBPlugin) setRouteNextHopGroupObject(clnt AsicdNextHopGroupClntIntf, routeInfoRecord RouteInfoRecord, oldGroup *NextHopGroup, group *NextHopGroup) {
+	if plugin.groups[group.groupId] {
+		clnt.OnewaySetRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String(), int32(group.groupId))
+	} else if oldGroup != nil && plugin.groups[oldGroup.groupId] {
+		clnt.OnewayDeleteRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String())
+	}
+}
+
+func (plugin *AsicdFIBPlugin) SetRouteNextHopGroup(routeInfoRecord RouteInfoRecord, oldGroup *NextHopGroup, group *NextHopGroup) {
+	if !isAsicdVrfRoute(routeInfoRecord) {
+		logger.Debug("SetRouteNextHopGroup: skip ", routeInfoRecord.networkAddr, " of vrf ", routeInfoRecord.vrf)
+		return
+	}
+	if oldGroup != nil && isV6LinkLocalRoute(routeInfoRecord) {
+		//the link local routes of all the interfaces are installed once
+		return
+	}
+	if clnt, ok := plugin.nextHopGroupClnt(); ok {
+		logger.Info("SetRouteNextHopGroup: ", routeInfoRecord.networkAddr, " group ", group.groupId)
+		plugin.setRouteNextHopGroupObject(clnt, routeInfoRecord, oldGroup, group)
+		return
+	}
+	members := group.ActiveMembers()
+	oldMembers := make([]NextHopGroupMember, 0)
+	if oldGroup != nil {
+		oldMembers = oldGroup.ActiveMembers()
+	}
+	logger.Info("SetRouteNextHopGroup: ", routeInfoRecord.networkAddr, " group ", group.groupId, " ipType:", routeInfoRecord.ipType)
+	routes := []RouteInfoRecord{routeInfoRecord}
+	plugin.deleteRoutes(routeInfoRecord.ipType, routes, diffNextHopGroupMembers(oldMembers, members))
+	plugin.createRoutes(routeInfoRecord.ipType, routes, diffNextHopGroupMembers(members, oldMembers))
+}
+
+func (plugin *AsicdFIBPlugin) DeleteRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup) {
+	if !isAsicdVrfRoute(routeInfoRecord) {
+		return
+	}
+	logger.Info("DeleteRoute: ", routeInfoRecord.networkAddr, " ipType ", routeInfoRecord.ipType)
+	if clnt, ok := plugin.nextHopGroupClnt(); ok {
+		if plugin.groups[group.groupId] {
+			clnt.OnewayDeleteRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String())
+		}
+		return
+	}
+	plugin.deleteRoutes(routeInfoRecord.ipType, []RouteInfoRecord{routeInfoRecord}, group.ActiveMembers())
+}
+
+/*
+   asicd reports the next hops of a route as a comma separated list of
+   addresses
+*/
+func buildAsicdAuditRoute(ipType defs.IPType, destinationNw string, nextHopIps string) (*FIBAuditRoute, bool) {
+	_, dst, err := net.ParseCIDR(destinationNw)
+	if err != nil {
+		logger.Err("buildAsicdAuditRoute: invalid destination ", destinationNw, " in asicd")
+		return nil, false
+	}
+	return &FIBAuditRoute{
+		vrf:      DefaultVrf,
+		ipType:   ipType,
+		dst:      dst,
+		nextHops: sortFIBAuditNextHops(strings.Split(nextHopIps, ",")),
+	}, true
+}
+
+/*
+   Reads the IPv4 and IPv6 routes asicd has programmed in the hardware
+*/
+func (plugin *AsicdFIBPlugin) ReadFIBRoutes() (map[string]*FIBAuditRoute, error) {
+	routes := make(map[string]*FIBAuditRoute)
+	var currMarker int
+	count := 100
+	for {
+		bulkInfo, err := plugin.server.AsicdPlugin.GetBulkIPv4RouteHwState(currMarker, count)
+		if err != nil {
+			return nil, err
+		}
+		for _, routeState := range bulkInfo.IPv4RouteHwStateList {
+			if route, ok := buildAsicdAuditRoute(defs.IPv4, routeState.DestinationNw, routeState.NextHopIps); ok {
+				routes[route.key()] = route
+			}
+		}
+		if bulkInfo.Count == 0 || bulkInfo.More == false {
+			break
+		}
+		currMarker = int(bulkInfo.EndIdx)
+	}
+	currMarker = 0
+	for {
+		bulkInfo, err := plugin.server.AsicdPlugin.GetBulkIPv6RouteHwState(currMarker, count)
+		if err != nil {
+			return nil, err
+		}
+		for _, routeState := range bulkInfo.IPv6RouteHwStateList {
+			if route, ok := buildAsicdAuditRoute(defs.IPv6, routeState.DestinationNw, routeState.NextHopIps); ok {
+				routes[route.key()] = route
 			}
 		}
-		if del {
-			//fmt.Sprintln("RIBD delAsicdRoute:del is true, calling delasicdroute for ", routeInfoRecord.destNetIp.String())
-			server.AsicdPlugin.OnewayDeleteIPv6Route([]*asicdClntDefs.IPv6Route{
-				&asicdClntDefs.IPv6Route{
-					routeInfoRecord.destNetIp.String(),
-					routeInfoRecord.networkMask.String(),
-					[]*asicdClntDefs.IPv6NextHop{
-						&asicdClntDefs.IPv6NextHop{
-							NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
-							Weight:    int32(routeInfoRecord.weight + 1),
-							//NextHopIfType: int32(routeInfoRecord.resolvedNextHopIpIntf.NextHopIfType),
-						},
-					},
-				},
-			})
+		if bulkInfo.Count == 0 || bulkInfo.More == false {
+			break
 		}
+		currMarker = int(bulkInfo.EndIdx)
 	}
+	logger.Info("ReadFIBRoutes: ", len(routes), " routes in asicd")
+	return routes, nil
 }
+
+/*
+   The link local routes are installed once for all the interfaces, they
+   are not audited
+*/
+func (plugin *AsicdFIBPlugin) ExpectedFIBRoute(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) (*FIBAuditRoute, bool) {
+	if !isAsicdVrfRoute(routeInfoRecord) || isV6LinkLocalRoute(routeInfoRecord) {
+		return nil, false
+	}
+	nextHops := make([]string, 0, len(members))
+	for _, member := range members {
+		nextHops = append(nextHops, member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
+	}
+	return &FIBAuditRoute{
+		vrf:      DefaultVrf,
+		ipType:   routeInfoRecord.ipType,
+		dst:      getRouteDstNet(routeInfoRecord),
+		nextHops: sortFIBAuditNextHops(nextHops),
+	}, true
+}
+
+/*
+   Queues the delete of next hops of a route asicd has but ribd does not
+*/
+func (plugin *AsicdFIBPlugin) deleteAuditNextHops(route *FIBAuditRoute, nextHops []string) {
+	if len(nextHops) == 0 {
+		return
+	}
+	plugin.startBatch(route.ipType, true)
+	mask := net.IP(route.dst.Mask).String()
+	if route.ipType == defs.IPv4 {
+		v4NextHops := make([]*asicdClntDefs.IPv4NextHop, 0, len(nextHops))
+		for _, nextHop := range nextHops {
+			v4NextHops = append(v4NextHops, &asicdClntDefs.IPv4NextHop{NextHopIp: nextHop})
+		}
+		plugin.v4Routes = append(plugin.v4Routes, &asicdClntDefs.IPv4Route{route.dst.IP.String(), mask, v4NextHops})
+	} else {
+		v6NextHops := make([]*asicdClntDefs.IPv6NextHop, 0, len(nextHops))
+		for _, nextHop := range nextHops {
+			v6NextHops = append(v6NextHops, &asicdClntDefs.IPv6NextHop{NextHopIp: nextHop})
+		}
+		plugin.v6Routes = append(plugin.v6Routes, &asicdClntDefs.IPv6Route{route.dst.IP.String(), mask, v6NextHops})
+	}
+	if plugin.batchSize() >= asicdBulkCount {
+		plugin.Flush()
+	}
+}
+
+/*
+   Deletes the next hops asicd has that are not in the group and creates the
+   ones it is missing
+*/
+func (plugin *AsicdFIBPlugin) RepairFIBRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup, programmed *FIBAuditRoute) {
+	if clnt, ok := plugin.nextHopGroupClnt(); ok {
+		logger.Info("RepairFIBRoute: ", routeInfoRecord.networkAddr, " group ", group.groupId)
+		if plugin.groups[group.groupId] {
+			clnt.OnewaySetRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String(), int32(group.groupId))
+		} else if programmed != nil {
+			clnt.OnewayDeleteRouteNextHopGroup(routeInfoRecord.destNetIp.String(), routeInfoRecord.networkMask.String())
+		}
+		return
+	}
+	members := group.ActiveMembers()
+	expectedNextHops := make(map[string]bool)
+	for _, member := range members {
+		expectedNextHops[normalizeFIBAuditNextHop(member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)] = true
+	}
+	programmedNextHops := make(map[string]bool)
+	if programmed != nil {
+		staleNextHops := make([]string, 0)
+		for _, nextHop := range programmed.nextHops {
+			programmedNextHops[nextHop] = true
+			if !expectedNextHops[nextHop] {
+				staleNextHops = append(staleNextHops, nextHop)
+			}
+		}
+		plugin.deleteAuditNextHops(programmed, staleNextHops)
+	}
+	missingMembers := make([]NextHopGroupMember, 0, len(members))
+	for _, member := range members {
+		if !programmedNextHops[normalizeFIBAuditNextHop(member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)] {
+			missingMembers = append(missingMembers, member)
+		}
+	}
+	logger.Info("RepairFIBRoute: ", routeInfoRecord.networkAddr, " group ", group.groupId, " missing next hops ", len(missingMembers))
+	plugin.createRoutes(routeInfoRecord.ipType, []RouteInfoRecord{routeInfoRecord}, missingMembers)
+}
+
+func (plugin *AsicdFIBPlugin) RemoveFIBRoute(programmed *FIBAuditRoute) {
+	logger.Info("RemoveFIBRoute: ", programmed.dst.String(), " next hops ", programmed.nextHops)
+	if clnt, ok := plugin.nextHopGroupClnt(); ok {
+		clnt.OnewayDeleteRouteNextHopGroup(programmed.dst.IP.String(), net.IP(programmed.dst.Mask).String())
+		return
+	}
+	plugin.deleteAuditNextHops(programmed, programmed.nextHops)
+}
+
 func (m RIBDServer) GetV4ConnectedRoutes() {
 	logger.Info("Getting v4 Intfs from asicd")
 	var currMarker int
@@ -175,7 +497,7 @@ func (m RIBDServer) GetV4ConnectedRoutes() {
 	ipv4IntfList := make([]*objects.IPv4IntfState, 0)
 	count = 100
 	ret_count := 0
-	for {
+	for m.AsicdPlugin != nil {
 		IPIntfBulk, err := m.AsicdPlugin.GetBulkIPv4IntfState(currMarker, count)
 		if err != nil {
 			logger.Debug("GetBulkIPv4IntfState with err ", err)
@@ -204,7 +526,7 @@ func (m RIBDServer) GetV6ConnectedRoutes() {
 	ipv6IntfList := make([]*objects.IPv6IntfState, 0)
 	count = 100
 	ret_count := 0
-	for {
+	for m.AsicdPlugin != nil {
 		IPIntfBulk, err := m.AsicdPlugin.GetBulkIPv6IntfState(currMarker, count)
 		if err != nil {
 			logger.Debug("GetBulkIPv6IntfState with err ", err)
@@ -226,29 +548,96 @@ func (m RIBDServer) GetV6ConnectedRoutes() {
 	m.V6IntfsGetDone <- V6IntfGetInfo{ret_count, ipv6IntfList}
 }
 
+/*
+   Route updates go to the FIB queue, the other updates apply to the routes
+   queued before them so the queue is flushed first
+*/
+func (ribdServiceHandler *RIBDServer) processAsicdRouteMsg(route RIBdServerConfig) {
+	if route.Op == defs.Add || route.Op == defs.Del {
+		queuedTime, ok := route.AdditionalParams.(time.Time)
+		if !ok {
+			queuedTime = time.Now()
+		}
+		ribdServiceHandler.FIBQueue.Add(ribdServiceHandler.NextHopGroupTable, route.OrigConfigObject.(RouteInfoRecord), route.Op, queuedTime)
+		return
+	}
+	logger.Info(" received message on AsicdRouteCh, op:", route.Op)
+	ribdServiceHandler.flushFIBQueue()
+	if route.Op == defs.NextHopDown {
+		ribdServiceHandler.processNextHopGroupEvent(route.OrigConfigObject.(NextHopGroupEvent), false)
+	} else if route.Op == defs.NextHopUp {
+		ribdServiceHandler.processNextHopGroupEvent(route.OrigConfigObject.(NextHopGroupEvent), true)
+	} else if route.Op == defs.FlushStaleRoutes {
+		if ribdServiceHandler.NetlinkPlugin != nil {
+			ribdServiceHandler.NetlinkPlugin.FlushStaleRoutes()
+		}
+	} else if route.Op == defs.AsicdFetchv4 {
+		logger.Info("AsicdServer loop fetchv4, call getv4connectedroutes")
+		ribdServiceHandler.GetV4ConnectedRoutes()
+	} else if route.Op == defs.AsicdFetchv6 {
+		logger.Info("AsicdServer loop fetchv6, call getv6connectedroutes")
+		ribdServiceHandler.GetV6ConnectedRoutes()
+	} else if route.Op == defs.FIBAudit {
+		ribdServiceHandler.auditFIB(route.OrigConfigObject.(FIBAuditRequest))
+	}
+}
+
+/*
+   The updates waiting in AsicdRouteCh are taken into the FIB queue, up to
+   its depth, before it is flushed. A burst of updates is sent to the FIB in
+   batches, and the updates of the same route within a batch are coalesced.
+*/
 func (ribdServiceHandler *RIBDServer) StartAsicdServer() {
 	logger.Info("Starting the asicdserver loop")
-	asicdv4Route = make([]asicdClntDefs.IPv4Route, asicdBulkCount)
-	asicdv6Route = make([]asicdClntDefs.IPv6Route, asicdBulkCount)
+	queue := ribdServiceHandler.FIBQueue
 	for {
-		select {
-		case route := <-ribdServiceHandler.AsicdRouteCh:
-			logger.Info(" received message on AsicdRouteCh, op:", route.Op)
-			if route.Op == defs.Add {
-				if route.Bulk {
-					ribdServiceHandler.addAsicdRouteBulk(route.OrigConfigObject.(RouteInfoRecord), route.BulkEnd)
-				} else {
-					ribdServiceHandler.addAsicdRoute(route.OrigConfigObject.(RouteInfoRecord))
-				}
-			} else if route.Op == defs.Del {
-				ribdServiceHandler.delAsicdRoute(route.OrigConfigObject.(RouteInfoRecord))
-			} else if route.Op == defs.AsicdFetchv4 {
-				logger.Info("AsicdServer loop fetchv4, call getv4connectedroutes")
-				ribdServiceHandler.GetV4ConnectedRoutes()
-			} else if route.Op == defs.AsicdFetchv6 {
-				logger.Info("AsicdServer loop fetchv6, call getv6connectedroutes")
-				ribdServiceHandler.GetV6ConnectedRoutes()
+		route := <-ribdServiceHandler.AsicdRouteCh
+		ribdServiceHandler.processAsicdRouteMsg(route)
+	drain:
+		for count := 1; count < queue.maxDepth && !queue.IsFull(); count++ {
+			select {
+			case route = <-ribdServiceHandler.AsicdRouteCh:
+				ribdServiceHandler.processAsicdRouteMsg(route)
+			default:
+				break drain
 			}
 		}
+		ribdServiceHandler.flushFIBQueue()
+	}
+}
+
+/*
+   PBR rules are programmed in the ingress ACL of the interface, unset match
+   fields are sent empty or 0
+*/
+func buildAsicdPbrPrefix(prefix *net.IPNet) string {
+	if prefix == nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+func (plugin *AsicdFIBPlugin) PbrSupported() bool {
+	_, ok := plugin.server.AsicdPlugin.(AsicdPbrClntIntf)
+	return ok
+}
+
+func (plugin *AsicdFIBPlugin) InstallPbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	logger.Info("InstallPbrRule: policy ", policy, " rule ", rule.seq, " ifIndex ", ifIndex)
+	clnt, ok := plugin.server.AsicdPlugin.(AsicdPbrClntIntf)
+	if !ok {
+		logger.Err("InstallPbrRule: asicd does not support PBR rules, rule ", rule.seq, " of policy ", policy, " not installed on ifIndex ", ifIndex)
+		return
+	}
+	clnt.OnewayCreatePbrRule(policy, rule.seq, ifIndex, buildAsicdPbrPrefix(rule.srcPrefix), buildAsicdPbrPrefix(rule.dstPrefix),
+		int32(rule.protocol), rule.srcPort, rule.dstPort, rule.dscp, rule.nextHop.NextHopIp, rule.resolveVrf())
+}
+
+func (plugin *AsicdFIBPlugin) DeletePbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	logger.Info("DeletePbrRule: policy ", policy, " rule ", rule.seq, " ifIndex ", ifIndex)
+	clnt, ok := plugin.server.AsicdPlugin.(AsicdPbrClntIntf)
+	if !ok {
+		return
 	}
+	clnt.OnewayDeletePbrRule(policy, rule.seq, ifIndex)
 }
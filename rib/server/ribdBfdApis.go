@@ -0,0 +1,237 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdBfdApis.go
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l3/bfd/bfddCommonDefs"
+	defs "l3/rib/ribdCommonDefs"
+	"ribd"
+	"strconv"
+	"strings"
+
+	"github.com/op/go-nanomsg"
+)
+
+/*
+   BFD option of a static route next hop
+*/
+const (
+	BfdSingleHop = "single-hop"
+	BfdMultiHop  = "multi-hop"
+)
+
+/*
+   BFD session bfdd runs to a static route next hop on behalf of ribd. The
+   session is shared by all the static routes using the next hop and is
+   deleted with the last of them. The next hop stays installed until bfdd
+   reports the session down.
+*/
+type BfdNextHopInfo struct {
+	destIp   string
+	intf     string
+	multiHop bool
+	refCount int
+	up       bool
+}
+
+/*
+   bfdd sessions are not VRF aware, so BFD can only track the next hops of
+   the default VRF
+*/
+func validateNextHopBfd(protocol string, nextHop *ribd.NextHopInfo) error {
+	switch nextHop.Bfd {
+	case "":
+		return nil
+	case BfdSingleHop, BfdMultiHop:
+	default:
+		logger.Err("validateNextHopBfd: invalid BFD option ", nextHop.Bfd, " for next hop ", nextHop.NextHopIp)
+		return errors.New(fmt.Sprintln("Invalid BFD option ", nextHop.Bfd, " expected ", BfdSingleHop, " or ", BfdMultiHop))
+	}
+	if protocol != "STATIC" {
+		return errors.New(fmt.Sprintln("BFD not supported for ", protocol, " route next hops"))
+	}
+	nextHopIfIndex, _ := strconv.Atoi(nextHop.NextHopIntRef)
+	if vrf := getVrfName(getIntfVrf(int32(nextHopIfIndex))); vrf != DefaultVrf {
+		return errors.New(fmt.Sprintln("BFD not supported for next hop ", nextHop.NextHopIp, " in vrf ", vrf))
+	}
+	return nil
+}
+
+func BfdSessionNotificationSend(info *BfdNextHopInfo, evt int) {
+	publisherInfo, ok := PublisherInfoMap["BFD"]
+	if !ok {
+		logger.Info("Publisher not found for BFD")
+		return
+	}
+	msgInfo := defs.BfdSessionMsgInfo{
+		DestIp:    info.destIp,
+		Interface: info.intf,
+		MultiHop:  info.multiHop,
+	}
+	msgbufbytes, err := json.Marshal(msgInfo)
+	if err != nil {
+		logger.Err("Error in marshalling Json")
+		return
+	}
+	msg := defs.RibdNotifyMsg{Vrf: DefaultVrf, MsgType: uint16(evt), MsgBuf: msgbufbytes}
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		logger.Err("Error in marshalling Json")
+		return
+	}
+	eventInfo := "Create BFD session to next hop " + info.destIp
+	if evt == defs.NOTIFY_BFD_SESSION_DELETE {
+		eventInfo = "Delete BFD session to next hop " + info.destIp
+	}
+	RouteServiceHandler.NotificationChannel <- NotificationMsg{publisherInfo.pub_socket, buf, eventInfo}
+}
+
+/*
+   Asks bfdd for a session to the next hop of the static route when the
+   first route with the BFD option uses it
+*/
+func (r *RIB) trackBfdNextHop(routeInfoRecord RouteInfoRecord) {
+	if routeInfoRecord.bfd == "" {
+		return
+	}
+	destIp := routeInfoRecord.nextHopIp.String()
+	if info, ok := r.bfdNextHops[destIp]; ok {
+		info.refCount++
+		return
+	}
+	info := &BfdNextHopInfo{
+		destIp:   destIp,
+		multiHop: routeInfoRecord.bfd == BfdMultiHop,
+		refCount: 1,
+		up:       true,
+	}
+	if !info.multiHop {
+		if intfEntry, ok := r.IntfEntry(int32(routeInfoRecord.nextHopIfIndex)); ok {
+			info.intf = intfEntry.name
+		}
+	}
+	logger.Info("trackBfdNextHop: ", routeInfoRecord.bfd, " session to ", destIp, " interface ", info.intf)
+	r.bfdNextHops[destIp] = info
+	BfdSessionNotificationSend(info, defs.NOTIFY_BFD_SESSION_CREATE)
+}
+
+/*
+   Deletes the session with the last static route using the next hop. A next
+   hop withdrawn by BFD is marked up again in the FIB, the routes that still
+   use it are not tracked by BFD.
+*/
+func (r *RIB) untrackBfdNextHop(routeInfoRecord RouteInfoRecord) {
+	if routeInfoRecord.bfd == "" {
+		return
+	}
+	destIp := routeInfoRecord.nextHopIp.String()
+	info, ok := r.bfdNextHops[destIp]
+	if !ok {
+		return
+	}
+	info.refCount--
+	if info.refCount > 0 {
+		return
+	}
+	logger.Info("untrackBfdNextHop: delete session to ", destIp)
+	delete(r.bfdNextHops, destIp)
+	if !info.up {
+		notifyBfdNextHopGroups(destIp, true)
+	}
+	BfdSessionNotificationSend(info, defs.NOTIFY_BFD_SESSION_DELETE)
+}
+
+/*
+   Queues the BFD session state change of the next hop to the FIB
+*/
+func notifyBfdNextHopGroups(destIp string, up bool) {
+	op := defs.NextHopDown
+	if up {
+		op = defs.NextHopUp
+	}
+	RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{
+		OrigConfigObject: NextHopGroupEvent{vrf: DefaultVrf, ifIndex: -1, bfdNextHop: destIp},
+		Op:               op,
+	}
+}
+
+/*
+   Withdraws the static next hops tracked by the session from the FIB when
+   the session goes down and reinstalls them when it comes back up
+*/
+func (ribdServiceHandler *RIBDServer) ProcessBfdSessionStateChange(msg bfddCommonDefs.BfddNotifyMsg) {
+	//link local sessions are reported as ip%interface
+	destIp := strings.Split(msg.DestIp, "%")[0]
+	info, ok := ribdServiceHandler.RIB.bfdNextHops[destIp]
+	if !ok || info.up == msg.State {
+		return
+	}
+	logger.Info("ProcessBfdSessionStateChange: session to ", destIp, " up ", msg.State)
+	info.up = msg.State
+	notifyBfdNextHopGroups(destIp, msg.State)
+}
+
+/*
+   Listens for the session state changes bfdd publishes to the protocols
+*/
+func (ribdServiceHandler *RIBDServer) StartBfdServer() {
+	logger.Info("Starting the bfd server loop")
+	socket, err := nanomsg.NewSubSocket()
+	if err != nil {
+		logger.Err("Failed to create BFD subscribe socket, error:", err)
+		return
+	}
+	if err = socket.Subscribe(""); err != nil {
+		logger.Err("Failed to subscribe to \"\" on BFD subscribe socket, error:", err)
+		return
+	}
+	if _, err = socket.Connect(bfddCommonDefs.PUB_SOCKET_ADDR); err != nil {
+		logger.Err("Failed to connect to BFD publisher socket, address:", bfddCommonDefs.PUB_SOCKET_ADDR, " error:", err)
+		return
+	}
+	if err = socket.SetRecvBuffer(1024 * 1024); err != nil {
+		logger.Err("Failed to set the buffer size for BFD subscribe socket, error:", err)
+		return
+	}
+	for {
+		rxBuf, err := socket.Recv(0)
+		if err != nil {
+			logger.Err("Recv on BFD subscriber socket failed with error:", err)
+			continue
+		}
+		var msg bfddCommonDefs.BfddNotifyMsg
+		if err = json.Unmarshal(rxBuf, &msg); err != nil {
+			logger.Err("Unable to unmarshal BFD notification:", rxBuf)
+			continue
+		}
+		ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
+			OrigConfigObject: msg,
+			Op:               defs.BfdSessionStateChange,
+		}
+	}
+}
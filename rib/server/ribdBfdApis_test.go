@@ -0,0 +1,121 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdBfdApis_test.go
+package server
+
+import (
+	"fmt"
+	"l3/bfd/bfddCommonDefs"
+	defs "l3/rib/ribdCommonDefs"
+	"ribd"
+	"testing"
+)
+
+func TestValidateNextHopBfd(t *testing.T) {
+	fmt.Println("****TestValidateNextHopBfd****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	valid := []string{"", BfdSingleHop, BfdMultiHop}
+	for _, bfd := range valid {
+		nextHop := &ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "1", Bfd: bfd}
+		if err := validateNextHopBfd("STATIC", nextHop); err != nil {
+			t.Error("BFD option ", bfd, " rejected with err ", err)
+		}
+	}
+	nextHop := &ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "1", Bfd: "echo"}
+	if err := validateNextHopBfd("STATIC", nextHop); err == nil {
+		t.Error("Invalid BFD option ", nextHop.Bfd, " accepted")
+	}
+	nextHop.Bfd = BfdSingleHop
+	if err := validateNextHopBfd("OSPF", nextHop); err == nil {
+		t.Error("BFD option accepted for an OSPF next hop")
+	}
+	fmt.Println("***********************************")
+}
+
+func TestBfdNextHop(t *testing.T) {
+	fmt.Println("****TestBfdNextHop****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	plugin := &testFIBPlugin{}
+	rib := NewRIB()
+	testServer := &RIBDServer{
+		RIB:               rib,
+		FIBPlugin:         plugin,
+		NextHopGroupTable: NewNextHopGroupTable(),
+		AsicdRouteCh:      make(chan RIBdServerConfig, 4),
+	}
+	RouteServiceHandler = testServer
+
+	bfdNextHop := buildTestNextHopGroupRouteInfoRecord("40.0.1.0", "11.1.10.2", 1, "")
+	bfdNextHop.bfd = BfdSingleHop
+	nextHop := buildTestNextHopGroupRouteInfoRecord("40.0.1.0", "12.1.10.2", 2, "")
+	rib.trackBfdNextHop(bfdNextHop)
+	rib.trackBfdNextHop(nextHop)
+	testServer.addNextHopGroupRoute(bfdNextHop)
+	testServer.addNextHopGroupRoute(nextHop)
+	if len(rib.bfdNextHops) != 1 || rib.bfdNextHops["11.1.10.2"] == nil || rib.bfdNextHops["11.1.10.2"].refCount != 1 {
+		t.Error("Unexpected BFD next hops ", rib.bfdNextHops)
+	}
+
+	testServer.ProcessBfdSessionStateChange(bfddCommonDefs.BfddNotifyMsg{DestIp: "11.1.10.2", State: false})
+	event := <-testServer.AsicdRouteCh
+	fmt.Println("session down event:", event)
+	if event.Op != defs.NextHopDown {
+		t.Error("Unexpected op ", event.Op, " for session down")
+	}
+	testServer.processNextHopGroupEvent(event.OrigConfigObject.(NextHopGroupEvent), false)
+	for _, group := range testServer.NextHopGroupTable.groups {
+		members := group.ActiveMembers()
+		if len(members) != 1 || members[0].routeInfoRecord.nextHopIp.String() != "12.1.10.2" {
+			t.Error("Unexpected active next hops ", members, " after BFD session down")
+		}
+	}
+	//a repeated state is not queued again
+	testServer.ProcessBfdSessionStateChange(bfddCommonDefs.BfddNotifyMsg{DestIp: "11.1.10.2", State: false})
+	if len(testServer.AsicdRouteCh) != 0 {
+		t.Error("Repeated session down queued to the FIB")
+	}
+
+	testServer.ProcessBfdSessionStateChange(bfddCommonDefs.BfddNotifyMsg{DestIp: "11.1.10.2", State: true})
+	event = <-testServer.AsicdRouteCh
+	testServer.processNextHopGroupEvent(event.OrigConfigObject.(NextHopGroupEvent), true)
+	for _, group := range testServer.NextHopGroupTable.groups {
+		if len(group.ActiveMembers()) != 2 {
+			t.Error("Unexpected active next hops ", group.ActiveMembers(), " after BFD session up")
+		}
+	}
+
+	rib.untrackBfdNextHop(bfdNextHop)
+	if len(rib.bfdNextHops) != 0 {
+		t.Error("BFD next hop ", rib.bfdNextHops, " not deleted with the last route")
+	}
+	fmt.Println("***********************************")
+}
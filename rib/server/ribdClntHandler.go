@@ -57,6 +57,9 @@ type BGPdClient struct {
 type OSPFdClient struct {
 	baseClient
 }
+type RIPdClient struct {
+	baseClient
+}
 type ClientIf interface {
 	DmnDownHandler()
 	DmnUpHandler()
@@ -75,12 +78,17 @@ var asicdclnt AsicdClient
 var arpdclnt ArpdClient
 var bgpdclnt BGPdClient
 var ospfdclnt OSPFdClient
+var ripdclnt RIPdClient
 
-func deleteV4RoutesOfType(protocol string, destNet string) {
+func deleteV4RoutesOfType(vrf string, protocol string, destNet string) {
 	var testroutes []RouteInfoRecord
 	testroutes = make([]RouteInfoRecord, 0)
 
-	routeInfoRecordListItem := V4RouteInfoMap.Get(patriciaDB.Prefix(destNet))
+	routeInfoMap := getRouteInfoMap(vrf, ribdCommonDefs.IPv4)
+	if routeInfoMap == nil {
+		return
+	}
+	routeInfoRecordListItem := routeInfoMap.Get(patriciaDB.Prefix(destNet))
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected: no route for destNet:", destNet, " found in routeMap")
 		return
@@ -99,15 +107,19 @@ func deleteV4RoutesOfType(protocol string, destNet string) {
 	for _, protoroute := range testroutes { //protocolRouteList {
 		//logger.Info(len(testroutes), " number of ", protocol, " routes in routemap:", testroutes, " remaining")
 		//logger.Info("protoroute:", protoroute, " nexthop:", protoroute.nextHopIp.String())
-		_, err := deleteIPRoute(protoroute.destNetIp.String(), ribdCommonDefs.IPv4, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		_, err := RouteServiceHandler.RIB.deleteIPRoute(vrf, protoroute.destNetIp.String(), ribdCommonDefs.IPv4, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
 		logger.Info("err :", err, " while deleting ", protocol, " route with destNet:", protoroute.destNetIp.String(), " nexthopIP:", protoroute.nextHopIp.String())
 	}
 }
-func deleteV6RoutesOfType(protocol string, destNet string) {
+func deleteV6RoutesOfType(vrf string, protocol string, destNet string) {
 	var testroutes []RouteInfoRecord
 	testroutes = make([]RouteInfoRecord, 0)
 
-	routeInfoRecordListItem := V6RouteInfoMap.Get(patriciaDB.Prefix(destNet))
+	routeInfoMap := getRouteInfoMap(vrf, ribdCommonDefs.IPv6)
+	if routeInfoMap == nil {
+		return
+	}
+	routeInfoRecordListItem := routeInfoMap.Get(patriciaDB.Prefix(destNet))
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected: no route for destNet:", destNet, " found in routeMap")
 		return
@@ -126,13 +138,18 @@ func deleteV6RoutesOfType(protocol string, destNet string) {
 	for _, protoroute := range testroutes { //protocolRouteList {
 		//logger.Info(len(testroutes), " number of ", protocol, " routes in routemap:", testroutes, " remaining")
 		//logger.Info("protoroute:", protoroute, " nexthop:", protoroute.nextHopIp.String())
-		_, err := deleteIPRoute(protoroute.destNetIp.String(), ribdCommonDefs.IPv6, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		_, err := RouteServiceHandler.RIB.deleteIPRoute(vrf, protoroute.destNetIp.String(), ribdCommonDefs.IPv6, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
 		logger.Info("err :", err, " while deleting ", protocol, " route with destNet:", protoroute.destNetIp.String(), " nexthopIP:", protoroute.nextHopIp.String())
 	}
 }
 func DeleteRoutesOfType(protocol string) {
-	func_mesg := "DeleteRoutesOfType of type:" + protocol
-	protocolRouteMap, ok := ProtocolRouteMap[protocol]
+	for _, rib := range RouteServiceHandler.RIB.vrfs {
+		deleteVrfRoutesOfType(rib, protocol)
+	}
+}
+func deleteVrfRoutesOfType(rib *VrfRIB, protocol string) {
+	func_mesg := "DeleteRoutesOfType of type:" + protocol + " vrf:" + rib.name
+	protocolRouteMap, ok := rib.protocolRouteMap[protocol]
 	if !ok {
 		logger.Info(func_mesg, "No routes of ", protocol, " type configured")
 		return
@@ -142,7 +159,7 @@ func DeleteRoutesOfType(protocol string) {
 		for destNet, count := range protocolRouteMap.v4routeMap {
 			if count.totalcount > 0 {
 				logger.Info(func_mesg, ":", count, " number of v4 routes for destNet IP:", string(destNet))
-				deleteV4RoutesOfType(protocol, destNet)
+				deleteV4RoutesOfType(rib.name, protocol, destNet)
 				//deleteV6RoutesOfType(protocol, destNet)
 				protocolRouteMap.totalcount.totalcount = protocolRouteMap.totalcount.totalcount - count.totalcount
 				protocolRouteMap.totalcount.ecmpcount = protocolRouteMap.totalcount.ecmpcount - count.ecmpcount
@@ -151,7 +168,7 @@ func DeleteRoutesOfType(protocol string) {
 				totalCount.ecmpcount = 0
 				protocolRouteMap.v4routeMap[destNet] = totalCount
 				//			protocolRouteMap.routeMap[destNet].ecmpcount = 0
-				ProtocolRouteMap[protocol] = protocolRouteMap
+				rib.protocolRouteMap[protocol] = protocolRouteMap
 			}
 		}
 	}
@@ -161,7 +178,7 @@ func DeleteRoutesOfType(protocol string) {
 			if count.totalcount > 0 {
 				logger.Info(count, " number of v6 routes for destNet IP:", string(destNet))
 				//deleteV4RoutesOfType(protocol, destNet)
-				deleteV6RoutesOfType(protocol, destNet)
+				deleteV6RoutesOfType(rib.name, protocol, destNet)
 				protocolRouteMap.totalcount.totalcount = protocolRouteMap.totalcount.totalcount - count.totalcount
 				protocolRouteMap.totalcount.ecmpcount = protocolRouteMap.totalcount.ecmpcount - count.ecmpcount
 				totalCount := protocolRouteMap.v6routeMap[destNet]
@@ -169,7 +186,7 @@ func DeleteRoutesOfType(protocol string) {
 				totalCount.ecmpcount = 0
 				protocolRouteMap.v6routeMap[destNet] = totalCount
 				//			protocolRouteMap.routeMap[destNet].ecmpcount = 0
-				ProtocolRouteMap[protocol] = protocolRouteMap
+				rib.protocolRouteMap[protocol] = protocolRouteMap
 			}
 		}
 	}
@@ -189,13 +206,24 @@ func (clnt *baseClient) DmnDownHandler() {
 }
 func (clnt *BGPdClient) DmnDownHandler() {
 	logger.Info("DmnDownHandler for BGPd")
-	//uninstall all BGP routes
-	DeleteRoutesOfType("EBGP")
+	//keep forwarding with the BGP routes until bgpd refreshes them
+	for _, protocol := range clientProtocolsMap["bgpd"] {
+		RouteServiceHandler.RouteConfCh <- RIBdServerConfig{OrigConfigObject: protocol, Op: ribdCommonDefs.MarkStaleRoutes}
+	}
 }
 func (clnt *OSPFdClient) DmnDownHandler() {
 	logger.Info("DmnDownHandler for OSPFd")
-	//uninstall all OSPF routes
-	DeleteRoutesOfType("OSPF")
+	//keep forwarding with the OSPF routes until ospfd refreshes them
+	for _, protocol := range clientProtocolsMap["ospfd"] {
+		RouteServiceHandler.RouteConfCh <- RIBdServerConfig{OrigConfigObject: protocol, Op: ribdCommonDefs.MarkStaleRoutes}
+	}
+}
+func (clnt *RIPdClient) DmnDownHandler() {
+	logger.Info("DmnDownHandler for RIPd")
+	//keep forwarding with the RIP routes until ripd learns them again
+	for _, protocol := range clientProtocolsMap["ripd"] {
+		RouteServiceHandler.RouteConfCh <- RIBdServerConfig{OrigConfigObject: protocol, Op: ribdCommonDefs.MarkStaleRoutes}
+	}
 }
 func (mgr *RIBDServer) DmnDownHandler(name string) error {
 	logger.Info("In DmnDownHandler call DmnDownHandler for client: ", name)
@@ -379,6 +407,9 @@ func (ribdServiceHandler *RIBDServer) ConnectToClients(paramsFile string) {
 		if client.Name == "ospfd" {
 			ribdServiceHandler.Clients["ospfd"] = &ospfdclnt
 		}
+		if client.Name == "ripd" {
+			ribdServiceHandler.Clients["ripd"] = &ripdclnt
+		}
 		/*
 			if client.Name == "asicd" {
 				logger.Info("found asicd at port ", client.Port)
@@ -25,7 +25,10 @@ package server
 
 import (
 	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"ribd"
 	"testing"
+	"time"
 )
 
 func TestInitRtClntHandlerServer(t *testing.T) {
@@ -47,3 +50,90 @@ func TestDeleteRoutesOfType(t *testing.T) {
 	TestProcessv4RouteDeleteConfig(t)
 	fmt.Println("**********************************")
 }
+
+func getProtocolRoutes(vrf string, destNet string, protocol string) []RouteInfoRecord {
+	prefix, err := getNetowrkPrefixFromStrings(destNet, "255.255.255.0")
+	if err != nil {
+		return nil
+	}
+	item := server.RIB.RouteInfoMapGet(vrf, defs.IPv4, prefix)
+	if item == nil {
+		return nil
+	}
+	return item.(RouteInfoRecordList).routeInfoProtocolMap[protocol]
+}
+
+func TestStaleRoutesOfType(t *testing.T) {
+	fmt.Println("****Test stale routes of type****")
+	TestProcessLogicalIntfCreateEvent(t)
+	TestIPv4IntfCreateEvent(t)
+	TestProcessV4RouteCreateConfig(t)
+	server.MarkRoutesOfTypeStale("EBGP")
+	rt, err := server.Getv4Route("41.1.10.0")
+	fmt.Println("route after mark stale:", rt, " err:", err)
+	if err != nil {
+		t.Fatal("Getv4Route 41.1.10.0 failed after mark stale, err:", err)
+	}
+	if !rt.IsStale {
+		t.Error("EBGP route 41.1.10.0 not marked stale")
+	}
+	if !isRouteStale(getProtocolRoutes(DefaultVrf, "40.1.10.0", "EBGP")) {
+		t.Error("EBGP route 40.1.10.0 not marked stale")
+	}
+	//only 41.1.10.0 is refreshed, 40.1.10.0 stays stale
+	val, err := server.ProcessV4RouteCreateConfig(ipv4RouteList[1], FIBAndRIB, ribd.Int(len(server.RIB.destNetSlice)))
+	fmt.Println("val = ", val, " err: ", err, " for refreshed route:", ipv4RouteList[1])
+	rt, err = server.Getv4Route("41.1.10.0")
+	fmt.Println("route after refresh:", rt, " err:", err)
+	if err != nil {
+		t.Fatal("Getv4Route 41.1.10.0 failed after refresh, err:", err)
+	}
+	if rt.IsStale {
+		t.Error("EBGP route 41.1.10.0 still stale after refresh")
+	}
+	server.SweepStaleRoutesOfType("EBGP")
+	if routes := getProtocolRoutes(DefaultVrf, "40.1.10.0", "EBGP"); len(routes) != 0 {
+		t.Error("Stale EBGP route 40.1.10.0 not swept ", routes)
+	}
+	if routes := getProtocolRoutes(DefaultVrf, "41.1.10.0", "EBGP"); len(routes) != 1 || routes[0].stale {
+		t.Error("Refreshed EBGP route 41.1.10.0 swept or stale ", routes)
+	}
+	if _, ok := server.RIB.staleRouteTimers["EBGP"]; ok {
+		t.Error("Stale route hold timer of EBGP not stopped by the sweep")
+	}
+	fmt.Println("route reachability after sweep of stale routes")
+	TestGetRouteReachability(t)
+	TestProcessv4RouteDeleteConfig(t)
+	fmt.Println("**********************************")
+}
+
+func TestHoldStaleRoutesOfType(t *testing.T) {
+	fmt.Println("****Test hold stale routes of type****")
+	server.HoldStaleRoutesOfType("EBGP", time.Hour)
+	if _, ok := server.RIB.staleRouteTimers["EBGP"]; ok {
+		t.Error("Hold timer of EBGP started without stale routes")
+	}
+	TestProcessLogicalIntfCreateEvent(t)
+	TestIPv4IntfCreateEvent(t)
+	TestProcessV4RouteCreateConfig(t)
+	server.MarkRoutesOfTypeStale("EBGP")
+	markTimer := server.RIB.staleRouteTimers["EBGP"]
+	server.HoldStaleRoutesOfType("EBGP", time.Hour)
+	holdTimer, ok := server.RIB.staleRouteTimers["EBGP"]
+	if !ok || holdTimer == markTimer {
+		t.Error("Hold timer of the stale EBGP routes not restarted")
+	}
+	//the timer of the mark is stopped, it can not sweep the held routes
+	if markTimer.Stop() {
+		t.Error("Hold timer of the mark of the EBGP routes still running")
+	}
+	if !isRouteStale(getProtocolRoutes(DefaultVrf, "40.1.10.0", "EBGP")) {
+		t.Error("EBGP route 40.1.10.0 not stale after hold")
+	}
+	server.SweepStaleRoutesOfType("EBGP")
+	if _, ok := server.RIB.staleRouteTimers["EBGP"]; ok {
+		t.Error("Hold timer of EBGP not stopped by the sweep")
+	}
+	TestProcessv4RouteDeleteConfig(t)
+	fmt.Println("**********************************")
+}
@@ -0,0 +1,443 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdFIBAuditApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribdInt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"utils/patriciaDB"
+)
+
+/*
+   Interval of the periodic audit of the FIB against the RIB, 0 disables it
+*/
+const DefaultFIBAuditInterval = 300 * time.Second
+
+const (
+	FIBAuditMissing  = "missing"  //selected route not in the FIB
+	FIBAuditExtra    = "extra"    //route in the FIB that ribd did not select
+	FIBAuditMismatch = "mismatch" //route in the FIB with other next hops than ribd resolved
+)
+
+/*
+   Route of the FIB as read back from the backend or as ribd expects it to be
+   programmed. The next hops are sorted and use the representation of the
+   backend so that the two can be compared.
+*/
+type FIBAuditRoute struct {
+	vrf      string
+	ipType   defs.IPType
+	dst      *net.IPNet
+	nextHops []string
+	table    int //kernel table of the route, netlink only
+}
+
+func (route *FIBAuditRoute) key() string {
+	return getVrfPrefixKey(route.vrf, route.dst.String())
+}
+
+/*
+   FIB backends that can read back the routes they programmed. The audit runs
+   in the asicd server loop, after the FIB queue is flushed, so the backends
+   are not updated while they are read.
+*/
+type FIBAuditPlugin interface {
+	ReadFIBRoutes() (map[string]*FIBAuditRoute, error)
+	//route the backend programs for the destination, false if it does not program it
+	ExpectedFIBRoute(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) (*FIBAuditRoute, bool)
+	//programmed is nil when the route is missing from the FIB
+	RepairFIBRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup, programmed *FIBAuditRoute)
+	RemoveFIBRoute(programmed *FIBAuditRoute)
+}
+
+/*
+   Audit queued to the asicd server loop with the selected routes of the RIB,
+   by destination, at the time it was requested
+*/
+type FIBAuditRequest struct {
+	repair bool
+	routes map[string][]RouteInfoRecord
+}
+
+type FIBAuditEntry struct {
+	key         string
+	vrf         string
+	dst         string
+	status      string
+	ribNextHops []string
+	fibNextHops []string
+	repaired    bool
+}
+
+type FIBAuditResult struct {
+	auditTime  time.Time
+	duration   time.Duration
+	repair     bool
+	ribRoutes  int
+	fibRoutes  int
+	missing    int
+	extra      int
+	mismatched int
+	repaired   int
+	err        error
+	entries    []FIBAuditEntry
+}
+
+/*
+   Settings of the audit and the result of the last one. The result is
+   written by the asicd server loop and read by the RPC handlers.
+*/
+type FIBAudit struct {
+	Interval time.Duration
+	Repair   bool //the periodic audit repairs the differences it finds
+	lock     sync.Mutex
+	audits   int64
+	last     FIBAuditResult
+}
+
+func NewFIBAudit(interval time.Duration, repair bool) *FIBAudit {
+	return &FIBAudit{
+		Interval: interval,
+		Repair:   repair,
+	}
+}
+
+func (audit *FIBAudit) setResult(result FIBAuditResult) {
+	audit.lock.Lock()
+	audit.audits++
+	audit.last = result
+	audit.lock.Unlock()
+}
+
+/*
+   Normalizes the address so that the next hops of the RIB and of the FIB
+   compare equal
+*/
+func normalizeFIBAuditNextHop(nextHop string) string {
+	nextHop = strings.TrimSpace(nextHop)
+	if ip := net.ParseIP(nextHop); ip != nil {
+		return ip.String()
+	}
+	return nextHop
+}
+
+func sortFIBAuditNextHops(nextHops []string) []string {
+	sorted := make([]string, 0, len(nextHops))
+	seen := make(map[string]bool)
+	for _, nextHop := range nextHops {
+		nextHop = normalizeFIBAuditNextHop(nextHop)
+		if nextHop == "" || seen[nextHop] {
+			continue
+		}
+		seen[nextHop] = true
+		sorted = append(sorted, nextHop)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func equalFIBAuditNextHops(nextHops []string, other []string) bool {
+	if len(nextHops) != len(other) {
+		return false
+	}
+	for idx := range nextHops {
+		if nextHops[idx] != other[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+   Routes of the selected protocol of every destination of the RIB
+*/
+func (r *RIB) selectedFIBRoutes() map[string][]RouteInfoRecord {
+	routes := make(map[string][]RouteInfoRecord)
+	collectSelectedRoutes := func(prefix patriciaDB.Prefix, item patriciaDB.Item) (err error) {
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		if routeInfoRecordList.selectedRouteProtocol == "INVALID" {
+			return nil
+		}
+		for _, routeInfoRecord := range routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol] {
+			dst := getVrfPrefixKey(routeInfoRecord.vrf, getRouteDstNet(routeInfoRecord).String())
+			routes[dst] = append(routes[dst], routeInfoRecord)
+		}
+		return nil
+	}
+	for _, rib := range r.vrfs {
+		rib.v4RouteInfoMap.Visit(collectSelectedRoutes)
+		rib.v6RouteInfoMap.Visit(collectSelectedRoutes)
+	}
+	return routes
+}
+
+/*
+   Next hops of the routes that can be used for forwarding, with the
+   reachability the next hop groups give them
+*/
+func (table *NextHopGroupTable) activeMembers(routeInfoRecords []RouteInfoRecord) []NextHopGroupMember {
+	members := make([]NextHopGroupMember, 0, len(routeInfoRecords))
+	for _, routeInfoRecord := range routeInfoRecords {
+		if member := table.newMember(routeInfoRecord); member.isReachable {
+			members = append(members, member)
+		}
+	}
+	sort.Sort(nextHopGroupMembers(members))
+	return members
+}
+
+/*
+   Routes the backend should hold for the selected routes of the RIB. A
+   destination without a reachable next hop is not in the FIB.
+*/
+func (table *NextHopGroupTable) expectedFIBRoutes(plugin FIBAuditPlugin, routes map[string][]RouteInfoRecord) map[string]*FIBAuditRoute {
+	expected := make(map[string]*FIBAuditRoute)
+	for _, routeInfoRecords := range routes {
+		members := table.activeMembers(routeInfoRecords)
+		if len(members) == 0 {
+			continue
+		}
+		route, ok := plugin.ExpectedFIBRoute(routeInfoRecords[0], members)
+		if !ok {
+			continue
+		}
+		expected[route.key()] = route
+	}
+	return expected
+}
+
+/*
+   Differences between the expected and the programmed routes, ordered by
+   destination
+*/
+func diffFIBRoutes(expected map[string]*FIBAuditRoute, programmed map[string]*FIBAuditRoute) []FIBAuditEntry {
+	keys := make([]string, 0, len(expected)+len(programmed))
+	for key, _ := range expected {
+		keys = append(keys, key)
+	}
+	for key, _ := range programmed {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	entries := make([]FIBAuditEntry, 0)
+	for _, key := range keys {
+		ribRoute, inRIB := expected[key]
+		fibRoute, inFIB := programmed[key]
+		entry := FIBAuditEntry{key: key}
+		if inRIB {
+			entry.vrf, entry.dst, entry.ribNextHops = getVrfName(ribRoute.vrf), ribRoute.dst.String(), ribRoute.nextHops
+		}
+		if inFIB {
+			entry.vrf, entry.dst, entry.fibNextHops = getVrfName(fibRoute.vrf), fibRoute.dst.String(), fibRoute.nextHops
+		}
+		if !inFIB {
+			entry.status = FIBAuditMissing
+		} else if !inRIB {
+			entry.status = FIBAuditExtra
+		} else if !equalFIBAuditNextHops(ribRoute.nextHops, fibRoute.nextHops) {
+			entry.status = FIBAuditMismatch
+		} else {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+/*
+   Brings the next hops of the destination in the next hop group table in line
+   with the routes of the RIB, the table programs the FIB for the next hops it
+   adds or deletes. Returns false when the table already had them.
+*/
+func (server *RIBDServer) syncNextHopGroupRoutes(dst string, routeInfoRecords []RouteInfoRecord) bool {
+	table := server.NextHopGroupTable
+	nextHops := make(map[string]RouteInfoRecord)
+	for _, routeInfoRecord := range routeInfoRecords {
+		nextHops[getRouteNextHopKey(routeInfoRecord)] = routeInfoRecord
+	}
+	changed := false
+	for key, routeInfoRecord := range nextHops {
+		if _, ok := table.routes[dst][key]; !ok {
+			server.addNextHopGroupRoute(routeInfoRecord)
+			changed = true
+		}
+	}
+	for key, routeInfoRecord := range table.routes[dst] {
+		if _, ok := nextHops[key]; !ok {
+			server.delNextHopGroupRoute(routeInfoRecord)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (server *RIBDServer) repairFIBRoute(plugin FIBAuditPlugin, entry FIBAuditEntry, routeInfoRecords []RouteInfoRecord, programmed *FIBAuditRoute) bool {
+	if server.syncNextHopGroupRoutes(entry.key, routeInfoRecords) {
+		return true
+	}
+	if entry.status == FIBAuditExtra {
+		plugin.RemoveFIBRoute(programmed)
+		return true
+	}
+	group, ok := server.NextHopGroupTable.routeGroups[entry.key]
+	if !ok {
+		return false
+	}
+	plugin.RepairFIBRoute(group.routes[entry.key], group, programmed)
+	return true
+}
+
+/*
+   Compares the routes programmed in the FIB with the selected routes and
+   their resolved next hops, and repairs the FIB when asked to. Each
+   difference is logged as a RIB event.
+*/
+func (server *RIBDServer) auditFIB(request FIBAuditRequest) {
+	repair := request.repair
+	result := FIBAuditResult{auditTime: time.Now(), repair: repair}
+	defer func() {
+		result.duration = time.Since(result.auditTime)
+		server.FIBAudit.setResult(result)
+	}()
+	plugin, ok := server.FIBPlugin.(FIBAuditPlugin)
+	if !ok {
+		result.err = errors.New("The FIB backend cannot be audited")
+		return
+	}
+	programmed, err := plugin.ReadFIBRoutes()
+	if err != nil {
+		logger.Err("auditFIB: failed to read the FIB, err:", err)
+		result.err = err
+		return
+	}
+	expected := server.NextHopGroupTable.expectedFIBRoutes(plugin, request.routes)
+	result.ribRoutes = len(expected)
+	result.fibRoutes = len(programmed)
+	result.entries = diffFIBRoutes(expected, programmed)
+	for idx := range result.entries {
+		entry := &result.entries[idx]
+		switch entry.status {
+		case FIBAuditMissing:
+			result.missing++
+		case FIBAuditExtra:
+			result.extra++
+		case FIBAuditMismatch:
+			result.mismatched++
+		}
+		if repair {
+			entry.repaired = server.repairFIBRoute(plugin, *entry, request.routes[entry.key], programmed[entry.key])
+			if entry.repaired {
+				result.repaired++
+			}
+		}
+		eventInfo := fmt.Sprint("FIB audit found ", entry.status, " route ", entry.dst, " in vrf ", entry.vrf,
+			" RIB next hops ", entry.ribNextHops, " FIB next hops ", entry.fibNextHops)
+		if entry.repaired {
+			eventInfo += ", repaired"
+		}
+		server.RIB.AddRouteEvent(RouteEventInfo{timeStamp: time.Now().String(), eventInfo: eventInfo})
+	}
+	if repair {
+		server.FIBPlugin.Flush()
+	}
+	logger.Info("auditFIB: ", result.ribRoutes, " RIB routes, ", result.fibRoutes, " FIB routes, missing ", result.missing,
+		" extra ", result.extra, " mismatched ", result.mismatched, " repaired ", result.repaired)
+}
+
+/*
+   Queues an audit of the FIB to the asicd server loop. The selected routes
+   are queued before the RIB read lock is released so that the audit comes
+   after the FIB updates of the routes it compares.
+*/
+func (server *RIBDServer) StartFIBAudit(repair bool) {
+	server.RIB.RLock()
+	defer server.RIB.RUnlock()
+	request := FIBAuditRequest{repair: repair, routes: server.RIB.selectedFIBRoutes()}
+	server.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: request, Op: defs.FIBAudit}
+}
+
+/*
+   Audits the FIB every FIBAudit.Interval once ribd accepts configuration
+*/
+func (server *RIBDServer) StartFIBAuditTimer() {
+	if server.FIBAudit.Interval <= 0 {
+		logger.Info("Periodic FIB audit disabled")
+		return
+	}
+	ticker := time.NewTicker(server.FIBAudit.Interval)
+	for range ticker.C {
+		if !server.AcceptConfig {
+			continue
+		}
+		server.StartFIBAudit(server.FIBAudit.Repair)
+	}
+}
+
+func (m RIBDServer) GetFIBAuditState() (*ribdInt.FIBAuditState, error) {
+	audit := m.FIBAudit
+	state := ribdInt.NewFIBAuditState()
+	audit.lock.Lock()
+	defer audit.lock.Unlock()
+	result := audit.last
+	state.IntervalSec = int32(audit.Interval / time.Second)
+	state.Repair = audit.Repair
+	state.Audits = audit.audits
+	if audit.audits == 0 {
+		return state, nil
+	}
+	state.LastAuditTime = result.auditTime.String()
+	state.DurationUsec = int64(result.duration / time.Microsecond)
+	state.LastAuditRepair = result.repair
+	state.RIBRoutes = int32(result.ribRoutes)
+	state.FIBRoutes = int32(result.fibRoutes)
+	state.Missing = int32(result.missing)
+	state.Extra = int32(result.extra)
+	state.Mismatched = int32(result.mismatched)
+	state.Repaired = int32(result.repaired)
+	if result.err != nil {
+		state.Error = result.err.Error()
+	}
+	state.Entries = make([]*ribdInt.FIBAuditEntryState, 0, len(result.entries))
+	for _, entry := range result.entries {
+		state.Entries = append(state.Entries, &ribdInt.FIBAuditEntryState{
+			Vrf:           entry.vrf,
+			DestinationNw: entry.dst,
+			Status:        entry.status,
+			RIBNextHops:   entry.ribNextHops,
+			FIBNextHops:   entry.fibNextHops,
+			Repaired:      entry.repaired,
+		})
+	}
+	return state, nil
+}
@@ -0,0 +1,196 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdFIBAuditApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"strings"
+	"testing"
+	"utils/policy"
+)
+
+type testFIBAuditPlugin struct {
+	testFIBPlugin
+	programmed map[string]*FIBAuditRoute
+	repaired   int
+	removed    int
+}
+
+func (plugin *testFIBAuditPlugin) ReadFIBRoutes() (map[string]*FIBAuditRoute, error) {
+	routes := make(map[string]*FIBAuditRoute)
+	for key, route := range plugin.programmed {
+		routes[key] = route
+	}
+	return routes, nil
+}
+func (plugin *testFIBAuditPlugin) ExpectedFIBRoute(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) (*FIBAuditRoute, bool) {
+	nextHops := make([]string, 0)
+	for _, member := range members {
+		nextHops = append(nextHops, member.routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
+	}
+	return &FIBAuditRoute{vrf: routeInfoRecord.vrf, ipType: routeInfoRecord.ipType, dst: getRouteDstNet(routeInfoRecord), nextHops: sortFIBAuditNextHops(nextHops)}, true
+}
+func (plugin *testFIBAuditPlugin) RepairFIBRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup, programmed *FIBAuditRoute) {
+	route, _ := plugin.ExpectedFIBRoute(routeInfoRecord, group.ActiveMembers())
+	plugin.programmed[route.key()] = route
+	plugin.repaired++
+}
+func (plugin *testFIBAuditPlugin) RemoveFIBRoute(programmed *FIBAuditRoute) {
+	delete(plugin.programmed, programmed.key())
+	plugin.removed++
+}
+
+func buildTestFIBAuditRoute(destNet string, nextHops ...string) *FIBAuditRoute {
+	_, dst, _ := net.ParseCIDR(destNet)
+	return &FIBAuditRoute{vrf: DefaultVrf, dst: dst, nextHops: sortFIBAuditNextHops(nextHops)}
+}
+
+/*
+   Adds the routes to the next hop group table and to the selected routes the
+   audit compares the FIB with
+*/
+func addTestFIBAuditRoutes(testServer *RIBDServer, routes map[string][]RouteInfoRecord, routeInfoRecords ...RouteInfoRecord) {
+	for _, routeInfoRecord := range routeInfoRecords {
+		dst := getVrfPrefixKey(routeInfoRecord.vrf, getRouteDstNet(routeInfoRecord).String())
+		routes[dst] = append(routes[dst], routeInfoRecord)
+		testServer.addNextHopGroupRoute(routeInfoRecord)
+	}
+}
+
+func TestFIBAudit(t *testing.T) {
+	fmt.Println("****TestFIBAudit****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	plugin := &testFIBAuditPlugin{programmed: make(map[string]*FIBAuditRoute)}
+	testServer := &RIBDServer{
+		FIBPlugin:         plugin,
+		NextHopGroupTable: NewNextHopGroupTable(),
+		FIBAudit:          NewFIBAudit(DefaultFIBAuditInterval, false),
+		RIB:               NewRIB(),
+	}
+	routes := make(map[string][]RouteInfoRecord)
+	addTestFIBAuditRoutes(testServer, routes,
+		buildTestNextHopGroupRouteInfoRecord("40.0.1.0", "11.1.10.2", 1, ""),
+		buildTestNextHopGroupRouteInfoRecord("40.0.2.0", "11.1.10.2", 1, ""),
+		buildTestNextHopGroupRouteInfoRecord("40.0.2.0", "12.1.10.2", 2, ""),
+		buildTestNextHopGroupRouteInfoRecord("40.0.3.0", "11.1.10.2", 1, ""))
+	//40.0.3.0/24 is missing, 40.0.2.0/24 lost a next hop and 50.0.1.0/24 was not deleted
+	plugin.programmed[getVrfPrefixKey(DefaultVrf, "40.0.1.0/24")] = buildTestFIBAuditRoute("40.0.1.0/24", "11.1.10.2")
+	plugin.programmed[getVrfPrefixKey(DefaultVrf, "40.0.2.0/24")] = buildTestFIBAuditRoute("40.0.2.0/24", "11.1.10.2")
+	plugin.programmed[getVrfPrefixKey(DefaultVrf, "50.0.1.0/24")] = buildTestFIBAuditRoute("50.0.1.0/24", "13.1.10.2")
+
+	testServer.auditFIB(FIBAuditRequest{repair: false, routes: routes})
+	state, _ := testServer.GetFIBAuditState()
+	for _, entry := range state.Entries {
+		fmt.Println("entry:", entry)
+	}
+	if state.Audits != 1 || state.RIBRoutes != 3 || state.FIBRoutes != 3 || state.Missing != 1 || state.Extra != 1 || state.Mismatched != 1 || state.Repaired != 0 {
+		t.Error("Unexpected audit state ", state)
+		return
+	}
+	if len(state.Entries) != 3 || state.Entries[0].DestinationNw != "40.0.2.0/24" || state.Entries[0].Status != FIBAuditMismatch ||
+		state.Entries[1].Status != FIBAuditMissing || state.Entries[2].Status != FIBAuditExtra || state.Entries[2].Vrf != DefaultVrf {
+		t.Error("Unexpected audit entries ", state.Entries)
+	}
+	if plugin.repaired != 0 || plugin.removed != 0 {
+		t.Error("Audit without repair updated the FIB")
+	}
+	if events := testServer.RIB.RouteEvents(); len(events) != 3 || !strings.Contains(events[0].eventInfo, "mismatch") {
+		t.Error("Unexpected audit events ", events)
+	}
+
+	testServer.auditFIB(FIBAuditRequest{repair: true, routes: routes})
+	state, _ = testServer.GetFIBAuditState()
+	if state.Repaired != 3 || plugin.repaired != 2 || plugin.removed != 1 || plugin.flushes != 1 || !state.LastAuditRepair {
+		t.Error("Unexpected repair, state ", state, " plugin ", plugin)
+	}
+	testServer.auditFIB(FIBAuditRequest{repair: false, routes: routes})
+	state, _ = testServer.GetFIBAuditState()
+	if state.Audits != 3 || len(state.Entries) != 0 || state.FIBRoutes != 3 {
+		t.Error("FIB still differs from the RIB after repair ", state)
+	}
+
+	//a destination without a reachable next hop is not in the FIB
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: 1}, false)
+	testServer.auditFIB(FIBAuditRequest{repair: false, routes: routes})
+	state, _ = testServer.GetFIBAuditState()
+	if state.RIBRoutes != 1 || state.Extra != 2 || state.Mismatched != 1 {
+		t.Error("Unexpected audit state after interface down ", state)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestFIBAuditRIBRoutes(t *testing.T) {
+	fmt.Println("****TestFIBAuditRIBRoutes****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	savedHandler := RouteServiceHandler
+	savedPolicyEngineDB := PolicyEngineDB
+	defer func() {
+		RouteServiceHandler = savedHandler
+		PolicyEngineDB = savedPolicyEngineDB
+	}()
+	plugin := &testFIBAuditPlugin{programmed: make(map[string]*FIBAuditRoute)}
+	testServer := &RIBDServer{
+		FIBPlugin:         plugin,
+		NextHopGroupTable: NewNextHopGroupTable(),
+		FIBAudit:          NewFIBAudit(DefaultFIBAuditInterval, false),
+		RIB:               initTestRIB(),
+		DBRouteCh:         make(chan RIBdServerConfig, 100),
+		ArpdRouteCh:       make(chan RIBdServerConfig, 100),
+		AsicdRouteCh:      make(chan RIBdServerConfig, 100),
+	}
+	RouteServiceHandler = testServer
+	PolicyEngineDB = policy.NewPolicyEngineDB(logger)
+	PolicyEngineDB.SetDefaultExportPolicyActionFunc(defaultExportPolicyEngineActionFunc)
+	createTestRIBRoute(t, testServer.RIB, "11.1.10.0", "0.0.0.0", 1, defs.CONNECTED)
+	createTestRIBRoute(t, testServer.RIB, "40.0.1.0", "11.1.10.2", 1, defs.STATIC)
+
+	//the FIB updates of the routes are not applied, the table does not have them
+	testServer.StartFIBAudit(true)
+	var request FIBAuditRequest
+	for len(testServer.AsicdRouteCh) > 0 {
+		if route := <-testServer.AsicdRouteCh; route.Op == defs.FIBAudit {
+			request = route.OrigConfigObject.(FIBAuditRequest)
+		}
+	}
+	if len(request.routes) != 2 || !request.repair {
+		t.Error("Unexpected selected routes ", request.routes)
+		return
+	}
+	testServer.auditFIB(request)
+	state, _ := testServer.GetFIBAuditState()
+	if state.RIBRoutes != 2 || state.Missing != 2 || state.Repaired != 2 {
+		t.Error("Unexpected audit state ", state)
+	}
+	if len(testServer.NextHopGroupTable.routeGroups) != 2 || plugin.routeUpdates != 2 || plugin.repaired != 0 {
+		t.Error("Missing routes not repaired through the next hop group table, plugin ", plugin)
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,240 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdFIBQueue.go
+package server
+
+import (
+	defs "l3/rib/ribdCommonDefs"
+	"ribdInt"
+	"sync"
+	"time"
+)
+
+/*
+   Number of route updates the FIB queue takes before it is flushed to the
+   FIB backend, also the size of AsicdRouteCh. Route selection blocks on
+   AsicdRouteCh once both are full, which slows the route updates down to the
+   speed of the FIB.
+*/
+const FIBQueueMaxDepth = 10000
+
+type fibQueueEntry struct {
+	op              defs.OpType //defs.Add or defs.Del
+	routeInfoRecord RouteInfoRecord
+	installed       bool //the route was in the FIB when it was first queued
+	queuedTime      time.Time
+}
+
+/*
+   Coalescing queue of the route updates between route selection and the
+   FIB backend. The updates of a route (destination and next hop) replace
+   each other, and a route added and deleted before the queue is flushed is
+   never sent to the FIB. The queue is owned by the asicd server loop, the
+   statistics are also read by the RPC handlers.
+*/
+type FIBQueue struct {
+	entries    map[string]*fibQueueEntry
+	order      []string //keys in the order they were first queued
+	maxDepth   int
+	statsLock  sync.Mutex
+	stats      FIBQueueStats
+	programmed map[string]time.Time //time each route in the FIB was sent to it, guarded by statsLock
+}
+
+type FIBQueueStats struct {
+	depth            int
+	highWatermark    int
+	batches          int64
+	routesProgrammed int64
+	routesCoalesced  int64
+	lastBatchSize    int
+	lastLatency      time.Duration //latency of the oldest route of the last batch
+	maxLatency       time.Duration
+	totalLatency     time.Duration
+}
+
+func NewFIBQueue(maxDepth int) *FIBQueue {
+	return &FIBQueue{
+		entries:    make(map[string]*fibQueueEntry),
+		order:      make([]string, 0),
+		maxDepth:   maxDepth,
+		programmed: make(map[string]time.Time),
+	}
+}
+
+func (queue *FIBQueue) Depth() int {
+	return len(queue.entries)
+}
+
+func (queue *FIBQueue) IsFull() bool {
+	return len(queue.entries) >= queue.maxDepth
+}
+
+func (queue *FIBQueue) updateDepth(coalesced int) {
+	queue.statsLock.Lock()
+	queue.stats.depth = len(queue.entries)
+	if queue.stats.depth > queue.stats.highWatermark {
+		queue.stats.highWatermark = queue.stats.depth
+	}
+	queue.stats.routesCoalesced += int64(coalesced)
+	queue.statsLock.Unlock()
+}
+
+/*
+   Queues the update of the route. table is used to find out if the route is
+   already in the FIB.
+*/
+func getFIBQueueKey(routeInfoRecord RouteInfoRecord) (dst string, nextHopKey string, key string) {
+	dst = getVrfPrefixKey(routeInfoRecord.vrf, getRouteDstNet(routeInfoRecord).String())
+	nextHopKey = getRouteNextHopKey(routeInfoRecord)
+	return dst, nextHopKey, dst + " " + nextHopKey
+}
+
+func (queue *FIBQueue) Add(table *NextHopGroupTable, routeInfoRecord RouteInfoRecord, op defs.OpType, queuedTime time.Time) {
+	dst, nextHopKey, key := getFIBQueueKey(routeInfoRecord)
+	entry, ok := queue.entries[key]
+	if !ok {
+		_, installed := table.routes[dst][nextHopKey]
+		queue.entries[key] = &fibQueueEntry{
+			op:              op,
+			routeInfoRecord: routeInfoRecord,
+			installed:       installed,
+			queuedTime:      queuedTime,
+		}
+		queue.order = append(queue.order, key)
+		queue.updateDepth(0)
+		return
+	}
+	if op == defs.Del && !entry.installed {
+		logger.Debug("FIBQueue: ", key, " deleted before it was installed")
+		delete(queue.entries, key)
+		queue.updateDepth(2)
+		return
+	}
+	//the entry keeps the time of the first update, the FIB is behind since then
+	entry.op = op
+	entry.routeInfoRecord = routeInfoRecord
+	queue.updateDepth(1)
+}
+
+/*
+   Applies the queued updates to the next hop groups in the order the routes
+   were first queued and has the FIB backend send them
+*/
+func (server *RIBDServer) flushFIBQueue() {
+	queue := server.FIBQueue
+	queuedTimes := make([]time.Time, 0, len(queue.entries))
+	added := make([]string, 0)
+	deleted := make([]string, 0)
+	for _, key := range queue.order {
+		entry, ok := queue.entries[key]
+		if !ok {
+			//coalesced, or queued again after being coalesced
+			continue
+		}
+		delete(queue.entries, key)
+		if entry.op == defs.Add {
+			server.addNextHopGroupRoute(entry.routeInfoRecord)
+			added = append(added, key)
+		} else {
+			server.delNextHopGroupRoute(entry.routeInfoRecord)
+			deleted = append(deleted, key)
+		}
+		queuedTimes = append(queuedTimes, entry.queuedTime)
+	}
+	queue.order = queue.order[:0]
+	server.FIBPlugin.Flush()
+	if len(queuedTimes) == 0 {
+		return
+	}
+	now := time.Now()
+	queue.statsLock.Lock()
+	defer queue.statsLock.Unlock()
+	for _, key := range added {
+		queue.programmed[key] = now
+	}
+	for _, key := range deleted {
+		delete(queue.programmed, key)
+	}
+	queue.stats.depth = len(queue.entries)
+	queue.stats.batches++
+	queue.stats.lastBatchSize = len(queuedTimes)
+	queue.stats.lastLatency = 0
+	for _, queuedTime := range queuedTimes {
+		latency := now.Sub(queuedTime)
+		if latency > queue.stats.lastLatency {
+			queue.stats.lastLatency = latency
+		}
+		queue.stats.totalLatency += latency
+		queue.stats.routesProgrammed++
+	}
+	if queue.stats.lastLatency > queue.stats.maxLatency {
+		queue.stats.maxLatency = queue.stats.lastLatency
+	}
+	logger.Debug("flushFIBQueue: ", len(queuedTimes), " routes, latency ", queue.stats.lastLatency)
+}
+
+/*
+   Time the route was sent to the FIB, found is false while the route is not in the FIB
+*/
+func (queue *FIBQueue) ProgrammedTime(routeInfoRecord RouteInfoRecord) (programmedTime time.Time, found bool) {
+	_, _, key := getFIBQueueKey(routeInfoRecord)
+	queue.statsLock.Lock()
+	defer queue.statsLock.Unlock()
+	programmedTime, found = queue.programmed[key]
+	return programmedTime, found
+}
+
+/*
+   Queues the route update to the FIB with the time it was made, the FIB
+   latency includes the time spent in AsicdRouteCh
+*/
+func queueFIBRoute(routeInfoRecord RouteInfoRecord, op defs.OpType) {
+	RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{
+		OrigConfigObject: routeInfoRecord,
+		Op:               op,
+		AdditionalParams: time.Now(),
+	}
+}
+
+func (m RIBDServer) GetFIBQueueState() (*ribdInt.FIBQueueState, error) {
+	queue := m.FIBQueue
+	state := ribdInt.NewFIBQueueState()
+	queue.statsLock.Lock()
+	defer queue.statsLock.Unlock()
+	state.QueueDepth = int32(queue.stats.depth)
+	state.MaxQueueDepth = int32(queue.maxDepth)
+	state.HighWatermark = int32(queue.stats.highWatermark)
+	state.ChannelDepth = int32(len(m.AsicdRouteCh))
+	state.Batches = queue.stats.batches
+	state.RoutesProgrammed = queue.stats.routesProgrammed
+	state.RoutesCoalesced = queue.stats.routesCoalesced
+	state.LastBatchSize = int32(queue.stats.lastBatchSize)
+	state.LastLatencyUsec = int64(queue.stats.lastLatency / time.Microsecond)
+	state.MaxLatencyUsec = int64(queue.stats.maxLatency / time.Microsecond)
+	if queue.stats.routesProgrammed > 0 {
+		state.AvgLatencyUsec = int64(queue.stats.totalLatency/time.Microsecond) / queue.stats.routesProgrammed
+	}
+	return state, nil
+}
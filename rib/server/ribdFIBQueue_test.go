@@ -0,0 +1,94 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdFIBQueue_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"testing"
+	"time"
+)
+
+func TestFIBQueue(t *testing.T) {
+	fmt.Println("****TestFIBQueue****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	plugin := &testFIBPlugin{}
+	testServer := &RIBDServer{
+		FIBPlugin:         plugin,
+		NextHopGroupTable: NewNextHopGroupTable(),
+		FIBQueue:          NewFIBQueue(4),
+	}
+	queue := testServer.FIBQueue
+	table := testServer.NextHopGroupTable
+	queuedTime := time.Now()
+
+	installed := buildTestNextHopGroupRouteInfoRecord("40.0.1.0", "11.1.10.2", 1, "")
+	testServer.addNextHopGroupRoute(installed)
+	plugin.routeUpdates = 0
+
+	//a new route added and deleted never reaches the FIB
+	route := buildTestNextHopGroupRouteInfoRecord("40.0.2.0", "11.1.10.2", 1, "")
+	queue.Add(table, route, defs.Add, queuedTime)
+	queue.Add(table, route, defs.Del, queuedTime)
+	if queue.Depth() != 0 {
+		t.Error("Route added and deleted still queued, depth ", queue.Depth())
+	}
+	//an installed route updated and deleted is deleted
+	queue.Add(table, installed, defs.Add, queuedTime)
+	queue.Add(table, installed, defs.Del, queuedTime)
+	//a route updated twice is sent once
+	queue.Add(table, route, defs.Add, queuedTime)
+	queue.Add(table, route, defs.Add, queuedTime)
+	if queue.Depth() != 2 || queue.IsFull() {
+		t.Error("Unexpected queue depth ", queue.Depth())
+	}
+	testServer.flushFIBQueue()
+	fmt.Println("plugin:", plugin, " stats:", queue.stats)
+	if queue.Depth() != 0 || len(queue.order) != 0 {
+		t.Error("Queue not empty after flush")
+	}
+	if plugin.routeUpdates != 1 || plugin.routesDeleted != 1 || plugin.flushes != 1 {
+		t.Error("Unexpected FIB updates ", plugin)
+	}
+	if _, ok := table.routes[getVrfPrefixKey("", "40.0.1.0/24")]; ok {
+		t.Error("Deleted route still in the FIB")
+	}
+	if queue.stats.routesProgrammed != 2 || queue.stats.routesCoalesced != 4 || queue.stats.batches != 1 || queue.stats.lastBatchSize != 2 {
+		t.Error("Unexpected queue stats ", queue.stats)
+	}
+	if queue.stats.lastLatency < time.Since(queuedTime)-time.Second || queue.stats.maxLatency != queue.stats.lastLatency {
+		t.Error("Unexpected queue latency ", queue.stats)
+	}
+
+	for i := 0; i < 4; i++ {
+		queue.Add(table, buildTestNextHopGroupRouteInfoRecord(fmt.Sprint("40.0.1", i, ".0"), "11.1.10.2", 1, ""), defs.Add, queuedTime)
+	}
+	if !queue.IsFull() || queue.stats.highWatermark != 4 {
+		t.Error("Queue not full, depth ", queue.Depth(), " high watermark ", queue.stats.highWatermark)
+	}
+	fmt.Println("***********************************")
+}
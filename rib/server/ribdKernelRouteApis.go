@@ -0,0 +1,394 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdKernelRouteApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink"
+)
+
+/*
+   Time the kernel route import waits before it subscribes again when the
+   netlink subscription fails
+*/
+const KernelRouteResubscribeTime = 5 * time.Second
+
+/*
+   Routes added to the kernel by other agents, imported into the RIB as
+   KERNEL routes. The route of a kernel table is sent to the route loop as
+   one route per next hop, like a thrift route create, and withdrawn the same
+   way when it is removed from the kernel. The routes ribd installs itself
+   (protocol RTPROT_RIBD) and the connected routes of the kernel are not
+   imported.
+*/
+type KernelRouteImporter struct {
+	server   *RIBDServer
+	handle   *netlink.Handle
+	tables   map[int]bool
+	imported map[string]*kernelRoute
+	//name of the kernel link, the next hop interface of the ribd route
+	linkName func(linkIndex int) (string, bool)
+}
+
+/*
+   Kernel route as it was imported, one ribd route per next hop
+*/
+type kernelRoute struct {
+	key      string
+	ipType   defs.IPType
+	cost     int
+	nextHops []string
+	v4Routes []*ribd.IPv4Route
+	v6Routes []*ribd.IPv6Route
+}
+
+/*
+   Parses the comma separated list of kernel tables of the -kerneltables flag
+*/
+func ParseKernelRouteTables(tables string) ([]int, error) {
+	tableIds := make([]int, 0)
+	for _, table := range strings.Split(tables, ",") {
+		table = strings.TrimSpace(table)
+		if table == "" {
+			continue
+		}
+		tableId, err := strconv.Atoi(table)
+		if err != nil || tableId <= syscall.RT_TABLE_UNSPEC || tableId >= syscall.RT_TABLE_MAX {
+			return nil, errors.New(fmt.Sprintln("Invalid kernel route table ", table))
+		}
+		tableIds = append(tableIds, tableId)
+	}
+	return tableIds, nil
+}
+
+func NewKernelRouteImporter(server *RIBDServer, tables []int) (*KernelRouteImporter, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("No kernel route table to import")
+	}
+	handle, err := netlink.NewHandle()
+	if err != nil {
+		return nil, err
+	}
+	importer := &KernelRouteImporter{
+		server:   server,
+		handle:   handle,
+		tables:   make(map[int]bool),
+		imported: make(map[string]*kernelRoute),
+	}
+	for _, table := range tables {
+		importer.tables[table] = true
+	}
+	importer.linkName = func(linkIndex int) (string, bool) {
+		link, err := handle.LinkByIndex(linkIndex)
+		if err != nil {
+			return "", false
+		}
+		return link.Attrs().Name, true
+	}
+	return importer, nil
+}
+
+/*
+   Destination of the kernel route, the kernel reports a default route
+   without one
+*/
+func getKernelRouteDst(route *netlink.Route) *net.IPNet {
+	if route.Dst != nil {
+		return route.Dst
+	}
+	gw := route.Gw
+	if gw == nil && len(route.MultiPath) > 0 {
+		gw = route.MultiPath[0].Gw
+	}
+	if gw != nil && gw.To4() == nil {
+		_, dst, _ := net.ParseCIDR("::/0")
+		return dst
+	}
+	_, dst, _ := net.ParseCIDR("0.0.0.0/0")
+	return dst
+}
+
+func (importer *KernelRouteImporter) isImported(route *netlink.Route) bool {
+	if !importer.tables[route.Table] || route.Protocol == RTPROT_RIBD || route.Protocol == syscall.RTPROT_KERNEL {
+		return false
+	}
+	if route.Type != syscall.RTN_UNICAST && route.Type != syscall.RTN_BLACKHOLE {
+		return false
+	}
+	dst := getKernelRouteDst(route)
+	return !dst.IP.IsLinkLocalUnicast() && !dst.IP.IsMulticast()
+}
+
+/*
+   Builds the ribd routes of the kernel route. Only the next hops with a
+   gateway on an interface known to ribd can be imported, a route through an
+   interface without a gateway is left out.
+*/
+func (importer *KernelRouteImporter) buildKernelRoute(route *netlink.Route) (*kernelRoute, bool) {
+	dst := getKernelRouteDst(route)
+	kr := &kernelRoute{
+		key:    netlinkRouteKey(route.Table, dst),
+		ipType: defs.IPv4,
+		cost:   route.Priority,
+	}
+	mask := net.IP(dst.Mask)
+	if dst.IP.To4() == nil {
+		kr.ipType = defs.IPv6
+	} else {
+		mask = net.IP(net.IPMask(dst.Mask)[len(dst.Mask)-net.IPv4len:])
+	}
+	nextHops := make([]*ribd.NextHopInfo, 0)
+	if route.Type == syscall.RTN_BLACKHOLE {
+		nextHops = append(nextHops, nil)
+		kr.nextHops = []string{"blackhole"}
+	} else {
+		paths := route.MultiPath
+		if len(paths) == 0 {
+			paths = []*netlink.NexthopInfo{&netlink.NexthopInfo{Gw: route.Gw, LinkIndex: route.LinkIndex}}
+		}
+		for _, path := range paths {
+			if path.Gw == nil || path.Gw.IsUnspecified() {
+				logger.Debug("buildKernelRoute: ", kr.key, " next hop without a gateway on link ", path.LinkIndex, " not imported")
+				continue
+			}
+			nextHop := &ribd.NextHopInfo{NextHopIp: path.Gw.String()}
+			if name, ok := importer.linkName(path.LinkIndex); ok {
+				nextHop.NextHopIntRef = name
+			}
+			nextHops = append(nextHops, nextHop)
+			kr.nextHops = append(kr.nextHops, nextHop.NextHopIp+"%"+nextHop.NextHopIntRef)
+		}
+	}
+	for _, nextHop := range nextHops {
+		if kr.ipType == defs.IPv4 {
+			cfg := &ribd.IPv4Route{
+				DestinationNw: dst.IP.String(),
+				NetworkMask:   mask.String(),
+				Protocol:      "KERNEL",
+				Cost:          int32(route.Priority),
+				NullRoute:     nextHop == nil,
+			}
+			if nextHop != nil {
+				cfg.NextHop = []*ribd.NextHopInfo{nextHop}
+			}
+			kr.v4Routes = append(kr.v4Routes, cfg)
+		} else {
+			cfg := &ribd.IPv6Route{
+				DestinationNw: dst.IP.String(),
+				NetworkMask:   mask.String(),
+				Protocol:      "KERNEL",
+				Cost:          int32(route.Priority),
+				NullRoute:     nextHop == nil,
+			}
+			if nextHop != nil {
+				cfg.NextHop = []*ribd.NextHopInfo{nextHop}
+			}
+			kr.v6Routes = append(kr.v6Routes, cfg)
+		}
+	}
+	return kr, len(nextHops) > 0
+}
+
+func (kr *kernelRoute) equal(other *kernelRoute) bool {
+	if kr.cost != other.cost || len(kr.nextHops) != len(other.nextHops) {
+		return false
+	}
+	for idx := range kr.nextHops {
+		if kr.nextHops[idx] != other.nextHops[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+   Validates the routes of the kernel route like a thrift create and queues
+   them to the route loop. The routes that fail validation are left out,
+   false is returned when none is left.
+*/
+func (importer *KernelRouteImporter) install(kr *kernelRoute) bool {
+	server := importer.server
+	v4Routes := make([]*ribd.IPv4Route, 0, len(kr.v4Routes))
+	v6Routes := make([]*ribd.IPv6Route, 0, len(kr.v6Routes))
+	server.RIB.RLock()
+	for _, cfg := range kr.v4Routes {
+		if err := server.RouteConfigValidationCheck(cfg, "add"); err != nil {
+			logger.Info("Kernel route ", kr.key, " next hop ", cfg.NextHop, " not imported, err:", err)
+			continue
+		}
+		v4Routes = append(v4Routes, cfg)
+	}
+	for _, cfg := range kr.v6Routes {
+		if err := server.IPv6RouteConfigValidationCheck(cfg, "add"); err != nil {
+			logger.Info("Kernel route ", kr.key, " next hop ", cfg.NextHop, " not imported, err:", err)
+			continue
+		}
+		v6Routes = append(v6Routes, cfg)
+	}
+	server.RIB.RUnlock()
+	kr.v4Routes, kr.v6Routes = v4Routes, v6Routes
+	for _, cfg := range kr.v4Routes {
+		server.RouteConfCh <- RIBdServerConfig{OrigConfigObject: copyKernelIPv4Route(cfg), Op: defs.Add}
+	}
+	for _, cfg := range kr.v6Routes {
+		server.RouteConfCh <- RIBdServerConfig{OrigConfigObject: copyKernelIPv6Route(cfg), Op: defs.Addv6}
+	}
+	return len(kr.v4Routes)+len(kr.v6Routes) > 0
+}
+
+func (importer *KernelRouteImporter) withdraw(kr *kernelRoute) {
+	server := importer.server
+	for _, cfg := range kr.v4Routes {
+		server.RouteConfCh <- RIBdServerConfig{OrigConfigObject: copyKernelIPv4Route(cfg), Op: defs.Del}
+	}
+	for _, cfg := range kr.v6Routes {
+		server.RouteConfCh <- RIBdServerConfig{OrigConfigObject: copyKernelIPv6Route(cfg), Op: defs.Delv6}
+	}
+}
+
+/*
+   The route loop rewrites the next hops of the route it is given, each
+   create and delete gets a copy of the imported route
+*/
+func copyKernelIPv4Route(cfg *ribd.IPv4Route) *ribd.IPv4Route {
+	route := *cfg
+	route.NextHop = make([]*ribd.NextHopInfo, 0, len(cfg.NextHop))
+	for _, nextHop := range cfg.NextHop {
+		nh := *nextHop
+		route.NextHop = append(route.NextHop, &nh)
+	}
+	return &route
+}
+
+func copyKernelIPv6Route(cfg *ribd.IPv6Route) *ribd.IPv6Route {
+	route := *cfg
+	route.NextHop = make([]*ribd.NextHopInfo, 0, len(cfg.NextHop))
+	for _, nextHop := range cfg.NextHop {
+		nh := *nextHop
+		route.NextHop = append(route.NextHop, &nh)
+	}
+	return &route
+}
+
+/*
+   Imports the kernel route when it is added or changed and withdraws it
+   when it is deleted
+*/
+func (importer *KernelRouteImporter) update(route *netlink.Route, add bool) {
+	if !importer.isImported(route) {
+		return
+	}
+	key := netlinkRouteKey(route.Table, getKernelRouteDst(route))
+	old, found := importer.imported[key]
+	var kr *kernelRoute
+	if add {
+		kr, add = importer.buildKernelRoute(route)
+	}
+	if found && add && old.equal(kr) {
+		return
+	}
+	if found {
+		logger.Info("Withdrawing kernel route ", key)
+		importer.withdraw(old)
+		delete(importer.imported, key)
+	}
+	if add && importer.install(kr) {
+		logger.Info("Imported kernel route ", key, " next hops ", kr.nextHops)
+		importer.imported[key] = kr
+	}
+}
+
+/*
+   Imports the routes of the kernel tables and withdraws the imported routes
+   that are no longer in the kernel
+*/
+func (importer *KernelRouteImporter) sync() error {
+	current := make(map[string]bool)
+	for table, _ := range importer.tables {
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			routes, err := importer.handle.RouteListFiltered(family, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+			if err != nil {
+				return err
+			}
+			for idx := range routes {
+				if !importer.isImported(&routes[idx]) {
+					continue
+				}
+				current[netlinkRouteKey(table, getKernelRouteDst(&routes[idx]))] = true
+				importer.update(&routes[idx], true)
+			}
+		}
+	}
+	for key, kr := range importer.imported {
+		if !current[key] {
+			logger.Info("Withdrawing kernel route ", key, " removed while not watched")
+			importer.withdraw(kr)
+			delete(importer.imported, key)
+		}
+	}
+	logger.Info("Kernel route import: ", len(importer.imported), " routes imported")
+	return nil
+}
+
+/*
+   Watches the kernel tables and keeps the KERNEL routes of the RIB in sync
+   with them. The tables are read again each time the netlink subscription
+   is set up, so the changes missed while it was down are not lost.
+*/
+func (server *RIBDServer) StartKernelRouteImport() {
+	importer := server.KernelRouteImporter
+	logger.Info("Starting the kernel route import of tables ", importer.tables)
+	for {
+		updates := make(chan netlink.RouteUpdate, 1000)
+		done := make(chan struct{})
+		err := netlink.RouteSubscribeWithOptions(updates, done, netlink.RouteSubscribeOptions{
+			ErrorCallback: func(err error) {
+				logger.Err("Kernel route subscription failed, err:", err)
+			},
+		})
+		if err != nil {
+			logger.Err("Failed to subscribe to the kernel routes, err:", err)
+			time.Sleep(KernelRouteResubscribeTime)
+			continue
+		}
+		if err = importer.sync(); err != nil {
+			logger.Err("Failed to read the kernel routes, err:", err)
+		}
+		for update := range updates {
+			importer.update(&update.Route, update.Type == syscall.RTM_NEWROUTE)
+		}
+		close(done)
+		time.Sleep(KernelRouteResubscribeTime)
+	}
+}
@@ -0,0 +1,130 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdKernelRouteApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"syscall"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestParseKernelRouteTables(t *testing.T) {
+	fmt.Println("****TestParseKernelRouteTables****")
+	tables, err := ParseKernelRouteTables("254, 100,")
+	if err != nil || len(tables) != 2 || tables[0] != 254 || tables[1] != 100 {
+		t.Error("Unexpected kernel tables ", tables, " err ", err)
+	}
+	for _, invalid := range []string{"0", "main", "300"} {
+		if _, err := ParseKernelRouteTables(invalid); err == nil {
+			t.Error("Kernel table ", invalid, " not rejected")
+		}
+	}
+	fmt.Println("***********************************")
+}
+
+func TestKernelRouteImport(t *testing.T) {
+	fmt.Println("****TestKernelRouteImport****")
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	testServer := &RIBDServer{RIB: initTestRIB(), RouteConfCh: make(chan RIBdServerConfig, 10)}
+	RouteServiceHandler = testServer
+	importer := &KernelRouteImporter{
+		server:   testServer,
+		tables:   map[int]bool{syscall.RT_TABLE_MAIN: true},
+		imported: make(map[string]*kernelRoute),
+		linkName: func(linkIndex int) (string, bool) {
+			return fmt.Sprint("eth", linkIndex), true
+		},
+	}
+	_, dst, _ := net.ParseCIDR("40.0.1.0/24")
+	route := netlink.Route{
+		Dst:      dst,
+		Table:    syscall.RT_TABLE_MAIN,
+		Protocol: syscall.RTPROT_BOOT,
+		Type:     syscall.RTN_UNICAST,
+		MultiPath: []*netlink.NexthopInfo{
+			&netlink.NexthopInfo{Gw: net.ParseIP("11.1.10.2"), LinkIndex: 1},
+			&netlink.NexthopInfo{LinkIndex: 2},
+		},
+	}
+	kr, ok := importer.buildKernelRoute(&route)
+	if !ok || kr.ipType != defs.IPv4 || len(kr.v4Routes) != 1 || kr.v4Routes[0].NetworkMask != "255.255.255.0" ||
+		kr.v4Routes[0].NextHop[0].NextHopIp != "11.1.10.2" || kr.v4Routes[0].NextHop[0].NextHopIntRef != "eth1" || kr.v4Routes[0].Protocol != "KERNEL" {
+		t.Error("Unexpected kernel route ", kr)
+	}
+	for _, skipped := range []netlink.Route{
+		netlink.Route{Dst: dst, Table: 100, Type: syscall.RTN_UNICAST},
+		netlink.Route{Dst: dst, Table: syscall.RT_TABLE_MAIN, Protocol: RTPROT_RIBD, Type: syscall.RTN_UNICAST},
+		netlink.Route{Dst: dst, Table: syscall.RT_TABLE_MAIN, Protocol: syscall.RTPROT_KERNEL, Type: syscall.RTN_UNICAST},
+		netlink.Route{Dst: dst, Table: syscall.RT_TABLE_MAIN, Type: syscall.RTN_LOCAL},
+	} {
+		if importer.isImported(&skipped) {
+			t.Error("Kernel route ", skipped, " should not be imported")
+		}
+	}
+
+	//the next hop interface is not known to ribd
+	importer.update(&route, true)
+	if len(importer.imported) != 0 || len(testServer.RouteConfCh) != 0 {
+		t.Error("Kernel route through an unknown interface imported")
+	}
+
+	blackhole := netlink.Route{Dst: dst, Table: syscall.RT_TABLE_MAIN, Protocol: syscall.RTPROT_STATIC, Type: syscall.RTN_BLACKHOLE, Priority: 10}
+	importer.update(&blackhole, true)
+	importer.update(&blackhole, true)
+	if len(importer.imported) != 1 || len(testServer.RouteConfCh) != 1 {
+		t.Error("Blackhole kernel route not imported once, ", len(testServer.RouteConfCh), " updates")
+		return
+	}
+	routeConf := <-testServer.RouteConfCh
+	cfg := routeConf.OrigConfigObject.(*ribd.IPv4Route)
+	if routeConf.Op != defs.Add || !cfg.NullRoute || cfg.Cost != 10 || cfg.DestinationNw != "40.0.1.0" {
+		t.Error("Unexpected route create ", cfg, " op ", routeConf.Op)
+	}
+	blackhole.Priority = 20
+	importer.update(&blackhole, true)
+	if len(testServer.RouteConfCh) != 2 {
+		t.Error("Changed kernel route not imported again")
+		return
+	}
+	if routeConf = <-testServer.RouteConfCh; routeConf.Op != defs.Del || routeConf.OrigConfigObject.(*ribd.IPv4Route).Cost != 10 {
+		t.Error("Old kernel route not withdrawn first, op ", routeConf.Op)
+	}
+	<-testServer.RouteConfCh
+	importer.update(&blackhole, false)
+	if len(importer.imported) != 0 || len(testServer.RouteConfCh) != 1 {
+		t.Error("Deleted kernel route not withdrawn")
+	} else if routeConf = <-testServer.RouteConfCh; routeConf.Op != defs.Del {
+		t.Error("Unexpected op ", routeConf.Op, " for a deleted kernel route")
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,251 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdLabeledRouteApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"ribdInt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+   Labeled unicast routes learnt by BGP are kept in a table of their own, keyed
+   by network and protocol. They never go to the unicast RIB, so a labeled path
+   and a unicast path for the same prefix do not replace or withdraw each other.
+*/
+type labeledRoute struct {
+	networkAddr      string
+	protocol         string
+	cost             int32
+	inLabel          int32
+	nextHops         []*ribdInt.LabeledNextHopInfo
+	routeCreatedTime string
+	routeUpdatedTime string
+}
+
+func labeledRouteKey(networkAddr string, protocol string) string {
+	return protocol + ":" + networkAddr
+}
+
+func getLabeledRouteNetworkAddr(cfg *ribdInt.LabeledRoute) (nwAddr string, err error) {
+	destNetIpAddr, err := getIP(cfg.DestinationNw)
+	if err != nil {
+		return nwAddr, err
+	}
+	networkMaskAddr, err := getIP(cfg.NetworkMask)
+	if err != nil {
+		return nwAddr, err
+	}
+	_, nwAddr, err = getNetworkPrefix(destNetIpAddr, networkMaskAddr)
+	return nwAddr, err
+}
+
+/*
+    This function performs config parameters validation for labeled routes.
+	   - Validate destinationNw. If provided in CIDR notation, convert to ip addr and mask values
+	   - check that the protocol is valid and at least one next hop is given
+	   - convert the next hop interface to its ifIndex string
+*/
+func (m RIBDServer) LabeledRouteConfigValidationCheck(cfg *ribdInt.LabeledRoute, op string) (err error) {
+	if strings.Contains(cfg.DestinationNw, "/") {
+		ip, ipNet, err := net.ParseCIDR(cfg.DestinationNw)
+		if err != nil {
+			logger.Err("Invalid labeled route destination ", cfg.DestinationNw)
+			return errors.New("Invalid Destination IP address")
+		}
+		cfg.DestinationNw = ip.String()
+		cfg.NetworkMask = net.IP(ipNet.Mask).String()
+	}
+	_, err = validateNetworkPrefix(cfg.DestinationNw, cfg.NetworkMask)
+	if err != nil {
+		logger.Err("LabeledRouteConfigValidationCheck for route:", cfg, " validateNetworkPrefix() returned err ", err)
+		return err
+	}
+	if _, ok := RouteProtocolTypeMapDB[cfg.Protocol]; !ok {
+		logger.Err("route type ", cfg.Protocol, " invalid")
+		return errors.New("Invalid route protocol type")
+	}
+	if op == "del" {
+		return nil
+	}
+	if len(cfg.NextHop) == 0 {
+		logger.Err("Must specify next hop")
+		return errors.New("Next hop ip not specified")
+	}
+	for i := 0; i < len(cfg.NextHop); i++ {
+		_, err = getIP(cfg.NextHop[i].NextHopIp)
+		if err != nil {
+			logger.Err("nextHopIpAddr invalid")
+			return errors.New("Invalid next hop ip address")
+		}
+		if cfg.NextHop[i].NextHopIntRef != "" {
+			cfg.NextHop[i].NextHopIntRef, err = m.ConvertIntfStrToIfIndexStr(cfg.NextHop[i].NextHopIntRef)
+			if err != nil {
+				logger.Err("Invalid NextHop IntRef ", cfg.NextHop[i].NextHopIntRef)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func findLabeledNextHop(nextHops []*ribdInt.LabeledNextHopInfo, nextHopIp string) int {
+	for idx, nh := range nextHops {
+		if nh.NextHopIp == nextHopIp {
+			return idx
+		}
+	}
+	return -1
+}
+
+/*
+   Adds the next hops of the labeled route, a next hop that is already present
+   gets the label stack of the new config
+*/
+func (m RIBDServer) ProcessLabeledRouteCreateConfig(cfg *ribdInt.LabeledRoute) (val bool, err error) {
+	logger.Debug("ProcessLabeledRouteCreateConfig: Received create labeled route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " in label ", cfg.InLabel, " number of next hops: ", len(cfg.NextHop))
+	nwAddr, err := getLabeledRouteNetworkAddr(cfg)
+	if err != nil {
+		logger.Err("ProcessLabeledRouteCreateConfig: invalid labeled route ", cfg, " err:", err)
+		return false, err
+	}
+	rib := m.RIB
+	key := labeledRouteKey(nwAddr, cfg.Protocol)
+	route, ok := rib.labeledRoutes[key]
+	if !ok {
+		route = &labeledRoute{
+			networkAddr:      nwAddr,
+			protocol:         cfg.Protocol,
+			nextHops:         make([]*ribdInt.LabeledNextHopInfo, 0),
+			routeCreatedTime: time.Now().String(),
+		}
+		rib.labeledRoutes[key] = route
+		rib.labeledRouteKeys = append(rib.labeledRouteKeys, key)
+	}
+	route.cost = cfg.Cost
+	route.inLabel = cfg.InLabel
+	route.routeUpdatedTime = time.Now().String()
+	for _, nh := range cfg.NextHop {
+		if idx := findLabeledNextHop(route.nextHops, nh.NextHopIp); idx != -1 {
+			route.nextHops[idx] = nh
+		} else {
+			route.nextHops = append(route.nextHops, nh)
+		}
+	}
+	return true, nil
+}
+
+/*
+   Removes the next hops of the labeled route. The route is removed when the
+   config has no next hops or no next hop is left.
+*/
+func (m RIBDServer) ProcessLabeledRouteDeleteConfig(cfg *ribdInt.LabeledRoute) (val bool, err error) {
+	logger.Debug("ProcessLabeledRouteDeleteConfig: Received delete labeled route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " number of next hops: ", len(cfg.NextHop))
+	nwAddr, err := getLabeledRouteNetworkAddr(cfg)
+	if err != nil {
+		return false, err
+	}
+	rib := m.RIB
+	key := labeledRouteKey(nwAddr, cfg.Protocol)
+	route, ok := rib.labeledRoutes[key]
+	if !ok {
+		logger.Err("ProcessLabeledRouteDeleteConfig: no labeled route for ", nwAddr, " protocol ", cfg.Protocol)
+		return false, errors.New(fmt.Sprintln("No labeled route found for ", nwAddr, " protocol ", cfg.Protocol))
+	}
+	if len(cfg.NextHop) == 0 {
+		route.nextHops = nil
+	}
+	for _, nh := range cfg.NextHop {
+		if idx := findLabeledNextHop(route.nextHops, nh.NextHopIp); idx != -1 {
+			route.nextHops = append(route.nextHops[:idx], route.nextHops[idx+1:]...)
+		}
+	}
+	if len(route.nextHops) != 0 {
+		route.routeUpdatedTime = time.Now().String()
+		return true, nil
+	}
+	delete(rib.labeledRoutes, key)
+	for idx := 0; idx < len(rib.labeledRouteKeys); idx++ {
+		if rib.labeledRouteKeys[idx] == key {
+			rib.labeledRouteKeys = append(rib.labeledRouteKeys[:idx], rib.labeledRouteKeys[idx+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+func (m RIBDServer) GetBulkLabeledRouteState(fromIndex ribdInt.Int, rcount ribdInt.Int) (routes *ribdInt.LabeledRouteStateGetInfo, err error) {
+	var i, validCount, toIndex ribdInt.Int
+	rib := m.RIB
+	routes = ribdInt.NewLabeledRouteStateGetInfo()
+	routes.LabeledRouteStateList = make([]*ribdInt.LabeledRouteState, 0)
+	more := true
+	for ; ; i++ {
+		if i+fromIndex >= ribdInt.Int(len(rib.labeledRouteKeys)) {
+			more = false
+			break
+		}
+		if validCount == rcount {
+			break
+		}
+		route, ok := rib.labeledRoutes[rib.labeledRouteKeys[i+fromIndex]]
+		if !ok {
+			continue
+		}
+		labeledRouteState := &ribdInt.LabeledRouteState{
+			DestinationNw:    route.networkAddr,
+			Protocol:         route.protocol,
+			InLabel:          route.inLabel,
+			RouteCreatedTime: route.routeCreatedTime,
+			RouteUpdatedTime: route.routeUpdatedTime,
+			NextHopList:      make([]*ribdInt.LabeledNextHopInfo, 0),
+		}
+		for _, nh := range route.nextHops {
+			nextHop := &ribdInt.LabeledNextHopInfo{
+				NextHopIp:     nh.NextHopIp,
+				NextHopIntRef: nh.NextHopIntRef,
+				OutLabels:     nh.OutLabels,
+			}
+			if ifIndex, err := strconv.Atoi(nh.NextHopIntRef); err == nil {
+				if intfEntry, ok := rib.IntfEntry(int32(ifIndex)); ok {
+					nextHop.NextHopIntRef = intfEntry.name
+				}
+			}
+			labeledRouteState.NextHopList = append(labeledRouteState.NextHopList, nextHop)
+		}
+		routes.LabeledRouteStateList = append(routes.LabeledRouteStateList, labeledRouteState)
+		toIndex = i + fromIndex
+		validCount++
+	}
+	routes.StartIdx = fromIndex
+	routes.EndIdx = toIndex + 1
+	routes.More = more
+	routes.Count = validCount
+	return routes, err
+}
@@ -0,0 +1,89 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdLabeledRouteApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"ribdInt"
+	"testing"
+)
+
+func TestLabeledRoutes(t *testing.T) {
+	fmt.Println("****TestLabeledRoutes****")
+	server := RIBDServer{RIB: NewRIB()}
+	newLabeledRoute := func(nextHopIp string, outLabel int32) *ribdInt.LabeledRoute {
+		return &ribdInt.LabeledRoute{
+			DestinationNw: "40.0.1.0",
+			NetworkMask:   "255.255.255.0",
+			Protocol:      "EBGP",
+			InLabel:       16,
+			NextHop: []*ribdInt.LabeledNextHopInfo{
+				&ribdInt.LabeledNextHopInfo{NextHopIp: nextHopIp, OutLabels: []int32{outLabel}},
+			},
+		}
+	}
+	if _, err := server.ProcessLabeledRouteCreateConfig(newLabeledRoute("11.1.10.2", 1000)); err != nil {
+		t.Fatal("Failed to create labeled route, err:", err)
+	}
+	if _, err := server.ProcessLabeledRouteCreateConfig(newLabeledRoute("11.1.10.3", 2000)); err != nil {
+		t.Fatal("Failed to create labeled route, err:", err)
+	}
+	// Labeled routes are not added to the unicast route tables
+	prefix, _ := getNetowrkPrefixFromStrings("40.0.1.0", "255.255.255.0")
+	if _, found := server.RIB.Get(DefaultVrf, defs.IPv4, prefix); found {
+		t.Error("Labeled route added to the unicast RIB")
+	}
+	routes, _ := server.GetBulkLabeledRouteState(0, 10)
+	if routes.Count != 1 || len(routes.LabeledRouteStateList[0].NextHopList) != 2 {
+		t.Fatal("Expected one labeled route with two next hops, got ", routes)
+	}
+	route := routes.LabeledRouteStateList[0]
+	if route.DestinationNw != "40.0.1.0/24" || route.InLabel != 16 || route.NextHopList[1].OutLabels[0] != 2000 {
+		t.Error("Unexpected labeled route ", route)
+	}
+
+	if _, err := server.ProcessLabeledRouteDeleteConfig(newLabeledRoute("11.1.10.2", 1000)); err != nil {
+		t.Fatal("Failed to delete labeled route next hop, err:", err)
+	}
+	routes, _ = server.GetBulkLabeledRouteState(0, 10)
+	if routes.Count != 1 || len(routes.LabeledRouteStateList[0].NextHopList) != 1 ||
+		routes.LabeledRouteStateList[0].NextHopList[0].NextHopIp != "11.1.10.3" {
+		t.Error("Expected the labeled route with next hop 11.1.10.3 only, got ", routes)
+	}
+	// A delete without next hops removes the route
+	cfg := newLabeledRoute("", 0)
+	cfg.NextHop = nil
+	if _, err := server.ProcessLabeledRouteDeleteConfig(cfg); err != nil {
+		t.Fatal("Failed to delete labeled route, err:", err)
+	}
+	if routes, _ = server.GetBulkLabeledRouteState(0, 10); routes.Count != 0 || len(server.RIB.labeledRouteKeys) != 0 {
+		t.Error("Labeled route not deleted ", routes)
+	}
+	if _, err := server.ProcessLabeledRouteDeleteConfig(cfg); err == nil {
+		t.Error("Deleting a missing labeled route did not fail")
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,360 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNetlinkNextHop.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+/*
+   Kernel next hop objects (Linux 5.3 and later), see linux/nexthop.h
+*/
+const (
+	RTM_NEWNEXTHOP = 104
+	RTM_DELNEXTHOP = 105
+	RTM_GETNEXTHOP = 106
+
+	NHA_ID        = 1
+	NHA_GROUP     = 2
+	NHA_BLACKHOLE = 4
+	NHA_OIF       = 5
+	NHA_GATEWAY   = 6
+
+	RTA_NH_ID = 30
+
+	sizeofNhMsg      = 8
+	sizeofNexthopGrp = 8
+)
+
+/*
+   Ids of the kernel next hop objects are global to the network namespace,
+   ribd allocates them from its own range
+*/
+const netlinkNextHopIdBase uint32 = RTPROT_RIBD << 24
+
+/*
+   struct nhmsg
+*/
+type nhMsg struct {
+	family   uint8
+	scope    uint8
+	protocol uint8
+	resvd    uint8
+	flags    uint32
+}
+
+func (msg *nhMsg) Len() int {
+	return sizeofNhMsg
+}
+
+func (msg *nhMsg) Serialize() []byte {
+	b := make([]byte, sizeofNhMsg)
+	b[0] = msg.family
+	b[1] = msg.scope
+	b[2] = msg.protocol
+	b[3] = msg.resvd
+	nl.NativeEndian().PutUint32(b[4:], msg.flags)
+	return b
+}
+
+/*
+   Kernel next hop object shared by the groups that use the same gateway
+*/
+type netlinkNextHop struct {
+	id   uint32
+	refs int
+}
+
+/*
+   Kernel group object of a ribd next hop group, and the next hop objects it
+   holds
+*/
+type netlinkNextHopGroup struct {
+	id       uint32
+	nextHops []string
+}
+
+type netlinkNextHopGroupEntry struct {
+	id     uint32
+	weight int
+}
+
+func getNetlinkFamily(ipType defs.IPType) uint8 {
+	if ipType == defs.IPv6 {
+		return syscall.AF_INET6
+	}
+	return syscall.AF_INET
+}
+
+func getNetlinkIPBytes(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+/*
+   NHA_GROUP attribute, an array of struct nexthop_grp. The kernel weight of
+   a next hop is its weight + 1.
+*/
+func buildNextHopGroupAttr(entries []netlinkNextHopGroupEntry) []byte {
+	b := make([]byte, sizeofNexthopGrp*len(entries))
+	for idx, entry := range entries {
+		weight := nexthopHops(entry.weight)
+		if weight > 255 {
+			weight = 255
+		}
+		nl.NativeEndian().PutUint32(b[idx*sizeofNexthopGrp:], entry.id)
+		b[idx*sizeofNexthopGrp+4] = uint8(weight)
+	}
+	return b
+}
+
+func executeNetlinkRequest(req *nl.NetlinkRequest) error {
+	_, err := req.Execute(syscall.NETLINK_ROUTE, 0)
+	return err
+}
+
+func (plugin *NetlinkPlugin) allocNextHopId() uint32 {
+	for plugin.nextHopIds[plugin.nextNextHopId] {
+		plugin.nextNextHopId++
+	}
+	id := plugin.nextNextHopId
+	plugin.nextHopIds[id] = true
+	plugin.nextNextHopId++
+	return id
+}
+
+func (plugin *NetlinkPlugin) deleteNextHopObject(id uint32) error {
+	req := nl.NewNetlinkRequest(RTM_DELNEXTHOP, syscall.NLM_F_ACK)
+	req.AddData(&nhMsg{})
+	req.AddData(nl.NewRtAttr(NHA_ID, nl.Uint32Attr(id)))
+	delete(plugin.nextHopIds, id)
+	return executeNetlinkRequest(req)
+}
+
+/*
+   Returns the kernel next hop object of the member, creating it on its
+   first use
+*/
+func (plugin *NetlinkPlugin) acquireNextHopObject(member NextHopGroupMember) (string, uint32, error) {
+	routeInfoRecord := member.routeInfoRecord
+	msg := &nhMsg{
+		family:   getNetlinkFamily(routeInfoRecord.ipType),
+		protocol: RTPROT_RIBD,
+	}
+	var key string
+	attrs := make([]*nl.RtAttr, 0)
+	if isNullRoute(routeInfoRecord) {
+		key = fmt.Sprint(msg.family, " blackhole")
+		attrs = append(attrs, nl.NewRtAttr(NHA_BLACKHOLE, nil))
+	} else {
+		nh := plugin.buildNexthop(routeInfoRecord)
+		if nh.LinkIndex == 0 {
+			return "", 0, fmt.Errorf("no kernel link for next hop %s", routeInfoRecord.nextHopIp)
+		}
+		key = fmt.Sprint(msg.family, " ", netlinkNextHopString(nh.Gw, nh.LinkIndex))
+		attrs = append(attrs, nl.NewRtAttr(NHA_OIF, nl.Uint32Attr(uint32(nh.LinkIndex))))
+		if nh.Gw != nil && !nh.Gw.IsUnspecified() {
+			attrs = append(attrs, nl.NewRtAttr(NHA_GATEWAY, getNetlinkIPBytes(nh.Gw)))
+		}
+	}
+	if nextHop, ok := plugin.nextHops[key]; ok {
+		nextHop.refs++
+		return key, nextHop.id, nil
+	}
+	id := plugin.allocNextHopId()
+	req := nl.NewNetlinkRequest(RTM_NEWNEXTHOP, syscall.NLM_F_CREATE|syscall.NLM_F_EXCL|syscall.NLM_F_ACK)
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(NHA_ID, nl.Uint32Attr(id)))
+	for _, attr := range attrs {
+		req.AddData(attr)
+	}
+	if err := executeNetlinkRequest(req); err != nil {
+		delete(plugin.nextHopIds, id)
+		return "", 0, err
+	}
+	plugin.nextHops[key] = &netlinkNextHop{id: id, refs: 1}
+	return key, id, nil
+}
+
+func (plugin *NetlinkPlugin) releaseNextHopObjects(keys []string) {
+	for _, key := range keys {
+		nextHop, ok := plugin.nextHops[key]
+		if !ok {
+			continue
+		}
+		nextHop.refs--
+		if nextHop.refs > 0 {
+			continue
+		}
+		delete(plugin.nextHops, key)
+		if err := plugin.deleteNextHopObject(nextHop.id); err != nil {
+			logger.Err("releaseNextHopObjects: failed to delete kernel next hop ", key, " err:", err)
+		}
+	}
+}
+
+/*
+   Creates or replaces the kernel group object with the usable next hops of
+   the group. The routes pointing at the group object follow the new next
+   hops without being touched. A group without usable next hops is deleted,
+   the kernel removes the routes using it along with it. Returns whether
+   the group object is installed.
+*/
+func (plugin *NetlinkPlugin) programNextHopGroup(group *NextHopGroup) bool {
+	members := group.ActiveMembers()
+	for _, member := range members {
+		if isNullRoute(member.routeInfoRecord) {
+			//a blackhole can not be in a group with other next hops
+			members = []NextHopGroupMember{member}
+			break
+		}
+	}
+	keys := make([]string, 0, len(members))
+	entries := make([]netlinkNextHopGroupEntry, 0, len(members))
+	for _, member := range members {
+		key, id, err := plugin.acquireNextHopObject(member)
+		if err != nil {
+			logger.Err("programNextHopGroup: group ", group.groupId, " skipping next hop ", member.key, " err:", err)
+			continue
+		}
+		keys = append(keys, key)
+		entries = append(entries, netlinkNextHopGroupEntry{id: id, weight: int(member.routeInfoRecord.weight)})
+	}
+	if len(entries) == 0 {
+		plugin.deleteNextHopGroupObject(group)
+		return false
+	}
+	kernelGroup, ok := plugin.groups[group.groupId]
+	if !ok {
+		kernelGroup = &netlinkNextHopGroup{id: plugin.allocNextHopId()}
+	}
+	req := nl.NewNetlinkRequest(RTM_NEWNEXTHOP, syscall.NLM_F_CREATE|syscall.NLM_F_REPLACE|syscall.NLM_F_ACK)
+	req.AddData(&nhMsg{family: syscall.AF_UNSPEC, protocol: RTPROT_RIBD})
+	req.AddData(nl.NewRtAttr(NHA_ID, nl.Uint32Attr(kernelGroup.id)))
+	req.AddData(nl.NewRtAttr(NHA_GROUP, buildNextHopGroupAttr(entries)))
+	if err := executeNetlinkRequest(req); err != nil {
+		logger.Err("programNextHopGroup: failed to program kernel group ", kernelGroup.id, " for group ", group.groupId, " err:", err)
+		plugin.releaseNextHopObjects(keys)
+		if !ok {
+			delete(plugin.nextHopIds, kernelGroup.id)
+		}
+		return ok
+	}
+	logger.Debug("programNextHopGroup: group ", group.groupId, " kernel group ", kernelGroup.id, " next hops ", len(entries))
+	plugin.releaseNextHopObjects(kernelGroup.nextHops)
+	kernelGroup.nextHops = keys
+	plugin.groups[group.groupId] = kernelGroup
+	return true
+}
+
+func (plugin *NetlinkPlugin) deleteNextHopGroupObject(group *NextHopGroup) {
+	kernelGroup, ok := plugin.groups[group.groupId]
+	if !ok {
+		return
+	}
+	delete(plugin.groups, group.groupId)
+	if err := plugin.deleteNextHopObject(kernelGroup.id); err != nil {
+		logger.Err("deleteNextHopGroupObject: failed to delete kernel group ", kernelGroup.id, " err:", err)
+	}
+	plugin.releaseNextHopObjects(kernelGroup.nextHops)
+}
+
+/*
+   Points the kernel route of the destination at the kernel group object
+*/
+func (plugin *NetlinkPlugin) installNextHopGroupRoute(dst *net.IPNet, table int, group *NextHopGroup) error {
+	kernelGroup, ok := plugin.groups[group.groupId]
+	if !ok {
+		//none of the next hops can be used, withdraw the destination
+		plugin.removeRoute(dst, table)
+		return nil
+	}
+	ones, _ := dst.Mask.Size()
+	msg := nl.NewRtMsg()
+	msg.Family = syscall.AF_INET
+	if dst.IP.To4() == nil {
+		msg.Family = syscall.AF_INET6
+	}
+	msg.Dst_len = uint8(ones)
+	msg.Protocol = RTPROT_RIBD
+	msg.Table = syscall.RT_TABLE_UNSPEC
+	if table < 256 {
+		msg.Table = uint8(table)
+	}
+	req := nl.NewNetlinkRequest(syscall.RTM_NEWROUTE, syscall.NLM_F_CREATE|syscall.NLM_F_REPLACE|syscall.NLM_F_ACK)
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(syscall.RTA_DST, getNetlinkIPBytes(dst.IP)))
+	req.AddData(nl.NewRtAttr(syscall.RTA_TABLE, nl.Uint32Attr(uint32(table))))
+	req.AddData(nl.NewRtAttr(RTA_NH_ID, nl.Uint32Attr(kernelGroup.id)))
+	return executeNetlinkRequest(req)
+}
+
+/*
+   Reads the next hop objects left by a previous instance of ribd. An error
+   means the kernel has no next hop objects.
+*/
+func (plugin *NetlinkPlugin) readStaleNextHops() error {
+	req := nl.NewNetlinkRequest(RTM_GETNEXTHOP, syscall.NLM_F_DUMP)
+	req.AddData(&nhMsg{})
+	msgs, err := req.Execute(syscall.NETLINK_ROUTE, RTM_NEWNEXTHOP)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if len(msg) < sizeofNhMsg || msg[2] != RTPROT_RIBD {
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(msg[sizeofNhMsg:])
+		if err != nil {
+			continue
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type == NHA_ID && len(attr.Value) >= 4 {
+				id := nl.NativeEndian().Uint32(attr.Value)
+				plugin.nextHopIds[id] = true
+				plugin.staleNextHops = append(plugin.staleNextHops, id)
+			}
+		}
+	}
+	return nil
+}
+
+func (plugin *NetlinkPlugin) flushStaleNextHops() {
+	logger.Info("flushStaleNextHops: removing ", len(plugin.staleNextHops), " stale kernel next hops")
+	for _, id := range plugin.staleNextHops {
+		if err := plugin.deleteNextHopObject(id); err != nil {
+			//members of a deleted group may already be gone
+			logger.Debug("flushStaleNextHops: failed to delete kernel next hop ", id, " err:", err)
+		}
+	}
+	plugin.staleNextHops = nil
+}
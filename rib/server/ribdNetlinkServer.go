@@ -0,0 +1,814 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNetlinkServer.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	FIBPluginAsicd   = "asicd"
+	FIBPluginNetlink = "netlink"
+)
+
+/*
+   Protocol id of the kernel routes installed by ribd, used to tell them
+   apart from the routes owned by the kernel and other daemons
+*/
+const RTPROT_RIBD = 0xc4
+
+/*
+   Linux routing table programmed by ribd instead of asicd. Each next hop
+   group is a kernel next hop group object and the kernel routes of its
+   destinations point at it, so a next hop group update replaces the group
+   object only. Kernels without next hop objects get a multipath route per
+   destination, which is replaced for each destination of an updated group.
+   The routes of a non default VRF go to the table of the Linux VRF device of
+   the same name.
+*/
+type NetlinkPlugin struct {
+	handle *netlink.Handle
+	table  int
+	stale  map[string]netlink.Route
+	//kernel next hop objects, by the ribd next hop group id for the groups
+	nextHopObjects bool
+	nextHops       map[string]*netlinkNextHop
+	groups         map[int]*netlinkNextHopGroup
+	nextHopIds     map[uint32]bool
+	nextNextHopId  uint32
+	staleNextHops  []uint32
+	//kernel tables of the PBR rules with a next hop
+	pbrTables   map[string]*pbrTable
+	pbrTableIds map[int]bool
+	pbrMarks    map[string]int //references to the mangle rules of the PBR DSCP marks
+}
+
+func NewNetlinkPlugin(table int) (*NetlinkPlugin, error) {
+	if table <= syscall.RT_TABLE_UNSPEC || table >= syscall.RT_TABLE_MAX {
+		return nil, errors.New(fmt.Sprintln("Invalid kernel route table ", table))
+	}
+	handle, err := netlink.NewHandle()
+	if err != nil {
+		return nil, err
+	}
+	plugin := &NetlinkPlugin{
+		handle:        handle,
+		table:         table,
+		stale:         make(map[string]netlink.Route),
+		nextHops:      make(map[string]*netlinkNextHop),
+		groups:        make(map[int]*netlinkNextHopGroup),
+		nextHopIds:    make(map[uint32]bool),
+		nextNextHopId: netlinkNextHopIdBase,
+		pbrTables:     make(map[string]*pbrTable),
+		pbrTableIds:   make(map[int]bool),
+		pbrMarks:      make(map[string]int),
+	}
+	return plugin, nil
+}
+
+func netlinkRouteKey(table int, dst *net.IPNet) string {
+	return fmt.Sprint(table, ":", dst.String())
+}
+
+func isNullRoute(routeInfoRecord RouteInfoRecord) bool {
+	return routeInfoRecord.nextHopIp.Equal(net.IPv4bcast)
+}
+
+/*
+   Maps the ribd interface index of the next hop to the kernel link of the
+   same name
+*/
+func (plugin *NetlinkPlugin) getLinkIndex(ifIndex int32) int {
+	intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(ifIndex)
+	if !ok {
+		return 0
+	}
+	link, err := plugin.handle.LinkByName(intfEntry.name)
+	if err != nil {
+		logger.Debug("getLinkIndex: no kernel link for interface ", intfEntry.name, " err:", err)
+		return 0
+	}
+	return link.Attrs().Index
+}
+
+/*
+   Without asicd the interfaces of ribd are the kernel links, named and
+   indexed as in the kernel
+*/
+func (plugin *NetlinkPlugin) GetLinkInfo() {
+	links, err := plugin.handle.LinkList()
+	if err != nil {
+		logger.Err("GetLinkInfo: failed to read the kernel links, err:", err)
+		return
+	}
+	for _, link := range links {
+		logger.Info("kernel link = ", link.Attrs().Name, " ifId = ", link.Attrs().Index)
+		RouteServiceHandler.RIB.SetIntfEntry(int32(link.Attrs().Index), link.Attrs().Name)
+	}
+}
+
+/*
+   The kernel weight of a multipath next hop is Hops + 1, a weight of 0 or 1
+   is the kernel default weight of 1
+*/
+func nexthopHops(weight int) int {
+	if weight <= 1 {
+		return 0
+	}
+	return weight - 1
+}
+
+func (plugin *NetlinkPlugin) buildNexthop(routeInfoRecord RouteInfoRecord) *netlink.NexthopInfo {
+	nh := &netlink.NexthopInfo{
+		Hops: nexthopHops(int(routeInfoRecord.weight)),
+	}
+	if routeInfoRecord.protocol == defs.CONNECTED {
+		nh.LinkIndex = plugin.getLinkIndex(int32(routeInfoRecord.nextHopIfIndex))
+		return nh
+	}
+	nh.Gw = net.ParseIP(routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
+	if nh.Gw == nil || nh.Gw.IsUnspecified() {
+		nh.Gw = routeInfoRecord.nextHopIp
+	}
+	nh.LinkIndex = plugin.getLinkIndex(int32(routeInfoRecord.resolvedNextHopIpIntf.NextHopIfIndex))
+	return nh
+}
+
+/*
+   Returns the kernel table of the VRF of the route
+*/
+func (plugin *NetlinkPlugin) getRouteTable(routeInfoRecord RouteInfoRecord) (int, bool) {
+	return plugin.getVrfTable(routeInfoRecord.vrf)
+}
+
+func (plugin *NetlinkPlugin) getVrfTable(vrf string) (int, bool) {
+	vrf = getVrfName(vrf)
+	if vrf == DefaultVrf {
+		return plugin.table, true
+	}
+	link, err := plugin.handle.LinkByName(vrf)
+	if err != nil {
+		logger.Err("getVrfTable: no kernel VRF device for vrf ", vrf, " err:", err)
+		return 0, false
+	}
+	vrfLink, ok := link.(*netlink.Vrf)
+	if !ok {
+		logger.Err("getVrfTable: kernel link ", vrf, " is not a VRF device")
+		return 0, false
+	}
+	return int(vrfLink.Table), true
+}
+
+/*
+   Builds the kernel route for the next hops of the destination
+*/
+func (plugin *NetlinkPlugin) buildRoute(dst *net.IPNet, table int, members []NextHopGroupMember) *netlink.Route {
+	route := &netlink.Route{
+		Dst:      dst,
+		Table:    table,
+		Protocol: RTPROT_RIBD,
+		Type:     syscall.RTN_UNICAST,
+	}
+	for _, member := range members {
+		if isNullRoute(member.routeInfoRecord) {
+			route.Type = syscall.RTN_BLACKHOLE
+			route.MultiPath = nil
+			return route
+		}
+		route.MultiPath = append(route.MultiPath, plugin.buildNexthop(member.routeInfoRecord))
+	}
+	if len(route.MultiPath) == 1 {
+		route.LinkIndex = route.MultiPath[0].LinkIndex
+		route.Gw = route.MultiPath[0].Gw
+		if route.Gw == nil {
+			route.Scope = netlink.SCOPE_LINK
+		}
+		route.MultiPath = nil
+	}
+	return route
+}
+
+/*
+   The kernel owns the connected and the IPv6 link local routes of the main
+   table and of the VRF tables. The KERNEL routes were imported from the
+   kernel and are left to the agents that added them.
+*/
+func (plugin *NetlinkPlugin) skipRoute(routeInfoRecord RouteInfoRecord) bool {
+	if routeInfoRecord.ipType == defs.IPv6 && routeInfoRecord.destNetIp.IsLinkLocalUnicast() {
+		return true
+	}
+	if routeInfoRecord.protocol == defs.KERNEL {
+		return true
+	}
+	if routeInfoRecord.protocol != defs.CONNECTED || routeInfoRecord.sourceVrf != "" {
+		return false
+	}
+	return plugin.table == syscall.RT_TABLE_MAIN || getVrfName(routeInfoRecord.vrf) != DefaultVrf
+}
+
+func (plugin *NetlinkPlugin) installRoute(dst *net.IPNet, table int, group *NextHopGroup) {
+	if plugin.nextHopObjects {
+		if err := plugin.installNextHopGroupRoute(dst, table, group); err != nil {
+			logger.Err("installRoute: failed to install kernel route ", netlinkRouteKey(table, dst), " err:", err)
+			return
+		}
+		delete(plugin.stale, netlinkRouteKey(table, dst))
+		return
+	}
+	members := group.ActiveMembers()
+	if len(members) == 0 {
+		//none of the next hops can be used, withdraw the destination
+		plugin.removeRoute(dst, table)
+		return
+	}
+	route := plugin.buildRoute(dst, table, members)
+	logger.Debug("installRoute: replace kernel route ", route)
+	if err := plugin.handle.RouteReplace(route); err != nil {
+		logger.Err("installRoute: failed to install kernel route ", route, " err:", err)
+		return
+	}
+	delete(plugin.stale, netlinkRouteKey(table, dst))
+}
+
+func (plugin *NetlinkPlugin) removeRoute(dst *net.IPNet, table int) {
+	route := &netlink.Route{
+		Dst:      dst,
+		Table:    table,
+		Protocol: RTPROT_RIBD,
+	}
+	if err := plugin.handle.RouteDel(route); err != nil {
+		logger.Debug("removeRoute: failed to delete kernel route ", netlinkRouteKey(table, dst), " err:", err)
+	}
+}
+
+func (plugin *NetlinkPlugin) CreateNextHopGroup(group *NextHopGroup) {
+	logger.Debug("CreateNextHopGroup: group ", group.groupId, " next hops ", group.key)
+	if plugin.nextHopObjects {
+		plugin.programNextHopGroup(group)
+	}
+}
+
+func (plugin *NetlinkPlugin) DeleteNextHopGroup(group *NextHopGroup) {
+	logger.Debug("DeleteNextHopGroup: group ", group.groupId)
+	if plugin.nextHopObjects {
+		plugin.deleteNextHopGroupObject(group)
+	}
+}
+
+/*
+   With next hop objects only the group object is replaced. The routes are
+   installed one by one when the group gets usable next hops back, as they
+   were removed along with the group object.
+*/
+func (plugin *NetlinkPlugin) UpdateNextHopGroup(group *NextHopGroup, oldMembers []NextHopGroupMember) {
+	logger.Info("UpdateNextHopGroup: group ", group.groupId, " routes ", len(group.routes), " table ", plugin.table)
+	if plugin.nextHopObjects {
+		_, installed := plugin.groups[group.groupId]
+		if !plugin.programNextHopGroup(group) || installed {
+			return
+		}
+	}
+	for _, routeInfoRecord := range group.routes {
+		if plugin.skipRoute(routeInfoRecord) {
+			continue
+		}
+		table, ok := plugin.getRouteTable(routeInfoRecord)
+		if !ok {
+			continue
+		}
+		plugin.installRoute(getRouteDstNet(routeInfoRecord), table, group)
+	}
+}
+
+func (plugin *NetlinkPlugin) SetRouteNextHopGroup(routeInfoRecord RouteInfoRecord, oldGroup *NextHopGroup, group *NextHopGroup) {
+	if plugin.skipRoute(routeInfoRecord) {
+		return
+	}
+	table, ok := plugin.getRouteTable(routeInfoRecord)
+	if !ok {
+		return
+	}
+	dst := getRouteDstNet(routeInfoRecord)
+	logger.Info("SetRouteNextHopGroup: ", netlinkRouteKey(table, dst), " group ", group.groupId)
+	plugin.installRoute(dst, table, group)
+}
+
+/*
+   The kernel routes are replaced as the updates are made
+*/
+func (plugin *NetlinkPlugin) Flush() {
+}
+
+func (plugin *NetlinkPlugin) DeleteRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup) {
+	if plugin.skipRoute(routeInfoRecord) {
+		return
+	}
+	table, ok := plugin.getRouteTable(routeInfoRecord)
+	if !ok {
+		return
+	}
+	dst := getRouteDstNet(routeInfoRecord)
+	logger.Info("DeleteRoute: ", netlinkRouteKey(table, dst))
+	plugin.removeRoute(dst, table)
+}
+
+/*
+   Reads the routes left in the table by a previous instance of ribd. They
+   are removed by FlushStaleRoutes unless ribd installs them again.
+*/
+func (plugin *NetlinkPlugin) ReadStaleRoutes() error {
+	filter := &netlink.Route{
+		Table:    plugin.table,
+		Protocol: RTPROT_RIBD,
+	}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := plugin.handle.RouteListFiltered(family, filter, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_PROTOCOL)
+		if err != nil {
+			return err
+		}
+		for _, route := range routes {
+			if route.Dst == nil {
+				continue
+			}
+			plugin.stale[netlinkRouteKey(plugin.table, route.Dst)] = route
+		}
+	}
+	logger.Info("ReadStaleRoutes: ", len(plugin.stale), " routes found in table ", plugin.table)
+	if err := plugin.readStaleNextHops(); err != nil {
+		logger.Info("ReadStaleRoutes: kernel has no next hop objects, installing multipath routes, err:", err)
+	} else {
+		plugin.nextHopObjects = true
+		logger.Info("ReadStaleRoutes: ", len(plugin.staleNextHops), " next hop objects found")
+	}
+	plugin.flushPbrRules()
+	return nil
+}
+
+func (plugin *NetlinkPlugin) FlushStaleRoutes() {
+	logger.Info("FlushStaleRoutes: removing ", len(plugin.stale), " stale routes from table ", plugin.table)
+	for key, route := range plugin.stale {
+		if err := plugin.handle.RouteDel(&route); err != nil {
+			logger.Err("FlushStaleRoutes: failed to delete kernel route ", key, " err:", err)
+		}
+		delete(plugin.stale, key)
+	}
+	plugin.flushStaleNextHops()
+}
+
+/*
+   Next hops of a kernel route as the audit compares them, the gateway and
+   the kernel link of each path
+*/
+func netlinkNextHopString(gw net.IP, linkIndex int) string {
+	if gw == nil || gw.IsUnspecified() {
+		return fmt.Sprint("dev ", linkIndex)
+	}
+	return fmt.Sprint(gw.String(), " dev ", linkIndex)
+}
+
+func netlinkRouteNextHops(route *netlink.Route) []string {
+	if route.Type == syscall.RTN_BLACKHOLE {
+		return []string{"blackhole"}
+	}
+	if len(route.MultiPath) == 0 {
+		return []string{netlinkNextHopString(route.Gw, route.LinkIndex)}
+	}
+	nextHops := make([]string, 0, len(route.MultiPath))
+	for _, nh := range route.MultiPath {
+		nextHops = append(nextHops, netlinkNextHopString(nh.Gw, nh.LinkIndex))
+	}
+	sort.Strings(nextHops)
+	return nextHops
+}
+
+/*
+   Tables ribd installs routes in, its own table for the default VRF and the
+   tables of the Linux VRF devices
+*/
+func (plugin *NetlinkPlugin) getAuditTables() (map[int]string, error) {
+	tables := map[int]string{plugin.table: DefaultVrf}
+	links, err := plugin.handle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if vrfLink, ok := link.(*netlink.Vrf); ok {
+			tables[int(vrfLink.Table)] = vrfLink.Attrs().Name
+		}
+	}
+	return tables, nil
+}
+
+/*
+   Reads the routes ribd installed in the kernel. The routes left by a
+   previous instance of ribd are not reported until FlushStaleRoutes has had
+   a chance to remove them.
+*/
+func (plugin *NetlinkPlugin) ReadFIBRoutes() (map[string]*FIBAuditRoute, error) {
+	tables, err := plugin.getAuditTables()
+	if err != nil {
+		return nil, err
+	}
+	routes := make(map[string]*FIBAuditRoute)
+	for table, vrf := range tables {
+		filter := &netlink.Route{
+			Table:    table,
+			Protocol: RTPROT_RIBD,
+		}
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			kernelRoutes, err := plugin.handle.RouteListFiltered(family, filter, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_PROTOCOL)
+			if err != nil {
+				return nil, err
+			}
+			for idx := range kernelRoutes {
+				kernelRoute := kernelRoutes[idx]
+				route := &FIBAuditRoute{
+					vrf:      vrf,
+					ipType:   defs.IPv4,
+					dst:      kernelRoute.Dst,
+					nextHops: netlinkRouteNextHops(&kernelRoute),
+					table:    table,
+				}
+				if family == netlink.FAMILY_V6 {
+					route.ipType = defs.IPv6
+				}
+				if route.dst == nil {
+					//the kernel reports the default route without a destination
+					_, route.dst, _ = net.ParseCIDR("0.0.0.0/0")
+					if family == netlink.FAMILY_V6 {
+						_, route.dst, _ = net.ParseCIDR("::/0")
+					}
+				}
+				if _, ok := plugin.stale[netlinkRouteKey(table, route.dst)]; ok {
+					continue
+				}
+				routes[route.key()] = route
+			}
+		}
+	}
+	logger.Info("ReadFIBRoutes: ", len(routes), " routes in ", len(tables), " kernel tables")
+	return routes, nil
+}
+
+func (plugin *NetlinkPlugin) ExpectedFIBRoute(routeInfoRecord RouteInfoRecord, members []NextHopGroupMember) (*FIBAuditRoute, bool) {
+	if plugin.skipRoute(routeInfoRecord) {
+		return nil, false
+	}
+	table, ok := plugin.getRouteTable(routeInfoRecord)
+	if !ok {
+		return nil, false
+	}
+	dst := getRouteDstNet(routeInfoRecord)
+	return &FIBAuditRoute{
+		vrf:      routeInfoRecord.vrf,
+		ipType:   routeInfoRecord.ipType,
+		dst:      dst,
+		nextHops: netlinkRouteNextHops(plugin.buildRoute(dst, table, members)),
+		table:    table,
+	}, true
+}
+
+/*
+   The kernel route of the destination is replaced with the next hops of its
+   group
+*/
+func (plugin *NetlinkPlugin) RepairFIBRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup, programmed *FIBAuditRoute) {
+	table, ok := plugin.getRouteTable(routeInfoRecord)
+	if !ok {
+		return
+	}
+	dst := getRouteDstNet(routeInfoRecord)
+	logger.Info("RepairFIBRoute: ", netlinkRouteKey(table, dst), " group ", group.groupId)
+	plugin.installRoute(dst, table, group)
+}
+
+func (plugin *NetlinkPlugin) RemoveFIBRoute(programmed *FIBAuditRoute) {
+	logger.Info("RemoveFIBRoute: ", netlinkRouteKey(programmed.table, programmed.dst))
+	plugin.removeRoute(programmed.dst, programmed.table)
+}
+
+/*
+   PBR rules are installed as kernel ip rules matching the input interface
+   and the match fields of the rule, ordered by the position of the rule in
+   its policy. A rule with a next hop looks up a table of its own holding a
+   default route through the next hop, a rule without one looks up the table
+   of its VRF. Ports and protocols are matched by the kernel from 4.17.
+   Kernel rules cannot match the DSCP of a packet, the tos of an IPv4 rule
+   only covers the old TOS bits. A mangle rule of the input interface in the
+   PbrMangleChain chain marks the packets with the DSCP of a rule, and the
+   kernel rule matches the mark.
+*/
+const (
+	PbrRulePriorityBase = 100
+	PbrTableBase        = 10000
+	PbrTableMax         = 20000
+	PbrMangleChain      = "RIBD_PBR"
+	pbrDscpMarkShift    = 24
+	pbrDscpMarkMask     = uint32(0x7f) << pbrDscpMarkShift
+)
+
+type pbrTable struct {
+	id   int
+	refs int
+}
+
+func pbrRuleKey(policy string, rule *PbrRule) string {
+	return fmt.Sprint(policy, ":", rule.seq)
+}
+
+func (plugin *NetlinkPlugin) allocPbrTable(key string) (int, bool) {
+	if table, ok := plugin.pbrTables[key]; ok {
+		table.refs++
+		return table.id, true
+	}
+	for id := PbrTableBase; id < PbrTableMax; id++ {
+		if !plugin.pbrTableIds[id] {
+			plugin.pbrTableIds[id] = true
+			plugin.pbrTables[key] = &pbrTable{id: id, refs: 1}
+			return id, true
+		}
+	}
+	logger.Err("allocPbrTable: no free kernel table for PBR rule ", key)
+	return 0, false
+}
+
+func (plugin *NetlinkPlugin) freePbrTable(key string) {
+	table, ok := plugin.pbrTables[key]
+	if !ok {
+		return
+	}
+	table.refs--
+	if table.refs > 0 {
+		return
+	}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := plugin.handle.RouteListFiltered(family, &netlink.Route{Table: table.id}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			continue
+		}
+		for _, route := range routes {
+			plugin.handle.RouteDel(&route)
+		}
+	}
+	delete(plugin.pbrTableIds, table.id)
+	delete(plugin.pbrTables, key)
+}
+
+func pbrRuleFamilies(rule *PbrRule) []int {
+	for _, ip := range []net.IP{net.ParseIP(rule.nextHopIp), pbrPrefixIP(rule.srcPrefix), pbrPrefixIP(rule.dstPrefix)} {
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			return []int{netlink.FAMILY_V4}
+		}
+		return []int{netlink.FAMILY_V6}
+	}
+	return []int{netlink.FAMILY_V4, netlink.FAMILY_V6}
+}
+
+func pbrPrefixIP(prefix *net.IPNet) net.IP {
+	if prefix == nil {
+		return nil
+	}
+	return prefix.IP
+}
+
+func (plugin *NetlinkPlugin) buildPbrRules(rule *PbrRule, ifIndex int32, table int) (rules []*netlink.Rule) {
+	intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(ifIndex)
+	if !ok {
+		logger.Err("buildPbrRules: interface ", ifIndex, " not found")
+		return nil
+	}
+	for _, family := range pbrRuleFamilies(rule) {
+		kernelRule := netlink.NewRule()
+		kernelRule.Family = family
+		kernelRule.Priority = PbrRulePriorityBase + rule.order
+		kernelRule.Table = table
+		kernelRule.IifName = intfEntry.name
+		kernelRule.Src = rule.srcPrefix
+		kernelRule.Dst = rule.dstPrefix
+		kernelRule.IPProto = rule.protocol
+		if rule.srcPort != 0 {
+			kernelRule.Sport = netlink.NewRulePortRange(uint16(rule.srcPort), uint16(rule.srcPort))
+		}
+		if rule.dstPort != 0 {
+			kernelRule.Dport = netlink.NewRulePortRange(uint16(rule.dstPort), uint16(rule.dstPort))
+		}
+		if rule.dscp != PbrDscpAny {
+			mask := pbrDscpMarkMask
+			kernelRule.Mark = pbrDscpMark(rule.dscp)
+			kernelRule.Mask = &mask
+		}
+		rules = append(rules, kernelRule)
+	}
+	return rules
+}
+
+/*
+   Firewall mark of the packets with the DSCP, 0 is left to unmarked packets
+*/
+func pbrDscpMark(dscp int32) uint32 {
+	return uint32(dscp+1) << pbrDscpMarkShift
+}
+
+func runIptables(family int, args ...string) error {
+	cmd := "iptables"
+	if family == netlink.FAMILY_V6 {
+		cmd = "ip6tables"
+	}
+	out, err := exec.Command(cmd, append([]string{"-w", "-t", "mangle"}, args...)...).CombinedOutput()
+	if err != nil {
+		return errors.New(fmt.Sprint(cmd, " ", strings.Join(args, " "), ": ", strings.TrimSpace(string(out)), " ", err))
+	}
+	return nil
+}
+
+func pbrMangleRule(ifName string, dscp int32) []string {
+	return []string{PbrMangleChain, "-i", ifName, "-m", "dscp", "--dscp", strconv.Itoa(int(dscp)),
+		"-j", "MARK", "--set-xmark", fmt.Sprintf("0x%x/0x%x", pbrDscpMark(dscp), pbrDscpMarkMask)}
+}
+
+func pbrDscpMarkKey(family int, ifName string, dscp int32) string {
+	return fmt.Sprint(family, ":", ifName, ":", dscp)
+}
+
+/*
+   The mangle rule of an interface and DSCP is shared by the PBR rules
+   matching them
+*/
+func (plugin *NetlinkPlugin) addPbrDscpMark(family int, ifName string, dscp int32) bool {
+	key := pbrDscpMarkKey(family, ifName, dscp)
+	if plugin.pbrMarks[key] > 0 {
+		plugin.pbrMarks[key]++
+		return true
+	}
+	if err := runIptables(family, append([]string{"-A"}, pbrMangleRule(ifName, dscp)...)...); err != nil {
+		logger.Err("addPbrDscpMark: failed to mark the packets of ", ifName, " with dscp ", dscp, " err:", err)
+		return false
+	}
+	plugin.pbrMarks[key] = 1
+	return true
+}
+
+func (plugin *NetlinkPlugin) deletePbrDscpMark(family int, ifName string, dscp int32) {
+	key := pbrDscpMarkKey(family, ifName, dscp)
+	refs, ok := plugin.pbrMarks[key]
+	if !ok {
+		return
+	}
+	if refs > 1 {
+		plugin.pbrMarks[key]--
+		return
+	}
+	delete(plugin.pbrMarks, key)
+	if err := runIptables(family, append([]string{"-D"}, pbrMangleRule(ifName, dscp)...)...); err != nil {
+		logger.Err("deletePbrDscpMark: failed to remove the mark of the packets of ", ifName, " with dscp ", dscp, " err:", err)
+	}
+}
+
+/*
+   Creates the mangle chain of the PBR marks, or empties the one left by a
+   previous instance of ribd, and sends the packets received to it
+*/
+func initPbrMangleChain(family int) {
+	runIptables(family, "-N", PbrMangleChain)
+	if err := runIptables(family, "-F", PbrMangleChain); err != nil {
+		logger.Err("initPbrMangleChain: err:", err)
+		return
+	}
+	if runIptables(family, "-C", "PREROUTING", "-j", PbrMangleChain) != nil {
+		if err := runIptables(family, "-I", "PREROUTING", "-j", PbrMangleChain); err != nil {
+			logger.Err("initPbrMangleChain: err:", err)
+		}
+	}
+}
+
+func (plugin *NetlinkPlugin) PbrSupported() bool {
+	return true
+}
+
+func (plugin *NetlinkPlugin) InstallPbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	key := pbrRuleKey(policy, rule)
+	table, ok := plugin.getVrfTable(rule.vrf)
+	if rule.nextHopIp != "" {
+		table, ok = plugin.allocPbrTable(key)
+		if !ok {
+			return
+		}
+		gw := net.ParseIP(rule.nextHop.NextHopIp)
+		dst := &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}
+		if gw.To4() == nil {
+			dst = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+		}
+		route := &netlink.Route{
+			Dst:       dst,
+			Gw:        gw,
+			LinkIndex: plugin.getLinkIndex(int32(rule.nextHop.NextHopIfIndex)),
+			Table:     table,
+			Protocol:  RTPROT_RIBD,
+		}
+		if err := plugin.handle.RouteReplace(route); err != nil {
+			logger.Err("InstallPbrRule: failed to install next hop route ", route, " of PBR rule ", key, " err:", err)
+		}
+	}
+	if !ok {
+		return
+	}
+	for _, kernelRule := range plugin.buildPbrRules(rule, ifIndex, table) {
+		logger.Info("InstallPbrRule: ", key, " kernel rule ", kernelRule)
+		if rule.dscp != PbrDscpAny && !plugin.addPbrDscpMark(kernelRule.Family, kernelRule.IifName, rule.dscp) {
+			continue
+		}
+		if err := plugin.handle.RuleAdd(kernelRule); err != nil {
+			logger.Err("InstallPbrRule: failed to add kernel rule ", kernelRule, " err:", err)
+		}
+	}
+}
+
+func (plugin *NetlinkPlugin) DeletePbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	key := pbrRuleKey(policy, rule)
+	table, ok := plugin.getVrfTable(rule.vrf)
+	if rule.nextHopIp != "" {
+		pbrTable, found := plugin.pbrTables[key]
+		if !found {
+			return
+		}
+		table, ok = pbrTable.id, true
+	}
+	if !ok {
+		return
+	}
+	for _, kernelRule := range plugin.buildPbrRules(rule, ifIndex, table) {
+		logger.Info("DeletePbrRule: ", key, " kernel rule ", kernelRule)
+		if err := plugin.handle.RuleDel(kernelRule); err != nil {
+			logger.Err("DeletePbrRule: failed to delete kernel rule ", kernelRule, " err:", err)
+		}
+		if rule.dscp != PbrDscpAny {
+			plugin.deletePbrDscpMark(kernelRule.Family, kernelRule.IifName, rule.dscp)
+		}
+	}
+	if rule.nextHopIp != "" {
+		plugin.freePbrTable(key)
+	}
+}
+
+/*
+   Removes the PBR rules and tables left by a previous instance of ribd, the
+   rules are installed again when the PBR policies are configured
+*/
+func (plugin *NetlinkPlugin) flushPbrRules() {
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		initPbrMangleChain(family)
+		rules, err := plugin.handle.RuleList(family)
+		if err != nil {
+			logger.Err("flushPbrRules: failed to read kernel rules err:", err)
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Table < PbrTableBase || rule.Table >= PbrTableMax {
+				continue
+			}
+			plugin.handle.RuleDel(&rule)
+			routes, err := plugin.handle.RouteListFiltered(family, &netlink.Route{Table: rule.Table}, netlink.RT_FILTER_TABLE)
+			if err != nil {
+				continue
+			}
+			for _, route := range routes {
+				plugin.handle.RouteDel(&route)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNetlinkServer_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"syscall"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+func buildTestNetlinkRouteInfoRecord(destNet string, mask string, nextHop string, weight int) RouteInfoRecord {
+	return RouteInfoRecord{
+		ipType:                defs.IPv4,
+		destNetIp:             net.ParseIP(destNet),
+		networkMask:           net.ParseIP(mask),
+		nextHopIp:             net.ParseIP(nextHop),
+		resolvedNextHopIpIntf: ribdInt.NextHopInfo{NextHopIp: nextHop, NextHopIfIndex: -1},
+		nextHopIfIndex:        -1,
+		weight:                ribd.Int(weight),
+		protocol:              defs.STATIC,
+	}
+}
+
+func TestNetlinkBuildRoute(t *testing.T) {
+	fmt.Println("****TestNetlinkBuildRoute****")
+	plugin := &NetlinkPlugin{
+		table: 100,
+	}
+	nh1 := buildTestNetlinkRouteInfoRecord("40.0.1.0", "255.255.255.0", "11.1.10.2", 0)
+	nh2 := buildTestNetlinkRouteInfoRecord("40.0.1.0", "255.255.255.0", "12.1.10.2", 3)
+	dst := getRouteDstNet(nh1)
+	fmt.Println("dst:", dst)
+	if dst.String() != "40.0.1.0/24" {
+		t.Error("getRouteDstNet returned ", dst, " expected 40.0.1.0/24")
+	}
+
+	route := plugin.buildRoute(dst, plugin.table, []NextHopGroupMember{{routeInfoRecord: nh1}})
+	fmt.Println("single next hop route:", route)
+	if route.Table != 100 || route.Protocol != RTPROT_RIBD || !route.Gw.Equal(nh1.nextHopIp) || len(route.MultiPath) != 0 {
+		t.Error("Unexpected single next hop route ", route)
+	}
+
+	route = plugin.buildRoute(dst, plugin.table, []NextHopGroupMember{{routeInfoRecord: nh1}, {routeInfoRecord: nh2}})
+	fmt.Println("ecmp route:", route)
+	if len(route.MultiPath) != 2 || route.Gw != nil {
+		t.Error("Unexpected ecmp route ", route)
+	} else if route.MultiPath[0].Hops != 0 || route.MultiPath[1].Hops != 2 {
+		//kernel weight is Hops + 1
+		t.Error("Unexpected ecmp next hop weights ", route.MultiPath[0].Hops, " ", route.MultiPath[1].Hops, " expected 0 and 2")
+	}
+	for _, weight := range []int{-1, 0, 1} {
+		if hops := nexthopHops(weight); hops != 0 {
+			t.Error("Weight ", weight, " mapped to ", hops, " hops, expected 0")
+		}
+	}
+
+	null := buildTestNetlinkRouteInfoRecord("50.0.1.0", "255.255.255.0", "255.255.255.255", 0)
+	route = plugin.buildRoute(getRouteDstNet(null), plugin.table, []NextHopGroupMember{{routeInfoRecord: null}})
+	fmt.Println("null route:", route)
+	if route.Type != syscall.RTN_BLACKHOLE || route.Gw != nil || len(route.MultiPath) != 0 {
+		t.Error("Unexpected null route ", route)
+	}
+
+	connected := buildTestNetlinkRouteInfoRecord("11.1.10.0", "255.255.255.0", "0.0.0.0", 0)
+	connected.protocol = defs.CONNECTED
+	if plugin.skipRoute(connected) {
+		t.Error("Connected route skipped for table ", plugin.table)
+	}
+	connected.vrf = "red"
+	if !plugin.skipRoute(connected) {
+		t.Error("Connected route not skipped for vrf ", connected.vrf)
+	}
+	connected.vrf = DefaultVrf
+	plugin.table = syscall.RT_TABLE_MAIN
+	if !plugin.skipRoute(connected) {
+		t.Error("Connected route not skipped for the main table")
+	}
+	fmt.Println("***********************************")
+}
+
+func TestNetlinkNextHopGroupAttr(t *testing.T) {
+	fmt.Println("****TestNetlinkNextHopGroupAttr****")
+	attr := buildNextHopGroupAttr([]netlinkNextHopGroupEntry{{id: netlinkNextHopIdBase + 1, weight: 0}, {id: netlinkNextHopIdBase + 2, weight: 3}, {id: 7, weight: 1000}})
+	fmt.Println("NHA_GROUP:", attr)
+	if len(attr) != 3*sizeofNexthopGrp {
+		t.Fatal("NHA_GROUP of 3 next hops is ", len(attr), " bytes, expected ", 3*sizeofNexthopGrp)
+	}
+	for idx, expected := range []struct {
+		id     uint32
+		weight uint8
+	}{{netlinkNextHopIdBase + 1, 0}, {netlinkNextHopIdBase + 2, 2}, {7, 255}} {
+		entry := attr[idx*sizeofNexthopGrp : (idx+1)*sizeofNexthopGrp]
+		id := nl.NativeEndian().Uint32(entry)
+		if id != expected.id || entry[4] != expected.weight || entry[5] != 0 || entry[6] != 0 || entry[7] != 0 {
+			t.Error("Unexpected nexthop_grp ", entry, " expected id ", expected.id, " weight ", expected.weight)
+		}
+	}
+	msg := (&nhMsg{family: syscall.AF_INET6, protocol: RTPROT_RIBD}).Serialize()
+	if len(msg) != sizeofNhMsg || msg[0] != syscall.AF_INET6 || msg[1] != 0 || msg[2] != RTPROT_RIBD {
+		t.Error("Unexpected nhmsg ", msg)
+	}
+
+	plugin := &NetlinkPlugin{
+		nextHopIds:    map[uint32]bool{netlinkNextHopIdBase: true, netlinkNextHopIdBase + 1: true},
+		nextNextHopId: netlinkNextHopIdBase,
+	}
+	//ids left by a previous instance are not reused
+	if id := plugin.allocNextHopId(); id != netlinkNextHopIdBase+2 {
+		t.Error("Allocated next hop id ", id, " expected ", netlinkNextHopIdBase+2)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestNetlinkPbrDscpMark(t *testing.T) {
+	fmt.Println("****TestNetlinkPbrDscpMark****")
+	marks := make(map[uint32]int32)
+	for dscp := int32(0); dscp <= PbrDscpMax; dscp++ {
+		mark := pbrDscpMark(dscp)
+		if mark == 0 || mark&^pbrDscpMarkMask != 0 {
+			t.Error("Mark ", mark, " of dscp ", dscp, " outside the mark mask")
+		}
+		if other, ok := marks[mark]; ok {
+			t.Error("Dscp ", dscp, " and ", other, " have the same mark ", mark)
+		}
+		marks[mark] = dscp
+	}
+	rule := pbrMangleRule("fpPort1", 46)
+	fmt.Println("mangle rule:", rule)
+	expected := []string{PbrMangleChain, "-i", "fpPort1", "-m", "dscp", "--dscp", "46", "-j", "MARK", "--set-xmark", "0x2f000000/0x7f000000"}
+	if fmt.Sprint(rule) != fmt.Sprint(expected) {
+		t.Error("Unexpected mangle rule ", rule, " expected ", expected)
+	}
+	fmt.Println("***********************************")
+}
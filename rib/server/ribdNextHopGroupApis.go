@@ -0,0 +1,397 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNextHopGroupApis.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"sort"
+	"strings"
+	"utils/patriciaDB"
+)
+
+/*
+   Next hop of a next hop group. The next hop attributes are taken from the
+   route which first used the next hop, the destination fields of
+   routeInfoRecord are not meaningful here.
+*/
+type NextHopGroupMember struct {
+	key             string
+	ifIndex         ribd.Int //interface the next hop resolves to
+	nextHopPrefix   string   //prefix of the route the next hop resolves through
+	routeInfoRecord RouteInfoRecord
+	isReachable     bool
+}
+
+/*
+   Next hop group shared by all the destinations whose selected routes have
+   the same set of next hops. The FIB programs the group once and points the
+   destinations at it, so a next hop failure is handled by updating the group
+   instead of each of the destinations using it.
+*/
+type NextHopGroup struct {
+	groupId int
+	key     string
+	ipType  defs.IPType
+	members []NextHopGroupMember
+	routes  map[string]RouteInfoRecord //destinations using the group
+}
+
+/*
+   Interface, next hop prefix or BFD tracked next hop whose state changed.
+   ifIndex is -1 when the event is not for an interface.
+*/
+type NextHopGroupEvent struct {
+	vrf           string
+	ifIndex       ribd.Int
+	nextHopPrefix string
+	bfdNextHop    string
+}
+
+/*
+   FIB backends program the next hop groups and point the destinations at
+   them. Backends may hold the updates back until Flush is called at the end
+   of each batch of the FIB queue.
+*/
+type FIBPlugin interface {
+	CreateNextHopGroup(group *NextHopGroup)
+	UpdateNextHopGroup(group *NextHopGroup, oldMembers []NextHopGroupMember)
+	DeleteNextHopGroup(group *NextHopGroup)
+	SetRouteNextHopGroup(routeInfoRecord RouteInfoRecord, oldGroup *NextHopGroup, group *NextHopGroup)
+	DeleteRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup)
+	Flush()
+}
+
+/*
+   Next hop groups and the next hops of each destination installed in the
+   FIB. Owned by the asicd server loop.
+*/
+type NextHopGroupTable struct {
+	groups          map[string]*NextHopGroup
+	routes          map[string]map[string]RouteInfoRecord
+	routeGroups     map[string]*NextHopGroup
+	downIntfs       map[ribd.Int]bool
+	downPrefixes    map[string]bool
+	downBfdNextHops map[string]bool
+	nextGroupId     int
+}
+
+func NewNextHopGroupTable() *NextHopGroupTable {
+	return &NextHopGroupTable{
+		groups:          make(map[string]*NextHopGroup),
+		routes:          make(map[string]map[string]RouteInfoRecord),
+		routeGroups:     make(map[string]*NextHopGroup),
+		downIntfs:       make(map[ribd.Int]bool),
+		downPrefixes:    make(map[string]bool),
+		downBfdNextHops: make(map[string]bool),
+		nextGroupId:     1,
+	}
+}
+
+func getRouteDstNet(routeInfoRecord RouteInfoRecord) *net.IPNet {
+	dst := &net.IPNet{
+		IP:   routeInfoRecord.destNetIp,
+		Mask: net.IPMask(routeInfoRecord.networkMask),
+	}
+	if routeInfoRecord.ipType == defs.IPv4 {
+		dst.IP = routeInfoRecord.destNetIp.To4()
+		dst.Mask = net.IPMask(routeInfoRecord.networkMask.To4())
+	}
+	dst.IP = dst.IP.Mask(dst.Mask)
+	return dst
+}
+
+/*
+   Destinations and next hop prefixes are only unique within a VRF
+*/
+func getVrfPrefixKey(vrf string, prefix string) string {
+	return getVrfName(vrf) + ":" + prefix
+}
+
+func getNextHopGroupMemberIfIndex(routeInfoRecord RouteInfoRecord) ribd.Int {
+	if routeInfoRecord.protocol == defs.CONNECTED {
+		return routeInfoRecord.nextHopIfIndex
+	}
+	return ribd.Int(routeInfoRecord.resolvedNextHopIpIntf.NextHopIfIndex)
+}
+
+/*
+   A next hop tracked by BFD is a different member than the same next hop
+   without BFD since only the former is withdrawn by the session
+*/
+func getNextHopGroupMemberKey(routeInfoRecord RouteInfoRecord) string {
+	key := fmt.Sprintf("%s/%s/%d/%d", routeInfoRecord.nextHopIp.String(), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+		getNextHopGroupMemberIfIndex(routeInfoRecord), routeInfoRecord.weight)
+	if routeInfoRecord.bfd != "" {
+		key += "/bfd"
+	}
+	return key
+}
+
+/*
+   Next hop of the destination as known to the RIB
+*/
+func getRouteNextHopKey(routeInfoRecord RouteInfoRecord) string {
+	return fmt.Sprintf("%s/%d", routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
+}
+
+func getNextHopGroupKey(nextHops map[string]RouteInfoRecord) string {
+	keys := make([]string, 0, len(nextHops))
+	for _, routeInfoRecord := range nextHops {
+		keys = append(keys, getNextHopGroupMemberKey(routeInfoRecord))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func (table *NextHopGroupTable) isMemberReachable(member NextHopGroupMember) bool {
+	if table.downIntfs[member.ifIndex] {
+		return false
+	}
+	if member.routeInfoRecord.bfd != "" && table.downBfdNextHops[member.routeInfoRecord.nextHopIp.String()] {
+		return false
+	}
+	return member.nextHopPrefix == "" || !table.downPrefixes[getVrfPrefixKey(getRouteResolveVrf(member.routeInfoRecord), member.nextHopPrefix)]
+}
+
+func (table *NextHopGroupTable) newMember(routeInfoRecord RouteInfoRecord) NextHopGroupMember {
+	member := NextHopGroupMember{
+		key:             getNextHopGroupMemberKey(routeInfoRecord),
+		ifIndex:         getNextHopGroupMemberIfIndex(routeInfoRecord),
+		nextHopPrefix:   routeInfoRecord.nextHopPrefix,
+		routeInfoRecord: routeInfoRecord,
+	}
+	member.isReachable = table.isMemberReachable(member)
+	return member
+}
+
+/*
+   Next hops of the group that can be used for forwarding
+*/
+func (group *NextHopGroup) ActiveMembers() []NextHopGroupMember {
+	members := make([]NextHopGroupMember, 0, len(group.members))
+	for _, member := range group.members {
+		if member.isReachable {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+/*
+   Next hops in members that are not in other
+*/
+func diffNextHopGroupMembers(members []NextHopGroupMember, other []NextHopGroupMember) []NextHopGroupMember {
+	diff := make([]NextHopGroupMember, 0)
+	for _, member := range members {
+		found := false
+		for _, otherMember := range other {
+			if member.key == otherMember.key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, member)
+		}
+	}
+	return diff
+}
+
+func (table *NextHopGroupTable) getGroup(ipType defs.IPType, nextHops map[string]RouteInfoRecord) (group *NextHopGroup, created bool) {
+	key := getNextHopGroupKey(nextHops)
+	if group, ok := table.groups[key]; ok {
+		return group, false
+	}
+	group = &NextHopGroup{
+		groupId: table.nextGroupId,
+		key:     key,
+		ipType:  ipType,
+		members: make([]NextHopGroupMember, 0, len(nextHops)),
+		routes:  make(map[string]RouteInfoRecord),
+	}
+	table.nextGroupId++
+	for _, routeInfoRecord := range nextHops {
+		group.members = append(group.members, table.newMember(routeInfoRecord))
+	}
+	sort.Sort(nextHopGroupMembers(group.members))
+	table.groups[key] = group
+	return group, true
+}
+
+type nextHopGroupMembers []NextHopGroupMember
+
+func (members nextHopGroupMembers) Len() int {
+	return len(members)
+}
+func (members nextHopGroupMembers) Less(i, j int) bool {
+	return members[i].key < members[j].key
+}
+func (members nextHopGroupMembers) Swap(i, j int) {
+	members[i], members[j] = members[j], members[i]
+}
+
+/*
+   Moves the destination to the group of its current next hops. Returns the
+   group the destination used before, nil if it is a new destination.
+*/
+func (table *NextHopGroupTable) setRouteGroup(dst string, routeInfoRecord RouteInfoRecord) (oldGroup *NextHopGroup, group *NextHopGroup, created bool) {
+	oldGroup = table.routeGroups[dst]
+	if oldGroup != nil {
+		delete(oldGroup.routes, dst)
+	}
+	nextHops := table.routes[dst]
+	if len(nextHops) == 0 {
+		delete(table.routes, dst)
+		delete(table.routeGroups, dst)
+		return oldGroup, nil, false
+	}
+	group, created = table.getGroup(routeInfoRecord.ipType, nextHops)
+	group.routes[dst] = routeInfoRecord
+	table.routeGroups[dst] = group
+	return oldGroup, group, created
+}
+
+/*
+   Deletes the group when no destination uses it anymore
+*/
+func (table *NextHopGroupTable) releaseGroup(group *NextHopGroup) bool {
+	if group == nil || len(group.routes) > 0 {
+		return false
+	}
+	delete(table.groups, group.key)
+	return true
+}
+
+func (server *RIBDServer) addNextHopGroupRoute(routeInfoRecord RouteInfoRecord) {
+	table := server.NextHopGroupTable
+	dst := getVrfPrefixKey(routeInfoRecord.vrf, getRouteDstNet(routeInfoRecord).String())
+	if _, ok := table.routes[dst]; !ok {
+		table.routes[dst] = make(map[string]RouteInfoRecord)
+	}
+	table.routes[dst][getRouteNextHopKey(routeInfoRecord)] = routeInfoRecord
+	oldGroup, group, created := table.setRouteGroup(dst, routeInfoRecord)
+	logger.Debug("addNextHopGroupRoute: ", dst, " next hop ", routeInfoRecord.nextHopIp.String(), " group ", group.groupId)
+	if created {
+		server.FIBPlugin.CreateNextHopGroup(group)
+	}
+	if oldGroup != group {
+		server.FIBPlugin.SetRouteNextHopGroup(routeInfoRecord, oldGroup, group)
+	}
+	if oldGroup != group && table.releaseGroup(oldGroup) {
+		server.FIBPlugin.DeleteNextHopGroup(oldGroup)
+	}
+}
+
+func (server *RIBDServer) delNextHopGroupRoute(routeInfoRecord RouteInfoRecord) {
+	table := server.NextHopGroupTable
+	dst := getVrfPrefixKey(routeInfoRecord.vrf, getRouteDstNet(routeInfoRecord).String())
+	nextHops, ok := table.routes[dst]
+	if !ok {
+		logger.Debug("delNextHopGroupRoute: route ", dst, " not installed")
+		return
+	}
+	delete(nextHops, getRouteNextHopKey(routeInfoRecord))
+	oldGroup, group, created := table.setRouteGroup(dst, routeInfoRecord)
+	if group == nil {
+		logger.Debug("delNextHopGroupRoute: ", dst, " has no next hops left")
+		server.FIBPlugin.DeleteRoute(routeInfoRecord, oldGroup)
+	} else {
+		if created {
+			server.FIBPlugin.CreateNextHopGroup(group)
+		}
+		if oldGroup != group {
+			server.FIBPlugin.SetRouteNextHopGroup(routeInfoRecord, oldGroup, group)
+		}
+	}
+	if oldGroup != group && table.releaseGroup(oldGroup) {
+		server.FIBPlugin.DeleteNextHopGroup(oldGroup)
+	}
+}
+
+/*
+   Updates the next hops of all the groups using the interface or next hop
+   prefix. The destinations pointing at the groups are not visited.
+*/
+func (server *RIBDServer) processNextHopGroupEvent(event NextHopGroupEvent, up bool) {
+	table := server.NextHopGroupTable
+	logger.Info("processNextHopGroupEvent: ifIndex ", event.ifIndex, " up ", up)
+	if event.ifIndex != -1 {
+		if up {
+			delete(table.downIntfs, event.ifIndex)
+		} else {
+			table.downIntfs[event.ifIndex] = true
+		}
+	}
+	if event.nextHopPrefix != "" {
+		prefix := getVrfPrefixKey(event.vrf, event.nextHopPrefix)
+		if up {
+			delete(table.downPrefixes, prefix)
+		} else {
+			table.downPrefixes[prefix] = true
+		}
+	}
+	if event.bfdNextHop != "" {
+		if up {
+			delete(table.downBfdNextHops, event.bfdNextHop)
+		} else {
+			table.downBfdNextHops[event.bfdNextHop] = true
+		}
+	}
+	updated := 0
+	for _, group := range table.groups {
+		oldMembers := group.ActiveMembers()
+		changed := false
+		for idx := 0; idx < len(group.members); idx++ {
+			isReachable := table.isMemberReachable(group.members[idx])
+			if isReachable != group.members[idx].isReachable {
+				group.members[idx].isReachable = isReachable
+				changed = true
+			}
+		}
+		if changed {
+			server.FIBPlugin.UpdateNextHopGroup(group, oldMembers)
+			updated++
+		}
+	}
+	logger.Info("processNextHopGroupEvent: updated ", updated, " of ", len(table.groups), " next hop groups")
+}
+
+/*
+   Queues the next hop state change to the FIB ahead of the walk over the
+   dependent routes
+*/
+func notifyNextHopGroups(vrf string, ifIndex ribd.Int, nextHopPrefix patriciaDB.Prefix, up bool) {
+	op := defs.NextHopDown
+	if up {
+		op = defs.NextHopUp
+	}
+	RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{
+		OrigConfigObject: NextHopGroupEvent{vrf: vrf, ifIndex: ifIndex, nextHopPrefix: string(nextHopPrefix)},
+		Op:               op,
+	}
+}
@@ -0,0 +1,232 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNextHopGroupApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"strconv"
+	"testing"
+	"utils/clntUtils/clntIntfs/asicdClntIntfs"
+)
+
+type testFIBPlugin struct {
+	groupsCreated int
+	groupsDeleted int
+	groupUpdates  int
+	routeUpdates  int
+	routesDeleted int
+	flushes       int
+}
+
+func (plugin *testFIBPlugin) CreateNextHopGroup(group *NextHopGroup) {
+	plugin.groupsCreated++
+}
+func (plugin *testFIBPlugin) UpdateNextHopGroup(group *NextHopGroup, oldMembers []NextHopGroupMember) {
+	plugin.groupUpdates++
+}
+func (plugin *testFIBPlugin) DeleteNextHopGroup(group *NextHopGroup) {
+	plugin.groupsDeleted++
+}
+func (plugin *testFIBPlugin) SetRouteNextHopGroup(routeInfoRecord RouteInfoRecord, oldGroup *NextHopGroup, group *NextHopGroup) {
+	plugin.routeUpdates++
+}
+func (plugin *testFIBPlugin) DeleteRoute(routeInfoRecord RouteInfoRecord, group *NextHopGroup) {
+	plugin.routesDeleted++
+}
+func (plugin *testFIBPlugin) Flush() {
+	plugin.flushes++
+}
+
+/*
+   asicd client with next hop group objects, the other asicd calls are not
+   used by the tests
+*/
+type testAsicdNextHopGroupClnt struct {
+	asicdClntIntfs.AsicdClntIntf
+	groups        map[int32][]string
+	groupUpdates  int
+	routeSets     int
+	routesDeleted int
+}
+
+func (clnt *testAsicdNextHopGroupClnt) OnewayCreateNextHopGroup(groupId int32, nextHopIps []string, weights []int32) {
+	clnt.groups[groupId] = nextHopIps
+}
+func (clnt *testAsicdNextHopGroupClnt) OnewayUpdateNextHopGroup(groupId int32, nextHopIps []string, weights []int32) {
+	clnt.groups[groupId] = nextHopIps
+	clnt.groupUpdates++
+}
+func (clnt *testAsicdNextHopGroupClnt) OnewayDeleteNextHopGroup(groupId int32) {
+	delete(clnt.groups, groupId)
+}
+func (clnt *testAsicdNextHopGroupClnt) OnewaySetRouteNextHopGroup(destinationNw string, networkMask string, groupId int32) {
+	clnt.routeSets++
+}
+func (clnt *testAsicdNextHopGroupClnt) OnewayDeleteRouteNextHopGroup(destinationNw string, networkMask string) {
+	clnt.routesDeleted++
+}
+
+func buildTestNextHopGroupRouteInfoRecord(destNet string, nextHop string, ifIndex int, nextHopPrefix string) RouteInfoRecord {
+	return RouteInfoRecord{
+		ipType:                defs.IPv4,
+		destNetIp:             net.ParseIP(destNet),
+		networkMask:           net.ParseIP("255.255.255.0"),
+		nextHopIp:             net.ParseIP(nextHop),
+		resolvedNextHopIpIntf: ribdInt.NextHopInfo{NextHopIp: nextHop, NextHopIfIndex: ribdInt.Int(ifIndex)},
+		nextHopPrefix:         nextHopPrefix,
+		nextHopIfIndex:        -1,
+		weight:                ribd.Int(0),
+		protocol:              defs.STATIC,
+	}
+}
+
+func TestNextHopGroups(t *testing.T) {
+	fmt.Println("****TestNextHopGroups****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	plugin := &testFIBPlugin{}
+	testServer := &RIBDServer{FIBPlugin: plugin, NextHopGroupTable: NewNextHopGroupTable()}
+	nh1Prefix := "11.1.10.0/24"
+	routeCount := 100
+	for i := 0; i < routeCount; i++ {
+		destNet := "40.0." + strconv.Itoa(i) + ".0"
+		testServer.addNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "11.1.10.2", 1, nh1Prefix))
+		testServer.addNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "12.1.10.2", 2, ""))
+	}
+	table := testServer.NextHopGroupTable
+	fmt.Println("groups:", len(table.groups), " created:", plugin.groupsCreated, " deleted:", plugin.groupsDeleted)
+	if len(table.groups) != 1 {
+		t.Error("Expected the ecmp routes to share one next hop group, found ", len(table.groups))
+	}
+	for _, group := range table.groups {
+		if len(group.routes) != routeCount || len(group.ActiveMembers()) != 2 {
+			t.Error("Unexpected next hop group ", group.key, " routes ", len(group.routes), " active next hops ", len(group.ActiveMembers()))
+		}
+	}
+
+	plugin.routeUpdates = 0
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: 2}, false)
+	fmt.Println("after interface down group updates:", plugin.groupUpdates, " route updates:", plugin.routeUpdates)
+	if plugin.groupUpdates != 1 || plugin.routeUpdates != 0 {
+		t.Error("Interface down updated ", plugin.groupUpdates, " groups and ", plugin.routeUpdates, " routes, expected one group update")
+	}
+	for _, group := range table.groups {
+		members := group.ActiveMembers()
+		if len(members) != 1 || members[0].routeInfoRecord.nextHopIp.String() != "11.1.10.2" {
+			t.Error("Unexpected active next hops ", members, " after interface down")
+		}
+	}
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: -1, nextHopPrefix: nh1Prefix}, false)
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: 2}, true)
+	for _, group := range table.groups {
+		members := group.ActiveMembers()
+		if len(members) != 1 || members[0].routeInfoRecord.nextHopIp.String() != "12.1.10.2" {
+			t.Error("Unexpected active next hops ", members, " after next hop prefix down")
+		}
+	}
+	if plugin.groupUpdates != 3 {
+		t.Error("Expected 3 group updates, found ", plugin.groupUpdates)
+	}
+
+	for i := 0; i < routeCount; i++ {
+		destNet := "40.0." + strconv.Itoa(i) + ".0"
+		testServer.delNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "12.1.10.2", 2, ""))
+	}
+	fmt.Println("groups after ecmp next hop delete:", len(table.groups), " created:", plugin.groupsCreated, " deleted:", plugin.groupsDeleted)
+	if len(table.groups) != 1 || plugin.groupsCreated-plugin.groupsDeleted != 1 {
+		t.Error("Unexpected next hop groups after deleting an ecmp next hop")
+	}
+	for i := 0; i < routeCount; i++ {
+		destNet := "40.0." + strconv.Itoa(i) + ".0"
+		testServer.delNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "11.1.10.2", 1, nh1Prefix))
+	}
+	if len(table.groups) != 0 || len(table.routes) != 0 || plugin.routesDeleted != routeCount || plugin.groupsCreated != plugin.groupsDeleted {
+		t.Error("Next hop groups or routes left after delete, groups:", len(table.groups), " routes:", len(table.routes))
+	}
+	fmt.Println("***********************************")
+}
+
+func TestAsicdNextHopGroupObjects(t *testing.T) {
+	fmt.Println("****TestAsicdNextHopGroupObjects****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	clnt := &testAsicdNextHopGroupClnt{groups: make(map[int32][]string)}
+	testServer := &RIBDServer{AsicdPlugin: clnt, NextHopGroupTable: NewNextHopGroupTable()}
+	testServer.FIBPlugin = &AsicdFIBPlugin{server: testServer}
+	nh1Prefix := "11.1.10.0/24"
+	routeCount := 100
+	for i := 0; i < routeCount; i++ {
+		destNet := "40.0." + strconv.Itoa(i) + ".0"
+		testServer.addNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "11.1.10.2", 1, nh1Prefix))
+		testServer.addNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "12.1.10.2", 2, ""))
+	}
+	fmt.Println("asicd groups:", clnt.groups, " route sets:", clnt.routeSets)
+	if len(clnt.groups) != 1 {
+		t.Fatal("Expected one next hop group in asicd, found ", len(clnt.groups))
+	}
+	for _, nextHopIps := range clnt.groups {
+		if len(nextHopIps) != 2 {
+			t.Error("Unexpected next hops ", nextHopIps, " of the asicd next hop group")
+		}
+	}
+
+	routeSets := clnt.routeSets
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: 2}, false)
+	fmt.Println("after interface down group updates:", clnt.groupUpdates, " route sets:", clnt.routeSets-routeSets)
+	if clnt.groupUpdates != 1 || clnt.routeSets != routeSets {
+		t.Error("Interface down updated ", clnt.groupUpdates, " groups and ", clnt.routeSets-routeSets, " routes, expected one group update")
+	}
+	for _, nextHopIps := range clnt.groups {
+		if len(nextHopIps) != 1 || nextHopIps[0] != "11.1.10.2" {
+			t.Error("Unexpected next hops ", nextHopIps, " after interface down")
+		}
+	}
+
+	//without usable next hops the routes are removed along with the group
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: -1, nextHopPrefix: nh1Prefix}, false)
+	if len(clnt.groups) != 0 || clnt.routesDeleted != routeCount {
+		t.Error("Expected the group and its ", routeCount, " routes to be removed, groups:", len(clnt.groups), " routes deleted:", clnt.routesDeleted)
+	}
+	testServer.processNextHopGroupEvent(NextHopGroupEvent{ifIndex: 2}, true)
+	if len(clnt.groups) != 1 || clnt.routeSets != routeSets+routeCount {
+		t.Error("Expected the group and its ", routeCount, " routes back, groups:", len(clnt.groups), " route sets:", clnt.routeSets-routeSets)
+	}
+
+	for i := 0; i < routeCount; i++ {
+		destNet := "40.0." + strconv.Itoa(i) + ".0"
+		testServer.delNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "11.1.10.2", 1, nh1Prefix))
+		testServer.delNextHopGroupRoute(buildTestNextHopGroupRouteInfoRecord(destNet, "12.1.10.2", 2, ""))
+	}
+	if len(clnt.groups) != 0 || clnt.routesDeleted != 2*routeCount {
+		t.Error("Next hop groups or routes left in asicd after delete, groups:", len(clnt.groups), " routes deleted:", clnt.routesDeleted)
+	}
+	fmt.Println("***********************************")
+}
@@ -41,7 +41,7 @@ func (ribdServiceHandler *RIBDServer) NotificationServer() {
 		notificationMsg := <-ribdServiceHandler.NotificationChannel
 		logger.Info("Event received with eventInfo: ", notificationMsg.eventInfo)
 		eventInfo := RouteEventInfo{timeStamp: time.Now().String(), eventInfo: notificationMsg.eventInfo}
-		localRouteEventsDB = append(localRouteEventsDB, eventInfo)
+		ribdServiceHandler.RIB.AddRouteEvent(eventInfo)
 		notificationMsg.pub_socket.Send(notificationMsg.msg, nanomsg.DontWait)
 	}
 }
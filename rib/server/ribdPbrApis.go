@@ -0,0 +1,495 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdPbrApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"ribdInt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+   Protocol name PBR uses to track the reachability of the next hops of its
+   rules. The reachability updates are handled in ribd instead of being
+   published.
+*/
+const PbrTrackProtocol = "PBR"
+
+const (
+	PbrDscpAny = -1
+	PbrDscpMax = 63
+)
+
+var pbrIpProtocols = map[string]int{
+	"icmp":   1,
+	"tcp":    6,
+	"udp":    17,
+	"icmpv6": 58,
+	"sctp":   132,
+}
+
+/*
+   Match fields and action of a PBR rule. Unset match fields match all the
+   packets. The next hop of the rule is resolved in the VRF of the rule, a
+   rule without a next hop looks up the routing table of its VRF.
+*/
+type PbrRule struct {
+	seq       int32
+	srcPrefix *net.IPNet
+	dstPrefix *net.IPNet
+	protocol  int //IP protocol number, 0 for any
+	srcPort   int32
+	dstPort   int32
+	dscp      int32
+	nextHopIp string
+	vrf       string
+	order     int //position of the rule in its policy
+	//resolved next hop and FIB state of the rule
+	nextHop   ribdInt.NextHopInfo
+	installed bool
+}
+
+type PbrPolicy struct {
+	name  string
+	rules []*PbrRule //sorted by sequence number
+	intfs map[int32]bool
+}
+
+type PbrRuleSlice []*PbrRule
+
+func (slice PbrRuleSlice) Len() int {
+	return len(slice)
+}
+func (slice PbrRuleSlice) Less(i, j int) bool {
+	return slice[i].seq < slice[j].seq
+}
+func (slice PbrRuleSlice) Swap(i, j int) {
+	slice[i], slice[j] = slice[j], slice[i]
+}
+
+/*
+   PBR backends program the rules of a policy on each interface it is
+   attached to. PBR policies are rejected when the backend can not install
+   rules.
+*/
+type PBRPlugin interface {
+	PbrSupported() bool
+	InstallPbrRule(policy string, rule *PbrRule, ifIndex int32)
+	DeletePbrRule(policy string, rule *PbrRule, ifIndex int32)
+}
+
+var PbrPolicyMap = make(map[string]*PbrPolicy)
+var PbrIntfMap = make(map[int32]string) //policy attached to the interface
+
+func (rule *PbrRule) resolveVrf() string {
+	return getVrfName(rule.vrf)
+}
+
+func parsePbrPrefix(prefix string) (*net.IPNet, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid prefix ", prefix))
+	}
+	return ipNet, nil
+}
+
+func parsePbrProtocol(protocol string) (int, error) {
+	protocol = strings.ToLower(protocol)
+	if protocol == "" || protocol == "any" {
+		return 0, nil
+	}
+	if val, ok := pbrIpProtocols[protocol]; ok {
+		return val, nil
+	}
+	val, err := strconv.Atoi(protocol)
+	if err != nil || val <= 0 || val > 255 {
+		return 0, errors.New(fmt.Sprintln("Invalid protocol ", protocol))
+	}
+	return val, nil
+}
+
+func hasPbrPorts(protocol int) bool {
+	return protocol == pbrIpProtocols["tcp"] || protocol == pbrIpProtocols["udp"] || protocol == pbrIpProtocols["sctp"]
+}
+
+func buildPbrRule(cfg *ribdInt.PbrRule) (rule *PbrRule, err error) {
+	rule = &PbrRule{
+		seq:       cfg.Seq,
+		srcPort:   cfg.SrcPort,
+		dstPort:   cfg.DstPort,
+		dscp:      cfg.Dscp,
+		nextHopIp: cfg.NextHopIp,
+		vrf:       cfg.Vrf,
+	}
+	if rule.srcPrefix, err = parsePbrPrefix(cfg.SrcPrefix); err != nil {
+		return nil, err
+	}
+	if rule.dstPrefix, err = parsePbrPrefix(cfg.DstPrefix); err != nil {
+		return nil, err
+	}
+	if rule.srcPrefix != nil && rule.dstPrefix != nil && (rule.srcPrefix.IP.To4() == nil) != (rule.dstPrefix.IP.To4() == nil) {
+		return nil, errors.New(fmt.Sprintln("Source prefix ", cfg.SrcPrefix, " and destination prefix ", cfg.DstPrefix, " of different address families"))
+	}
+	if rule.protocol, err = parsePbrProtocol(cfg.Protocol); err != nil {
+		return nil, err
+	}
+	if rule.srcPort < 0 || rule.srcPort > 65535 || rule.dstPort < 0 || rule.dstPort > 65535 {
+		return nil, errors.New(fmt.Sprintln("Invalid ports ", cfg.SrcPort, " ", cfg.DstPort))
+	}
+	if (rule.srcPort != 0 || rule.dstPort != 0) && !hasPbrPorts(rule.protocol) {
+		return nil, errors.New(fmt.Sprintln("Ports can not be matched for protocol ", cfg.Protocol))
+	}
+	if rule.dscp < PbrDscpAny || rule.dscp > PbrDscpMax {
+		return nil, errors.New(fmt.Sprintln("Invalid DSCP ", cfg.Dscp))
+	}
+	if rule.nextHopIp == "" && rule.vrf == "" {
+		return nil, errors.New(fmt.Sprintln("Rule ", cfg.Seq, " has no next hop or VRF"))
+	}
+	if rule.nextHopIp != "" && net.ParseIP(rule.nextHopIp) == nil {
+		return nil, errors.New(fmt.Sprintln("Invalid next hop ", cfg.NextHopIp))
+	}
+	if getVrfRIB(rule.vrf) == nil {
+		return nil, errors.New(fmt.Sprintln("VRF ", cfg.Vrf, " not found"))
+	}
+	return rule, nil
+}
+
+func buildPbrRules(cfgRules []*ribdInt.PbrRule) (rules []*PbrRule, err error) {
+	seqs := make(map[int32]bool)
+	for _, cfg := range cfgRules {
+		if seqs[cfg.Seq] {
+			return nil, errors.New(fmt.Sprintln("Duplicate rule sequence number ", cfg.Seq))
+		}
+		seqs[cfg.Seq] = true
+		rule, err := buildPbrRule(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	sort.Sort(PbrRuleSlice(rules))
+	for i, rule := range rules {
+		rule.order = i
+	}
+	return rules, nil
+}
+
+func (m RIBDServer) PbrPolicyConfigValidationCheck(cfg *ribdInt.PbrPolicy, op string) (err error) {
+	if cfg.Name == "" {
+		return errors.New("Invalid PBR policy name")
+	}
+	_, ok := PbrPolicyMap[cfg.Name]
+	if op == "add" && ok {
+		return errors.New(fmt.Sprintln("PBR policy ", cfg.Name, " already exists"))
+	}
+	if op != "add" && !ok {
+		return errors.New(fmt.Sprintln("PBR policy ", cfg.Name, " not found"))
+	}
+	if op == "del" {
+		return nil
+	}
+	if m.PBRPlugin == nil || !m.PBRPlugin.PbrSupported() {
+		logger.Err("PbrPolicyConfigValidationCheck: PBR rules can not be installed in the FIB")
+		return errors.New("PBR not supported by the FIB")
+	}
+	if _, err = buildPbrRules(cfg.Rules); err != nil {
+		logger.Err("PbrPolicyConfigValidationCheck: ", err)
+		return err
+	}
+	intfs, err := m.getVrfIntfs(cfg.IntfList)
+	if err != nil {
+		return err
+	}
+	for _, ifIndex := range intfs {
+		if policy, ok := PbrIntfMap[ifIndex]; ok && policy != cfg.Name {
+			return errors.New(fmt.Sprintln("Interface ", ifIndex, " already has PBR policy ", policy))
+		}
+	}
+	return nil
+}
+
+/*
+   VRFs used by the rules of a PBR policy can not be deleted
+*/
+func isPbrVrfConfigured(vrf string) bool {
+	for _, policy := range PbrPolicyMap {
+		for _, rule := range policy.rules {
+			if rule.vrf == vrf {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+   Resolves the next hop of the rule through the routing table of its VRF.
+   Rules that only set the VRF are always usable.
+*/
+func (m RIBDServer) resolvePbrRule(rule *PbrRule) bool {
+	if rule.nextHopIp == "" {
+		return true
+	}
+	nextHopIntf, err := m.GetVrfRouteReachabilityInfo(rule.resolveVrf(), rule.nextHopIp, -1)
+	if err != nil || nextHopIntf == nil || !nextHopIntf.IsReachable {
+		logger.Info("resolvePbrRule: next hop ", rule.nextHopIp, " of rule ", rule.seq, " not reachable")
+		rule.nextHop = ribdInt.NextHopInfo{}
+		return false
+	}
+	rule.nextHop = *nextHopIntf
+	//connected next hops are resolved to themselves
+	if rule.nextHop.NextHopIp == "" || net.ParseIP(rule.nextHop.NextHopIp).IsUnspecified() {
+		rule.nextHop.NextHopIp = rule.nextHopIp
+	}
+	return true
+}
+
+/*
+   Installs the rule on the interfaces of the policy when its action can be
+   used, otherwise the packets it matches are routed normally
+*/
+func (m RIBDServer) installPbrRule(policy *PbrPolicy, rule *PbrRule) {
+	if rule.installed {
+		m.deletePbrRule(policy, rule)
+	}
+	if !m.resolvePbrRule(rule) {
+		return
+	}
+	for ifIndex, _ := range policy.intfs {
+		m.PBRPlugin.InstallPbrRule(policy.name, rule, ifIndex)
+	}
+	rule.installed = true
+}
+
+func (m RIBDServer) deletePbrRule(policy *PbrPolicy, rule *PbrRule) {
+	if !rule.installed {
+		return
+	}
+	for ifIndex, _ := range policy.intfs {
+		m.PBRPlugin.DeletePbrRule(policy.name, rule, ifIndex)
+	}
+	rule.installed = false
+}
+
+func (m RIBDServer) addPbrRules(policy *PbrPolicy, rules []*PbrRule) {
+	for _, rule := range rules {
+		if rule.nextHopIp != "" {
+			m.TrackVrfReachabilityStatus(rule.resolveVrf(), rule.nextHopIp, PbrTrackProtocol, "add")
+		}
+		m.installPbrRule(policy, rule)
+	}
+	policy.rules = rules
+}
+
+/*
+   Withdraws the rules and stops tracking the next hops no other rule uses
+*/
+func (m RIBDServer) releasePbrRules(policy *PbrPolicy, rules []*PbrRule) {
+	for _, rule := range rules {
+		m.deletePbrRule(policy, rule)
+	}
+	released := make(map[string]bool)
+	for _, rule := range rules {
+		key := rule.resolveVrf() + ":" + rule.nextHopIp
+		if rule.nextHopIp == "" || released[key] || isPbrNextHopUsed(rule.resolveVrf(), rule.nextHopIp) {
+			continue
+		}
+		released[key] = true
+		m.TrackVrfReachabilityStatus(rule.resolveVrf(), rule.nextHopIp, PbrTrackProtocol, "del")
+	}
+}
+
+func isPbrNextHopUsed(vrf string, nextHopIp string) bool {
+	for _, policy := range PbrPolicyMap {
+		for _, rule := range policy.rules {
+			if rule.nextHopIp == nextHopIp && rule.resolveVrf() == vrf {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m RIBDServer) attachPbrPolicy(policy *PbrPolicy, ifIndex int32) {
+	if policy.intfs[ifIndex] {
+		return
+	}
+	policy.intfs[ifIndex] = true
+	PbrIntfMap[ifIndex] = policy.name
+	for _, rule := range policy.rules {
+		if rule.installed {
+			m.PBRPlugin.InstallPbrRule(policy.name, rule, ifIndex)
+		}
+	}
+}
+
+func (m RIBDServer) detachPbrPolicy(policy *PbrPolicy, ifIndex int32) {
+	if !policy.intfs[ifIndex] {
+		return
+	}
+	for _, rule := range policy.rules {
+		if rule.installed {
+			m.PBRPlugin.DeletePbrRule(policy.name, rule, ifIndex)
+		}
+	}
+	delete(policy.intfs, ifIndex)
+	delete(PbrIntfMap, ifIndex)
+}
+
+func (m RIBDServer) ProcessPbrPolicyCreateConfig(cfg *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("ProcessPbrPolicyCreateConfig: policy ", cfg.Name, " interfaces ", cfg.IntfList)
+	if _, ok := PbrPolicyMap[cfg.Name]; ok {
+		return false, errors.New(fmt.Sprintln("PBR policy ", cfg.Name, " already exists"))
+	}
+	rules, err := buildPbrRules(cfg.Rules)
+	if err != nil {
+		return false, err
+	}
+	intfs, err := m.getVrfIntfs(cfg.IntfList)
+	if err != nil {
+		return false, err
+	}
+	policy := &PbrPolicy{
+		name:  cfg.Name,
+		intfs: make(map[int32]bool),
+	}
+	PbrPolicyMap[cfg.Name] = policy
+	m.addPbrRules(policy, rules)
+	for _, ifIndex := range intfs {
+		m.attachPbrPolicy(policy, ifIndex)
+	}
+	return true, nil
+}
+
+func (m RIBDServer) ProcessPbrPolicyDeleteConfig(cfg *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("ProcessPbrPolicyDeleteConfig: policy ", cfg.Name)
+	policy, ok := PbrPolicyMap[cfg.Name]
+	if !ok {
+		return false, errors.New(fmt.Sprintln("PBR policy ", cfg.Name, " not found"))
+	}
+	for ifIndex, _ := range policy.intfs {
+		m.detachPbrPolicy(policy, ifIndex)
+	}
+	rules := policy.rules
+	delete(PbrPolicyMap, cfg.Name)
+	m.releasePbrRules(policy, rules)
+	return true, nil
+}
+
+/*
+   The rules of the policy are replaced, the interfaces it is no longer
+   attached to are detached first
+*/
+func (m RIBDServer) ProcessPbrPolicyUpdateConfig(origconfig *ribdInt.PbrPolicy, newconfig *ribdInt.PbrPolicy) (val bool, err error) {
+	logger.Info("ProcessPbrPolicyUpdateConfig: policy ", newconfig.Name, " interfaces ", newconfig.IntfList)
+	policy, ok := PbrPolicyMap[newconfig.Name]
+	if !ok {
+		return false, errors.New(fmt.Sprintln("PBR policy ", newconfig.Name, " not found"))
+	}
+	rules, err := buildPbrRules(newconfig.Rules)
+	if err != nil {
+		return false, err
+	}
+	intfs, err := m.getVrfIntfs(newconfig.IntfList)
+	if err != nil {
+		return false, err
+	}
+	newIntfs := make(map[int32]bool)
+	for _, ifIndex := range intfs {
+		newIntfs[ifIndex] = true
+	}
+	for ifIndex, _ := range policy.intfs {
+		if !newIntfs[ifIndex] {
+			m.detachPbrPolicy(policy, ifIndex)
+		}
+	}
+	oldRules := policy.rules
+	policy.rules = nil
+	m.releasePbrRules(policy, oldRules)
+	m.addPbrRules(policy, rules)
+	for _, ifIndex := range intfs {
+		m.attachPbrPolicy(policy, ifIndex)
+	}
+	return true, nil
+}
+
+/*
+   Reachability updates of the next hops tracked by PBR. The rules using the
+   next hop are resolved again and reinstalled or withdrawn.
+*/
+func (m RIBDServer) ProcessPbrNextHopReachability(info RouteReachabilityStatusInfo) {
+	logger.Info("ProcessPbrNextHopReachability: next hop ", info.destNet, " vrf ", getVrfName(info.vrf), " status ", info.status)
+	for _, policy := range PbrPolicyMap {
+		for _, rule := range policy.rules {
+			if rule.nextHopIp != info.destNet || rule.resolveVrf() != getVrfName(info.vrf) {
+				continue
+			}
+			nextHop := rule.nextHop
+			if !m.resolvePbrRule(rule) {
+				m.deletePbrRule(policy, rule)
+				continue
+			}
+			if !rule.installed || nextHop.NextHopIp != rule.nextHop.NextHopIp || nextHop.NextHopIfIndex != rule.nextHop.NextHopIfIndex {
+				m.installPbrRule(policy, rule)
+			}
+		}
+	}
+}
+
+func (m RIBDServer) GetPbrPolicyState(name string) (*ribdInt.PbrPolicyState, error) {
+	policy, ok := PbrPolicyMap[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintln("PBR policy ", name, " not found"))
+	}
+	state := ribdInt.NewPbrPolicyState()
+	state.Name = policy.name
+	state.IntfList = make([]string, 0)
+	for ifIndex, _ := range policy.intfs {
+		intfref := strconv.Itoa(int(ifIndex))
+		if intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(ifIndex); ok {
+			intfref = intfEntry.name
+		}
+		state.IntfList = append(state.IntfList, intfref)
+	}
+	sort.Strings(state.IntfList)
+	state.Rules = make([]*ribdInt.PbrRuleState, 0)
+	for _, rule := range policy.rules {
+		state.Rules = append(state.Rules, &ribdInt.PbrRuleState{
+			Seq:               rule.seq,
+			NextHopIp:         rule.nextHopIp,
+			Vrf:               rule.resolveVrf(),
+			ResolvedNextHopIp: rule.nextHop.NextHopIp,
+			Installed:         rule.installed,
+		})
+	}
+	return state, nil
+}
@@ -0,0 +1,241 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdPbrApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribdInt"
+	"testing"
+	"utils/patriciaDB"
+)
+
+type testPBRPlugin struct {
+	rules       map[string]*PbrRule
+	unsupported bool
+}
+
+func (plugin *testPBRPlugin) PbrSupported() bool {
+	return !plugin.unsupported
+}
+func (plugin *testPBRPlugin) InstallPbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	plugin.rules[fmt.Sprint(policy, ":", rule.seq, ":", ifIndex)] = rule
+}
+func (plugin *testPBRPlugin) DeletePbrRule(policy string, rule *PbrRule, ifIndex int32) {
+	delete(plugin.rules, fmt.Sprint(policy, ":", rule.seq, ":", ifIndex))
+}
+
+func TestBuildPbrRule(t *testing.T) {
+	fmt.Println("****TestBuildPbrRule****")
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	RouteServiceHandler = &RIBDServer{RIB: NewRIB()}
+	RouteServiceHandler.RIB.vrfs["red"] = newVrfRIB("red", defaultAdminDistanceMap())
+
+	validRules := []ribdInt.PbrRule{
+		{Seq: 10, SrcPrefix: "10.1.0.0/16", Protocol: "tcp", DstPort: 80, Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+		{Seq: 20, DstPrefix: "2001:db8::/32", Protocol: "17", SrcPort: 53, Dscp: 46, Vrf: "red"},
+		{Seq: 30, Dscp: 0, NextHopIp: "12.1.10.2", Vrf: "red"},
+	}
+	for _, cfg := range validRules {
+		rule, err := buildPbrRule(&cfg)
+		fmt.Println("rule:", rule, " err:", err)
+		if err != nil {
+			t.Error("Valid rule ", cfg, " rejected with err ", err)
+		}
+	}
+	invalidRules := []ribdInt.PbrRule{
+		{Seq: 10, SrcPrefix: "10.1.0.0", Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+		{Seq: 10, SrcPrefix: "10.1.0.0/16", DstPrefix: "2001:db8::/32", Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+		{Seq: 10, Protocol: "icmp", DstPort: 80, Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+		{Seq: 10, Protocol: "gre2", Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+		{Seq: 10, Dscp: 64, NextHopIp: "11.1.10.2"},
+		{Seq: 10, Dscp: PbrDscpAny},
+		{Seq: 10, Dscp: PbrDscpAny, NextHopIp: "11.1.10"},
+		{Seq: 10, Dscp: PbrDscpAny, Vrf: "blue"},
+	}
+	for _, cfg := range invalidRules {
+		if _, err := buildPbrRule(&cfg); err == nil {
+			t.Error("Invalid rule ", cfg, " accepted")
+		}
+	}
+	if _, err := buildPbrRules([]*ribdInt.PbrRule{&validRules[0], &validRules[0]}); err == nil {
+		t.Error("Rules with the same sequence number accepted")
+	}
+	rules, err := buildPbrRules([]*ribdInt.PbrRule{&validRules[2], &validRules[0]})
+	if err != nil || rules[0].seq != 10 || rules[0].order != 0 || rules[1].seq != 30 || rules[1].order != 1 {
+		t.Error("Rules not ordered by sequence number ", rules, " err ", err)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestPbrPolicy(t *testing.T) {
+	fmt.Println("****TestPbrPolicy****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	savedHandler := RouteServiceHandler
+	savedPbrPolicyMap, savedPbrIntfMap := PbrPolicyMap, PbrIntfMap
+	defer func() {
+		RouteServiceHandler = savedHandler
+		PbrPolicyMap, PbrIntfMap = savedPbrPolicyMap, savedPbrIntfMap
+	}()
+	plugin := &testPBRPlugin{rules: make(map[string]*PbrRule)}
+	testServer := &RIBDServer{PBRPlugin: plugin, RIB: NewRIB()}
+	RouteServiceHandler = testServer
+	testServer.RIB.vrfs["red"] = newVrfRIB("red", defaultAdminDistanceMap())
+	testServer.RIB.SetIntfEntry(1, "eth1")
+	testServer.RIB.SetIntfEntry(2, "eth2")
+	PbrPolicyMap = make(map[string]*PbrPolicy)
+	PbrIntfMap = make(map[int32]string)
+
+	prefix, _ := getNetowrkPrefixFromStrings("11.1.10.0", "255.255.255.0")
+	routeList := RouteInfoRecordList{
+		selectedRouteProtocol: "CONNECTED",
+		routeInfoProtocolMap: map[string][]RouteInfoRecord{
+			"CONNECTED": {{
+				ipType:         defs.IPv4,
+				destNetIp:      net.ParseIP("11.1.10.0"),
+				networkMask:    net.ParseIP("255.255.255.0"),
+				networkAddr:    "11.1.10.0/24",
+				nextHopIp:      net.ParseIP("0.0.0.0"),
+				nextHopIpType:  defs.IPv4,
+				nextHopIfIndex: 2,
+				protocol:       defs.CONNECTED,
+			}},
+		},
+	}
+	testServer.RIB.routeInfoMap(DefaultVrf, defs.IPv4).Insert(prefix, routeList)
+
+	cfg := &ribdInt.PbrPolicy{
+		Name:     "web",
+		IntfList: []string{"1"},
+		Rules: []*ribdInt.PbrRule{
+			{Seq: 10, Protocol: "tcp", DstPort: 80, Dscp: PbrDscpAny, NextHopIp: "11.1.10.2"},
+			{Seq: 20, Dscp: PbrDscpAny, Vrf: "red"},
+		},
+	}
+	if err := testServer.PbrPolicyConfigValidationCheck(cfg, "add"); err != nil {
+		t.Error("Valid PBR policy rejected with err ", err)
+	}
+	testServer.ProcessPbrPolicyCreateConfig(cfg)
+	fmt.Println("installed rules:", plugin.rules)
+	if len(plugin.rules) != 2 || plugin.rules["web:10:1"] == nil || plugin.rules["web:20:1"] == nil {
+		t.Error("Unexpected installed rules ", plugin.rules)
+	}
+	if plugin.rules["web:10:1"].nextHop.NextHopIp != "11.1.10.2" {
+		t.Error("Unexpected resolved next hop ", plugin.rules["web:10:1"].nextHop)
+	}
+	if list := testServer.RIB.Vrf(DefaultVrf).trackReachabilityMap["11.1.10.2"]; len(list) != 1 || list[0] != PbrTrackProtocol {
+		t.Error("Next hop not tracked ", testServer.RIB.Vrf(DefaultVrf).trackReachabilityMap)
+	}
+	if err := testServer.PbrPolicyConfigValidationCheck(&ribdInt.PbrPolicy{Name: "voice", IntfList: []string{"1"}}, "add"); err == nil {
+		t.Error("Second PBR policy accepted on interface 1")
+	}
+	if err := testServer.VrfConfigValidationCheck(&ribdInt.Vrf{VrfName: "red"}, "del"); err == nil {
+		t.Error("VRF used by a PBR policy deleted")
+	}
+
+	//the rule is withdrawn while its next hop is not reachable
+	testServer.RIB.routeInfoMap(DefaultVrf, defs.IPv4).Delete(prefix)
+	RouteReachabilityStatusNotificationSend(PbrTrackProtocol, RouteReachabilityStatusInfo{destNet: "11.1.10.2", status: "Down", vrf: DefaultVrf})
+	if len(plugin.rules) != 1 || plugin.rules["web:20:1"] == nil {
+		t.Error("Unexpected installed rules ", plugin.rules, " after next hop down")
+	}
+	testServer.RIB.routeInfoMap(DefaultVrf, defs.IPv4).Insert(prefix, routeList)
+	RouteReachabilityStatusNotificationSend(PbrTrackProtocol, RouteReachabilityStatusInfo{destNet: "11.1.10.2", status: "Up", vrf: DefaultVrf})
+	if len(plugin.rules) != 2 {
+		t.Error("Unexpected installed rules ", plugin.rules, " after next hop up")
+	}
+
+	newCfg := &ribdInt.PbrPolicy{Name: "web", IntfList: []string{"2"}, Rules: cfg.Rules[1:]}
+	testServer.ProcessPbrPolicyUpdateConfig(cfg, newCfg)
+	if len(plugin.rules) != 1 || plugin.rules["web:20:2"] == nil || PbrIntfMap[2] != "web" || PbrIntfMap[1] != "" {
+		t.Error("Unexpected installed rules ", plugin.rules, " after update")
+	}
+	if len(testServer.RIB.Vrf(DefaultVrf).trackReachabilityMap["11.1.10.2"]) != 0 {
+		t.Error("Next hop of the deleted rule still tracked ", testServer.RIB.Vrf(DefaultVrf).trackReachabilityMap)
+	}
+
+	testServer.ProcessPbrPolicyDeleteConfig(newCfg)
+	if len(plugin.rules) != 0 || len(PbrPolicyMap) != 0 || len(PbrIntfMap) != 0 {
+		t.Error("PBR policy not deleted ", plugin.rules, PbrPolicyMap, PbrIntfMap)
+	}
+	fmt.Println("***********************************")
+}
+
+type testAsicdPbrClnt struct {
+	testAsicdNextHopGroupClnt
+	rules map[string]string
+}
+
+func (clnt *testAsicdPbrClnt) OnewayCreatePbrRule(policy string, seq int32, ifIndex int32, srcPrefix string, dstPrefix string, protocol int32, srcPort int32, dstPort int32, dscp int32, nextHopIp string, vrf string) {
+	clnt.rules[fmt.Sprint(policy, ":", seq, ":", ifIndex)] = fmt.Sprint(srcPrefix, " ", dstPrefix, " ", dscp, " ", nextHopIp, " ", vrf)
+}
+func (clnt *testAsicdPbrClnt) OnewayDeletePbrRule(policy string, seq int32, ifIndex int32) {
+	delete(clnt.rules, fmt.Sprint(policy, ":", seq, ":", ifIndex))
+}
+
+func TestAsicdPbrRule(t *testing.T) {
+	fmt.Println("****TestAsicdPbrRule****")
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	_, srcPrefix, _ := net.ParseCIDR("10.1.0.0/16")
+	rule := &PbrRule{seq: 10, srcPrefix: srcPrefix, dscp: 46, nextHopIp: "11.1.10.2", nextHop: ribdInt.NextHopInfo{NextHopIp: "11.1.10.2"}}
+
+	//asicd without PBR
+	testServer := &RIBDServer{AsicdPlugin: &testAsicdNextHopGroupClnt{}}
+	plugin := &AsicdFIBPlugin{server: testServer}
+	testServer.PBRPlugin = plugin
+	if plugin.PbrSupported() {
+		t.Error("PBR supported by asicd without PBR rules")
+	}
+	if err := testServer.PbrPolicyConfigValidationCheck(&ribdInt.PbrPolicy{Name: "pbr1", IntfList: []string{"1"}}, "add"); err == nil {
+		t.Error("PBR policy accepted by asicd without PBR rules")
+	}
+	plugin.InstallPbrRule("pbr1", rule, 1)
+	plugin.DeletePbrRule("pbr1", rule, 1)
+
+	clnt := &testAsicdPbrClnt{rules: make(map[string]string)}
+	testServer = &RIBDServer{AsicdPlugin: clnt}
+	plugin = &AsicdFIBPlugin{server: testServer}
+	if !plugin.PbrSupported() {
+		t.Error("PBR not supported by asicd with PBR rules")
+	}
+	plugin.InstallPbrRule("pbr1", rule, 1)
+	fmt.Println("asicd PBR rules:", clnt.rules)
+	if clnt.rules["pbr1:10:1"] != "10.1.0.0/16  46 11.1.10.2 "+DefaultVrf {
+		t.Error("Unexpected asicd PBR rule ", clnt.rules["pbr1:10:1"])
+	}
+	plugin.DeletePbrRule("pbr1", rule, 1)
+	if len(clnt.rules) != 0 {
+		t.Error("asicd PBR rule not deleted ", clnt.rules)
+	}
+	fmt.Println("***********************************")
+}
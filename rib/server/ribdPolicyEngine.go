@@ -61,6 +61,10 @@ type RouteParams struct {
 	weight         ribd.Int
 	bulk           bool
 	bulkEnd        bool
+	vrf            string
+	sourceVrf      string
+	bfd            string
+	tag            uint32
 }
 
 type TraverseAndApplyPolicyData struct {
@@ -267,7 +271,7 @@ func policyEngineActionUndoNetworkStatemenAdvertiseAction(actionItem interface{}
 	switch RouteProtocolTypeMapDB[networkStatementTargetProtocol] {
 	case ribdCommonDefs.BGP:
 		logger.Info("Undo network statement advertise to BGP")
-		route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType)}
+		route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType), Vrf: RouteInfo.vrf}
 		route.NetworkStatement = true
 		publisherInfo, ok := PublisherInfoMap["BGP"]
 		if ok {
@@ -277,7 +281,7 @@ func policyEngineActionUndoNetworkStatemenAdvertiseAction(actionItem interface{}
 	default:
 		logger.Info("Unknown target protocol")
 	}
-	UpdateRedistributeTargetMap(evt, networkStatementTargetProtocol, route)
+	RouteServiceHandler.RIB.UpdateRedistributeTargetMap(evt, networkStatementTargetProtocol, route)
 }
 func policyEngineActionUndoRedistribute(actionItem interface{}, conditionsList []interface{}, policyDef policy.Policy, params interface{}, policyStmt policy.PolicyStmt) {
 	logger.Info("policyEngineActionUndoRedistribute")
@@ -294,7 +298,12 @@ func policyEngineActionUndoRedistribute(actionItem interface{}, conditionsList [
 		logger.Info("evt = NOTIFY_ROUTE_CREATED")
 		evt = ribdCommonDefs.NOTIFY_ROUTE_CREATED
 	}
-	route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType)}
+	RouteInfo.tag = getPolicyStmtRouteTag(policyStmt, RouteInfo.tag)
+	if leakInfo, ok := RouteLeakMap[redistributeActionInfo.RedistributeTargetProtocol]; ok {
+		policyEngineActionLeakRoute(leakInfo, RouteInfo, evt)
+		return
+	}
+	route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType), Vrf: RouteInfo.vrf, RouteTag: ribdInt.Int(RouteInfo.tag)}
 	route.RouteOrigin = ReverseRouteProtoTypeMapDB[int(RouteInfo.routeType)]
 	publisherInfo, ok := PublisherInfoMap[redistributeActionInfo.RedistributeTargetProtocol]
 	if ok {
@@ -303,7 +312,7 @@ func policyEngineActionUndoRedistribute(actionItem interface{}, conditionsList [
 	} else {
 		logger.Info("Unknown target protocol")
 	}
-	UpdateRedistributeTargetMap(evt, redistributeActionInfo.RedistributeTargetProtocol, route)
+	RouteServiceHandler.RIB.UpdateRedistributeTargetMap(evt, redistributeActionInfo.RedistributeTargetProtocol, route)
 }
 func policyEngineUpdateRoute(prefix patriciaDB.Prefix, item patriciaDB.Item, handle patriciaDB.Item) (err error) {
 	logger.Info("policyEngineUpdateRoute for ", prefix)
@@ -357,15 +366,17 @@ func policyEngineUpdateRoute(prefix patriciaDB.Prefix, item patriciaDB.Item, han
 }
 func policyEngineTraverseAndUpdate() {
 	logger.Info("policyEngineTraverseAndUpdate")
-	V4RouteInfoMap.VisitAndUpdate(policyEngineUpdateRoute, nil)
-	V6RouteInfoMap.VisitAndUpdate(policyEngineUpdateRoute, nil)
+	for _, rib := range RouteServiceHandler.RIB.vrfs {
+		rib.v4RouteInfoMap.VisitAndUpdate(policyEngineUpdateRoute, nil)
+		rib.v6RouteInfoMap.VisitAndUpdate(policyEngineUpdateRoute, nil)
+	}
 }
 func policyEngineActionAcceptRoute(params interface{}) {
 	routeInfo := params.(RouteParams)
 	logger.Info("policyEngineActionAcceptRoute for ip ", routeInfo.destNetIp, " and mask ", routeInfo.networkMask)
 	//	_, err := createRoute(routeInfo.ipType, routeInfo.destNetIp, routeInfo.networkMask, routeInfo.metric, routeInfo.weight, routeInfo.nextHopIp, routeInfo.nextHopIfIndex, routeInfo.routeType, routeInfo.createType, ribdCommonDefs.RoutePolicyStateChangetoValid, routeInfo.sliceIdx)
 	//_, err := routeServiceHandler.InstallRoute(routeInfo)
-	_, err := createRoute(routeInfo)
+	_, err := RouteServiceHandler.RIB.createRoute(routeInfo)
 	if err != nil {
 		logger.Info("creating v4 route failed with err ", err)
 		return
@@ -373,10 +384,6 @@ func policyEngineActionAcceptRoute(params interface{}) {
 }
 func policyEngineActionUndoSetAdminDistance(actionItem interface{}, conditionsList []interface{}, policyDef policy.Policy, conditionItem interface{}, policyStmt policy.PolicyStmt) {
 	logger.Info("policyEngineActionUndoSetAdminDistance")
-	if ProtocolAdminDistanceMapDB == nil {
-		logger.Info("ProtocolAdminDistanceMap nil")
-		return
-	}
 	if conditionItem == nil {
 		logger.Info("No valid condition provided for set admin distance action")
 		return
@@ -384,14 +391,11 @@ func policyEngineActionUndoSetAdminDistance(actionItem interface{}, conditionsLi
 	conditionInfo := conditionItem.(policy.PolicyCondition).ConditionInfo
 	conditionProtocol := conditionInfo.(string)
 	//case policyCommonDefs.PolicyConditionTypeProtocolMatch:
-	routeDistanceConfig, ok := ProtocolAdminDistanceMapDB[conditionProtocol]
-	if !ok {
+	if !RouteServiceHandler.RIB.setAdminDistance(conditionProtocol, -1) {
 		logger.Info("Invalid protocol provided for undo set admin distance")
 		return
 	}
-	routeDistanceConfig.configuredDistance = -1
-	ProtocolAdminDistanceMapDB[conditionProtocol] = routeDistanceConfig
-	logger.Info("Setting configured distance of prototype ", conditionProtocol, " to value ", 0, " default distance of this protocol is ", routeDistanceConfig.defaultDistance)
+	logger.Info("Setting configured distance of prototype ", conditionProtocol, " to its default distance")
 	policyEngineTraverseAndUpdate()
 }
 
@@ -400,10 +404,6 @@ func policyEngineActionSetAdminDistance(actionItem interface{}, conditionList []
 	logger.Info("policyEngipolicyEngineActionSetAdminDistance")
 	actionInfo := actionItem.(int)
 	logger.Info("PoilcyActionTypeSetAdminDistance action to be applied")
-	if ProtocolAdminDistanceMapDB == nil {
-		logger.Info("ProtocolAdminDistanceMap nil")
-		return
-	}
 	if conditionList == nil {
 		logger.Info("No valid condition provided for set admin distance action")
 		return
@@ -411,13 +411,10 @@ func policyEngineActionSetAdminDistance(actionItem interface{}, conditionList []
 	for i := 0; i < len(conditionList); i++ {
 		//case policyCommonDefs.PolicyConditionTypeProtocolMatch:
 		conditionProtocol := conditionList[i].(string)
-		routeDistanceConfig, ok := ProtocolAdminDistanceMapDB[conditionProtocol]
-		if !ok {
+		if !RouteServiceHandler.RIB.setAdminDistance(conditionProtocol, actionInfo) {
 			logger.Info("Invalid protocol provided for set admin distance")
 			return
 		}
-		routeDistanceConfig.configuredDistance = actionInfo
-		ProtocolAdminDistanceMapDB[conditionProtocol] = routeDistanceConfig
 		logger.Info("Setting distance of prototype ", conditionProtocol, " to value ", actionInfo)
 	}
 	policyEngineTraverseAndUpdate()
@@ -467,7 +464,7 @@ func policyEngineActionNetworkStatementAdvertise(actionInfo interface{}, conditi
 	switch RouteProtocolTypeMapDB[networkStatementAdvertiseTargetProtocol] {
 	case ribdCommonDefs.BGP:
 		logger.Info("NetworkStatemtnAdvertise to BGP")
-		route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType)}
+		route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType), Vrf: RouteInfo.vrf}
 		route.NetworkStatement = true
 		publisherInfo, ok := PublisherInfoMap["BGP"]
 		if ok {
@@ -477,7 +474,20 @@ func policyEngineActionNetworkStatementAdvertise(actionInfo interface{}, conditi
 	default:
 		logger.Info("Unknown target protocol")
 	}
-	UpdateRedistributeTargetMap(evt, networkStatementAdvertiseTargetProtocol, route)
+	RouteServiceHandler.RIB.UpdateRedistributeTargetMap(evt, networkStatementAdvertiseTargetProtocol, route)
+}
+
+/*
+   Returns the tag set by a set tag action of the policy statement, or the tag
+   of the route when the statement does not set one.
+*/
+func getPolicyStmtRouteTag(policyStmt policy.PolicyStmt, tag uint32) uint32 {
+	for _, action := range policyStmt.SetActionsState {
+		if action.Attr == policyCommonDefs.PolicyActionTypeSetRouteTag {
+			tag = action.RouteTag
+		}
+	}
+	return tag
 }
 
 func policyEngineActionRedistribute(actionInfo interface{}, conditionInfo []interface{}, policyDef policy.Policy,
@@ -508,6 +518,11 @@ func policyEngineActionRedistribute(actionInfo interface{}, conditionInfo []inte
 			evt = ribdCommonDefs.NOTIFY_ROUTE_DELETED
 		}
 	}
+	RouteInfo.tag = getPolicyStmtRouteTag(policyStmt, RouteInfo.tag)
+	if leakInfo, ok := RouteLeakMap[redistributeActionInfo.RedistributeTargetProtocol]; ok {
+		policyEngineActionLeakRoute(leakInfo, RouteInfo, evt)
+		return
+	}
 	if strings.Contains(ReverseRouteProtoTypeMapDB[int(RouteInfo.routeType)], redistributeActionInfo.RedistributeTargetProtocol) {
 		logger.Info("Redistribute target protocol same as route source, do nothing more here")
 		return
@@ -521,7 +536,7 @@ func policyEngineActionRedistribute(actionInfo interface{}, conditionInfo []inte
 			return
 		}
 	}
-	route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType)}
+	route = ribdInt.Routes{Ipaddr: RouteInfo.destNetIp, Mask: RouteInfo.networkMask, NextHopIp: RouteInfo.nextHopIp, IPAddrType: ribdInt.Int(RouteInfo.ipType), IfIndex: ribdInt.Int(RouteInfo.nextHopIfIndex), Metric: ribdInt.Int(RouteInfo.metric), Prototype: ribdInt.Int(RouteInfo.routeType), Vrf: RouteInfo.vrf, RouteTag: ribdInt.Int(RouteInfo.tag)}
 	route.RouteOrigin = ReverseRouteProtoTypeMapDB[int(RouteInfo.routeType)]
 	publisherInfo, ok := PublisherInfoMap[redistributeActionInfo.RedistributeTargetProtocol]
 	if ok {
@@ -530,12 +545,12 @@ func policyEngineActionRedistribute(actionInfo interface{}, conditionInfo []inte
 	} else {
 		logger.Info("Unknown target protocol")
 	}
-	UpdateRedistributeTargetMap(evt, redistributeActionInfo.RedistributeTargetProtocol, route)
+	RouteServiceHandler.RIB.UpdateRedistributeTargetMap(evt, redistributeActionInfo.RedistributeTargetProtocol, route)
 }
 
 func UpdateRouteAndPolicyDB(policyDetails policy.PolicyDetails, params interface{}) {
 	routeInfo := params.(RouteParams)
-	route := ribdInt.Routes{Ipaddr: routeInfo.destNetIp, Mask: routeInfo.networkMask, IPAddrType: ribdInt.Int(routeInfo.ipType), NextHopIp: routeInfo.nextHopIp, IfIndex: ribdInt.Int(routeInfo.nextHopIfIndex), Metric: ribdInt.Int(routeInfo.metric), Prototype: ribdInt.Int(routeInfo.routeType)}
+	route := ribdInt.Routes{Ipaddr: routeInfo.destNetIp, Mask: routeInfo.networkMask, IPAddrType: ribdInt.Int(routeInfo.ipType), NextHopIp: routeInfo.nextHopIp, IfIndex: ribdInt.Int(routeInfo.nextHopIfIndex), Metric: ribdInt.Int(routeInfo.metric), Prototype: ribdInt.Int(routeInfo.routeType), Vrf: routeInfo.vrf, RouteTag: ribdInt.Int(routeInfo.tag)}
 	var op int
 	if routeInfo.deleteType != Invalid {
 		op = del
@@ -543,7 +558,7 @@ func UpdateRouteAndPolicyDB(policyDetails policy.PolicyDetails, params interface
 		if policyDetails.EntityDeleted == false {
 			logger.Info("Reject action was not applied, so add this policy to the route")
 			op = add
-			updateRoutePolicyState(route, op, policyDetails.Policy, policyDetails.PolicyStmt)
+			RouteServiceHandler.RIB.updateRoutePolicyState(route, op, policyDetails.Policy, policyDetails.PolicyStmt)
 		}
 		route.PolicyHitCounter++
 	}
@@ -559,7 +574,7 @@ func DoesRouteExist(params interface{}) (exists bool) {
 		logger.Info("Error when getting ipPrefix, err= ", err)
 		return
 	}
-	routeInfoRecordList := RouteInfoMapGet(routeInfo.ipType, ipPrefix)
+	routeInfoRecordList := RouteServiceHandler.RIB.RouteInfoMapGet(routeInfo.vrf, routeInfo.ipType, ipPrefix)
 	if routeInfoRecordList == nil {
 		logger.Info("Route for type ", routeInfo.ipType, " and prefix", ipPrefix, " no longer exists")
 		routeDeleted = true
@@ -574,9 +589,9 @@ func DoesRouteExist(params interface{}) (exists bool) {
 				routeDeleted = true
 			} else {
 				routeFound := false
-				route := ribdInt.Routes{Ipaddr: routeInfo.destNetIp, Mask: routeInfo.networkMask, NextHopIp: routeInfo.nextHopIp, IfIndex: ribdInt.Int(routeInfo.nextHopIfIndex), Metric: ribdInt.Int(routeInfo.metric), Prototype: ribdInt.Int(routeInfo.routeType)}
+				route := ribdInt.Routes{Ipaddr: routeInfo.destNetIp, Mask: routeInfo.networkMask, NextHopIp: routeInfo.nextHopIp, IfIndex: ribdInt.Int(routeInfo.nextHopIfIndex), Metric: ribdInt.Int(routeInfo.metric), Prototype: ribdInt.Int(routeInfo.routeType), Vrf: routeInfo.vrf}
 				for i := 0; i < len(routeInfoList); i++ {
-					testRoute := ribdInt.Routes{Ipaddr: routeInfoList[i].destNetIp.String(), Mask: routeInfoList[i].networkMask.String(), NextHopIp: routeInfoList[i].nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoList[i].nextHopIfIndex), Metric: ribdInt.Int(routeInfoList[i].metric), Prototype: ribdInt.Int(routeInfoList[i].protocol), IsPolicyBasedStateValid: routeInfoList[i].isPolicyBasedStateValid}
+					testRoute := ribdInt.Routes{Ipaddr: routeInfoList[i].destNetIp.String(), Mask: routeInfoList[i].networkMask.String(), NextHopIp: routeInfoList[i].nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoList[i].nextHopIfIndex), Metric: ribdInt.Int(routeInfoList[i].metric), Prototype: ribdInt.Int(routeInfoList[i].protocol), IsPolicyBasedStateValid: routeInfoList[i].isPolicyBasedStateValid, Vrf: routeInfoList[i].vrf}
 					if isSameRoute(testRoute, route) {
 						logger.Info("Route still exists")
 						routeFound = true
@@ -592,7 +607,7 @@ func DoesRouteExist(params interface{}) (exists bool) {
 	exists = !routeDeleted
 	return exists
 }
-func PolicyEngineFilter(route ribdInt.Routes, policyPath int, params interface{}) {
+func (r *RIB) PolicyEngineFilter(route ribdInt.Routes, policyPath int, params interface{}) {
 	logger.Info("PolicyEngineFilter")
 	var policyPath_Str string
 	if policyPath == policyCommonDefs.PolicyPath_Import {
@@ -615,7 +630,7 @@ func PolicyEngineFilter(route ribdInt.Routes, policyPath int, params interface{}
 			return
 		}
 	}
-	if destNetSlice[routeInfo.sliceIdx].isValid == false && routeInfo.createType != Invalid && policyPath == policyCommonDefs.PolicyPath_Export {
+	if r.destNetSlice[routeInfo.sliceIdx].isValid == false && routeInfo.createType != Invalid && policyPath == policyCommonDefs.PolicyPath_Export {
 		logger.Info("route down, return from policyenginefilter for deletetype and export path")
 		return
 	}
@@ -633,7 +648,7 @@ func PolicyEngineFilter(route ribdInt.Routes, policyPath int, params interface{}
 	var op int
 	if routeInfo.deleteType != Invalid {
 		op = delAll //wipe out the policyList
-		updateRoutePolicyState(route, op, "", "")
+		r.updateRoutePolicyState(route, op, "", "")
 	}
 }
 
@@ -653,12 +668,12 @@ func policyEngineApplyForRoute(prefix patriciaDB.Prefix, item patriciaDB.Item, t
 	}
 	for i := 0; i < len(selectedRouteList); i++ {
 		selectedRouteInfoRecord := selectedRouteList[i]
-		if selectedRouteInfoRecord.sliceIdx == -1 || selectedRouteInfoRecord.sliceIdx >= len(destNetSlice) || destNetSlice[selectedRouteInfoRecord.sliceIdx].isValid == false {
-			logger.Info("route ", selectedRouteInfoRecord, " not valid, continue, sliceIdx:", selectedRouteInfoRecord.sliceIdx, " len(destNetSlice):", len(destNetSlice))
+		if selectedRouteInfoRecord.sliceIdx == -1 || selectedRouteInfoRecord.sliceIdx >= len(RouteServiceHandler.RIB.destNetSlice) || RouteServiceHandler.RIB.destNetSlice[selectedRouteInfoRecord.sliceIdx].isValid == false {
+			logger.Info("route ", selectedRouteInfoRecord, " not valid, continue, sliceIdx:", selectedRouteInfoRecord.sliceIdx, " len(destNetSlice):", len(RouteServiceHandler.RIB.destNetSlice))
 			continue
 		}
-		policyRoute := ribdInt.Routes{Ipaddr: selectedRouteInfoRecord.destNetIp.String(), Mask: selectedRouteInfoRecord.networkMask.String(), NextHopIp: selectedRouteInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(selectedRouteInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(selectedRouteInfoRecord.metric), Prototype: ribdInt.Int(selectedRouteInfoRecord.protocol), IsPolicyBasedStateValid: rmapInfoRecordList.isPolicyBasedStateValid}
-		params := RouteParams{destNetIp: policyRoute.Ipaddr, networkMask: policyRoute.Mask, routeType: ribd.Int(policyRoute.Prototype), nextHopIp: selectedRouteInfoRecord.nextHopIp.String(), sliceIdx: ribd.Int(policyRoute.SliceIdx), createType: Invalid, deleteType: Invalid}
+		policyRoute := ribdInt.Routes{Ipaddr: selectedRouteInfoRecord.destNetIp.String(), Mask: selectedRouteInfoRecord.networkMask.String(), NextHopIp: selectedRouteInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(selectedRouteInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(selectedRouteInfoRecord.metric), Prototype: ribdInt.Int(selectedRouteInfoRecord.protocol), IsPolicyBasedStateValid: rmapInfoRecordList.isPolicyBasedStateValid, Vrf: selectedRouteInfoRecord.vrf}
+		params := RouteParams{destNetIp: policyRoute.Ipaddr, networkMask: policyRoute.Mask, routeType: ribd.Int(policyRoute.Prototype), nextHopIp: selectedRouteInfoRecord.nextHopIp.String(), sliceIdx: ribd.Int(policyRoute.SliceIdx), createType: Invalid, deleteType: Invalid, vrf: selectedRouteInfoRecord.vrf, tag: selectedRouteInfoRecord.tag}
 		entity, err := buildPolicyEntityFromRoute(policyRoute, params)
 		if err != nil {
 			logger.Err("Error builiding policy entity params")
@@ -675,8 +690,10 @@ func policyEngineApplyForRoute(prefix patriciaDB.Prefix, item patriciaDB.Item, t
 func policyEngineTraverseAndApply(data interface{}, updatefunc policy.PolicyApplyfunc) {
 	logger.Info("PolicyEngineTraverseAndApply - traverse routing table and apply policy ")
 	traverseAndApplyPolicyData := TraverseAndApplyPolicyData{data: data, updatefunc: updatefunc}
-	V4RouteInfoMap.VisitAndUpdate(policyEngineApplyForRoute, traverseAndApplyPolicyData)
-	V6RouteInfoMap.VisitAndUpdate(policyEngineApplyForRoute, traverseAndApplyPolicyData)
+	for _, rib := range RouteServiceHandler.RIB.vrfs {
+		rib.v4RouteInfoMap.VisitAndUpdate(policyEngineApplyForRoute, traverseAndApplyPolicyData)
+		rib.v6RouteInfoMap.VisitAndUpdate(policyEngineApplyForRoute, traverseAndApplyPolicyData)
+	}
 }
 func policyEngineTraverseAndReverse(applyPolicyItem interface{}) {
 	updateInfo := applyPolicyItem.(policy.PolicyEngineApplyInfo)
@@ -692,7 +709,7 @@ func policyEngineTraverseAndReverse(applyPolicyItem interface{}) {
 	var params RouteParams
 	for idx := 0; idx < len(ext.routeInfoList); idx++ {
 		policyRoute = ext.routeInfoList[idx]
-		params = RouteParams{destNetIp: policyRoute.Ipaddr, networkMask: policyRoute.Mask, routeType: ribd.Int(policyRoute.Prototype), sliceIdx: ribd.Int(policyRoute.SliceIdx), createType: Invalid, deleteType: Invalid}
+		params = RouteParams{destNetIp: policyRoute.Ipaddr, networkMask: policyRoute.Mask, routeType: ribd.Int(policyRoute.Prototype), sliceIdx: ribd.Int(policyRoute.SliceIdx), createType: Invalid, deleteType: Invalid, vrf: policyRoute.Vrf, tag: uint32(policyRoute.RouteTag)}
 		ipPrefix, err := getNetowrkPrefixFromStrings(ext.routeInfoList[idx].Ipaddr, ext.routeInfoList[idx].Mask)
 		if err != nil {
 			logger.Info("Invalid route ", ext.routeList[idx])
@@ -706,7 +723,7 @@ func policyEngineTraverseAndReverse(applyPolicyItem interface{}) {
 		//PolicyEngineDB.PolicyEngineUndoPolicyForEntity(entity, policy, params)
 		success := PolicyEngineDB.PolicyEngineUndoApplyPolicyForEntity(entity, updateInfo, params)
 		if success {
-			deleteRoutePolicyState(params.ipType, ipPrefix, policy.Name)
+			RouteServiceHandler.RIB.deleteRoutePolicyState(params.vrf, params.ipType, ipPrefix, policy.Name)
 			PolicyEngineDB.DeletePolicyEntityMapEntry(entity, policy.Name)
 		}
 	}
@@ -62,6 +62,7 @@ func (m RIBDServer) ProcessPolicyConditionConfigCreate(cfg *ribd.PolicyCondition
 		MatchProtocolConditionInfo:  cfg.Protocol,
 		MatchLocalPrefConditionInfo: uint32(cfg.LocalPref),
 		MatchMEDConditionInfo:       uint32(cfg.MED),
+		MatchRouteTagConditionInfo:  uint32(cfg.RouteTag),
 	}
 	matchPrefix := policy.PolicyPrefix{IpPrefix: cfg.IpPrefix, MasklengthRange: cfg.MaskLengthRange}
 	newPolicy.MatchDstIpPrefixConditionInfo = policy.PolicyDstIpMatchPrefixSetCondition{Prefix: matchPrefix, PrefixSet: cfg.PrefixSet}
@@ -211,6 +212,7 @@ func (m RIBDServer) ProcessPolicyStmtConfigCreate(cfg *ribd.PolicyStmt, db *poli
 			LocalPref:         uint32(setAction.LocalPref),
 			MED:               uint32(setAction.MED),
 			PrependASPath:     setAction.PrependASPath,
+			RouteTag:          uint32(setAction.RouteTag),
 		})
 	}
 	err = db.CreatePolicyStatement(newPolicyStmt)
@@ -513,7 +515,8 @@ func (m RIBDServer) GetBulkPolicyStmtState(fromIndex ribd.Int, rcount ribd.Int,
 					Community:              setAction.Community,
 					ExtendedCommunityType:  setAction.ExtendedCommunity.Type,
 					ExtendedCommunityValue: setAction.ExtendedCommunity.Value,
-					LocalPref:              int32(setAction.LocalPref)})
+					LocalPref:              int32(setAction.LocalPref),
+					RouteTag:               int32(setAction.RouteTag)})
 			}
 			if prefixNode.PolicyList != nil {
 				nextNode.PolicyList = make([]string, 0)
@@ -661,7 +664,7 @@ func (m *RIBDServer) UndoApplyPolicy(info *ribdInt.ApplyPolicyInfo, apply bool,
 		}
 	}
 	switch action {
-	case "Redistribution":
+	case "Redistribution", "Leak":
 		logger.Debug("Setting up Redistribution action map")
 		redistributeActionInfo := policy.RedistributeActionInfo{false, source}
 		policyAction = policy.PolicyAction{Name: action, ActionType: policyCommonDefs.PolicyActionTypeRouteRedistribute, ActionInfo: redistributeActionInfo}
@@ -732,7 +735,7 @@ func (m RIBDServer) UpdateApplyPolicy(info *ribdInt.ApplyPolicyInfo, apply bool,
 	//}
 	//define Action
 	switch action {
-	case "Redistribution":
+	case "Redistribution", "Leak":
 		logger.Debug("Setting up Redistribution action map")
 		redistributeActionInfo := policy.RedistributeActionInfo{true, source}
 		policyAction = policy.PolicyAction{Name: action, ActionType: policyCommonDefs.PolicyActionTypeRouteRedistribute, ActionInfo: redistributeActionInfo}
@@ -280,6 +280,7 @@ func (ribdServiceHandler *RIBDServer) StartPolicyServer() {
 		case conf := <-ribdServiceHandler.PolicyConfCh:
 			logger.Debug("received message on PolicyConfCh channel, op: ", conf.Op, " policyconfdone:", ribdServiceHandler.PolicyConfDone)
 			var err error
+			ribdServiceHandler.RIB.Lock()
 			if conf.Op == defs.AddPolicyCondition {
 				_, err = ribdServiceHandler.ProcessPolicyConditionConfigCreate(conf.OrigConfigObject.(*ribd.PolicyCondition), ribdServiceHandler.GlobalPolicyEngineDB)
 				if err == nil {
@@ -473,7 +474,12 @@ func (ribdServiceHandler *RIBDServer) StartPolicyServer() {
 			} else if conf.Op == defs.ApplyPolicy {
 				ribdServiceHandler.UpdateApplyPolicyList(conf.PolicyList.ApplyList, conf.PolicyList.UndoList, true, ribdServiceHandler.PolicyEngineDB)
 				ribdServiceHandler.UpdateApplyPolicyList(conf.PolicyList.ApplyList, conf.PolicyList.UndoList, false, GlobalPolicyEngineDB)
+			} else if conf.Op == defs.AddVrfRouteLeak {
+				err = ribdServiceHandler.ProcessVrfRouteLeakCreateConfig(conf.OrigConfigObject.(*ribdInt.VrfRouteLeak), ribdServiceHandler.PolicyEngineDB, GlobalPolicyEngineDB)
+			} else if conf.Op == defs.DelVrfRouteLeak {
+				err = ribdServiceHandler.ProcessVrfRouteLeakDeleteConfig(conf.OrigConfigObject.(*ribdInt.VrfRouteLeak), ribdServiceHandler.PolicyEngineDB, GlobalPolicyEngineDB)
 			}
+			ribdServiceHandler.RIB.Unlock()
 			ribdServiceHandler.PolicyConfDone <- err
 		case info := <-ribdServiceHandler.PolicyUpdateApplyCh:
 			/*
@@ -481,7 +487,9 @@ func (ribdServiceHandler *RIBDServer) StartPolicyServer() {
 			*/
 			logger.Debug("received message on PolicyUpdateApplyCh channel")
 			//update the global policyEngineDB
+			ribdServiceHandler.RIB.Lock()
 			ribdServiceHandler.UpdateApplyPolicyList(info.ApplyList, info.UndoList, false, GlobalPolicyEngineDB)
+			ribdServiceHandler.RIB.Unlock()
 		}
 	}
 }
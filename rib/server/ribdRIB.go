@@ -0,0 +1,569 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+
+// ribdRIB.go
+package server
+
+import (
+	"bytes"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribdInt"
+	"strconv"
+	"sync"
+	"time"
+	"utils/patriciaDB"
+	"utils/policy"
+	"utils/policy/policyCommonDefs"
+)
+
+/*
+   Route tables and route bookkeeping of ribd. The RIB methods do not lock, the
+   goroutine running a code path takes the RIB lock once: the route and policy
+   processing loops take the write lock around every config or event they handle
+   and the rpc getters take the read lock. The interface names and the route
+   events have locks of their own because the FIB, DB and notification loops use
+   them without holding the RIB lock, they must never take it since the route
+   processing loop blocks on their channels while it holds the lock.
+*/
+type RIB struct {
+	sync.RWMutex
+	vrfs               map[string]*VrfRIB
+	intfVrfs           map[int32]string //interfaces bound to a VRF other than the default VRF
+	destNetSlice       []localDB
+	interfaceRouteMap  map[string]PerProtocolRouteInfo
+	connectedRoutes    []*ribdInt.Routes
+	v4RouteCount       int
+	v4RouteCreatedTime map[int]string
+	v6RouteCount       int
+	v6RouteCreatedTime map[int]string
+	policyDB           *policy.PolicyEngineDB //route disposition policies applied by SelectBestRoute
+
+	//RPF routes (multicast SAFI routes learnt by BGP), never installed in the FIB
+	rpfV4RouteInfoMap *patriciaDB.Trie
+	rpfV6RouteInfoMap *patriciaDB.Trie
+	rpfDestNets       []rpfDestNet
+
+	redistributeRouteMap map[string][]RedistributeRouteInfo
+	bfdNextHops          map[string]*BfdNextHopInfo //static route next hops tracked by bfdd
+	staleRouteTimers     map[string]*time.Timer     //hold timers of the protocols whose routes are stale
+
+	//labeled unicast routes, kept out of the VRF route tables
+	labeledRoutes    map[string]*labeledRoute
+	labeledRouteKeys []string
+
+	intfLock        sync.RWMutex
+	intfIdNameMap   map[int32]IntfEntry
+	ifNameToIfIndex map[string]int32
+
+	eventLock   sync.Mutex
+	routeEvents []RouteEventInfo
+}
+
+func NewRIB() *RIB {
+	rib := &RIB{
+		vrfs:                 make(map[string]*VrfRIB),
+		intfVrfs:             make(map[int32]string),
+		destNetSlice:         make([]localDB, 0),
+		interfaceRouteMap:    make(map[string]PerProtocolRouteInfo),
+		v4RouteCreatedTime:   make(map[int]string),
+		v6RouteCreatedTime:   make(map[int]string),
+		rpfV4RouteInfoMap:    patriciaDB.NewTrie(),
+		rpfV6RouteInfoMap:    patriciaDB.NewTrie(),
+		rpfDestNets:          make([]rpfDestNet, 0),
+		redistributeRouteMap: make(map[string][]RedistributeRouteInfo),
+		bfdNextHops:          make(map[string]*BfdNextHopInfo),
+		staleRouteTimers:     make(map[string]*time.Timer),
+		labeledRoutes:        make(map[string]*labeledRoute),
+		labeledRouteKeys:     make([]string, 0),
+		intfIdNameMap:        make(map[int32]IntfEntry),
+		ifNameToIfIndex:      make(map[string]int32),
+		routeEvents:          make([]RouteEventInfo, 0),
+	}
+	rib.vrfs[DefaultVrf] = newVrfRIB(DefaultVrf, defaultAdminDistanceMap())
+	return rib
+}
+
+func defaultAdminDistanceMap() map[string]RouteDistanceConfig {
+	return map[string]RouteDistanceConfig{
+		"CONNECTED": RouteDistanceConfig{defaultDistance: 0, configuredDistance: -1},
+		"STATIC":    RouteDistanceConfig{defaultDistance: 1, configuredDistance: -1},
+		"EBGP":      RouteDistanceConfig{defaultDistance: 20, configuredDistance: -1},
+		"IBGP":      RouteDistanceConfig{defaultDistance: 200, configuredDistance: -1},
+		"OSPF":      RouteDistanceConfig{defaultDistance: 110, configuredDistance: -1},
+		"RIP":       RouteDistanceConfig{defaultDistance: 120, configuredDistance: -1},
+		"KERNEL":    RouteDistanceConfig{defaultDistance: 180, configuredDistance: -1},
+	}
+}
+
+func (r *RIB) SetPolicyDB(policyDB *policy.PolicyEngineDB) {
+	r.policyDB = policyDB
+}
+
+func (r *RIB) Vrf(vrf string) *VrfRIB {
+	return r.vrfs[getVrfName(vrf)]
+}
+
+func (r *RIB) routeInfoMap(vrf string, ipType defs.IPType) *patriciaDB.Trie {
+	rib := r.Vrf(vrf)
+	if rib == nil {
+		return nil
+	}
+	if ipType == defs.IPv4 {
+		return rib.v4RouteInfoMap
+	}
+	return rib.v6RouteInfoMap
+}
+
+/*
+   VRF an interface is bound to, the default VRF when it is not bound.
+   Routes carry their own vrf, this is used to check that their next hop
+   interfaces are in that vrf, to pick the vrf of a route that does not
+   name one and to find the routes affected by an interface event
+*/
+func (r *RIB) IntfVrf(ifIndex int32) string {
+	if vrf, ok := r.intfVrfs[ifIndex]; ok {
+		return vrf
+	}
+	return DefaultVrf
+}
+
+func (r *RIB) Insert(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList RouteInfoRecordList) bool {
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return false
+	}
+	return routeInfoMap.Insert(prefix, routeInfoRecordList)
+}
+
+func (r *RIB) Set(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList RouteInfoRecordList) bool {
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return false
+	}
+	routeInfoMap.Set(prefix, routeInfoRecordList)
+	return true
+}
+
+func (r *RIB) Delete(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix) {
+	if routeInfoMap := r.routeInfoMap(vrf, ipType); routeInfoMap != nil {
+		routeInfoMap.Delete(prefix)
+	}
+}
+
+func (r *RIB) Get(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix) (routeInfoRecordList RouteInfoRecordList, found bool) {
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return routeInfoRecordList, false
+	}
+	item := routeInfoMap.Get(prefix)
+	if item == nil {
+		return routeInfoRecordList, false
+	}
+	return item.(RouteInfoRecordList), true
+}
+
+/*
+   Longest prefix match of ip among all the destinations of the vrf
+*/
+func (r *RIB) Match(vrf string, ip net.IP) (routeInfoRecordList RouteInfoRecordList, found bool) {
+	ipType := defs.IPv4
+	key := ip.To4()
+	if key == nil {
+		ipType = defs.IPv6
+		key = ip.To16()
+	}
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if key == nil || routeInfoMap == nil {
+		return routeInfoRecordList, false
+	}
+	item := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(key))
+	if item == nil {
+		return routeInfoRecordList, false
+	}
+	return item.(RouteInfoRecordList), true
+}
+
+/*
+   Longest prefix match of ip among the destinations of the vrf that have a selected route
+*/
+func (r *RIB) Lookup(vrf string, ip net.IP) (routeInfoRecordList RouteInfoRecordList, found bool) {
+	routeInfoRecordList, found = r.Match(vrf, ip)
+	if !found || routeInfoRecordList.selectedRouteProtocol == "INVALID" || len(routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]) == 0 {
+		return routeInfoRecordList, false
+	}
+	return routeInfoRecordList, true
+}
+
+/*
+   Admin distance policies are not VRF aware, they apply to the tables of all the VRFs
+*/
+func (r *RIB) setAdminDistance(protocol string, distance int) bool {
+	set := false
+	for _, rib := range r.vrfs {
+		routeDistanceConfig, ok := rib.adminDistanceMap[protocol]
+		if !ok {
+			continue
+		}
+		routeDistanceConfig.configuredDistance = distance
+		rib.adminDistanceMap[protocol] = routeDistanceConfig
+		rib.adminDistanceSlice = buildAdminDistanceSlice(rib.adminDistanceMap)
+		set = true
+	}
+	return set
+}
+
+func (r *RIB) IntfEntry(ifIndex int32) (IntfEntry, bool) {
+	r.intfLock.RLock()
+	defer r.intfLock.RUnlock()
+	intfEntry, ok := r.intfIdNameMap[ifIndex]
+	return intfEntry, ok
+}
+
+func (r *RIB) IfIndex(name string) (int32, bool) {
+	r.intfLock.RLock()
+	defer r.intfLock.RUnlock()
+	ifIndex, ok := r.ifNameToIfIndex[name]
+	return ifIndex, ok
+}
+
+func (r *RIB) SetIntfEntry(ifIndex int32, name string) {
+	r.intfLock.Lock()
+	r.intfIdNameMap[ifIndex] = IntfEntry{name: name}
+	r.ifNameToIfIndex[name] = ifIndex
+	r.intfLock.Unlock()
+}
+
+func (r *RIB) AddRouteEvent(eventInfo RouteEventInfo) {
+	r.eventLock.Lock()
+	r.routeEvents = append(r.routeEvents, eventInfo)
+	r.eventLock.Unlock()
+}
+
+func (r *RIB) RouteEvents() []RouteEventInfo {
+	r.eventLock.Lock()
+	defer r.eventLock.Unlock()
+	routeEvents := make([]RouteEventInfo, len(r.routeEvents))
+	copy(routeEvents, r.routeEvents)
+	return routeEvents
+}
+
+/*
+   Stores the route in the routes of its protocol for the destination. Returns the
+   route as stored and whether it added a next hop to the destination, ok is false
+   when the destNetSlice entry of the route belongs to another destination.
+*/
+func (r *RIB) addRoute(destNetPrefix patriciaDB.Prefix, routeInfoRecord RouteInfoRecord, routeInfoRecordList RouteInfoRecordList) (record RouteInfoRecord, newNextHop bool, ok bool) {
+	if len(r.destNetSlice) > routeInfoRecord.sliceIdx {
+		if bytes.Equal(r.destNetSlice[routeInfoRecord.sliceIdx].prefix, destNetPrefix) == false {
+			logger.Debug("Unexpected destination network prefix ", r.destNetSlice[routeInfoRecord.sliceIdx].prefix, " found at the slice Idx ", routeInfoRecord.sliceIdx, " expected prefix ", destNetPrefix)
+			return routeInfoRecord, false, false
+		}
+		//There is already an entry in the destNetSlice at the route index and was invalidated earlier because  of a link down of the nexthop intf of the route or if the route was deleted
+		//In this case since the old route was invalid, there is nothing to delete
+		r.destNetSlice[routeInfoRecord.sliceIdx].isValid = true
+	} else {
+		logger.Debug("This is a new route for selectedProtocolType being added, create destNetSlice entry at index ", len(r.destNetSlice))
+		routeInfoRecord.sliceIdx = len(r.destNetSlice)
+		localDBRecord := localDB{prefix: destNetPrefix, isValid: true, nextHopIp: routeInfoRecord.nextHopIp.String(), vrf: routeInfoRecord.vrf}
+		r.destNetSlice = append(r.destNetSlice, localDBRecord)
+	}
+	protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	if routeInfoRecordList.routeInfoProtocolMap[protocol] == nil {
+		routeInfoRecordList.routeInfoProtocolMap[protocol] = make([]RouteInfoRecord, 0)
+	}
+	found, currRecord, idx := findRouteWithNextHop(routeInfoRecordList.routeInfoProtocolMap[protocol], routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
+	if !found {
+		routeInfoRecordList.routeInfoProtocolMap[protocol] = append(routeInfoRecordList.routeInfoProtocolMap[protocol], routeInfoRecord)
+	} else {
+		//already existing route needs to be updated
+		currRecord.routeUpdatedTime = time.Now().String()
+		currRecord.resolvedNextHopIpIntf.IsReachable = true
+		routeInfoRecordList.routeInfoProtocolMap[protocol][idx] = currRecord
+	}
+	r.Set(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
+	if routeInfoRecord.ipType == defs.IPv4 {
+		r.v4RouteCount++
+		r.v4RouteCreatedTime[r.v4RouteCount] = routeInfoRecord.routeCreatedTime
+	} else if routeInfoRecord.ipType == defs.IPv6 {
+		r.v6RouteCount++
+		r.v6RouteCreatedTime[r.v6RouteCount] = routeInfoRecord.routeCreatedTime
+	}
+	r.updateProtocolRouteMap(routeInfoRecord.vrf, protocol, "add", routeInfoRecord.ipType, string(destNetPrefix), !found)
+	r.updateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNetPrefix), !found)
+	return routeInfoRecord, !found, true
+}
+
+/*
+   Removes the route from the routes of its protocol for the destination, the caller
+   deletes the destination once no route is left for it
+*/
+func (r *RIB) removeRoute(routeInfoRecord RouteInfoRecord, routeInfoRecordList RouteInfoRecordList) (found bool, empty bool) {
+	protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	routeInfoList := routeInfoRecordList.routeInfoProtocolMap[protocol]
+	found, _, index := findRouteWithNextHop(routeInfoList, routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
+	if !found || index == -1 {
+		return false, false
+	}
+	if len(routeInfoList) <= index+1 {
+		routeInfoList = routeInfoList[:index]
+	} else {
+		routeInfoList = append(routeInfoList[:index], routeInfoList[index+1:]...)
+	}
+	routeInfoRecordList.routeInfoProtocolMap[protocol] = routeInfoList
+	if len(routeInfoList) != 0 {
+		return true, false
+	}
+	logger.Debug("All routes for this destination from protocol ", protocol, " deleted")
+	routeInfoRecordList.routeInfoProtocolMap[protocol] = nil
+	for _, v := range routeInfoRecordList.routeInfoProtocolMap {
+		if len(v) != 0 {
+			return true, false
+		}
+	}
+	return true, true
+}
+
+func (r *RIB) updateProtocolRouteMap(vrf string, protocol string, op string, ipType defs.IPType, value string, ecmp bool) {
+	rib := r.Vrf(vrf)
+	if rib == nil {
+		return
+	}
+	if ipType == defs.IPv4 {
+		UpdateV4ProtocolRouteMap(rib.protocolRouteMap, protocol, op, value, ecmp)
+	} else {
+		UpdateV6ProtocolRouteMap(rib.protocolRouteMap, protocol, op, value, ecmp)
+	}
+}
+
+func (r *RIB) updateInterfaceRouteMap(intf int, op string, ipType defs.IPType, value string, ecmp bool) {
+	intfref := strconv.Itoa(intf)
+	if intfEntry, ok := r.IntfEntry(int32(intf)); ok {
+		intfref = intfEntry.name
+	}
+	if ipType == defs.IPv4 {
+		UpdateV4ProtocolRouteMap(r.interfaceRouteMap, intfref, op, value, ecmp)
+	} else {
+		UpdateV6ProtocolRouteMap(r.interfaceRouteMap, intfref, op, value, ecmp)
+	}
+}
+
+/*
+   Function which determines the next best route
+*/
+func (r *RIB) SelectNextBestRoute(routeInfoRecordList RouteInfoRecordList, protocol string) (newSelectedProtocol string) {
+	logger.Debug("SelectBestRoute, the current selected route protocol is ", routeInfoRecordList.selectedRouteProtocol)
+	tempSelectedProtocol := "INVALID"
+	/*
+	   Build protocol admin distance slice based on the current admin distance values
+	*/
+	adminDistanceSlice := r.Vrf(routeInfoRecordList.vrf).getAdminDistanceSlice()
+	for i := 0; i < len(adminDistanceSlice); i++ {
+		tempSelectedProtocol = adminDistanceSlice[i].Protocol
+		if tempSelectedProtocol == protocol {
+			continue
+		}
+		//logger.Debug("Best preferred protocol ", tempSelectedProtocol)
+		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]
+		if routeInfoList == nil || len(routeInfoList) == 0 {
+			logger.Debug("No routes are configured with this protocol ", tempSelectedProtocol, " for this route")
+			tempSelectedProtocol = "INVALID"
+			continue
+		}
+		if tempSelectedProtocol != "INVALID" {
+			logger.Debug("Found a valid protocol ", tempSelectedProtocol)
+			break
+		}
+	}
+	return tempSelectedProtocol
+}
+
+/*
+   Route disposition policies can reject the routes of a protocol
+*/
+func (r *RIB) isRouteRejected(routeInfoRecordList RouteInfoRecordList, routeInfoRecord RouteInfoRecord) bool {
+	if r.policyDB == nil {
+		return false
+	}
+	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid, Vrf: routeInfoRecord.vrf}
+	entity, _ := buildPolicyEntityFromRoute(policyRoute, RouteParams{})
+	actionList := r.policyDB.PolicyEngineCheckActionsForEntity(entity, policyCommonDefs.PolicyConditionTypeProtocolMatch)
+	return r.policyDB.ActionNameListHasAction(actionList, policyCommonDefs.PolicyActionTypeRouteDisposition, "Reject")
+}
+
+/*
+   Function which determines the best route when a route is deleted or updated
+*/
+func (r *RIB) SelectBestRoute(routeInfoRecordList RouteInfoRecordList) (addRouteList []RouteOpInfoRecord, deleteRouteList []RouteOpInfoRecord, newSelectedProtocol string) {
+	logger.Info("SelectBestRoute, the current selected route protocol is ", routeInfoRecordList.selectedRouteProtocol)
+	tempSelectedProtocol := "INVALID"
+	newSelectedProtocol = "INVALID"
+	deleteRouteList = make([]RouteOpInfoRecord, 0)
+	addRouteList = make([]RouteOpInfoRecord, 0)
+	var routeOpInfoRecord RouteOpInfoRecord
+	/*
+	   Build protocol admin distance slice based on the current admin distance values
+	*/
+	adminDistanceSlice := r.Vrf(routeInfoRecordList.vrf).getAdminDistanceSlice()
+	logger.Info("len(protocolAdminDistanceSlice):", len(adminDistanceSlice))
+	/*
+	   go over the protocol admin distance slice, select the protocols from best to worst
+	   and check if there are any routes configured with that protocol type
+	   If yes, then verify if that route is eligible to be selected.
+	   If yes, then check if it is the same protocol as the incoming protocol
+	   If not, then delete all the routes configured with the old selected protocol in FIB
+	   and configure the routes of the new selected type
+	*/
+	for i := 0; i < len(adminDistanceSlice); i++ {
+		tempSelectedProtocol = adminDistanceSlice[i].Protocol
+		logger.Info("Best preferred protocol ", tempSelectedProtocol, " at i= ", i)
+		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]
+		if routeInfoList == nil || len(routeInfoList) == 0 {
+			logger.Debug("No routes are configured with this protocol ", tempSelectedProtocol, " for this route")
+			tempSelectedProtocol = "INVALID"
+			continue
+		}
+		tempSelectedProtocol = "INVALID"
+		for j := 0; j < len(routeInfoList); j++ {
+			if !r.isRouteRejected(routeInfoRecordList, routeInfoList[j]) {
+				logger.Info("atleast one of the routes of this protocol will not be rejected by the policy engine -protocol at index i:", i)
+				tempSelectedProtocol = adminDistanceSlice[i].Protocol
+				break
+			}
+		}
+		if tempSelectedProtocol != "INVALID" {
+			logger.Info("Found a valid protocol ", tempSelectedProtocol)
+			break
+		}
+	}
+	if tempSelectedProtocol == routeInfoRecordList.selectedRouteProtocol {
+		logger.Debug("The current protocol remains the new selected protocol")
+		return addRouteList, deleteRouteList, newSelectedProtocol
+	}
+	if routeInfoRecordList.selectedRouteProtocol != "INVALID" {
+		logger.Debug("Valid protocol currently selected as ", routeInfoRecordList.selectedRouteProtocol)
+		for j := 0; j < len(routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]); j++ {
+			routeOpInfoRecord.opType = FIBOnly
+			routeOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][j]
+			deleteRouteList = append(deleteRouteList, routeOpInfoRecord)
+		}
+	}
+	if tempSelectedProtocol != "INVALID" {
+		logger.Debug("New Valid protocol selected as ", tempSelectedProtocol)
+		for j := 0; j < len(routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]); j++ {
+			routeOpInfoRecord.opType = FIBOnly
+			routeOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol][j]
+			logger.Debug("Adding route with nexthop ip ", routeOpInfoRecord.routeInfoRecord.nextHopIp.String(), "/", routeOpInfoRecord.routeInfoRecord.nextHopIfIndex)
+			addRouteList = append(addRouteList, routeOpInfoRecord)
+		}
+		newSelectedProtocol = tempSelectedProtocol
+	}
+	return addRouteList, deleteRouteList, newSelectedProtocol
+}
+
+//this function is called when a route is being added after it has cleared import policies
+func (r *RIB) selectBestRouteOnAdd(routeInfoRecordList RouteInfoRecordList, routeInfoRecord RouteInfoRecord) (addRouteList []RouteOpInfoRecord, deleteRouteList []RouteOpInfoRecord, newSelectedProtocol string) {
+	logger.Debug("selectBestRouteOnAdd current selected protocol = ", routeInfoRecordList.selectedRouteProtocol)
+	deleteRouteList = make([]RouteOpInfoRecord, 0)
+	addRouteList = make([]RouteOpInfoRecord, 0)
+	newSelectedProtocol = routeInfoRecordList.selectedRouteProtocol
+	newRouteProtocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	add := false
+	del := false
+	var addrouteOpInfoRecord RouteOpInfoRecord
+	var delrouteOpInfoRecord RouteOpInfoRecord
+	adminDistanceMap := r.Vrf(routeInfoRecordList.vrf).getAdminDistanceMap()
+
+	if routeInfoRecordList.selectedRouteProtocol == "INVALID" {
+		/*
+		   Currently, no route has been selected
+		*/
+		if routeInfoRecord.protocol != PROTOCOL_NONE {
+			logger.Debug("Selecting the new route because the current selected route is invalid")
+			add = true
+			addrouteOpInfoRecord.opType = FIBAndRIB
+			newSelectedProtocol = newRouteProtocol
+		}
+	} else if adminDistanceMap[newRouteProtocol].configuredDistance > adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
+		/*
+		   If the configured admin distance is more than the incoming route, add the route in RIB
+		*/
+		add = true
+		addrouteOpInfoRecord.opType = RIBOnly
+	} else if adminDistanceMap[newRouteProtocol].configuredDistance < adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
+		logger.Debug(" Selecting the new route because the admin distance of the new routetype ", newRouteProtocol, ":", adminDistanceMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]].configuredDistance, "is better than the selected route protocol ", routeInfoRecordList.selectedRouteProtocol, "'s admin distance ", adminDistanceMap[routeInfoRecordList.selectedRouteProtocol])
+		del = true
+		add = true
+		addrouteOpInfoRecord.opType = FIBAndRIB
+		delrouteOpInfoRecord.opType = FIBOnly
+		newSelectedProtocol = newRouteProtocol
+	} else if adminDistanceMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]].configuredDistance == adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
+		logger.Debug("Same admin distance ")
+		if newRouteProtocol == routeInfoRecordList.selectedRouteProtocol {
+			logger.Debug("Same protocol as the selected route")
+			if routeInfoRecord.metric == routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric {
+				logger.Debug("Adding a same cost route as the current selected routes")
+				if !newNextHop(routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]) {
+					logger.Debug("Not a new next hop ip, so do nothing")
+				} else {
+					logger.Debug("This is a new route with a new next hop IP")
+					addrouteOpInfoRecord.opType = FIBAndRIB
+					add = true
+				}
+			} else if routeInfoRecord.metric < routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric {
+				logger.Debug("New metric ", routeInfoRecord.metric, " is lower than the current metric ", routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric)
+				del = true
+				delrouteOpInfoRecord.opType = FIBAndRIB
+				add = true
+				addrouteOpInfoRecord.opType = FIBAndRIB
+			}
+		} else {
+			logger.Debug("Protocol ", newRouteProtocol, " has the same admin distance ", adminDistanceMap[newRouteProtocol].configuredDistance, " as the protocol", routeInfoRecordList.selectedRouteProtocol, "'s configured admin distance ", adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].configuredDistance)
+			if adminDistanceMap[newRouteProtocol].defaultDistance < adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].defaultDistance {
+				logger.Debug("Protocol ", newRouteProtocol, " has lower default admin distance ", adminDistanceMap[newRouteProtocol].defaultDistance, " than the protocol", routeInfoRecordList.selectedRouteProtocol, "'s default admin distance ", adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].defaultDistance)
+				del = true
+				delrouteOpInfoRecord.opType = FIBOnly
+				add = true
+				addrouteOpInfoRecord.opType = FIBAndRIB
+				newSelectedProtocol = newRouteProtocol
+			} else {
+				logger.Debug("Protocol ", newRouteProtocol, " has higher default admin distance ", adminDistanceMap[newRouteProtocol].configuredDistance, " than the protocol", routeInfoRecordList.selectedRouteProtocol, "'s default admin distance ", adminDistanceMap[routeInfoRecordList.selectedRouteProtocol].configuredDistance)
+				add = true
+				addrouteOpInfoRecord.opType = RIBOnly
+			}
+		}
+	}
+	logger.Debug("At the end of the route selection logic, add = ", add, " del = ", del)
+	if add == true {
+		addrouteOpInfoRecord.routeInfoRecord = routeInfoRecord
+		addRouteList = append(addRouteList, addrouteOpInfoRecord)
+	}
+	if del == true {
+		for i := 0; i < len(routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]); i++ {
+			delrouteOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][i]
+			deleteRouteList = append(deleteRouteList, delrouteOpInfoRecord)
+		}
+	}
+	return addRouteList, deleteRouteList, newSelectedProtocol
+}
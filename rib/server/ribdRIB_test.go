@@ -0,0 +1,297 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdRIB_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"testing"
+	"utils/policy"
+)
+
+func initTestRIB() *RIB {
+	if logger == nil {
+		logger, _ = RIBdNewLogger("ribd", "RIBDTEST")
+	}
+	if RouteProtocolTypeMapDB == nil || ReverseRouteProtoTypeMapDB == nil {
+		RouteProtocolTypeMapDB = make(map[string]int)
+		ReverseRouteProtoTypeMapDB = make(map[int]string)
+		BuildRouteProtocolTypeMapDB()
+	}
+	return NewRIB()
+}
+
+func buildTestRIBRouteInfoRecord(destNet string, nextHop string, protocol int8, metric int) RouteInfoRecord {
+	return RouteInfoRecord{
+		ipType:         defs.IPv4,
+		destNetIp:      net.ParseIP(destNet),
+		networkMask:    net.ParseIP("255.255.255.0"),
+		nextHopIp:      net.ParseIP(nextHop),
+		nextHopIpType:  defs.IPv4,
+		nextHopIfIndex: 1,
+		metric:         ribd.Int(metric),
+		protocol:       protocol,
+		vrf:            DefaultVrf,
+	}
+}
+
+func buildTestRIBRouteInfoRecordList(selectedProtocol string, records ...RouteInfoRecord) RouteInfoRecordList {
+	routeInfoRecordList := RouteInfoRecordList{
+		vrf:                   DefaultVrf,
+		selectedRouteProtocol: selectedProtocol,
+		routeInfoProtocolMap:  make(map[string][]RouteInfoRecord),
+	}
+	for _, record := range records {
+		protocol := ReverseRouteProtoTypeMapDB[int(record.protocol)]
+		routeInfoRecordList.routeInfoProtocolMap[protocol] = append(routeInfoRecordList.routeInfoProtocolMap[protocol], record)
+	}
+	return routeInfoRecordList
+}
+
+func TestRIBSelectBestRouteOnAdd(t *testing.T) {
+	fmt.Println("****TestRIBSelectBestRouteOnAdd****")
+	rib := initTestRIB()
+	static := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 0)
+	routeInfoRecordList := buildTestRIBRouteInfoRecordList("STATIC", static)
+
+	//no route selected yet
+	addList, delList, selected := rib.selectBestRouteOnAdd(buildTestRIBRouteInfoRecordList("INVALID"), static)
+	if len(addList) != 1 || addList[0].opType != FIBAndRIB || len(delList) != 0 || selected != "STATIC" {
+		t.Error("Unexpected selection of the first route, add:", addList, " del:", delList, " selected:", selected)
+	}
+	//worse admin distance
+	ospf := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.3", defs.OSPF, 10)
+	addList, delList, selected = rib.selectBestRouteOnAdd(routeInfoRecordList, ospf)
+	if len(addList) != 1 || addList[0].opType != RIBOnly || len(delList) != 0 || selected != "STATIC" {
+		t.Error("Unexpected selection of a route with a worse admin distance, add:", addList, " del:", delList, " selected:", selected)
+	}
+	//better admin distance
+	connected := buildTestRIBRouteInfoRecord("40.0.1.0", "0.0.0.0", defs.CONNECTED, 0)
+	addList, delList, selected = rib.selectBestRouteOnAdd(routeInfoRecordList, connected)
+	if len(addList) != 1 || addList[0].opType != FIBAndRIB || selected != "CONNECTED" {
+		t.Error("Unexpected selection of a route with a better admin distance, add:", addList, " selected:", selected)
+	}
+	if len(delList) != 1 || delList[0].opType != FIBOnly || !delList[0].routeInfoRecord.nextHopIp.Equal(static.nextHopIp) {
+		t.Error("Selected route not removed from the FIB, del:", delList)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestRIBSelectBestRouteOnAddECMP(t *testing.T) {
+	fmt.Println("****TestRIBSelectBestRouteOnAddECMP****")
+	rib := initTestRIB()
+	static := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 5)
+	routeInfoRecordList := buildTestRIBRouteInfoRecordList("STATIC", static)
+
+	//same cost, new next hop
+	addList, delList, selected := rib.selectBestRouteOnAdd(routeInfoRecordList, buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.3", defs.STATIC, 5))
+	if len(addList) != 1 || addList[0].opType != FIBAndRIB || len(delList) != 0 || selected != "STATIC" {
+		t.Error("ECMP route not added, add:", addList, " del:", delList, " selected:", selected)
+	}
+	//same cost, same next hop
+	addList, delList, _ = rib.selectBestRouteOnAdd(routeInfoRecordList, buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 5))
+	if len(addList) != 0 || len(delList) != 0 {
+		t.Error("Unexpected ops for an existing next hop, add:", addList, " del:", delList)
+	}
+	//lower cost replaces the selected routes
+	addList, delList, _ = rib.selectBestRouteOnAdd(routeInfoRecordList, buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.4", defs.STATIC, 1))
+	if len(addList) != 1 || addList[0].opType != FIBAndRIB || len(delList) != 1 || delList[0].opType != FIBAndRIB {
+		t.Error("Lower cost route did not replace the selected route, add:", addList, " del:", delList)
+	}
+	//higher cost is not added
+	addList, delList, _ = rib.selectBestRouteOnAdd(routeInfoRecordList, buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.4", defs.STATIC, 10))
+	if len(addList) != 0 || len(delList) != 0 {
+		t.Error("Unexpected ops for a higher cost route, add:", addList, " del:", delList)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestRIBSelectBestRoute(t *testing.T) {
+	fmt.Println("****TestRIBSelectBestRoute****")
+	rib := initTestRIB()
+	static1 := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 0)
+	static2 := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.3", defs.STATIC, 0)
+	ospf := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.4", defs.OSPF, 10)
+
+	//the selected protocol is still the best one
+	addList, delList, selected := rib.SelectBestRoute(buildTestRIBRouteInfoRecordList("STATIC", static1, static2, ospf))
+	if len(addList) != 0 || len(delList) != 0 || selected != "INVALID" {
+		t.Error("Unexpected reselection, add:", addList, " del:", delList, " selected:", selected)
+	}
+	//both ECMP routes of the better protocol replace the selected route
+	addList, delList, selected = rib.SelectBestRoute(buildTestRIBRouteInfoRecordList("OSPF", static1, static2, ospf))
+	if len(addList) != 2 || len(delList) != 1 || selected != "STATIC" {
+		t.Error("Unexpected reselection, add:", addList, " del:", delList, " selected:", selected)
+	}
+	if !delList[0].routeInfoRecord.nextHopIp.Equal(ospf.nextHopIp) || delList[0].opType != FIBOnly {
+		t.Error("Unexpected route removed from the FIB ", delList[0])
+	}
+	//the last routes of the selected protocol went away
+	addList, delList, selected = rib.SelectBestRoute(buildTestRIBRouteInfoRecordList("STATIC", ospf))
+	if len(addList) != 1 || len(delList) != 0 || selected != "OSPF" {
+		t.Error("Unexpected reselection, add:", addList, " del:", delList, " selected:", selected)
+	}
+	//a configured admin distance changes the order
+	rib.setAdminDistance("OSPF", 0)
+	addList, delList, selected = rib.SelectBestRoute(buildTestRIBRouteInfoRecordList("STATIC", static1, ospf))
+	if len(addList) != 1 || len(delList) != 1 || selected != "OSPF" {
+		t.Error("Admin distance not applied, add:", addList, " del:", delList, " selected:", selected)
+	}
+	if next := rib.SelectNextBestRoute(buildTestRIBRouteInfoRecordList("OSPF", static1, ospf), "OSPF"); next != "STATIC" {
+		t.Error("Unexpected next best route protocol ", next)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestRIBAddRemoveRoute(t *testing.T) {
+	fmt.Println("****TestRIBAddRemoveRoute****")
+	rib := initTestRIB()
+	prefix, _ := getNetowrkPrefixFromStrings("40.0.1.0", "255.255.255.0")
+	rib.Insert(DefaultVrf, defs.IPv4, prefix, buildTestRIBRouteInfoRecordList("INVALID"))
+
+	for i, nextHop := range []string{"11.1.10.2", "11.1.10.3", "11.1.10.3"} {
+		routeInfoRecordList, _ := rib.Get(DefaultVrf, defs.IPv4, prefix)
+		record := buildTestRIBRouteInfoRecord("40.0.1.0", nextHop, defs.STATIC, 0)
+		record.sliceIdx = len(rib.destNetSlice)
+		_, newNextHop, ok := rib.addRoute(prefix, record, routeInfoRecordList)
+		if !ok || newNextHop != (i < 2) {
+			t.Error("Unexpected result adding next hop ", nextHop, " ok:", ok, " newNextHop:", newNextHop)
+		}
+	}
+	routeInfoRecordList, found := rib.Get(DefaultVrf, defs.IPv4, prefix)
+	if !found || len(routeInfoRecordList.routeInfoProtocolMap["STATIC"]) != 2 {
+		t.Error("Unexpected routes ", routeInfoRecordList)
+	}
+	if rib.v4RouteCount != 3 || len(rib.destNetSlice) != 3 {
+		t.Error("Unexpected route count ", rib.v4RouteCount, " destNetSlice len ", len(rib.destNetSlice))
+	}
+	if _, found := rib.Lookup(DefaultVrf, net.ParseIP("40.0.1.5")); found {
+		t.Error("Destination without a selected route found by lookup")
+	}
+	routeInfoRecordList.selectedRouteProtocol = "STATIC"
+	rib.Set(DefaultVrf, defs.IPv4, prefix, routeInfoRecordList)
+	if _, found := rib.Lookup(DefaultVrf, net.ParseIP("40.0.1.5")); !found {
+		t.Error("Lookup of 40.0.1.5 failed")
+	}
+	if _, found := rib.Lookup(DefaultVrf, net.ParseIP("40.0.2.5")); found {
+		t.Error("Lookup of 40.0.2.5 succeeded")
+	}
+
+	if found, _ := rib.removeRoute(buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.9", defs.STATIC, 0), routeInfoRecordList); found {
+		t.Error("Unknown next hop removed")
+	}
+	if found, empty := rib.removeRoute(buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 0), routeInfoRecordList); !found || empty {
+		t.Error("Unexpected result removing the first next hop, found:", found, " empty:", empty)
+	}
+	if found, empty := rib.removeRoute(buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.3", defs.STATIC, 0), routeInfoRecordList); !found || !empty {
+		t.Error("Unexpected result removing the last next hop, found:", found, " empty:", empty)
+	}
+	fmt.Println("***********************************")
+}
+
+func createTestRIBRoute(t *testing.T, rib *RIB, destNet string, nextHop string, ifIndex ribd.Int, routeType ribd.Int) {
+	params := RouteParams{
+		ipType:         defs.IPv4,
+		destNetIp:      destNet,
+		networkMask:    "255.255.255.0",
+		nextHopIp:      nextHop,
+		nextHopIfIndex: ifIndex,
+		routeType:      routeType,
+		createType:     FIBAndRIB,
+		deleteType:     Invalid,
+		sliceIdx:       ribd.Int(len(rib.destNetSlice)),
+		vrf:            DefaultVrf,
+	}
+	if _, err := rib.createRoute(params); err != nil {
+		t.Error("createRoute ", destNet, " via ", nextHop, " failed, err:", err)
+	}
+}
+
+func TestTwoRIBs(t *testing.T) {
+	fmt.Println("****TestTwoRIBs****")
+	ribA := initTestRIB()
+	ribB := initTestRIB()
+	savedHandler := RouteServiceHandler
+	savedPolicyEngineDB := PolicyEngineDB
+	defer func() {
+		RouteServiceHandler = savedHandler
+		PolicyEngineDB = savedPolicyEngineDB
+	}()
+	testServer := &RIBDServer{
+		RIB:          initTestRIB(),
+		DBRouteCh:    make(chan RIBdServerConfig, 100),
+		ArpdRouteCh:  make(chan RIBdServerConfig, 100),
+		AsicdRouteCh: make(chan RIBdServerConfig, 100),
+	}
+	RouteServiceHandler = testServer
+	PolicyEngineDB = policy.NewPolicyEngineDB(logger)
+	PolicyEngineDB.SetDefaultExportPolicyActionFunc(defaultExportPolicyEngineActionFunc)
+
+	createTestRIBRoute(t, ribA, "11.1.10.0", "0.0.0.0", 1, defs.CONNECTED)
+	createTestRIBRoute(t, ribA, "40.0.1.0", "11.1.10.2", 1, defs.STATIC)
+	createTestRIBRoute(t, ribB, "12.1.10.0", "0.0.0.0", 2, defs.CONNECTED)
+	createTestRIBRoute(t, ribB, "40.0.1.0", "12.1.10.2", 2, defs.STATIC)
+
+	for _, rib := range []*RIB{ribA, ribB} {
+		if rib.v4RouteCount != 2 || len(rib.destNetSlice) != 2 || len(rib.connectedRoutes) != 1 {
+			t.Error("Unexpected route count ", rib.v4RouteCount, " destNetSlice len ", len(rib.destNetSlice), " connected routes ", rib.connectedRoutes)
+		}
+	}
+	if ribA.connectedRoutes[0].Ipaddr != "11.1.10.0" || ribB.connectedRoutes[0].Ipaddr != "12.1.10.0" {
+		t.Error("Connected routes mixed up ", ribA.connectedRoutes[0], " ", ribB.connectedRoutes[0])
+	}
+	if testServer.RIB.v4RouteCount != 0 || len(testServer.RIB.destNetSlice) != 0 {
+		t.Error("Routes created in the RIB of the server ", testServer.RIB.destNetSlice)
+	}
+	//each RIB resolves next hops with its own routes
+	if _, err := ribA.GetVrfRouteReachabilityInfo(DefaultVrf, "11.1.10.2", -1); err != nil {
+		t.Error("11.1.10.2 not reachable in RIB A, err:", err)
+	}
+	if _, err := ribB.GetVrfRouteReachabilityInfo(DefaultVrf, "11.1.10.2", -1); err == nil {
+		t.Error("11.1.10.2 reachable in RIB B")
+	}
+	for rib, nextHop := range map[*RIB]string{ribA: "11.1.10.2", ribB: "12.1.10.2"} {
+		nh, err := rib.GetVrfRouteReachabilityInfo(DefaultVrf, "40.0.1.5", -1)
+		if err != nil || nh.NextHopIp != nextHop {
+			t.Error("Unexpected next hop ", nh, " for 40.0.1.5, expected ", nextHop, " err:", err)
+		}
+	}
+
+	if _, err := ribA.deleteIPRoute(DefaultVrf, "40.0.1.0", defs.IPv4, "255.255.255.0", "STATIC", "11.1.10.2", 1, FIBAndRIB, defs.RoutePolicyStateChangetoInValid); err != nil {
+		t.Error("deleteIPRoute 40.0.1.0 in RIB A failed, err:", err)
+	}
+	if _, err := ribA.GetVrfRouteReachabilityInfo(DefaultVrf, "40.0.1.5", -1); err == nil {
+		t.Error("40.0.1.5 reachable in RIB A after the delete")
+	}
+	if nh, err := ribB.GetVrfRouteReachabilityInfo(DefaultVrf, "40.0.1.5", -1); err != nil || nh.NextHopIp != "12.1.10.2" {
+		t.Error("Route 40.0.1.0 of RIB B changed by the delete in RIB A, next hop ", nh, " err:", err)
+	}
+	if ribA.v4RouteCount != 1 || ribB.v4RouteCount != 2 {
+		t.Error("Unexpected route count ", ribA.v4RouteCount, " ", ribB.v4RouteCount, " after the delete in RIB A")
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,366 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdRPFRouteProcessApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"strconv"
+	"strings"
+	"time"
+	"utils/patriciaDB"
+)
+
+/*
+   RPF routes (multicast SAFI routes learnt by BGP) are stored in their own tries.
+   They are never installed in the FIB, multicast protocols look them up to find
+   the RPF interface towards a source.
+*/
+type rpfDestNet struct {
+	prefix patriciaDB.Prefix
+	ipType defs.IPType
+}
+
+func (r *RIB) getRPFRouteInfoMap(ipType defs.IPType) *patriciaDB.Trie {
+	if ipType == defs.IPv4 {
+		return r.rpfV4RouteInfoMap
+	}
+	return r.rpfV6RouteInfoMap
+}
+
+/*
+   Returns the protocol with the best admin distance that has RPF routes for this prefix
+*/
+func (r *RIB) selectRPFRouteProtocol(routeInfoRecordList RouteInfoRecordList) string {
+	adminDistanceSlice := r.Vrf(DefaultVrf).getAdminDistanceSlice()
+	for i := 0; i < len(adminDistanceSlice); i++ {
+		protocol := adminDistanceSlice[i].Protocol
+		if len(routeInfoRecordList.routeInfoProtocolMap[protocol]) > 0 {
+			return protocol
+		}
+	}
+	return "INVALID"
+}
+
+/*
+    This function performs config parameters validation for RPF routes.
+	   - Validate destinationNw. If provided in CIDR notation, convert to ip addr and mask values
+	   - check that the protocol is valid and at least one next hop is given
+	   - convert the next hop interface to its ifIndex string
+*/
+func (m RIBDServer) RPFRouteConfigValidationCheck(cfg *ribdInt.RPFRoute, op string) (err error) {
+	if strings.Contains(cfg.DestinationNw, "/") {
+		ip, ipNet, err := net.ParseCIDR(cfg.DestinationNw)
+		if err != nil {
+			logger.Err("Invalid RPF route destination ", cfg.DestinationNw)
+			return errors.New("Invalid Destination IP address")
+		}
+		cfg.DestinationNw = ip.String()
+		cfg.NetworkMask = net.IP(ipNet.Mask).String()
+	}
+	_, err = validateNetworkPrefix(cfg.DestinationNw, cfg.NetworkMask)
+	if err != nil {
+		logger.Err("RPFRouteConfigValidationCheck for route:", cfg, " validateNetworkPrefix() returned err ", err)
+		return err
+	}
+	if _, ok := RouteProtocolTypeMapDB[cfg.Protocol]; !ok {
+		logger.Err("route type ", cfg.Protocol, " invalid")
+		return errors.New("Invalid route protocol type")
+	}
+	if op == "del" {
+		return nil
+	}
+	if len(cfg.NextHop) == 0 {
+		logger.Err("Must specify next hop")
+		return errors.New("Next hop ip not specified")
+	}
+	for i := 0; i < len(cfg.NextHop); i++ {
+		_, err = getIP(cfg.NextHop[i].NextHopIp)
+		if err != nil {
+			logger.Err("nextHopIpAddr invalid")
+			return errors.New("Invalid next hop ip address")
+		}
+		if cfg.NextHop[i].NextHopIntRef != "" {
+			cfg.NextHop[i].NextHopIntRef, err = m.ConvertIntfStrToIfIndexStr(cfg.NextHop[i].NextHopIntRef)
+			if err != nil {
+				logger.Err("Invalid NextHop IntRef ", cfg.NextHop[i].NextHopIntRef)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func buildRPFRouteInfoRecord(cfg *ribdInt.RPFRoute, nh *ribdInt.RouteNextHopInfo) (destNet patriciaDB.Prefix,
+	routeInfoRecord RouteInfoRecord, err error) {
+	destNetIpAddr, err := getIP(cfg.DestinationNw)
+	if err != nil {
+		return destNet, routeInfoRecord, err
+	}
+	networkMaskAddr, err := getIP(cfg.NetworkMask)
+	if err != nil {
+		return destNet, routeInfoRecord, err
+	}
+	nextHopIpAddr, err := getIP(nh.NextHopIp)
+	if err != nil {
+		return destNet, routeInfoRecord, err
+	}
+	destNet, nwAddr, err := getNetworkPrefix(destNetIpAddr, networkMaskAddr)
+	if err != nil {
+		return destNet, routeInfoRecord, err
+	}
+	ipType := defs.IPv4
+	if destNetIpAddr.To4() == nil {
+		ipType = defs.IPv6
+	}
+	nextHopIpType := defs.IPv4
+	if nextHopIpAddr.To4() == nil {
+		nextHopIpType = defs.IPv6
+	}
+	nextHopIfIndex := ribd.Int(-1)
+	if nh.NextHopIntRef != "" {
+		ifIndex, err := strconv.Atoi(nh.NextHopIntRef)
+		if err == nil {
+			nextHopIfIndex = ribd.Int(ifIndex)
+		}
+	}
+	routeInfoRecord = RouteInfoRecord{
+		ipType:         ipType,
+		destNetIp:      destNetIpAddr,
+		networkMask:    networkMaskAddr,
+		protocol:       int8(RouteProtocolTypeMapDB[cfg.Protocol]),
+		nextHopIp:      nextHopIpAddr,
+		nextHopIpType:  nextHopIpType,
+		networkAddr:    nwAddr,
+		nextHopIfIndex: nextHopIfIndex,
+		metric:         ribd.Int(cfg.Cost),
+		weight:         ribd.Int(nh.Weight),
+	}
+	return destNet, routeInfoRecord, err
+}
+
+/*
+   Adds the next hops of the RPF route to the RPF table of the address family
+*/
+func (m RIBDServer) ProcessRPFRouteCreateConfig(cfg *ribdInt.RPFRoute) (val bool, err error) {
+	logger.Debug("ProcessRPFRouteCreateConfig: Received create RPF route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " number of next hops: ", len(cfg.NextHop))
+	for _, nh := range cfg.NextHop {
+		destNet, routeInfoRecord, err := buildRPFRouteInfoRecord(cfg, nh)
+		if err != nil {
+			logger.Err("ProcessRPFRouteCreateConfig: invalid RPF route ", cfg, " err:", err)
+			return false, err
+		}
+		rpfMap := m.RIB.getRPFRouteInfoMap(routeInfoRecord.ipType)
+		var routeInfoRecordList RouteInfoRecordList
+		item := rpfMap.Get(destNet)
+		if item == nil {
+			routeInfoRecordList = RouteInfoRecordList{
+				selectedRouteProtocol: "INVALID",
+				routeInfoProtocolMap:  make(map[string][]RouteInfoRecord),
+			}
+			m.RIB.rpfDestNets = append(m.RIB.rpfDestNets, rpfDestNet{destNet, routeInfoRecord.ipType})
+		} else {
+			routeInfoRecordList = item.(RouteInfoRecordList)
+		}
+		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[cfg.Protocol]
+		found, _, idx := findRouteWithNextHop(routeInfoList, routeInfoRecord.nextHopIpType,
+			routeInfoRecord.nextHopIp.String(), -1)
+		routeInfoRecord.routeUpdatedTime = time.Now().String()
+		if found {
+			routeInfoRecord.routeCreatedTime = routeInfoList[idx].routeCreatedTime
+			routeInfoList[idx] = routeInfoRecord
+		} else {
+			routeInfoRecord.routeCreatedTime = routeInfoRecord.routeUpdatedTime
+			routeInfoList = append(routeInfoList, routeInfoRecord)
+		}
+		routeInfoRecordList.routeInfoProtocolMap[cfg.Protocol] = routeInfoList
+		routeInfoRecordList.selectedRouteProtocol = m.RIB.selectRPFRouteProtocol(routeInfoRecordList)
+		rpfMap.Set(destNet, routeInfoRecordList)
+	}
+	return true, nil
+}
+
+/*
+   Removes the next hops of the RPF route. All the next hops of the protocol are
+   removed when the route has no next hops.
+*/
+func (m RIBDServer) ProcessRPFRouteDeleteConfig(cfg *ribdInt.RPFRoute) (val bool, err error) {
+	logger.Debug("ProcessRPFRouteDeleteConfig: Received delete RPF route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " number of next hops: ", len(cfg.NextHop))
+	destNet, err := getNetowrkPrefixFromStrings(cfg.DestinationNw, cfg.NetworkMask)
+	if err != nil {
+		return false, err
+	}
+	ipType := defs.IPv4
+	if ip, _ := getIP(cfg.DestinationNw); ip.To4() == nil {
+		ipType = defs.IPv6
+	}
+	rpfMap := m.RIB.getRPFRouteInfoMap(ipType)
+	item := rpfMap.Get(destNet)
+	if item == nil {
+		logger.Err("ProcessRPFRouteDeleteConfig: no RPF route for ", cfg.DestinationNw, ":", cfg.NetworkMask)
+		return false, errors.New(fmt.Sprintln("No RPF route found for ", cfg.DestinationNw, ":", cfg.NetworkMask))
+	}
+	routeInfoRecordList := item.(RouteInfoRecordList)
+	routeInfoList := routeInfoRecordList.routeInfoProtocolMap[cfg.Protocol]
+	if len(cfg.NextHop) == 0 {
+		routeInfoList = nil
+	}
+	for _, nh := range cfg.NextHop {
+		nextHopIp, err := getIP(nh.NextHopIp)
+		if err != nil {
+			continue
+		}
+		nextHopIpType := defs.IPv4
+		if nextHopIp.To4() == nil {
+			nextHopIpType = defs.IPv6
+		}
+		found, _, idx := findRouteWithNextHop(routeInfoList, nextHopIpType, nextHopIp.String(), -1)
+		if found {
+			routeInfoList = append(routeInfoList[:idx], routeInfoList[idx+1:]...)
+		}
+	}
+	if len(routeInfoList) == 0 {
+		delete(routeInfoRecordList.routeInfoProtocolMap, cfg.Protocol)
+	} else {
+		routeInfoRecordList.routeInfoProtocolMap[cfg.Protocol] = routeInfoList
+	}
+	if len(routeInfoRecordList.routeInfoProtocolMap) == 0 {
+		rpfMap.Delete(destNet)
+		for idx := 0; idx < len(m.RIB.rpfDestNets); idx++ {
+			if m.RIB.rpfDestNets[idx].ipType == ipType && string(m.RIB.rpfDestNets[idx].prefix) == string(destNet) {
+				m.RIB.rpfDestNets = append(m.RIB.rpfDestNets[:idx], m.RIB.rpfDestNets[idx+1:]...)
+				break
+			}
+		}
+		return true, nil
+	}
+	routeInfoRecordList.selectedRouteProtocol = m.RIB.selectRPFRouteProtocol(routeInfoRecordList)
+	rpfMap.Set(destNet, routeInfoRecordList)
+	return true, nil
+}
+
+/*
+   Returns the RPF next hop towards the source. The RPF table is looked up first
+   and the unicast RIB is used when there is no RPF route for the source.
+*/
+func (m RIBDServer) GetRPFRouteReachabilityInfo(srcIp string) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	logger.Debug("GetRPFRouteReachabilityInfo of ", srcIp)
+	srcIpAddr, err := getIP(srcIp)
+	if err != nil {
+		logger.Err("getIP returned Invalid source ip address for ", srcIp)
+		return nil, errors.New("Invalid source ip address")
+	}
+	rpfMap := m.RIB.rpfV6RouteInfoMap
+	lookupIp := srcIpAddr.To4()
+	if lookupIp != nil {
+		rpfMap = m.RIB.rpfV4RouteInfoMap
+		srcIpAddr = lookupIp
+	}
+	item := rpfMap.GetLongestPrefixNode(patriciaDB.Prefix(srcIpAddr))
+	if item != nil {
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]
+		if len(routeInfoList) > 0 {
+			v := routeInfoList[0]
+			nextHopIntf = &ribdInt.NextHopInfo{
+				NextHopIp:      v.nextHopIp.String(),
+				NextHopIfIndex: ribdInt.Int(v.nextHopIfIndex),
+				Metric:         ribdInt.Int(v.metric),
+				Ipaddr:         v.destNetIp.String(),
+				Mask:           v.networkMask.String(),
+				IsReachable:    true,
+			}
+			if v.nextHopIfIndex == -1 {
+				/*
+				   BGP next hops are resolved through the unicast RIB
+				*/
+				nhIntf, err := m.GetRouteReachabilityInfo(v.nextHopIp.String(), -1)
+				if err != nil {
+					logger.Err("RPF next hop ", v.nextHopIp.String(), " for source ", srcIp, " not reachable")
+					return nil, err
+				}
+				nextHopIntf.NextHopIfIndex = nhIntf.NextHopIfIndex
+			}
+			return nextHopIntf, nil
+		}
+	}
+	return m.GetRouteReachabilityInfo(srcIp, -1)
+}
+
+func (m RIBDServer) GetBulkRPFRouteState(fromIndex ribdInt.Int, rcount ribdInt.Int) (routes *ribdInt.RPFRouteStateGetInfo, err error) {
+	var i, validCount, toIndex ribdInt.Int
+	routes = ribdInt.NewRPFRouteStateGetInfo()
+	routes.RPFRouteStateList = make([]*ribdInt.RPFRouteState, 0)
+	more := true
+	for ; ; i++ {
+		if i+fromIndex >= ribdInt.Int(len(m.RIB.rpfDestNets)) {
+			more = false
+			break
+		}
+		if validCount == rcount {
+			break
+		}
+		destNet := m.RIB.rpfDestNets[i+fromIndex]
+		item := m.RIB.getRPFRouteInfoMap(destNet.ipType).Get(destNet.prefix)
+		if item == nil {
+			continue
+		}
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]
+		if len(routeInfoList) == 0 {
+			continue
+		}
+		rpfRoute := &ribdInt.RPFRouteState{
+			DestinationNw:    routeInfoList[0].networkAddr,
+			Protocol:         routeInfoRecordList.selectedRouteProtocol,
+			RouteCreatedTime: routeInfoList[0].routeCreatedTime,
+			RouteUpdatedTime: routeInfoList[0].routeUpdatedTime,
+			NextHopList:      make([]*ribdInt.RouteNextHopInfo, 0),
+		}
+		for _, routeInfoRecord := range routeInfoList {
+			nextHop := &ribdInt.RouteNextHopInfo{
+				NextHopIp:     routeInfoRecord.nextHopIp.String(),
+				NextHopIntRef: strconv.Itoa(int(routeInfoRecord.nextHopIfIndex)),
+				Weight:        int32(routeInfoRecord.weight),
+			}
+			if intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(routeInfoRecord.nextHopIfIndex)); ok {
+				nextHop.NextHopIntRef = intfEntry.name
+			}
+			rpfRoute.NextHopList = append(rpfRoute.NextHopList, nextHop)
+		}
+		routes.RPFRouteStateList = append(routes.RPFRouteStateList, rpfRoute)
+		toIndex = i + fromIndex
+		validCount++
+	}
+	routes.StartIdx = fromIndex
+	routes.EndIdx = toIndex + 1
+	routes.More = more
+	routes.Count = validCount
+	return routes, err
+}
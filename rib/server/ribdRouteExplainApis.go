@@ -0,0 +1,226 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdRouteExplainApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribdInt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+   Admin distance route selection uses for the protocol in the VRF, the
+   configured distance when there is one
+*/
+func (rib *VrfRIB) adminDistance(protocol string) int {
+	routeDistanceConfig, ok := rib.getAdminDistanceMap()[protocol]
+	if !ok {
+		return 255
+	}
+	if routeDistanceConfig.configuredDistance != -1 {
+		return routeDistanceConfig.configuredDistance
+	}
+	return routeDistanceConfig.defaultDistance
+}
+
+/*
+   Candidate routes of the destination in the order route selection goes
+   over them, by admin distance and then in the order they were added
+*/
+func (r *RIB) candidateRoutes(routeInfoRecordList RouteInfoRecordList) []RouteInfoRecord {
+	protocols := make([]string, 0)
+	for _, protocolDistance := range r.Vrf(routeInfoRecordList.vrf).getAdminDistanceSlice() {
+		protocols = append(protocols, protocolDistance.Protocol)
+	}
+	others := make([]string, 0)
+	for protocol, _ := range routeInfoRecordList.routeInfoProtocolMap {
+		if _, ok := r.Vrf(routeInfoRecordList.vrf).getAdminDistanceMap()[protocol]; !ok {
+			others = append(others, protocol)
+		}
+	}
+	sort.Strings(others)
+	candidates := make([]RouteInfoRecord, 0)
+	for _, protocol := range append(protocols, others...) {
+		candidates = append(candidates, routeInfoRecordList.routeInfoProtocolMap[protocol]...)
+	}
+	return candidates
+}
+
+/*
+   Why the candidate route is not in the FIB, reason is empty for a selected
+   route with a reachable next hop
+*/
+func (r *RIB) explainCandidate(routeInfoRecordList RouteInfoRecordList, routeInfoRecord RouteInfoRecord) (selected bool, rejected bool, reason string) {
+	rib := r.Vrf(routeInfoRecordList.vrf)
+	protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	selectedProtocol := routeInfoRecordList.selectedRouteProtocol
+	if r.isRouteRejected(routeInfoRecordList, routeInfoRecord) {
+		return false, true, "rejected by a route disposition policy"
+	}
+	if selectedProtocol == "INVALID" || len(routeInfoRecordList.routeInfoProtocolMap[selectedProtocol]) == 0 {
+		return false, false, "no route is selected for the destination"
+	}
+	if protocol != selectedProtocol {
+		distance, selectedDistance := rib.adminDistance(protocol), rib.adminDistance(selectedProtocol)
+		if distance > selectedDistance {
+			return false, false, fmt.Sprint("admin distance ", distance, " is worse than ", selectedDistance, " of the selected protocol ", selectedProtocol)
+		}
+		if distance == selectedDistance {
+			return false, false, fmt.Sprint("admin distance ", distance, " is the same as the selected protocol ", selectedProtocol, ", which is preferred on a tie")
+		}
+		return false, false, fmt.Sprint("admin distance ", distance, " is better than ", selectedDistance, " of the selected protocol ", selectedProtocol, " but no route of ", protocol, " was eligible when the route was selected")
+	}
+	bestMetric := routeInfoRecordList.routeInfoProtocolMap[selectedProtocol][0].metric
+	for _, selectedRecord := range routeInfoRecordList.routeInfoProtocolMap[selectedProtocol] {
+		if selectedRecord.metric < bestMetric {
+			bestMetric = selectedRecord.metric
+		}
+	}
+	if routeInfoRecord.metric > bestMetric {
+		return false, false, fmt.Sprint("metric ", routeInfoRecord.metric, " is worse than ", bestMetric, " of the selected route")
+	}
+	if !routeInfoRecord.resolvedNextHopIpIntf.IsReachable && !routeInfoRecord.nextHopIp.IsUnspecified() {
+		return true, false, "next hop " + routeInfoRecord.nextHopIp.String() + " is not reachable"
+	}
+	return true, false, ""
+}
+
+/*
+   Destination the explain API looks at. An address is looked up with a
+   longest prefix match, a prefix is looked up as is and falls back to the
+   longest match that covers it.
+*/
+func (r *RIB) explainMatch(vrf string, destNet string) (routeInfoRecordList RouteInfoRecordList, err error) {
+	if !strings.Contains(destNet, "/") {
+		ip := net.ParseIP(destNet)
+		if ip == nil {
+			return routeInfoRecordList, errors.New(fmt.Sprintln("Invalid address ", destNet))
+		}
+		routeInfoRecordList, found := r.Match(vrf, ip)
+		if !found {
+			return routeInfoRecordList, errors.New(fmt.Sprintln("No route to ", destNet, " in vrf ", getVrfName(vrf)))
+		}
+		return routeInfoRecordList, nil
+	}
+	ip, ipNet, err := net.ParseCIDR(destNet)
+	if err != nil {
+		return routeInfoRecordList, errors.New(fmt.Sprintln("Invalid prefix ", destNet))
+	}
+	ipType := defs.IPv4
+	if ip.To4() == nil {
+		ipType = defs.IPv6
+	}
+	prefix, err := getNetworkPrefixFromCIDR(destNet)
+	if err == nil {
+		if routeInfoRecordList, found := r.Get(vrf, ipType, prefix); found {
+			return routeInfoRecordList, nil
+		}
+	}
+	queryLen, _ := ipNet.Mask.Size()
+	routeInfoRecordList, found := r.Match(vrf, ipNet.IP)
+	if found {
+		for _, routeInfoList := range routeInfoRecordList.routeInfoProtocolMap {
+			if len(routeInfoList) == 0 {
+				continue
+			}
+			if matchLen, err := getPrefixLen(routeInfoList[0].networkMask); err == nil && matchLen <= queryLen {
+				return routeInfoRecordList, nil
+			}
+			break
+		}
+	}
+	return routeInfoRecordList, errors.New(fmt.Sprintln("No route to ", destNet, " in vrf ", getVrfName(vrf)))
+}
+
+/*
+   Explains the route selection for the destination of destNet, an address or
+   a prefix. Every candidate route is returned with its admin distance, metric,
+   next hop resolution and the reason it was not selected, the selected routes
+   also tell whether and when they were programmed in the FIB.
+*/
+func (m RIBDServer) GetRouteSelectionExplain(vrf string, destNet string) (*ribdInt.RouteSelectionExplainState, error) {
+	vrf = getVrfName(vrf)
+	if m.RIB.Vrf(vrf) == nil {
+		return nil, errors.New(fmt.Sprintln("VRF ", vrf, " not found"))
+	}
+	routeInfoRecordList, err := m.RIB.explainMatch(vrf, destNet)
+	if err != nil {
+		return nil, err
+	}
+	state := ribdInt.NewRouteSelectionExplainState()
+	state.Vrf = vrf
+	state.Destination = destNet
+	state.SelectedProtocol = routeInfoRecordList.selectedRouteProtocol
+	state.PolicyList = routeInfoRecordList.policyList
+	state.PolicyHitCounter = int32(routeInfoRecordList.policyHitCounter)
+	state.Candidates = make([]*ribdInt.RouteCandidateState, 0)
+	rib := m.RIB.Vrf(vrf)
+	for _, routeInfoRecord := range m.RIB.candidateRoutes(routeInfoRecordList) {
+		protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+		state.MatchedPrefix = routeInfoRecord.networkAddr
+		selected, rejected, reason := m.RIB.explainCandidate(routeInfoRecordList, routeInfoRecord)
+		candidate := &ribdInt.RouteCandidateState{
+			Protocol:         protocol,
+			AdminDistance:    int32(rib.adminDistance(protocol)),
+			Metric:           int32(routeInfoRecord.metric),
+			NextHopIp:        routeInfoRecord.nextHopIp.String(),
+			NextHopIntRef:    strconv.Itoa(int(routeInfoRecord.nextHopIfIndex)),
+			IsStale:          routeInfoRecord.stale,
+			Selected:         selected,
+			PolicyRejected:   rejected,
+			Reason:           reason,
+			RouteCreatedTime: routeInfoRecord.routeCreatedTime,
+		}
+		if intfEntry, ok := m.RIB.IntfEntry(int32(routeInfoRecord.nextHopIfIndex)); ok {
+			candidate.NextHopIntRef = intfEntry.name
+		}
+		resolveVrf := vrf
+		if routeInfoRecord.sourceVrf != "" {
+			resolveVrf = routeInfoRecord.sourceVrf
+		}
+		_, resolvedNextHopIntf, err := m.RIB.ResolveVrfNextHop(resolveVrf, routeInfoRecord.nextHopIp.String())
+		if err == nil {
+			candidate.ResolvedNextHopIp = resolvedNextHopIntf.NextHopIp
+			candidate.ResolvedNextHopIntRef = strconv.Itoa(int(resolvedNextHopIntf.NextHopIfIndex))
+			if intfEntry, ok := m.RIB.IntfEntry(int32(resolvedNextHopIntf.NextHopIfIndex)); ok {
+				candidate.ResolvedNextHopIntRef = intfEntry.name
+			}
+			candidate.IsReachable = resolvedNextHopIntf.IsReachable
+		}
+		if selected && m.FIBQueue != nil {
+			if programmedTime, found := m.FIBQueue.ProgrammedTime(routeInfoRecord); found {
+				candidate.InFIB = true
+				candidate.FIBProgrammedTime = programmedTime.String()
+			}
+		}
+		state.Candidates = append(state.Candidates, candidate)
+	}
+	return state, nil
+}
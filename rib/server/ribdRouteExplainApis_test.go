@@ -0,0 +1,97 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdRouteExplainApis_test.go
+package server
+
+import (
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteSelectionExplain(t *testing.T) {
+	fmt.Println("****TestRouteSelectionExplain****")
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	rib := initTestRIB()
+	testServer := &RIBDServer{RIB: rib, FIBQueue: NewFIBQueue(FIBQueueMaxDepth)}
+	RouteServiceHandler = testServer
+
+	connected := buildTestRIBRouteInfoRecord("11.1.10.0", "0.0.0.0", defs.CONNECTED, 0)
+	connected.networkAddr = "11.1.10.0/24"
+	prefix, _ := getNetowrkPrefixFromStrings("11.1.10.0", "255.255.255.0")
+	rib.Insert(DefaultVrf, defs.IPv4, prefix, buildTestRIBRouteInfoRecordList("CONNECTED", connected))
+
+	static1 := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.2", defs.STATIC, 0)
+	static2 := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.3", defs.STATIC, 5)
+	ospf := buildTestRIBRouteInfoRecord("40.0.1.0", "11.1.10.4", defs.OSPF, 10)
+	for _, record := range []*RouteInfoRecord{&static1, &static2, &ospf} {
+		record.networkAddr = "40.0.1.0/24"
+		record.resolvedNextHopIpIntf.IsReachable = true
+	}
+	prefix, _ = getNetowrkPrefixFromStrings("40.0.1.0", "255.255.255.0")
+	rib.Insert(DefaultVrf, defs.IPv4, prefix, buildTestRIBRouteInfoRecordList("STATIC", ospf, static1, static2))
+	_, _, key := getFIBQueueKey(static1)
+	testServer.FIBQueue.programmed[key] = time.Now()
+
+	explain, err := testServer.GetRouteSelectionExplain(DefaultVrf, "40.0.1.5")
+	if err != nil {
+		t.Error("Explain of 40.0.1.5 failed with err ", err)
+		return
+	}
+	for _, candidate := range explain.Candidates {
+		fmt.Println("candidate:", candidate)
+	}
+	if explain.MatchedPrefix != "40.0.1.0/24" || explain.SelectedProtocol != "STATIC" || len(explain.Candidates) != 3 {
+		t.Error("Unexpected explain ", explain)
+		return
+	}
+	selected := explain.Candidates[0]
+	if selected.NextHopIp != "11.1.10.2" || !selected.Selected || selected.Reason != "" || !selected.InFIB || !selected.IsReachable || selected.AdminDistance != 1 {
+		t.Error("Unexpected selected candidate ", selected)
+	}
+	if candidate := explain.Candidates[1]; candidate.Selected || !strings.Contains(candidate.Reason, "metric") {
+		t.Error("Unexpected reason for the higher metric candidate ", candidate)
+	}
+	if candidate := explain.Candidates[2]; candidate.Protocol != "OSPF" || candidate.Selected || candidate.InFIB || !strings.Contains(candidate.Reason, "admin distance 110") {
+		t.Error("Unexpected reason for the OSPF candidate ", candidate)
+	}
+
+	if _, err := testServer.GetRouteSelectionExplain(DefaultVrf, "40.0.1.128/25"); err != nil {
+		t.Error("Explain of a prefix covered by 40.0.1.0/24 failed with err ", err)
+	}
+	for _, destNet := range []string{"40.0.0.0/16", "50.0.0.1", "40.0.1"} {
+		if _, err := testServer.GetRouteSelectionExplain(DefaultVrf, destNet); err == nil {
+			t.Error("Explain of ", destNet, " did not fail")
+		}
+	}
+	if _, err := testServer.GetRouteSelectionExplain("red", "40.0.1.5"); err == nil {
+		t.Error("Explain in an unknown vrf did not fail")
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,261 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdRouteLeakApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"utils/patriciaDB"
+	"utils/policy"
+)
+
+/*
+   Routes of a VRF imported into another VRF. The routes of the source VRF
+   matched by the policy are copied to the destination VRF. The copies keep
+   the source VRF so that their next hops are resolved in the source table,
+   and they are withdrawn when the route they were copied from is withdrawn.
+*/
+type RouteLeakInfo struct {
+	srcVrf string
+	dstVrf string
+	policy string
+}
+
+var RouteLeakMap = make(map[string]RouteLeakInfo) //map[leak target]
+
+/*
+   The leak is applied as a redistribution of the source VRF routes, the
+   target of the redistribution names the leak
+*/
+func getRouteLeakTarget(srcVrf string, dstVrf string) string {
+	return "__VrfLeak" + srcVrf + ":" + dstVrf + "__"
+}
+
+/*
+   VRF the next hop of the route is resolved in
+*/
+func getRouteResolveVrf(routeInfoRecord RouteInfoRecord) string {
+	if routeInfoRecord.sourceVrf != "" {
+		return routeInfoRecord.sourceVrf
+	}
+	return getVrfName(routeInfoRecord.vrf)
+}
+
+/*
+   VRFs importing routes from the vrf
+*/
+func getRouteLeakDstVrfs(vrf string) (dstVrfs []string) {
+	vrf = getVrfName(vrf)
+	for _, leakInfo := range RouteLeakMap {
+		if leakInfo.srcVrf == vrf {
+			dstVrfs = append(dstVrfs, leakInfo.dstVrf)
+		}
+	}
+	return dstVrfs
+}
+
+func isVrfRouteLeakConfigured(vrf string) bool {
+	for _, leakInfo := range RouteLeakMap {
+		if leakInfo.srcVrf == vrf || leakInfo.dstVrf == vrf {
+			return true
+		}
+	}
+	return false
+}
+
+func (m RIBDServer) VrfRouteLeakConfigValidationCheck(cfg *ribdInt.VrfRouteLeak, op string) (err error) {
+	srcVrf := getVrfName(cfg.SrcVrf)
+	dstVrf := getVrfName(cfg.DstVrf)
+	if srcVrf == dstVrf {
+		return errors.New(fmt.Sprintln("Source and destination VRF are the same: ", srcVrf))
+	}
+	_, ok := RouteLeakMap[getRouteLeakTarget(srcVrf, dstVrf)]
+	if op == "del" {
+		if !ok {
+			return errors.New(fmt.Sprintln("No route leak from vrf ", srcVrf, " to vrf ", dstVrf))
+		}
+		return nil
+	}
+	if ok {
+		return errors.New(fmt.Sprintln("Route leak from vrf ", srcVrf, " to vrf ", dstVrf, " already exists"))
+	}
+	if getVrfRIB(srcVrf) == nil || getVrfRIB(dstVrf) == nil {
+		return errors.New(fmt.Sprintln("VRF ", srcVrf, " or ", dstVrf, " not found"))
+	}
+	if m.PolicyEngineDB.PolicyDB.Get(patriciaDB.Prefix(cfg.Policy)) == nil {
+		return errors.New(fmt.Sprintln("Policy ", cfg.Policy, " not defined"))
+	}
+	return nil
+}
+
+func getRouteLeakApplyPolicyInfo(leakInfo RouteLeakInfo) []*ribdInt.ApplyPolicyInfo {
+	return []*ribdInt.ApplyPolicyInfo{&ribdInt.ApplyPolicyInfo{
+		Source:     getRouteLeakTarget(leakInfo.srcVrf, leakInfo.dstVrf),
+		Policy:     leakInfo.policy,
+		Action:     "Leak",
+		Conditions: make([]*ribdInt.ConditionInfo, 0),
+	}}
+}
+
+func (m *RIBDServer) ProcessVrfRouteLeakCreateConfig(cfg *ribdInt.VrfRouteLeak, db *policy.PolicyEngineDB, globalDb *policy.PolicyEngineDB) (err error) {
+	logger.Info("ProcessVrfRouteLeakCreateConfig: vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " policy ", cfg.Policy)
+	leakInfo := RouteLeakInfo{getVrfName(cfg.SrcVrf), getVrfName(cfg.DstVrf), cfg.Policy}
+	RouteLeakMap[getRouteLeakTarget(leakInfo.srcVrf, leakInfo.dstVrf)] = leakInfo
+	m.UpdateApplyPolicyList(getRouteLeakApplyPolicyInfo(leakInfo), nil, true, db)
+	m.UpdateApplyPolicyList(getRouteLeakApplyPolicyInfo(leakInfo), nil, false, globalDb)
+	return nil
+}
+
+func (m *RIBDServer) ProcessVrfRouteLeakDeleteConfig(cfg *ribdInt.VrfRouteLeak, db *policy.PolicyEngineDB, globalDb *policy.PolicyEngineDB) (err error) {
+	logger.Info("ProcessVrfRouteLeakDeleteConfig: vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf)
+	target := getRouteLeakTarget(getVrfName(cfg.SrcVrf), getVrfName(cfg.DstVrf))
+	leakInfo, ok := RouteLeakMap[target]
+	if !ok {
+		return errors.New("Route leak not found")
+	}
+	//the leaked routes are withdrawn by the undo of the policy, keep the leak until then
+	m.UpdateApplyPolicyList(nil, getRouteLeakApplyPolicyInfo(leakInfo), true, db)
+	m.UpdateApplyPolicyList(nil, getRouteLeakApplyPolicyInfo(leakInfo), false, globalDb)
+	for _, routeInfoRecord := range getLeakedRoutes(leakInfo, nil) {
+		withdrawLeakedRoute(routeInfoRecord)
+	}
+	delete(RouteLeakMap, target)
+	return nil
+}
+
+func getRouteParamsIpType(routeInfo RouteParams) defs.IPType {
+	if ip := net.ParseIP(routeInfo.destNetIp); ip != nil && ip.To4() == nil {
+		return defs.IPv6
+	}
+	return defs.IPv4
+}
+
+/*
+   Routes of the protocol of the route in the vrf. The policy engine does not
+   always pass the next hop, all the next hops of the destination are
+   returned then.
+*/
+func getPolicyRouteRecords(vrf string, routeInfo RouteParams) (routeInfoList []RouteInfoRecord) {
+	ipPrefix, err := getNetowrkPrefixFromStrings(routeInfo.destNetIp, routeInfo.networkMask)
+	if err != nil {
+		return routeInfoList
+	}
+	routeInfoRecordListItem := RouteServiceHandler.RIB.RouteInfoMapGet(vrf, getRouteParamsIpType(routeInfo), ipPrefix)
+	if routeInfoRecordListItem == nil {
+		return routeInfoList
+	}
+	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
+	for _, routeInfoRecord := range routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfo.routeType)]] {
+		if routeInfo.nextHopIp != "" && routeInfoRecord.nextHopIp.String() != routeInfo.nextHopIp {
+			continue
+		}
+		routeInfoList = append(routeInfoList, routeInfoRecord)
+	}
+	return routeInfoList
+}
+
+/*
+   Copies of the route in the destination VRF of the leak. All the leaked
+   routes of the leak are returned when routeInfo is nil.
+*/
+func getLeakedRoutes(leakInfo RouteLeakInfo, routeInfo *RouteParams) (routeInfoList []RouteInfoRecord) {
+	if routeInfo != nil {
+		for _, routeInfoRecord := range getPolicyRouteRecords(leakInfo.dstVrf, *routeInfo) {
+			if routeInfoRecord.sourceVrf == leakInfo.srcVrf {
+				routeInfoList = append(routeInfoList, routeInfoRecord)
+			}
+		}
+		return routeInfoList
+	}
+	rib := getVrfRIB(leakInfo.dstVrf)
+	if rib == nil {
+		return routeInfoList
+	}
+	collectLeakedRoutes := func(prefix patriciaDB.Prefix, item patriciaDB.Item) (err error) {
+		for _, protocolRouteList := range item.(RouteInfoRecordList).routeInfoProtocolMap {
+			for _, routeInfoRecord := range protocolRouteList {
+				if routeInfoRecord.sourceVrf == leakInfo.srcVrf {
+					routeInfoList = append(routeInfoList, routeInfoRecord)
+				}
+			}
+		}
+		return nil
+	}
+	rib.v4RouteInfoMap.Visit(collectLeakedRoutes)
+	rib.v6RouteInfoMap.Visit(collectLeakedRoutes)
+	return routeInfoList
+}
+
+func leakRoute(leakInfo RouteLeakInfo, routeInfo RouteParams) {
+	for _, routeInfoRecord := range getPolicyRouteRecords(leakInfo.srcVrf, routeInfo) {
+		if routeInfoRecord.sourceVrf != "" {
+			//leaked routes are not leaked again
+			continue
+		}
+		params := BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
+		params.weight = routeInfoRecord.weight
+		params.vrf = leakInfo.dstVrf
+		params.sourceVrf = leakInfo.srcVrf
+		params.tag = routeInfo.tag
+		params.sliceIdx = ribd.Int(len(RouteServiceHandler.RIB.destNetSlice))
+		params.createType = FIBAndRIB
+		params.deleteType = Invalid
+		logger.Info("leakRoute: ", routeInfoRecord.networkAddr, " nextHopIp ", params.nextHopIp, " from vrf ", leakInfo.srcVrf, " to vrf ", leakInfo.dstVrf)
+		_, err := RouteServiceHandler.RIB.createRoute(params)
+		if err != nil {
+			logger.Debug("leakRoute: route not leaked, err ", err)
+		}
+	}
+}
+
+func withdrawLeakedRoute(routeInfoRecord RouteInfoRecord) {
+	logger.Info("withdrawLeakedRoute: ", routeInfoRecord.networkAddr, " nextHopIp ", routeInfoRecord.nextHopIp.String(), " from vrf ", routeInfoRecord.vrf)
+	_, err := RouteServiceHandler.RIB.deleteIPRoute(routeInfoRecord.vrf, routeInfoRecord.destNetIp.String(), routeInfoRecord.ipType, routeInfoRecord.networkMask.String(),
+		ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, FIBAndRIB, defs.RoutePolicyStateChangetoInValid)
+	if err != nil {
+		logger.Err("withdrawLeakedRoute: failed to delete leaked route ", routeInfoRecord.networkAddr, " err ", err)
+	}
+}
+
+/*
+   Leak action of the policy engine, evt tells whether the route of the
+   source VRF is to be leaked or withdrawn
+*/
+func policyEngineActionLeakRoute(leakInfo RouteLeakInfo, routeInfo RouteParams, evt int) {
+	if getVrfName(routeInfo.vrf) != leakInfo.srcVrf || routeInfo.sourceVrf != "" {
+		return
+	}
+	if evt == defs.NOTIFY_ROUTE_CREATED {
+		leakRoute(leakInfo, routeInfo)
+		return
+	}
+	for _, routeInfoRecord := range getLeakedRoutes(leakInfo, &routeInfo) {
+		withdrawLeakedRoute(routeInfoRecord)
+	}
+}
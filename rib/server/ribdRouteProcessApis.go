@@ -24,7 +24,6 @@
 package server
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	defs "l3/rib/ribdCommonDefs"
@@ -49,6 +48,7 @@ type RouteInfoRecord struct {
 	nextHopIp               net.IP
 	nextHopIpType           defs.IPType
 	resolvedNextHopIpIntf   ribdInt.NextHopInfo //immediate next hop info
+	nextHopPrefix           string              //prefix of the route the next hop resolves through
 	networkAddr             string              //cidr
 	nextHopIfIndex          ribd.Int
 	metric                  ribd.Int
@@ -58,12 +58,18 @@ type RouteInfoRecord struct {
 	isPolicyBasedStateValid bool
 	routeCreatedTime        string
 	routeUpdatedTime        string
+	stale                   bool   //protocol daemon went down and has not refreshed the route yet
+	vrf                     string //VRF of the next hop interface
+	sourceVrf               string //VRF the route was leaked from, the next hop is resolved in that VRF
+	bfd                     string //BFD session type tracking the next hop of a static route
+	tag                     uint32 //route tag set by the origin of the route or by a set tag policy action
 }
 
 /*
    Map of routeInfoRecords for each protocol type along with few other attributes
 */
 type RouteInfoRecordList struct {
+	vrf                     string
 	selectedRouteProtocol   string
 	routeInfoProtocolMap    map[string][]RouteInfoRecord
 	policyHitCounter        ribd.Int
@@ -96,91 +102,104 @@ type RouteReachabilityStatusInfo struct {
 	status      string
 	protocol    string
 	nextHopIntf ribdInt.NextHopInfo
+	vrf         string
 }
 
 var DummyRouteInfoRecord RouteInfoRecord
-var destNetSlice []localDB
-var localRouteEventsDB []RouteEventInfo
 
 /*
    RoutInfoMap operations functions
 */
-func RouteInfoMapInsert(ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) (ok bool) {
-	logger.Debug("RouteInfoMapInsert prefix: %v", prefix, "ipType:", ipType)
-	if ipType == defs.IPv4 {
-		ok = V4RouteInfoMap.Insert(prefix, routeInfoRecordList)
-	} else {
-		ok = V6RouteInfoMap.Insert(prefix, routeInfoRecordList)
-	}
-	return ok
+func (r *RIB) RouteInfoMapInsert(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) (ok bool) {
+	logger.Debug("RouteInfoMapInsert prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		logger.Err("RouteInfoMapInsert: VRF ", vrf, " not found")
+		return false
+	}
+	return routeInfoMap.Insert(prefix, routeInfoRecordList)
 }
-func RouteInfoMapSet(ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) {
-	logger.Debug("RouteInfoMapSet prefix: %v", prefix, "ipType:", ipType)
-	if ipType == defs.IPv4 {
-		V4RouteInfoMap.Set(prefix, routeInfoRecordList)
-	} else {
-		V6RouteInfoMap.Set(prefix, routeInfoRecordList)
+func (r *RIB) RouteInfoMapSet(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) {
+	logger.Debug("RouteInfoMapSet prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		logger.Err("RouteInfoMapSet: VRF ", vrf, " not found")
+		return
 	}
+	routeInfoMap.Set(prefix, routeInfoRecordList)
 }
-func RouteInfoMapDelete(ipType defs.IPType, prefix patriciaDB.Prefix) {
-	logger.Debug("RouteInfoMapDelete prefix: %v", prefix, "ipType:", ipType)
-	if ipType == defs.IPv4 {
-		V4RouteInfoMap.Delete(prefix)
-	} else {
-		V6RouteInfoMap.Delete(prefix)
+func (r *RIB) RouteInfoMapDelete(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix) {
+	logger.Debug("RouteInfoMapDelete prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return
 	}
+	routeInfoMap.Delete(prefix)
 }
-func RouteInfoMapGet(ipType defs.IPType, prefix patriciaDB.Prefix) (item interface{}) {
-	logger.Debug("RouteInfoMapGet prefix: %v", prefix, "ipType:", ipType)
-	if ipType == defs.IPv4 {
-		item = V4RouteInfoMap.Get(prefix)
-	} else {
-		item = V6RouteInfoMap.Get(prefix)
+func (r *RIB) RouteInfoMapGet(vrf string, ipType defs.IPType, prefix patriciaDB.Prefix) (item interface{}) {
+	logger.Debug("RouteInfoMapGet prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := r.routeInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return nil
 	}
-	return item
+	return routeInfoMap.Get(prefix)
 }
-func RouteInfoMapVisitAndUpdate(ipType defs.IPType, routeReachabilityStatusInfo RouteReachabilityStatusInfo) {
-	logger.Debug("RouteInfoMapVisitAndUpdate() routeReachabilityStatusInfo", routeReachabilityStatusInfo, "ipType:", ipType)
-	if ipType == defs.IPv4 {
-		V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, routeReachabilityStatusInfo)
-	} else {
-		V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, routeReachabilityStatusInfo)
+func (r *RIB) RouteInfoMapVisitAndUpdate(ipType defs.IPType, routeReachabilityStatusInfo RouteReachabilityStatusInfo) {
+	logger.Debug("r.RouteInfoMapVisitAndUpdate() routeReachabilityStatusInfo", routeReachabilityStatusInfo, "ipType:", ipType)
+	routeInfoMap := r.routeInfoMap(routeReachabilityStatusInfo.vrf, ipType)
+	if routeInfoMap == nil {
+		return
+	}
+	routeInfoMaps := []*patriciaDB.Trie{routeInfoMap}
+	//routes leaked from this VRF resolve their next hops in it
+	for _, dstVrf := range getRouteLeakDstVrfs(routeReachabilityStatusInfo.vrf) {
+		if leakedRouteInfoMap := r.routeInfoMap(dstVrf, ipType); leakedRouteInfoMap != nil {
+			routeInfoMaps = append(routeInfoMaps, leakedRouteInfoMap)
+		}
+	}
+	for _, routeInfoMap := range routeInfoMaps {
+		if ipType == defs.IPv4 {
+			routeInfoMap.VisitAndUpdate(r.UpdateV4RouteReachabilityStatus, routeReachabilityStatusInfo)
+		} else {
+			routeInfoMap.VisitAndUpdate(r.UpdateV6RouteReachabilityStatus, routeReachabilityStatusInfo)
+		}
 	}
 }
 
 /*
    Update Connected route info
 */
-func updateConnectedRoutes(destNetIPAddr string, networkMaskAddr string, nextHopIP string, nextHopIfIndex ribd.Int, op int, sliceIdx ribd.Int) {
+func (r *RIB) updateConnectedRoutes(vrf string, destNetIPAddr string, networkMaskAddr string, nextHopIP string, nextHopIfIndex ribd.Int, op int, sliceIdx ribd.Int) {
 	var temproute ribdInt.Routes
 	route := &temproute
 	//logger.Debug("number of connectd routes = ", len(ConnectedRoutes), "current op is to ", op, " ipAddr:mask = ", destNetIPAddr, ":", networkMaskAddr)
-	if len(ConnectedRoutes) == 0 {
+	if len(r.connectedRoutes) == 0 {
 		if op == del {
 			//logger.Debug("Cannot delete a non-existent connected route")
 			return
 		}
-		ConnectedRoutes = make([]*ribdInt.Routes, 1)
+		r.connectedRoutes = make([]*ribdInt.Routes, 1)
 		route.Ipaddr = destNetIPAddr
 		route.Mask = networkMaskAddr
 		route.NextHopIp = nextHopIP
 		route.IfIndex = ribdInt.Int(nextHopIfIndex)
 		route.IsValid = true
 		route.SliceIdx = ribdInt.Int(sliceIdx)
-		ConnectedRoutes[0] = route
+		route.Vrf = vrf
+		r.connectedRoutes[0] = route
 		return
 	}
-	for i := 0; i < len(ConnectedRoutes); i++ {
+	for i := 0; i < len(r.connectedRoutes); i++ {
 		//		if(!strings.EqualFold(ConnectedRoutes[i].Ipaddr,destNetIPAddr) && !strings.EqualFold(ConnectedRoutes[i].Mask,networkMaskAddr)){
-		if ConnectedRoutes[i].Ipaddr == destNetIPAddr && ConnectedRoutes[i].Mask == networkMaskAddr {
+		if r.connectedRoutes[i].Ipaddr == destNetIPAddr && r.connectedRoutes[i].Mask == networkMaskAddr && r.connectedRoutes[i].Vrf == vrf {
 			if op == del {
-				if len(ConnectedRoutes) <= i+1 {
-					ConnectedRoutes = ConnectedRoutes[:i]
+				if len(r.connectedRoutes) <= i+1 {
+					r.connectedRoutes = r.connectedRoutes[:i]
 				} else {
-					ConnectedRoutes = append(ConnectedRoutes[:i], ConnectedRoutes[i+1:]...)
+					r.connectedRoutes = append(r.connectedRoutes[:i], r.connectedRoutes[i+1:]...)
 				}
 			} else if op == invalidate { //op is invalidate when a link on which the connectedroutes is configured goes down
-				ConnectedRoutes[i].IsValid = false
+				r.connectedRoutes[i].IsValid = false
 			}
 			return
 		}
@@ -194,7 +213,8 @@ func updateConnectedRoutes(destNetIPAddr string, networkMaskAddr string, nextHop
 	route.IfIndex = ribdInt.Int(nextHopIfIndex)
 	route.IsValid = true
 	route.SliceIdx = ribdInt.Int(sliceIdx)
-	ConnectedRoutes = append(ConnectedRoutes, route)
+	route.Vrf = vrf
+	r.connectedRoutes = append(r.connectedRoutes, route)
 }
 
 /*
@@ -274,11 +294,7 @@ func CreateV6ConnectedRoutes(count int, iPv6IntfStateList []*objects.IPv6IntfSta
 func (m RIBDServer) GetRouteDistanceState(protocol string) (*ribd.RouteDistanceState, error) {
 	//logger.Debug("Get state for RouteDistanceState")
 	state := ribd.NewRouteDistanceState()
-	if ProtocolAdminDistanceMapDB == nil {
-		//logger.Debug("ProtocolAdminDistanceMapDB not initialized")
-		return state, errors.New("ProtocolAdminDistanceMapDB not initialized")
-	}
-	val, ok := ProtocolAdminDistanceMapDB[protocol]
+	val, ok := getVrfRIB(DefaultVrf).adminDistanceMap[protocol]
 	if !ok {
 		//logger.Err("Admin Distance for protocol ", protocol, " not set")
 		return state, errors.New(fmt.Sprintln("Admin Distance for protocol ", protocol, " not set"))
@@ -299,14 +315,10 @@ func (m RIBDServer) GetBulkRouteDistanceState(fromIndex ribd.Int, rcount ribd.In
 	i = 0
 	routeDistanceStates = &returnGetInfo
 	more := true
-	BuildProtocolAdminDistanceSlice(false)
-	if ProtocolAdminDistanceSlice == nil {
-		//logger.Debug("ProtocolAdminDistanceSlice not initialized")
-		return routeDistanceStates, err
-	}
+	adminDistanceSlice := getVrfRIB(DefaultVrf).getAdminDistanceSlice()
 	for ; ; i++ {
 		//logger.Debug(fmt.Sprintf("Fetching record for index %d\n", i+fromIndex))
-		if i+fromIndex >= ribd.Int(len(ProtocolAdminDistanceSlice)) {
+		if i+fromIndex >= ribd.Int(len(adminDistanceSlice)) {
 			//logger.Debug("All the events fetched")
 			more = false
 			break
@@ -317,8 +329,8 @@ func (m RIBDServer) GetBulkRouteDistanceState(fromIndex ribd.Int, rcount ribd.In
 		}
 		//logger.Debug(fmt.Sprintf("Fetching event record for index ", i+fromIndex))
 		nextNode = &tempNode[validCount]
-		nextNode.Protocol = ProtocolAdminDistanceSlice[i+fromIndex].Protocol
-		nextNode.Distance = ProtocolAdminDistanceSlice[i+fromIndex].Distance
+		nextNode.Protocol = adminDistanceSlice[i+fromIndex].Protocol
+		nextNode.Distance = adminDistanceSlice[i+fromIndex].Distance
 		toIndex = ribd.Int(i + fromIndex)
 		if len(returnNodes) == 0 {
 			returnNodes = make([]*ribd.RouteDistanceState, 0)
@@ -334,9 +346,13 @@ func (m RIBDServer) GetBulkRouteDistanceState(fromIndex ribd.Int, rcount ribd.In
 	routeDistanceStates.Count = validCount
 	return routeDistanceStates, err
 }
-func (m RIBDServer) GetPerProtocolRouteCountList() (retList []*ribd.PerProtocolRouteCount) {
+func (m RIBDServer) GetPerProtocolRouteCountList(vrf string) (retList []*ribd.PerProtocolRouteCount) {
 	retList = make([]*ribd.PerProtocolRouteCount, 0)
-	for k, v := range ProtocolRouteMap {
+	rib := getVrfRIB(vrf)
+	if rib == nil {
+		return retList
+	}
+	for k, v := range rib.protocolRouteMap {
 		retList = append(retList, &ribd.PerProtocolRouteCount{
 			Protocol:   k,
 			RouteCount: int32(v.totalcount.totalcount),
@@ -359,7 +375,7 @@ func (m RIBDServer) GetBulkRoutesForProtocol(srcProtocol string, fromIndex ribdI
 	i = 0
 	routes = &returnRouteGetInfo
 	moreRoutes := true
-	redistributeRouteMap := RedistributeRouteMap[srcProtocol]
+	redistributeRouteMap := m.RIB.redistributeRouteMap[srcProtocol]
 	if redistributeRouteMap == nil {
 		//logger.Debug("no routes to be advertised for this protocol ", srcProtocol)
 		return routes, err
@@ -394,15 +410,27 @@ func (m RIBDServer) GetBulkRoutesForProtocol(srcProtocol string, fromIndex ribdI
 	This function adds and removes ipAddr from the TrachReachabilityMap based on the op value
 */
 func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op string) error {
-	logger.Info("TrackReachabilityStatus for ipAddr: ", ipAddr, " by protocol ", protocol, " op = ", op)
+	return m.TrackVrfReachabilityStatus(DefaultVrf, ipAddr, protocol, op)
+}
+
+/*
+   Tracks the reachability status of ipAddr in the RIB of the vrf
+*/
+func (m RIBDServer) TrackVrfReachabilityStatus(vrf string, ipAddr string, protocol string, op string) error {
+	logger.Info("TrackReachabilityStatus for ipAddr: ", ipAddr, " vrf ", vrf, " by protocol ", protocol, " op = ", op)
 	if op != "add" && op != "del" {
 		logger.Err("Invalid operation ", op)
 		return errors.New("Invalid operation")
 	}
+	rib := getVrfRIB(vrf)
+	if rib == nil {
+		logger.Err("VRF ", vrf, " not found")
+		return errors.New(fmt.Sprintln("VRF ", vrf, " not found"))
+	}
 	/*
 	   Check if this ipAddr is being tracked.
 	*/
-	protocolList, ok := TrackReachabilityMap[ipAddr]
+	protocolList, ok := rib.trackReachabilityMap[ipAddr]
 	if !ok {
 		if op == "del" {
 			logger.Err("ipAddr ", ipAddr, " not being tracked currently")
@@ -419,7 +447,7 @@ func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op s
 	index = findElement(protocolList, protocol)
 	if index != -1 {
 		if op == "del" {
-			protocolList = append(protocolList[:index], protocolList[index:]...)
+			protocolList = append(protocolList[:index], protocolList[index+1:]...)
 		} else if op == "add" {
 			logger.Debug(protocol, " already tracking ip ", ipAddr)
 			return nil
@@ -435,7 +463,7 @@ func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op s
 	/*
 	   Update the TrackReachabilityMap for this ip
 	*/
-	TrackReachabilityMap[ipAddr] = protocolList
+	rib.trackReachabilityMap[ipAddr] = protocolList
 	return nil
 }
 func (m RIBDServer) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count ribd.Int) (stats *ribd.RouteStatsPerProtocolStateGetInfo, err error) {
@@ -443,7 +471,7 @@ func (m RIBDServer) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count
 	stats = &returnInfo
 	count = 0
 	var tempNode []*ribd.RouteStatsPerProtocolState = make([]*ribd.RouteStatsPerProtocolState, 0)
-	for protocol, _ := range ProtocolRouteMap {
+	for protocol, _ := range getVrfRIB(DefaultVrf).protocolRouteMap {
 		routes := Getv4RoutesPerProtocol(protocol)
 		v6routes := Getv6RoutesPerProtocol(protocol)
 		/*		for destNet, _ := range routemapInfo.routeMap {
@@ -478,7 +506,7 @@ func (m RIBDServer) GetBulkRouteStatsPerInterfaceState(fromIndex ribd.Int, count
 	stats = &returnInfo
 	count = 0
 	var tempNode []*ribd.RouteStatsPerInterfaceState = make([]*ribd.RouteStatsPerInterfaceState, 0)
-	for intfref, _ := range InterfaceRouteMap {
+	for intfref, _ := range RouteServiceHandler.RIB.interfaceRouteMap {
 		routes := Getv4RoutesPerInterface(intfref)
 		v6routes := Getv6RoutesPerInterface(intfref)
 		tempNode = append(tempNode, &ribd.RouteStatsPerInterfaceState{
@@ -515,12 +543,9 @@ func (m RIBDServer) GetBulkRIBEventState(fromIndex ribd.Int, rcount ribd.Int) (e
 	i = 0
 	events = &returnGetInfo
 	more := true
-	if localRouteEventsDB == nil {
-		//logger.Debug("localRouteEventsDB not initialized")
-		return events, err
-	}
+	routeEvents := m.RIB.RouteEvents()
 	for ; ; i++ {
-		if i+fromIndex >= ribd.Int(len(localRouteEventsDB)) {
+		if i+fromIndex >= ribd.Int(len(routeEvents)) {
 			//logger.Debug("All the events fetched")
 			more = false
 			break
@@ -531,8 +556,8 @@ func (m RIBDServer) GetBulkRIBEventState(fromIndex ribd.Int, rcount ribd.Int) (e
 		}
 		//logger.Debug("Fetching event record for index ", i+fromIndex)
 		nextNode = &tempNode[validCount]
-		nextNode.TimeStamp = localRouteEventsDB[i+fromIndex].timeStamp
-		nextNode.EventInfo = localRouteEventsDB[i+fromIndex].eventInfo
+		nextNode.TimeStamp = routeEvents[i+fromIndex].timeStamp
+		nextNode.EventInfo = routeEvents[i+fromIndex].eventInfo
 		toIndex = ribd.Int(i + fromIndex)
 		if len(returnNodes) == 0 {
 			returnNodes = make([]*ribd.RIBEventState, 0)
@@ -553,11 +578,22 @@ func (m RIBDServer) GetBulkRIBEventState(fromIndex ribd.Int, rcount ribd.Int) (e
 */
 
 func (m RIBDServer) GetRouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.GetVrfRouteReachabilityInfo(DefaultVrf, destNet, ifIndex)
+}
+
+/*
+   Returns the longest prefix match route to reach destNet in the RIB of the vrf
+*/
+func (m RIBDServer) GetVrfRouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.RIB.GetVrfRouteReachabilityInfo(vrf, destNet, ifIndex)
+}
+
+func (r *RIB) GetVrfRouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
 	//logger.Debug("GetRouteReachabilityInfo of ", destNet)
-	nextHopIntf, err = RouteServiceHandler.GetV4RouteReachabilityInfo(destNet, ifIndex)
+	nextHopIntf, err = r.getV4RouteReachabilityInfo(vrf, destNet, ifIndex)
 	if err != nil {
 		//logger.Info("next hop ", destNet, " not reachable via ipv4 network")
-		nextHopIntf, err = RouteServiceHandler.GetV6RouteReachabilityInfo(destNet, ifIndex)
+		nextHopIntf, err = r.getV6RouteReachabilityInfo(vrf, destNet, ifIndex)
 		if err != nil {
 			logger.Err("next hop ", destNet, " not reachable")
 		}
@@ -713,6 +749,13 @@ func UpdateRouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the nod
    Resolve and determine the immediate next hop info for a given ipAddr
 */
 func ResolveNextHop(ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNextHopIntf ribdInt.NextHopInfo, err error) {
+	return RouteServiceHandler.RIB.ResolveVrfNextHop(DefaultVrf, ipAddr)
+}
+
+/*
+   Resolve the immediate next hop info for ipAddr in the RIB of the vrf
+*/
+func (r *RIB) ResolveVrfNextHop(vrf string, ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNextHopIntf ribdInt.NextHopInfo, err error) {
 	func_mesg := "ResolveNextHop() for " + ipAddr
 	logger.Debug("ResolveNextHop for ", ipAddr)
 	var prev_intf ribdInt.NextHopInfo
@@ -725,7 +768,7 @@ func ResolveNextHop(ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNex
 	}
 	ip := ipAddr
 	for {
-		intf, err := RouteServiceHandler.GetRouteReachabilityInfo(ip, -1)
+		intf, err := r.GetVrfRouteReachabilityInfo(vrf, ip, -1)
 		if err != nil {
 			logger.Err(func_mesg, "next hop ", ip, " not reachable")
 			return nextHopIntf, nextHopIntf, err
@@ -754,196 +797,6 @@ func ResolveNextHop(ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNex
 	return nextHopIntf, nextHopIntf, err
 }
 
-/*
-   Function which determines the next best route
-*/
-func SelectNextBestRoute(routeInfoRecordList RouteInfoRecordList, protocol string) (newSelectedProtocol string) {
-	logger.Debug("SelectBestRoute, the current selected route protocol is ", routeInfoRecordList.selectedRouteProtocol)
-	tempSelectedProtocol := "INVALID"
-	//logger.Debug("len(protocolAdminDistanceSlice):", len(ProtocolAdminDistanceSlice))
-	/*
-	   Build protocol admin distance slice based on the current admin distance values
-	*/
-	BuildProtocolAdminDistanceSlice(false)
-	for i := 0; i < len(ProtocolAdminDistanceSlice); i++ {
-		tempSelectedProtocol = ProtocolAdminDistanceSlice[i].Protocol
-		if tempSelectedProtocol == protocol {
-			continue
-		}
-		//logger.Debug("Best preferred protocol ", tempSelectedProtocol)
-		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]
-		if routeInfoList == nil || len(routeInfoList) == 0 {
-			logger.Debug("No routes are configured with this protocol ", tempSelectedProtocol, " for this route")
-			tempSelectedProtocol = "INVALID"
-			continue
-		}
-		if tempSelectedProtocol != "INVALID" {
-			logger.Debug("Found a valid protocol ", tempSelectedProtocol)
-			break
-		}
-	}
-	return tempSelectedProtocol
-}
-
-/*
-   Function which determines the best route when a route is deleted or updated
-*/
-func SelectBestRoute(routeInfoRecordList RouteInfoRecordList) (addRouteList []RouteOpInfoRecord, deleteRouteList []RouteOpInfoRecord, newSelectedProtocol string) {
-	logger.Info("SelectBestRoute, the current selected route protocol is ", routeInfoRecordList.selectedRouteProtocol)
-	tempSelectedProtocol := "INVALID"
-	newSelectedProtocol = "INVALID"
-	deleteRouteList = make([]RouteOpInfoRecord, 0)
-	addRouteList = make([]RouteOpInfoRecord, 0)
-	var routeOpInfoRecord RouteOpInfoRecord
-	/*
-	   Build protocol admin distance slice based on the current admin distance values
-	*/
-	BuildProtocolAdminDistanceSlice(false)
-	logger.Info("len(protocolAdminDistanceSlice):", len(ProtocolAdminDistanceSlice))
-	/*
-	   go over the protocol admin distance slice, select the protocols from best to worst
-	   and check if there are any routes configured with that protocol type
-	   If yes, then verify if that route is eligible to be selected.
-	   If yes, then check if it is the same protocol as the incoming protocol
-	   If not, then delete all the routes configured with the old selected protocol in FIB
-	   and configure the routes of the new selected type
-	*/
-	for i := 0; i < len(ProtocolAdminDistanceSlice); i++ {
-		tempSelectedProtocol = ProtocolAdminDistanceSlice[i].Protocol
-		logger.Info("Best preferred protocol ", tempSelectedProtocol, " at i= ", i)
-		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]
-		if routeInfoList == nil || len(routeInfoList) == 0 {
-			logger.Debug("No routes are configured with this protocol ", tempSelectedProtocol, " for this route")
-			tempSelectedProtocol = "INVALID"
-			continue
-		}
-		tempSelectedProtocol = "INVALID"
-		for j := 0; j < len(routeInfoList); j++ {
-			routeInfoRecord := routeInfoList[j]
-			policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid}
-			entity, _ := buildPolicyEntityFromRoute(policyRoute, RouteParams{})
-			actionList := PolicyEngineDB.PolicyEngineCheckActionsForEntity(entity, policyCommonDefs.PolicyConditionTypeProtocolMatch)
-			if !PolicyEngineDB.ActionNameListHasAction(actionList, policyCommonDefs.PolicyActionTypeRouteDisposition, "Reject") {
-				logger.Info("atleast one of the routes of this protocol will not be rejected by the policy engine -protocol at index i:", i)
-				tempSelectedProtocol = ProtocolAdminDistanceSlice[i].Protocol
-				break
-			}
-		}
-		if tempSelectedProtocol != "INVALID" {
-			logger.Info("Found a valid protocol ", tempSelectedProtocol)
-			break
-		}
-	}
-	if tempSelectedProtocol == routeInfoRecordList.selectedRouteProtocol {
-		logger.Debug("The current protocol remains the new selected protocol")
-		return addRouteList, deleteRouteList, newSelectedProtocol
-	}
-	if routeInfoRecordList.selectedRouteProtocol != "INVALID" {
-		logger.Debug("Valid protocol currently selected as ", routeInfoRecordList.selectedRouteProtocol)
-		for j := 0; j < len(routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]); j++ {
-			routeOpInfoRecord.opType = FIBOnly
-			routeOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][j]
-			deleteRouteList = append(deleteRouteList, routeOpInfoRecord)
-		}
-	}
-	if tempSelectedProtocol != "INVALID" {
-		logger.Debug("New Valid protocol selected as ", tempSelectedProtocol)
-		for j := 0; j < len(routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol]); j++ {
-			routeOpInfoRecord.opType = FIBOnly
-			routeOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[tempSelectedProtocol][j]
-			logger.Debug("Adding route with nexthop ip ", routeOpInfoRecord.routeInfoRecord.nextHopIp.String(), "/", routeOpInfoRecord.routeInfoRecord.nextHopIfIndex)
-			addRouteList = append(addRouteList, routeOpInfoRecord)
-		}
-		newSelectedProtocol = tempSelectedProtocol
-	}
-	return addRouteList, deleteRouteList, newSelectedProtocol
-}
-
-//this function is called when a route is being added after it has cleared import policies
-func selectBestRouteOnAdd(routeInfoRecordList RouteInfoRecordList, routeInfoRecord RouteInfoRecord) (addRouteList []RouteOpInfoRecord, deleteRouteList []RouteOpInfoRecord, newSelectedProtocol string) {
-	logger.Debug("selectBestRouteOnAdd current selected protocol = ", routeInfoRecordList.selectedRouteProtocol)
-	deleteRouteList = make([]RouteOpInfoRecord, 0)
-	addRouteList = make([]RouteOpInfoRecord, 0)
-	newSelectedProtocol = routeInfoRecordList.selectedRouteProtocol
-	newRouteProtocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
-	add := false
-	del := false
-	var addrouteOpInfoRecord RouteOpInfoRecord
-	var delrouteOpInfoRecord RouteOpInfoRecord
-
-	if routeInfoRecordList.selectedRouteProtocol == "INVALID" {
-		/*
-		   Currently, no route has been selected
-		*/
-		if routeInfoRecord.protocol != PROTOCOL_NONE {
-			logger.Debug("Selecting the new route because the current selected route is invalid")
-			add = true
-			addrouteOpInfoRecord.opType = FIBAndRIB
-			newSelectedProtocol = newRouteProtocol
-		}
-	} else if ProtocolAdminDistanceMapDB[newRouteProtocol].configuredDistance > ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
-		/*
-		   If the configured admin distance is more than the incoming route, add the route in RIB
-		*/
-		add = true
-		addrouteOpInfoRecord.opType = RIBOnly
-	} else if ProtocolAdminDistanceMapDB[newRouteProtocol].configuredDistance < ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
-		logger.Debug(" Selecting the new route because the admin distance of the new routetype ", newRouteProtocol, ":", ProtocolAdminDistanceMapDB[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]].configuredDistance, "is better than the selected route protocol ", routeInfoRecordList.selectedRouteProtocol, "'s admin distance ", ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol])
-		del = true
-		add = true
-		addrouteOpInfoRecord.opType = FIBAndRIB
-		delrouteOpInfoRecord.opType = FIBOnly
-		newSelectedProtocol = newRouteProtocol
-	} else if ProtocolAdminDistanceMapDB[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]].configuredDistance == ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].configuredDistance {
-		logger.Debug("Same admin distance ")
-		if newRouteProtocol == routeInfoRecordList.selectedRouteProtocol {
-			logger.Debug("Same protocol as the selected route")
-			if routeInfoRecord.metric == routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric {
-				logger.Debug("Adding a same cost route as the current selected routes")
-				if !newNextHop(routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]) {
-					logger.Debug("Not a new next hop ip, so do nothing")
-				} else {
-					logger.Debug("This is a new route with a new next hop IP")
-					addrouteOpInfoRecord.opType = FIBAndRIB
-					add = true
-				}
-			} else if routeInfoRecord.metric < routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric {
-				logger.Debug("New metric ", routeInfoRecord.metric, " is lower than the current metric ", routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0].metric)
-				del = true
-				delrouteOpInfoRecord.opType = FIBAndRIB
-				add = true
-				addrouteOpInfoRecord.opType = FIBAndRIB
-			}
-		} else {
-			logger.Debug("Protocol ", newRouteProtocol, " has the same admin distance ", ProtocolAdminDistanceMapDB[newRouteProtocol].configuredDistance, " as the protocol", routeInfoRecordList.selectedRouteProtocol, "'s configured admin distance ", ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].configuredDistance)
-			if ProtocolAdminDistanceMapDB[newRouteProtocol].defaultDistance < ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].defaultDistance {
-				logger.Debug("Protocol ", newRouteProtocol, " has lower default admin distance ", ProtocolAdminDistanceMapDB[newRouteProtocol].defaultDistance, " than the protocol", routeInfoRecordList.selectedRouteProtocol, "'s default admin distance ", ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].defaultDistance)
-				del = true
-				delrouteOpInfoRecord.opType = FIBOnly
-				add = true
-				addrouteOpInfoRecord.opType = FIBAndRIB
-				newSelectedProtocol = newRouteProtocol
-			} else {
-				logger.Debug("Protocol ", newRouteProtocol, " has higher default admin distance ", ProtocolAdminDistanceMapDB[newRouteProtocol].configuredDistance, " than the protocol", routeInfoRecordList.selectedRouteProtocol, "'s default admin distance ", ProtocolAdminDistanceMapDB[routeInfoRecordList.selectedRouteProtocol].configuredDistance)
-				add = true
-				addrouteOpInfoRecord.opType = RIBOnly
-			}
-		}
-	}
-	logger.Debug("At the end of the route selection logic, add = ", add, " del = ", del)
-	if add == true {
-		addrouteOpInfoRecord.routeInfoRecord = routeInfoRecord
-		addRouteList = append(addRouteList, addrouteOpInfoRecord)
-	}
-	if del == true {
-		for i := 0; i < len(routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]); i++ {
-			delrouteOpInfoRecord.routeInfoRecord = routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][i]
-			deleteRouteList = append(deleteRouteList, delrouteOpInfoRecord)
-		}
-	}
-	return addRouteList, deleteRouteList, newSelectedProtocol
-}
-
 /*
     This function adds the route in RIB RouteMap after it has cleared the import policies
 	If the route being added is the selected route protocol and this function is called with export policy path, then
@@ -954,7 +807,7 @@ func selectBestRouteOnAdd(routeInfoRecordList RouteInfoRecordList, routeInfoReco
 	event/user                       import                        accept                                                        export
 	------------->ProcessRouteCreate--------->policyEngineFilter----------->createv4route------>selectV4Route------>addNewRoute--------->policyEngineFilter----------->export_actions(redistribute)
 */
-func addNewRoute(destNetPrefix patriciaDB.Prefix,
+func (r *RIB) addNewRoute(destNetPrefix patriciaDB.Prefix,
 	routeInfoRecord RouteInfoRecord,
 	routeInfoRecordList RouteInfoRecordList,
 	policyPath int) {
@@ -964,61 +817,11 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 	} else {
 		policyPathStr = "Import"
 	}
-	logger.Debug(" addNewRoute for nwAddr: ", routeInfoRecord.networkAddr, "protocol ", routeInfoRecord.protocol, " policy path: ", policyPathStr, " next hop ip: ", routeInfoRecord.nextHopIp.String(), "/", routeInfoRecord.nextHopIfIndex, "sliceIdx ", routeInfoRecord.sliceIdx, " len(destNetSlice):", len(destNetSlice))
-	if destNetSlice != nil && (len(destNetSlice) > int(routeInfoRecord.sliceIdx)) { //&& bytes.Equal(destNetSlice[routeInfoRecord.sliceIdx].prefix, destNet)) {
-		if bytes.Equal(destNetSlice[routeInfoRecord.sliceIdx].prefix, destNetPrefix) == false {
-			logger.Debug("Unexpected destination network prefix ", destNetSlice[routeInfoRecord.sliceIdx].prefix, " found at the slice Idx ", routeInfoRecord.sliceIdx, " expected prefix ", destNetPrefix)
-			return
-		}
-		//There is already an entry in the destNetSlice at the route index and was invalidated earlier because  of a link down of the nexthop intf of the route or if the route was deleted
-		//In this case since the old route was invalid, there is nothing to delete
-		//logger.Debug("sliceIdx ", routeInfoRecord.sliceIdx)
-		destNetSlice[routeInfoRecord.sliceIdx].isValid = true
-	} else {
-		logger.Debug("This is a new route for selectedProtocolType being added, create destNetSlice entry at index ", len(destNetSlice))
-		routeInfoRecord.sliceIdx = len(destNetSlice)
-		localDBRecord := localDB{prefix: destNetPrefix, isValid: true, nextHopIp: routeInfoRecord.nextHopIp.String()}
-		if destNetSlice == nil {
-			destNetSlice = make([]localDB, 0)
-		}
-		destNetSlice = append(destNetSlice, localDBRecord)
-	}
-	if routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] == nil {
-		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = make([]RouteInfoRecord, 0)
-	}
-	found, currRecord, idx := findRouteWithNextHop(routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]], routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
-	if !found { // newNextHopIP(routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]]) {
-		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = append(routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]], routeInfoRecord)
-	} else {
-		//already existing route needs to be updated
-		/*	found, currRecord, idx := findRouteWithNextHop(routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]], routeInfoRecord.nextHopIp.String())
-			if !found {
-				logger.Err("Unexpected error - did not find route with ip: ", routeInfoRecord.destNetIp.String(), " next hop: ", routeInfoRecord.nextHopIp.String())
-				return
-			}*/
-		//update the patriciaDB trie with the updated route info record list
-		t1 := time.Now()
-		currRecord.routeUpdatedTime = t1.String()
-		currRecord.resolvedNextHopIpIntf.IsReachable = true
-		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]][idx] = currRecord
-	}
-	/*
-	   Update route info in RouteMap
-	*/
-	RouteInfoMapSet(routeInfoRecord.ipType, patriciaDB.Prefix(destNetPrefix), routeInfoRecordList)
-	if routeInfoRecord.ipType == defs.IPv4 {
-		v4rtCount++
-		v4routeCreatedTimeMap[v4rtCount] = routeInfoRecord.routeCreatedTime
-	} else if routeInfoRecord.ipType == defs.IPv6 {
-		v6rtCount++
-		v6routeCreatedTimeMap[v6rtCount] = routeInfoRecord.routeCreatedTime
-	}
-	ecmp := false
-	if !found {
-		ecmp = true
+	logger.Debug(" addNewRoute for nwAddr: ", routeInfoRecord.networkAddr, "protocol ", routeInfoRecord.protocol, " policy path: ", policyPathStr, " next hop ip: ", routeInfoRecord.nextHopIp.String(), "/", routeInfoRecord.nextHopIfIndex, "sliceIdx ", routeInfoRecord.sliceIdx, " len(destNetSlice):", len(r.destNetSlice))
+	routeInfoRecord, _, ok := r.addRoute(destNetPrefix, routeInfoRecord, routeInfoRecordList)
+	if !ok {
+		return
 	}
-	UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
-	UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
 
 	if ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)] != routeInfoRecordList.selectedRouteProtocol {
 		logger.Debug("This is not a selected route, so nothing more to do here")
@@ -1032,7 +835,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 		Op:               defs.Add,
 	}
 
-	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid}
+	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid, Vrf: routeInfoRecord.vrf}
 	var params RouteParams
 	params = BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
 	if policyPath == policyCommonDefs.PolicyPath_Export {
@@ -1041,13 +844,18 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 		/*
 		   Find resolved next hop
 		*/
-		nhIntf, resolvedNextHopIntf, res_err := ResolveNextHop(routeInfoRecord.nextHopIp.String())
+		nhIntf, resolvedNextHopIntf, res_err := r.ResolveVrfNextHop(getRouteResolveVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String())
 		//logger.Debug("nhIntf:ipAddr:mask = ", nhIntf.Ipaddr, ":", nhIntf.Mask, " nexthop ip :", routeInfoRecord.nextHopIp.String())
 		routeInfoRecord.resolvedNextHopIpIntf = resolvedNextHopIntf
+		if res_err == nil {
+			if nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask); err == nil {
+				routeInfoRecord.nextHopPrefix = string(nhPrefix)
+			}
+		}
 		//call asicd to add
 		//	if asicdclnt.IsConnected {
 		logger.Debug("New route selected, call asicd to install a new route - ip", routeInfoRecord.destNetIp.String(), " mask ", routeInfoRecord.networkMask.String(), " nextHopIP ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
-		RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Add}
+		queueFIBRoute(routeInfoRecord, defs.Add)
 		//	}
 		/*
 		   Call Arp to resolve the next hop if this is not a connected route
@@ -1057,7 +865,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 			/*
 			   Call arp resolve only if it has not yet been called for this next hop
 			*/
-			if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			if !arpResolveCalled(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 				//call arpd to resolve the ip
 				logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Add}
@@ -1065,19 +873,19 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 			/*
 			   Update next hop map for this next hop ip
 			*/
-			updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
+			updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
 		}
 		//update in the event log
 		eventInfo := "Installed " + ReverseRouteProtoTypeMapDB[int(policyRoute.Prototype)] + " route " + policyRoute.Ipaddr + ":" + policyRoute.Mask + " nextHopIp :" + routeInfoRecord.nextHopIp.String() + " in Hardware and RIB "
 		t1 := time.Now()
 		routeEventInfo := RouteEventInfo{timeStamp: t1.String(), eventInfo: eventInfo}
-		localRouteEventsDB = append(localRouteEventsDB, routeEventInfo)
+		r.AddRouteEvent(routeEventInfo)
 
 		//get the network address associated with the nexthop and update its refcount
 		if res_err == nil {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, add)
+				updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), string(nhPrefix)}, add)
 			}
 		}
 		if routeInfoRecord.resolvedNextHopIpIntf.IsReachable {
@@ -1087,29 +895,30 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
 			}
 			//check if there are routes depending on this network as next hop
-			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
-				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
-				RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-				RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
+			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{routeInfoRecord.vrf, string(destNetPrefix)}].refCount > 0 {
+				notifyNextHopGroups(routeInfoRecord.vrf, -1, destNetPrefix, true)
+				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
+				r.RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
+				r.RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
 			}
 		}
 	}
 	params.deleteType = Invalid
-	PolicyEngineFilter(policyRoute, policyPath, params)
+	r.PolicyEngineFilter(policyRoute, policyPath, params)
 }
-func addNewRouteList(destNetPrefix patriciaDB.Prefix,
+func (r *RIB) addNewRouteList(destNetPrefix patriciaDB.Prefix,
 	addRouteList []RouteOpInfoRecord,
 	routeInfoRecordList RouteInfoRecordList,
 	policyPath int) {
 	//logger.Debug("addNewRoutes")
 	for i := 0; i < len(addRouteList); i++ {
 		//logger.Debug("Calling addNewRoute for next hop ip: ", addRouteList[i].routeInfoRecord.nextHopIp.String(), "/", addRouteList[i].routeInfoRecord.nextHopIfIndex)
-		addNewRoute(destNetPrefix, addRouteList[i].routeInfoRecord, routeInfoRecordList, policyPath)
+		r.addNewRoute(destNetPrefix, addRouteList[i].routeInfoRecord, routeInfoRecordList, policyPath)
 	}
 }
 
 //note: selectedrouteProtocol should not have been set to INVALID by either of the selects when this function is called
-func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route being deleted
+func (r *RIB) deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route being deleted
 	routeInfoRecord RouteInfoRecord, //route info record of the route being deleted
 	routeInfoRecordList RouteInfoRecordList,
 	policyPath int, //Import/Export
@@ -1117,83 +926,59 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 ) {
 
 	logger.Debug(" deleteRoute")
-	deleteNode := true
 	nodeDeleted := false
-	if destNetSlice == nil || int(routeInfoRecord.sliceIdx) >= len(destNetSlice) {
+	if int(routeInfoRecord.sliceIdx) >= len(r.destNetSlice) {
 		//logger.Debug("Destination slice not found at the expected slice index ", routeInfoRecord.sliceIdx)
 		return
 	}
-	destNetSlice[routeInfoRecord.sliceIdx].isValid = false //invalidate this entry in the local db
+	r.destNetSlice[routeInfoRecord.sliceIdx].isValid = false //invalidate this entry in the local db
 	//the following operations delete this node from the RIB DB
 	if delType == FIBAndRIB {
 		logger.Debug("Del type = FIBAndRIB, so delete the entry in RIB DB")
-		routeInfoList := routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]]
-		found, _, index := findRouteWithNextHop(routeInfoList, routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
-		if !found || index == -1 {
+		found, deleteNode := r.removeRoute(routeInfoRecord, routeInfoRecordList)
+		if !found {
 			logger.Debug("Invalid nextHopIP")
 			return
 		}
-		//logger.Debug("Found the route at index ", index)
-		if len(routeInfoList) <= index+1 {
-			routeInfoList = routeInfoList[:index]
-		} else {
-			routeInfoList = append(routeInfoList[:index], routeInfoList[index+1:]...)
-		}
-		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = routeInfoList
-		if len(routeInfoList) == 0 {
-			/*
-			   If all the routes from this protocol have been deleted
-			*/
-			logger.Debug("All routes for this destination from protocol ", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], " deleted")
-			routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = nil
-			deleteNode = true
-			/*
-			   Go over the routeInfoProtocolMap for this network to see if routes from other protocol are configured
-			*/
-			for _, v := range routeInfoRecordList.routeInfoProtocolMap {
-				if v != nil && len(v) != 0 {
-					//	logger.Debug("There are still other protocol ", k, " routes for this destination")
-					deleteNode = false
-				}
+		r.untrackBfdNextHop(routeInfoRecord)
+		if deleteNode {
+			//logger.Debug("Route deleted for this destination, traverse dependent routes to update routeReachability status")
+			//check if there are routes dependent on this network
+			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{routeInfoRecord.vrf, string(destNetPrefix)}].refCount > 0 {
+				notifyNextHopGroups(routeInfoRecord.vrf, -1, destNetPrefix, false)
+				nextHopIntf := ribdInt.NextHopInfo{}
+				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
+				r.RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
+				r.RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
 			}
-			if deleteNode == true {
-				//logger.Debug("Route deleted for this destination, traverse dependent routes to update routeReachability status")
-				//check if there are routes dependent on this network
-				if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
-					nextHopIntf := ribdInt.NextHopInfo{}
-					routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
-					RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-					RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
-				}
-				//get the network address associated with the nexthop and update its refcount
-				nhIntf, err := RouteServiceHandler.GetRouteReachabilityInfo(routeInfoRecord.nextHopIp.String(), -1)
+			//get the network address associated with the nexthop and update its refcount
+			nhIntf, err := r.GetVrfRouteReachabilityInfo(getRouteResolveVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String(), -1)
+			if err == nil {
+				nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 				if err == nil {
-					nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
-					if err == nil {
-						updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, del)
-					}
+					updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), string(nhPrefix)}, del)
 				}
-				/*
-				   delete the route in state db and routeInfoMap
-				*/
-				RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
-					OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
-					Op:               defs.Del,
-				}
-				RouteInfoMapDelete(routeInfoRecord.ipType, destNetPrefix)
-				UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), false)
-				UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), false)
-				nodeDeleted = true
 			}
+			/*
+			   delete the route in state db and routeInfoMap
+			*/
+			RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
+				OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
+				Op:               defs.Del,
+			}
+			r.RouteInfoMapDelete(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix)
+			r.updateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), false)
+			r.updateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), false)
+			nodeDeleted = true
 		}
 		if !nodeDeleted {
 			RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 				OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
 				Op:               defs.Add,
 			}
-			RouteInfoMapSet(routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
-			UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), true)
-			UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), true)
+			r.RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
+			r.updateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), true)
+			r.updateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), true)
 		}
 	} else if delType == FIBOnly {
 		/*
@@ -1211,19 +996,20 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = routeInfoList
 		logger.Debug("Route deleted for this destination, traverse dependent routes to update routeReachability status")
 		//check if there are routes dependent on this network
-		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
-			logger.Debug("NextHopInfoMap for ", destNetPrefix, " RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}]")
+		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{routeInfoRecord.vrf, string(destNetPrefix)}].refCount > 0 {
+			logger.Debug("NextHopInfoMap for ", destNetPrefix, " RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{routeInfoRecord.vrf, string(destNetPrefix)}]")
+			notifyNextHopGroups(routeInfoRecord.vrf, -1, destNetPrefix, false)
 			nextHopIntf := ribdInt.NextHopInfo{}
-			routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
-			RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-			RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
+			routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
+			r.RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
+			r.RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
 		}
 		//get the network address associated with the nexthop and update its refcount
-		nhIntf, err := RouteServiceHandler.GetRouteReachabilityInfo(routeInfoRecord.nextHopIp.String(), -1)
+		nhIntf, err := r.GetVrfRouteReachabilityInfo(getRouteResolveVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String(), -1)
 		if err == nil {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, del)
+				updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), string(nhPrefix)}, del)
 			}
 		}
 		logger.Debug("Adding to DBRouteCh from deletev4Route")
@@ -1231,13 +1017,13 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 			OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
 			Op:               defs.Add,
 		}
-		RouteInfoMapSet(routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
+		r.RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
 	}
 	if routeInfoRecordList.selectedRouteProtocol != ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)] {
 		logger.Debug("This is not the selected protocol, nothing more to do here")
 		return
 	}
-	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid}
+	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid, Vrf: routeInfoRecord.vrf}
 	if policyPath != policyCommonDefs.PolicyPath_Export {
 		//logger.Debug("Expected export path for delete op")
 		return
@@ -1245,14 +1031,14 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 	//delete in asicd
 	//if asicdclnt.IsConnected {
 	logger.Debug("This is the selected protocol:Calling asicd to delete this route- ip", routeInfoRecord.destNetIp.String(), " mask ", routeInfoRecord.networkMask.String(), " nextHopIP ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
-	RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Del}
+	queueFIBRoute(routeInfoRecord, defs.Del)
 	//}
 	//if arpdclnt.IsConnected &&
 	if routeInfoRecord.protocol != defs.CONNECTED {
-		if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+		if !arpResolveCalled(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 			logger.Debug("ARP resolve was never called for ", routeInfoRecord.nextHopIp.String())
 		} else {
-			refCount := updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, del)
+			refCount := updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, del)
 			if refCount == 0 {
 				logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Del}
@@ -1267,21 +1053,21 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 	eventInfo := delStr + ":" + ReverseRouteProtoTypeMapDB[int(policyRoute.Prototype)] + " " + policyRoute.Ipaddr + ":" + policyRoute.Mask + " nextHopIp :" + routeInfoRecord.nextHopIp.String()
 	t1 := time.Now()
 	routeEventInfo := RouteEventInfo{timeStamp: t1.String(), eventInfo: eventInfo}
-	localRouteEventsDB = append(localRouteEventsDB, routeEventInfo)
+	r.AddRouteEvent(routeEventInfo)
 
 	var params RouteParams
 	params = BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
 	params.createType = Invalid
 	policyRoute.PolicyList = routeInfoRecordList.policyList
-	PolicyEngineFilter(policyRoute, policyPath, params)
+	r.PolicyEngineFilter(policyRoute, policyPath, params)
 }
-func deleteRoutes(destNetPrefix patriciaDB.Prefix,
+func (r *RIB) deleteRoutes(destNetPrefix patriciaDB.Prefix,
 	deleteRouteList []RouteOpInfoRecord,
 	routeInfoRecordList RouteInfoRecordList,
 	policyPath int) {
 	//logger.Debug("deleteRoutes")
 	for i := 0; i < len(deleteRouteList); i++ {
-		deleteRoute(destNetPrefix, deleteRouteList[i].routeInfoRecord, routeInfoRecordList, policyPath, deleteRouteList[i].opType)
+		r.deleteRoute(destNetPrefix, deleteRouteList[i].routeInfoRecord, routeInfoRecordList, policyPath, deleteRouteList[i].opType)
 	}
 }
 
@@ -1289,7 +1075,7 @@ func deleteRoutes(destNetPrefix patriciaDB.Prefix,
     This function is called whenever a route is added or deleted. In either of the cases,
 	this function selects the best route to be programmed in FIB.
 */
-func SelectRoute(destNetPrefix patriciaDB.Prefix,
+func (r *RIB) SelectRoute(destNetPrefix patriciaDB.Prefix,
 	routeInfoRecordList RouteInfoRecordList, //the current list of routes for this prefix
 	routeInfoRecord RouteInfoRecord, //the route to be added or deleted or invalidated or validated
 	op ribd.Int, //add or delete of the route
@@ -1298,37 +1084,37 @@ func SelectRoute(destNetPrefix patriciaDB.Prefix,
 	logger.Debug("SelectRoute: Selecting the best Route for destNetPrefix ", destNetPrefix)
 	if op == add {
 		//logger.Debug("Op is to add the new route")
-		_, deleteRouteList, newSelectedProtocol := selectBestRouteOnAdd(routeInfoRecordList, routeInfoRecord)
+		_, deleteRouteList, newSelectedProtocol := r.selectBestRouteOnAdd(routeInfoRecordList, routeInfoRecord)
 		/*
 		   If any of the routes need to be deleted as part of adding the new route, call delete of those routes
 		*/
 		if len(deleteRouteList) > 0 {
-			deleteRoutes(destNetPrefix, deleteRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
+			r.deleteRoutes(destNetPrefix, deleteRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
 		}
 		routeInfoRecordList.selectedRouteProtocol = newSelectedProtocol
-		addNewRoute(destNetPrefix, routeInfoRecord, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
+		r.addNewRoute(destNetPrefix, routeInfoRecord, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
 	} else if op == del {
 		logger.Debug("SelectRoute: Op is to delete new route")
-		deleteRoute(destNetPrefix, routeInfoRecord, routeInfoRecordList, policyCommonDefs.PolicyPath_Export, opType)
-		addRouteList, _, newSelectedProtocol := SelectBestRoute(routeInfoRecordList)
+		r.deleteRoute(destNetPrefix, routeInfoRecord, routeInfoRecordList, policyCommonDefs.PolicyPath_Export, opType)
+		addRouteList, _, newSelectedProtocol := r.SelectBestRoute(routeInfoRecordList)
 		routeInfoRecordList.selectedRouteProtocol = newSelectedProtocol
 		if len(addRouteList) > 0 {
-			addNewRouteList(destNetPrefix, addRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Import)
+			r.addNewRouteList(destNetPrefix, addRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Import)
 		}
 	}
 	return err
 }
-func updateBestRoute(destNetPrefix patriciaDB.Prefix, routeInfoRecordList RouteInfoRecordList) {
+func (r *RIB) updateBestRoute(destNetPrefix patriciaDB.Prefix, routeInfoRecordList RouteInfoRecordList) {
 	//logger.Debug("updateBestRoute for ip network ", destNetPrefix)
-	addRouteList, deleteRouteList, newSelectedProtocol := SelectBestRoute(routeInfoRecordList)
+	addRouteList, deleteRouteList, newSelectedProtocol := r.SelectBestRoute(routeInfoRecordList)
 	if len(deleteRouteList) > 0 {
 		//logger.Debug(len(deleteRouteList), " to be deleted")
-		deleteRoutes(destNetPrefix, deleteRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
+		r.deleteRoutes(destNetPrefix, deleteRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Export)
 	}
 	routeInfoRecordList.selectedRouteProtocol = newSelectedProtocol
 	if len(addRouteList) > 0 {
 		//logger.Debug("New ", len(addRouteList), " to be added")
-		addNewRouteList(destNetPrefix, addRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Import)
+		r.addNewRouteList(destNetPrefix, addRouteList, routeInfoRecordList, policyCommonDefs.PolicyPath_Import)
 	}
 }
 
@@ -1337,7 +1123,7 @@ func updateBestRoute(destNetPrefix patriciaDB.Prefix, routeInfoRecordList RouteI
  - a user/routing protocol installs a new route. In that case, addType will be RIBAndFIB
  - when a operationally down link comes up. In this case, the addType will be FIBOnly because on a link down, the route is still preserved in the RIB database and only deleted from FIB (Asic)
 **/
-func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
+func (r *RIB) createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 	/*func createRoute(ipType defs.IPType, destNetIp string,
 	  networkMask string,
 	  metric ribd.Int,
@@ -1360,6 +1146,10 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 	addType := routeInfo.createType
 	policyStateChange := defs.RoutePolicyStateChangetoValid
 	sliceIdx := routeInfo.sliceIdx
+	vrf := routeInfo.vrf
+	if vrf == "" {
+		vrf = r.IntfVrf(int32(nextHopIfIndex))
+	}
 	callSelectRoute := false
 	destNetIpAddr, err := getIP(destNetIp)
 	if err != nil {
@@ -1405,20 +1195,32 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		metric:         metric,
 		sliceIdx:       int(sliceIdx),
 		weight:         weight,
+		vrf:            vrf,
+		sourceVrf:      routeInfo.sourceVrf,
+		bfd:            routeInfo.bfd,
+		tag:            routeInfo.tag,
 	}
 
-	policyRoute := ribdInt.Routes{Ipaddr: destNetIp, IPAddrType: ribdInt.Int(ipType), Mask: networkMask, NextHopIp: nextHopIp, IfIndex: ribdInt.Int(nextHopIfIndex), Metric: ribdInt.Int(metric), Prototype: ribdInt.Int(routeType), Weight: ribdInt.Int(weight)}
+	policyRoute := ribdInt.Routes{Ipaddr: destNetIp, IPAddrType: ribdInt.Int(ipType), Mask: networkMask, NextHopIp: nextHopIp, IfIndex: ribdInt.Int(nextHopIfIndex), Metric: ribdInt.Int(metric), Prototype: ribdInt.Int(routeType), Weight: ribdInt.Int(weight), Vrf: vrf}
 	//logger.Info("createroute:,setting ipaddrtype to :", policyRoute.IPAddrType, " from iptype:", ipType)
 	routeInfoRecord.resolvedNextHopIpIntf.NextHopIp = routeInfoRecord.nextHopIp.String()
 	routeInfoRecord.resolvedNextHopIpIntf.NextHopIfIndex = ribdInt.Int(routeInfoRecord.nextHopIfIndex)
 
-	nhIntf, resolvedNextHopIntf, res_err := ResolveNextHop(routeInfoRecord.nextHopIp.String())
+	nhIntf, resolvedNextHopIntf, res_err := r.ResolveVrfNextHop(getRouteResolveVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String())
 	//_, resolvedNextHopIntf, _ := ResolveNextHop(routeInfoRecord.nextHopIp.String())
 	routeInfoRecord.resolvedNextHopIpIntf = resolvedNextHopIntf
+	if res_err == nil {
+		if nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask); err == nil {
+			routeInfoRecord.nextHopPrefix = string(nhPrefix)
+		}
+	}
 	logger.Info("nhIntf ipaddr/mask: ", nhIntf.Ipaddr, ":", nhIntf.Mask, " resolvedNex ", resolvedNextHopIntf.NextHopIp, " nexthop ", nextHopIp, "Is reachable:", resolvedNextHopIntf.IsReachable)
 
 	routeInfoRecord.routeCreatedTime = time.Now().String()
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, destNet)
+	if addType == FIBAndRIB && r.refreshStaleRoute(destNet, routeInfoRecord) {
+		return 0, nil
+	}
+	routeInfoRecordListItem := r.RouteInfoMapGet(vrf, ipType, destNet)
 	if routeInfoRecordListItem == nil {
 		/*
 		   no routes for this destination are currently configured
@@ -1430,6 +1232,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 			return 0, err
 		}
 		var newRouteInfoRecordList RouteInfoRecordList
+		newRouteInfoRecordList.vrf = vrf
 		newRouteInfoRecordList.routeInfoProtocolMap = make(map[string][]RouteInfoRecord)
 		newRouteInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeType)]] = make([]RouteInfoRecord, 0)
 		newRouteInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeType)]] = append(newRouteInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeType)]], routeInfoRecord)
@@ -1440,38 +1243,35 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		} else if policyStateChange == defs.RoutePolicyStateChangetoValid {
 			newRouteInfoRecordList.isPolicyBasedStateValid = true
 		}
-		if ok := RouteInfoMapInsert(ipType, destNet, newRouteInfoRecordList); ok != true {
+		if ok := r.RouteInfoMapInsert(vrf, ipType, destNet, newRouteInfoRecordList); ok != true {
 			logger.Err("Route map insert return value not ok")
 			return 0, err
 		}
 		if ipType == defs.IPv4 {
-			v4rtCount++
-			v4routeCreatedTimeMap[v4rtCount] = routeInfoRecord.routeCreatedTime
+			r.v4RouteCount++
+			r.v4RouteCreatedTime[r.v4RouteCount] = routeInfoRecord.routeCreatedTime
 		} else if ipType == defs.IPv6 {
-			v6rtCount++
-			v6routeCreatedTimeMap[v6rtCount] = routeInfoRecord.routeCreatedTime
+			r.v6RouteCount++
+			r.v6RouteCreatedTime[r.v6RouteCount] = routeInfoRecord.routeCreatedTime
 		}
-		UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeType)], "add", ipType, string(destNet), false)
-		UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNet), false)
-		localDBRecord := localDB{prefix: destNet, isValid: true, nextHopIp: nextHopIp}
-		if destNetSlice == nil {
-			destNetSlice = make([]localDB, 0)
-		}
-		destNetSlice = append(destNetSlice, localDBRecord)
+		r.updateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeType)], "add", ipType, string(destNet), false)
+		r.updateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNet), false)
+		localDBRecord := localDB{prefix: destNet, isValid: true, nextHopIp: nextHopIp, vrf: vrf}
+		r.destNetSlice = append(r.destNetSlice, localDBRecord)
 		//call asicd
 		//		if asicdclnt.IsConnected {
 		//logger.Debug("New route selected, call asicd to install a new route - ip", routeInfoRecord.destNetIp.String(), " mask ", routeInfoRecord.networkMask.String(), " nextHopIP ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
-		RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Add, Bulk: routeInfo.bulk, BulkEnd: routeInfo.bulkEnd}
+		queueFIBRoute(routeInfoRecord, defs.Add)
 		//		}
 		//if arpdclnt.IsConnected &&
 		if routeInfoRecord.protocol != defs.CONNECTED {
-			if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			if !arpResolveCalled(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 				//call arpd to resolve the ip
 				//logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: defs.Add}
 			}
 			//update the ref count for the resolved next hop ip
-			updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
+			updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
 		}
 		//logger.Debug("Adding to DBRouteCh from createv4Route")
 		RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
@@ -1481,14 +1281,14 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		//update in the event log
 		eventInfo := "Installed " + ReverseRouteProtoTypeMapDB[int(policyRoute.Prototype)] + " route " + policyRoute.Ipaddr + ":" + policyRoute.Mask + " nextHopIp :" + routeInfoRecord.nextHopIp.String() + " in Hardware and RIB "
 		routeEventInfo := RouteEventInfo{timeStamp: routeInfoRecord.routeCreatedTime, eventInfo: eventInfo}
-		localRouteEventsDB = append(localRouteEventsDB, routeEventInfo)
+		r.AddRouteEvent(routeEventInfo)
 
 		//update the ref count for the next hop ip
 		if res_err == nil {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
 				logger.Debug("network address of the nh route: ", nhPrefix)
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, add)
+				updateNextHopMap(NextHopInfoKey{getRouteResolveVrf(routeInfoRecord), string(nhPrefix)}, add)
 			}
 		}
 		if routeInfoRecord.resolvedNextHopIpIntf.IsReachable {
@@ -1497,11 +1297,12 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 				NextHopIp:      routeInfoRecord.nextHopIp.String(),
 				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
 			}
-			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNet)}].refCount > 0 {
-				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
-				RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
+			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{routeInfoRecord.vrf, string(destNet)}].refCount > 0 {
+				notifyNextHopGroups(routeInfoRecord.vrf, -1, destNet, true)
+				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
+				r.RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
 				//If there are dependent routes for this ip, then bring them up
-				RouteInfoMapVisitAndUpdate(ipType, routeReachabilityStatusInfo)
+				r.RouteInfoMapVisitAndUpdate(ipType, routeReachabilityStatusInfo)
 			}
 		}
 		var params RouteParams
@@ -1510,7 +1311,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		params.deleteType = Invalid
 		policyRoute.IsPolicyBasedStateValid = newRouteInfoRecordList.isPolicyBasedStateValid
 		//logger.Info("Createroute:policy route addr type:", policyRoute.IPAddrType)
-		PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Export, params)
+		r.PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Export, params)
 	} else {
 		logger.Debug("routeInfoRecordListItem not nil")
 		routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList) //RouteInfoMap.Get(destNet).(RouteInfoRecordList)
@@ -1553,11 +1354,14 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 			routeInfoRecordList.isPolicyBasedStateValid = true
 		}
 		if callSelectRoute {
-			err = SelectRoute(destNet, routeInfoRecordList, routeInfoRecord, add, int(addType)) //, len(routeInfoRecordList.routeInfoList)-1)
+			err = r.SelectRoute(destNet, routeInfoRecordList, routeInfoRecord, add, int(addType)) //, len(routeInfoRecordList.routeInfoList)-1)
 		}
 	}
-	if addType != FIBOnly && routePrototype == defs.CONNECTED { //PROTOCOL_CONNECTED {
-		updateConnectedRoutes(destNetIp, networkMask, nextHopIp, nextHopIfIndex, add, sliceIdx)
+	if addType != FIBOnly && routePrototype == defs.CONNECTED && routeInfoRecord.sourceVrf == "" { //PROTOCOL_CONNECTED {
+		r.updateConnectedRoutes(vrf, destNetIp, networkMask, nextHopIp, nextHopIfIndex, add, sliceIdx)
+	}
+	if addType == FIBAndRIB && err == nil {
+		r.trackBfdNextHop(routeInfoRecord)
 	}
 	return 0, err
 
@@ -1568,7 +1372,8 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
    -  a user/protocol deletes a route - delType = FIBAndRIB
    - when a link goes down and we have connected routes on that link - delType = FIBOnly
 **/
-func deleteIPRoute(destNetIp string,
+func (r *RIB) deleteIPRoute(vrf string,
+	destNetIp string,
 	ipType defs.IPType,
 	networkMask string,
 	routeType string,
@@ -1606,7 +1411,7 @@ func deleteIPRoute(destNetIp string,
 		}
 	}
 	//logger.Debug("destNet = ", destNet)
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, destNet)
+	routeInfoRecordListItem := r.RouteInfoMapGet(vrf, ipType, destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Err("Destnet ", destNet, " not found")
 		return 0, errors.New("No match found ")
@@ -1631,22 +1436,22 @@ func deleteIPRoute(destNetIp string,
 	/*
 	   Call selectv4Route to select the best route
 	*/
-	SelectRoute(destNet, routeInfoRecordList, routeInfoRecord, del, int(delType))
+	r.SelectRoute(destNet, routeInfoRecordList, routeInfoRecord, del, int(delType))
 
-	if routeType == "CONNECTED" { //PROTOCOL_CONNECTED {
+	if routeType == "CONNECTED" && routeInfoRecord.sourceVrf == "" { //PROTOCOL_CONNECTED {
 		if delType == FIBOnly { //link gone down, just invalidate the connected route
-			updateConnectedRoutes(destNetIp, networkMask, "", 0, invalidate, 0)
+			r.updateConnectedRoutes(vrf, destNetIp, networkMask, "", 0, invalidate, 0)
 		} else {
-			updateConnectedRoutes(destNetIp, networkMask, "", 0, del, 0)
+			r.updateConnectedRoutes(vrf, destNetIp, networkMask, "", 0, del, 0)
 		}
 	}
 
 	if ipType == defs.IPv4 {
-		v4rtCount--
-		v4routeCreatedTimeMap[v4rtCount] = ""
+		r.v4RouteCount--
+		r.v4RouteCreatedTime[r.v4RouteCount] = ""
 	} else if ipType == defs.IPv6 {
-		v6rtCount--
-		v6routeCreatedTimeMap[v6rtCount] = ""
+		r.v6RouteCount--
+		r.v6RouteCreatedTime[r.v6RouteCount] = ""
 	}
 
 	return 0, err
@@ -25,9 +25,11 @@
 package server
 
 import (
+	"l3/bfd/bfddCommonDefs"
 	defs "l3/rib/ribdCommonDefs"
 	"ribd"
-	"strconv"
+	"ribdInt"
+	"time"
 )
 
 type RouteConfigInfo struct {
@@ -37,11 +39,13 @@ type RouteConfigInfo struct {
 	Op        string //"add"/"del"/"update"
 }
 type TrackReachabilityInfo struct {
+	Vrf      string
 	IpAddr   string
 	Protocol string
 	Op       string
 }
 type NextHopInfoKey struct {
+	vrf       string
 	nextHopIp string
 }
 type NextHopInfo struct {
@@ -57,19 +61,13 @@ type PerProtocolRouteInfo struct {
 	totalcount RouteCountInfo
 }
 
-var ProtocolRouteMap map[string]PerProtocolRouteInfo  //map[string]int
-var InterfaceRouteMap map[string]PerProtocolRouteInfo //map[string]int
-
-func UpdateV4ProtocolRouteMap(protocol string, op string, value string, ecmp bool) {
+func UpdateV4ProtocolRouteMap(routeMap map[string]PerProtocolRouteInfo, protocol string, op string, value string, ecmp bool) {
 	var info PerProtocolRouteInfo
 
-	if ProtocolRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	if routeMap == nil {
+		return
 	}
-	info, ok := ProtocolRouteMap[protocol]
+	info, ok := routeMap[protocol]
 	if !ok || info.v4routeMap == nil {
 		if op == "del" {
 			return
@@ -116,18 +114,15 @@ func UpdateV4ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	protocolroutemap[value] = count
 	info.v4routeMap = protocolroutemap
 	info.totalcount = totalcount
-	ProtocolRouteMap[protocol] = info
+	routeMap[protocol] = info
 }
-func UpdateV6ProtocolRouteMap(protocol string, op string, value string, ecmp bool) {
+func UpdateV6ProtocolRouteMap(routeMap map[string]PerProtocolRouteInfo, protocol string, op string, value string, ecmp bool) {
 	var info PerProtocolRouteInfo
 
-	if ProtocolRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	if routeMap == nil {
+		return
 	}
-	info, ok := ProtocolRouteMap[protocol]
+	info, ok := routeMap[protocol]
 	if !ok || info.v6routeMap == nil {
 		if op == "del" {
 			return
@@ -174,157 +169,17 @@ func UpdateV6ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	protocolroutemap[value] = count
 	info.v6routeMap = protocolroutemap
 	info.totalcount = totalcount
-	ProtocolRouteMap[protocol] = info
-}
-func UpdateProtocolRouteMap(protocol string, op string, ipType defs.IPType, value string, ecmp bool) {
-	//logger.Debug("UpdateProtocolRouteMap,protocol:", protocol, " iptype:", ipType)
-	if ipType == defs.IPv4 {
-		UpdateV4ProtocolRouteMap(protocol, op, value, ecmp)
-	} else {
-		UpdateV6ProtocolRouteMap(protocol, op, value, ecmp)
-	}
-
-}
-
-func UpdateV4InterfaceRouteMap(intfref string, op string, value string, ecmp bool) {
-	var info PerProtocolRouteInfo
-
-	if InterfaceRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		InterfaceRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
-	}
-	info, ok := InterfaceRouteMap[intfref]
-	if !ok || info.v4routeMap == nil {
-		if op == "del" {
-			return
-		}
-		if info.v4routeMap == nil {
-			info.v4routeMap = make(map[string]RouteCountInfo)
-		}
-	}
-	interfaceroutemap := info.v4routeMap
-	if interfaceroutemap == nil {
-		return
-	}
-	totalcount := info.totalcount
-	count, ok := interfaceroutemap[value]
-	if !ok {
-		if op == "del" {
-			return
-		}
-	}
-	if op == "add" {
-		count.totalcount++
-		totalcount.totalcount++
-		if ecmp {
-			if count.ecmpcount == 0 {
-				//first time ecmp route is added, add an additional count
-				count.ecmpcount++
-				totalcount.ecmpcount++
-			}
-			count.ecmpcount++
-			totalcount.ecmpcount++
-		}
-	} else if op == "del" {
-		count.totalcount--
-		totalcount.totalcount--
-		if ecmp {
-			count.ecmpcount--
-			totalcount.ecmpcount--
-			if count.totalcount <= 1 {
-				count.ecmpcount--
-				totalcount.ecmpcount--
-			}
-		}
-	}
-	interfaceroutemap[value] = count
-	info.v4routeMap = interfaceroutemap
-	info.totalcount = totalcount
-	InterfaceRouteMap[intfref] = info
-}
-func UpdateV6InterfaceRouteMap(intfref string, op string, value string, ecmp bool) {
-	var info PerProtocolRouteInfo
-
-	if InterfaceRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		InterfaceRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
-	}
-	info, ok := InterfaceRouteMap[intfref]
-	if !ok || info.v6routeMap == nil {
-		if op == "del" {
-			return
-		}
-		if info.v6routeMap == nil {
-			info.v6routeMap = make(map[string]RouteCountInfo)
-		}
-	}
-	interfaceroutemap := info.v6routeMap
-	if interfaceroutemap == nil {
-		return
-	}
-	totalcount := info.totalcount
-	count, ok := interfaceroutemap[value]
-	if !ok {
-		if op == "del" {
-			return
-		}
-	}
-	if op == "add" {
-		count.totalcount++
-		totalcount.totalcount++
-		if ecmp {
-			if count.ecmpcount == 0 {
-				//first time ecmp route is added, add an additional count
-				count.ecmpcount++
-				totalcount.ecmpcount++
-			}
-			count.ecmpcount++
-			totalcount.ecmpcount++
-		}
-	} else if op == "del" {
-		count.totalcount--
-		totalcount.totalcount--
-		if ecmp {
-			count.ecmpcount--
-			totalcount.ecmpcount--
-			if count.totalcount <= 1 {
-				count.ecmpcount--
-				totalcount.ecmpcount--
-			}
-		}
-	}
-	interfaceroutemap[value] = count
-	info.v6routeMap = interfaceroutemap
-	info.totalcount = totalcount
-	InterfaceRouteMap[intfref] = info
-}
-func UpdateInterfaceRouteMap(intf int, op string, ipType defs.IPType, value string, ecmp bool) {
-	intfref := strconv.Itoa(int(intf))
-	intfEntry, ok := IntfIdNameMap[int32(intf)]
-	if ok {
-		//logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
-		intfref = intfEntry.name
-	}
-	if ipType == defs.IPv4 {
-		UpdateV4InterfaceRouteMap(intfref, op, value, ecmp)
-	} else {
-		UpdateV6InterfaceRouteMap(intfref, op, value, ecmp)
-	}
-
+	routeMap[protocol] = info
 }
 func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 	logger.Info("Starting the routeserver loop")
-	ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
 	for {
 		select {
 		case routeConf := <-ribdServiceHandler.RouteConfCh:
 			//logger.Debug(fmt.Sprintln("received message on RouteConfCh channel, op: ", routeConf.Op)
+			ribdServiceHandler.RIB.Lock()
 			if routeConf.Op == defs.Add {
-				ribdServiceHandler.ProcessV4RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBAndRIB, ribd.Int(len(destNetSlice)))
+				ribdServiceHandler.ProcessV4RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
 			} else if routeConf.Op == defs.AddFIBOnly {
 				ribdServiceHandler.ProcessV4RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
 			} else if routeConf.Op == defs.AddBulk {
@@ -341,7 +196,7 @@ func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 				}
 			} else if routeConf.Op == defs.Addv6 {
 				//create ipv6 route
-				ribdServiceHandler.ProcessV6RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBAndRIB, ribd.Int(len(destNetSlice)))
+				ribdServiceHandler.ProcessV6RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
 			} else if routeConf.Op == defs.Addv6FIBOnly {
 				//create ipv6 route
 				ribdServiceHandler.ProcessV6RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
@@ -358,7 +213,39 @@ func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 				} else {
 					ribdServiceHandler.Processv6RoutePatchUpdateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), routeConf.NewConfigObject.(*ribd.IPv6Route), routeConf.PatchOp)
 				}
+			} else if routeConf.Op == defs.AddRPF {
+				ribdServiceHandler.ProcessRPFRouteCreateConfig(routeConf.OrigConfigObject.(*ribdInt.RPFRoute))
+			} else if routeConf.Op == defs.DelRPF {
+				ribdServiceHandler.ProcessRPFRouteDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.RPFRoute))
+			} else if routeConf.Op == defs.AddLabeled {
+				ribdServiceHandler.ProcessLabeledRouteCreateConfig(routeConf.OrigConfigObject.(*ribdInt.LabeledRoute))
+			} else if routeConf.Op == defs.DelLabeled {
+				ribdServiceHandler.ProcessLabeledRouteDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.LabeledRoute))
+			} else if routeConf.Op == defs.AddVrf {
+				ribdServiceHandler.ProcessVrfCreateConfig(routeConf.OrigConfigObject.(*ribdInt.Vrf))
+			} else if routeConf.Op == defs.DelVrf {
+				ribdServiceHandler.ProcessVrfDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.Vrf))
+			} else if routeConf.Op == defs.UpdateVrf {
+				ribdServiceHandler.ProcessVrfUpdateConfig(routeConf.OrigConfigObject.(*ribdInt.Vrf), routeConf.NewConfigObject.(*ribdInt.Vrf))
+			} else if routeConf.Op == defs.AddPbrPolicy {
+				ribdServiceHandler.ProcessPbrPolicyCreateConfig(routeConf.OrigConfigObject.(*ribdInt.PbrPolicy))
+			} else if routeConf.Op == defs.DelPbrPolicy {
+				ribdServiceHandler.ProcessPbrPolicyDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.PbrPolicy))
+			} else if routeConf.Op == defs.UpdatePbrPolicy {
+				ribdServiceHandler.ProcessPbrPolicyUpdateConfig(routeConf.OrigConfigObject.(*ribdInt.PbrPolicy), routeConf.NewConfigObject.(*ribdInt.PbrPolicy))
+			} else if routeConf.Op == defs.BfdSessionStateChange {
+				ribdServiceHandler.ProcessBfdSessionStateChange(routeConf.OrigConfigObject.(bfddCommonDefs.BfddNotifyMsg))
+			} else if routeConf.Op == defs.MarkStaleRoutes {
+				ribdServiceHandler.MarkRoutesOfTypeStale(routeConf.OrigConfigObject.(string))
+			} else if routeConf.Op == defs.SweepStaleRoutes {
+				ribdServiceHandler.SweepStaleRoutesOfType(routeConf.OrigConfigObject.(string))
+			} else if routeConf.Op == defs.HoldStaleRoutes {
+				ribdServiceHandler.HoldStaleRoutesOfType(routeConf.OrigConfigObject.(string), routeConf.AdditionalParams.(time.Duration))
+			} else if routeConf.Op == defs.FlushStaleRoutes {
+				//queued behind the routes read at startup
+				ribdServiceHandler.AsicdRouteCh <- routeConf
 			}
+			ribdServiceHandler.RIB.Unlock()
 		}
 	}
 }
@@ -32,6 +32,7 @@ import (
 	"ribd"
 	"ribdInt"
 	"strconv"
+	"time"
 	"utils/clntUtils/clntIntfs"
 	"utils/clntUtils/clntIntfs/arpdClntIntfs"
 	"utils/clntUtils/clntIntfs/asicdClntIntfs"
@@ -95,9 +96,25 @@ type RIBDServer struct {
 	DbHdl               *dbutils.DBUtil
 	Clients             map[string]ClientIf
 	//RouteInstallCh                 chan RouteParams
+	//arpd and asicd clients, nil when the netlink fib runs without them
 	ArpdClntPlugin   arpdClntIntfs.ArpdClntIntf
 	AsicdPlugin      asicdClntIntfs.AsicdClntIntf
 	AsicdSubSocketCh chan clntIntfs.NotifyMsg
+	NetlinkPlugin    *NetlinkPlugin //routes are installed in the kernel instead of asicd when set
+	FIBPlugin        FIBPlugin
+	PBRPlugin        PBRPlugin
+	//next hop groups of the routes installed in the FIB
+	NextHopGroupTable *NextHopGroupTable
+	//route updates waiting to be sent to the FIB
+	FIBQueue *FIBQueue
+	//periodic audit of the FIB against the RIB
+	FIBAudit *FIBAudit
+	//imports the routes of other agents from the kernel when set
+	KernelRouteImporter *KernelRouteImporter
+	//route tables, see RIB for the locking rules
+	RIB *RIB
+	//routes of a protocol daemon that went down are kept for this long
+	StaleRouteHoldTime time.Duration
 }
 
 const (
@@ -106,7 +123,9 @@ const (
 	PROTOCOL_STATIC    = 1
 	PROTOCOL_OSPF      = 2
 	PROTOCOL_BGP       = 3
-	PROTOCOL_LAST      = 4
+	PROTOCOL_KERNEL    = 4
+	PROTOCOL_RIP       = 5
+	PROTOCOL_LAST      = 6
 )
 
 const (
@@ -130,25 +149,19 @@ type localDB struct {
 	isValid    bool
 	precedence int
 	nextHopIp  string
+	vrf        string
 }
 type IntfEntry struct {
 	name string
 }
 
 var count int
-var ConnectedRoutes []*ribdInt.Routes
 var logger *logging.Writer
 var AsicdSub *nanomsg.SubSocket
 var RouteServiceHandler *RIBDServer
-var IntfIdNameMap map[int32]IntfEntry
-var IfNameToIfIndex map[string]int32
 var GlobalPolicyEngineDB *policy.PolicyEngineDB
 var PolicyEngineDB *policy.PolicyEngineDB
 var PARAMSDIR string
-var v4rtCount int
-var v4routeCreatedTimeMap map[int]string
-var v6rtCount int
-var v6routeCreatedTimeMap map[int]string
 
 var dbReqCount = 0
 var dbReqCountLimit = 1
@@ -183,6 +196,7 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv4IntfDownEvent(ipAddr string, if
 	}
 	cfg.NextHop = make([]*ribd.NextHopInfo, 0)
 	cfg.NextHop = append(cfg.NextHop, &nextHop)
+	notifyNextHopGroups(getIntfVrf(int32(ifIndex)), ribd.Int(ifIndex), nil, false)
 	ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
 		OrigConfigObject: &cfg,
 		Op:               defs.DelFIBOnly,
@@ -212,6 +226,7 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv6IntfDownEvent(ipAddr string, if
 	}
 	cfg.NextHop = make([]*ribd.NextHopInfo, 0)
 	cfg.NextHop = append(cfg.NextHop, &nextHop)
+	notifyNextHopGroups(getIntfVrf(int32(ifIndex)), ribd.Int(ifIndex), nil, false)
 	ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
 		OrigConfigObject: &cfg,
 		Op:               defs.Delv6FIBOnly,
@@ -233,15 +248,18 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv4IntfUpEvent(ipAddr string, ifIn
 	ipAddrStr := ip.String()
 	ipMaskStr := net.IP(ipMask).String()
 	logger.Info(" processIPv4IntfUpEvent for  ipaddr ", ipAddrStr, " mask ", ipMaskStr)
-	for i := 0; i < len(ConnectedRoutes); i++ {
+	if ifIndex != -1 {
+		notifyNextHopGroups(getIntfVrf(int32(ifIndex)), ribd.Int(ifIndex), nil, true)
+	}
+	for i := 0; i < len(RouteServiceHandler.RIB.connectedRoutes); i++ {
 		//logger.Info("Current state of this connected route is ", ConnectedRoutes[i].IsValid)
-		if ConnectedRoutes[i].Ipaddr == ipAddrStr && ConnectedRoutes[i].Mask == ipMaskStr && ConnectedRoutes[i].IsValid == false {
-			if ifIndex != -1 && ConnectedRoutes[i].IfIndex != ribdInt.Int(ifIndex) {
+		if RouteServiceHandler.RIB.connectedRoutes[i].Ipaddr == ipAddrStr && RouteServiceHandler.RIB.connectedRoutes[i].Mask == ipMaskStr && RouteServiceHandler.RIB.connectedRoutes[i].IsValid == false {
+			if ifIndex != -1 && RouteServiceHandler.RIB.connectedRoutes[i].IfIndex != ribdInt.Int(ifIndex) {
 				continue
 			}
-			logger.Info("Add this route with destAddress = ", ConnectedRoutes[i].Ipaddr, " nwMask = ", ConnectedRoutes[i].Mask)
+			logger.Info("Add this route with destAddress = ", RouteServiceHandler.RIB.connectedRoutes[i].Ipaddr, " nwMask = ", RouteServiceHandler.RIB.connectedRoutes[i].Mask)
 
-			ConnectedRoutes[i].IsValid = true
+			RouteServiceHandler.RIB.connectedRoutes[i].IsValid = true
 			//			policyRoute := ribdInt.Routes{Ipaddr: ConnectedRoutes[i].Ipaddr, IPAddrType: ribdInt.Int(defs.IPv4), Mask: ConnectedRoutes[i].Mask, NextHopIp: ConnectedRoutes[i].NextHopIp, IfIndex: ConnectedRoutes[i].IfIndex, Metric: ConnectedRoutes[i].Metric, Prototype: ConnectedRoutes[i].Prototype}
 			//			params := RouteParams{destNetIp: ConnectedRoutes[i].Ipaddr, ipType: defs.IPv4, networkMask: ConnectedRoutes[i].Mask, nextHopIp: ConnectedRoutes[i].NextHopIp, nextHopIfIndex: ribd.Int(ConnectedRoutes[i].IfIndex), metric: ribd.Int(ConnectedRoutes[i].Metric), routeType: ribd.Int(ConnectedRoutes[i].Prototype), sliceIdx: ribd.Int(ConnectedRoutes[i].SliceIdx), createType: FIBOnly, deleteType: Invalid}
 			//			PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Import, params)
@@ -261,7 +279,7 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv4IntfUpEvent(ipAddr string, ifIn
 			ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
 				OrigConfigObject: &cfg,
 				Op:               defs.AddFIBOnly,
-				AdditionalParams: ribd.Int(ConnectedRoutes[i].SliceIdx),
+				AdditionalParams: ribd.Int(RouteServiceHandler.RIB.connectedRoutes[i].SliceIdx),
 			}
 		}
 	}
@@ -278,15 +296,18 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv6IntfUpEvent(ipAddr string, ifIn
 	ipAddrStr := ip.String()
 	ipMaskStr := net.IP(ipMask).String()
 	logger.Info(" processIPv6IntfUpEvent for  ipaddr ", ipAddrStr, " mask ", ipMaskStr)
-	for i := 0; i < len(ConnectedRoutes); i++ {
+	if ifIndex != -1 {
+		notifyNextHopGroups(getIntfVrf(int32(ifIndex)), ribd.Int(ifIndex), nil, true)
+	}
+	for i := 0; i < len(RouteServiceHandler.RIB.connectedRoutes); i++ {
 		//logger.Info("Current state of this connected route is ", ConnectedRoutes[i].IsValid)
-		if ConnectedRoutes[i].Ipaddr == ipAddrStr && ConnectedRoutes[i].Mask == ipMaskStr && ConnectedRoutes[i].IsValid == false {
-			if ifIndex != -1 && ConnectedRoutes[i].IfIndex != ribdInt.Int(ifIndex) {
+		if RouteServiceHandler.RIB.connectedRoutes[i].Ipaddr == ipAddrStr && RouteServiceHandler.RIB.connectedRoutes[i].Mask == ipMaskStr && RouteServiceHandler.RIB.connectedRoutes[i].IsValid == false {
+			if ifIndex != -1 && RouteServiceHandler.RIB.connectedRoutes[i].IfIndex != ribdInt.Int(ifIndex) {
 				continue
 			}
-			logger.Info("Add this route with destAddress = ", ConnectedRoutes[i].Ipaddr, " nwMask = ", ConnectedRoutes[i].Mask)
+			logger.Info("Add this route with destAddress = ", RouteServiceHandler.RIB.connectedRoutes[i].Ipaddr, " nwMask = ", RouteServiceHandler.RIB.connectedRoutes[i].Mask)
 
-			ConnectedRoutes[i].IsValid = true
+			RouteServiceHandler.RIB.connectedRoutes[i].IsValid = true
 			//			policyRoute := ribdInt.Routes{Ipaddr: ConnectedRoutes[i].Ipaddr, IPAddrType: ribdInt.Int(defs.IPv6), Mask: ConnectedRoutes[i].Mask, NextHopIp: ConnectedRoutes[i].NextHopIp, IfIndex: ConnectedRoutes[i].IfIndex, Metric: ConnectedRoutes[i].Metric, Prototype: ConnectedRoutes[i].Prototype}
 			//			params := RouteParams{destNetIp: ConnectedRoutes[i].Ipaddr, ipType: defs.IPv6, networkMask: ConnectedRoutes[i].Mask, nextHopIp: ConnectedRoutes[i].NextHopIp, nextHopIfIndex: ribd.Int(ConnectedRoutes[i].IfIndex), metric: ribd.Int(ConnectedRoutes[i].Metric), routeType: ribd.Int(ConnectedRoutes[i].Prototype), sliceIdx: ribd.Int(ConnectedRoutes[i].SliceIdx), createType: FIBOnly, deleteType: Invalid}
 			//			PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Import, params)
@@ -306,7 +327,7 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv6IntfUpEvent(ipAddr string, ifIn
 			ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
 				OrigConfigObject: &cfg,
 				Op:               defs.Addv6FIBOnly,
-				AdditionalParams: ribd.Int(ConnectedRoutes[i].SliceIdx),
+				AdditionalParams: ribd.Int(RouteServiceHandler.RIB.connectedRoutes[i].SliceIdx),
 			}
 		}
 	}
@@ -332,15 +353,7 @@ func (server *RIBDServer) GetLogicalIntfInfo() {
 		for i := 0; i < int(bulkInfo.Count); i++ {
 			ifId := (bulkInfo.LogicalIntfStateList[i].IfIndex)
 			logger.Info("logical interface = ", bulkInfo.LogicalIntfStateList[i].Name, "ifId = ", ifId)
-			if IntfIdNameMap == nil {
-				IntfIdNameMap = make(map[int32]IntfEntry)
-			}
-			intfEntry := IntfEntry{name: bulkInfo.LogicalIntfStateList[i].Name}
-			IntfIdNameMap[ifId] = intfEntry
-			if IfNameToIfIndex == nil {
-				IfNameToIfIndex = make(map[string]int32)
-			}
-			IfNameToIfIndex[bulkInfo.LogicalIntfStateList[i].Name] = ifId
+			server.RIB.SetIntfEntry(ifId, bulkInfo.LogicalIntfStateList[i].Name)
 		}
 		if bulkInfo.More == false {
 			logger.Info("more returned as false, so no more get bulks")
@@ -369,15 +382,7 @@ func (server *RIBDServer) GetVlanInfo() {
 		for i := 0; i < int(bulkInfo.Count); i++ {
 			ifId := (bulkInfo.VlanStateList[i].IfIndex)
 			logger.Info("vlan = ", bulkInfo.VlanStateList[i].VlanId, "ifId = ", ifId)
-			if IntfIdNameMap == nil {
-				IntfIdNameMap = make(map[int32]IntfEntry)
-			}
-			intfEntry := IntfEntry{name: bulkInfo.VlanStateList[i].VlanName}
-			IntfIdNameMap[ifId] = intfEntry
-			if IfNameToIfIndex == nil {
-				IfNameToIfIndex = make(map[string]int32)
-			}
-			IfNameToIfIndex[bulkInfo.VlanStateList[i].VlanName] = ifId
+			server.RIB.SetIntfEntry(ifId, bulkInfo.VlanStateList[i].VlanName)
 		}
 		if bulkInfo.More == false {
 			logger.Info("more returned as false, so no more get bulks")
@@ -405,16 +410,8 @@ func (server *RIBDServer) GetPortInfo() {
 		logger.Info("len(bulkInfo.PortStateList)  = ", len(bulkInfo.PortStateList), " num objects returned = ", bulkInfo.Count)
 		for i := 0; i < int(bulkInfo.Count); i++ {
 			ifId := bulkInfo.PortStateList[i].IfIndex
-			if IntfIdNameMap == nil {
-				IntfIdNameMap = make(map[int32]IntfEntry)
-			}
-			intfEntry := IntfEntry{name: bulkInfo.PortStateList[i].Name}
-			IntfIdNameMap[ifId] = intfEntry
-			if IfNameToIfIndex == nil {
-				IfNameToIfIndex = make(map[string]int32)
-			}
-			IfNameToIfIndex[bulkInfo.PortStateList[i].Name] = ifId
-			logger.Info("ifId = ", ifId, "IntfIdNameMap[", ifId, "] = ", IntfIdNameMap[ifId], "IfNameToIfIndex[", bulkInfo.PortStateList[i].Name, "] = ", IfNameToIfIndex[bulkInfo.PortStateList[i].Name])
+			server.RIB.SetIntfEntry(ifId, bulkInfo.PortStateList[i].Name)
+			logger.Info("ifId = ", ifId, " name = ", bulkInfo.PortStateList[i].Name)
 		}
 		if bulkInfo.More == false {
 			logger.Info("more returned as false, so no more get bulks")
@@ -424,6 +421,12 @@ func (server *RIBDServer) GetPortInfo() {
 	}
 }
 func (server *RIBDServer) GetIntfInfo() {
+	if server.AsicdPlugin == nil {
+		if server.NetlinkPlugin != nil {
+			server.NetlinkPlugin.GetLinkInfo()
+		}
+		return
+	}
 	server.GetPortInfo()
 	server.GetVlanInfo()
 	server.GetLogicalIntfInfo()
@@ -450,6 +453,10 @@ func (ribdServiceHandler *RIBDServer) AcceptConfigActions() {
 	if dbRead != true {
 		logger.Err("DB read failed")
 	}
+	ribdServiceHandler.RouteConfCh <- RIBdServerConfig{Op: defs.FlushStaleRoutes}
+	if ribdServiceHandler.KernelRouteImporter != nil {
+		go ribdServiceHandler.StartKernelRouteImport()
+	}
 	//	go ribdServiceHandler.SetupEventHandler(AsicdSub, asicdCommonDefs.PUB_SOCKET_ADDR, SUB_ASICD)
 	logger.Info("All set to signal start the RIBd server")
 	ribdServiceHandler.ServerUpCh <- true
@@ -496,26 +503,29 @@ func (ribdServiceHandler *RIBDServer) InitializePolicyDB() *policy.PolicyEngineD
 	return ribdServiceHandler.PolicyEngineDB
 }
 func NewRIBDServicesHandler(dbHdl *dbutils.DBUtil, loggerC *logging.Writer) *RIBDServer {
-	V4RouteInfoMap = patriciaDB.NewTrie()
-	V6RouteInfoMap = patriciaDB.NewTrie()
 	ribdServicesHandler := &RIBDServer{}
 	ribdServicesHandler.Logger = loggerC
 	logger = loggerC
-	localRouteEventsDB = make([]RouteEventInfo, 0)
-	RedistributeRouteMap = make(map[string][]RedistributeRouteInfo)
 	ribdServicesHandler.Clients = make(map[string]ClientIf)
-	TrackReachabilityMap = make(map[string][]string)
-	v4routeCreatedTimeMap = make(map[int]string)
-	v6routeCreatedTimeMap = make(map[int]string)
+	ribdServicesHandler.Clients["bgpd"] = &bgpdclnt
+	ribdServicesHandler.Clients["ospfd"] = &ospfdclnt
+	ribdServicesHandler.Clients["ospfv2d"] = &ospfdclnt
+	ribdServicesHandler.Clients["ripd"] = &ripdclnt
+	ribdServicesHandler.StaleRouteHoldTime = DefaultStaleRouteHoldTime
+	ribdServicesHandler.FIBPlugin = &AsicdFIBPlugin{server: ribdServicesHandler}
+	ribdServicesHandler.PBRPlugin = &AsicdFIBPlugin{server: ribdServicesHandler}
+	ribdServicesHandler.NextHopGroupTable = NewNextHopGroupTable()
+	ribdServicesHandler.FIBQueue = NewFIBQueue(FIBQueueMaxDepth)
+	ribdServicesHandler.FIBAudit = NewFIBAudit(DefaultFIBAuditInterval, false)
+	ribdServicesHandler.RIB = NewRIB()
 	RouteProtocolTypeMapDB = make(map[string]int)
 	ReverseRouteProtoTypeMapDB = make(map[int]string)
-	ProtocolAdminDistanceMapDB = make(map[string]RouteDistanceConfig)
 	PublisherInfoMap = make(map[string]PublisherMapInfo)
 	ribdServicesHandler.NextHopInfoMap = make(map[NextHopInfoKey]NextHopInfo)
 	ribdServicesHandler.AsicdSubSocketCh = make(chan clntIntfs.NotifyMsg)
 	ribdServicesHandler.TrackReachabilityCh = make(chan TrackReachabilityInfo, 1000)
 	ribdServicesHandler.RouteConfCh = make(chan RIBdServerConfig, 200000)
-	ribdServicesHandler.AsicdRouteCh = make(chan RIBdServerConfig, 200000)
+	ribdServicesHandler.AsicdRouteCh = make(chan RIBdServerConfig, FIBQueueMaxDepth)
 	ribdServicesHandler.ArpdRouteCh = make(chan RIBdServerConfig, 5000)
 	ribdServicesHandler.NotificationChannel = make(chan NotificationMsg, 5000)
 	/*	ribdServicesHandler.PolicyConditionConfCh = make(chan RIBdServerConfig, 5000)
@@ -534,9 +544,9 @@ func NewRIBDServicesHandler(dbHdl *dbutils.DBUtil, loggerC *logging.Writer) *RIB
 	RouteServiceHandler = ribdServicesHandler
 	//ribdServicesHandler.RouteInstallCh = make(chan RouteParams)
 	BuildRouteProtocolTypeMapDB()
-	BuildProtocolAdminDistanceMapDB()
 	BuildPublisherMap()
 	PolicyEngineDB = ribdServicesHandler.InitializePolicyDB()
+	ribdServicesHandler.RIB.SetPolicyDB(PolicyEngineDB)
 	GlobalPolicyEngineDB = ribdServicesHandler.InitializeGlobalPolicyDB()
 	return ribdServicesHandler
 }
@@ -552,6 +562,8 @@ func (s *RIBDServer) InitServer() {
 	go s.NotificationServer()
 	go s.StartAsicdServer()
 	go s.StartArpdServer()
+	go s.StartBfdServer()
+	go s.StartFIBAuditTimer()
 
 }
 func (ribdServiceHandler *RIBDServer) StartServer(paramsDir string) {
@@ -582,9 +594,13 @@ func (ribdServiceHandler *RIBDServer) StartServer(paramsDir string) {
 		ribdServiceHandler.PolicyUpdateApplyCh <- list)*/
 		case info := <-ribdServiceHandler.TrackReachabilityCh:
 			logger.Debug("received message on TrackReachabilityCh channel")
-			ribdServiceHandler.TrackReachabilityStatus(info.IpAddr, info.Protocol, info.Op)
+			ribdServiceHandler.RIB.Lock()
+			ribdServiceHandler.TrackVrfReachabilityStatus(info.Vrf, info.IpAddr, info.Protocol, info.Op)
+			ribdServiceHandler.RIB.Unlock()
 		case msg := <-ribdServiceHandler.AsicdSubSocketCh:
+			ribdServiceHandler.RIB.Lock()
 			ribdServiceHandler.processAsicdNotification(msg)
+			ribdServiceHandler.RIB.Unlock()
 		}
 	}
 }
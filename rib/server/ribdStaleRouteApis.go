@@ -0,0 +1,221 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdStaleRouteApis.go
+package server
+
+import (
+	defs "l3/rib/ribdCommonDefs"
+	"time"
+	"utils/patriciaDB"
+)
+
+const DefaultStaleRouteHoldTime = 120 * time.Second
+
+/*
+   Protocol route types owned by each of the protocol daemons
+*/
+var clientProtocolsMap = map[string][]string{
+	"bgpd":    []string{"EBGP", "IBGP"},
+	"ospfd":   []string{"OSPF"},
+	"ospfv2d": []string{"OSPF"},
+	"ripd":    []string{"RIP"},
+}
+
+func getProtocolDestNets(rib *VrfRIB, protocol string) (v4DestNets []string, v6DestNets []string) {
+	protocolRouteMap, ok := rib.protocolRouteMap[protocol]
+	if !ok {
+		return v4DestNets, v6DestNets
+	}
+	for destNet, count := range protocolRouteMap.v4routeMap {
+		if count.totalcount > 0 {
+			v4DestNets = append(v4DestNets, destNet)
+		}
+	}
+	for destNet, count := range protocolRouteMap.v6routeMap {
+		if count.totalcount > 0 {
+			v6DestNets = append(v6DestNets, destNet)
+		}
+	}
+	return v4DestNets, v6DestNets
+}
+
+func (r *RIB) markRoutesStale(vrf string, protocol string, ipType defs.IPType, destNet string) {
+	routeInfoRecordListItem := r.RouteInfoMapGet(vrf, ipType, patriciaDB.Prefix(destNet))
+	if routeInfoRecordListItem == nil {
+		return
+	}
+	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
+	routeInfoList := routeInfoRecordList.routeInfoProtocolMap[protocol]
+	if len(routeInfoList) == 0 {
+		return
+	}
+	for idx := 0; idx < len(routeInfoList); idx++ {
+		if routeInfoList[idx].sourceVrf != "" {
+			//leaked routes are withdrawn with the route they were leaked from
+			continue
+		}
+		routeInfoList[idx].stale = true
+	}
+	routeInfoRecordList.routeInfoProtocolMap[protocol] = routeInfoList
+	r.RouteInfoMapSet(vrf, ipType, patriciaDB.Prefix(destNet), routeInfoRecordList)
+	if routeInfoRecordList.selectedRouteProtocol == protocol {
+		RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
+			OrigConfigObject: RouteDBInfo{routeInfoList[0], routeInfoRecordList},
+			Op:               defs.Add,
+		}
+	}
+}
+
+/*
+   Marks the routes of the protocol stale, they are kept in the FIB until the
+   protocol refreshes them or the hold time expires
+*/
+func (m *RIBDServer) MarkRoutesOfTypeStale(protocol string) {
+	logger.Info("MarkRoutesOfTypeStale: protocol ", protocol, " hold time ", m.StaleRouteHoldTime)
+	for _, rib := range m.RIB.vrfs {
+		v4DestNets, v6DestNets := getProtocolDestNets(rib, protocol)
+		for _, destNet := range v4DestNets {
+			m.RIB.markRoutesStale(rib.name, protocol, defs.IPv4, destNet)
+		}
+		for _, destNet := range v6DestNets {
+			m.RIB.markRoutesStale(rib.name, protocol, defs.IPv6, destNet)
+		}
+	}
+	m.startStaleRouteTimer(protocol, m.StaleRouteHoldTime)
+}
+
+func (m *RIBDServer) startStaleRouteTimer(protocol string, holdTime time.Duration) {
+	if timer, ok := m.RIB.staleRouteTimers[protocol]; ok {
+		timer.Stop()
+	}
+	m.RIB.staleRouteTimers[protocol] = time.AfterFunc(holdTime, func() {
+		logger.Info("Stale route hold time expired for protocol ", protocol)
+		m.RouteConfCh <- RIBdServerConfig{OrigConfigObject: protocol, Op: defs.SweepStaleRoutes}
+	})
+}
+
+/*
+   Restarts the hold timer of the stale routes of the protocol with the hold
+   time asked for by the protocol daemon, ospfd keeps its routes for the
+   grace period of a graceful restart this way
+*/
+func (m *RIBDServer) HoldStaleRoutesOfType(protocol string, holdTime time.Duration) {
+	logger.Info("HoldStaleRoutesOfType: protocol ", protocol, " hold time ", holdTime)
+	if _, ok := m.RIB.staleRouteTimers[protocol]; !ok {
+		logger.Info("HoldStaleRoutesOfType: no stale routes of protocol ", protocol)
+		return
+	}
+	m.startStaleRouteTimer(protocol, holdTime)
+}
+
+func (r *RIB) sweepStaleRoutes(vrf string, protocol string, ipType defs.IPType, destNet string) {
+	routeInfoRecordListItem := r.RouteInfoMapGet(vrf, ipType, patriciaDB.Prefix(destNet))
+	if routeInfoRecordListItem == nil {
+		return
+	}
+	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
+	staleRoutes := make([]RouteInfoRecord, 0)
+	for _, routeInfoRecord := range routeInfoRecordList.routeInfoProtocolMap[protocol] {
+		if routeInfoRecord.stale {
+			staleRoutes = append(staleRoutes, routeInfoRecord)
+		}
+	}
+	for _, staleRoute := range staleRoutes {
+		_, err := r.deleteIPRoute(vrf, staleRoute.destNetIp.String(), ipType, staleRoute.networkMask.String(), protocol,
+			staleRoute.nextHopIp.String(), staleRoute.nextHopIfIndex, FIBAndRIB, defs.RoutePolicyStateChangetoInValid)
+		logger.Info("sweepStaleRoutes: err ", err, " while deleting stale ", protocol, " route ", staleRoute.networkAddr, " nexthopIP:", staleRoute.nextHopIp.String())
+	}
+}
+
+/*
+   Deletes the routes of the protocol that were not refreshed since the
+   protocol daemon went down
+*/
+func (m *RIBDServer) SweepStaleRoutesOfType(protocol string) {
+	logger.Info("SweepStaleRoutesOfType: protocol ", protocol)
+	if timer, ok := m.RIB.staleRouteTimers[protocol]; ok {
+		timer.Stop()
+		delete(m.RIB.staleRouteTimers, protocol)
+	}
+	for _, rib := range m.RIB.vrfs {
+		v4DestNets, v6DestNets := getProtocolDestNets(rib, protocol)
+		for _, destNet := range v4DestNets {
+			m.RIB.sweepStaleRoutes(rib.name, protocol, defs.IPv4, destNet)
+		}
+		for _, destNet := range v6DestNets {
+			m.RIB.sweepStaleRoutes(rib.name, protocol, defs.IPv6, destNet)
+		}
+	}
+}
+
+/*
+   Clears the stale flag when the protocol adds the same route again. Stale
+   routes with a different cost are removed so that the new route replaces
+   them. Returns true when the route was refreshed and nothing more needs to
+   be done.
+*/
+func (r *RIB) refreshStaleRoute(destNet patriciaDB.Prefix, routeInfoRecord RouteInfoRecord) bool {
+	routeInfoRecordListItem := r.RouteInfoMapGet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNet)
+	if routeInfoRecordListItem == nil {
+		return false
+	}
+	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
+	protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	routeInfoList := routeInfoRecordList.routeInfoProtocolMap[protocol]
+	found, currRecord, idx := findRouteWithNextHop(routeInfoList, routeInfoRecord.nextHopIpType, routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex)
+	if found && currRecord.stale && currRecord.metric == routeInfoRecord.metric {
+		logger.Debug("refreshStaleRoute: refreshed ", protocol, " route ", currRecord.networkAddr, " nexthopIP:", currRecord.nextHopIp.String())
+		routeInfoList[idx].stale = false
+		routeInfoList[idx].routeUpdatedTime = time.Now().String()
+		routeInfoRecordList.routeInfoProtocolMap[protocol] = routeInfoList
+		r.RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNet, routeInfoRecordList)
+		if routeInfoRecordList.selectedRouteProtocol == protocol {
+			RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
+				OrigConfigObject: RouteDBInfo{routeInfoList[idx], routeInfoRecordList},
+				Op:               defs.Add,
+			}
+		}
+		return true
+	}
+	staleRoutes := make([]RouteInfoRecord, 0)
+	for _, currRecord := range routeInfoList {
+		if currRecord.stale && currRecord.metric != routeInfoRecord.metric {
+			staleRoutes = append(staleRoutes, currRecord)
+		}
+	}
+	for _, staleRoute := range staleRoutes {
+		r.deleteIPRoute(routeInfoRecord.vrf, staleRoute.destNetIp.String(), staleRoute.ipType, staleRoute.networkMask.String(), protocol,
+			staleRoute.nextHopIp.String(), staleRoute.nextHopIfIndex, FIBAndRIB, defs.RoutePolicyStateChangetoInValid)
+	}
+	return false
+}
+
+func isRouteStale(routeInfoList []RouteInfoRecord) bool {
+	for _, routeInfoRecord := range routeInfoList {
+		if routeInfoRecord.stale {
+			return true
+		}
+	}
+	return false
+}
@@ -33,7 +33,6 @@ import (
 	"net"
 	"ribd"
 	"ribdInt"
-	"sort"
 	"strconv"
 	"strings"
 	"utils/patriciaDB"
@@ -59,13 +58,9 @@ type PublisherMapInfo struct {
 	pub_socket *nanomsg.PubSocket
 }
 
-var RedistributeRouteMap map[string][]RedistributeRouteInfo
 var RedistributionPolicyMap map[string]RedistributionPolicyInfo
-var TrackReachabilityMap map[string][]string //map[ipAddr][]protocols
 var RouteProtocolTypeMapDB map[string]int
 var ReverseRouteProtoTypeMapDB map[int]string
-var ProtocolAdminDistanceMapDB map[string]RouteDistanceConfig
-var ProtocolAdminDistanceSlice AdminDistanceSlice
 var PublisherInfoMap map[string]PublisherMapInfo
 var RIBD_PUB *nanomsg.PubSocket
 var RIBD_POLICY_PUB *nanomsg.PubSocket
@@ -95,7 +90,7 @@ func BuildPublisherMap() {
 	RIBD_POLICY_PUB = InitPublisher(defs.PUB_SOCKET_POLICY_ADDR)
 	for k, _ := range RouteProtocolTypeMapDB {
 		logger.Info("Building publisher map for protocol ", k)
-		if k == "CONNECTED" || k == "STATIC" {
+		if k == "CONNECTED" || k == "STATIC" || k == "KERNEL" {
 			logger.Info("Publisher info for protocol ", k, " not required")
 			continue
 		}
@@ -119,6 +114,8 @@ func BuildRouteProtocolTypeMapDB() {
 	RouteProtocolTypeMapDB["BGP"] = defs.BGP
 	RouteProtocolTypeMapDB["OSPF"] = defs.OSPF
 	RouteProtocolTypeMapDB["STATIC"] = defs.STATIC
+	RouteProtocolTypeMapDB["KERNEL"] = defs.KERNEL
+	RouteProtocolTypeMapDB["RIP"] = defs.RIP
 
 	//reverse
 	ReverseRouteProtoTypeMapDB[defs.CONNECTED] = "CONNECTED"
@@ -127,13 +124,8 @@ func BuildRouteProtocolTypeMapDB() {
 	ReverseRouteProtoTypeMapDB[defs.BGP] = "BGP"
 	ReverseRouteProtoTypeMapDB[defs.STATIC] = "STATIC"
 	ReverseRouteProtoTypeMapDB[defs.OSPF] = "OSPF"
-}
-func BuildProtocolAdminDistanceMapDB() {
-	ProtocolAdminDistanceMapDB["CONNECTED"] = RouteDistanceConfig{defaultDistance: 0, configuredDistance: -1}
-	ProtocolAdminDistanceMapDB["STATIC"] = RouteDistanceConfig{defaultDistance: 1, configuredDistance: -1}
-	ProtocolAdminDistanceMapDB["EBGP"] = RouteDistanceConfig{defaultDistance: 20, configuredDistance: -1}
-	ProtocolAdminDistanceMapDB["IBGP"] = RouteDistanceConfig{defaultDistance: 200, configuredDistance: -1}
-	ProtocolAdminDistanceMapDB["OSPF"] = RouteDistanceConfig{defaultDistance: 110, configuredDistance: -1}
+	ReverseRouteProtoTypeMapDB[defs.KERNEL] = "KERNEL"
+	ReverseRouteProtoTypeMapDB[defs.RIP] = "RIP"
 }
 func (slice AdminDistanceSlice) Len() int {
 	return len(slice)
@@ -145,35 +137,11 @@ func (slice AdminDistanceSlice) Swap(i, j int) {
 	slice[i].Protocol, slice[j].Protocol = slice[j].Protocol, slice[i].Protocol
 	slice[i].Distance, slice[j].Distance = slice[j].Distance, slice[i].Distance
 }
-func BuildProtocolAdminDistanceSlice(force bool) {
-	distance := 0
-	protocol := ""
-	if ProtocolAdminDistanceSlice != nil && force == false {
-		//dont build it if it is already built
-		return
-	}
-	ProtocolAdminDistanceSlice = nil
-	ProtocolAdminDistanceSlice = make([]ribd.RouteDistanceState, 0)
-	if ProtocolAdminDistanceMapDB == nil {
-		ProtocolAdminDistanceMapDB = make(map[string]RouteDistanceConfig)
-		BuildProtocolAdminDistanceMapDB()
-	}
-	for k, v := range ProtocolAdminDistanceMapDB {
-		protocol = k
-		distance = v.defaultDistance
-		if v.configuredDistance != -1 {
-			distance = v.configuredDistance
-		}
-		routeDistance := ribd.RouteDistanceState{Protocol: protocol, Distance: int32(distance)}
-		ProtocolAdminDistanceSlice = append(ProtocolAdminDistanceSlice, routeDistance)
-	}
-	sort.Sort(ProtocolAdminDistanceSlice)
-}
 func (m RIBDServer) ConvertIntfStrToIfIndexStr(intfString string) (ifIndex string, err error) {
 	if val, err := strconv.Atoi(intfString); err == nil {
 		//Verify ifIndex is valid
 		//logger.Info("IfIndex = ", val)
-		_, ok := IntfIdNameMap[int32(val)]
+		_, ok := RouteServiceHandler.RIB.IntfEntry(int32(val))
 		if !ok {
 			logger.Err("Cannot create ip route on a unknown L3 interface")
 			return ifIndex, errors.New("Cannot create ip route on a unknown L3 interface")
@@ -181,10 +149,11 @@ func (m RIBDServer) ConvertIntfStrToIfIndexStr(intfString string) (ifIndex strin
 		ifIndex = intfString
 	} else {
 		//Verify ifName is valid
-		if _, ok := IfNameToIfIndex[intfString]; !ok {
+		idx, ok := RouteServiceHandler.RIB.IfIndex(intfString)
+		if !ok {
 			return ifIndex, errors.New("Invalid ifName value")
 		}
-		ifIndex = strconv.Itoa(int(IfNameToIfIndex[intfString]))
+		ifIndex = strconv.Itoa(int(idx))
 	}
 	return ifIndex, nil
 }
@@ -251,6 +220,7 @@ func buildPolicyEntityFromRoute(route ribdInt.Routes, params interface{}) (entit
 	//logger.Info("buildPolicyEntityFromRoute: destNetIp:", entity.DestNetIp)
 	entity.NextHopIp = route.NextHopIp
 	entity.RouteProtocol = ReverseRouteProtoTypeMapDB[int(route.Prototype)]
+	entity.RouteTag = routeInfo.tag
 	if routeInfo.createType != Invalid {
 		entity.CreatePath = true
 	}
@@ -269,6 +239,10 @@ func BuildRouteParamsFromRouteInoRecord(routeInfoRecord RouteInfoRecord) RoutePa
 	params.metric = routeInfoRecord.metric
 	params.nextHopIp = routeInfoRecord.nextHopIp.String()
 	params.nextHopIfIndex = routeInfoRecord.nextHopIfIndex
+	params.vrf = routeInfoRecord.vrf
+	params.sourceVrf = routeInfoRecord.sourceVrf
+	params.bfd = routeInfoRecord.bfd
+	params.tag = routeInfoRecord.tag
 	return params
 }
 func BuildRouteParamsFromribdIPv4Route(cfg *ribd.IPv4Route, createType int, deleteType int, sliceIdx ribd.Int) RouteParams {
@@ -289,6 +263,9 @@ func BuildRouteParamsFromribdIPv4Route(cfg *ribd.IPv4Route, createType int, dele
 		sliceIdx:       ribd.Int(sliceIdx),
 		createType:     ribd.Int(createType),
 		deleteType:     ribd.Int(deleteType),
+		vrf:            getVrfName(cfg.Vrf),
+		bfd:            cfg.NextHop[0].Bfd,
+		tag:            uint32(cfg.RouteTag),
 	}
 	return params
 }
@@ -310,6 +287,9 @@ func BuildRouteParamsFromribdIPv6Route(cfg *ribd.IPv6Route, createType int, dele
 		sliceIdx:       ribd.Int(sliceIdx),
 		createType:     ribd.Int(createType),
 		deleteType:     ribd.Int(deleteType),
+		vrf:            getVrfName(cfg.Vrf),
+		bfd:            cfg.NextHop[0].Bfd,
+		tag:            uint32(cfg.RouteTag),
 	}
 	return params
 }
@@ -328,6 +308,7 @@ func BuildPolicyRouteFromribdIPv4Route(cfg *ribd.IPv4Route) (policyRoute ribdInt
 		Weight:     ribdInt.Int(cfg.NextHop[0].Weight),
 		Metric:     ribdInt.Int(cfg.Cost),
 		Prototype:  ribdInt.Int(RouteProtocolTypeMapDB[cfg.Protocol]),
+		Vrf:        getVrfName(cfg.Vrf),
 	}
 	return policyRoute
 }
@@ -346,6 +327,7 @@ func BuildPolicyRouteFromribdIPv6Route(cfg *ribd.IPv6Route) (policyRoute ribdInt
 		Weight:     ribdInt.Int(cfg.NextHop[0].Weight),
 		Metric:     ribdInt.Int(cfg.Cost),
 		Prototype:  ribdInt.Int(RouteProtocolTypeMapDB[cfg.Protocol]),
+		Vrf:        getVrfName(cfg.Vrf),
 	}
 	return policyRoute
 }
@@ -387,7 +369,7 @@ func newNextHop(ipType defs.IPType, ip string, nextHopIfIndex ribd.Int, routeInf
 }
 func isSameRoute(selectedRoute ribdInt.Routes, route ribdInt.Routes) (same bool) {
 	//logger.Info("isSameRoute")
-	if selectedRoute.IPAddrType == route.IPAddrType && selectedRoute.Ipaddr == route.Ipaddr && selectedRoute.Mask == route.Mask && selectedRoute.Prototype == route.Prototype {
+	if selectedRoute.IPAddrType == route.IPAddrType && selectedRoute.Ipaddr == route.Ipaddr && selectedRoute.Mask == route.Mask && selectedRoute.Prototype == route.Prototype && getVrfName(selectedRoute.Vrf) == getVrfName(route.Vrf) {
 		same = true
 	}
 	return same
@@ -476,14 +458,14 @@ func updatePolicyRouteMap(route ribdInt.Routes, policy string, op int) {
 
 }
 
-func deleteRoutePolicyStateAll(route ribdInt.Routes) {
+func (r *RIB) deleteRoutePolicyStateAll(route ribdInt.Routes) {
 	//logger.Info("deleteRoutePolicyStateAll")
 	destNet, err := getNetowrkPrefixFromStrings(route.Ipaddr, route.Mask)
 	if err != nil {
 		return
 	}
 
-	routeInfoRecordListItem := RouteInfoMapGet(defs.IPType(route.IPAddrType), destNet)
+	routeInfoRecordListItem := r.RouteInfoMapGet(route.Vrf, defs.IPType(route.IPAddrType), destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Info(" entry not found for prefix %v", destNet)
 		return
@@ -491,17 +473,17 @@ func deleteRoutePolicyStateAll(route ribdInt.Routes) {
 	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
 	routeInfoRecordList.policyHitCounter = ribd.Int(route.PolicyHitCounter)
 	routeInfoRecordList.policyList = nil //append(routeInfoRecordList.policyList[:0])
-	RouteInfoMapSet(defs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
+	r.RouteInfoMapSet(route.Vrf, defs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
 	return
 }
-func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string) {
+func (r *RIB) addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string) {
 	//logger.Info("addRoutePolicyState for ", route.Ipaddr, ":", route.Mask, " ipType:", route.IPAddrType)
 	destNet, err := getNetowrkPrefixFromStrings(route.Ipaddr, route.Mask)
 	if err != nil {
 		return
 	}
 
-	routeInfoRecordListItem := RouteInfoMapGet(defs.IPType(route.IPAddrType), destNet)
+	routeInfoRecordListItem := r.RouteInfoMapGet(route.Vrf, defs.IPType(route.IPAddrType), destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected - entry not found for prefix ", destNet)
 		return
@@ -531,7 +513,7 @@ func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string)
 		policyStmtList = append(policyStmtList,policyStmt)
 	    routeInfoRecordList.policyList[policy] = policyStmtList*/
 	routeInfoRecordList.policyList = append(routeInfoRecordList.policyList, policy)
-	RouteInfoMapSet(defs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
+	r.RouteInfoMapSet(route.Vrf, defs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
 	//logger.Debug("Adding to DBRouteCh from addRoutePolicyState")
 	RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 		OrigConfigObject: RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList},
@@ -540,11 +522,11 @@ func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string)
 	//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList})
 	return
 }
-func deleteRoutePolicyState(ipType defs.IPType, ipPrefix patriciaDB.Prefix, policyName string) {
+func (r *RIB) deleteRoutePolicyState(vrf string, ipType defs.IPType, ipPrefix patriciaDB.Prefix, policyName string) {
 	//logger.Info("deleteRoutePolicyState")
 	found := false
 	idx := 0
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, ipPrefix)
+	routeInfoRecordListItem := r.RouteInfoMapGet(vrf, ipType, ipPrefix)
 	if routeInfoRecordListItem == nil {
 		logger.Info("routeInfoRecordListItem nil for prefix ", ipPrefix)
 		return
@@ -570,7 +552,7 @@ func deleteRoutePolicyState(ipType defs.IPType, ipPrefix patriciaDB.Prefix, poli
 	} else {
 		routeInfoRecordList.policyList = append(routeInfoRecordList.policyList[:idx], routeInfoRecordList.policyList[idx+1:]...)
 	}
-	RouteInfoMapSet(ipType, ipPrefix, routeInfoRecordList)
+	r.RouteInfoMapSet(vrf, ipType, ipPrefix, routeInfoRecordList)
 	//logger.Debug("Adding to DBRouteCh from deleteRoutePolicyState")
 	RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 		OrigConfigObject: RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList},
@@ -578,26 +560,26 @@ func deleteRoutePolicyState(ipType defs.IPType, ipPrefix patriciaDB.Prefix, poli
 	}
 }
 
-func updateRoutePolicyState(route ribdInt.Routes, op int, policy string, policyStmt string) {
+func (r *RIB) updateRoutePolicyState(route ribdInt.Routes, op int, policy string, policyStmt string) {
 	//logger.Info("updateRoutePolicyState")
 	if op == delAll {
-		deleteRoutePolicyStateAll(route)
+		r.deleteRoutePolicyStateAll(route)
 	} else if op == add {
-		addRoutePolicyState(route, policy, policyStmt)
+		r.addRoutePolicyState(route, policy, policyStmt)
 	}
 }
-func UpdateRedistributeTargetMap(evt int, protocol string, route ribdInt.Routes) {
+func (r *RIB) UpdateRedistributeTargetMap(evt int, protocol string, route ribdInt.Routes) {
 	//logger.Info("UpdateRedistributeTargetMap")
 	if evt == defs.NOTIFY_ROUTE_CREATED {
-		redistributeMapInfo := RedistributeRouteMap[protocol]
+		redistributeMapInfo := r.redistributeRouteMap[protocol]
 		if redistributeMapInfo == nil {
 			redistributeMapInfo = make([]RedistributeRouteInfo, 0)
 		}
 		redistributeRouteInfo := RedistributeRouteInfo{route: route}
 		redistributeMapInfo = append(redistributeMapInfo, redistributeRouteInfo)
-		RedistributeRouteMap[protocol] = redistributeMapInfo
+		r.redistributeRouteMap[protocol] = redistributeMapInfo
 	} else if evt == defs.NOTIFY_ROUTE_DELETED {
-		redistributeMapInfo := RedistributeRouteMap[protocol]
+		redistributeMapInfo := r.redistributeRouteMap[protocol]
 		if redistributeMapInfo != nil {
 			found := false
 			i := 0
@@ -615,7 +597,7 @@ func UpdateRedistributeTargetMap(evt int, protocol string, route ribdInt.Routes)
 					redistributeMapInfo = append(redistributeMapInfo[:i], redistributeMapInfo[i+1:]...)
 				}
 			}
-			RedistributeRouteMap[protocol] = redistributeMapInfo
+			r.redistributeRouteMap[protocol] = redistributeMapInfo
 		}
 	}
 }
@@ -623,7 +605,7 @@ func RedistributionNotificationSend(PUB *nanomsg.PubSocket, route ribdInt.Routes
 	//logger.Info("RedistributionNotificationSend")
 	msgBuf := defs.RoutelistInfo{RouteInfo: route}
 	msgbufbytes, err := json.Marshal(msgBuf)
-	msg := defs.RibdNotifyMsg{MsgType: uint16(evt), MsgBuf: msgbufbytes}
+	msg := defs.RibdNotifyMsg{Vrf: getVrfName(route.Vrf), MsgType: uint16(evt), MsgBuf: msgbufbytes}
 	buf, err := json.Marshal(msg)
 	if err != nil {
 		logger.Err("Error in marshalling Json")
@@ -645,6 +627,10 @@ func RedistributionNotificationSend(PUB *nanomsg.PubSocket, route ribdInt.Routes
 }
 func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteReachabilityStatusInfo) {
 	logger.Info("RouteReachabilityStatusNotificationSend for protocol ", targetProtocol)
+	if targetProtocol == PbrTrackProtocol {
+		RouteServiceHandler.ProcessPbrNextHopReachability(info)
+		return
+	}
 	publisherInfo, ok := PublisherInfoMap[targetProtocol]
 	if !ok {
 		logger.Info("Publisher not found for protocol ", targetProtocol)
@@ -653,6 +639,7 @@ func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteRe
 	evt := defs.NOTIFY_ROUTE_REACHABILITY_STATUS_UPDATE
 	PUB := publisherInfo.pub_socket
 	msgInfo := defs.RouteReachabilityStatusMsgInfo{}
+	msgInfo.Vrf = getVrfName(info.vrf)
 	msgInfo.Network = info.destNet
 	if info.status == "Up" || info.status == "Updated" {
 		msgInfo.IsReachable = true
@@ -660,7 +647,7 @@ func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteRe
 	msgInfo.NextHopIntf = info.nextHopIntf
 	msgBuf := msgInfo
 	msgbufbytes, err := json.Marshal(msgBuf)
-	msg := defs.RibdNotifyMsg{MsgType: uint16(evt), MsgBuf: msgbufbytes}
+	msg := defs.RibdNotifyMsg{Vrf: msgInfo.Vrf, MsgType: uint16(evt), MsgBuf: msgbufbytes}
 	buf, err := json.Marshal(msg)
 	if err != nil {
 		logger.Err("Error in marshalling Json")
@@ -673,7 +660,7 @@ func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteRe
 	//logger.Info("Adding  NOTIFY_ROUTE_REACHABILITY_STATUS_UPDATE with status ", info.status, " for network ", info.destNet, " to notification channel")
 	RouteServiceHandler.NotificationChannel <- NotificationMsg{PUB, buf, eventInfo}
 }
-func RouteReachabilityStatusUpdate(targetProtocol string, info RouteReachabilityStatusInfo) {
+func (r *RIB) RouteReachabilityStatusUpdate(targetProtocol string, info RouteReachabilityStatusInfo) {
 	logger.Info("RouteReachabilityStatusUpdate targetProtocol ", targetProtocol, " info:", info)
 	if targetProtocol != "NONE" {
 		RouteReachabilityStatusNotificationSend(targetProtocol, info)
@@ -693,15 +680,19 @@ func RouteReachabilityStatusUpdate(targetProtocol string, info RouteReachability
 		logger.Err("Error getting ip prefix for ip:", ipAddrStr, " mask:", ipMaskStr)
 		return
 	}
-	logger.Info("RouteReachabilityStatusUpdate(), destIpPrefix:", destIpPrefix)
-	//check the TrackReachabilityMap to see if any other protocols are interested in receiving updates for this network
-	for k, list := range TrackReachabilityMap {
+	logger.Info("r.RouteReachabilityStatusUpdate(), destIpPrefix:", destIpPrefix)
+	rib := r.Vrf(info.vrf)
+	if rib == nil {
+		return
+	}
+	//check the TrackReachabilityMap of the VRF to see if any other protocols are interested in receiving updates for this network
+	for k, list := range rib.trackReachabilityMap {
 		prefix, err := getNetowrkPrefixFromStrings(k, ipMaskStr)
 		if err != nil {
 			logger.Err("Error getting ip prefix for ip:", k, " mask:", ipMaskStr)
 			continue
 		}
-		logger.Info("RouteReachabilityStatusUpdate(), prefix:", prefix, " for k:", k, " list:", list)
+		logger.Info("r.RouteReachabilityStatusUpdate(), prefix:", prefix, " for k:", k, " list:", list)
 		if bytes.Equal(destIpPrefix, prefix) {
 			for idx := 0; idx < len(list); idx++ {
 				logger.Info(" protocol ", list[idx], " interested in receving reachability updates for ipAddr ", info.destNet)
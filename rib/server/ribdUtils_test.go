@@ -26,7 +26,9 @@ package server
 import (
 	"fmt"
 	//"net"
+	"ribd"
 	"testing"
+	"utils/policy"
 )
 
 func TestInitRtUtilsTestServer(t *testing.T) {
@@ -106,3 +108,26 @@ func TestGetPrefixLen(t *testing.T) {
 	fmt.Println("prefixLen,err:", prefixLen, ",", err, " for ip:", ip)
 	fmt.Println("**************************")
 }
+func TestRouteTag(t *testing.T) {
+	fmt.Println("****TestRouteTag****")
+	cfg := ribd.IPv4Route{
+		DestinationNw: "40.0.1.0",
+		NetworkMask:   "255.255.255.0",
+		Protocol:      "STATIC",
+		RouteTag:      -1,
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.2"}},
+	}
+	params := BuildRouteParamsFromribdIPv4Route(&cfg, FIBAndRIB, Invalid, 0)
+	if params.tag != 0xffffffff {
+		t.Error("Route tag ", params.tag, " not built from route tag ", cfg.RouteTag)
+	}
+	entity, err := buildPolicyEntityFromRoute(BuildPolicyRouteFromribdIPv4Route(&cfg), params)
+	if err != nil || entity.RouteTag != params.tag {
+		t.Error("Route tag ", entity.RouteTag, " err ", err, " in policy entity, expected ", params.tag)
+	}
+	stmt := policy.PolicyStmt{}
+	if tag := getPolicyStmtRouteTag(stmt, params.tag); tag != params.tag {
+		t.Error("Route tag changed to ", tag, " without a set tag action")
+	}
+	fmt.Println("***********************************")
+}
@@ -0,0 +1,370 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+
+// ribdVrfApis.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"sort"
+	"strconv"
+	"utils/patriciaDB"
+)
+
+const DefaultVrf = defs.DefaultVrf
+
+/*
+   Routing table of a VRF
+*/
+type VrfRIB struct {
+	name                 string
+	v4RouteInfoMap       *patriciaDB.Trie
+	v6RouteInfoMap       *patriciaDB.Trie
+	protocolRouteMap     map[string]PerProtocolRouteInfo
+	adminDistanceMap     map[string]RouteDistanceConfig
+	adminDistanceSlice   AdminDistanceSlice
+	trackReachabilityMap map[string][]string //map[ipAddr][]protocols
+	intfs                map[int32]bool      //not used for the default VRF, it has all the unbound interfaces
+}
+
+func newVrfRIB(name string, adminDistanceMap map[string]RouteDistanceConfig) *VrfRIB {
+	rib := &VrfRIB{
+		name:                 name,
+		v4RouteInfoMap:       patriciaDB.NewTrie(),
+		v6RouteInfoMap:       patriciaDB.NewTrie(),
+		protocolRouteMap:     make(map[string]PerProtocolRouteInfo),
+		adminDistanceMap:     make(map[string]RouteDistanceConfig),
+		trackReachabilityMap: make(map[string][]string),
+		intfs:                make(map[int32]bool),
+	}
+	for protocol, distance := range adminDistanceMap {
+		rib.adminDistanceMap[protocol] = distance
+	}
+	rib.adminDistanceSlice = buildAdminDistanceSlice(rib.adminDistanceMap)
+	return rib
+}
+
+func getVrfName(vrf string) string {
+	if vrf == "" {
+		return DefaultVrf
+	}
+	return vrf
+}
+
+func getVrfRIB(vrf string) *VrfRIB {
+	return RouteServiceHandler.RIB.Vrf(vrf)
+}
+
+func getRouteInfoMap(vrf string, ipType defs.IPType) *patriciaDB.Trie {
+	return RouteServiceHandler.RIB.routeInfoMap(vrf, ipType)
+}
+
+func getIntfVrf(ifIndex int32) string {
+	return RouteServiceHandler.RIB.IntfVrf(ifIndex)
+}
+
+/*
+   A configured route must name a VRF that exists
+*/
+func validateRouteVrf(vrf string) error {
+	if getVrfRIB(vrf) == nil {
+		return errors.New(fmt.Sprintln("vrf ", getVrfName(vrf), " not found"))
+	}
+	return nil
+}
+
+/*
+   The next hop interface of a route must be bound to the VRF of the route
+*/
+func validateNextHopIntfVrf(vrf string, ifIndex int32) error {
+	if intfVrf := getIntfVrf(ifIndex); intfVrf != getVrfName(vrf) {
+		return errors.New(fmt.Sprintln("next hop interface ", ifIndex, " is in vrf ", intfVrf, " not in vrf ", getVrfName(vrf)))
+	}
+	return nil
+}
+
+func (rib *VrfRIB) getAdminDistanceSlice() AdminDistanceSlice {
+	if rib == nil {
+		return nil
+	}
+	return rib.adminDistanceSlice
+}
+
+func (rib *VrfRIB) getAdminDistanceMap() map[string]RouteDistanceConfig {
+	if rib == nil {
+		return nil
+	}
+	return rib.adminDistanceMap
+}
+
+func buildAdminDistanceSlice(adminDistanceMap map[string]RouteDistanceConfig) AdminDistanceSlice {
+	slice := make(AdminDistanceSlice, 0)
+	for protocol, v := range adminDistanceMap {
+		distance := v.defaultDistance
+		if v.configuredDistance != -1 {
+			distance = v.configuredDistance
+		}
+		slice = append(slice, ribd.RouteDistanceState{Protocol: protocol, Distance: int32(distance)})
+	}
+	sort.Sort(slice)
+	return slice
+}
+
+/*
+   Number of routes in the VRF that were not learnt from its interfaces
+*/
+func (rib *VrfRIB) protocolRouteCount() (count int) {
+	for protocol, info := range rib.protocolRouteMap {
+		if protocol == "CONNECTED" {
+			continue
+		}
+		count += info.totalcount.totalcount
+	}
+	return count
+}
+
+func getIntfRouteCount(ifIndex int32) (count int) {
+	intfref := strconv.Itoa(int(ifIndex))
+	if intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(ifIndex); ok {
+		intfref = intfEntry.name
+	}
+	return RouteServiceHandler.RIB.interfaceRouteMap[intfref].totalcount.totalcount
+}
+
+func getIntfConnectedRoutes(ifIndex int32) (routes []*ribdInt.Routes) {
+	for _, route := range RouteServiceHandler.RIB.connectedRoutes {
+		if route.IfIndex == ribdInt.Int(ifIndex) {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func (m RIBDServer) getVrfIntfs(intfList []string) (intfs []int32, err error) {
+	for _, intf := range intfList {
+		ifIndexStr, err := m.ConvertIntfStrToIfIndexStr(intf)
+		if err != nil {
+			logger.Err("Invalid interface ", intf)
+			return intfs, err
+		}
+		ifIndex, _ := strconv.Atoi(ifIndexStr)
+		intfs = append(intfs, int32(ifIndex))
+	}
+	return intfs, err
+}
+
+/*
+   An interface can be moved to another VRF only when the connected routes of
+   its addresses are the only routes using it
+*/
+func validateVrfIntf(vrf string, ifIndex int32) error {
+	currVrf := getIntfVrf(ifIndex)
+	if currVrf != DefaultVrf && currVrf != vrf {
+		return errors.New(fmt.Sprintln("Interface ", ifIndex, " already bound to VRF ", currVrf))
+	}
+	if getIntfRouteCount(ifIndex) > len(getIntfConnectedRoutes(ifIndex)) {
+		return errors.New(fmt.Sprintln("Interface ", ifIndex, " is the next hop interface of routes, delete them before moving it to another VRF"))
+	}
+	return nil
+}
+
+func (m RIBDServer) VrfConfigValidationCheck(cfg *ribdInt.Vrf, op string) (err error) {
+	if cfg.VrfName == "" || cfg.VrfName == DefaultVrf {
+		return errors.New(fmt.Sprintln("Invalid VRF name ", cfg.VrfName))
+	}
+	rib, ok := RouteServiceHandler.RIB.vrfs[cfg.VrfName]
+	if op == "add" && ok {
+		return errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " already exists"))
+	}
+	if op != "add" && !ok {
+		return errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " not found"))
+	}
+	if op == "del" {
+		if isVrfRouteLeakConfigured(cfg.VrfName) {
+			return errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " has route leaks, delete them before deleting the VRF"))
+		}
+		if isPbrVrfConfigured(cfg.VrfName) {
+			return errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " is used by PBR policies, delete them before deleting the VRF"))
+		}
+		if rib.protocolRouteCount() > 0 {
+			return errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " has routes, delete them before deleting the VRF"))
+		}
+		return nil
+	}
+	intfs, err := m.getVrfIntfs(cfg.IntfList)
+	if err != nil {
+		return err
+	}
+	for _, ifIndex := range intfs {
+		if err = validateVrfIntf(cfg.VrfName, ifIndex); err != nil {
+			logger.Err("VrfConfigValidationCheck: ", err)
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+   Moves the connected routes of the interface to the RIB of the VRF the
+   interface is bound to. Routes of an interface that is down stay invalid.
+*/
+func (m RIBDServer) moveConnectedRoutes(ifIndex int32, vrf string) {
+	currVrf := getIntfVrf(ifIndex)
+	if currVrf == vrf {
+		return
+	}
+	routes := getIntfConnectedRoutes(ifIndex)
+	for _, route := range routes {
+		ipType := defs.IPv4
+		if ip := net.ParseIP(route.Ipaddr); ip != nil && ip.To4() == nil {
+			ipType = defs.IPv6
+		}
+		m.RIB.deleteIPRoute(currVrf, route.Ipaddr, ipType, route.Mask, "CONNECTED", route.NextHopIp, ribd.Int(ifIndex), FIBAndRIB, defs.RoutePolicyStateChangetoInValid)
+	}
+	if currVrf != DefaultVrf {
+		delete(RouteServiceHandler.RIB.vrfs[currVrf].intfs, ifIndex)
+	}
+	if vrf == DefaultVrf {
+		delete(RouteServiceHandler.RIB.intfVrfs, ifIndex)
+	} else {
+		RouteServiceHandler.RIB.intfVrfs[ifIndex] = vrf
+		RouteServiceHandler.RIB.vrfs[vrf].intfs[ifIndex] = true
+	}
+	for _, route := range routes {
+		nextHop := ribd.NextHopInfo{
+			NextHopIp:     route.NextHopIp,
+			NextHopIntRef: strconv.Itoa(int(ifIndex)),
+		}
+		if ip := net.ParseIP(route.Ipaddr); ip != nil && ip.To4() == nil {
+			cfg := ribd.IPv6Route{DestinationNw: route.Ipaddr, NetworkMask: route.Mask, Protocol: "CONNECTED"}
+			cfg.NextHop = []*ribd.NextHopInfo{&nextHop}
+			m.ProcessV6RouteCreateConfig(&cfg, FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
+			if !route.IsValid {
+				m.ProcessV6RouteDeleteConfig(&cfg, FIBOnly)
+			}
+		} else {
+			cfg := ribd.IPv4Route{DestinationNw: route.Ipaddr, NetworkMask: route.Mask, Protocol: "CONNECTED"}
+			cfg.NextHop = []*ribd.NextHopInfo{&nextHop}
+			m.ProcessV4RouteCreateConfig(&cfg, FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
+			if !route.IsValid {
+				m.ProcessV4RouteDeleteConfig(&cfg, FIBOnly)
+			}
+		}
+	}
+}
+
+func (m RIBDServer) ProcessVrfCreateConfig(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("ProcessVrfCreateConfig: VRF ", cfg.VrfName, " interfaces ", cfg.IntfList)
+	if _, ok := RouteServiceHandler.RIB.vrfs[cfg.VrfName]; ok {
+		return false, errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " already exists"))
+	}
+	RouteServiceHandler.RIB.vrfs[cfg.VrfName] = newVrfRIB(cfg.VrfName, getVrfRIB(DefaultVrf).adminDistanceMap)
+	intfs, err := m.getVrfIntfs(cfg.IntfList)
+	if err != nil {
+		return false, err
+	}
+	for _, ifIndex := range intfs {
+		m.moveConnectedRoutes(ifIndex, cfg.VrfName)
+	}
+	return true, nil
+}
+
+func (m RIBDServer) ProcessVrfDeleteConfig(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("ProcessVrfDeleteConfig: VRF ", cfg.VrfName)
+	rib, ok := RouteServiceHandler.RIB.vrfs[cfg.VrfName]
+	if !ok || cfg.VrfName == DefaultVrf {
+		return false, errors.New(fmt.Sprintln("VRF ", cfg.VrfName, " not found"))
+	}
+	for ifIndex, _ := range rib.intfs {
+		m.moveConnectedRoutes(ifIndex, DefaultVrf)
+	}
+	delete(RouteServiceHandler.RIB.vrfs, cfg.VrfName)
+	return true, nil
+}
+
+func (m RIBDServer) ProcessVrfUpdateConfig(origconfig *ribdInt.Vrf, newconfig *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("ProcessVrfUpdateConfig: VRF ", newconfig.VrfName, " interfaces ", newconfig.IntfList)
+	rib, ok := RouteServiceHandler.RIB.vrfs[newconfig.VrfName]
+	if !ok {
+		return false, errors.New(fmt.Sprintln("VRF ", newconfig.VrfName, " not found"))
+	}
+	intfs, err := m.getVrfIntfs(newconfig.IntfList)
+	if err != nil {
+		return false, err
+	}
+	newIntfs := make(map[int32]bool)
+	for _, ifIndex := range intfs {
+		newIntfs[ifIndex] = true
+	}
+	for ifIndex, _ := range rib.intfs {
+		if !newIntfs[ifIndex] {
+			m.moveConnectedRoutes(ifIndex, DefaultVrf)
+		}
+	}
+	for _, ifIndex := range intfs {
+		m.moveConnectedRoutes(ifIndex, newconfig.VrfName)
+	}
+	return true, nil
+}
+
+func (m RIBDServer) GetVrfState(vrf string) (*ribdInt.VrfState, error) {
+	rib := getVrfRIB(vrf)
+	if rib == nil {
+		return nil, errors.New(fmt.Sprintln("VRF ", vrf, " not found"))
+	}
+	state := ribdInt.NewVrfState()
+	state.VrfName = rib.name
+	intfs := rib.intfs
+	if rib.name == DefaultVrf {
+		intfs = make(map[int32]bool)
+		for _, route := range RouteServiceHandler.RIB.connectedRoutes {
+			if getIntfVrf(int32(route.IfIndex)) == DefaultVrf {
+				intfs[int32(route.IfIndex)] = true
+			}
+		}
+	}
+	state.IntfList = make([]string, 0)
+	for ifIndex, _ := range intfs {
+		intfref := strconv.Itoa(int(ifIndex))
+		if intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(ifIndex); ok {
+			intfref = intfEntry.name
+		}
+		state.IntfList = append(state.IntfList, intfref)
+	}
+	sort.Strings(state.IntfList)
+	for _, info := range rib.protocolRouteMap {
+		for _, count := range info.v4routeMap {
+			state.V4RouteCount += int32(count.totalcount)
+		}
+		for _, count := range info.v6routeMap {
+			state.V6RouteCount += int32(count.totalcount)
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,173 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdVrfApis_test.go
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	defs "l3/rib/ribdCommonDefs"
+	"ribd"
+	"ribdInt"
+	"strings"
+	"testing"
+)
+
+func TestVrfRIB(t *testing.T) {
+	fmt.Println("****TestVrfRIB****")
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	rib := NewRIB()
+	RouteServiceHandler = &RIBDServer{RIB: rib}
+	rib.vrfs["red"] = newVrfRIB("red", defaultAdminDistanceMap())
+	rib.intfVrfs[10] = "red"
+
+	if getIntfVrf(10) != "red" || getIntfVrf(11) != DefaultVrf {
+		t.Error("Unexpected interface vrf ", getIntfVrf(10), " ", getIntfVrf(11))
+	}
+	if getVrfRIB("") != rib.vrfs[DefaultVrf] || getVrfRIB("blue") != nil {
+		t.Error("Unexpected vrf RIB lookup")
+	}
+	if getRouteInfoMap("red", defs.IPv4) == rib.vrfs[DefaultVrf].v4RouteInfoMap || getRouteInfoMap("", defs.IPv6) != rib.vrfs[DefaultVrf].v6RouteInfoMap {
+		t.Error("Unexpected route table for vrf")
+	}
+	if getVrfPrefixKey("", "40.0.1.0/24") == getVrfPrefixKey("red", "40.0.1.0/24") {
+		t.Error("Prefix keys of different vrfs are the same")
+	}
+
+	if !rib.setAdminDistance("STATIC", 250) || rib.setAdminDistance("UNKNOWN", 250) {
+		t.Error("Unexpected result of setting the admin distance")
+	}
+	if rib.vrfs["red"].adminDistanceMap["STATIC"].configuredDistance != 250 {
+		t.Error("Admin distance of STATIC not updated for vrf red")
+	}
+	if slice := rib.vrfs["red"].getAdminDistanceSlice(); len(slice) == 0 || slice[len(slice)-1].Protocol != "STATIC" {
+		t.Error("Admin distance slice not rebuilt for vrf red ", slice)
+	}
+	fmt.Println("***********************************")
+}
+
+func TestVrfNotifyMsgFilter(t *testing.T) {
+	fmt.Println("****TestVrfNotifyMsgFilter****")
+	for _, vrf := range []string{DefaultVrf, "red"} {
+		buf, err := json.Marshal(defs.RibdNotifyMsg{Vrf: vrf, MsgType: defs.NOTIFY_ROUTE_CREATED})
+		if err != nil {
+			t.Error("Error marshalling notification ", err)
+			continue
+		}
+		fmt.Println("notification:", string(buf))
+		if !strings.HasPrefix(string(buf), defs.VrfNotifyMsgFilter(vrf)) {
+			t.Error("Notification of vrf ", vrf, " does not match filter ", defs.VrfNotifyMsgFilter(vrf))
+		}
+		if vrf != DefaultVrf && strings.HasPrefix(string(buf), defs.VrfNotifyMsgFilter(DefaultVrf)) {
+			t.Error("Notification of vrf ", vrf, " matches the default vrf filter")
+		}
+	}
+	fmt.Println("***********************************")
+}
+
+func TestVrfRouteLeak(t *testing.T) {
+	fmt.Println("****TestVrfRouteLeak****")
+	savedHandler, savedRouteLeakMap := RouteServiceHandler, RouteLeakMap
+	defer func() {
+		RouteServiceHandler, RouteLeakMap = savedHandler, savedRouteLeakMap
+	}()
+	RouteServiceHandler = &RIBDServer{RIB: NewRIB()}
+	RouteServiceHandler.RIB.vrfs["red"] = newVrfRIB("red", defaultAdminDistanceMap())
+	RouteLeakMap = make(map[string]RouteLeakInfo)
+	RouteLeakMap[getRouteLeakTarget("red", DefaultVrf)] = RouteLeakInfo{"red", DefaultVrf, "leakpolicy"}
+
+	dstVrfs := getRouteLeakDstVrfs("red")
+	if len(dstVrfs) != 1 || dstVrfs[0] != DefaultVrf {
+		t.Error("Unexpected leak destination vrfs of vrf red ", dstVrfs)
+	}
+	if len(getRouteLeakDstVrfs("")) != 0 {
+		t.Error("Unexpected leak destination vrfs of the default vrf ", getRouteLeakDstVrfs(""))
+	}
+	if !isVrfRouteLeakConfigured("red") {
+		t.Error("Route leak of vrf red not found")
+	}
+	leaked := RouteInfoRecord{vrf: DefaultVrf, sourceVrf: "red"}
+	if getRouteResolveVrf(leaked) != "red" || getRouteResolveVrf(RouteInfoRecord{}) != DefaultVrf {
+		t.Error("Unexpected next hop resolution vrf")
+	}
+
+	var server RIBDServer
+	if server.VrfRouteLeakConfigValidationCheck(&ribdInt.VrfRouteLeak{SrcVrf: "red", DstVrf: "red"}, "add") == nil {
+		t.Error("Route leak into the source vrf accepted")
+	}
+	if server.VrfRouteLeakConfigValidationCheck(&ribdInt.VrfRouteLeak{SrcVrf: "red", DstVrf: ""}, "add") == nil {
+		t.Error("Duplicate route leak accepted")
+	}
+	if server.VrfRouteLeakConfigValidationCheck(&ribdInt.VrfRouteLeak{SrcVrf: "", DstVrf: "red"}, "del") == nil {
+		t.Error("Delete of a route leak that does not exist accepted")
+	}
+	if server.VrfConfigValidationCheck(&ribdInt.Vrf{VrfName: "red"}, "del") == nil {
+		t.Error("Delete of vrf red with a route leak accepted")
+	}
+	fmt.Println("***********************************")
+}
+
+func TestVrfRouteConfig(t *testing.T) {
+	fmt.Println("****TestVrfRouteConfig****")
+	savedHandler := RouteServiceHandler
+	defer func() {
+		RouteServiceHandler = savedHandler
+	}()
+	rib := NewRIB()
+	RouteServiceHandler = &RIBDServer{RIB: rib}
+	rib.vrfs["red"] = newVrfRIB("red", defaultAdminDistanceMap())
+	rib.intfVrfs[10] = "red"
+
+	cfg := &ribd.IPv4Route{
+		DestinationNw: "40.0.1.0",
+		NetworkMask:   "255.255.255.0",
+		Protocol:      "STATIC",
+		Vrf:           "red",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "11"}},
+	}
+	if params := BuildRouteParamsFromribdIPv4Route(cfg, FIBAndRIB, Invalid, 0); params.vrf != "red" {
+		t.Error("Route of vrf red built in vrf ", params.vrf)
+	}
+	if policyRoute := BuildPolicyRouteFromribdIPv4Route(cfg); policyRoute.Vrf != "red" {
+		t.Error("Policy route of vrf red built in vrf ", policyRoute.Vrf)
+	}
+	cfg.Vrf = ""
+	if params := BuildRouteParamsFromribdIPv4Route(cfg, FIBAndRIB, Invalid, 0); params.vrf != DefaultVrf {
+		t.Error("Route without a vrf built in vrf ", params.vrf)
+	}
+
+	if validateRouteVrf("red") != nil || validateRouteVrf("") != nil || validateRouteVrf("blue") == nil {
+		t.Error("Unexpected result of the route vrf validation")
+	}
+	if validateNextHopIntfVrf("red", 10) != nil || validateNextHopIntfVrf("", 11) != nil {
+		t.Error("Next hop interface in the vrf of the route rejected")
+	}
+	if validateNextHopIntfVrf("red", 11) == nil || validateNextHopIntfVrf("", 10) == nil {
+		t.Error("Next hop interface in another vrf accepted")
+	}
+	fmt.Println("***********************************")
+}
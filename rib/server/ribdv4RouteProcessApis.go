@@ -41,12 +41,14 @@ import (
 	//"utils/policy/policyCommonDefs"
 )
 
-var V4RouteInfoMap *patriciaDB.Trie //Routes are stored in patricia trie
-
 /*
    Returns the longest prefix match route to reach the destination network destNet
 */
 func (m RIBDServer) GetV4RouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.RIB.getV4RouteReachabilityInfo(DefaultVrf, destNet, ifIndex)
+}
+
+func (r *RIB) getV4RouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
 	logger.Debug("GetV4RouteReachabilityInfo of ", destNet, " ifIndex:", ifIndex)
 	//t1 := time.Now()
 	var retnextHopIntf ribdInt.NextHopInfo
@@ -63,7 +65,11 @@ func (m RIBDServer) GetV4RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		return nextHopIntf, errors.New("Incorrect ip type lookup")
 	}
 	destNetIp = lookupIp
-	rmapInfoListItem := V4RouteInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
+	routeInfoMap := r.routeInfoMap(vrf, defs.IPv4)
+	if routeInfoMap == nil {
+		return nextHopIntf, errors.New(fmt.Sprintln("VRF ", vrf, " not found"))
+	}
+	rmapInfoListItem := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
 	if rmapInfoListItem != nil {
 		//fmt.Println("Madhavi!! GetV4RouteReachabilityInfo:, rmapInfoList not nil for ", destNetIp)
 		rmapInfoList := rmapInfoListItem.(RouteInfoRecordList)
@@ -123,7 +129,7 @@ func (m RIBDServer) GetV4RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		handle = routeInfoList data stored at this node
 		item - reachabilityInfo data formed with route that is modified and the state
 */
-func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the node being traversed
+func (r *RIB) UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the node being traversed
 	handle patriciaDB.Item, //data interface (routeInforRecordList) for this node
 	item patriciaDB.Item) /*RouteReachabilityStatusInfo data */ (err error) {
 
@@ -149,10 +155,15 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 	}
 	logger.Debug("UpdateRouteReachabilityStatus network: ", routeReachabilityStatusInfo.destNet, " status:", routeReachabilityStatusInfo.status, "ip: ", ip.String(), " destIPPrefix: ", destIpPrefix, " ipMaskStr:", ipMaskStr)
 	rmapInfoRecordList := handle.(RouteInfoRecordList)
+	routeInfoMap := r.routeInfoMap(rmapInfoRecordList.vrf, defs.IPv4)
 	//for each of the routes for this destination, check if the nexthop ip matches destPrefix - which is the route being modified
 	for k, v := range rmapInfoRecordList.routeInfoProtocolMap {
 		//logger.Debug("UpdateRouteReachabilityStatus - protocol: ", k)
 		for i := 0; i < len(v); i++ {
+			if getRouteResolveVrf(v[i]) != getVrfName(routeReachabilityStatusInfo.vrf) {
+				//next hop of the route is not in the VRF of the modified route
+				continue
+			}
 			if v[i].nextHopIpType != routeReachabilityStatusInfo.ipType {
 				logger.Debug("Skipping nexthop:", v[i].nextHopIp.String(), " since the nextHopIpType ", v[i].nextHopIpType, " not the same as ipType:", routeReachabilityStatusInfo.ipType)
 				continue
@@ -171,7 +182,7 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 				if routeReachabilityStatusInfo.status == "Down" && v[i].resolvedNextHopIpIntf.IsReachable == true {
 					v[i].resolvedNextHopIpIntf.IsReachable = false
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V4RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 1")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
@@ -179,34 +190,36 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
 					//logger.Debug("Bringing down route : ip: ", v[i].networkAddr)
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+					r.RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{v[i].vrf, string(prefix)}].refCount > 0 {
+						notifyNextHopGroups(v[i].vrf, -1, prefix, false)
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+						r.RouteInfoMapVisitAndUpdate(defs.IPv4, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					}
 				} else if routeReachabilityStatusInfo.status == "Up" && v[i].resolvedNextHopIpIntf.IsReachable == false {
 					//logger.Debug("Bringing up route : ip: ", v[i].networkAddr)
 					v[i].resolvedNextHopIpIntf.IsReachable = true
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V4RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 2")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
 						Op:               defs.Add,
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+					r.RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{v[i].vrf, string(prefix)}].refCount > 0 {
+						notifyNextHopGroups(v[i].vrf, -1, prefix, true)
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+						r.RouteInfoMapVisitAndUpdate(defs.IPv4, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					}
 				}
 			}
@@ -275,6 +288,10 @@ func (m RIBDServer) RouteConfigValidationCheckForUpdate(oldcfg *ribd.IPv4Route,
 					logger.Err("Cannot update null route attribute, please delete and create the route with the correct value")
 					return errors.New("Cannot update null route attribute, please delete and create the route with the correct value")
 				}
+				if objName == "Vrf" {
+					logger.Err("Cannot update the vrf of a route, please delete and create the route in the correct vrf")
+					return errors.New("Cannot update the vrf of a route, please delete and create the route in the correct vrf")
+				}
 				if objName == "NextHop" {
 					/*
 					   Next hop info is being updated
@@ -318,6 +335,9 @@ func (m RIBDServer) RouteConfigValidationCheckForUpdate(oldcfg *ribd.IPv4Route,
 								return errors.New("Invalid Nexthop Intref")
 							}
 						}
+						if err = validateNextHopBfd(oldcfg.Protocol, cfg.NextHop[i]); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -401,6 +421,9 @@ func (m RIBDServer) RouteConfigValidationCheckForPatchUpdate(oldcfg *ribd.IPv4Ro
 						logger.Err("Invalid NextHop IntRef ", val.NextHopIntRef)
 						return errors.New("Invalid NextHop Intref")
 					}
+					if err = validateNextHopBfd(oldcfg.Protocol, &val); err != nil {
+						return err
+					}
 					//logger.Debug("IntRef after : ", val.NextHopIntRef)
 				case "remove":
 					//logger.Debug("remove op"))
@@ -488,6 +511,10 @@ func (m RIBDServer) RouteConfigValidationCheck(cfg *ribd.IPv4Route, op string) (
 			err = errors.New("Invalid route protocol type")
 			return err
 		}
+		if err = validateRouteVrf(cfg.Vrf); err != nil {
+			logger.Err("RouteConfigValidationCheck for route:", cfg, " err:", err)
+			return err
+		}
 		if cfg.NullRoute == true {
 			logger.Debug("this is a null route, so dont validate nexthop attribute")
 			if cfg.NextHop == nil || len(cfg.NextHop) == 0 {
@@ -521,7 +548,7 @@ func (m RIBDServer) RouteConfigValidationCheck(cfg *ribd.IPv4Route, op string) (
 			*/
 			if cfg.NextHop[i].NextHopIntRef == "" {
 				//logger.Info("RouteConfigValidationCheck for route:", cfg, "NextHopIntRef not set")
-				nhIntf, err := RouteServiceHandler.GetV4RouteReachabilityInfo(cfg.NextHop[i].NextHopIp, -1)
+				nhIntf, err := m.RIB.getV4RouteReachabilityInfo(cfg.Vrf, cfg.NextHop[i].NextHopIp, -1)
 				if err != nil {
 					logger.Err("RouteConfigValidationCheck for route:", cfg, "next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable")
 					return errors.New(fmt.Sprintln("next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable"))
@@ -535,12 +562,19 @@ func (m RIBDServer) RouteConfigValidationCheck(cfg *ribd.IPv4Route, op string) (
 					return err
 				}
 				nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
-				_, err := RouteServiceHandler.GetV4RouteReachabilityInfo(cfg.NextHop[i].NextHopIp, ribdInt.Int(nextHopIntRef))
+				if err = validateNextHopIntfVrf(cfg.Vrf, int32(nextHopIntRef)); err != nil {
+					logger.Err("RouteConfigValidationCheck for route:", cfg, " err:", err)
+					return err
+				}
+				_, err := m.RIB.getV4RouteReachabilityInfo(cfg.Vrf, cfg.NextHop[i].NextHopIp, ribdInt.Int(nextHopIntRef))
 				if err != nil {
 					logger.Err("RouteConfigValidationCheck for route:", cfg, "next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable via interface ", nhIntf)
 					return errors.New(fmt.Sprintln("next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable via ", nhIntf))
 				}
 			}
+			if err = validateNextHopBfd(cfg.Protocol, cfg.NextHop[i]); err != nil {
+				return err
+			}
 			//logger.Debug("IntRef after : ", cfg.NextHop[i].NextHopIntRef)
 		}
 	} else {
@@ -559,7 +593,7 @@ func (m RIBDServer) RouteConfigValidationCheck(cfg *ribd.IPv4Route, op string) (
 }
 func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 	routes := make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := ProtocolRouteMap[protocol]
+	routemapInfo := getVrfRIB(DefaultVrf).protocolRouteMap[protocol]
 	if routemapInfo.v4routeMap == nil {
 		return routes
 	}
@@ -567,7 +601,7 @@ func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 		if val.totalcount == 0 {
 			continue
 		}
-		v4Item := V4RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v4Item := getRouteInfoMap(DefaultVrf, defs.IPv4).Get(patriciaDB.Prefix(destNetIp))
 		if v4Item == nil {
 			continue
 		}
@@ -590,7 +624,7 @@ func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 			destNet = v4protocolRouteList[sel].networkAddr
 			nextHopInfo[i].NextHopIp = v4protocolRouteList[sel].nextHopIp.String()
 			nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(v4protocolRouteList[sel].nextHopIfIndex))
-			intfEntry, ok := IntfIdNameMap[int32(v4protocolRouteList[sel].nextHopIfIndex)]
+			intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(v4protocolRouteList[sel].nextHopIfIndex))
 			if ok {
 				nextHopInfo[i].NextHopIntRef = intfEntry.name
 			}
@@ -608,15 +642,20 @@ func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 }
 func Getv4RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary {
 	routes := make([]string, 0) //[]*ribd.RouteInfoSummary, 0)
-	routemapInfo := InterfaceRouteMap[intfref]
+	routemapInfo := RouteServiceHandler.RIB.interfaceRouteMap[intfref]
 	if routemapInfo.v4routeMap == nil {
 		return routes
 	}
+	ifIndex, _ := RouteServiceHandler.RIB.IfIndex(intfref)
+	routeInfoMap := getRouteInfoMap(getIntfVrf(ifIndex), defs.IPv4)
+	if routeInfoMap == nil {
+		return routes
+	}
 	for destNetIp, val := range routemapInfo.v4routeMap {
 		if val.totalcount == 0 {
 			continue
 		}
-		v4Item := V4RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v4Item := routeInfoMap.Get(patriciaDB.Prefix(destNetIp))
 		if v4Item == nil {
 			continue
 		}
@@ -635,7 +674,7 @@ func Getv4RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary
 			destNet = v4protocolRouteList[sel].networkAddr
 			/*			nextHopInfo[i].NextHopIp = v4protocolRouteList[sel].nextHopIp.String()
 						nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(v4protocolRouteList[sel].nextHopIfIndex))
-						intfEntry, ok := IntfIdNameMap[int32(v4protocolRouteList[sel].nextHopIfIndex)]
+						intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(v4protocolRouteList[sel].nextHopIfIndex))
 						if ok {
 							nextHopInfo[i].NextHopIntRef = intfEntry.name
 						}
@@ -668,18 +707,18 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 	found := false
 	routes = &returnRouteGetInfo
 	moreRoutes := true
-	if destNetSlice == nil {
+	if RouteServiceHandler.RIB.destNetSlice == nil {
 		//logger.Debug("destNetSlice not initialized: No Routes installed in RIB")
 		return routes, err
 	}
 	for ; ; i++ {
 		found = false
-		if i+fromIndex >= ribd.Int(len(destNetSlice)) {
+		if i+fromIndex >= ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)) {
 			//logger.Debug("All the routes fetched")
 			moreRoutes = false
 			break
 		}
-		/*		if destNetSlice[i+fromIndex].isValid == false {
+		/*		if RouteServiceHandler.RIB.destNetSlice[i+fromIndex].isValid == false {
 				//logger.Debug("Invalid route")
 				continue
 			}*/
@@ -687,7 +726,11 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 			//logger.Debug("Enough routes fetched")
 			break
 		}
-		prefixNode := V4RouteInfoMap.Get(destNetSlice[i+fromIndex].prefix)
+		routeInfoMap := getRouteInfoMap(RouteServiceHandler.RIB.destNetSlice[i+fromIndex].vrf, defs.IPv4)
+		if routeInfoMap == nil {
+			continue
+		}
+		prefixNode := routeInfoMap.Get(RouteServiceHandler.RIB.destNetSlice[i+fromIndex].prefix)
 		if prefixNode != nil {
 			prefixNodeRouteList = prefixNode.(RouteInfoRecordList)
 			if prefixNodeRouteList.isPolicyBasedStateValid == false {
@@ -700,7 +743,7 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 			}
 			routeInfoList := prefixNodeRouteList.routeInfoProtocolMap[prefixNodeRouteList.selectedRouteProtocol]
 			for sel = 0; sel < len(routeInfoList); sel++ {
-				if routeInfoList[sel].nextHopIp.String() == destNetSlice[i+fromIndex].nextHopIp {
+				if routeInfoList[sel].nextHopIp.String() == RouteServiceHandler.RIB.destNetSlice[i+fromIndex].nextHopIp {
 					//logger.Debug("Found the entry corresponding to the nextHop ip")
 					found = true
 					break
@@ -716,6 +759,7 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 			nextRoute.RouteCreatedTime = prefixNodeRoute.routeCreatedTime
 			nextRoute.RouteUpdatedTime = prefixNodeRoute.routeUpdatedTime
 			nextRoute.IsNetworkReachable = prefixNodeRoute.resolvedNextHopIpIntf.IsReachable
+			nextRoute.IsStale = prefixNodeRoute.stale
 			nextRoute.PolicyList = make([]string, 0)
 			routePolicyListInfo := ""
 			if prefixNodeRouteList.policyList != nil {
@@ -759,6 +803,10 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 }
 
 func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState, err error) {
+	return m.GetVrfv4Route(DefaultVrf, destNetIp)
+}
+
+func (m RIBDServer) GetVrfv4Route(vrf string, destNetIp string) (route *ribdInt.IPv4RouteState, err error) {
 	var returnRoute ribdInt.IPv4RouteState
 	route = &returnRoute
 	/*
@@ -768,7 +816,7 @@ func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState,
 	if err != nil {
 		return route, errors.New("Invalid destination ip/network Mask")
 	}
-	routeInfoRecordListItem := V4RouteInfoMap.Get(destNet)
+	routeInfoRecordListItem := m.RIB.RouteInfoMapGet(vrf, defs.IPv4, destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Err("No such route")
 		err = errors.New("Route does not exist")
@@ -788,7 +836,7 @@ func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState,
 		routeInfoRecord := nh
 		nextHopInfo[i].NextHopIp = routeInfoRecord.nextHopIp.String()
 		nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(routeInfoRecord.nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(routeInfoRecord.nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(routeInfoRecord.nextHopIfIndex))
 		if ok {
 			//logger.Debug("Map found for ifndex : ", routeInfoRecord.nextHopIfIndex, "Name = ", intfEntry.name)
 			nextHopInfo[i].NextHopIntRef = intfEntry.name
@@ -802,10 +850,14 @@ func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState,
 	routeInfoRecord := routeInfoList[0]
 	route.DestinationNw = routeInfoRecord.networkAddr
 	route.Protocol = routeInfoRecordList.selectedRouteProtocol
+	route.Vrf = getVrfName(routeInfoRecordList.vrf)
+	route.SourceVrf = routeInfoRecord.sourceVrf
+	route.IsStale = routeInfoRecord.stale
+	route.RouteTag = ribdInt.Int(routeInfoRecord.tag)
 	route.RouteCreatedTime = routeInfoRecord.routeCreatedTime
 	route.RouteUpdatedTime = routeInfoRecord.routeUpdatedTime
 	route.NextBestRoute = &ribdInt.NextBestRouteInfo{}
-	route.NextBestRoute.Protocol = SelectNextBestRoute(routeInfoRecordList, routeInfoRecordList.selectedRouteProtocol)
+	route.NextBestRoute.Protocol = RouteServiceHandler.RIB.SelectNextBestRoute(routeInfoRecordList, routeInfoRecordList.selectedRouteProtocol)
 	nextbestrouteInfoList := routeInfoRecordList.routeInfoProtocolMap[route.NextBestRoute.Protocol]
 	//logger.Info("len of routeInfoList - ", len(routeInfoList), "selected route protocol = ", routeList.selectedRouteProtocol, " route Protocol: ", entry.protocol, " route nwAddr: ", entry.networkAddr)
 	nextBestRouteNextHopInfo := make([]ribdInt.RouteNextHopInfo, len(nextbestrouteInfoList))
@@ -814,7 +866,7 @@ func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState,
 		//logger.Info("nextHop ", sel, " weight = ", routeInfoList[sel].weight, " ip ", routeInfoList[sel].nextHopIp, " intref ", routeInfoList[sel].nextHopIfIndex)
 		nextBestRouteNextHopInfo[i1].NextHopIp = nextbestrouteInfoList[sel1].nextHopIp.String()
 		nextBestRouteNextHopInfo[i1].NextHopIntRef = strconv.Itoa(int(nextbestrouteInfoList[sel1].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(nextbestrouteInfoList[sel1].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(nextbestrouteInfoList[sel1].nextHopIfIndex))
 		if ok {
 			//logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
 			nextBestRouteNextHopInfo[i1].NextHopIntRef = intfEntry.name
@@ -827,15 +879,15 @@ func (m RIBDServer) Getv4Route(destNetIp string) (route *ribdInt.IPv4RouteState,
 	return route, err
 }
 func (m RIBDServer) GetTotalv4RouteCount() (number int, err error) {
-	return v4rtCount, err
+	return RouteServiceHandler.RIB.v4RouteCount, err
 }
 func (m RIBDServer) Getv4RouteCreatedTime(number int) (time string, err error) {
-	_, ok := v4routeCreatedTimeMap[number]
+	_, ok := RouteServiceHandler.RIB.v4RouteCreatedTime[number]
 	if !ok {
 		logger.Info(number, " number of  v4 routes not created yet")
 		return "", errors.New("Not enough v4 routes")
 	}
-	return v4routeCreatedTimeMap[number], err
+	return RouteServiceHandler.RIB.v4RouteCreatedTime[number], err
 }
 
 func (m RIBDServer) ProcessV4RouteCreateConfig(cfg *ribd.IPv4Route, addType int, sliceIdx ribd.Int) (val bool, err error) {
@@ -846,6 +898,8 @@ func (m RIBDServer) ProcessV4RouteCreateConfig(cfg *ribd.IPv4Route, addType int,
 		Protocol:      cfg.Protocol,
 		Cost:          cfg.Cost,
 		NullRoute:     cfg.NullRoute,
+		RouteTag:      cfg.RouteTag,
+		Vrf:           cfg.Vrf,
 	}
 	for i := 0; i < len(cfg.NextHop); i++ {
 		logger.Debug("nexthop info: ip: ", cfg.NextHop[i].NextHopIp, " intref: ", cfg.NextHop[i].NextHopIntRef)
@@ -853,12 +907,13 @@ func (m RIBDServer) ProcessV4RouteCreateConfig(cfg *ribd.IPv4Route, addType int,
 			NextHopIp:     cfg.NextHop[i].NextHopIp,
 			NextHopIntRef: cfg.NextHop[i].NextHopIntRef,
 			Weight:        cfg.NextHop[i].Weight,
+			Bfd:           cfg.NextHop[i].Bfd,
 		}
 		newCfg.NextHop = make([]*ribd.NextHopInfo, 0)
 		newCfg.NextHop = append(newCfg.NextHop, &nh)
 		//policyRoute := BuildPolicyRouteFromribdIPv4Route(&newCfg)
 		params := BuildRouteParamsFromribdIPv4Route(&newCfg, addType, Invalid, sliceIdx)
-		_, err = createRoute(params)
+		_, err = m.RIB.createRoute(params)
 	}
 
 	return true, err
@@ -875,6 +930,8 @@ func (m RIBDServer) ProcessBulkRouteCreateConfig(bulkCfg []*ribdInt.IPv4RouteCon
 			Protocol:      cfg.Protocol,
 			Cost:          cfg.Cost,
 			NullRoute:     cfg.NullRoute,
+			RouteTag:      cfg.RouteTag,
+			Vrf:           cfg.Vrf,
 		}
 		for i := 0; i < len(cfg.NextHop); i++ {
 			logger.Debug("nexthop info: ip: ", cfg.NextHop[i].NextHopIp, " intref: ", cfg.NextHop[i].NextHopIntRef)
@@ -888,7 +945,7 @@ func (m RIBDServer) ProcessBulkRouteCreateConfig(bulkCfg []*ribdInt.IPv4RouteCon
 		}
 
 		//policyRoute := BuildPolicyRouteFromribdIPv4Route(&newCfg)
-		params := BuildRouteParamsFromribdIPv4Route(&newCfg, FIBAndRIB, Invalid, ribd.Int(len(destNetSlice)))
+		params := BuildRouteParamsFromribdIPv4Route(&newCfg, FIBAndRIB, Invalid, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
 		params.bulk = true
 		index++
 		if index == len(bulkCfg) {
@@ -896,7 +953,7 @@ func (m RIBDServer) ProcessBulkRouteCreateConfig(bulkCfg []*ribdInt.IPv4RouteCon
 		}
 		//logger.Debug("createType = ", params.createType, "deleteType = ", params.deleteType, "index:", index, " bulk:", params.bulk, " bulkEnd:", params.bulkEnd))
 		//PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Import, params)
-		_, err = createRoute(params)
+		_, err = m.RIB.createRoute(params)
 	}
 
 	return true, err
@@ -925,7 +982,7 @@ func (m RIBDServer) ProcessV4RouteDeleteConfig(cfg *ribd.IPv4Route, delType int)
 			nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
 			nextHopIfIndex = ribd.Int(nextHopIntRef)
 		}
-		_, err = deleteIPRoute(cfg.DestinationNw, defs.IPv4, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), defs.RoutePolicyStateChangetoInValid)
+		_, err = m.RIB.deleteIPRoute(cfg.Vrf, cfg.DestinationNw, defs.IPv4, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), defs.RoutePolicyStateChangetoInValid)
 	}
 	return true, err
 }
@@ -941,7 +998,11 @@ func (m RIBDServer) Processv4RoutePatchUpdateConfig(origconfig *ribd.IPv4Route,
 		logger.Err(" getNetowrkPrefixFromStrings returned err ", err)
 		return ret, err
 	}
-	ok := V4RouteInfoMap.Match(destNet)
+	routeInfoMap := getRouteInfoMap(origconfig.Vrf, defs.IPv4)
+	if routeInfoMap == nil {
+		return ret, errors.New(fmt.Sprintln("No route found for ip ", destNet))
+	}
+	ok := routeInfoMap.Match(destNet)
 	if !ok {
 		err = errors.New("Processv4RoutePatchUpdateConfig:No route found")
 		return ret, err
@@ -974,12 +1035,13 @@ func (m RIBDServer) Processv4RoutePatchUpdateConfig(origconfig *ribd.IPv4Route,
 					NextHopIp:     val.NextHopIp,
 					NextHopIntRef: val.NextHopIntRef,
 					Weight:        val.Weight,
+					Bfd:           val.Bfd,
 				}
 				newconfig.NextHop = append(newconfig.NextHop, &nh)
 			}
 			switch op[idx].Op {
 			case "add":
-				m.ProcessV4RouteCreateConfig(newconfig, FIBAndRIB, ribd.Int(len(destNetSlice)))
+				m.ProcessV4RouteCreateConfig(newconfig, FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
 			case "remove":
 				m.ProcessV4RouteDeleteConfig(newconfig, FIBAndRIB)
 			default:
@@ -1004,12 +1066,16 @@ func (m RIBDServer) Processv4RouteUpdateConfig(origconfig *ribd.IPv4Route, newco
 		logger.Err(" getNetowrkPrefixFromStrings returned err ", err)
 		return val, err
 	}
-	ok := V4RouteInfoMap.Match(destNet)
+	routeInfoMap := getRouteInfoMap(origconfig.Vrf, defs.IPv4)
+	if routeInfoMap == nil {
+		return val, errors.New(fmt.Sprintln("No route found for ip ", destNet))
+	}
+	ok := routeInfoMap.Match(destNet)
 	if !ok {
 		err = errors.New(fmt.Sprintln("No route found for ip ", destNet))
 		return val, err
 	}
-	routeInfoRecordListItem := V4RouteInfoMap.Get(destNet)
+	routeInfoRecordListItem := routeInfoMap.Get(destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Err("No route for destination network", destNet)
 		return val, err
@@ -1038,21 +1104,27 @@ func (m RIBDServer) Processv4RouteUpdateConfig(origconfig *ribd.IPv4Route, newco
 							return val, errors.New("Invalid next hop")
 						}
 						//logger.Debug("Update the next hop info old ip: ", origconfig.NextHop[0].NextHopIp, " new value: ", newconfig.NextHop[0].NextHopIp, " weight : ", newconfig.NextHop[0].Weight)
+						m.RIB.untrackBfdNextHop(routeInfoRecord)
 						routeInfoRecord.nextHopIp = nextHopIpAddr
 						routeInfoRecord.weight = ribd.Int(newconfig.NextHop[0].Weight)
+						routeInfoRecord.bfd = newconfig.NextHop[0].Bfd
 						if newconfig.NextHop[0].NextHopIntRef != "" {
 							nextHopIntRef, _ := strconv.Atoi(newconfig.NextHop[0].NextHopIntRef)
 							routeInfoRecord.nextHopIfIndex = ribd.Int(nextHopIntRef)
 						}
+						m.RIB.trackBfdNextHop(routeInfoRecord)
 					}
 				}
 				if objName == "Cost" {
 					routeInfoRecord.metric = ribd.Int(newconfig.Cost)
 				}
+				if objName == "RouteTag" {
+					routeInfoRecord.tag = uint32(newconfig.RouteTag)
+				}
 			}
 		}
 		routeInfoRecordList.routeInfoProtocolMap[origconfig.Protocol][index] = routeInfoRecord
-		V4RouteInfoMap.Set(destNet, routeInfoRecordList)
+		routeInfoMap.Set(destNet, routeInfoRecordList)
 		//logger.Debug("Adding to DBRouteCh from processRouteUpdateConfig")
 		RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 			OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
@@ -1062,6 +1134,6 @@ func (m RIBDServer) Processv4RouteUpdateConfig(origconfig *ribd.IPv4Route, newco
 			return val, err
 		}
 	}
-	updateBestRoute(destNet, routeInfoRecordList)
+	m.RIB.updateBestRoute(destNet, routeInfoRecordList)
 	return val, err
 }
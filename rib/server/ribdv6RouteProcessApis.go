@@ -19,12 +19,14 @@ import (
 	//"utils/policy/policyCommonDefs"
 )
 
-var V6RouteInfoMap *patriciaDB.Trie //Routes are stored in patricia trie
-
 /*
    Returns the longest prefix match route to reach the destination network destNet
 */
 func (m RIBDServer) GetV6RouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.RIB.getV6RouteReachabilityInfo(DefaultVrf, destNet, ifIndex)
+}
+
+func (r *RIB) getV6RouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
 	//logger.Debug("GetRouteReachabilityInfo of ", destNet)
 	//t1 := time.Now()
 	var retnextHopIntf ribdInt.NextHopInfo
@@ -41,7 +43,11 @@ func (m RIBDServer) GetV6RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		return nextHopIntf, errors.New("Invalid dest ip address")
 	}
 	destNetIp = lookupIp
-	rmapInfoListItem := V6RouteInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
+	routeInfoMap := r.routeInfoMap(vrf, defs.IPv6)
+	if routeInfoMap == nil {
+		return nextHopIntf, errors.New(fmt.Sprintln("VRF ", vrf, " not found"))
+	}
+	rmapInfoListItem := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
 	if rmapInfoListItem != nil {
 		rmapInfoList := rmapInfoListItem.(RouteInfoRecordList)
 		if rmapInfoList.selectedRouteProtocol != "INVALID" {
@@ -83,7 +89,7 @@ func (m RIBDServer) GetV6RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		handle = routeInfoList data stored at this node
 		item - reachabilityInfo data formed with route that is modified and the state
 */
-func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the node being traversed
+func (r *RIB) UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the node being traversed
 	handle patriciaDB.Item, //data interface (routeInforRecordList) for this node
 	item patriciaDB.Item) /*RouteReachabilityStatusInfo data of the v6 route that is being checked with*/ (err error) {
 
@@ -109,10 +115,15 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 	}
 	//logger.Debug("UpdateRouteReachabilityStatus network: ", routeReachabilityStatusInfo.destNet, " status:", routeReachabilityStatusInfo.status, "ip: ", ip.String(), " destIPPrefix: ", destIpPrefix, " ipMaskStr:", ipMaskStr)
 	rmapInfoRecordList := handle.(RouteInfoRecordList)
+	routeInfoMap := r.routeInfoMap(rmapInfoRecordList.vrf, defs.IPv6)
 	//for each of the routes for this destination, check if the nexthop ip matches destPrefix - which is the route being modified
 	for k, v := range rmapInfoRecordList.routeInfoProtocolMap {
 		//logger.Debug("UpdateRouteReachabilityStatus - protocol: ", k)
 		for i := 0; i < len(v); i++ {
+			if getRouteResolveVrf(v[i]) != getVrfName(routeReachabilityStatusInfo.vrf) {
+				//next hop of the route is not in the VRF of the modified route
+				continue
+			}
 			if v[i].nextHopIpType != routeReachabilityStatusInfo.ipType {
 				logger.Debug("Skipping nexthop:", v[i].nextHopIp.String(), " since the nextHopIpType ", v[i].nextHopIpType, " not the same as ipType:", routeReachabilityStatusInfo.ipType)
 				continue
@@ -131,7 +142,7 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 				if routeReachabilityStatusInfo.status == "Down" && v[i].resolvedNextHopIpIntf.IsReachable == true {
 					v[i].resolvedNextHopIpIntf.IsReachable = false
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V6RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 1")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
@@ -139,34 +150,36 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
 					//logger.Debug("Bringing down route : ip: ", v[i].networkAddr)
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+					r.RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{v[i].vrf, string(prefix)}].refCount > 0 {
+						notifyNextHopGroups(v[i].vrf, -1, prefix, false)
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+						r.RouteInfoMapVisitAndUpdate(defs.IPv6, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					}
 				} else if routeReachabilityStatusInfo.status == "Up" && v[i].resolvedNextHopIpIntf.IsReachable == false {
 					//logger.Debug("Bringing up route : ip: ", v[i].networkAddr)
 					v[i].resolvedNextHopIpIntf.IsReachable = true
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V6RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 2")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
 						Op:               defs.Add,
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+					r.RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{v[i].vrf, string(prefix)}].refCount > 0 {
+						notifyNextHopGroups(v[i].vrf, -1, prefix, true)
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+						r.RouteInfoMapVisitAndUpdate(defs.IPv6, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					}
 				}
 			}
@@ -235,6 +248,10 @@ func (m RIBDServer) IPv6RouteConfigValidationCheckForUpdate(oldcfg *ribd.IPv6Rou
 					logger.Err("Cannot update null route attribute, please delete and create the route with the correct value")
 					return errors.New("Cannot update null route attribute, please delete and create the route with the correct value")
 				}
+				if objName == "Vrf" {
+					logger.Err("Cannot update the vrf of a route, please delete and create the route in the correct vrf")
+					return errors.New("Cannot update the vrf of a route, please delete and create the route in the correct vrf")
+				}
 				if objName == "NextHop" {
 					/*
 					   Next hop info is being updated
@@ -278,6 +295,9 @@ func (m RIBDServer) IPv6RouteConfigValidationCheckForUpdate(oldcfg *ribd.IPv6Rou
 								return errors.New("Invalid Nexthop Intref")
 							}
 						}
+						if err = validateNextHopBfd(oldcfg.Protocol, cfg.NextHop[i]); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -374,6 +394,9 @@ func (m RIBDServer) IPv6RouteConfigValidationCheckForPatchUpdate(oldcfg *ribd.IP
 							return err
 						}
 					}
+					if err = validateNextHopBfd(oldcfg.Protocol, &val); err != nil {
+						return err
+					}
 					logger.Debug(fmt.Sprintln("IntRef after : ", val.NextHopIntRef))
 				case "remove":
 					logger.Debug(fmt.Sprintln("remove op"))
@@ -462,6 +485,10 @@ func (m RIBDServer) IPv6RouteConfigValidationCheck(cfg *ribd.IPv6Route, op strin
 			err = errors.New("Invalid route protocol type")
 			return err
 		}
+		if err = validateRouteVrf(cfg.Vrf); err != nil {
+			logger.Err("IPv6RouteConfigValidationCheck for route:", cfg, " err:", err)
+			return err
+		}
 		logger.Debug(fmt.Sprintln("Number of nexthops = ", len(cfg.NextHop)))
 		if cfg.NullRoute == true {
 			logger.Debug("this is a null route, so dont validate nexthop attribute")
@@ -495,7 +522,7 @@ func (m RIBDServer) IPv6RouteConfigValidationCheck(cfg *ribd.IPv6Route, op strin
 			*/
 			if cfg.NextHop[i].NextHopIntRef == "" {
 				logger.Info(fmt.Sprintln("NextHopIntRef not set"))
-				nhIntf, err := RouteServiceHandler.GetRouteReachabilityInfo(cfg.NextHop[i].NextHopIp, -1)
+				nhIntf, err := RouteServiceHandler.GetVrfRouteReachabilityInfo(cfg.Vrf, cfg.NextHop[i].NextHopIp, -1)
 				if err != nil {
 					logger.Err(fmt.Sprintln("next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable"))
 					return errors.New(fmt.Sprintln("next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable"))
@@ -509,12 +536,19 @@ func (m RIBDServer) IPv6RouteConfigValidationCheck(cfg *ribd.IPv6Route, op strin
 					return err
 				}
 				nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
-				_, err := RouteServiceHandler.GetRouteReachabilityInfo(cfg.NextHop[i].NextHopIp, ribdInt.Int(nextHopIntRef))
+				if err = validateNextHopIntfVrf(cfg.Vrf, int32(nextHopIntRef)); err != nil {
+					logger.Err("IPv6RouteConfigValidationCheck for route:", cfg, " err:", err)
+					return err
+				}
+				_, err := RouteServiceHandler.GetVrfRouteReachabilityInfo(cfg.Vrf, cfg.NextHop[i].NextHopIp, ribdInt.Int(nextHopIntRef))
 				if err != nil {
 					logger.Err("RouteConfigValidationCheck for route:", cfg, "next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable via interface ", nhIntf)
 					return errors.New(fmt.Sprintln("next hop ip ", cfg.NextHop[i].NextHopIp, " not reachable via ", nhIntf))
 				}
 			}
+			if err = validateNextHopBfd(cfg.Protocol, cfg.NextHop[i]); err != nil {
+				return err
+			}
 			//logger.Debug(fmt.Sprintln("IntRef after : ", cfg.NextHop[i].NextHopIntRef))
 		}
 	} else {
@@ -532,19 +566,19 @@ func (m RIBDServer) IPv6RouteConfigValidationCheck(cfg *ribd.IPv6Route, op strin
 	return nil
 }
 func (m RIBDServer) GetTotalv6RouteCount() (number int, err error) {
-	return v6rtCount, err
+	return RouteServiceHandler.RIB.v6RouteCount, err
 }
 func (m RIBDServer) Getv6RouteCreatedTime(number int) (time string, err error) {
-	_, ok := v6routeCreatedTimeMap[number]
+	_, ok := RouteServiceHandler.RIB.v6RouteCreatedTime[number]
 	if !ok {
 		logger.Info(number, " number of  v6 routes not created yet")
 		return "", errors.New("Not enough v6 routes")
 	}
-	return v6routeCreatedTimeMap[number], err
+	return RouteServiceHandler.RIB.v6RouteCreatedTime[number], err
 }
 func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 	v6routes := make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := ProtocolRouteMap[protocol]
+	routemapInfo := getVrfRIB(DefaultVrf).protocolRouteMap[protocol]
 	if routemapInfo.v6routeMap == nil {
 		return v6routes
 	}
@@ -552,7 +586,7 @@ func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 		if val.totalcount == 0 {
 			continue
 		}
-		v6Item := V6RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v6Item := getRouteInfoMap(DefaultVrf, defs.IPv6).Get(patriciaDB.Prefix(destNetIp))
 		if v6Item == nil {
 			continue
 		}
@@ -574,7 +608,7 @@ func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 			v6destNet = v6protocolRouteList[sel1].networkAddr
 			v6nextHopInfo[j].NextHopIp = v6protocolRouteList[sel1].nextHopIp.String()
 			v6nextHopInfo[j].NextHopIntRef = strconv.Itoa(int(v6protocolRouteList[sel1].nextHopIfIndex))
-			intfEntry, ok := IntfIdNameMap[int32(v6protocolRouteList[sel1].nextHopIfIndex)]
+			intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(v6protocolRouteList[sel1].nextHopIfIndex))
 			if ok {
 				v6nextHopInfo[j].NextHopIntRef = intfEntry.name
 			}
@@ -592,15 +626,20 @@ func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 }
 func Getv6RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary {
 	v6routes := make([]string, 0) //make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := InterfaceRouteMap[intfref]
+	routemapInfo := RouteServiceHandler.RIB.interfaceRouteMap[intfref]
 	if routemapInfo.v6routeMap == nil {
 		return v6routes
 	}
+	ifIndex, _ := RouteServiceHandler.RIB.IfIndex(intfref)
+	routeInfoMap := getRouteInfoMap(getIntfVrf(ifIndex), defs.IPv6)
+	if routeInfoMap == nil {
+		return v6routes
+	}
 	for destNetIp, val := range routemapInfo.v6routeMap {
 		if val.totalcount == 0 {
 			continue
 		}
-		v6Item := V6RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v6Item := routeInfoMap.Get(patriciaDB.Prefix(destNetIp))
 		if v6Item == nil {
 			continue
 		}
@@ -619,7 +658,7 @@ func Getv6RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary
 			v6destNet = v6protocolRouteList[sel1].networkAddr
 			/*		v6nextHopInfo[j].NextHopIp = v6protocolRouteList[sel1].nextHopIp.String()
 					v6nextHopInfo[j].NextHopIntRef = strconv.Itoa(int(v6protocolRouteList[sel1].nextHopIfIndex))
-					intfEntry, ok := IntfIdNameMap[int32(v6protocolRouteList[sel1].nextHopIfIndex)]
+					intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(v6protocolRouteList[sel1].nextHopIfIndex))
 					if ok {
 						v6nextHopInfo[j].NextHopIntRef = intfEntry.name
 					}
@@ -646,7 +685,7 @@ func (m RIBDServer) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState,
 	if err != nil {
 		return route, errors.New("Invalid destination ip/network Mask")
 	}
-	routeInfoRecordListItem := V6RouteInfoMap.Get(destNet)
+	routeInfoRecordListItem := getRouteInfoMap(DefaultVrf, defs.IPv6).Get(destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Debug("No such route")
 		err = errors.New("Route does not exist")
@@ -666,7 +705,7 @@ func (m RIBDServer) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState,
 		routeInfoRecord := nh
 		nextHopInfo[i].NextHopIp = routeInfoRecord.nextHopIp.String()
 		nextHopInfo[i].NextHopIntRef = strconv.Itoa(int(routeInfoRecord.nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(routeInfoRecord.nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(routeInfoRecord.nextHopIfIndex))
 		if ok {
 			logger.Debug(fmt.Sprintln("Map found for ifndex : ", routeInfoRecord.nextHopIfIndex, "Name = ", intfEntry.name))
 			nextHopInfo[i].NextHopIntRef = intfEntry.name
@@ -680,10 +719,14 @@ func (m RIBDServer) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState,
 	routeInfoRecord := routeInfoList[0]
 	route.DestinationNw = routeInfoRecord.networkAddr
 	route.Protocol = routeInfoRecordList.selectedRouteProtocol
+	route.Vrf = getVrfName(routeInfoRecordList.vrf)
+	route.SourceVrf = routeInfoRecord.sourceVrf
+	route.IsStale = routeInfoRecord.stale
+	route.RouteTag = ribdInt.Int(routeInfoRecord.tag)
 	route.RouteCreatedTime = routeInfoRecord.routeCreatedTime
 	route.RouteUpdatedTime = routeInfoRecord.routeUpdatedTime
 	route.NextBestRoute = &ribdInt.NextBestRouteInfo{}
-	route.NextBestRoute.Protocol = SelectNextBestRoute(routeInfoRecordList, routeInfoRecordList.selectedRouteProtocol)
+	route.NextBestRoute.Protocol = RouteServiceHandler.RIB.SelectNextBestRoute(routeInfoRecordList, routeInfoRecordList.selectedRouteProtocol)
 	nextbestrouteInfoList := routeInfoRecordList.routeInfoProtocolMap[route.NextBestRoute.Protocol]
 	//logger.Info("len of routeInfoList - ", len(routeInfoList), "selected route protocol = ", routeList.selectedRouteProtocol, " route Protocol: ", entry.protocol, " route nwAddr: ", entry.networkAddr)
 	nextBestRouteNextHopInfo := make([]ribdInt.RouteNextHopInfo, len(nextbestrouteInfoList))
@@ -692,7 +735,7 @@ func (m RIBDServer) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState,
 		//logger.Info("nextHop ", sel, " weight = ", routeInfoList[sel].weight, " ip ", routeInfoList[sel].nextHopIp, " intref ", routeInfoList[sel].nextHopIfIndex)
 		nextBestRouteNextHopInfo[i1].NextHopIp = nextbestrouteInfoList[sel1].nextHopIp.String()
 		nextBestRouteNextHopInfo[i1].NextHopIntRef = strconv.Itoa(int(nextbestrouteInfoList[sel1].nextHopIfIndex))
-		intfEntry, ok := IntfIdNameMap[int32(nextbestrouteInfoList[sel1].nextHopIfIndex)]
+		intfEntry, ok := RouteServiceHandler.RIB.IntfEntry(int32(nextbestrouteInfoList[sel1].nextHopIfIndex))
 		if ok {
 			//logger.Debug("Map foud for ifndex : ", routeInfoList[sel].nextHopIfIndex, "Name = ", intfEntry.name)
 			nextBestRouteNextHopInfo[i1].NextHopIntRef = intfEntry.name
@@ -713,6 +756,8 @@ func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int,
 		Protocol:      cfg.Protocol,
 		Cost:          cfg.Cost,
 		NullRoute:     cfg.NullRoute,
+		RouteTag:      cfg.RouteTag,
+		Vrf:           cfg.Vrf,
 	}
 	for i := 0; i < len(cfg.NextHop); i++ {
 		logger.Debug("nexthop info: ip: ", cfg.NextHop[i].NextHopIp, " intref: ", cfg.NextHop[i].NextHopIntRef)
@@ -720,6 +765,7 @@ func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int,
 			NextHopIp:     cfg.NextHop[i].NextHopIp,
 			NextHopIntRef: cfg.NextHop[i].NextHopIntRef,
 			Weight:        cfg.NextHop[i].Weight,
+			Bfd:           cfg.NextHop[i].Bfd,
 		}
 		newCfg.NextHop = make([]*ribd.NextHopInfo, 0)
 		newCfg.NextHop = append(newCfg.NextHop, &nh)
@@ -730,7 +776,7 @@ func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int,
 
 	logger.Debug("createType = ", params.createType, "deleteType = ", params.deleteType)
 	//	PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Import, params)
-	_, err = createRoute(params)
+	_, err = m.RIB.createRoute(params)
 
 	return true, err
 }
@@ -759,7 +805,7 @@ func (m RIBDServer) ProcessV6RouteDeleteConfig(cfg *ribd.IPv6Route, delType int)
 			nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
 			nextHopIfIndex = ribd.Int(nextHopIntRef)
 		}
-		_, err = deleteIPRoute(cfg.DestinationNw, defs.IPv6, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), defs.RoutePolicyStateChangetoInValid)
+		_, err = m.RIB.deleteIPRoute(cfg.Vrf, cfg.DestinationNw, defs.IPv6, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), defs.RoutePolicyStateChangetoInValid)
 	}
 	return true, err
 }
@@ -775,7 +821,11 @@ func (m RIBDServer) Processv6RoutePatchUpdateConfig(origconfig *ribd.IPv6Route,
 		logger.Debug(" getNetowrkPrefixFromStrings returned err ", err)
 		return ret, err
 	}
-	ok := V6RouteInfoMap.Match(destNet)
+	routeInfoMap := getRouteInfoMap(origconfig.Vrf, defs.IPv6)
+	if routeInfoMap == nil {
+		return ret, errors.New(fmt.Sprintln("No route found for ip ", destNet))
+	}
+	ok := routeInfoMap.Match(destNet)
 	if !ok {
 		err = errors.New("No route found")
 		return ret, err
@@ -818,12 +868,13 @@ func (m RIBDServer) Processv6RoutePatchUpdateConfig(origconfig *ribd.IPv6Route,
 					NextHopIp:     val.NextHopIp,
 					NextHopIntRef: val.NextHopIntRef,
 					Weight:        val.Weight,
+					Bfd:           val.Bfd,
 				}
 				newconfig.NextHop = append(newconfig.NextHop, &nh)
 			}
 			switch op[idx].Op {
 			case "add":
-				m.ProcessV6RouteCreateConfig(newconfig, FIBAndRIB, ribd.Int(len(destNetSlice)))
+				m.ProcessV6RouteCreateConfig(newconfig, FIBAndRIB, ribd.Int(len(RouteServiceHandler.RIB.destNetSlice)))
 			case "remove":
 				m.ProcessV6RouteDeleteConfig(newconfig, FIBAndRIB)
 			default:
@@ -847,12 +898,16 @@ func (m RIBDServer) Processv6RouteUpdateConfig(origconfig *ribd.IPv6Route, newco
 		logger.Debug(fmt.Sprintln(" getNetowrkPrefixFromStrings returned err ", err))
 		return val, err
 	}
-	ok := V6RouteInfoMap.Match(destNet)
+	routeInfoMap := getRouteInfoMap(origconfig.Vrf, defs.IPv6)
+	if routeInfoMap == nil {
+		return val, errors.New(fmt.Sprintln("No route found for ip ", destNet))
+	}
+	ok := routeInfoMap.Match(destNet)
 	if !ok {
 		err = errors.New(fmt.Sprintln("No route found for ip ", destNet))
 		return val, err
 	}
-	routeInfoRecordListItem := V6RouteInfoMap.Get(destNet)
+	routeInfoRecordListItem := routeInfoMap.Get(destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Debug(fmt.Sprintln("No route for destination network", destNet))
 		return val, err
@@ -881,17 +936,23 @@ func (m RIBDServer) Processv6RouteUpdateConfig(origconfig *ribd.IPv6Route, newco
 							return val, errors.New("Invalid next hop")
 						}
 						logger.Debug(fmt.Sprintln("Update the next hop info old ip: ", origconfig.NextHop[0].NextHopIp, " new value: ", newconfig.NextHop[0].NextHopIp, " weight : ", newconfig.NextHop[0].Weight))
+						m.RIB.untrackBfdNextHop(routeInfoRecord)
 						routeInfoRecord.nextHopIp = nextHopIpAddr
 						routeInfoRecord.weight = ribd.Int(newconfig.NextHop[0].Weight)
+						routeInfoRecord.bfd = newconfig.NextHop[0].Bfd
 						if newconfig.NextHop[0].NextHopIntRef != "" {
 							nextHopIntRef, _ := strconv.Atoi(newconfig.NextHop[0].NextHopIntRef)
 							routeInfoRecord.nextHopIfIndex = ribd.Int(nextHopIntRef)
 						}
+						m.RIB.trackBfdNextHop(routeInfoRecord)
 					}
 				}
 				if objName == "Cost" {
 					routeInfoRecord.metric = ribd.Int(newconfig.Cost)
 				}
+				if objName == "RouteTag" {
+					routeInfoRecord.tag = uint32(newconfig.RouteTag)
+				}
 				/*				if objName == "OutgoingInterface" {
 								nextHopIfIndex, _ := strconv.Atoi(newconfig.OutgoingInterface)
 								routeInfoRecord.nextHopIfIndex = ribd.Int(nextHopIfIndex)
@@ -900,7 +961,7 @@ func (m RIBDServer) Processv6RouteUpdateConfig(origconfig *ribd.IPv6Route, newco
 			}
 		}
 		routeInfoRecordList.routeInfoProtocolMap[origconfig.Protocol][index] = routeInfoRecord
-		V6RouteInfoMap.Set(destNet, routeInfoRecordList)
+		routeInfoMap.Set(destNet, routeInfoRecordList)
 		logger.Debug("Adding to DBRouteCh from processRouteUpdateConfig")
 		RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 			OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
@@ -911,6 +972,6 @@ func (m RIBDServer) Processv6RouteUpdateConfig(origconfig *ribd.IPv6Route, newco
 			return val, err
 		}
 	}
-	updateBestRoute(destNet, routeInfoRecordList)
+	m.RIB.updateBestRoute(destNet, routeInfoRecordList)
 	return val, err
 }
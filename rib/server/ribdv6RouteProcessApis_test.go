@@ -75,10 +75,10 @@ func TestProcessV6RouteCreateConfig(t *testing.T) {
 			fmt.Println("Validation failed for route:", ipv6RouteList[0], " with error:", val_err)
 			continue
 		}
-		val, err := server.ProcessV6RouteCreateConfig(v6route, FIBAndRIB, ribd.Int(len(destNetSlice)))
+		val, err := server.ProcessV6RouteCreateConfig(v6route, FIBAndRIB, ribd.Int(len(server.RIB.destNetSlice)))
 		fmt.Println("val = ", val, " err: ", err, " for route:", v6route)
 	}
-	val, err := server.ProcessV6RouteCreateConfig(ipv6RouteList[0], FIBAndRIB, ribd.Int(len(destNetSlice)))
+	val, err := server.ProcessV6RouteCreateConfig(ipv6RouteList[0], FIBAndRIB, ribd.Int(len(server.RIB.destNetSlice)))
 	fmt.Println("val = ", val, " err: ", err, " for route:", ipv6RouteList[0])
 	TestGetRouteReachability(t)
 	TestResolveNextHop(t)
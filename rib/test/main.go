@@ -137,6 +137,18 @@ func main() {
 			routeThriftTest.Wg.Wait()
 			//time.Sleep(time.Second * 60)
 			//fmt.Println("After sleep")
+		case "failoverv4":
+			if (i + 4) >= len(route_ops) {
+				fmt.Println("Incorrect usage: should be ./main failoverv4 <gw1> <gw2> <num of routes> <kernel table>")
+				break
+			}
+			gw1 := route_ops[i+1]
+			gw2 := route_ops[i+2]
+			number, _ := strconv.Atoi(route_ops[i+3])
+			table, _ := strconv.Atoi(route_ops[i+4])
+			i = i + 4
+			fmt.Println("Failover test for ", number, " ecmp v4 routes through ", gw1, " and ", gw2, " in table ", table)
+			routeThriftTest.FailoverV4(ribdClient, gw1, gw2, int64(number), table)
 		case "RouteCount":
 			fmt.Println("RouteCount")
 			routeThriftTest.GetTotalRouteCount(ribdClient)
@@ -0,0 +1,274 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// failoverV4
+package routeThriftTest
+
+import (
+	"fmt"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"net"
+	"ribd"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const failoverTimeout = 300 * time.Second
+
+/*
+   Kernel next hop objects installed by ribd, see ribdNetlinkNextHop.go
+*/
+const (
+	rtmNewNextHop = 104
+	rtmGetNextHop = 106
+	nhaId         = 1
+	nhaGroup      = 2
+	nhaGateway    = 6
+	rtprotRibd    = 0xc4
+)
+
+/*
+   Network of the failover test routes, 24.x.y.0/24
+*/
+var failoverNet = &net.IPNet{IP: net.IPv4(24, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}
+
+func failoverRoute(destNw string, mask string, nextHops []string) *ribd.IPv4Route {
+	route := &ribd.IPv4Route{
+		DestinationNw: destNw,
+		NetworkMask:   mask,
+		Protocol:      "STATIC",
+		NextHop:       make([]*ribd.NextHopInfo, 0),
+	}
+	for _, nextHop := range nextHops {
+		route.NextHop = append(route.NextHop, &ribd.NextHopInfo{NextHopIp: nextHop})
+	}
+	return route
+}
+
+func failoverDestNw(count int64) string {
+	return "24." + strconv.Itoa(int(count/254)%254+1) + "." + strconv.Itoa(int(count%254)+1) + ".0"
+}
+
+/*
+   Counts the test routes in the kernel table that have the given number of
+   next hops
+*/
+func countKernelRoutes(table int, nextHops int) (count int64, err error) {
+	filter := &netlink.Route{Table: table}
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, filter, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return 0, err
+	}
+	for _, route := range routes {
+		if route.Dst == nil || !failoverNet.Contains(route.Dst.IP) {
+			continue
+		}
+		routeNextHops := len(route.MultiPath)
+		if routeNextHops == 0 && route.Gw != nil {
+			routeNextHops = 1
+		}
+		if routeNextHops == nextHops {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func waitForKernelRoutes(table int, nextHops int, number int64) (elapsed time.Duration, err error) {
+	start := time.Now()
+	for {
+		count, err := countKernelRoutes(table, nextHops)
+		if err != nil {
+			return time.Since(start), err
+		}
+		if count >= number {
+			return time.Since(start), nil
+		}
+		if time.Since(start) > failoverTimeout {
+			return time.Since(start), fmt.Errorf("only %d of %d routes with %d next hops after %v", count, number, nextHops, failoverTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+/*
+   Counts the ECMP next hop group objects of ribd that use the gateway. The
+   dump is of the next hop objects only, its cost does not depend on the
+   number of routes.
+*/
+func countEcmpGroupsUsingGateway(gw net.IP) (count int, err error) {
+	req := nl.NewNetlinkRequest(rtmGetNextHop, syscall.NLM_F_DUMP)
+	req.AddRawData(make([]byte, 8))
+	msgs, err := req.Execute(syscall.NETLINK_ROUTE, rtmNewNextHop)
+	if err != nil {
+		return 0, err
+	}
+	gwIds := make(map[uint32]bool)
+	groups := make([][]byte, 0)
+	for _, msg := range msgs {
+		if len(msg) < 8 || msg[2] != rtprotRibd {
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(msg[8:])
+		if err != nil {
+			continue
+		}
+		var id uint32
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case nhaId:
+				id = nl.NativeEndian().Uint32(attr.Value)
+			case nhaGateway:
+				if gw.Equal(net.IP(attr.Value)) {
+					gwIds[id] = true
+				}
+			case nhaGroup:
+				groups = append(groups, attr.Value)
+			}
+		}
+	}
+	for _, group := range groups {
+		if len(group) < 16 {
+			continue
+		}
+		for idx := 0; idx+8 <= len(group); idx += 8 {
+			if gwIds[nl.NativeEndian().Uint32(group[idx:])] {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+/*
+   Waits for the ECMP groups using the gateway to be removed, or to be
+   installed again. Without kernel next hop objects the kernel routes are
+   polled instead.
+*/
+func waitForEcmpGroups(gw net.IP, installed bool, table int, number int64) error {
+	start := time.Now()
+	for {
+		count, err := countEcmpGroupsUsingGateway(gw)
+		if err != nil {
+			nextHops := 1
+			if installed {
+				nextHops = 2
+			}
+			_, err = waitForKernelRoutes(table, nextHops, number)
+			return err
+		}
+		if (count > 0) == installed {
+			return nil
+		}
+		if time.Since(start) > failoverTimeout {
+			return fmt.Errorf("ecmp groups using %s not updated after %v", gw, failoverTimeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+/*
+   Route counts the failover is measured at, up to number
+*/
+func failoverScales(number int64) []int64 {
+	scales := make([]int64, 0)
+	for _, scale := range []int64{number / 100, number / 10, number} {
+		if scale > 0 && (len(scales) == 0 || scales[len(scales)-1] != scale) {
+			scales = append(scales, scale)
+		}
+	}
+	return scales
+}
+
+/*
+   Installs ECMP routes through two next hops, withdraws the route the first
+   next hop resolves through and measures the time until the kernel stops
+   using the first next hop, then the time until it uses it again. This is
+   repeated with number/100, number/10 and number routes: ribd only replaces
+   the kernel next hop group objects, so the failover time should stay the
+   same as the number of routes grows. ribd has to run with -fib=netlink
+   -fibtable=<table>. gw1 and gw2 are directly connected gateways.
+*/
+func FailoverV4(client *ribd.RIBDServicesClient, gw1 string, gw2 string, number int64, table int) {
+	nhRoute1 := failoverRoute("40.2.1.0", "255.255.255.0", []string{gw1})
+	nhRoute2 := failoverRoute("40.2.2.0", "255.255.255.0", []string{gw2})
+	nextHops := []string{"40.2.1.2", "40.2.2.2"}
+	gw1Ip := net.ParseIP(gw1)
+	client.OnewayCreateIPv4Route(nhRoute1)
+	client.OnewayCreateIPv4Route(nhRoute2)
+	var count int64
+	results := make([]string, 0)
+	for _, scale := range failoverScales(number) {
+		start := time.Now()
+		for ; count < scale; count++ {
+			rv := client.OnewayCreateIPv4Route(failoverRoute(failoverDestNw(count), "255.255.255.0", nextHops))
+			if rv != nil {
+				fmt.Println("Call failed", rv, "count: ", count)
+				return
+			}
+		}
+		_, err := waitForKernelRoutes(table, 2, scale)
+		if err != nil {
+			fmt.Println("Failed to install the ecmp routes:", err)
+			return
+		}
+		fmt.Println(" ## Time to install ", scale, " ecmp routes:", time.Since(start))
+
+		start = time.Now()
+		client.OnewayDeleteIPv4Route(nhRoute1)
+		if err = waitForEcmpGroups(gw1Ip, false, table, scale); err != nil {
+			fmt.Println("Failover did not complete:", err)
+			return
+		}
+		failover := time.Since(start)
+		if _, err = waitForKernelRoutes(table, 1, scale); err != nil {
+			fmt.Println("Routes not failed over:", err)
+			return
+		}
+
+		start = time.Now()
+		client.OnewayCreateIPv4Route(nhRoute1)
+		if err = waitForEcmpGroups(gw1Ip, true, table, scale); err != nil {
+			fmt.Println("Recovery did not complete:", err)
+			return
+		}
+		recovery := time.Since(start)
+		if _, err = waitForKernelRoutes(table, 2, scale); err != nil {
+			fmt.Println("Routes not recovered:", err)
+			return
+		}
+		results = append(results, fmt.Sprint(" ## ", scale, " routes: failover time ", failover, " recovery time ", recovery))
+	}
+	for _, result := range results {
+		fmt.Println(result)
+	}
+
+	for count = 0; count < number; count++ {
+		client.OnewayDeleteIPv4Route(failoverRoute(failoverDestNw(count), "255.255.255.0", nextHops))
+	}
+	client.OnewayDeleteIPv4Route(nhRoute1)
+	client.OnewayDeleteIPv4Route(nhRoute2)
+}
@@ -0,0 +1,123 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package api
+
+import (
+	"l3/rip/common"
+	"l3/rip/server"
+	"sync"
+)
+
+var ripApi *RIPApiLayer = nil
+var once sync.Once
+
+type RIPApiLayer struct {
+	server *server.RipServer
+}
+
+func InitComplete() bool {
+	if ripApi == nil {
+		return false
+	}
+	if ripApi.server == nil {
+		return false
+	}
+	return true
+}
+
+// Singleton instance should be accessible only within api
+func getApiInstance() *RIPApiLayer {
+	once.Do(func() {
+		ripApi = &RIPApiLayer{}
+	})
+	return ripApi
+}
+
+func Init(svr *server.RipServer) {
+	ripApi = getApiInstance()
+	ripApi.server = svr
+}
+
+func RipGlobalConfig(cfg *common.GlobalConfig) (bool, error) {
+	rv, err := ripApi.server.ValidGlobalConfiguration(cfg)
+	if rv == false {
+		return rv, err
+	}
+	ripApi.server.GblCfgCh <- cfg
+	return true, nil
+}
+
+// this includes both RIPv2 & RIPng interfaces
+func RipIntfConfig(cfg *common.IntfConfig) (bool, error) {
+	rv, err := ripApi.server.ValidConfiguration(cfg)
+	if rv == false {
+		return rv, err
+	}
+	ripApi.server.IntfCfgCh <- cfg
+	return true, nil
+}
+
+func RipNeighborConfig(cfg *common.NeighborConfig) (bool, error) {
+	rv, err := ripApi.server.ValidNeighborConfiguration(cfg)
+	if rv == false {
+		return rv, err
+	}
+	ripApi.server.NbrCfgCh <- cfg
+	return true, nil
+}
+
+// Route added to or removed from the routes ribd redistributes into RIP
+func SendRedistributeRoute(route *common.RedistributeRoute) {
+	ripApi.server.RedistCh <- route
+}
+
+func GetRipGlobalState(vrf string) (*common.GlobalState, error) {
+	return ripApi.server.GetGlobalState(vrf), nil
+}
+
+func GetAllIntfStates(ipType int, from, count int) (n int, c int, result []common.IntfState) {
+	n, c, result = ripApi.server.GetIntfStates(ipType, from, count)
+	return n, c, result
+}
+
+func GetIntfState(intfRef string, ipType int) *common.IntfState {
+	return ripApi.server.GetIntfState(intfRef, ipType)
+}
+
+func GetAllNeighborStates(from, count int) (n int, c int, result []common.NeighborState) {
+	n, c, result = ripApi.server.GetNeighborStates(from, count)
+	return n, c, result
+}
+
+func GetNeighborState(intfRef, address string) *common.NeighborState {
+	return ripApi.server.GetNeighborState(intfRef, address)
+}
+
+func GetAllRouteStates(ipType int, from, count int) (n int, c int, result []common.RouteState) {
+	n, c, result = ripApi.server.GetRouteStates(ipType, from, count)
+	return n, c, result
+}
+
+func GetRouteState(prefix string) *common.RouteState {
+	return ripApi.server.GetRouteState(prefix)
+}
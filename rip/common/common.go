@@ -0,0 +1,162 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package common
+
+import (
+	"net"
+)
+
+const (
+	_ = iota
+	CREATE
+	UPDATE
+	DELETE
+)
+
+const (
+	STATE_UP                       = "UP"
+	STATE_DOWN                     = "DOWN"
+	SPLIT_HORIZON_DISABLE          = "Disable"
+	SPLIT_HORIZON_SIMPLE           = "Simple"
+	SPLIT_HORIZON_POISONED_REVERSE = "PoisonedReverse"
+	AUTH_TYPE_NONE                 = "None"
+	AUTH_TYPE_SIMPLE_PASSWORD      = "SimplePassword"
+	AUTH_TYPE_MD5                  = "MD5"
+	ROUTE_TYPE_RIP                 = "RIP"
+	ROUTE_TYPE_CONNECTED           = "Connected"
+	ROUTE_TYPE_REDISTRIBUTE        = "Redistribute"
+	ROUTE_STATE_VALID              = "Valid"
+	ROUTE_STATE_DELETING           = "Deleting"
+	RIB_PROTOCOL                   = "RIP" // protocol of the routes installed in ribd
+)
+
+const (
+	// RFC 2453 timers, in seconds
+	DEFAULT_UPDATE_INTERVAL  = 30
+	DEFAULT_TIMEOUT_INTERVAL = 180
+	DEFAULT_GARBAGE_INTERVAL = 120
+	DEFAULT_METRIC           = 1 // metric of the routes redistributed into RIP
+	DEFAULT_COST             = 1 // metric added to the routes received on an interface
+	MAX_METRIC               = 15
+	MAX_AUTH_KEY_LEN         = 16
+)
+
+type GlobalConfig struct {
+	Vrf             string
+	Enable          bool
+	UpdateInterval  int32
+	TimeoutInterval int32
+	GarbageInterval int32
+	DefaultMetric   int32
+	Operation       uint8 // Information that will be used by server
+}
+
+// RIPv2 runs on the interfaces of IpType syscall.AF_INET and RIPng on the
+// ones of syscall.AF_INET6
+type IntfConfig struct {
+	IntfRef      string
+	IpType       int
+	AdminState   bool
+	Passive      bool // receive only, updates are sent to the configured neighbors only
+	Cost         int32
+	SplitHorizon string
+	AuthType     string
+	AuthKey      string
+	AuthKeyId    int32
+	Operation    uint8 // Information that will be used by server
+}
+
+// Neighbor that gets unicast updates on the interface, in addition to the
+// multicast ones
+type NeighborConfig struct {
+	IntfRef   string
+	Address   string
+	Operation uint8 // Information that will be used by server
+}
+
+// Route of another protocol that the ribd policy engine redistributes into RIP
+type RedistributeRoute struct {
+	Prefix *net.IPNet
+	Tag    uint16
+	Add    bool
+}
+
+// Route learned from RIP, as it is installed in ribd
+type RouteInfo struct {
+	Prefix  *net.IPNet
+	NextHop net.IP
+	IntfRef string
+	Metric  int32
+	Tag     uint16
+}
+
+// Configuration file of a ripd started without flexswitch
+type StandaloneConfig struct {
+	Global    GlobalConfig
+	Intfs     []IntfConfig
+	Neighbors []NeighborConfig
+}
+
+type GlobalState struct {
+	Vrf              string
+	Enable           bool
+	V4Routes         int32
+	V6Routes         int32
+	RouteChanges     int32
+	PeriodicUpdates  int32
+	TriggeredUpdates int32
+}
+
+type IntfState struct {
+	IntfRef      string
+	IpType       int
+	OperState    string
+	IpAddr       string
+	RxPkts       uint32
+	TxPkts       uint32
+	RxBadPkts    uint32
+	RxBadRoutes  uint32
+	AuthFailures uint32
+}
+
+type NeighborState struct {
+	IntfRef    string
+	Address    string
+	Version    uint8
+	LastUpdate string
+	RxPkts     uint32
+	BadPkts    uint32
+	BadRoutes  uint32
+	SeqNum     uint32
+}
+
+type RouteState struct {
+	Prefix    string
+	NextHop   string
+	IntfRef   string
+	Metric    int32
+	Tag       int32
+	RouteType string
+	State     string
+	Expires   int32 // seconds before the route times out, or is removed while it is deleted
+}
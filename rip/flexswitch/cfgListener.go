@@ -0,0 +1,162 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package flexswitch
+
+import (
+	"errors"
+	"l3/rip/api"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"ripd"
+	"syscall"
+)
+
+func convertGlobalConfig(cfg *ripd.RipGlobal, op uint8) *common.GlobalConfig {
+	return &common.GlobalConfig{
+		Vrf:             cfg.Vrf,
+		Enable:          cfg.Enable,
+		UpdateInterval:  cfg.UpdateInterval,
+		TimeoutInterval: cfg.TimeoutInterval,
+		GarbageInterval: cfg.GarbageInterval,
+		DefaultMetric:   cfg.DefaultMetric,
+		Operation:       op,
+	}
+}
+
+func (h *ConfigHandler) CreateRipGlobal(cfg *ripd.RipGlobal) (r bool, err error) {
+	debug.Logger.Info("Thrift request for creating rip global object:", *cfg)
+	r, err = api.RipGlobalConfig(convertGlobalConfig(cfg, common.CREATE))
+	debug.Logger.Info("Thrift returning for creating rip global object:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) UpdateRipGlobal(ocfg *ripd.RipGlobal, cfg *ripd.RipGlobal, attrset []bool, op []*ripd.PatchOpInfo) (r bool, err error) {
+	debug.Logger.Info("Thrift request for updating rip global object:", *cfg)
+	r, err = api.RipGlobalConfig(convertGlobalConfig(cfg, common.UPDATE))
+	debug.Logger.Info("Thrift returning for updating rip global object:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) DeleteRipGlobal(cfg *ripd.RipGlobal) (r bool, err error) {
+	debug.Logger.Info("Thrift request for deleting rip global object:", *cfg)
+	err = errors.New("Deleting Rip Global Object is not Supported")
+	r = false
+	debug.Logger.Info("Thrift returning for deleting rip global object:", r, err)
+	return r, err
+}
+
+func convertRipIntfConfig(cfg *ripd.RipIntf, op uint8) *common.IntfConfig {
+	intfCfg := &common.IntfConfig{
+		IntfRef:      cfg.IntfRef,
+		IpType:       syscall.AF_INET,
+		Passive:      cfg.Passive,
+		Cost:         cfg.Cost,
+		SplitHorizon: cfg.SplitHorizon,
+		AuthType:     cfg.AuthType,
+		AuthKey:      cfg.AuthKey,
+		AuthKeyId:    cfg.AuthKeyId,
+		Operation:    op,
+	}
+	if cfg.AdminState == common.STATE_UP {
+		intfCfg.AdminState = true
+	}
+	return intfCfg
+}
+
+func (h *ConfigHandler) CreateRipIntf(cfg *ripd.RipIntf) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for creating rip interface:", cfg.IntfRef)
+	r, err = api.RipIntfConfig(convertRipIntfConfig(cfg, common.CREATE))
+	debug.Logger.Info("Thrift request returning for creating rip interface config returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) UpdateRipIntf(origconfig *ripd.RipIntf, newconfig *ripd.RipIntf, attrset []bool, op []*ripd.PatchOpInfo) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for updating rip interface:", newconfig.IntfRef)
+	r, err = api.RipIntfConfig(convertRipIntfConfig(newconfig, common.UPDATE))
+	debug.Logger.Info("Thrift request returning for updating rip interface config returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) DeleteRipIntf(cfg *ripd.RipIntf) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for deleting rip interface:", cfg.IntfRef)
+	r, err = api.RipIntfConfig(convertRipIntfConfig(cfg, common.DELETE))
+	debug.Logger.Info("Thrift request returning for deleting rip interface config returning:", r, err)
+	return r, err
+}
+
+func convertRipngIntfConfig(cfg *ripd.RipngIntf, op uint8) *common.IntfConfig {
+	intfCfg := &common.IntfConfig{
+		IntfRef:      cfg.IntfRef,
+		IpType:       syscall.AF_INET6,
+		Passive:      cfg.Passive,
+		Cost:         cfg.Cost,
+		SplitHorizon: cfg.SplitHorizon,
+		AuthType:     common.AUTH_TYPE_NONE,
+		Operation:    op,
+	}
+	if cfg.AdminState == common.STATE_UP {
+		intfCfg.AdminState = true
+	}
+	return intfCfg
+}
+
+func (h *ConfigHandler) CreateRipngIntf(cfg *ripd.RipngIntf) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for creating ripng interface:", *cfg)
+	r, err = api.RipIntfConfig(convertRipngIntfConfig(cfg, common.CREATE))
+	debug.Logger.Info("Thrift request returning for creating ripng interface config returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) UpdateRipngIntf(origconfig *ripd.RipngIntf, newconfig *ripd.RipngIntf, attrset []bool, op []*ripd.PatchOpInfo) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for updating ripng interface config for:", *origconfig, "to new:", *newconfig)
+	r, err = api.RipIntfConfig(convertRipngIntfConfig(newconfig, common.UPDATE))
+	debug.Logger.Info("Thrift request returning for updating ripng interface config returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) DeleteRipngIntf(cfg *ripd.RipngIntf) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for deleting ripng interface:", *cfg)
+	r, err = api.RipIntfConfig(convertRipngIntfConfig(cfg, common.DELETE))
+	debug.Logger.Info("Thrift request returning for deleting ripng interface config returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) CreateRipNeighbor(cfg *ripd.RipNeighbor) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for creating rip neighbor:", *cfg)
+	r, err = api.RipNeighborConfig(&common.NeighborConfig{cfg.IntfRef, cfg.Address, common.CREATE})
+	debug.Logger.Info("Thrift request returning for creating rip neighbor returning:", r, err)
+	return r, err
+}
+
+func (h *ConfigHandler) UpdateRipNeighbor(origconfig *ripd.RipNeighbor, newconfig *ripd.RipNeighbor, attrset []bool, op []*ripd.PatchOpInfo) (r bool, err error) {
+	// the interface and the address are the keys of the neighbor, there is
+	// nothing to update
+	return true, nil
+}
+
+func (h *ConfigHandler) DeleteRipNeighbor(cfg *ripd.RipNeighbor) (r bool, err error) {
+	debug.Logger.Info("Thrift request received for deleting rip neighbor:", *cfg)
+	r, err = api.RipNeighborConfig(&common.NeighborConfig{cfg.IntfRef, cfg.Address, common.DELETE})
+	debug.Logger.Info("Thrift request returning for deleting rip neighbor returning:", r, err)
+	return r, err
+}
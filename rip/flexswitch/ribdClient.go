@@ -0,0 +1,249 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package flexswitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"l3/rib/ribdCommonDefs"
+	"l3/rip/api"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"net"
+	"ribd"
+	"ribdInt"
+	"strconv"
+	"time"
+	"utils/ipcutils"
+
+	nanomsg "github.com/op/go-nanomsg"
+)
+
+// Installs the RIP routes in ribd and feeds the server with the routes the
+// ribd policy engine redistributes into RIP
+type RibdRouteMgr struct {
+	ribdClient   *ribd.RIBDServicesClient
+	ribSubSocket *nanomsg.SubSocket
+}
+
+func NewRibdRouteMgr(fileName string) (*RibdRouteMgr, error) {
+	clientJson, err := getClient(fileName+"clients.json", "ribd")
+	if err != nil || clientJson == nil {
+		return nil, errors.New("Failed to find ribd port info")
+	}
+	address := "localhost:" + strconv.Itoa(clientJson.Port)
+	transport, protocolFactory, err := ipcutils.CreateIPCHandles(address)
+	if err != nil {
+		debug.Logger.Info("Failed to connect to ribd, retrying until connection is successful")
+		count := 0
+		ticker := time.NewTicker(time.Duration(1000) * time.Millisecond)
+		for _ = range ticker.C {
+			transport, protocolFactory, err = ipcutils.CreateIPCHandles(address)
+			if err == nil {
+				ticker.Stop()
+				break
+			}
+			count++
+			if (count % 10) == 0 {
+				debug.Logger.Info("Still can't connect to ribd, retrying..")
+			}
+		}
+	}
+	debug.Logger.Info("Ripd is connected to ribd")
+	mgr := &RibdRouteMgr{
+		ribdClient: ribd.NewRIBDServicesClientFactory(transport, protocolFactory),
+	}
+	return mgr, nil
+}
+
+func maskString(mask net.IPMask) string {
+	return net.IP(mask).String()
+}
+
+func nextHopInfo(route *common.RouteInfo) []*ribd.NextHopInfo {
+	nextHop := ribd.NextHopInfo{
+		NextHopIp:     route.NextHop.String(),
+		NextHopIntRef: route.IntfRef,
+	}
+	return []*ribd.NextHopInfo{&nextHop}
+}
+
+func (mgr *RibdRouteMgr) CreateRoute(route *common.RouteInfo) error {
+	if route.Prefix.IP.To4() != nil {
+		cfg := ribd.IPv4Route{
+			DestinationNw: route.Prefix.IP.String(),
+			NetworkMask:   maskString(route.Prefix.Mask),
+			Protocol:      common.RIB_PROTOCOL,
+			Cost:          route.Metric,
+			RouteTag:      int32(route.Tag),
+			NextHop:       nextHopInfo(route),
+		}
+		return mgr.ribdClient.OnewayCreateIPv4Route(&cfg)
+	}
+	cfg := ribd.IPv6Route{
+		DestinationNw: route.Prefix.IP.String(),
+		NetworkMask:   maskString(route.Prefix.Mask),
+		Protocol:      common.RIB_PROTOCOL,
+		Cost:          route.Metric,
+		RouteTag:      int32(route.Tag),
+		NextHop:       nextHopInfo(route),
+	}
+	return mgr.ribdClient.OnewayCreateIPv6Route(&cfg)
+}
+
+func (mgr *RibdRouteMgr) DeleteRoute(route *common.RouteInfo) error {
+	if route.Prefix.IP.To4() != nil {
+		cfg := ribd.IPv4Route{
+			DestinationNw: route.Prefix.IP.String(),
+			NetworkMask:   maskString(route.Prefix.Mask),
+			Protocol:      common.RIB_PROTOCOL,
+			Cost:          route.Metric,
+			NextHop:       nextHopInfo(route),
+		}
+		return mgr.ribdClient.OnewayDeleteIPv4Route(&cfg)
+	}
+	cfg := ribd.IPv6Route{
+		DestinationNw: route.Prefix.IP.String(),
+		NetworkMask:   maskString(route.Prefix.Mask),
+		Protocol:      common.RIB_PROTOCOL,
+		Cost:          route.Metric,
+		NextHop:       nextHopInfo(route),
+	}
+	return mgr.ribdClient.OnewayDeleteIPv6Route(&cfg)
+}
+
+// ribd flushes the RIP routes of the previous run that were not installed
+// again
+func (mgr *RibdRouteMgr) RoutesEndOfRIB() {
+	if err := mgr.ribdClient.OnewayRoutesEndOfRIB(common.RIB_PROTOCOL); err != nil {
+		debug.Logger.Err("Failed to send end of RIB to ribd, err:", err)
+	}
+}
+
+func convertRibdRoute(route *ribdInt.Routes, add bool) *common.RedistributeRoute {
+	ip := net.ParseIP(route.Ipaddr)
+	mask := net.ParseIP(route.Mask)
+	if ip == nil || mask == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		mask = mask.To4()
+		if mask == nil {
+			return nil
+		}
+	}
+	return &common.RedistributeRoute{
+		Prefix: &net.IPNet{IP: ip, Mask: net.IPMask(mask)},
+		Tag:    uint16(route.RouteTag),
+		Add:    add,
+	}
+}
+
+func (mgr *RibdRouteMgr) redistribute(route *ribdInt.Routes, add bool) {
+	if route.RouteOrigin == common.RIB_PROTOCOL {
+		// routes learned from RIP are advertised by the server already
+		return
+	}
+	redist := convertRibdRoute(route, add)
+	if redist == nil {
+		debug.Logger.Err("Invalid route:", route.Ipaddr, route.Mask, "redistributed by ribd")
+		return
+	}
+	api.SendRedistributeRoute(redist)
+}
+
+// Gets the routes ribd redistributes into RIP and follows their changes
+func (mgr *RibdRouteMgr) StartRedistribution() error {
+	var err error
+	if mgr.ribSubSocket, err = nanomsg.NewSubSocket(); err != nil {
+		debug.Logger.Err("Failed to create RIB subscribe socket, error:", err)
+		return err
+	}
+	if err = mgr.ribSubSocket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
+		debug.Logger.Err("Failed to subscribe on RIB subscribe socket, error:", err)
+		return err
+	}
+	if _, err = mgr.ribSubSocket.Connect(ribdCommonDefs.PUB_SOCKET_RIPD_ADDR); err != nil {
+		debug.Logger.Err("Failed to connect to RIB publisher socket, error:", err)
+		return err
+	}
+	if err = mgr.ribSubSocket.SetRecvBuffer(1024 * 1024); err != nil {
+		debug.Logger.Err("Failed to set the buffer size for RIB publisher socket, error:", err)
+		return err
+	}
+	debug.Logger.Info("Connected to RIB publisher at address:", ribdCommonDefs.PUB_SOCKET_RIPD_ADDR)
+	mgr.getBulkRoutes()
+	go mgr.listenForRIBUpdates()
+	return nil
+}
+
+func (mgr *RibdRouteMgr) getBulkRoutes() {
+	curMark := ribdInt.Int(0)
+	rCount := ribdInt.Int(1000)
+	for {
+		bulkInfo, err := mgr.ribdClient.GetBulkRoutesForProtocol(common.RIB_PROTOCOL, curMark, rCount)
+		if err != nil || bulkInfo == nil {
+			break
+		}
+		for idx := 0; idx < int(bulkInfo.Count); idx++ {
+			mgr.redistribute(bulkInfo.RouteList[idx], true)
+		}
+		curMark = bulkInfo.EndIdx
+		if bool(bulkInfo.More) == false {
+			break
+		}
+	}
+}
+
+func (mgr *RibdRouteMgr) listenForRIBUpdates() {
+	for {
+		rxBuf, err := mgr.ribSubSocket.Recv(0)
+		if err != nil {
+			debug.Logger.Err("Recv on RIB subscriber socket failed with error:", err)
+			continue
+		}
+		mgr.handleRibUpdates(rxBuf)
+	}
+}
+
+func (mgr *RibdRouteMgr) handleRibUpdates(rxBuf []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(rxBuf))
+	msg := ribdCommonDefs.RibdNotifyMsg{}
+	for err := decoder.Decode(&msg); err == nil; err = decoder.Decode(&msg) {
+		var routeListInfo ribdCommonDefs.RoutelistInfo
+		if err = json.Unmarshal(msg.MsgBuf, &routeListInfo); err != nil {
+			debug.Logger.Err("Unmarshal RIB route update failed with err:", err)
+			continue
+		}
+		switch msg.MsgType {
+		case ribdCommonDefs.NOTIFY_ROUTE_CREATED:
+			mgr.redistribute(&routeListInfo.RouteInfo, true)
+		case ribdCommonDefs.NOTIFY_ROUTE_DELETED:
+			mgr.redistribute(&routeListInfo.RouteInfo, false)
+		default:
+			debug.Logger.Debug("Ignoring RIB update of type:", msg.MsgType)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+
+namespace go ripd
+typedef i32 int
+typedef i16 uint16
+struct PatchOpInfo {
+    1 : string Op
+    2 : string Path
+    3 : string Value
+}
+
+struct RipGlobal {
+	1 : string Vrf
+	2 : bool Enable
+	3 : i32 UpdateInterval
+	4 : i32 TimeoutInterval
+	5 : i32 GarbageInterval
+	6 : i32 DefaultMetric
+}
+struct RipIntf {
+	1 : string IntfRef
+	2 : string AdminState
+	3 : bool Passive
+	4 : i32 Cost
+	5 : string SplitHorizon
+	6 : string AuthType
+	7 : string AuthKey
+	8 : i32 AuthKeyId
+}
+struct RipngIntf {
+	1 : string IntfRef
+	2 : string AdminState
+	3 : bool Passive
+	4 : i32 Cost
+	5 : string SplitHorizon
+}
+struct RipNeighbor {
+	1 : string IntfRef
+	2 : string Address
+}
+struct RipGlobalState {
+	1 : string Vrf
+	2 : string Status
+	3 : i32 V4Routes
+	4 : i32 V6Routes
+	5 : i32 RouteChanges
+	6 : i32 PeriodicUpdates
+	7 : i32 TriggeredUpdates
+}
+struct RipGlobalStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipGlobalState> RipGlobalStateList
+}
+struct RipIntfState {
+	1 : string IntfRef
+	2 : string OperState
+	3 : string IpAddr
+	4 : i32 RxPkts
+	5 : i32 TxPkts
+	6 : i32 RxBadPkts
+	7 : i32 RxBadRoutes
+	8 : i32 AuthFailures
+}
+struct RipIntfStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipIntfState> RipIntfStateList
+}
+struct RipngIntfState {
+	1 : string IntfRef
+	2 : string OperState
+	3 : string IpAddr
+	4 : i32 RxPkts
+	5 : i32 TxPkts
+	6 : i32 RxBadPkts
+	7 : i32 RxBadRoutes
+}
+struct RipngIntfStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipngIntfState> RipngIntfStateList
+}
+struct RipNeighborState {
+	1 : string IntfRef
+	2 : string Address
+	3 : i32 Version
+	4 : string LastUpdate
+	5 : i32 RxPkts
+	6 : i32 BadPkts
+	7 : i32 BadRoutes
+}
+struct RipNeighborStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipNeighborState> RipNeighborStateList
+}
+struct RipRouteState {
+	1 : string Prefix
+	2 : string NextHop
+	3 : string IntfRef
+	4 : i32 Metric
+	5 : i32 RouteTag
+	6 : string RouteType
+	7 : string State
+	8 : i32 Expires
+}
+struct RipRouteStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipRouteState> RipRouteStateList
+}
+struct RipngRouteState {
+	1 : string Prefix
+	2 : string NextHop
+	3 : string IntfRef
+	4 : i32 Metric
+	5 : i32 RouteTag
+	6 : string RouteType
+	7 : string State
+	8 : i32 Expires
+}
+struct RipngRouteStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<RipngRouteState> RipngRouteStateList
+}
+service RIPDServices {
+	bool CreateRipGlobal(1: RipGlobal config);
+	bool UpdateRipGlobal(1: RipGlobal origconfig, 2: RipGlobal newconfig, 3: list<bool> attrset, 4: list<PatchOpInfo> op);
+	bool DeleteRipGlobal(1: RipGlobal config);
+
+	bool CreateRipIntf(1: RipIntf config);
+	bool UpdateRipIntf(1: RipIntf origconfig, 2: RipIntf newconfig, 3: list<bool> attrset, 4: list<PatchOpInfo> op);
+	bool DeleteRipIntf(1: RipIntf config);
+
+	bool CreateRipngIntf(1: RipngIntf config);
+	bool UpdateRipngIntf(1: RipngIntf origconfig, 2: RipngIntf newconfig, 3: list<bool> attrset, 4: list<PatchOpInfo> op);
+	bool DeleteRipngIntf(1: RipngIntf config);
+
+	bool CreateRipNeighbor(1: RipNeighbor config);
+	bool UpdateRipNeighbor(1: RipNeighbor origconfig, 2: RipNeighbor newconfig, 3: list<bool> attrset, 4: list<PatchOpInfo> op);
+	bool DeleteRipNeighbor(1: RipNeighbor config);
+
+	RipGlobalStateGetInfo GetBulkRipGlobalState(1: int fromIndex, 2: int count);
+	RipGlobalState GetRipGlobalState(1: string Vrf);
+	RipIntfStateGetInfo GetBulkRipIntfState(1: int fromIndex, 2: int count);
+	RipIntfState GetRipIntfState(1: string IntfRef);
+	RipngIntfStateGetInfo GetBulkRipngIntfState(1: int fromIndex, 2: int count);
+	RipngIntfState GetRipngIntfState(1: string IntfRef);
+	RipNeighborStateGetInfo GetBulkRipNeighborState(1: int fromIndex, 2: int count);
+	RipNeighborState GetRipNeighborState(1: string IntfRef, 2: string Address);
+	RipRouteStateGetInfo GetBulkRipRouteState(1: int fromIndex, 2: int count);
+	RipRouteState GetRipRouteState(1: string Prefix);
+	RipngRouteStateGetInfo GetBulkRipngRouteState(1: int fromIndex, 2: int count);
+	RipngRouteState GetRipngRouteState(1: string Prefix);
+}
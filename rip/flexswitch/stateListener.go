@@ -0,0 +1,263 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package flexswitch
+
+import (
+	"errors"
+	"fmt"
+	"l3/rip/api"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"ripd"
+	"syscall"
+)
+
+func (h *ConfigHandler) convertRipGlobalStateEntryToThriftEntry(state *common.GlobalState) *ripd.RipGlobalState {
+	entry := ripd.NewRipGlobalState()
+	entry.Vrf = state.Vrf
+	if state.Enable {
+		entry.Status = "Enable"
+	} else {
+		entry.Status = "Disable"
+	}
+	entry.V4Routes = state.V4Routes
+	entry.V6Routes = state.V6Routes
+	entry.RouteChanges = state.RouteChanges
+	entry.PeriodicUpdates = state.PeriodicUpdates
+	entry.TriggeredUpdates = state.TriggeredUpdates
+	return entry
+}
+
+func (h *ConfigHandler) convertRipIntfStateEntryToThriftEntry(state common.IntfState) *ripd.RipIntfState {
+	entry := ripd.NewRipIntfState()
+	entry.IntfRef = state.IntfRef
+	entry.OperState = state.OperState
+	entry.IpAddr = state.IpAddr
+	entry.RxPkts = int32(state.RxPkts)
+	entry.TxPkts = int32(state.TxPkts)
+	entry.RxBadPkts = int32(state.RxBadPkts)
+	entry.RxBadRoutes = int32(state.RxBadRoutes)
+	entry.AuthFailures = int32(state.AuthFailures)
+	return entry
+}
+
+func (h *ConfigHandler) convertRipngIntfStateEntryToThriftEntry(state common.IntfState) *ripd.RipngIntfState {
+	entry := ripd.NewRipngIntfState()
+	entry.IntfRef = state.IntfRef
+	entry.OperState = state.OperState
+	entry.IpAddr = state.IpAddr
+	entry.RxPkts = int32(state.RxPkts)
+	entry.TxPkts = int32(state.TxPkts)
+	entry.RxBadPkts = int32(state.RxBadPkts)
+	entry.RxBadRoutes = int32(state.RxBadRoutes)
+	return entry
+}
+
+func (h *ConfigHandler) convertRipNeighborStateEntryToThriftEntry(state common.NeighborState) *ripd.RipNeighborState {
+	entry := ripd.NewRipNeighborState()
+	entry.IntfRef = state.IntfRef
+	entry.Address = state.Address
+	entry.Version = int32(state.Version)
+	entry.LastUpdate = state.LastUpdate
+	entry.RxPkts = int32(state.RxPkts)
+	entry.BadPkts = int32(state.BadPkts)
+	entry.BadRoutes = int32(state.BadRoutes)
+	return entry
+}
+
+func (h *ConfigHandler) convertRipRouteStateEntryToThriftEntry(state common.RouteState) *ripd.RipRouteState {
+	entry := ripd.NewRipRouteState()
+	entry.Prefix = state.Prefix
+	entry.NextHop = state.NextHop
+	entry.IntfRef = state.IntfRef
+	entry.Metric = state.Metric
+	entry.RouteTag = state.Tag
+	entry.RouteType = state.RouteType
+	entry.State = state.State
+	entry.Expires = state.Expires
+	return entry
+}
+
+func (h *ConfigHandler) convertRipngRouteStateEntryToThriftEntry(state common.RouteState) *ripd.RipngRouteState {
+	entry := ripd.NewRipngRouteState()
+	entry.Prefix = state.Prefix
+	entry.NextHop = state.NextHop
+	entry.IntfRef = state.IntfRef
+	entry.Metric = state.Metric
+	entry.RouteTag = state.Tag
+	entry.RouteType = state.RouteType
+	entry.State = state.State
+	entry.Expires = state.Expires
+	return entry
+}
+
+func (h *ConfigHandler) GetBulkRipGlobalState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipGlobalStateGetInfo, error) {
+	bulkInfo := ripd.NewRipGlobalStateGetInfo()
+	bulkInfo.EndIdx = ripd.Int(0)
+	bulkInfo.Count = ripd.Int(1)
+	bulkInfo.More = false
+	bulkInfo.RipGlobalStateList = make([]*ripd.RipGlobalState, 1)
+	gblEntry, _ := api.GetRipGlobalState("default")
+	bulkInfo.RipGlobalStateList[0] = h.convertRipGlobalStateEntryToThriftEntry(gblEntry)
+	return bulkInfo, nil
+}
+
+func (h *ConfigHandler) GetRipGlobalState(vrf string) (*ripd.RipGlobalState, error) {
+	gblEntry, _ := api.GetRipGlobalState(vrf)
+	return h.convertRipGlobalStateEntryToThriftEntry(gblEntry), nil
+}
+
+func (h *ConfigHandler) GetBulkRipIntfState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipIntfStateGetInfo, error) {
+	debug.Logger.Debug("Get bulk request for rip intf states")
+	nextIdx, currCount, ripEntries := api.GetAllIntfStates(syscall.AF_INET, int(fromIdx), int(count))
+	if len(ripEntries) == 0 || ripEntries == nil {
+		return nil, errors.New("No Rip interfaces configured")
+	}
+	ripResp := make([]*ripd.RipIntfState, len(ripEntries))
+	for idx, ripEntry := range ripEntries {
+		ripResp[idx] = h.convertRipIntfStateEntryToThriftEntry(ripEntry)
+	}
+	ripEntryBulk := ripd.NewRipIntfStateGetInfo()
+	ripEntryBulk.StartIdx = fromIdx
+	ripEntryBulk.EndIdx = ripd.Int(nextIdx)
+	ripEntryBulk.Count = ripd.Int(currCount)
+	ripEntryBulk.More = (nextIdx != 0)
+	ripEntryBulk.RipIntfStateList = ripResp
+	return ripEntryBulk, nil
+}
+
+func (h *ConfigHandler) GetRipIntfState(intfRef string) (*ripd.RipIntfState, error) {
+	entry := api.GetIntfState(intfRef, syscall.AF_INET)
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintln("No rip interface configured for intfRef:", intfRef))
+	}
+	return h.convertRipIntfStateEntryToThriftEntry(*entry), nil
+}
+
+func (h *ConfigHandler) GetBulkRipngIntfState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipngIntfStateGetInfo, error) {
+	debug.Logger.Debug("Get bulk request for ripng intf states")
+	nextIdx, currCount, ripEntries := api.GetAllIntfStates(syscall.AF_INET6, int(fromIdx), int(count))
+	if len(ripEntries) == 0 || ripEntries == nil {
+		return nil, errors.New("No Ripng interfaces configured")
+	}
+	ripResp := make([]*ripd.RipngIntfState, len(ripEntries))
+	for idx, ripEntry := range ripEntries {
+		ripResp[idx] = h.convertRipngIntfStateEntryToThriftEntry(ripEntry)
+	}
+	ripEntryBulk := ripd.NewRipngIntfStateGetInfo()
+	ripEntryBulk.StartIdx = fromIdx
+	ripEntryBulk.EndIdx = ripd.Int(nextIdx)
+	ripEntryBulk.Count = ripd.Int(currCount)
+	ripEntryBulk.More = (nextIdx != 0)
+	ripEntryBulk.RipngIntfStateList = ripResp
+	return ripEntryBulk, nil
+}
+
+func (h *ConfigHandler) GetRipngIntfState(intfRef string) (*ripd.RipngIntfState, error) {
+	entry := api.GetIntfState(intfRef, syscall.AF_INET6)
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintln("No ripng interface configured for intfRef:", intfRef))
+	}
+	return h.convertRipngIntfStateEntryToThriftEntry(*entry), nil
+}
+
+func (h *ConfigHandler) GetBulkRipNeighborState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipNeighborStateGetInfo, error) {
+	debug.Logger.Debug("Get bulk request for rip neighbor states")
+	nextIdx, currCount, ripEntries := api.GetAllNeighborStates(int(fromIdx), int(count))
+	if len(ripEntries) == 0 || ripEntries == nil {
+		return nil, errors.New("No Rip neighbors heard from")
+	}
+	ripResp := make([]*ripd.RipNeighborState, len(ripEntries))
+	for idx, ripEntry := range ripEntries {
+		ripResp[idx] = h.convertRipNeighborStateEntryToThriftEntry(ripEntry)
+	}
+	ripEntryBulk := ripd.NewRipNeighborStateGetInfo()
+	ripEntryBulk.StartIdx = fromIdx
+	ripEntryBulk.EndIdx = ripd.Int(nextIdx)
+	ripEntryBulk.Count = ripd.Int(currCount)
+	ripEntryBulk.More = (nextIdx != 0)
+	ripEntryBulk.RipNeighborStateList = ripResp
+	return ripEntryBulk, nil
+}
+
+func (h *ConfigHandler) GetRipNeighborState(intfRef string, address string) (*ripd.RipNeighborState, error) {
+	entry := api.GetNeighborState(intfRef, address)
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintln("No rip neighbor:", address, "on intfRef:", intfRef))
+	}
+	return h.convertRipNeighborStateEntryToThriftEntry(*entry), nil
+}
+
+func (h *ConfigHandler) GetBulkRipRouteState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipRouteStateGetInfo, error) {
+	debug.Logger.Debug("Get bulk request for rip route states")
+	nextIdx, currCount, ripEntries := api.GetAllRouteStates(syscall.AF_INET, int(fromIdx), int(count))
+	if len(ripEntries) == 0 || ripEntries == nil {
+		return nil, errors.New("No Rip routes")
+	}
+	ripResp := make([]*ripd.RipRouteState, len(ripEntries))
+	for idx, ripEntry := range ripEntries {
+		ripResp[idx] = h.convertRipRouteStateEntryToThriftEntry(ripEntry)
+	}
+	ripEntryBulk := ripd.NewRipRouteStateGetInfo()
+	ripEntryBulk.StartIdx = fromIdx
+	ripEntryBulk.EndIdx = ripd.Int(nextIdx)
+	ripEntryBulk.Count = ripd.Int(currCount)
+	ripEntryBulk.More = (nextIdx != 0)
+	ripEntryBulk.RipRouteStateList = ripResp
+	return ripEntryBulk, nil
+}
+
+func (h *ConfigHandler) GetRipRouteState(prefix string) (*ripd.RipRouteState, error) {
+	entry := api.GetRouteState(prefix)
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintln("No rip route for prefix:", prefix))
+	}
+	return h.convertRipRouteStateEntryToThriftEntry(*entry), nil
+}
+
+func (h *ConfigHandler) GetBulkRipngRouteState(fromIdx ripd.Int, count ripd.Int) (*ripd.RipngRouteStateGetInfo, error) {
+	debug.Logger.Debug("Get bulk request for ripng route states")
+	nextIdx, currCount, ripEntries := api.GetAllRouteStates(syscall.AF_INET6, int(fromIdx), int(count))
+	if len(ripEntries) == 0 || ripEntries == nil {
+		return nil, errors.New("No Ripng routes")
+	}
+	ripResp := make([]*ripd.RipngRouteState, len(ripEntries))
+	for idx, ripEntry := range ripEntries {
+		ripResp[idx] = h.convertRipngRouteStateEntryToThriftEntry(ripEntry)
+	}
+	ripEntryBulk := ripd.NewRipngRouteStateGetInfo()
+	ripEntryBulk.StartIdx = fromIdx
+	ripEntryBulk.EndIdx = ripd.Int(nextIdx)
+	ripEntryBulk.Count = ripd.Int(currCount)
+	ripEntryBulk.More = (nextIdx != 0)
+	ripEntryBulk.RipngRouteStateList = ripResp
+	return ripEntryBulk, nil
+}
+
+func (h *ConfigHandler) GetRipngRouteState(prefix string) (*ripd.RipngRouteState, error) {
+	entry := api.GetRouteState(prefix)
+	if entry == nil {
+		return nil, errors.New(fmt.Sprintln("No ripng route for prefix:", prefix))
+	}
+	return h.convertRipngRouteStateEntryToThriftEntry(*entry), nil
+}
@@ -0,0 +1,176 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"infra/sysd/sysdCommonDefs"
+	"io/ioutil"
+	"l3/rip/api"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/flexswitch"
+	"l3/rip/server"
+	"log/syslog"
+	"os"
+	"strings"
+	"time"
+	"utils/dmnBase"
+	"utils/logging"
+)
+
+const (
+	CONFIG_FLAG = "-config"
+)
+
+// ripd started with -config=<file> runs without flexswitch: the
+// configuration is read from the json file and the routes are only logged
+func standaloneConfigFile() string {
+	for idx, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, CONFIG_FLAG+"=") {
+			return strings.TrimPrefix(arg, CONFIG_FLAG+"=")
+		}
+		if arg == CONFIG_FLAG && idx+2 < len(os.Args) {
+			return os.Args[idx+2]
+		}
+	}
+	return ""
+}
+
+func readStandaloneConfig(fileName string) (*common.StandaloneConfig, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &common.StandaloneConfig{}
+	if err = json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func applyStandaloneConfig(svr *server.RipServer, cfg *common.StandaloneConfig) error {
+	now := time.Now()
+	for idx, _ := range cfg.Intfs {
+		intfCfg := &cfg.Intfs[idx]
+		intfCfg.Operation = common.CREATE
+		if ok, err := svr.ValidConfiguration(intfCfg); !ok {
+			return err
+		}
+		svr.HandleIntfConfig(intfCfg, now)
+	}
+	for idx, _ := range cfg.Neighbors {
+		nbrCfg := &cfg.Neighbors[idx]
+		nbrCfg.Operation = common.CREATE
+		if ok, err := svr.ValidNeighborConfiguration(nbrCfg); !ok {
+			return err
+		}
+		svr.HandleNeighborConfig(nbrCfg)
+	}
+	if cfg.Global.Vrf == "" {
+		cfg.Global.Vrf = "default"
+	}
+	cfg.Global.Operation = common.CREATE
+	if ok, err := svr.ValidGlobalConfiguration(&cfg.Global); !ok {
+		return err
+	}
+	svr.HandleGlobalConfig(&cfg.Global, now)
+	return nil
+}
+
+func startStandalone(fileName string) {
+	var err error
+	logger := new(logging.Writer)
+	logger.MyComponentName = "RIPD"
+	logger.SysLogger, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "ripd")
+	if err != nil {
+		fmt.Println("Failed to initialize syslog:", err)
+		return
+	}
+	logger.MyLogLevel = sysdCommonDefs.INFO
+	debug.SetLogger(logger)
+
+	cfg, err := readStandaloneConfig(fileName)
+	if err != nil {
+		fmt.Println("Failed to read the configuration from:", fileName, "err:", err)
+		return
+	}
+	rxCh := make(chan *server.RxPkt, server.RIP_RX_CH_SIZE)
+	ripSvr := server.RipNewServer(server.NewUdpTransport(rxCh), rxCh, nil)
+	if err = applyStandaloneConfig(ripSvr, cfg); err != nil {
+		fmt.Println("Invalid configuration in:", fileName, "err:", err)
+		return
+	}
+	api.Init(ripSvr)
+	debug.Logger.Info("Starting standalone RIP Server")
+	ripSvr.RipStartServer()
+	select {}
+}
+
+func main() {
+	plugin := ""
+	if fileName := standaloneConfigFile(); fileName != "" {
+		startStandalone(fileName)
+		return
+	}
+
+	switch plugin {
+
+	case "OvsDB":
+
+	default:
+		ripBase := dmnBase.NewBaseDmn("ripd", "RIP")
+		status := ripBase.Init()
+		if status == false {
+			fmt.Println("Failed init basedmn for RIP")
+			return
+		}
+		debug.SetLogger(ripBase.Logger)
+
+		debug.Logger.Info("Initializing ribd client")
+		routeMgr, err := flexswitch.NewRibdRouteMgr(ripBase.ParamsDir)
+		if err != nil {
+			debug.Logger.Err("Failed to connect to ribd, err:", err)
+			return
+		}
+
+		debug.Logger.Info("Creating Config Plugin")
+		cfgPlugin := flexswitch.NewConfigPlugin(flexswitch.NewConfigHandler(), ripBase.ParamsDir)
+
+		rxCh := make(chan *server.RxPkt, server.RIP_RX_CH_SIZE)
+		ripSvr := server.RipNewServer(server.NewUdpTransport(rxCh), rxCh, routeMgr)
+
+		api.Init(ripSvr)
+		debug.Logger.Info("Starting RIP Server")
+
+		ripSvr.RipStartServer()
+		routeMgr.StartRedistribution()
+
+		ripBase.StartKeepAlive()
+
+		debug.Logger.Info("Starting Config Listener for FlexSwitch Plugin")
+
+		cfgPlugin.StartConfigListener()
+	}
+}
@@ -0,0 +1,142 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package packet
+
+import (
+	"errors"
+	"net"
+)
+
+const (
+	RIP_PORT             = 520         // udp port of RIPv2
+	RIPNG_PORT           = 521         // udp port of RIPng
+	RIP_V4_GROUP_IP      = "224.0.0.9" // RIPv2 routers group address
+	RIPNG_GROUP_IP       = "ff02::9"   // RIPng routers group address
+	RIP_CMD_REQUEST      = uint8(1)
+	RIP_CMD_RESPONSE     = uint8(2)
+	RIP_VERSION1         = uint8(1)
+	RIP_VERSION2         = uint8(2)
+	RIPNG_VERSION        = uint8(1)
+	RIP_HEADER_SIZE      = 4  // command, version and two zero bytes
+	RIP_ENTRY_SIZE       = 20 // size of a route entry, both for RIPv2 and RIPng
+	RIP_MAX_ENTRIES      = 25 // route entries in a RIPv2 packet, the authentication entry included
+	RIP_INFINITY         = uint8(16)
+	RIP_AFI_UNSPEC       = uint16(0) // address family of a request for the whole table
+	RIP_AFI_INET         = uint16(2)
+	RIP_AFI_AUTH         = uint16(0xFFFF)
+	RIP_AUTH_NONE        = uint16(0)
+	RIP_AUTH_SIMPLE      = uint16(2)
+	RIP_AUTH_MD5         = uint16(3)
+	RIP_AUTH_TRAILER     = uint16(1)   // type of the keyed MD5 trailer
+	RIP_AUTH_KEY_SIZE    = 16          // size of a simple password or a MD5 key
+	RIP_AUTH_MD5_LEN     = uint8(20)   // authentication data length sent, trailer header included (RFC 4822)
+	RIPNG_NEXTHOP_METRIC = uint8(0xFF) // metric of a RIPng next hop entry
+	IPV6_HEADER_SIZE     = 40
+	UDP_HEADER_SIZE      = 8
+
+	// error message from Packet
+	RIP_SHORT_PKT           = "RIP packet is shorter than its header"
+	RIP_INCORRECT_LENGTH    = "RIP packet length is not a multiple of the route entry size"
+	RIP_INCORRECT_COMMAND   = "Command is not correct for received RIP packet"
+	RIP_INCORRECT_VERSION   = "Version is not correct for received RIP packet"
+	RIP_INCORRECT_AUTH      = "Authentication entry is not correct for received RIP packet"
+	RIP_AUTH_MISMATCH       = "Authentication type does not match the interface"
+	RIP_AUTH_FAILURE        = "Authentication failure for received RIP packet"
+	RIP_AUTH_KEY_ID_UNKNOWN = "Key ID does not match the interface key"
+)
+
+// Authentication of a RIPv2 packet. On receive Key holds the simple
+// password, and PktLen the offset of the keyed MD5 trailer.
+type AuthInfo struct {
+	Type   uint16
+	Key    string
+	KeyId  uint8
+	SeqNum uint32
+	PktLen uint16
+}
+
+type RouteEntry struct {
+	Afi     uint16 // RIPv2 only
+	Prefix  *net.IPNet
+	NextHop net.IP
+	Metric  uint8
+	Tag     uint16
+}
+
+type Packet struct {
+	Command uint8
+	Version uint8
+	Auth    *AuthInfo
+	Entries []RouteEntry
+}
+
+// Request for the whole routing table of the receiver: a single entry of
+// address family 0 (RIPv2) or prefix ::/0 (RIPng) with a metric of infinity
+func (pkt *Packet) IsWholeTableRequest() bool {
+	if pkt.Command != RIP_CMD_REQUEST || len(pkt.Entries) != 1 {
+		return false
+	}
+	entry := pkt.Entries[0]
+	if entry.Metric != RIP_INFINITY {
+		return false
+	}
+	if entry.Prefix == nil {
+		return entry.Afi == RIP_AFI_UNSPEC
+	}
+	ones, _ := entry.Prefix.Mask.Size()
+	return entry.Prefix.IP.To4() == nil && entry.Prefix.IP.IsUnspecified() && ones == 0
+}
+
+// Route entries that fit in one RIPv2 packet with the authentication
+func MaxV2Entries(authType uint16) int {
+	if authType == RIP_AUTH_NONE {
+		return RIP_MAX_ENTRIES
+	}
+	return RIP_MAX_ENTRIES - 1
+}
+
+// Route entries that fit in one RIPng packet on a link of the given mtu
+func MaxNgEntries(mtu int) int {
+	entries := (mtu - IPV6_HEADER_SIZE - UDP_HEADER_SIZE - RIP_HEADER_SIZE) / RIP_ENTRY_SIZE
+	if entries < 1 {
+		return 1
+	}
+	return entries
+}
+
+func decodeHeader(data []byte) (*Packet, error) {
+	if len(data) < RIP_HEADER_SIZE {
+		return nil, errors.New(RIP_SHORT_PKT)
+	}
+	pkt := &Packet{
+		Command: data[0],
+		Version: data[1],
+	}
+	if pkt.Command != RIP_CMD_REQUEST && pkt.Command != RIP_CMD_RESPONSE {
+		return nil, errors.New(RIP_INCORRECT_COMMAND)
+	}
+	if pkt.Version == 0 {
+		return nil, errors.New(RIP_INCORRECT_VERSION)
+	}
+	return pkt, nil
+}
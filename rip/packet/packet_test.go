@@ -0,0 +1,194 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package packet
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+var testV2Entries = []RouteEntry{
+	RouteEntry{
+		Afi:     RIP_AFI_INET,
+		Prefix:  &net.IPNet{IP: net.ParseIP("10.1.0.0").To4(), Mask: net.CIDRMask(16, 32)},
+		NextHop: net.ParseIP("0.0.0.0").To4(),
+		Metric:  2,
+		Tag:     100,
+	},
+	RouteEntry{
+		Afi:     RIP_AFI_INET,
+		Prefix:  &net.IPNet{IP: net.ParseIP("192.168.1.0").To4(), Mask: net.CIDRMask(24, 32)},
+		NextHop: net.ParseIP("172.16.0.9").To4(),
+		Metric:  RIP_INFINITY,
+	},
+}
+
+func TestV2EncodeDecode(t *testing.T) {
+	data := EncodeV2(RIP_CMD_RESPONSE, testV2Entries, nil)
+	if len(data) != RIP_HEADER_SIZE+2*RIP_ENTRY_SIZE {
+		t.Error("Unexpected RIPv2 packet length", len(data))
+		return
+	}
+	pkt, err := DecodeV2(data)
+	if err != nil {
+		t.Error("Failed to decode RIPv2 packet:", err)
+		return
+	}
+	if pkt.Command != RIP_CMD_RESPONSE || pkt.Version != RIP_VERSION2 || pkt.Auth != nil {
+		t.Error("Unexpected RIPv2 header", *pkt)
+	}
+	if !reflect.DeepEqual(pkt.Entries, testV2Entries) {
+		t.Error("Decoded entries", pkt.Entries, "do not match", testV2Entries)
+	}
+	if err = VerifyV2Auth(data, pkt, nil); err != nil {
+		t.Error("Unauthenticated packet rejected:", err)
+	}
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_SIMPLE, Key: "secret"}); err == nil {
+		t.Error("Unauthenticated packet accepted on an interface with a password")
+	}
+	if _, err = DecodeV2(data[:len(data)-1]); err == nil {
+		t.Error("Truncated RIPv2 packet decoded")
+	}
+	data[1] = RIP_VERSION1
+	if _, err = DecodeV2(data); err == nil {
+		t.Error("RIPv1 packet decoded")
+	}
+}
+
+func TestV2SimpleAuth(t *testing.T) {
+	auth := &AuthInfo{Type: RIP_AUTH_SIMPLE, Key: "secret"}
+	data := EncodeV2(RIP_CMD_RESPONSE, testV2Entries, auth)
+	pkt, err := DecodeV2(data)
+	if err != nil {
+		t.Error("Failed to decode RIPv2 packet:", err)
+		return
+	}
+	if pkt.Auth == nil || pkt.Auth.Key != "secret" || len(pkt.Entries) != len(testV2Entries) {
+		t.Error("Unexpected RIPv2 packet with a password", *pkt)
+		return
+	}
+	if err = VerifyV2Auth(data, pkt, auth); err != nil {
+		t.Error("Password not accepted:", err)
+	}
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_SIMPLE, Key: "secret2"}); err == nil {
+		t.Error("Wrong password accepted")
+	}
+	if err = VerifyV2Auth(data, pkt, nil); err == nil {
+		t.Error("Authenticated packet accepted on an interface without authentication")
+	}
+}
+
+func TestV2MD5Auth(t *testing.T) {
+	auth := &AuthInfo{Type: RIP_AUTH_MD5, Key: "md5key", KeyId: 7, SeqNum: 1000}
+	data := EncodeV2(RIP_CMD_RESPONSE, testV2Entries, auth)
+	if len(data) != RIP_HEADER_SIZE+4*RIP_ENTRY_SIZE {
+		t.Error("Unexpected RIPv2 MD5 packet length", len(data))
+		return
+	}
+	pkt, err := DecodeV2(data)
+	if err != nil {
+		t.Error("Failed to decode RIPv2 packet:", err)
+		return
+	}
+	if pkt.Auth == nil || pkt.Auth.KeyId != 7 || pkt.Auth.SeqNum != 1000 ||
+		int(pkt.Auth.PktLen) != RIP_HEADER_SIZE+3*RIP_ENTRY_SIZE {
+		t.Error("Unexpected MD5 authentication entry", pkt.Auth)
+		return
+	}
+	if !reflect.DeepEqual(pkt.Entries, testV2Entries) {
+		t.Error("Decoded entries", pkt.Entries, "do not match", testV2Entries)
+	}
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_MD5, Key: "md5key", KeyId: 7}); err != nil {
+		t.Error("MD5 digest not accepted:", err)
+	}
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_MD5, Key: "md5key", KeyId: 8}); err == nil {
+		t.Error("Unknown key id accepted")
+	}
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_MD5, Key: "other", KeyId: 7}); err == nil {
+		t.Error("Wrong MD5 key accepted")
+	}
+	data[RIP_HEADER_SIZE+RIP_ENTRY_SIZE+19] = 3
+	if err = VerifyV2Auth(data, pkt, &AuthInfo{Type: RIP_AUTH_MD5, Key: "md5key", KeyId: 7}); err == nil {
+		t.Error("Modified packet accepted")
+	}
+}
+
+func TestWholeTableRequest(t *testing.T) {
+	data := EncodeV2(RIP_CMD_REQUEST, []RouteEntry{RouteEntry{Metric: RIP_INFINITY}}, nil)
+	pkt, err := DecodeV2(data)
+	if err != nil || !pkt.IsWholeTableRequest() {
+		t.Error("RIPv2 whole table request not decoded", pkt, err)
+	}
+	data = EncodeNg(RIP_CMD_REQUEST, []RouteEntry{RouteEntry{
+		Prefix: &net.IPNet{IP: net.IPv6unspecified, Mask: net.CIDRMask(0, 128)},
+		Metric: RIP_INFINITY,
+	}})
+	pkt, err = DecodeNg(data)
+	if err != nil || !pkt.IsWholeTableRequest() {
+		t.Error("RIPng whole table request not decoded", pkt, err)
+	}
+	data = EncodeV2(RIP_CMD_REQUEST, testV2Entries, nil)
+	if pkt, _ = DecodeV2(data); pkt.IsWholeTableRequest() {
+		t.Error("RIPv2 request for two routes taken as a whole table request")
+	}
+}
+
+func TestNgEncodeDecode(t *testing.T) {
+	nextHop := net.ParseIP("fe80::1")
+	entries := []RouteEntry{
+		RouteEntry{
+			Prefix: &net.IPNet{IP: net.ParseIP("2001:db8:1::"), Mask: net.CIDRMask(48, 128)},
+			Metric: 1,
+			Tag:    10,
+		},
+		RouteEntry{
+			Prefix:  &net.IPNet{IP: net.ParseIP("2001:db8:2::"), Mask: net.CIDRMask(64, 128)},
+			NextHop: nextHop,
+			Metric:  3,
+		},
+	}
+	data := EncodeNg(RIP_CMD_RESPONSE, entries)
+	if len(data) != RIP_HEADER_SIZE+3*RIP_ENTRY_SIZE {
+		t.Error("Unexpected RIPng packet length", len(data))
+		return
+	}
+	pkt, err := DecodeNg(data)
+	if err != nil {
+		t.Error("Failed to decode RIPng packet:", err)
+		return
+	}
+	if pkt.Command != RIP_CMD_RESPONSE || pkt.Version != RIPNG_VERSION || len(pkt.Entries) != 2 {
+		t.Error("Unexpected RIPng packet", *pkt)
+		return
+	}
+	if pkt.Entries[0].Prefix.String() != "2001:db8:1::/48" || pkt.Entries[0].Tag != 10 || pkt.Entries[0].NextHop != nil {
+		t.Error("Unexpected RIPng entry", pkt.Entries[0])
+	}
+	if pkt.Entries[1].Prefix.String() != "2001:db8:2::/64" || pkt.Entries[1].Metric != 3 || !pkt.Entries[1].NextHop.Equal(nextHop) {
+		t.Error("Unexpected RIPng entry", pkt.Entries[1])
+	}
+	if MaxNgEntries(1500) != 72 {
+		t.Error("Unexpected RIPng entries for mtu 1500:", MaxNgEntries(1500))
+	}
+}
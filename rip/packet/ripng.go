@@ -0,0 +1,121 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+/*
+	RIPng packet (RFC 2080)
+
+	0                   1                   2                   3
+	0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	|  command (1)  |  version (1)  |       must be zero (2)        |
+	+---------------+---------------+-------------------------------+
+	|                                                               |
+	~                        IPv6 prefix (16)                       ~
+	|                                                               |
+	+---------------------------------------------------------------+
+	|         route tag (2)         | prefix len (1)|  metric (1)   |
+	+---------------------------------------------------------------+
+	...
+
+	An entry with a metric of 0xFF is a next hop entry, its prefix is the
+	next hop of the entries that follow it.
+*/
+
+// Encodes a RIPng packet. A next hop entry is added before the entries
+// whose next hop differs from the one of the previous entry.
+func EncodeNg(command uint8, entries []RouteEntry) []byte {
+	buf := make([]byte, RIP_HEADER_SIZE, RIP_HEADER_SIZE+len(entries)*RIP_ENTRY_SIZE)
+	buf[0] = command
+	buf[1] = RIPNG_VERSION
+	nextHop := net.IPv6unspecified
+	for _, entry := range entries {
+		if entry.NextHop != nil && !entry.NextHop.Equal(nextHop) {
+			rte := make([]byte, RIP_ENTRY_SIZE)
+			copy(rte[0:16], entry.NextHop.To16())
+			rte[19] = RIPNG_NEXTHOP_METRIC
+			buf = append(buf, rte...)
+			nextHop = entry.NextHop
+		}
+		rte := make([]byte, RIP_ENTRY_SIZE)
+		if entry.Prefix != nil {
+			copy(rte[0:16], entry.Prefix.IP.To16())
+			ones, _ := entry.Prefix.Mask.Size()
+			rte[18] = uint8(ones)
+		}
+		binary.BigEndian.PutUint16(rte[16:18], entry.Tag)
+		rte[19] = entry.Metric
+		buf = append(buf, rte...)
+	}
+	return buf
+}
+
+// Decodes a RIPng packet. The next hop entries are not returned, the route
+// entries get the next hop they announce. A next hop that is not link local
+// is taken as the originator of the packet, as RFC 2080 asks.
+func DecodeNg(data []byte) (*Packet, error) {
+	pkt, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if pkt.Version != RIPNG_VERSION {
+		return nil, errors.New(RIP_INCORRECT_VERSION)
+	}
+	if (len(data)-RIP_HEADER_SIZE)%RIP_ENTRY_SIZE != 0 {
+		return nil, errors.New(RIP_INCORRECT_LENGTH)
+	}
+	var nextHop net.IP
+	for offset := RIP_HEADER_SIZE; offset < len(data); offset += RIP_ENTRY_SIZE {
+		rte := data[offset : offset+RIP_ENTRY_SIZE]
+		addr := net.IP(bytes.Repeat([]byte{0}, net.IPv6len))
+		copy(addr, rte[0:16])
+		if rte[19] == RIPNG_NEXTHOP_METRIC {
+			nextHop = nil
+			if addr.IsLinkLocalUnicast() {
+				nextHop = addr
+			}
+			continue
+		}
+		prefixLen := int(rte[18])
+		if prefixLen > 8*net.IPv6len {
+			//invalid entry, the others can still be used
+			continue
+		}
+		mask := net.CIDRMask(prefixLen, 8*net.IPv6len)
+		entry := RouteEntry{
+			Prefix:  &net.IPNet{IP: addr.Mask(mask), Mask: mask},
+			NextHop: nextHop,
+			Metric:  rte[19],
+			Tag:     binary.BigEndian.Uint16(rte[16:18]),
+		}
+		pkt.Entries = append(pkt.Entries, entry)
+	}
+	return pkt, nil
+}
@@ -0,0 +1,224 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package packet
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+/*
+	RIPv2 packet (RFC 2453), with the keyed MD5 authentication of RFC 2082
+
+	0                   1                   2                   3
+	0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	|  command (1)  |  version (1)  |       must be zero (2)        |
+	+---------------+---------------+-------------------------------+
+	|             0xFFFF            |    Authentication Type (3)    |
+	+-------------------------------+-------------------------------+
+	|    RIP-2 Packet Length        |    Key ID     | Auth Data Len |
+	+-------------------------------+-------------------------------+
+	|               Sequence Number (non-decreasing)                |
+	+---------------------------------------------------------------+
+	|                      reserved must be zero                    |
+	+---------------------------------------------------------------+
+	|                      reserved must be zero                    |
+	+---------------------------------------------------------------+
+	| Address Family Identifier (2) |        Route Tag (2)          |
+	+-------------------------------+-------------------------------+
+	|                         IP Address (4)                        |
+	+---------------------------------------------------------------+
+	|                         Subnet Mask (4)                       |
+	+---------------------------------------------------------------+
+	|                         Next Hop (4)                          |
+	+---------------------------------------------------------------+
+	|                         Metric (4)                            |
+	+---------------------------------------------------------------+
+	...
+	+-------------------------------+-------------------------------+
+	|             0xFFFF            |              0x01             |
+	+-------------------------------+-------------------------------+
+	|               Authentication Data (var. length; 16)           |
+	+---------------------------------------------------------------+
+*/
+
+func authKey(key string) []byte {
+	keyBytes := make([]byte, RIP_AUTH_KEY_SIZE)
+	copy(keyBytes, key)
+	return keyBytes
+}
+
+// Encodes a RIPv2 packet. auth is nil for an unauthenticated packet, its
+// Key is the simple password or the MD5 key.
+func EncodeV2(command uint8, entries []RouteEntry, auth *AuthInfo) []byte {
+	buf := make([]byte, RIP_HEADER_SIZE, RIP_HEADER_SIZE+(len(entries)+2)*RIP_ENTRY_SIZE)
+	buf[0] = command
+	buf[1] = RIP_VERSION2
+	authOffset := len(buf)
+	if auth != nil && auth.Type != RIP_AUTH_NONE {
+		authEntry := make([]byte, RIP_ENTRY_SIZE)
+		binary.BigEndian.PutUint16(authEntry[0:2], RIP_AFI_AUTH)
+		binary.BigEndian.PutUint16(authEntry[2:4], auth.Type)
+		switch auth.Type {
+		case RIP_AUTH_SIMPLE:
+			copy(authEntry[4:20], authKey(auth.Key))
+		case RIP_AUTH_MD5:
+			authEntry[6] = auth.KeyId
+			authEntry[7] = RIP_AUTH_MD5_LEN
+			binary.BigEndian.PutUint32(authEntry[8:12], auth.SeqNum)
+		}
+		buf = append(buf, authEntry...)
+	}
+	for _, entry := range entries {
+		rte := make([]byte, RIP_ENTRY_SIZE)
+		afi := entry.Afi
+		if afi == RIP_AFI_UNSPEC && entry.Prefix != nil {
+			afi = RIP_AFI_INET
+		}
+		binary.BigEndian.PutUint16(rte[0:2], afi)
+		binary.BigEndian.PutUint16(rte[2:4], entry.Tag)
+		if entry.Prefix != nil {
+			copy(rte[4:8], entry.Prefix.IP.To4())
+			copy(rte[8:12], net.IP(entry.Prefix.Mask).To4())
+		}
+		if entry.NextHop != nil {
+			copy(rte[12:16], entry.NextHop.To4())
+		}
+		binary.BigEndian.PutUint32(rte[16:20], uint32(entry.Metric))
+		buf = append(buf, rte...)
+	}
+	if auth != nil && auth.Type == RIP_AUTH_MD5 {
+		binary.BigEndian.PutUint16(buf[authOffset+4:authOffset+6], uint16(len(buf)))
+		trailer := make([]byte, 4)
+		binary.BigEndian.PutUint16(trailer[0:2], RIP_AFI_AUTH)
+		binary.BigEndian.PutUint16(trailer[2:4], RIP_AUTH_TRAILER)
+		buf = append(buf, trailer...)
+		buf = append(buf, authKey(auth.Key)...)
+		digest := md5.Sum(buf)
+		copy(buf[len(buf)-RIP_AUTH_KEY_SIZE:], digest[:])
+	}
+	return buf
+}
+
+// Decodes a RIPv2 packet. The authentication entry, when the packet has one,
+// is returned in Auth and is checked with VerifyV2Auth.
+func DecodeV2(data []byte) (*Packet, error) {
+	pkt, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if pkt.Version < RIP_VERSION2 {
+		return nil, errors.New(RIP_INCORRECT_VERSION)
+	}
+	end := len(data)
+	offset := RIP_HEADER_SIZE
+	if end >= offset+RIP_ENTRY_SIZE && binary.BigEndian.Uint16(data[offset:offset+2]) == RIP_AFI_AUTH {
+		auth := &AuthInfo{
+			Type: binary.BigEndian.Uint16(data[offset+2 : offset+4]),
+		}
+		switch auth.Type {
+		case RIP_AUTH_SIMPLE:
+			auth.Key = string(bytes.TrimRight(data[offset+4:offset+20], "\x00"))
+		case RIP_AUTH_MD5:
+			auth.PktLen = binary.BigEndian.Uint16(data[offset+4 : offset+6])
+			auth.KeyId = data[offset+6]
+			auth.SeqNum = binary.BigEndian.Uint32(data[offset+8 : offset+12])
+			if int(auth.PktLen) > end || int(auth.PktLen) < offset+RIP_ENTRY_SIZE {
+				return nil, errors.New(RIP_INCORRECT_AUTH)
+			}
+			end = int(auth.PktLen)
+		default:
+			return nil, errors.New(RIP_INCORRECT_AUTH)
+		}
+		pkt.Auth = auth
+		offset += RIP_ENTRY_SIZE
+	}
+	if (end-offset)%RIP_ENTRY_SIZE != 0 {
+		return nil, errors.New(RIP_INCORRECT_LENGTH)
+	}
+	for ; offset < end; offset += RIP_ENTRY_SIZE {
+		rte := data[offset : offset+RIP_ENTRY_SIZE]
+		entry := RouteEntry{
+			Afi:    binary.BigEndian.Uint16(rte[0:2]),
+			Tag:    binary.BigEndian.Uint16(rte[2:4]),
+			Metric: uint8(binary.BigEndian.Uint32(rte[16:20])),
+		}
+		if binary.BigEndian.Uint32(rte[16:20]) > uint32(RIP_INFINITY) {
+			entry.Metric = RIP_INFINITY + 1
+		}
+		if entry.Afi == RIP_AFI_AUTH {
+			return nil, errors.New(RIP_INCORRECT_AUTH)
+		}
+		if entry.Afi != RIP_AFI_UNSPEC {
+			entry.Prefix = &net.IPNet{
+				IP:   net.IPv4(rte[4], rte[5], rte[6], rte[7]).To4(),
+				Mask: net.IPv4Mask(rte[8], rte[9], rte[10], rte[11]),
+			}
+			entry.NextHop = net.IPv4(rte[12], rte[13], rte[14], rte[15]).To4()
+		}
+		pkt.Entries = append(pkt.Entries, entry)
+	}
+	return pkt, nil
+}
+
+// Checks the authentication of a received RIPv2 packet against the key of
+// the interface
+func VerifyV2Auth(data []byte, pkt *Packet, auth *AuthInfo) error {
+	if auth == nil || auth.Type == RIP_AUTH_NONE {
+		if pkt.Auth != nil {
+			return errors.New(RIP_AUTH_MISMATCH)
+		}
+		return nil
+	}
+	if pkt.Auth == nil || pkt.Auth.Type != auth.Type {
+		return errors.New(RIP_AUTH_MISMATCH)
+	}
+	switch auth.Type {
+	case RIP_AUTH_SIMPLE:
+		if pkt.Auth.Key != string(bytes.TrimRight(authKey(auth.Key), "\x00")) {
+			return errors.New(RIP_AUTH_FAILURE)
+		}
+	case RIP_AUTH_MD5:
+		if pkt.Auth.KeyId != auth.KeyId {
+			return errors.New(RIP_AUTH_KEY_ID_UNKNOWN)
+		}
+		trailer := int(pkt.Auth.PktLen)
+		if len(data) < trailer+4+RIP_AUTH_KEY_SIZE ||
+			binary.BigEndian.Uint16(data[trailer:trailer+2]) != RIP_AFI_AUTH ||
+			binary.BigEndian.Uint16(data[trailer+2:trailer+4]) != RIP_AUTH_TRAILER {
+			return errors.New(RIP_INCORRECT_AUTH)
+		}
+		digestData := make([]byte, 0, trailer+4+RIP_AUTH_KEY_SIZE)
+		digestData = append(digestData, data[:trailer+4]...)
+		digestData = append(digestData, authKey(auth.Key)...)
+		digest := md5.Sum(digestData)
+		if !bytes.Equal(digest[:], data[trailer+4:trailer+4+RIP_AUTH_KEY_SIZE]) {
+			return errors.New(RIP_AUTH_FAILURE)
+		}
+	}
+	return nil
+}
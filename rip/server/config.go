@@ -0,0 +1,208 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"net"
+	"syscall"
+	"time"
+)
+
+func (svr *RipServer) ValidGlobalConfiguration(cfg *common.GlobalConfig) (bool, error) {
+	if cfg.UpdateInterval <= 0 {
+		cfg.UpdateInterval = common.DEFAULT_UPDATE_INTERVAL
+	}
+	if cfg.TimeoutInterval <= 0 {
+		cfg.TimeoutInterval = common.DEFAULT_TIMEOUT_INTERVAL
+	}
+	if cfg.GarbageInterval <= 0 {
+		cfg.GarbageInterval = common.DEFAULT_GARBAGE_INTERVAL
+	}
+	if cfg.DefaultMetric == 0 {
+		cfg.DefaultMetric = common.DEFAULT_METRIC
+	}
+	if cfg.TimeoutInterval <= cfg.UpdateInterval {
+		return false, errors.New(fmt.Sprintln("Rip timeout interval:", cfg.TimeoutInterval,
+			"must be longer than the update interval:", cfg.UpdateInterval))
+	}
+	if cfg.DefaultMetric < 1 || cfg.DefaultMetric > common.MAX_METRIC {
+		return false, errors.New(fmt.Sprintln("Invalid rip default metric:", cfg.DefaultMetric))
+	}
+	return true, nil
+}
+
+func (svr *RipServer) validateIntfParams(cfg *common.IntfConfig) (bool, error) {
+	if cfg.IpType != syscall.AF_INET && cfg.IpType != syscall.AF_INET6 {
+		return false, errors.New("Invalid ip type")
+	}
+	if cfg.Cost == 0 {
+		cfg.Cost = common.DEFAULT_COST
+	}
+	if cfg.Cost < 1 || cfg.Cost > common.MAX_METRIC {
+		return false, errors.New(fmt.Sprintln("Invalid rip interface cost:", cfg.Cost))
+	}
+	switch cfg.SplitHorizon {
+	case "":
+		cfg.SplitHorizon = common.SPLIT_HORIZON_POISONED_REVERSE
+	case common.SPLIT_HORIZON_DISABLE, common.SPLIT_HORIZON_SIMPLE, common.SPLIT_HORIZON_POISONED_REVERSE:
+	default:
+		return false, errors.New(fmt.Sprintln("Invalid rip split horizon:", cfg.SplitHorizon))
+	}
+	switch cfg.AuthType {
+	case "":
+		cfg.AuthType = common.AUTH_TYPE_NONE
+	case common.AUTH_TYPE_NONE:
+	case common.AUTH_TYPE_SIMPLE_PASSWORD, common.AUTH_TYPE_MD5:
+		if cfg.IpType == syscall.AF_INET6 {
+			// RIPng relies on IPsec for authentication
+			return false, errors.New("Ripng interfaces do not support authentication")
+		}
+		if len(cfg.AuthKey) == 0 || len(cfg.AuthKey) > common.MAX_AUTH_KEY_LEN {
+			return false, errors.New(fmt.Sprintln("Rip authentication key must be 1 to", common.MAX_AUTH_KEY_LEN, "characters"))
+		}
+		if cfg.AuthKeyId < 0 || cfg.AuthKeyId > 255 {
+			return false, errors.New(fmt.Sprintln("Invalid rip authentication key id:", cfg.AuthKeyId))
+		}
+	default:
+		return false, errors.New(fmt.Sprintln("Invalid rip authentication type:", cfg.AuthType))
+	}
+	return true, nil
+}
+
+func (svr *RipServer) ValidConfiguration(cfg *common.IntfConfig) (bool, error) {
+	key := IntfKey{cfg.IntfRef, cfg.IpType}
+	svr.lock.RLock()
+	_, exists := svr.Intfs[key]
+	svr.lock.RUnlock()
+	switch cfg.Operation {
+	case common.CREATE:
+		if exists {
+			return false, errors.New(fmt.Sprintln("Rip Interface already created for config:", cfg,
+				"only update is allowed"))
+		}
+		return svr.validateIntfParams(cfg)
+	case common.UPDATE:
+		if !exists {
+			return false, errors.New(fmt.Sprintln("Rip Interface doesn't exists for key:", key,
+				"please do create before updating entry"))
+		}
+		return svr.validateIntfParams(cfg)
+	case common.DELETE:
+		if !exists {
+			return false, errors.New(fmt.Sprintln("Rip Interface was not created for config:", cfg))
+		}
+		return true, nil
+	}
+	return false, errors.New("Invalid Operation received for Rip Interface Config")
+}
+
+func (svr *RipServer) ValidNeighborConfiguration(cfg *common.NeighborConfig) (bool, error) {
+	ip := net.ParseIP(cfg.Address)
+	if ip == nil || ip.IsMulticast() || ip.IsUnspecified() {
+		return false, errors.New(fmt.Sprintln("Invalid rip neighbor address:", cfg.Address))
+	}
+	if ip.To4() == nil && !ip.IsLinkLocalUnicast() {
+		return false, errors.New("Ripng neighbors are reached on their link local address")
+	}
+	return true, nil
+}
+
+func (svr *RipServer) HandleGlobalConfig(gCfg *common.GlobalConfig, now time.Time) {
+	debug.Logger.Info("Handling Global Config for:", *gCfg)
+	wasEnabled := svr.GlobalConfig.Enable
+	svr.GlobalConfig = *gCfg
+	svr.globalState.Vrf = gCfg.Vrf
+	svr.globalState.Enable = gCfg.Enable
+	switch {
+	case gCfg.Enable && !wasEnabled:
+		debug.Logger.Info("Rip Enabled")
+		svr.scheduleUpdate(now)
+		svr.endOfRIBTime = now.Add(time.Duration(RIP_END_OF_RIB_INTERVALS*gCfg.UpdateInterval) * time.Second)
+		svr.endOfRIBSent = false
+		svr.refreshIntfs(now)
+	case !gCfg.Enable && wasEnabled:
+		debug.Logger.Info("Rip Disabled")
+		for _, intf := range svr.Intfs {
+			if intf.IsUp() {
+				svr.intfDown(intf, now)
+			}
+		}
+		svr.flushRoutes()
+		svr.Neighbors = make(map[NeighborKey]*Neighbor, RIP_GLOBAL_INFO_DEFAULT_SIZE)
+		svr.triggerPending = false
+	}
+}
+
+func (svr *RipServer) HandleIntfConfig(cfg *common.IntfConfig, now time.Time) {
+	debug.Logger.Info("Handling Rip Interface Config for:", *cfg)
+	key := IntfKey{cfg.IntfRef, cfg.IpType}
+	intf, exists := svr.Intfs[key]
+	switch cfg.Operation {
+	case common.CREATE:
+		if exists {
+			return
+		}
+		svr.Intfs[key] = newRipIntf(cfg)
+	case common.UPDATE:
+		if !exists {
+			return
+		}
+		if intf.IsUp() && (intf.Config.AdminState != cfg.AdminState || intf.Config.Cost != cfg.Cost) {
+			// the routes learned with the old cost are withdrawn and
+			// learned again
+			svr.intfDown(intf, now)
+		}
+		intf.Config = *cfg
+	case common.DELETE:
+		if !exists {
+			return
+		}
+		if intf.IsUp() {
+			svr.intfDown(intf, now)
+		}
+		for nbrKey, _ := range svr.Neighbors {
+			if nbrKey.IntfRef == cfg.IntfRef {
+				delete(svr.Neighbors, nbrKey)
+			}
+		}
+		delete(svr.Intfs, key)
+		return
+	}
+	svr.refreshIntfs(now)
+}
+
+func (svr *RipServer) HandleNeighborConfig(cfg *common.NeighborConfig) {
+	debug.Logger.Info("Handling Rip Neighbor Config for:", *cfg)
+	key := NeighborKey{cfg.IntfRef, cfg.Address}
+	switch cfg.Operation {
+	case common.CREATE, common.UPDATE:
+		nbrCfg := *cfg
+		svr.NeighborCfg[key] = &nbrCfg
+	case common.DELETE:
+		delete(svr.NeighborCfg, key)
+	}
+}
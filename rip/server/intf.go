@@ -0,0 +1,232 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"net"
+	"syscall"
+	"time"
+)
+
+type RipIntf struct {
+	Config    common.IntfConfig
+	IfIndex   int
+	Mtu       int
+	OperState string
+	Addrs     []*net.IPNet // addresses whose networks are advertised, the RIPng link local one excluded
+	LinkLocal net.IP       // RIPng source address
+	SeqNum    uint32       // keyed MD5 sequence number
+	State     common.IntfState
+}
+
+func newRipIntf(cfg *common.IntfConfig) *RipIntf {
+	intf := &RipIntf{
+		Config:    *cfg,
+		OperState: common.STATE_DOWN,
+		// start from the time so that the sequence numbers keep increasing
+		// across restarts
+		SeqNum: uint32(time.Now().Unix()),
+	}
+	intf.State.IntfRef = cfg.IntfRef
+	intf.State.IpType = cfg.IpType
+	return intf
+}
+
+func (intf *RipIntf) IsUp() bool {
+	return intf.OperState == common.STATE_UP
+}
+
+func (intf *RipIntf) isV6() bool {
+	return intf.Config.IpType == syscall.AF_INET6
+}
+
+func (intf *RipIntf) cost() uint8 {
+	return uint8(intf.Config.Cost)
+}
+
+// Whether the address belongs to one of the networks of the interface
+func (intf *RipIntf) onLink(ip net.IP) bool {
+	if intf.isV6() {
+		return ip.IsLinkLocalUnicast()
+	}
+	for _, addr := range intf.Addrs {
+		if addr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (intf *RipIntf) isOwnAddr(ip net.IP) bool {
+	if intf.LinkLocal != nil && intf.LinkLocal.Equal(ip) {
+		return true
+	}
+	for _, addr := range intf.Addrs {
+		if addr.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (intf *RipIntf) groupAddr() *net.UDPAddr {
+	if intf.isV6() {
+		return &net.UDPAddr{IP: net.ParseIP(packet.RIPNG_GROUP_IP), Port: packet.RIPNG_PORT}
+	}
+	return &net.UDPAddr{IP: net.ParseIP(packet.RIP_V4_GROUP_IP), Port: packet.RIP_PORT}
+}
+
+func (intf *RipIntf) port() int {
+	if intf.isV6() {
+		return packet.RIPNG_PORT
+	}
+	return packet.RIP_PORT
+}
+
+// Splits the addresses of the Linux interface into the networks advertised
+// and, for RIPng, the link local source address
+func (intf *RipIntf) getAddrs(info *IntfInfo) (addrs []*net.IPNet, linkLocal net.IP) {
+	for _, addr := range info.Addrs {
+		isV4 := addr.IP.To4() != nil
+		if isV4 == intf.isV6() {
+			continue
+		}
+		if addr.IP.IsLinkLocalUnicast() {
+			if intf.isV6() && linkLocal == nil {
+				linkLocal = addr.IP
+			}
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, linkLocal
+}
+
+func connectedPrefix(addr *net.IPNet) *net.IPNet {
+	return &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask}
+}
+
+func (svr *RipServer) intfUp(intf *RipIntf, info *IntfInfo, now time.Time) {
+	if err := svr.transport.Open(info.IfIndex, intf.Config.IpType); err != nil {
+		debug.Logger.Err("Failed to open RIP on interface:", intf.Config.IntfRef, "err:", err)
+		return
+	}
+	debug.Logger.Info("RIP interface:", intf.Config.IntfRef, "ip type:", intf.Config.IpType, "is up")
+	intf.IfIndex = info.IfIndex
+	intf.Mtu = info.Mtu
+	intf.OperState = common.STATE_UP
+	intf.State.OperState = common.STATE_UP
+	svr.updateConnectedRoutes(intf, info, now)
+	// ask the neighbors for their routes instead of waiting for their next
+	// periodic update
+	svr.sendWholeTableRequest(intf)
+}
+
+func (svr *RipServer) intfDown(intf *RipIntf, now time.Time) {
+	debug.Logger.Info("RIP interface:", intf.Config.IntfRef, "ip type:", intf.Config.IpType, "is down")
+	if err := svr.transport.Close(intf.IfIndex, intf.Config.IpType); err != nil {
+		debug.Logger.Warning("Failed to close RIP on interface:", intf.Config.IntfRef, "err:", err)
+	}
+	intf.OperState = common.STATE_DOWN
+	intf.State.OperState = common.STATE_DOWN
+	for _, route := range svr.Routes {
+		if route.IntfRef == intf.Config.IntfRef && route.IpType == intf.Config.IpType {
+			svr.deleteRoute(route, now)
+		}
+	}
+	intf.Addrs = nil
+	intf.LinkLocal = nil
+	intf.State.IpAddr = ""
+}
+
+// Advertises the networks of the interface, and withdraws the ones that
+// were removed from it
+func (svr *RipServer) updateConnectedRoutes(intf *RipIntf, info *IntfInfo, now time.Time) {
+	addrs, linkLocal := intf.getAddrs(info)
+	current := make(map[string]bool)
+	for _, addr := range addrs {
+		prefix := connectedPrefix(addr)
+		current[prefix.String()] = true
+		svr.addConnectedRoute(intf, prefix, now)
+	}
+	for key, route := range svr.Routes {
+		if route.Type == common.ROUTE_TYPE_CONNECTED && route.IntfRef == intf.Config.IntfRef &&
+			route.IpType == intf.Config.IpType && !current[key] {
+			svr.deleteRoute(route, now)
+		}
+	}
+	intf.Addrs = addrs
+	intf.LinkLocal = linkLocal
+	intf.State.IpAddr = ""
+	if len(addrs) > 0 {
+		intf.State.IpAddr = addrs[0].String()
+	} else if linkLocal != nil {
+		intf.State.IpAddr = linkLocal.String()
+	}
+}
+
+// Follows the state and the addresses of the Linux interfaces the RIP
+// interfaces run on
+func (svr *RipServer) refreshIntfs(now time.Time) {
+	for _, intf := range svr.Intfs {
+		var info *IntfInfo
+		up := false
+		if svr.GlobalConfig.Enable && intf.Config.AdminState {
+			var err error
+			info, err = svr.transport.LookupIntf(intf.Config.IntfRef)
+			if err == nil && info.Up {
+				addrs, linkLocal := intf.getAddrs(info)
+				// RIPv2 needs an address to send from, RIPng a link
+				// local one
+				up = (intf.isV6() && linkLocal != nil) || (!intf.isV6() && len(addrs) > 0)
+			}
+		}
+		switch {
+		case up && !intf.IsUp():
+			svr.intfUp(intf, info, now)
+		case !up && intf.IsUp():
+			svr.intfDown(intf, now)
+		case up && intf.IsUp():
+			if info.IfIndex != intf.IfIndex {
+				// the Linux interface was created again
+				svr.intfDown(intf, now)
+				svr.intfUp(intf, info, now)
+			} else {
+				intf.Mtu = info.Mtu
+				svr.updateConnectedRoutes(intf, info, now)
+			}
+		}
+	}
+}
+
+func (svr *RipServer) getIntfByIfIndex(ifIndex int, ipType int) *RipIntf {
+	for _, intf := range svr.Intfs {
+		if intf.IsUp() && intf.IfIndex == ifIndex && intf.Config.IpType == ipType {
+			return intf
+		}
+	}
+	return nil
+}
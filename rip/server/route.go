@@ -0,0 +1,266 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"net"
+	"syscall"
+	"time"
+)
+
+type Route struct {
+	Prefix    *net.IPNet
+	IpType    int
+	Type      string // RIP, Connected or Redistribute
+	NextHop   net.IP // RIP routes only
+	IntfRef   string // RIP and connected routes
+	Metric    uint8
+	Tag       uint16
+	Timeout   time.Time // RIP routes only
+	Garbage   time.Time // set while the route is deleted, it is advertised with an infinite metric until then
+	Changed   bool      // to be sent in the next triggered update
+	Installed bool      // installed in ribd
+}
+
+func prefixIpType(prefix *net.IPNet) int {
+	if prefix.IP.To4() != nil {
+		return syscall.AF_INET
+	}
+	return syscall.AF_INET6
+}
+
+func (route *Route) isDeleted() bool {
+	return !route.Garbage.IsZero()
+}
+
+func (route *Route) routeInfo() *common.RouteInfo {
+	return &common.RouteInfo{
+		Prefix:  route.Prefix,
+		NextHop: route.NextHop,
+		IntfRef: route.IntfRef,
+		Metric:  int32(route.Metric),
+		Tag:     route.Tag,
+	}
+}
+
+func (svr *RipServer) timeoutInterval() time.Duration {
+	return time.Duration(svr.GlobalConfig.TimeoutInterval) * time.Second
+}
+
+func (svr *RipServer) garbageInterval() time.Duration {
+	return time.Duration(svr.GlobalConfig.GarbageInterval) * time.Second
+}
+
+func (svr *RipServer) installRoute(route *Route) {
+	if route.Type != common.ROUTE_TYPE_RIP || route.Installed {
+		return
+	}
+	route.Installed = true
+	if svr.routeMgr == nil {
+		debug.Logger.Info("RIP route:", route.Prefix, "next hop:", route.NextHop, "interface:", route.IntfRef, "metric:", route.Metric)
+		return
+	}
+	if err := svr.routeMgr.CreateRoute(route.routeInfo()); err != nil {
+		debug.Logger.Err("Failed to install RIP route:", route.Prefix, "next hop:", route.NextHop, "err:", err)
+	}
+}
+
+func (svr *RipServer) uninstallRoute(route *Route) {
+	if !route.Installed {
+		return
+	}
+	route.Installed = false
+	if svr.routeMgr == nil {
+		debug.Logger.Info("RIP route:", route.Prefix, "next hop:", route.NextHop, "removed")
+		return
+	}
+	if err := svr.routeMgr.DeleteRoute(route.routeInfo()); err != nil {
+		debug.Logger.Err("Failed to delete RIP route:", route.Prefix, "next hop:", route.NextHop, "err:", err)
+	}
+}
+
+// Marks the route changed for the next triggered update
+func (svr *RipServer) routeChanged(route *Route) {
+	route.Changed = true
+	svr.triggerPending = true
+	svr.globalState.RouteChanges++
+}
+
+// Starts the deletion of the route: it is advertised with an infinite
+// metric until the garbage collection timer expires
+func (svr *RipServer) deleteRoute(route *Route, now time.Time) {
+	if route.isDeleted() {
+		return
+	}
+	debug.Logger.Info("Deleting", route.Type, "route:", route.Prefix, "next hop:", route.NextHop)
+	svr.uninstallRoute(route)
+	route.Metric = packet.RIP_INFINITY
+	route.Garbage = now.Add(svr.garbageInterval())
+	svr.routeChanged(route)
+}
+
+func (svr *RipServer) addConnectedRoute(intf *RipIntf, prefix *net.IPNet, now time.Time) {
+	key := prefix.String()
+	route, exist := svr.Routes[key]
+	if exist && route.Type == common.ROUTE_TYPE_CONNECTED && !route.isDeleted() {
+		if route.Metric != intf.cost() {
+			route.Metric = intf.cost()
+			svr.routeChanged(route)
+		}
+		return
+	}
+	if exist {
+		// the connected route wins over the learned and redistributed ones
+		svr.uninstallRoute(route)
+	}
+	route = &Route{
+		Prefix:  prefix,
+		IpType:  intf.Config.IpType,
+		Type:    common.ROUTE_TYPE_CONNECTED,
+		IntfRef: intf.Config.IntfRef,
+		Metric:  intf.cost(),
+	}
+	svr.Routes[key] = route
+	svr.routeChanged(route)
+}
+
+func (svr *RipServer) HandleRedistributeRoute(redist *common.RedistributeRoute, now time.Time) {
+	prefix := &net.IPNet{IP: redist.Prefix.IP.Mask(redist.Prefix.Mask), Mask: redist.Prefix.Mask}
+	key := prefix.String()
+	route, exist := svr.Routes[key]
+	if !redist.Add {
+		if exist && route.Type == common.ROUTE_TYPE_REDISTRIBUTE {
+			svr.deleteRoute(route, now)
+		}
+		return
+	}
+	debug.Logger.Info("Redistributing route:", key, "tag:", redist.Tag, "into RIP")
+	if exist && route.Type == common.ROUTE_TYPE_CONNECTED && !route.isDeleted() {
+		return
+	}
+	if exist && route.Type == common.ROUTE_TYPE_REDISTRIBUTE && !route.isDeleted() &&
+		route.Tag == redist.Tag && route.Metric == uint8(svr.GlobalConfig.DefaultMetric) {
+		return
+	}
+	if exist {
+		svr.uninstallRoute(route)
+	}
+	route = &Route{
+		Prefix: prefix,
+		IpType: prefixIpType(prefix),
+		Type:   common.ROUTE_TYPE_REDISTRIBUTE,
+		Metric: uint8(svr.GlobalConfig.DefaultMetric),
+		Tag:    redist.Tag,
+	}
+	svr.Routes[key] = route
+	svr.routeChanged(route)
+}
+
+// Processes a route entry of a response as in RFC 2453 3.9.2. metric is the
+// metric of the entry with the cost of the interface added.
+func (svr *RipServer) updateRoute(intf *RipIntf, prefix *net.IPNet, nextHop net.IP, metric uint8, tag uint16, now time.Time) {
+	key := prefix.String()
+	route, exist := svr.Routes[key]
+	if exist && route.Type != common.ROUTE_TYPE_RIP && !route.isDeleted() {
+		// routes of this router are not replaced by learned ones
+		return
+	}
+	if !exist || route.Type != common.ROUTE_TYPE_RIP {
+		if metric >= packet.RIP_INFINITY {
+			return
+		}
+		if exist {
+			svr.uninstallRoute(route)
+		}
+		route = &Route{
+			Prefix:  prefix,
+			IpType:  intf.Config.IpType,
+			Type:    common.ROUTE_TYPE_RIP,
+			NextHop: nextHop,
+			IntfRef: intf.Config.IntfRef,
+			Metric:  metric,
+			Tag:     tag,
+			Timeout: now.Add(svr.timeoutInterval()),
+		}
+		svr.Routes[key] = route
+		svr.installRoute(route)
+		svr.routeChanged(route)
+		return
+	}
+	sameRouter := route.NextHop.Equal(nextHop) && route.IntfRef == intf.Config.IntfRef
+	if sameRouter && metric < packet.RIP_INFINITY {
+		route.Timeout = now.Add(svr.timeoutInterval())
+	}
+	switch {
+	case sameRouter && metric == route.Metric && route.Tag == tag:
+		return
+	case sameRouter && metric >= packet.RIP_INFINITY:
+		svr.deleteRoute(route, now)
+		return
+	case sameRouter:
+	case metric < route.Metric:
+	case metric == route.Metric && metric < packet.RIP_INFINITY && !route.isDeleted() &&
+		route.Timeout.Sub(now) < svr.timeoutInterval()/2:
+		// the current route is about to time out, switch to the equally
+		// good one (RFC 2453 3.9.2)
+	default:
+		return
+	}
+	svr.uninstallRoute(route)
+	route.NextHop = nextHop
+	route.IntfRef = intf.Config.IntfRef
+	route.Metric = metric
+	route.Tag = tag
+	route.Timeout = now.Add(svr.timeoutInterval())
+	route.Garbage = time.Time{}
+	svr.installRoute(route)
+	svr.routeChanged(route)
+}
+
+// Times out the RIP routes that were not refreshed and removes the deleted
+// routes whose garbage collection timer expired
+func (svr *RipServer) processRouteTimers(now time.Time) {
+	for key, route := range svr.Routes {
+		if route.isDeleted() {
+			if !now.Before(route.Garbage) {
+				debug.Logger.Info("Removing", route.Type, "route:", key)
+				delete(svr.Routes, key)
+			}
+			continue
+		}
+		if route.Type == common.ROUTE_TYPE_RIP && !now.Before(route.Timeout) {
+			debug.Logger.Info("RIP route:", key, "next hop:", route.NextHop, "timed out")
+			svr.deleteRoute(route, now)
+		}
+	}
+}
+
+func (svr *RipServer) flushRoutes() {
+	for key, route := range svr.Routes {
+		svr.uninstallRoute(route)
+		delete(svr.Routes, key)
+	}
+}
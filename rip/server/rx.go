@@ -0,0 +1,192 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"net"
+	"time"
+)
+
+type NeighborKey struct {
+	IntfRef string
+	Address string
+}
+
+type Neighbor struct {
+	State     common.NeighborState
+	LastHeard time.Time
+}
+
+func (svr *RipServer) getNeighbor(intf *RipIntf, src net.IP, version uint8, now time.Time) *Neighbor {
+	key := NeighborKey{intf.Config.IntfRef, src.String()}
+	nbr, exist := svr.Neighbors[key]
+	if !exist {
+		debug.Logger.Info("New RIP neighbor:", key.Address, "on interface:", key.IntfRef)
+		nbr = &Neighbor{}
+		nbr.State.IntfRef = key.IntfRef
+		nbr.State.Address = key.Address
+		svr.Neighbors[key] = nbr
+	}
+	nbr.State.Version = version
+	nbr.LastHeard = now
+	nbr.State.LastUpdate = now.String()
+	return nbr
+}
+
+// Whether the prefix can be a RIP destination
+func validPrefix(prefix *net.IPNet) bool {
+	ones, bits := prefix.Mask.Size()
+	if bits == 0 {
+		// the mask is not contiguous
+		return false
+	}
+	if !prefix.IP.Mask(prefix.Mask).Equal(prefix.IP) {
+		return false
+	}
+	ip := prefix.IP
+	if ip.IsMulticast() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// class E, and network 0 other than the default route
+		return ip4[0] < 240 && (ip4[0] != 0 || ones == 0)
+	}
+	return true
+}
+
+func (svr *RipServer) decodeRxPkt(intf *RipIntf, rxPkt *RxPkt) (*packet.Packet, error) {
+	if intf.isV6() {
+		return packet.DecodeNg(rxPkt.Data)
+	}
+	pkt, err := packet.DecodeV2(rxPkt.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err = packet.VerifyV2Auth(rxPkt.Data, pkt, intf.authInfo()); err != nil {
+		intf.State.AuthFailures++
+		return nil, err
+	}
+	return pkt, nil
+}
+
+func (svr *RipServer) HandleRxPkt(rxPkt *RxPkt, now time.Time) {
+	if !svr.GlobalConfig.Enable {
+		return
+	}
+	intf := svr.getIntfByIfIndex(rxPkt.IfIndex, rxPkt.IpType)
+	if intf == nil || intf.isOwnAddr(rxPkt.Src.IP) {
+		return
+	}
+	intf.State.RxPkts++
+	pkt, err := svr.decodeRxPkt(intf, rxPkt)
+	if err != nil {
+		debug.Logger.Debug("Dropping RIP packet from:", rxPkt.Src, "on interface:", intf.Config.IntfRef, "err:", err)
+		intf.State.RxBadPkts++
+		return
+	}
+	switch pkt.Command {
+	case packet.RIP_CMD_REQUEST:
+		svr.processRequest(intf, rxPkt.Src, pkt)
+	case packet.RIP_CMD_RESPONSE:
+		svr.processResponse(intf, rxPkt, pkt, now)
+	}
+}
+
+// Answers a request for the whole table with a regular update, and a request
+// for some routes with their metrics. Requests from another port than the RIP
+// one come from monitoring tools and get the routes without split horizon.
+func (svr *RipServer) processRequest(intf *RipIntf, src *net.UDPAddr, pkt *packet.Packet) {
+	fromRouter := src.Port == intf.port()
+	if fromRouter && intf.Config.Passive {
+		return
+	}
+	if pkt.IsWholeTableRequest() {
+		svr.sendEntries(intf, src, svr.buildEntries(intf, false, fromRouter))
+		return
+	}
+	entries := make([]packet.RouteEntry, 0, len(pkt.Entries))
+	for _, entry := range pkt.Entries {
+		entry.Metric = packet.RIP_INFINITY
+		if entry.Prefix != nil {
+			if route, exist := svr.Routes[entry.Prefix.String()]; exist && route.IpType == intf.Config.IpType {
+				entry.Metric = route.Metric
+			}
+		}
+		entries = append(entries, entry)
+	}
+	svr.sendEntries(intf, src, entries)
+}
+
+func (svr *RipServer) processResponse(intf *RipIntf, rxPkt *RxPkt, pkt *packet.Packet, now time.Time) {
+	src := rxPkt.Src
+	// responses come from the RIP port of a router on the link, RIPng ones
+	// from a link local address and were not forwarded
+	if src.Port != intf.port() || !intf.onLink(src.IP) || (intf.isV6() && rxPkt.HopLimit != RIPNG_HOP_LIMIT) {
+		debug.Logger.Debug("Dropping RIP response from:", src, "on interface:", intf.Config.IntfRef)
+		intf.State.RxBadPkts++
+		return
+	}
+	nbr := svr.getNeighbor(intf, src.IP, pkt.Version, now)
+	nbr.State.RxPkts++
+	if pkt.Auth != nil && pkt.Auth.Type == packet.RIP_AUTH_MD5 {
+		if pkt.Auth.SeqNum < nbr.State.SeqNum {
+			debug.Logger.Info("Dropping RIP response from:", src, "sequence number:", pkt.Auth.SeqNum, "went back from:", nbr.State.SeqNum)
+			nbr.State.BadPkts++
+			intf.State.AuthFailures++
+			return
+		}
+		nbr.State.SeqNum = pkt.Auth.SeqNum
+	}
+	for _, entry := range pkt.Entries {
+		if entry.Prefix == nil || (!intf.isV6() && entry.Afi != packet.RIP_AFI_INET) {
+			continue
+		}
+		if !validPrefix(entry.Prefix) || entry.Metric < 1 || entry.Metric > packet.RIP_INFINITY {
+			nbr.State.BadRoutes++
+			intf.State.RxBadRoutes++
+			continue
+		}
+		nextHop := src.IP
+		if entry.NextHop != nil && !entry.NextHop.IsUnspecified() && intf.onLink(entry.NextHop) && !intf.isOwnAddr(entry.NextHop) {
+			nextHop = entry.NextHop
+		}
+		metric := entry.Metric + intf.cost()
+		if metric > packet.RIP_INFINITY {
+			metric = packet.RIP_INFINITY
+		}
+		svr.updateRoute(intf, entry.Prefix, nextHop, metric, entry.Tag, now)
+	}
+}
+
+// Forgets the neighbors not heard from for a while
+func (svr *RipServer) processNeighborTimers(now time.Time) {
+	hold := time.Duration(RIP_NEIGHBOR_HOLD_FACTOR) * svr.timeoutInterval()
+	for key, nbr := range svr.Neighbors {
+		if now.Sub(nbr.LastHeard) > hold {
+			delete(svr.Neighbors, key)
+		}
+	}
+}
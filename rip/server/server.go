@@ -0,0 +1,158 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"l3/rip/debug"
+	"sync"
+	"time"
+)
+
+// Installs the RIP routes in the RIB
+type RouteMgr interface {
+	CreateRoute(route *common.RouteInfo) error
+	DeleteRoute(route *common.RouteInfo) error
+	RoutesEndOfRIB()
+}
+
+type IntfKey struct {
+	IntfRef string
+	IpType  int
+}
+
+type RipServer struct {
+	// All System Related Information
+	transport      Transport
+	routeMgr       RouteMgr
+	lock           sync.RWMutex
+	GlobalConfig   common.GlobalConfig
+	Intfs          map[IntfKey]*RipIntf
+	Neighbors      map[NeighborKey]*Neighbor // neighbors heard from
+	NeighborCfg    map[NeighborKey]*common.NeighborConfig
+	Routes         map[string]*Route // key is the prefix
+	nextUpdate     time.Time
+	triggerPending bool
+	triggerHold    time.Time
+	endOfRIBTime   time.Time
+	endOfRIBSent   bool
+	globalState    common.GlobalState
+	GblCfgCh       chan *common.GlobalConfig // Starting from here all Channels Used during Events
+	IntfCfgCh      chan *common.IntfConfig
+	NbrCfgCh       chan *common.NeighborConfig
+	RedistCh       chan *common.RedistributeRoute
+	RxPktCh        chan *RxPkt
+}
+
+func (svr *RipServer) EventListener() {
+	ticker := time.NewTicker(RIP_TIMER_TICK)
+	for {
+		select {
+		case gCfg, ok := <-svr.GblCfgCh:
+			if ok {
+				svr.lock.Lock()
+				svr.HandleGlobalConfig(gCfg, time.Now())
+				svr.lock.Unlock()
+			}
+		case cfg, ok := <-svr.IntfCfgCh:
+			if ok {
+				svr.lock.Lock()
+				svr.HandleIntfConfig(cfg, time.Now())
+				svr.lock.Unlock()
+			}
+		case cfg, ok := <-svr.NbrCfgCh:
+			if ok {
+				svr.lock.Lock()
+				svr.HandleNeighborConfig(cfg)
+				svr.lock.Unlock()
+			}
+		case route, ok := <-svr.RedistCh:
+			if ok {
+				svr.lock.Lock()
+				svr.HandleRedistributeRoute(route, time.Now())
+				svr.lock.Unlock()
+			}
+		case pkt, ok := <-svr.RxPktCh:
+			if ok {
+				svr.lock.Lock()
+				svr.HandleRxPkt(pkt, time.Now())
+				svr.lock.Unlock()
+			}
+		case now := <-ticker.C:
+			svr.lock.Lock()
+			svr.ProcessTimers(now)
+			svr.lock.Unlock()
+		}
+	}
+}
+
+func (svr *RipServer) InitGlobalDS() {
+	svr.GlobalConfig = common.GlobalConfig{
+		Vrf:             "default",
+		UpdateInterval:  common.DEFAULT_UPDATE_INTERVAL,
+		TimeoutInterval: common.DEFAULT_TIMEOUT_INTERVAL,
+		GarbageInterval: common.DEFAULT_GARBAGE_INTERVAL,
+		DefaultMetric:   common.DEFAULT_METRIC,
+	}
+	svr.globalState.Vrf = svr.GlobalConfig.Vrf
+	svr.Intfs = make(map[IntfKey]*RipIntf, RIP_GLOBAL_INFO_DEFAULT_SIZE)
+	svr.Neighbors = make(map[NeighborKey]*Neighbor, RIP_GLOBAL_INFO_DEFAULT_SIZE)
+	svr.NeighborCfg = make(map[NeighborKey]*common.NeighborConfig, RIP_GLOBAL_INFO_DEFAULT_SIZE)
+	svr.Routes = make(map[string]*Route, RIP_ROUTE_TABLE_DEFAULT_SIZE)
+	svr.GblCfgCh = make(chan *common.GlobalConfig)
+	svr.IntfCfgCh = make(chan *common.IntfConfig, RIP_CONFIG_CH_SIZE)
+	svr.NbrCfgCh = make(chan *common.NeighborConfig, RIP_CONFIG_CH_SIZE)
+	svr.RedistCh = make(chan *common.RedistributeRoute, RIP_REDIST_CH_SIZE)
+}
+
+func (svr *RipServer) RipStartServer() {
+	go svr.EventListener()
+}
+
+// rxCh is the channel the transport delivers the received packets on,
+// routeMgr is nil when the routes are not installed in a RIB
+func RipNewServer(transport Transport, rxCh chan *RxPkt, routeMgr RouteMgr) *RipServer {
+	ripServer := &RipServer{}
+	ripServer.transport = transport
+	ripServer.routeMgr = routeMgr
+	ripServer.InitGlobalDS()
+	ripServer.RxPktCh = rxCh
+	debug.Logger.Info("RIP server created")
+	return ripServer
+}
+
+const (
+	// Default Size
+	RIP_GLOBAL_INFO_DEFAULT_SIZE = 50
+	RIP_ROUTE_TABLE_DEFAULT_SIZE = 1000
+	RIP_CONFIG_CH_SIZE           = 10
+	RIP_REDIST_CH_SIZE           = 1000
+	RIP_RX_CH_SIZE               = 100
+
+	RIP_TIMER_TICK           = time.Second
+	RIP_UPDATE_JITTER        = 5 // seconds, a periodic update is sent up to that much before or after the interval
+	RIP_TRIGGERED_HOLD_MIN   = 1 // seconds between triggered updates
+	RIP_TRIGGERED_HOLD_RANGE = 5
+	RIP_END_OF_RIB_INTERVALS = 2 // update intervals after which the routes not learned again are flushed from ribd
+	RIP_NEIGHBOR_HOLD_FACTOR = 2 // timeout intervals after which a silent neighbor is forgotten
+)
@@ -0,0 +1,378 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"errors"
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"log/syslog"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+	"utils/logging"
+)
+
+// In memory network connecting the transports of the test servers
+type testNetwork struct {
+	links map[string][]*testTransport
+}
+
+type testTransport struct {
+	network *testNetwork
+	svr     *RipServer
+	intfs   map[string]*IntfInfo
+	linkOf  map[int]string
+	opened  map[IntfKey]bool
+	rxQueue []*RxPkt
+}
+
+type testRouteMgr struct {
+	routes   map[string]common.RouteInfo
+	endOfRIB bool
+}
+
+func (mgr *testRouteMgr) CreateRoute(route *common.RouteInfo) error {
+	mgr.routes[route.Prefix.String()] = *route
+	return nil
+}
+
+func (mgr *testRouteMgr) DeleteRoute(route *common.RouteInfo) error {
+	delete(mgr.routes, route.Prefix.String())
+	return nil
+}
+
+func (mgr *testRouteMgr) RoutesEndOfRIB() {
+	mgr.endOfRIB = true
+}
+
+func (tr *testTransport) LookupIntf(intfRef string) (*IntfInfo, error) {
+	info, exists := tr.intfs[intfRef]
+	if !exists {
+		return nil, errors.New("no such interface")
+	}
+	return info, nil
+}
+
+func (tr *testTransport) Open(ifIndex int, ipType int) error {
+	tr.opened[IntfKey{tr.linkOf[ifIndex], ipType}] = true
+	return nil
+}
+
+func (tr *testTransport) Close(ifIndex int, ipType int) error {
+	delete(tr.opened, IntfKey{tr.linkOf[ifIndex], ipType})
+	return nil
+}
+
+func (tr *testTransport) srcAddr(ifIndex int, ipType int) net.IP {
+	for _, info := range tr.intfs {
+		if info.IfIndex != ifIndex {
+			continue
+		}
+		for _, addr := range info.Addrs {
+			isV6 := addr.IP.To4() == nil
+			if (ipType == syscall.AF_INET6) != isV6 {
+				continue
+			}
+			if !isV6 || addr.IP.IsLinkLocalUnicast() {
+				return addr.IP
+			}
+		}
+	}
+	return nil
+}
+
+func (tr *testTransport) ownsAddr(link string, ip net.IP) bool {
+	for _, info := range tr.intfs {
+		if tr.linkOf[info.IfIndex] != link {
+			continue
+		}
+		for _, addr := range info.Addrs {
+			if addr.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (tr *testTransport) Send(ifIndex int, ipType int, dst *net.UDPAddr, data []byte) error {
+	link := tr.linkOf[ifIndex]
+	port := packet.RIP_PORT
+	if ipType == syscall.AF_INET6 {
+		port = packet.RIPNG_PORT
+	}
+	src := &net.UDPAddr{IP: tr.srcAddr(ifIndex, ipType), Port: port}
+	for _, peer := range tr.network.links[link] {
+		if peer == tr || !peer.opened[IntfKey{link, ipType}] {
+			continue
+		}
+		if !dst.IP.IsMulticast() && !peer.ownsAddr(link, dst.IP) {
+			continue
+		}
+		var peerIfIndex int
+		for idx, peerLink := range peer.linkOf {
+			if peerLink == link {
+				peerIfIndex = idx
+			}
+		}
+		peer.rxQueue = append(peer.rxQueue, &RxPkt{
+			IfIndex:  peerIfIndex,
+			IpType:   ipType,
+			Src:      src,
+			HopLimit: RIPNG_HOP_LIMIT,
+			Data:     append([]byte(nil), data...),
+		})
+	}
+	return nil
+}
+
+// Interfaces are named after the link they are attached to
+func (network *testNetwork) addIntf(tr *testTransport, link string, addrs ...string) {
+	info := &IntfInfo{
+		IfIndex: len(tr.intfs) + 1,
+		Mtu:     1500,
+		Up:      true,
+	}
+	for _, addr := range addrs {
+		ip, ipNet, _ := net.ParseCIDR(addr)
+		ipNet.IP = ip
+		info.Addrs = append(info.Addrs, ipNet)
+	}
+	tr.intfs[link] = info
+	tr.linkOf[info.IfIndex] = link
+	network.links[link] = append(network.links[link], tr)
+}
+
+func (network *testNetwork) newNode() (*testTransport, *testRouteMgr) {
+	tr := &testTransport{
+		network: network,
+		intfs:   make(map[string]*IntfInfo),
+		linkOf:  make(map[int]string),
+		opened:  make(map[IntfKey]bool),
+	}
+	mgr := &testRouteMgr{routes: make(map[string]common.RouteInfo)}
+	tr.svr = RipNewServer(tr, make(chan *RxPkt), mgr)
+	return tr, mgr
+}
+
+// Runs the timers of all the servers once a second and delivers the packets
+// they send until then
+func (network *testNetwork) run(nodes []*testTransport, start time.Time, seconds int) time.Time {
+	now := start
+	for i := 0; i < seconds; i++ {
+		now = now.Add(time.Second)
+		for _, tr := range nodes {
+			tr.svr.ProcessTimers(now)
+		}
+		for pending := true; pending; {
+			pending = false
+			for _, tr := range nodes {
+				queue := tr.rxQueue
+				tr.rxQueue = nil
+				for _, pkt := range queue {
+					pending = true
+					tr.svr.HandleRxPkt(pkt, now)
+				}
+			}
+		}
+	}
+	return now
+}
+
+func initTestLogger(t *testing.T) {
+	if debug.Logger != nil {
+		return
+	}
+	var err error
+	logger := new(logging.Writer)
+	logger.MyComponentName = "RIPD"
+	logger.SysLogger, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "RIPTEST")
+	if err != nil {
+		t.Fatal("failed to initialize syslog:", err)
+	}
+	logger.MyLogLevel = 9 // trace level
+	debug.SetLogger(logger)
+}
+
+func enableRip(tr *testTransport, ipType int, now time.Time, intfCfg common.IntfConfig) {
+	for intfRef, _ := range tr.intfs {
+		cfg := intfCfg
+		cfg.IntfRef = intfRef
+		cfg.IpType = ipType
+		cfg.AdminState = true
+		cfg.Operation = common.CREATE
+		if ok, err := tr.svr.ValidConfiguration(&cfg); !ok {
+			panic(err)
+		}
+		tr.svr.HandleIntfConfig(&cfg, now)
+	}
+	gCfg := common.GlobalConfig{Vrf: "default", Enable: true, Operation: common.CREATE}
+	tr.svr.ValidGlobalConfiguration(&gCfg)
+	tr.svr.HandleGlobalConfig(&gCfg, now)
+}
+
+// a -- link ab -- b -- link bc -- c, with a stub network on a
+func newV4Topology(t *testing.T, intfCfgA, intfCfgB common.IntfConfig) (*testNetwork, []*testTransport, []*testRouteMgr, time.Time) {
+	initTestLogger(t)
+	network := &testNetwork{links: make(map[string][]*testTransport)}
+	a, mgrA := network.newNode()
+	b, mgrB := network.newNode()
+	c, mgrC := network.newNode()
+	network.addIntf(a, "stub", "10.0.1.1/24")
+	network.addIntf(a, "ab", "10.1.1.1/24")
+	network.addIntf(b, "ab", "10.1.1.2/24")
+	network.addIntf(b, "bc", "10.1.2.2/24")
+	network.addIntf(c, "bc", "10.1.2.3/24")
+	now := time.Now()
+	enableRip(a, syscall.AF_INET, now, intfCfgA)
+	enableRip(b, syscall.AF_INET, now, intfCfgB)
+	enableRip(c, syscall.AF_INET, now, intfCfgB)
+	return network, []*testTransport{a, b, c}, []*testRouteMgr{mgrA, mgrB, mgrC}, now
+}
+
+func checkRoute(t *testing.T, mgr *testRouteMgr, prefix, nextHop string, metric int32) {
+	route, exists := mgr.routes[prefix]
+	if !exists {
+		t.Error("route:", prefix, "not installed")
+		return
+	}
+	if route.NextHop.String() != nextHop || route.Metric != metric {
+		t.Error("route:", prefix, "installed with next hop:", route.NextHop, "metric:", route.Metric,
+			"instead of:", nextHop, metric)
+	}
+}
+
+func TestRouteLearning(t *testing.T) {
+	network, nodes, mgrs, now := newV4Topology(t, common.IntfConfig{}, common.IntfConfig{})
+	now = network.run(nodes, now, 5)
+	checkRoute(t, mgrs[1], "10.0.1.0/24", "10.1.1.1", 2)
+	checkRoute(t, mgrs[2], "10.0.1.0/24", "10.1.2.2", 3)
+	checkRoute(t, mgrs[2], "10.1.1.0/24", "10.1.2.2", 2)
+	checkRoute(t, mgrs[0], "10.1.2.0/24", "10.1.1.2", 2)
+	if _, exists := mgrs[0].routes["10.0.1.0/24"]; exists {
+		t.Error("connected route installed as a rip route")
+	}
+	// the periodic updates keep the routes from timing out
+	now = network.run(nodes, now, 4*common.DEFAULT_TIMEOUT_INTERVAL)
+	checkRoute(t, mgrs[2], "10.0.1.0/24", "10.1.2.2", 3)
+	if !mgrs[2].endOfRIB {
+		t.Error("end of rib was not sent")
+	}
+	_, count, routes := nodes[2].svr.GetRouteStates(syscall.AF_INET, 0, 10)
+	if count != 3 || len(routes) != 3 {
+		t.Error("unexpected route states:", routes)
+	}
+}
+
+func TestSplitHorizon(t *testing.T) {
+	network, nodes, _, now := newV4Topology(t, common.IntfConfig{}, common.IntfConfig{})
+	network.run(nodes, now, 5)
+	b := nodes[1].svr
+	intf := b.Intfs[IntfKey{"ab", syscall.AF_INET}]
+	find := func(entries []packet.RouteEntry, prefix string) *packet.RouteEntry {
+		for idx, _ := range entries {
+			if entries[idx].Prefix.String() == prefix {
+				return &entries[idx]
+			}
+		}
+		return nil
+	}
+	entry := find(b.buildEntries(intf, false, true), "10.0.1.0/24")
+	if entry == nil || entry.Metric != packet.RIP_INFINITY {
+		t.Error("route learned on the interface was not poisoned:", entry)
+	}
+	intf.Config.SplitHorizon = common.SPLIT_HORIZON_SIMPLE
+	if entry = find(b.buildEntries(intf, false, true), "10.0.1.0/24"); entry != nil {
+		t.Error("route learned on the interface was advertised back with simple split horizon")
+	}
+	intf.Config.SplitHorizon = common.SPLIT_HORIZON_DISABLE
+	entry = find(b.buildEntries(intf, false, true), "10.0.1.0/24")
+	if entry == nil || entry.Metric != 2 {
+		t.Error("route learned on the interface not advertised without split horizon:", entry)
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	network, nodes, mgrs, now := newV4Topology(t, common.IntfConfig{}, common.IntfConfig{})
+	now = network.run(nodes, now, 5)
+	// a goes silent without b seeing its link go down
+	nodes[0].svr.HandleGlobalConfig(&common.GlobalConfig{Vrf: "default"}, now)
+	now = network.run(nodes, now, common.DEFAULT_TIMEOUT_INTERVAL+common.DEFAULT_UPDATE_INTERVAL)
+	if _, exists := mgrs[1].routes["10.0.1.0/24"]; exists {
+		t.Error("route did not time out on b")
+	}
+	if _, exists := mgrs[2].routes["10.0.1.0/24"]; exists {
+		t.Error("route withdrawal did not reach c")
+	}
+	if state := nodes[1].svr.GetRouteState("10.0.1.0/24"); state == nil || state.State != common.ROUTE_STATE_DELETING {
+		t.Error("timed out route is not being deleted:", state)
+	}
+	network.run(nodes, now, common.DEFAULT_GARBAGE_INTERVAL)
+	if state := nodes[1].svr.GetRouteState("10.0.1.0/24"); state != nil {
+		t.Error("timed out route was not garbage collected:", state)
+	}
+}
+
+func TestMD5Auth(t *testing.T) {
+	auth := common.IntfConfig{AuthType: common.AUTH_TYPE_MD5, AuthKey: "secret", AuthKeyId: 1}
+	network, nodes, mgrs, now := newV4Topology(t, auth, auth)
+	network.run(nodes, now, 5)
+	checkRoute(t, mgrs[2], "10.0.1.0/24", "10.1.2.2", 3)
+
+	wrongKey := auth
+	wrongKey.AuthKey = "other"
+	network, nodes, mgrs, now = newV4Topology(t, auth, wrongKey)
+	network.run(nodes, now, 5)
+	if _, exists := mgrs[1].routes["10.0.1.0/24"]; exists {
+		t.Error("route learned with the wrong key")
+	}
+	if nodes[1].svr.GetIntfState("ab", syscall.AF_INET).AuthFailures == 0 {
+		t.Error("authentication failures not counted")
+	}
+}
+
+func TestRipng(t *testing.T) {
+	initTestLogger(t)
+	network := &testNetwork{links: make(map[string][]*testTransport)}
+	a, _ := network.newNode()
+	b, mgrB := network.newNode()
+	network.addIntf(a, "stub", "2001:db8:1::1/64", "fe80::a1/64")
+	network.addIntf(a, "ab", "2001:db8:12::1/64", "fe80::a2/64")
+	network.addIntf(b, "ab", "2001:db8:12::2/64", "fe80::b2/64")
+	now := time.Now()
+	enableRip(a, syscall.AF_INET6, now, common.IntfConfig{})
+	enableRip(b, syscall.AF_INET6, now, common.IntfConfig{})
+	network.run([]*testTransport{a, b}, now, 5)
+	checkRoute(t, mgrB, "2001:db8:1::/64", "fe80::a2", 2)
+	if _, exists := mgrB.routes["2001:db8:12::/64"]; exists {
+		t.Error("connected route replaced by a learned one")
+	}
+	cfg := common.IntfConfig{IntfRef: "ab", IpType: syscall.AF_INET6, AuthType: common.AUTH_TYPE_MD5,
+		AuthKey: "secret", Operation: common.UPDATE}
+	if ok, _ := b.svr.ValidConfiguration(&cfg); ok {
+		t.Error("authentication accepted on a ripng interface")
+	}
+}
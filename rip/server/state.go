@@ -0,0 +1,178 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"sort"
+	"syscall"
+	"time"
+)
+
+func (svr *RipServer) GetGlobalState(vrf string) *common.GlobalState {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	if svr.globalState.Vrf != vrf {
+		return new(common.GlobalState)
+	}
+	state := svr.globalState
+	for _, route := range svr.Routes {
+		if route.isDeleted() {
+			continue
+		}
+		if route.IpType == syscall.AF_INET {
+			state.V4Routes++
+		} else {
+			state.V6Routes++
+		}
+	}
+	return &state
+}
+
+// Returns the count entries from idx, and the index to continue from or 0
+// when there are no more
+func getPage(length, idx, cnt int) (start, end, nextIdx int) {
+	if idx >= length {
+		return length, length, 0
+	}
+	end = idx + cnt
+	if end >= length {
+		return idx, length, 0
+	}
+	return idx, end, end
+}
+
+// Interfaces of the ipType family, sorted by name
+func (svr *RipServer) GetIntfStates(ipType int, idx, cnt int) (int, int, []common.IntfState) {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	names := make([]string, 0, len(svr.Intfs))
+	for key, _ := range svr.Intfs {
+		if key.IpType == ipType {
+			names = append(names, key.IntfRef)
+		}
+	}
+	sort.Strings(names)
+	start, end, nextIdx := getPage(len(names), idx, cnt)
+	var result []common.IntfState
+	for _, name := range names[start:end] {
+		result = append(result, svr.Intfs[IntfKey{name, ipType}].State)
+	}
+	return nextIdx, len(result), result
+}
+
+func (svr *RipServer) GetIntfState(intfRef string, ipType int) *common.IntfState {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	intf, exists := svr.Intfs[IntfKey{intfRef, ipType}]
+	if !exists {
+		return nil
+	}
+	state := intf.State
+	return &state
+}
+
+func (svr *RipServer) GetNeighborStates(idx, cnt int) (int, int, []common.NeighborState) {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	keys := make([]NeighborKey, 0, len(svr.Neighbors))
+	for key, _ := range svr.Neighbors {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].IntfRef != keys[j].IntfRef {
+			return keys[i].IntfRef < keys[j].IntfRef
+		}
+		return keys[i].Address < keys[j].Address
+	})
+	start, end, nextIdx := getPage(len(keys), idx, cnt)
+	var result []common.NeighborState
+	for _, key := range keys[start:end] {
+		result = append(result, svr.Neighbors[key].State)
+	}
+	return nextIdx, len(result), result
+}
+
+func (svr *RipServer) GetNeighborState(intfRef, address string) *common.NeighborState {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	nbr, exists := svr.Neighbors[NeighborKey{intfRef, address}]
+	if !exists {
+		return nil
+	}
+	state := nbr.State
+	return &state
+}
+
+func (svr *RipServer) populateRouteState(route *Route, now time.Time) common.RouteState {
+	state := common.RouteState{
+		Prefix:    route.Prefix.String(),
+		IntfRef:   route.IntfRef,
+		Metric:    int32(route.Metric),
+		Tag:       int32(route.Tag),
+		RouteType: route.Type,
+		State:     common.ROUTE_STATE_VALID,
+	}
+	if route.NextHop != nil {
+		state.NextHop = route.NextHop.String()
+	}
+	switch {
+	case route.isDeleted():
+		state.State = common.ROUTE_STATE_DELETING
+		state.Expires = int32(route.Garbage.Sub(now) / time.Second)
+	case route.Type == common.ROUTE_TYPE_RIP:
+		state.Expires = int32(route.Timeout.Sub(now) / time.Second)
+	}
+	return state
+}
+
+// Routes of the ipType family, sorted by prefix
+func (svr *RipServer) GetRouteStates(ipType int, idx, cnt int) (int, int, []common.RouteState) {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	keys := make([]string, 0, len(svr.Routes))
+	for key, route := range svr.Routes {
+		if route.IpType == ipType {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	start, end, nextIdx := getPage(len(keys), idx, cnt)
+	now := time.Now()
+	var result []common.RouteState
+	for _, key := range keys[start:end] {
+		result = append(result, svr.populateRouteState(svr.Routes[key], now))
+	}
+	return nextIdx, len(result), result
+}
+
+func (svr *RipServer) GetRouteState(prefix string) *common.RouteState {
+	svr.lock.RLock()
+	defer svr.lock.RUnlock()
+	route, exists := svr.Routes[prefix]
+	if !exists {
+		return nil
+	}
+	state := svr.populateRouteState(route, time.Now())
+	return &state
+}
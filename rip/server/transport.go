@@ -0,0 +1,262 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+const (
+	RIP_RX_BUF_SIZE = 65535
+	RIPNG_HOP_LIMIT = 255
+)
+
+// Linux interface a RIP interface runs on
+type IntfInfo struct {
+	IfIndex int
+	Mtu     int
+	Up      bool
+	Addrs   []*net.IPNet
+}
+
+// Packet received on the RIP port of an interface
+type RxPkt struct {
+	IfIndex  int
+	IpType   int
+	Src      *net.UDPAddr
+	HopLimit int
+	Data     []byte
+}
+
+// Sends and receives the RIP packets. The server gets the received packets
+// on the channel given to the transport.
+type Transport interface {
+	LookupIntf(intfRef string) (*IntfInfo, error)
+	Open(ifIndex int, ipType int) error
+	Close(ifIndex int, ipType int) error
+	Send(ifIndex int, ipType int, dst *net.UDPAddr, data []byte) error
+}
+
+// RIPv2 and RIPng over udp sockets of the Linux interfaces
+type UdpTransport struct {
+	lock   sync.Mutex
+	rxCh   chan *RxPkt
+	v4Conn *ipv4.PacketConn
+	v6Conn *ipv6.PacketConn
+}
+
+func NewUdpTransport(rxCh chan *RxPkt) *UdpTransport {
+	return &UdpTransport{
+		rxCh: rxCh,
+	}
+}
+
+func (t *UdpTransport) LookupIntf(intfRef string) (*IntfInfo, error) {
+	intf, err := net.InterfaceByName(intfRef)
+	if err != nil {
+		return nil, err
+	}
+	info := &IntfInfo{
+		IfIndex: intf.Index,
+		Mtu:     intf.MTU,
+		Up:      intf.Flags&net.FlagUp != 0,
+	}
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			info.Addrs = append(info.Addrs, ipNet)
+		}
+	}
+	return info, nil
+}
+
+func (t *UdpTransport) openV4() error {
+	if t.v4Conn != nil {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp4", "0.0.0.0:"+strconv.Itoa(packet.RIP_PORT))
+	if err != nil {
+		return err
+	}
+	pktConn := ipv4.NewPacketConn(conn)
+	if err = pktConn.SetControlMessage(ipv4.FlagInterface|ipv4.FlagDst, true); err != nil {
+		conn.Close()
+		return err
+	}
+	pktConn.SetMulticastLoopback(false)
+	pktConn.SetMulticastTTL(1)
+	t.v4Conn = pktConn
+	go t.receiveV4(pktConn)
+	return nil
+}
+
+func (t *UdpTransport) openV6() error {
+	if t.v6Conn != nil {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp6", "[::]:"+strconv.Itoa(packet.RIPNG_PORT))
+	if err != nil {
+		return err
+	}
+	pktConn := ipv6.NewPacketConn(conn)
+	if err = pktConn.SetControlMessage(ipv6.FlagInterface|ipv6.FlagDst|ipv6.FlagHopLimit, true); err != nil {
+		conn.Close()
+		return err
+	}
+	pktConn.SetMulticastLoopback(false)
+	pktConn.SetMulticastHopLimit(RIPNG_HOP_LIMIT)
+	pktConn.SetHopLimit(RIPNG_HOP_LIMIT)
+	t.v6Conn = pktConn
+	go t.receiveV6(pktConn)
+	return nil
+}
+
+func (t *UdpTransport) receiveV4(conn *ipv4.PacketConn) {
+	buf := make([]byte, RIP_RX_BUF_SIZE)
+	for {
+		n, cm, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			debug.Logger.Err("Failed to read RIPv2 packet, err:", err)
+			return
+		}
+		if cm == nil {
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		t.rxCh <- &RxPkt{
+			IfIndex: cm.IfIndex,
+			IpType:  syscall.AF_INET,
+			Src:     src.(*net.UDPAddr),
+			Data:    data,
+		}
+	}
+}
+
+func (t *UdpTransport) receiveV6(conn *ipv6.PacketConn) {
+	buf := make([]byte, RIP_RX_BUF_SIZE)
+	for {
+		n, cm, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			debug.Logger.Err("Failed to read RIPng packet, err:", err)
+			return
+		}
+		if cm == nil {
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		t.rxCh <- &RxPkt{
+			IfIndex:  cm.IfIndex,
+			IpType:   syscall.AF_INET6,
+			Src:      src.(*net.UDPAddr),
+			HopLimit: cm.HopLimit,
+			Data:     data,
+		}
+	}
+}
+
+// Opens the socket of the address family and joins the RIP group on the
+// interface
+func (t *UdpTransport) Open(ifIndex int, ipType int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	intf, err := net.InterfaceByIndex(ifIndex)
+	if err != nil {
+		return err
+	}
+	switch ipType {
+	case syscall.AF_INET:
+		if err = t.openV4(); err != nil {
+			return err
+		}
+		return t.v4Conn.JoinGroup(intf, &net.UDPAddr{IP: net.ParseIP(packet.RIP_V4_GROUP_IP)})
+	case syscall.AF_INET6:
+		if err = t.openV6(); err != nil {
+			return err
+		}
+		return t.v6Conn.JoinGroup(intf, &net.UDPAddr{IP: net.ParseIP(packet.RIPNG_GROUP_IP)})
+	}
+	return errors.New(fmt.Sprintln("Invalid ip type", ipType))
+}
+
+func (t *UdpTransport) Close(ifIndex int, ipType int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	intf, err := net.InterfaceByIndex(ifIndex)
+	if err != nil {
+		return err
+	}
+	switch ipType {
+	case syscall.AF_INET:
+		if t.v4Conn != nil {
+			return t.v4Conn.LeaveGroup(intf, &net.UDPAddr{IP: net.ParseIP(packet.RIP_V4_GROUP_IP)})
+		}
+	case syscall.AF_INET6:
+		if t.v6Conn != nil {
+			return t.v6Conn.LeaveGroup(intf, &net.UDPAddr{IP: net.ParseIP(packet.RIPNG_GROUP_IP)})
+		}
+	}
+	return nil
+}
+
+func (t *UdpTransport) Send(ifIndex int, ipType int, dst *net.UDPAddr, data []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch ipType {
+	case syscall.AF_INET:
+		if t.v4Conn == nil {
+			return errors.New("RIPv2 socket is not open")
+		}
+		if dst.IP.IsMulticast() {
+			intf, err := net.InterfaceByIndex(ifIndex)
+			if err != nil {
+				return err
+			}
+			if err = t.v4Conn.SetMulticastInterface(intf); err != nil {
+				return err
+			}
+		}
+		_, err := t.v4Conn.WriteTo(data, &ipv4.ControlMessage{IfIndex: ifIndex}, dst)
+		return err
+	case syscall.AF_INET6:
+		if t.v6Conn == nil {
+			return errors.New("RIPng socket is not open")
+		}
+		_, err := t.v6Conn.WriteTo(data, &ipv6.ControlMessage{IfIndex: ifIndex, HopLimit: RIPNG_HOP_LIMIT}, dst)
+		return err
+	}
+	return errors.New(fmt.Sprintln("Invalid ip type", ipType))
+}
@@ -0,0 +1,235 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"l3/rip/common"
+	"l3/rip/debug"
+	"l3/rip/packet"
+	"math/rand"
+	"net"
+	"sort"
+	"time"
+)
+
+func (intf *RipIntf) authInfo() *packet.AuthInfo {
+	switch intf.Config.AuthType {
+	case common.AUTH_TYPE_SIMPLE_PASSWORD:
+		return &packet.AuthInfo{
+			Type: packet.RIP_AUTH_SIMPLE,
+			Key:  intf.Config.AuthKey,
+		}
+	case common.AUTH_TYPE_MD5:
+		return &packet.AuthInfo{
+			Type:  packet.RIP_AUTH_MD5,
+			Key:   intf.Config.AuthKey,
+			KeyId: uint8(intf.Config.AuthKeyId),
+		}
+	}
+	return nil
+}
+
+// Route entries advertised on the interface. With split horizon the routes
+// learned on the interface are left out, or advertised with an infinite
+// metric with poisoned reverse.
+func (svr *RipServer) buildEntries(intf *RipIntf, changedOnly bool, splitHorizon bool) []packet.RouteEntry {
+	keys := make([]string, 0, len(svr.Routes))
+	for key, route := range svr.Routes {
+		if route.IpType == intf.Config.IpType && (route.Changed || !changedOnly) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	entries := make([]packet.RouteEntry, 0, len(keys))
+	for _, key := range keys {
+		route := svr.Routes[key]
+		metric := route.Metric
+		if splitHorizon && route.Type == common.ROUTE_TYPE_RIP && route.IntfRef == intf.Config.IntfRef {
+			switch intf.Config.SplitHorizon {
+			case common.SPLIT_HORIZON_SIMPLE:
+				continue
+			case common.SPLIT_HORIZON_POISONED_REVERSE:
+				metric = packet.RIP_INFINITY
+			}
+		}
+		entry := packet.RouteEntry{
+			Prefix: route.Prefix,
+			Metric: metric,
+			Tag:    route.Tag,
+		}
+		if !intf.isV6() {
+			entry.Afi = packet.RIP_AFI_INET
+			entry.NextHop = net.IPv4zero
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (svr *RipServer) sendPkt(intf *RipIntf, dst *net.UDPAddr, command uint8, entries []packet.RouteEntry) {
+	var data []byte
+	if intf.isV6() {
+		data = packet.EncodeNg(command, entries)
+	} else {
+		auth := intf.authInfo()
+		if auth != nil && auth.Type == packet.RIP_AUTH_MD5 {
+			intf.SeqNum++
+			auth.SeqNum = intf.SeqNum
+		}
+		data = packet.EncodeV2(command, entries, auth)
+	}
+	if err := svr.transport.Send(intf.IfIndex, intf.Config.IpType, dst, data); err != nil {
+		debug.Logger.Err("Failed to send RIP packet to:", dst, "on interface:", intf.Config.IntfRef, "err:", err)
+		return
+	}
+	intf.State.TxPkts++
+}
+
+// Sends the entries in as many responses as needed
+func (svr *RipServer) sendEntries(intf *RipIntf, dst *net.UDPAddr, entries []packet.RouteEntry) {
+	maxEntries := packet.MaxNgEntries(intf.Mtu)
+	if !intf.isV6() {
+		auth := intf.authInfo()
+		if auth == nil {
+			maxEntries = packet.MaxV2Entries(packet.RIP_AUTH_NONE)
+		} else {
+			maxEntries = packet.MaxV2Entries(auth.Type)
+		}
+	}
+	for len(entries) > 0 {
+		count := len(entries)
+		if count > maxEntries {
+			count = maxEntries
+		}
+		svr.sendPkt(intf, dst, packet.RIP_CMD_RESPONSE, entries[:count])
+		entries = entries[count:]
+	}
+}
+
+func (svr *RipServer) sendWholeTableRequest(intf *RipIntf) {
+	if intf.Config.Passive {
+		return
+	}
+	entry := packet.RouteEntry{
+		Afi:    packet.RIP_AFI_UNSPEC,
+		Metric: packet.RIP_INFINITY,
+	}
+	if intf.isV6() {
+		entry.Prefix = &net.IPNet{IP: net.IPv6unspecified, Mask: net.CIDRMask(0, 8*net.IPv6len)}
+	}
+	svr.sendPkt(intf, intf.groupAddr(), packet.RIP_CMD_REQUEST, []packet.RouteEntry{entry})
+}
+
+// Sends the routes, or the changed ones only, to the RIP group of the
+// interface and to its configured neighbors
+func (svr *RipServer) sendUpdate(intf *RipIntf, changedOnly bool) {
+	entries := svr.buildEntries(intf, changedOnly, true)
+	if len(entries) == 0 {
+		return
+	}
+	if !intf.Config.Passive {
+		svr.sendEntries(intf, intf.groupAddr(), entries)
+	}
+	for key, _ := range svr.NeighborCfg {
+		if key.IntfRef != intf.Config.IntfRef {
+			continue
+		}
+		ip := net.ParseIP(key.Address)
+		if ip == nil || (ip.To4() == nil) != intf.isV6() {
+			continue
+		}
+		svr.sendEntries(intf, &net.UDPAddr{IP: ip, Port: intf.port()}, entries)
+	}
+}
+
+func jitter(seconds int) time.Duration {
+	return time.Duration(rand.Intn(2*seconds+1)-seconds) * time.Second
+}
+
+func (svr *RipServer) scheduleUpdate(now time.Time) {
+	interval := time.Duration(svr.GlobalConfig.UpdateInterval) * time.Second
+	next := interval + jitter(RIP_UPDATE_JITTER)
+	if next < time.Second {
+		next = time.Second
+	}
+	svr.nextUpdate = now.Add(next)
+}
+
+func (svr *RipServer) clearChanged() {
+	for _, route := range svr.Routes {
+		route.Changed = false
+	}
+	svr.triggerPending = false
+}
+
+// Sends the periodic updates when the update timer expires, and a triggered
+// update of the changed routes at most once in 1 to 5 seconds (RFC 2453
+// 3.10.1)
+func (svr *RipServer) processUpdateTimers(now time.Time) {
+	if !now.Before(svr.nextUpdate) {
+		for _, intf := range svr.Intfs {
+			if intf.IsUp() {
+				svr.sendUpdate(intf, false)
+			}
+		}
+		svr.globalState.PeriodicUpdates++
+		svr.clearChanged()
+		svr.scheduleUpdate(now)
+		return
+	}
+	if svr.triggerPending && !now.Before(svr.triggerHold) {
+		for _, intf := range svr.Intfs {
+			if intf.IsUp() {
+				svr.sendUpdate(intf, true)
+			}
+		}
+		svr.globalState.TriggeredUpdates++
+		svr.clearChanged()
+		hold := RIP_TRIGGERED_HOLD_MIN + rand.Intn(RIP_TRIGGERED_HOLD_RANGE)
+		svr.triggerHold = now.Add(time.Duration(hold) * time.Second)
+	}
+}
+
+// Lets ribd flush the stale RIP routes of a previous run once the neighbors
+// had the time to advertise their routes again
+func (svr *RipServer) processEndOfRIB(now time.Time) {
+	if svr.endOfRIBSent || now.Before(svr.endOfRIBTime) {
+		return
+	}
+	svr.endOfRIBSent = true
+	if svr.routeMgr != nil {
+		debug.Logger.Info("Sending end of RIB for RIP routes")
+		svr.routeMgr.RoutesEndOfRIB()
+	}
+}
+
+func (svr *RipServer) ProcessTimers(now time.Time) {
+	if !svr.GlobalConfig.Enable {
+		return
+	}
+	svr.refreshIntfs(now)
+	svr.processRouteTimers(now)
+	svr.processNeighborTimers(now)
+	svr.processUpdateTimers(now)
+	svr.processEndOfRIB(now)
+}
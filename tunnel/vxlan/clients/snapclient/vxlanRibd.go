@@ -43,7 +43,7 @@ func (intf VXLANSnapClient) createRIBdSubscriber() error {
 		return err
 	}
 
-	if err = intf.ribdSubSocket.Subscribe(""); err != nil {
+	if err = intf.ribdSubSocket.Subscribe(ribdCommonDefs.VrfNotifyMsgFilter(ribdCommonDefs.DefaultVrf)); err != nil {
 		logger.Err(fmt.Sprintln("Failed to subscribe to \"\" on RIBd subscribe socket, error:", err))
 		return err
 	}